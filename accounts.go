@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"maps"
 	"slices"
+	"strings"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
@@ -15,14 +17,29 @@ type Account struct {
 	Type         string
 	LiquidAmount sdk.Dec
 	StakedAmount sdk.Dec
+	// LockedAmount is the part of the balance that is still vesting at the
+	// snapshot, moved out of LiquidAmount by applyVestingLocks. It's nil if
+	// the account has no locked amount.
+	LockedAmount *sdk.Dec `json:",omitempty"`
 	Vote         govtypes.WeightedVoteOptions
 	Delegations  []Delegation
+	// Sources are the amounts of each chain of an account merged from the
+	// snapshots of several chains (see mergeChainAccounts).
+	Sources []SourceAmount `json:",omitempty"`
+	// key is the raw address of the account, set when the account is read or
+	// built so Address isn't decoded again (see addrKey).
+	key addrKey
 }
 
 type Delegation struct {
 	Amount           sdk.Dec
 	ValidatorAddress string
 	Vote             govtypes.WeightedVoteOptions
+	// Chain is the bech32 prefix of the chain of the delegations merged from
+	// the snapshot of another chain (see mergeChainAccounts), empty for the
+	// delegations of the hub. Their validators don't vote on the hub, and
+	// the direct vote of the account doesn't apply to them.
+	Chain string `json:",omitempty"`
 }
 
 // voteWeights returns a consolidated map of votes, merging direct and indirect
@@ -51,13 +68,29 @@ func (a Account) voteWeights() voteMap {
 		}
 		return v
 	}
-	// direct voter
+	// direct voter, whose vote doesn't apply to the stake on other chains
+	hubPerc := sdk.OneDec()
+	for _, del := range a.Delegations {
+		if del.Chain != "" {
+			delPerc := del.Amount.Quo(a.StakedAmount)
+			v.add(govtypes.OptionEmpty, delPerc)
+			hubPerc = hubPerc.Sub(delPerc)
+		}
+	}
 	for _, vote := range a.Vote {
-		v[vote.Option] = vote.Weight
+		v[vote.Option] = vote.Weight.Mul(hubPerc)
 	}
 	return v
 }
 
+// lockedAmount returns the LockedAmount of a, zero if it's nil.
+func (a Account) lockedAmount() sdk.Dec {
+	if a.LockedAmount == nil {
+		return sdk.ZeroDec()
+	}
+	return *a.LockedAmount
+}
+
 func (a Account) String() string {
 	bz, err := json.MarshalIndent(a, "", " ")
 	if err != nil {
@@ -67,20 +100,37 @@ func (a Account) String() string {
 }
 
 // getAccounts returns the list of all account with their vote and
-// power, from direct or indirect votes.
+// power, from direct or indirect votes. The accounts are matched by their raw
+// address, whatever the bech32 prefix of each input, and the returned
+// addresses use prefix.
 func getAccounts(
+	ctx context.Context,
 	delegsByAddr map[string][]stakingtypes.Delegation,
 	votesByAddr map[string]govtypes.WeightedVoteOptions,
 	valsByAddr map[string]govtypes.ValidatorGovInfo,
 	balancesByAddr map[string]sdk.Coin,
 	accountTypesPerAddr map[string]string,
-) []Account {
-	accountsByAddr := make(map[string]Account, len(delegsByAddr))
+	prefix string,
+) ([]Account, error) {
+	var (
+		delegsByKey       = keyByAddr(delegsByAddr)
+		votesByKey        = keyByAddr(votesByAddr)
+		balancesByKey     = keyByAddr(balancesByAddr)
+		accountTypesByKey = keyByAddr(accountTypesPerAddr)
+		accountsByKey     = make(map[addrKey]Account, len(delegsByKey))
+	)
 	// Feed delegations
-	for addr, delegs := range delegsByAddr {
-		accType := accountTypesPerAddr[addr]
+	for key, delegs := range delegsByKey {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		addr := key.bech32(prefix)
+		accType, ok := accountTypesByKey[key]
+		if !ok {
+			warnings.add(warnDelegatorNoAuth, addr)
+		}
 		if accType == "/cosmos.auth.v1beta1.ModuleAccount" ||
-			accType == "/ibc.applications.interchain_accounts.v1.InterchainAccount" {
+			accType == interchainAccountType {
 			// Ignore ModuleAccount & InterchainAccount
 			continue
 		}
@@ -89,13 +139,15 @@ func getAccounts(
 			Type:         accType,
 			LiquidAmount: sdk.ZeroDec(),
 			StakedAmount: sdk.ZeroDec(),
-			Vote:         votesByAddr[addr],
+			Vote:         votesByKey[key],
+			key:          key,
 		}
 		for _, deleg := range delegs {
 			// Find validator
 			val, ok := valsByAddr[deleg.ValidatorAddress]
 			if !ok {
 				// Validator isn't in active set or jailed, ignore
+				warnings.add(warnDelegInactiveVal, deleg.ValidatorAddress)
 				continue
 			}
 
@@ -110,33 +162,46 @@ func getAccounts(
 				Vote:             val.Vote,
 			})
 		}
-		accountsByAddr[addr] = account
+		accountsByKey[key] = account
 	}
 	// Feed balances
-	for addr, balance := range balancesByAddr {
-		acc, ok := accountsByAddr[addr]
+	for key, balance := range balancesByKey {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		acc, ok := accountsByKey[key]
 		if ok {
 			acc.LiquidAmount = balance.Amount.ToLegacyDec()
-			accountsByAddr[addr] = acc
+			accountsByKey[key] = acc
 		} else {
-			accType := accountTypesPerAddr[addr]
+			addr := key.bech32(prefix)
+			accType, ok := accountTypesByKey[key]
+			if !ok {
+				warnings.add(warnBalanceNoAuth, addr)
+			}
 			if accType == "/cosmos.auth.v1beta1.ModuleAccount" ||
-				accType == "/ibc.applications.interchain_accounts.v1.InterchainAccount" {
+				accType == interchainAccountType {
 				// Ignore ModuleAccount & InterchainAccount
 				continue
 			}
-			accountsByAddr[addr] = Account{
+			accountsByKey[key] = Account{
 				Address:      addr,
 				Type:         accType,
 				LiquidAmount: balance.Amount.ToLegacyDec(),
 				StakedAmount: sdk.ZeroDec(),
+				key:          key,
 			}
 		}
 	}
-	// Map to slice with deterministic order
-	var accounts []Account
-	for _, addr := range slices.Sorted(maps.Keys(accountsByAddr)) {
-		accounts = append(accounts, accountsByAddr[addr])
+	for key := range votesByKey {
+		if _, ok := accountsByKey[key]; !ok {
+			warnings.add(warnVoteUnknownAddr, key.bech32(prefix))
+		}
 	}
-	return accounts
+	// Map to slice with deterministic order
+	accounts := slices.Collect(maps.Values(accountsByKey))
+	slices.SortFunc(accounts, func(a, b Account) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+	return accounts, nil
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"sort"
 	"testing"
@@ -584,7 +585,8 @@ func TestGetAccounts(t *testing.T) {
 			assert := assert.New(t)
 			require := require.New(t)
 
-			accounts := getAccounts(tt.delegsByAddr, tt.votesByAddr, tt.valsByAddr, balancesByAddr, accountTypesByAddr)
+			accounts, err := getAccounts(context.Background(), tt.delegsByAddr, tt.votesByAddr, tt.valsByAddr, balancesByAddr, accountTypesByAddr, "cosmos")
+			require.NoError(err)
 
 			// order is not determistic, sort to have it
 			sort.Slice(accounts, func(i, j int) bool {
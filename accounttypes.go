@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// typeMultipliers maps account types to the multiplier applied to the
+// allocations of the accounts of that type, on top of the vote multipliers.
+// Types are either full type URLs (/cosmos.vesting.v1beta1.DelayedVestingAccount)
+// or their short name (DelayedVestingAccount).
+type typeMultipliers map[string]sdk.Dec
+
+// parseTypeMultipliers reads the multipliers per account type from a JSON
+// object, e.g. {"ModuleAccount": "0", "ContinuousVestingAccount": "0.5"}.
+func parseTypeMultipliers(path string) (_ typeMultipliers, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var m typeMultipliers
+	if err := json.NewDecoder(f).Decode(&m); err != nil {
+		return nil, fmt.Errorf("cannot json decode type multipliers from file %s: %w", path, err)
+	}
+	for typ, mult := range m {
+		if mult.IsNil() || mult.IsNegative() {
+			return nil, fmt.Errorf("invalid multiplier for account type '%s'", typ)
+		}
+	}
+	return m, nil
+}
+
+// get returns the multiplier of the account type typ and its key in m, and
+// false if there's none.
+func (m typeMultipliers) get(typ string) (string, sdk.Dec, bool) {
+	if mult, ok := m[typ]; ok {
+		return typ, mult, true
+	}
+	shortName := typ[strings.LastIndex(typ, ".")+1:]
+	mult, ok := m[shortName]
+	return shortName, mult, ok
+}
+
+func reportTypeMultipliers(sec *reportSection, airdrop airdrop) {
+	sec.text("Account type multipliers")
+	table := sec.table("TYPE", "MULTIPLIER", "ACCOUNTS")
+	for _, typ := range slices.Sorted(maps.Keys(airdrop.params.typeMultipliers)) {
+		table.Append([]string{
+			typ,
+			airdrop.params.typeMultipliers[typ].String(),
+			fmt.Sprint(airdrop.typeMultiplied[typ]),
+		})
+	}
+}
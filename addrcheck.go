@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+// inputFiles lists the files of a snapshot directory.
+var inputFiles = []string{
+	"votes.json",
+	"delegations.json",
+	"active_validators.json",
+	"balances.json",
+	"auth_genesis.json",
+}
+
+// addrFields maps the JSON fields holding bech32 addresses to their expected
+// prefix suffix (appended to the account prefix).
+var addrFields = map[string]string{
+	"voter":             "",
+	"delegator_address": "",
+	"address":           "",
+	"validator_address": "valoper",
+	"operator_address":  "valoper",
+}
+
+type addrIssue struct {
+	file   string
+	field  string
+	addr   string
+	reason string
+	// fix holds the canonical form of addr, empty if addr can't be fixed.
+	fix string
+}
+
+// checkAddresses validates all the bech32 addresses found in the input files
+// of datapath, and returns the invalid ones.
+func checkAddresses(datapath, prefix string) ([]addrIssue, error) {
+	var issues []addrIssue
+	for _, file := range inputFiles {
+		f, err := openInput(context.Background(), joinInput(datapath, file))
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(f)
+		dec.UseNumber()
+		var v any
+		err = dec.Decode(&v)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode %s: %w", file, err)
+		}
+		walkAddrFields(v, func(field, addr string) {
+			if issue, ok := checkAddress(addr, prefix+addrFields[field]); !ok {
+				issue.file = file
+				issue.field = field
+				issues = append(issues, issue)
+			}
+		})
+	}
+	return issues, nil
+}
+
+// walkAddrFields calls fn for each string value of v whose key is in
+// addrFields.
+func walkAddrFields(v any, fn func(field, addr string)) {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, v := range x {
+			if s, ok := v.(string); ok {
+				if _, ok := addrFields[k]; ok && s != "" {
+					fn(k, s)
+				}
+				continue
+			}
+			walkAddrFields(v, fn)
+		}
+	case []any:
+		for _, v := range x {
+			walkAddrFields(v, fn)
+		}
+	}
+}
+
+func checkAddress(addr, prefix string) (addrIssue, bool) {
+	hrp, _, err := bech32.DecodeAndConvert(addr)
+	if err == nil {
+		if hrp != prefix {
+			return addrIssue{addr: addr, reason: fmt.Sprintf("expected prefix %s, got %s", prefix, hrp)}, false
+		}
+		return addrIssue{}, true
+	}
+	issue := addrIssue{addr: addr, reason: err.Error()}
+	// bech32 forbids mixed case, check if the lower case version is valid.
+	lower := strings.ToLower(addr)
+	if lower != addr {
+		if hrp, _, err := bech32.DecodeAndConvert(lower); err == nil && hrp == prefix {
+			issue.fix = lower
+		}
+	}
+	return issue, false
+}
+
+// fixAddresses rewrites the input files of datapath, replacing the fixable
+// addresses of the addrFields fields by their canonical form. The rest of the
+// files is left untouched. The number of distinct fixed addresses is
+// returned.
+func fixAddresses(ctx context.Context, datapath string, issues []addrIssue) (int, error) {
+	if isURL(datapath) {
+		return 0, fmt.Errorf("can't fix the addresses of remote inputs %s, download them first", datapath)
+	}
+	fixesByFile := make(map[string]map[string]string)
+	for _, issue := range issues {
+		if issue.fix == "" {
+			continue
+		}
+		if fixesByFile[issue.file] == nil {
+			fixesByFile[issue.file] = make(map[string]string)
+		}
+		fixesByFile[issue.file][issue.addr] = issue.fix
+	}
+	fixed := make(map[string]bool)
+	for file, fixes := range fixesByFile {
+		path := filepath.Join(datapath, file)
+		bz, err := os.ReadFile(path)
+		if err != nil {
+			return len(fixed), err
+		}
+		bz, err = rewriteAddrFields(bz, fixes, fixed)
+		if err != nil {
+			return len(fixed), fmt.Errorf("fix %s: %w", file, err)
+		}
+		if err := writeFile(ctx, path, bz); err != nil {
+			return len(fixed), err
+		}
+	}
+	return len(fixed), nil
+}
+
+// rewriteAddrFields returns the JSON bz with the values of the addrFields
+// fields replaced by fixes[value], and adds the replaced values to fixed. The
+// other values, even equal to an address of fixes, and the formatting are
+// left untouched.
+func rewriteAddrFields(bz []byte, fixes map[string]string, fixed map[string]bool) ([]byte, error) {
+	type container struct {
+		// object is false for an array, and key is true when the next token
+		// of an object is a key.
+		object, key bool
+	}
+	var (
+		dec   = json.NewDecoder(bytes.NewReader(bz))
+		stack []container
+		field string
+		out   bytes.Buffer
+		last  int
+	)
+	for {
+		start := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var inObject, isKey bool
+		if n := len(stack); n > 0 && stack[n-1].object {
+			inObject, isKey = true, stack[n-1].key
+			stack[n-1].key = !isKey
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				stack = append(stack, container{object: true, key: true})
+			case '[':
+				stack = append(stack, container{})
+			default:
+				stack = stack[:len(stack)-1]
+			}
+		case string:
+			if isKey {
+				field = t
+				continue
+			}
+			fix, ok := fixes[t]
+			if _, isAddr := addrFields[field]; !ok || !inObject || !isAddr {
+				continue
+			}
+			// the raw value follows the separator of the key, it isn't found
+			// if it has escaped characters, which the addresses don't have
+			raw := bz[start:dec.InputOffset()]
+			i := bytes.LastIndex(raw, []byte(`"`+t+`"`))
+			if i < 0 {
+				continue
+			}
+			i += int(start)
+			out.Write(bz[last:i])
+			out.WriteString(`"` + fix + `"`)
+			last = i + len(t) + 2
+			fixed[t] = true
+		}
+	}
+	out.Write(bz[last:])
+	return out.Bytes(), nil
+}
+
+func printAddrIssues(issues []addrIssue) {
+	if len(issues) == 0 {
+		fmt.Println("All addresses are valid.")
+		return
+	}
+	const maxRows = 50
+	table := newMarkdownTable("FILE", "FIELD", "ADDRESS", "REASON", "FIX")
+	for i, issue := range issues {
+		if i == maxRows {
+			table.Append([]string{"...", "", fmt.Sprintf("%d more", len(issues)-maxRows), "", ""})
+			break
+		}
+		table.Append([]string{issue.file, issue.field, issue.addr, issue.reason, issue.fix})
+	}
+	table.Render()
+	fmt.Printf("%s invalid address(es)\n", humanCount(len(issues)))
+}
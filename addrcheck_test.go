@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAddress(t *testing.T) {
+	var (
+		addr    = "cosmos1z8mzakma7vnaajysmtkwt4wgjqr2m84tzvyfkz"
+		valAddr = "cosmosvaloper130mdu9a0etmeuw52qfxk73pn0ga6gawkxsrlwf"
+		mixed   = "COSMOS1Z8mzakma7vnaajysmtkwt4wgjqr2m84tzvyfkz"
+		badSum  = "cosmos1z8mzakma7vnaajysmtkwt4wgjqr2m84tzvyfkq"
+	)
+	tests := []struct {
+		name          string
+		addr          string
+		prefix        string
+		expectedValid bool
+		expectedFix   string
+	}{
+		{name: "valid", addr: addr, prefix: "cosmos", expectedValid: true},
+		{name: "valid valoper", addr: valAddr, prefix: "cosmosvaloper", expectedValid: true},
+		{name: "upper case is valid", addr: strings.ToUpper(addr), prefix: "cosmos", expectedValid: true},
+		{name: "mixed case", addr: mixed, prefix: "cosmos", expectedFix: addr},
+		{name: "wrong prefix", addr: valAddr, prefix: "cosmos"},
+		{name: "invalid checksum", addr: badSum, prefix: "cosmos"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issue, valid := checkAddress(tt.addr, tt.prefix)
+
+			assert.Equal(t, tt.expectedValid, valid)
+			assert.Equal(t, tt.expectedFix, issue.fix)
+		})
+	}
+}
+
+func TestFixAddresses(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addr  = "cosmos1z8mzakma7vnaajysmtkwt4wgjqr2m84tzvyfkz"
+		mixed = "COSMOS1Z8mzakma7vnaajysmtkwt4wgjqr2m84tzvyfkz"
+		dir   = t.TempDir()
+	)
+	// the mixed address is also the value of a memo, which isn't an address
+	// field, and is voter twice
+	votes := `[
+  {"voter": "` + mixed + `", "memo": "` + mixed + `"},
+  {"voter":"` + mixed + `", "tags": ["` + mixed + `"]}
+]`
+	require.NoError(os.WriteFile(filepath.Join(dir, "votes.json"), []byte(votes), 0o644))
+	issues := []addrIssue{
+		{file: "votes.json", field: "voter", addr: mixed, fix: addr},
+		{file: "votes.json", field: "voter", addr: mixed, fix: addr},
+	}
+
+	numFixed, err := fixAddresses(context.Background(), dir, issues)
+
+	require.NoError(err)
+	assert.Equal(1, numFixed)
+	bz, err := os.ReadFile(filepath.Join(dir, "votes.json"))
+	require.NoError(err)
+	assert.Equal(`[
+  {"voter": "`+addr+`", "memo": "`+mixed+`"},
+  {"voter":"`+addr+`", "tags": ["`+mixed+`"]}
+]`, string(bz))
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	policyAddressCap = "address-cap"
+
+	// capSolverMaxIterations bounds the iterations of solveNonVotersMultiplier.
+	capSolverMaxIterations = 100
+)
+
+// capSolverTolerance is the relative change of the nonVotersMultiplier below
+// which solveNonVotersMultiplier has converged.
+var capSolverTolerance = sdk.NewDecWithPrec(1, 12)
+
+// addressCapStats records the allocations reduced to the address cap, and the
+// convergence diagnostics of the nonVotersMultiplier solver.
+type addressCapStats struct {
+	accounts int
+	// excess is the total $ATONE removed from the capped allocations.
+	excess     sdk.Dec
+	iterations int
+	converged  bool
+	// residual is the difference between the non-voters share reached by the
+	// solver and the target.
+	residual sdk.Dec
+}
+
+// hasAddressCap returns true if params define a maximum allocation per
+// address.
+func (d distriParams) hasAddressCap() bool {
+	return !d.addressCap.IsNil() && d.addressCap.IsPositive()
+}
+
+// accountAirdropAmt returns the airdrop amount of acc for the
+// nonVotersMultiplier m, regardless of the entity groups policies.
+func (d distriParams) accountAirdropAmt(acc Account, voteWeights voteMap, m sdk.Dec) sdk.Dec {
+	unstaked := acc.LiquidAmount.Add(acc.lockedAmount().Mul(d.lockedFactor()))
+	amt := unstaked.Mul(m.Mul(d.malus)).Mul(d.supplyFactor)
+	for _, opt := range allVoteOptions {
+		mult, bonusMalus := d.voteMultiplier(opt, m)
+		amt = amt.Add(voteWeights[opt].Mul(acc.StakedAmount).Mul(mult).Mul(bonusMalus).Mul(d.supplyFactor))
+	}
+	return amt
+}
+
+// solveNonVotersMultiplier finds the nonVotersMultiplier that gives
+// targetPerc of the distribution to the non-voters (neutral bucket and liquid
+// amounts) when the allocations are capped to params.addressCap.
+//
+// The closed-form formula used without cap doesn't hold anymore, because the
+// share of each account depends on whether it's capped, which depends on the
+// multiplier. The solver iterates the closed-form formula, each account
+// weighted by its capped ratio for the previous multiplier, starting from m0,
+// until the multiplier is stable.
+func solveNonVotersMultiplier(ctx context.Context, accounts iter.Seq[Account], params distriParams,
+	m0, targetPerc sdk.Dec,
+) (sdk.Dec, addressCapStats, error) {
+	var (
+		stats  = addressCapStats{excess: sdk.ZeroDec(), residual: sdk.ZeroDec()}
+		capDec = params.addressCap.ToLegacyDec()
+		m      = m0
+	)
+	for stats.iterations < capSolverMaxIterations {
+		stats.iterations++
+		voters, nonVoters := sdk.ZeroDec(), sdk.ZeroDec()
+		for acc := range accounts {
+			if err := ctx.Err(); err != nil {
+				return m, stats, err
+			}
+			voteWeights := acc.voteWeights()
+			accVoters, accNonVoters := sdk.ZeroDec(), acc.LiquidAmount.Add(acc.lockedAmount().Mul(params.lockedFactor()))
+			for _, opt := range allVoteOptions {
+				atomAmt := voteWeights[opt].Mul(acc.StakedAmount)
+				switch params.voteBucket(opt) {
+				case voteBucketOpposed:
+					accVoters = accVoters.Add(atomAmt.Mul(params.yesVotesMultiplier))
+				case voteBucketAligned:
+					accVoters = accVoters.Add(atomAmt.Mul(params.noVotesMultiplier))
+				case voteBucketFixed:
+					accVoters = accVoters.Add(atomAmt.Mul(params.abstainMultiplier))
+				default:
+					accNonVoters = accNonVoters.Add(atomAmt)
+				}
+			}
+			if amt := params.accountAirdropAmt(acc, voteWeights, m); amt.GT(capDec) {
+				ratio := capDec.Quo(amt)
+				accVoters = accVoters.Mul(ratio)
+				accNonVoters = accNonVoters.Mul(ratio)
+			}
+			voters = voters.Add(accVoters)
+			nonVoters = nonVoters.Add(accNonVoters)
+		}
+		if nonVoters.IsZero() {
+			return m, stats, fmt.Errorf("address cap solver: no non-voters")
+		}
+		next := targetPerc.Mul(voters).Quo(sdk.OneDec().Sub(targetPerc).Mul(nonVoters))
+		change := next.Sub(m).Abs()
+		m = next
+		nonVotersAtone := nonVoters.Mul(m)
+		stats.residual = nonVotersAtone.Quo(voters.Add(nonVotersAtone)).Sub(targetPerc)
+		if change.LTE(capSolverTolerance.Mul(m)) {
+			stats.converged = true
+			break
+		}
+	}
+	return m, stats, nil
+}
+
+func reportAddressCap(sec *reportSection, airdrop airdrop) {
+	s := airdrop.addressCap
+	sec.text("Address cap of %s $ATONE: %d addresses capped, %s $ATONE removed from the supply",
+		human(airdrop.params.addressCap), s.accounts, humand(s.excess))
+	sec.text("nonVotersMultiplier solver: converged=%t after %d iteration(s), residual %s",
+		s.converged, s.iterations, s.residual)
+}
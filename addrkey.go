@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+// addrKey is the raw form of an account address, independent of its bech32
+// prefix, used to key the accounts internally. It's 20 bytes for the regular
+// accounts, and 32 bytes for some module and interchain accounts. The bech32
+// strings are only decoded and encoded when the data is read or written.
+type addrKey string
+
+// newAddrKey returns the addrKey of the bech32 address addr, whatever its
+// prefix.
+func newAddrKey(addr string) (addrKey, error) {
+	_, bz, err := bech32.DecodeAndConvert(addr)
+	if err != nil {
+		return "", fmt.Errorf("decode address '%s': %w", addr, err)
+	}
+	if err := sdk.VerifyAddressFormat(bz); err != nil {
+		return "", fmt.Errorf("address '%s': %w", addr, err)
+	}
+	return addrKey(bz), nil
+}
+
+// bech32 returns the address k encoded with prefix.
+func (k addrKey) bech32(prefix string) string {
+	return sdk.MustBech32ifyAddressBytes(prefix, []byte(k))
+}
+
+// addrKey returns the addrKey of the account, decoded from its address if the
+// account doesn't carry it.
+func (a Account) addrKey() (addrKey, error) {
+	if a.key != "" {
+		return a.key, nil
+	}
+	return newAddrKey(a.Address)
+}
+
+// hasKey returns true if the address of a has the addrKey key.
+func (a Account) hasKey(key addrKey) bool {
+	k, err := a.addrKey()
+	return err == nil && k == key
+}
+
+// withKey returns a with the addrKey of its address. It's left empty if the
+// address is invalid, the users of the key report it.
+func (a Account) withKey() Account {
+	a.key, _ = newAddrKey(a.Address)
+	return a
+}
+
+// keyByAddr returns the values of m keyed by the addrKey of their bech32
+// address. The invalid addresses are skipped, and the addresses present
+// several times with different prefixes are kept once (the first in
+// lexicographic order), both are reported in the warnings.
+func keyByAddr[V any](m map[string]V) map[addrKey]V {
+	keyed := make(map[addrKey]V, len(m))
+	for _, addr := range slices.Sorted(maps.Keys(m)) {
+		v := m[addr]
+		k, err := newAddrKey(addr)
+		if err != nil {
+			warnings.add(warnInvalidAddr, addr)
+			continue
+		}
+		if _, ok := keyed[k]; ok {
+			warnings.add(warnPrefixDuplicate, addr)
+			continue
+		}
+		keyed[k] = v
+	}
+	return keyed
+}
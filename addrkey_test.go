@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestAddrKey(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	addr := createAccountAddrs(1)[0]
+	atoneAddr := sdk.MustBech32ifyAddressBytes("atone", addr)
+
+	k1, err := newAddrKey(addr.String())
+	require.NoError(err)
+	k2, err := newAddrKey(atoneAddr)
+	require.NoError(err)
+
+	assert.Equal(k1, k2)
+	assert.Len(k1, 20)
+	assert.Equal(addr.String(), k1.bech32("cosmos"))
+	assert.Equal(atoneAddr, k1.bech32("atone"))
+	_, err = newAddrKey("cosmos1invalid")
+	assert.Error(err)
+}
+
+func TestAccountAddrKey(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	addr := createAccountAddrs(1)[0]
+
+	acc := Account{Address: sdk.MustBech32ifyAddressBytes("atone", addr)}.withKey()
+
+	assert.Equal(addrKey(addr), acc.key)
+	key, err := acc.addrKey()
+	require.NoError(err)
+	assert.Equal(addrKey(addr), key)
+	// the carried key isn't decoded from the address again
+	acc.Address = "invalid"
+	key, err = acc.addrKey()
+	require.NoError(err)
+	assert.Equal(addrKey(addr), key)
+	// the accounts without a key decode their address
+	acc = Account{Address: "invalid"}.withKey()
+	assert.Empty(acc.key)
+	_, err = acc.addrKey()
+	assert.Error(err)
+}
+
+func TestKeyByAddr(t *testing.T) {
+	assert := assert.New(t)
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	warnings = newWarningsRegistry()
+	addrs := createAccountAddrs(2)
+
+	keyed := keyByAddr(map[string]int{
+		addrs[0].String(): 1,
+		sdk.MustBech32ifyAddressBytes("atone", addrs[0]): 2,
+		addrs[1].String(): 3,
+		"invalid":         4,
+	})
+
+	assert.Equal(map[addrKey]int{
+		// atone is before cosmos in lexicographic order
+		addrKey(addrs[0]): 2,
+		addrKey(addrs[1]): 3,
+	}, keyed)
+	assert.Equal(1, warnings.get(warnPrefixDuplicate))
+	assert.Equal(1, warnings.get(warnInvalidAddr))
+}
+
+func TestGetAccountsMixedPrefixes(t *testing.T) {
+	assert := assert.New(t)
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	warnings = newWarningsRegistry()
+	var (
+		addr    = createAccountAddrs(1)[0]
+		voteYes = govtypes.NewNonSplitVoteOption(govtypes.OptionYes)
+	)
+
+	// The balance and the vote use a different prefix than the account type
+	accounts, err := getAccounts(
+		context.Background(),
+		nil,
+		map[string]govtypes.WeightedVoteOptions{sdk.MustBech32ifyAddressBytes("govgen", addr): voteYes},
+		nil,
+		map[string]sdk.Coin{sdk.MustBech32ifyAddressBytes("atone", addr): sdk.NewInt64Coin("uatom", 10)},
+		map[string]string{addr.String(): "/cosmos.auth.v1beta1.BaseAccount"},
+		"cosmos",
+	)
+
+	require.NoError(t, err)
+	if assert.Len(accounts, 1) {
+		assert.Equal(addr.String(), accounts[0].Address)
+		assert.Equal("/cosmos.auth.v1beta1.BaseAccount", accounts[0].Type)
+		assert.Equal(sdk.NewDec(10), accounts[0].LiquidAmount)
+		// the account carries its key, converted to bech32 only at output
+		assert.Equal(addrKey(addr), accounts[0].key)
+	}
+	assert.Zero(warnings.get(warnBalanceNoAuth))
+	assert.Zero(warnings.get(warnVoteUnknownAddr))
+}
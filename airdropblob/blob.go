@@ -0,0 +1,169 @@
+// Package airdropblob reads and writes the compact binary form of an airdrop,
+// an alternative to the giant JSON balances list for consumers that embed the
+// airdrop in a chain binary.
+//
+// A blob is made of:
+//   - an 8 bytes magic header ("GBOXAIR1"),
+//   - the sha256 checksum of the uncompressed payload (32 bytes),
+//   - the gzip compressed payload, which is the protobuf encoding of the
+//     Airdrop message described in airdrop.proto.
+//
+// Typical usage from a chain binary:
+//
+//	//go:embed airdrop.blob
+//	var blob []byte
+//
+//	airdrop, err := airdropblob.Load(blob)
+//
+// The package also provides MarshalResult and UnmarshalResult, which encode
+// the full airdrop result (parameters, amounts and aggregates) as a plain
+// protobuf Result message.
+package airdropblob
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var magic = []byte("GBOXAIR1")
+
+const headerLen = 8 + sha256.Size
+
+// Airdrop holds the amount per address of an airdrop.
+type Airdrop struct {
+	Denom   string
+	Entries []Entry
+}
+
+type Entry struct {
+	Address string
+	Amount  uint64
+}
+
+// Map returns the airdrop entries indexed by address.
+func (a Airdrop) Map() map[string]uint64 {
+	m := make(map[string]uint64, len(a.Entries))
+	for _, e := range a.Entries {
+		m[e.Address] = e.Amount
+	}
+	return m
+}
+
+// Write encodes a into w. Entries are sorted by address so the same airdrop
+// always produces the same blob.
+func Write(w io.Writer, a Airdrop) error {
+	entries := make([]Entry, len(a.Entries))
+	copy(entries, a.Entries)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Address < entries[j].Address
+	})
+	payload := protowire.AppendTag(nil, 1, protowire.BytesType)
+	payload = protowire.AppendString(payload, a.Denom)
+	for _, e := range entries {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, e.Address)
+		entry = protowire.AppendTag(entry, 2, protowire.VarintType)
+		entry = protowire.AppendVarint(entry, e.Amount)
+		payload = protowire.AppendTag(payload, 2, protowire.BytesType)
+		payload = protowire.AppendBytes(payload, entry)
+	}
+	checksum := sha256.Sum256(payload)
+	if _, err := w.Write(magic); err != nil {
+		return err
+	}
+	if _, err := w.Write(checksum[:]); err != nil {
+		return err
+	}
+	zw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(payload); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Load decodes a blob produced by Write, after having verified its checksum.
+func Load(bz []byte) (Airdrop, error) {
+	if len(bz) < headerLen || !bytes.Equal(bz[:len(magic)], magic) {
+		return Airdrop{}, errors.New("airdropblob: invalid header")
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(bz[headerLen:]))
+	if err != nil {
+		return Airdrop{}, fmt.Errorf("airdropblob: gzip: %w", err)
+	}
+	payload, err := io.ReadAll(zr)
+	if err != nil {
+		return Airdrop{}, fmt.Errorf("airdropblob: gzip: %w", err)
+	}
+	if checksum := sha256.Sum256(payload); !bytes.Equal(checksum[:], bz[len(magic):headerLen]) {
+		return Airdrop{}, fmt.Errorf("airdropblob: checksum mismatch, expected %X got %X",
+			bz[len(magic):headerLen], checksum)
+	}
+	return decodeAirdrop(payload)
+}
+
+func decodeAirdrop(b []byte) (Airdrop, error) {
+	var a Airdrop
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return a, protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			a.Denom, n = protowire.ConsumeString(b)
+		case num == 2 && typ == protowire.BytesType:
+			var v []byte
+			v, n = protowire.ConsumeBytes(b)
+			if n >= 0 {
+				e, err := decodeEntry(v)
+				if err != nil {
+					return a, err
+				}
+				a.Entries = append(a.Entries, e)
+			}
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return a, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return a, nil
+}
+
+func decodeEntry(b []byte) (Entry, error) {
+	var e Entry
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return e, protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			e.Address, n = protowire.ConsumeString(b)
+		case num == 2 && typ == protowire.VarintType:
+			e.Amount, n = protowire.ConsumeVarint(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return e, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return e, nil
+}
@@ -0,0 +1,64 @@
+package airdropblob
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLoad(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	airdrop := Airdrop{
+		Denom: "uatone",
+		Entries: []Entry{
+			{Address: "atone1b", Amount: 42},
+			{Address: "atone1a", Amount: 1_000_000_000_000},
+			{Address: "atone1c", Amount: 0},
+		},
+	}
+	var buf bytes.Buffer
+
+	err := Write(&buf, airdrop)
+
+	require.NoError(err)
+	res, err := Load(buf.Bytes())
+	require.NoError(err)
+	assert.Equal("uatone", res.Denom)
+	assert.Equal([]Entry{
+		{Address: "atone1a", Amount: 1_000_000_000_000},
+		{Address: "atone1b", Amount: 42},
+		{Address: "atone1c", Amount: 0},
+	}, res.Entries)
+	assert.Equal(map[string]uint64{
+		"atone1a": 1_000_000_000_000,
+		"atone1b": 42,
+		"atone1c": 0,
+	}, res.Map())
+}
+
+func TestLoadErrors(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, Airdrop{Denom: "uatone", Entries: []Entry{{"atone1a", 1}}})
+	require.NoError(t, err)
+	blob := buf.Bytes()
+
+	t.Run("invalid header", func(t *testing.T) {
+		_, err := Load([]byte("GBOX"))
+		assert.EqualError(t, err, "airdropblob: invalid header")
+	})
+	t.Run("checksum mismatch", func(t *testing.T) {
+		corrupted := bytes.Clone(blob)
+		corrupted[len(magic)] ^= 0xff
+
+		_, err := Load(corrupted)
+
+		assert.ErrorContains(t, err, "airdropblob: checksum mismatch")
+	})
+	t.Run("truncated gzip", func(t *testing.T) {
+		_, err := Load(blob[:len(blob)-4])
+		assert.Error(t, err)
+	})
+}
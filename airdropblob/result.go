@@ -0,0 +1,215 @@
+package airdropblob
+
+import (
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Result is the outcome of an airdrop computation, see the Result message in
+// airdrop.proto.
+type Result struct {
+	Denom      string
+	Params     Params
+	Entries    []Entry
+	Aggregates Aggregates
+}
+
+// Params holds the distribution parameters as decimal strings.
+type Params struct {
+	YesVotesMultiplier string
+	NoVotesMultiplier  string
+	Bonus              string
+	Malus              string
+	SupplyFactor       string
+	SupplyMintFactor   string
+}
+
+// Aggregates holds the totals of an airdrop as decimal strings.
+type Aggregates struct {
+	AtomSupply          string
+	AtoneSupply         string
+	NonVotersMultiplier string
+	CommunityPool       string
+	ReservedAddress     string
+	AtoneVotes          map[string]string
+	// StakingIncentives is empty if no minted supply is sent to the staking
+	// incentives module account.
+	StakingIncentives string
+}
+
+// MarshalResult returns the protobuf encoding of r. Entries and votes are
+// sorted so the same result always produces the same bytes.
+func MarshalResult(r Result) []byte {
+	entries := make([]Entry, len(r.Entries))
+	copy(entries, r.Entries)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Address < entries[j].Address
+	})
+	b := appendString(nil, 1, r.Denom)
+	b = appendMessage(b, 2, encodeParams(r.Params))
+	for _, e := range entries {
+		b = appendMessage(b, 3, encodeEntry(e))
+	}
+	return appendMessage(b, 4, encodeAggregates(r.Aggregates))
+}
+
+// UnmarshalResult decodes a result produced by MarshalResult.
+func UnmarshalResult(b []byte) (Result, error) {
+	var r Result
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if typ != protowire.BytesType {
+			return protowire.ConsumeFieldValue(num, typ, b), nil
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return n, nil
+		}
+		var err error
+		switch num {
+		case 1:
+			r.Denom = string(v)
+		case 2:
+			r.Params, err = decodeParams(v)
+		case 3:
+			var e Entry
+			e, err = decodeEntry(v)
+			r.Entries = append(r.Entries, e)
+		case 4:
+			r.Aggregates, err = decodeAggregates(v)
+		}
+		return n, err
+	})
+	return r, err
+}
+
+func encodeEntry(e Entry) []byte {
+	b := appendString(nil, 1, e.Address)
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	return protowire.AppendVarint(b, e.Amount)
+}
+
+func encodeParams(p Params) []byte {
+	b := appendString(nil, 1, p.YesVotesMultiplier)
+	b = appendString(b, 2, p.NoVotesMultiplier)
+	b = appendString(b, 3, p.Bonus)
+	b = appendString(b, 4, p.Malus)
+	b = appendString(b, 5, p.SupplyFactor)
+	return appendString(b, 6, p.SupplyMintFactor)
+}
+
+func decodeParams(b []byte) (Params, error) {
+	var p Params
+	fields := map[protowire.Number]*string{
+		1: &p.YesVotesMultiplier,
+		2: &p.NoVotesMultiplier,
+		3: &p.Bonus,
+		4: &p.Malus,
+		5: &p.SupplyFactor,
+		6: &p.SupplyMintFactor,
+	}
+	err := consumeStrings(b, fields, nil)
+	return p, err
+}
+
+func encodeAggregates(a Aggregates) []byte {
+	b := appendString(nil, 1, a.AtomSupply)
+	b = appendString(b, 2, a.AtoneSupply)
+	b = appendString(b, 3, a.NonVotersMultiplier)
+	b = appendString(b, 4, a.CommunityPool)
+	b = appendString(b, 5, a.ReservedAddress)
+	keys := make([]string, 0, len(a.AtoneVotes))
+	for k := range a.AtoneVotes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		// map entries are encoded as messages with key=1 and value=2
+		b = appendMessage(b, 6, appendString(appendString(nil, 1, k), 2, a.AtoneVotes[k]))
+	}
+	return appendString(b, 7, a.StakingIncentives)
+}
+
+func decodeAggregates(b []byte) (Aggregates, error) {
+	var a Aggregates
+	fields := map[protowire.Number]*string{
+		1: &a.AtomSupply,
+		2: &a.AtoneSupply,
+		3: &a.NonVotersMultiplier,
+		4: &a.CommunityPool,
+		5: &a.ReservedAddress,
+		7: &a.StakingIncentives,
+	}
+	err := consumeStrings(b, fields, func(num protowire.Number, v []byte) error {
+		if num != 6 {
+			return nil
+		}
+		var key, value string
+		err := consumeStrings(v, map[protowire.Number]*string{1: &key, 2: &value}, nil)
+		if err != nil {
+			return err
+		}
+		if a.AtoneVotes == nil {
+			a.AtoneVotes = make(map[string]string)
+		}
+		a.AtoneVotes[key] = value
+		return nil
+	})
+	return a, err
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendMessage(b []byte, num protowire.Number, m []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
+
+// consumeFields calls fn for each field of b. fn must return the number of
+// bytes consumed from the field value, or a negative protowire error code.
+func consumeFields(b []byte, fn func(protowire.Number, protowire.Type, []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		n, err := fn(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// consumeStrings fills the fields of b listed in strs. Other length-delimited
+// fields are passed to other if not nil.
+func consumeStrings(b []byte, strs map[protowire.Number]*string, other func(protowire.Number, []byte) error) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if typ != protowire.BytesType {
+			return protowire.ConsumeFieldValue(num, typ, b), nil
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return n, nil
+		}
+		if s, ok := strs[num]; ok {
+			*s = string(v)
+			return n, nil
+		}
+		if other != nil {
+			return n, other(num, v)
+		}
+		return n, nil
+	})
+}
@@ -0,0 +1,48 @@
+package airdropblob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalUnmarshalResult(t *testing.T) {
+	r := Result{
+		Denom: "uatone",
+		Params: Params{
+			YesVotesMultiplier: "1.000000000000000000",
+			NoVotesMultiplier:  "9.000000000000000000",
+			Bonus:              "1.030000000000000000",
+			Malus:              "0.970000000000000000",
+			SupplyFactor:       "0.100000000000000000",
+			SupplyMintFactor:   "0.111111111111111111",
+		},
+		Entries: []Entry{
+			{Address: "atone1b", Amount: 42},
+			{Address: "atone1a", Amount: 1_000_000_000_000},
+		},
+		Aggregates: Aggregates{
+			AtomSupply:          "100.5",
+			AtoneSupply:         "1000",
+			NonVotersMultiplier: "0.5",
+			CommunityPool:       "10",
+			ReservedAddress:     "20",
+			AtoneVotes:          map[string]string{"yes": "100", "no": "900"},
+			StakingIncentives:   "5",
+		},
+	}
+
+	bz := MarshalResult(r)
+
+	res, err := UnmarshalResult(bz)
+	require.NoError(t, err)
+	expected := r
+	expected.Entries = []Entry{
+		{Address: "atone1a", Amount: 1_000_000_000_000},
+		{Address: "atone1b", Amount: 42},
+	}
+	assert.Equal(t, expected, res)
+	// Deterministic output
+	assert.Equal(t, bz, MarshalResult(r))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Default thresholds of the supply alerts.
+const (
+	defaultAlertAddressPerc   = "0.02"
+	defaultAlertNonVotersPerc = "0.34"
+)
+
+// supplyAlerts are the thresholds of the percent-of-supply sanity checks of a
+// distribution, that guard against parameter mistakes. Zero or nil disables
+// a threshold.
+type supplyAlerts struct {
+	// addressPerc is the maximum share of the final $ATONE supply held by a
+	// single address. The reserved address and the community pool aren't
+	// checked, their shares are set by the params.
+	addressPerc sdk.Dec
+	// nonVotersPerc is the maximum share of the distributed $ATONE held by
+	// the non-voters (neutral bucket and unstaked amounts).
+	nonVotersPerc sdk.Dec
+}
+
+func parseSupplyAlerts(addressPerc, nonVotersPerc string) (supplyAlerts, error) {
+	var (
+		alerts supplyAlerts
+		err    error
+	)
+	if alerts.addressPerc, err = sdk.NewDecFromStr(addressPerc); err != nil {
+		return alerts, fmt.Errorf("invalid alertAddressPerc '%s': %w", addressPerc, err)
+	}
+	if alerts.nonVotersPerc, err = sdk.NewDecFromStr(nonVotersPerc); err != nil {
+		return alerts, fmt.Errorf("invalid alertNonVotersPerc '%s': %w", nonVotersPerc, err)
+	}
+	return alerts, nil
+}
+
+// check returns the alerts raised by airdrop.
+func (s supplyAlerts) check(airdrop airdrop) []string {
+	var alerts []string
+	if !s.addressPerc.IsNil() && s.addressPerc.IsPositive() {
+		var (
+			totalSupply = airdrop.totalSupply()
+			max         = totalSupply.Mul(s.addressPerc)
+		)
+		amounts := airdrop.addresses
+		if airdrop.streamed && !airdrop.largest.Amount.IsNil() {
+			// Only the largest allocation is kept, the next ones may also be
+			// above the threshold.
+			amounts = map[string]sdk.Int{airdrop.largest.Address: airdrop.largest.Amount}
+		}
+		// sort the addresses for a deterministic output
+		for _, addr := range slices.Sorted(maps.Keys(amounts)) {
+			if amt := amounts[addr].ToLegacyDec(); amt.GT(max) {
+				alerts = append(alerts, fmt.Sprintf("address %s holds %s of the supply (%s $ATONE), above %s",
+					addr, humanPercent(amt.Quo(totalSupply)), humand(amt), humanPercent(s.addressPerc)))
+			}
+		}
+	}
+	if !s.nonVotersPerc.IsNil() && s.nonVotersPerc.IsPositive() && airdrop.atone.supply.IsPositive() {
+		if perc := airdrop.nonVotersAmount().Quo(airdrop.atone.supply); perc.GT(s.nonVotersPerc) {
+			alerts = append(alerts, fmt.Sprintf("non-voters hold %s of the distribution, above %s",
+				humanPercent(perc), humanPercent(s.nonVotersPerc)))
+		}
+	}
+	return alerts
+}
+
+// checkSupplyAlerts prints the alerts raised by airdrops, and returns an
+// error if there are some and failOnAlert is true.
+func checkSupplyAlerts(w io.Writer, airdrops []airdrop, alerts supplyAlerts, failOnAlert bool) error {
+	var n int
+	for _, airdrop := range airdrops {
+		for _, alert := range alerts.check(airdrop) {
+			fmt.Fprintf(w, "⚠ ALERT (params: %s): %s ⚠\n", airdrop.params, alert)
+			n++
+		}
+	}
+	if n > 0 && failOnAlert {
+		return fmt.Errorf("%d supply alert(s) raised", n)
+	}
+	return nil
+}
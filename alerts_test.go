@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestSupplyAlerts(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "whale", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000), Vote: vote(govtypes.OptionNo)},
+		{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: vote(govtypes.OptionYes)},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.ZeroDec()},
+	}
+	a, err := distribution(accounts, defaultDistriParams(), "")
+	require.NoError(err)
+
+	alerts, err := parseSupplyAlerts("0.5", "0.34")
+	require.NoError(err)
+	// the whale holds most of the supply, the non-voters are below the 33% target (malus)
+	assert.Equal([]string{
+		"address whale holds 60.23 % of the supply (0 $ATONE), above 50.00 %",
+	}, alerts.check(a))
+	assert.EqualError(checkSupplyAlerts(io.Discard, []airdrop{a}, alerts, true), "1 supply alert(s) raised")
+	assert.NoError(checkSupplyAlerts(io.Discard, []airdrop{a}, alerts, false))
+	// only the largest allocation of a streamed airdrop is kept
+	streamed := a
+	streamed.addresses = nil
+	streamed.streamed = true
+	streamed.largest = addressResult{Address: "whale", Amount: a.addresses["whale"]}
+	assert.Equal(alerts.check(a), alerts.check(streamed))
+
+	alerts, err = parseSupplyAlerts("0", "0.3")
+	require.NoError(err)
+	assert.Equal([]string{
+		"non-voters hold 32.33 % of the distribution, above 30.00 %",
+	}, alerts.check(a))
+
+	alerts, err = parseSupplyAlerts("0", "0")
+	require.NoError(err)
+	assert.Empty(alerts.check(a))
+	assert.NoError(checkSupplyAlerts(io.Discard, []airdrop{a}, alerts, true))
+
+	_, err = parseSupplyAlerts("x", "0")
+	assert.Error(err)
+}
+
+func TestSupplyAlertsDefaults(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs    = createAccountAddrs(300)
+		accounts []Account
+	)
+	for i, addr := range addrs {
+		acc := Account{Address: addr.String(), LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.NewDec(1000)}
+		switch {
+		case i < 150:
+			acc.Vote = govtypes.WeightedVoteOptions{{Option: govtypes.OptionYes, Weight: sdk.OneDec()}}
+		case i < 240:
+			acc.Vote = govtypes.WeightedVoteOptions{{Option: govtypes.OptionNo, Weight: sdk.OneDec()}}
+		}
+		accounts = append(accounts, acc)
+	}
+	params := defaultDistriParams()
+	params.entityGroups = nil
+	a, err := distribution(accounts, params, "")
+	require.NoError(err)
+	alerts, err := parseSupplyAlerts(defaultAlertAddressPerc, defaultAlertNonVotersPerc)
+	require.NoError(err)
+	// the reserved address is above the default threshold, but isn't checked
+	require.True(a.reservedAddr.GT(a.totalSupply().Mul(alerts.addressPerc)))
+
+	assert.Empty(alerts.check(a))
+	assert.NoError(checkSupplyAlerts(io.Discard, []airdrop{a}, alerts, true))
+}
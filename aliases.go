@@ -0,0 +1,37 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// parsePrefixAliases parses a comma-separated list of bech32 prefixes.
+func parsePrefixAliases(s string) []string {
+	var prefixes []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes
+}
+
+// aliasAddresses returns addresses with each address converted to the bech32
+// prefix alias, whatever its current prefix. The amounts are unchanged, so
+// the same distribution can be consumed with the legacy and the new prefixes
+// of a chain.
+func aliasAddresses(addresses map[string]sdk.Int, alias string) (map[string]sdk.Int, error) {
+	addrs := slices.Collect(maps.Keys(addresses))
+	converted, err := convertBech32Batch(addrs, alias)
+	if err != nil {
+		return nil, err
+	}
+	aliased := make(map[string]sdk.Int, len(addresses))
+	for i, addr := range addrs {
+		aliased[converted[i]] = addresses[addr]
+	}
+	return aliased, nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestAliasAddresses(t *testing.T) {
+	addresses := map[string]sdk.Int{
+		"atone1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqrdqzf7whr": sdk.NewInt(42),
+	}
+
+	aliased, err := aliasAddresses(addresses, "cosmos")
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]sdk.Int{
+		"cosmos1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqrdqvfzfpm": sdk.NewInt(42),
+	}, aliased)
+
+	_, err = aliasAddresses(map[string]sdk.Int{"invalid": sdk.NewInt(1)}, "cosmos")
+	assert.ErrorContains(t, err, "decode 'invalid'")
+
+	assert.Equal(t, []string{"atone", "atomone"}, parsePrefixAliases(" atone,,atomone "))
+}
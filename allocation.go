@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/atomone-hub/govbox/airdropblob"
+)
+
+// Aggregates are the precomputed values of a distribution that the
+// allocation of a single account depends on.
+type Aggregates struct {
+	// NonVotersMultiplier is the solved multiplier of the non-voters and the
+	// liquid amounts.
+	NonVotersMultiplier sdk.Dec
+}
+
+// Detail is the allocation of a single account.
+type Detail struct {
+	// Amount is the final allocation in uatone, zero if the account gets
+	// nothing.
+	Amount sdk.Int
+	// Parts is the allocation per vote option, empty if Amount is zero.
+	Parts addrAmtDetail
+	// Audit holds the policies and the multipliers applied to the account.
+	Audit auditEntry
+}
+
+// aggregates returns the Aggregates of a, to compute the allocation of its
+// accounts one by one with ComputeAllocation.
+func (a airdrop) aggregates() Aggregates {
+	return Aggregates{NonVotersMultiplier: a.nonVotersMultiplier}
+}
+
+// aggregatesFromResult returns the Aggregates of an airdrop saved with
+// writeAirdropResult.
+func aggregatesFromResult(res airdropblob.Result) (Aggregates, error) {
+	m, err := sdk.NewDecFromStr(res.Aggregates.NonVotersMultiplier)
+	if err != nil {
+		return Aggregates{}, fmt.Errorf("invalid nonVotersMultiplier '%s': %w", res.Aggregates.NonVotersMultiplier, err)
+	}
+	return Aggregates{NonVotersMultiplier: m}, nil
+}
+
+// ComputeAllocation returns the allocation of acc for params, given the
+// aggregates of the whole distribution, without a pass over the other
+// accounts. It gives the same allocation as distribution, so a claim backend
+// can recompute it on the fly instead of trusting a static file.
+// The address is not converted to a prefix, and the voter floor top-up is not
+// taken from its pool.
+// The params that are solved over all the accounts must be resolved by the
+// caller: the supplyFactor of a target supply, the Yes multiplier of a Yes
+// ratio, and the shareFactors of the share bounds. The account hook is not
+// supported because it isn't pure, and the withholding isn't supported
+// because its escrow is credited over all the accounts.
+func ComputeAllocation(acc Account, params distriParams, agg Aggregates) (Detail, error) {
+	switch {
+	case agg.NonVotersMultiplier.IsNil() || agg.NonVotersMultiplier.IsNegative():
+		return Detail{}, errors.New("the aggregates have no valid nonVotersMultiplier")
+	case params.hasTargetSupply():
+		return Detail{}, errors.New("the target supply must be resolved into the supplyFactor")
+	case params.hasYesRatio():
+		return Detail{}, errors.New("the Yes ratio must be resolved into the Yes multiplier")
+	case params.hasShareBounds() && params.shareFactors == nil:
+		return Detail{}, errors.New("the share bounds must be resolved into the shareFactors")
+	case params.hook != nil:
+		return Detail{}, errors.New("the account hook isn't supported")
+	case params.withholding != nil:
+		return Detail{}, errors.New("the withholding isn't supported")
+	}
+	stages := newDistributionStages(slices.Values([]Account{acc}))
+	stages.atom = &distrib{supply: sdk.ZeroDec(), votes: newVoteMap(), unstaked: sdk.ZeroDec(), locked: sdk.ZeroDec()}
+	stages.solved[params.solveKey()] = solvedMultiplier{
+		nonVotersMultiplier: agg.NonVotersMultiplier,
+		addressCap:          addressCapStats{excess: sdk.ZeroDec(), residual: sdk.ZeroDec()},
+	}
+	stages.subset = true
+	airdrop, err := stages.run(context.Background(), params, "", checkpointConfig{})
+	if err != nil {
+		return Detail{}, fmt.Errorf("account %s: %w", acc.Address, err)
+	}
+	d := Detail{Amount: sdk.ZeroInt(), Audit: airdrop.audit[0]}
+	if len(airdrop.addressesDetail) > 0 {
+		d.Amount = d.Audit.FinalAmount
+		d.Parts = airdrop.addressesDetail[0]
+	}
+	return d, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestComputeAllocation(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs = createAccountAddrs(6)
+		dec   = sdk.MustNewDecFromStr
+		vote  = govtypes.NewNonSplitVoteOption
+	)
+	accounts := []Account{
+		{Address: addrs[0].String(), LiquidAmount: dec("0"), StakedAmount: dec("100.5"), Vote: vote(govtypes.OptionYes)},
+		{Address: addrs[1].String(), LiquidAmount: dec("7"), StakedAmount: dec("30"), Delegations: []Delegation{
+			{Amount: dec("10"), Vote: vote(govtypes.OptionAbstain)},
+			{Amount: dec("20")},
+		}},
+		{Address: addrs[2].String(), LiquidAmount: dec("1000"), StakedAmount: dec("0")},
+		// capped
+		{Address: addrs[3].String(), LiquidAmount: dec("0"), StakedAmount: dec("100000000"), Vote: vote(govtypes.OptionNo)},
+		// slashed
+		{Address: addrs[4].String(), LiquidAmount: dec("50"), StakedAmount: dec("50"), Vote: vote(govtypes.OptionNo)},
+		// opted-out
+		{Address: addrs[5].String(), LiquidAmount: dec("50"), StakedAmount: dec("50"), Vote: vote(govtypes.OptionNo)},
+	}
+	params := defaultDistriParams()
+	params.entityGroups = []entityGroup{{Name: "a", Policy: entityPolicySlash, Addresses: []string{addrs[4].String()}}}
+	params.optOut = []string{addrs[5].String()}
+	params.optOutPolicy = optOutPolicyBurn
+	params.addressCap = sdk.NewInt(50_000_000)
+	params.voterFloor = sdk.NewInt(20)
+	params.voterFloorPool = voterFloorPoolCommunityPool
+	airdrop, err := distribution(accounts, params, "")
+	require.NoError(err)
+	res, err := newAirdropResult("uatone", airdrop)
+	require.NoError(err)
+	agg, err := aggregatesFromResult(res)
+	require.NoError(err)
+	assert.Equal(airdrop.aggregates(), agg)
+
+	for i, acc := range accounts {
+		d, err := ComputeAllocation(acc, params, agg)
+
+		require.NoError(err)
+		assert.Equal(airdrop.audit[i], d.Audit, acc.Address)
+		if amt, ok := airdrop.addresses[acc.Address]; ok {
+			assert.Equal(amt, d.Amount, acc.Address)
+			assert.Equal(acc.Address, d.Parts.Address)
+		} else {
+			assert.True(d.Amount.IsZero(), acc.Address)
+		}
+	}
+
+	params.targetSupply = sdk.NewInt(1_000)
+	_, err = ComputeAllocation(accounts[0], params, agg)
+	assert.ErrorContains(err, "target supply must be resolved")
+	_, err = ComputeAllocation(accounts[0], defaultDistriParams(), Aggregates{})
+	assert.ErrorContains(err, "no valid nonVotersMultiplier")
+}
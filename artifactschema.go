@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// artifactSchema describes an artifact produced by govbox, whose JSON Schema
+// is generated from the Go type of its records.
+type artifactSchema struct {
+	name        string
+	description string
+	// files are the glob patterns of the artifact file names, used by the
+	// validate command to find the schema of a file.
+	files []string
+	// ndjson is true for the artifacts of one JSON record per line, in which
+	// case the schema is the schema of a line.
+	ndjson bool
+	typ    reflect.Type
+}
+
+// artifactSchemas are the artifacts whose schemas are published, so the
+// downstream integrations can rely on a stable format.
+var artifactSchemas = []artifactSchema{
+	{
+		name:        "accounts",
+		description: "The accounts of a snapshot, consolidated by the accounts command.",
+		files:       []string{"accounts.json"},
+		typ:         reflect.TypeFor[[]Account](),
+	},
+	{
+		// before airdrop, whose file names match airdrop_withholding.json
+		name:        "withholding",
+		description: "The withholding ledger written by the distribution command with -withholding.",
+		files:       []string{"airdrop_withholding.json"},
+		typ:         reflect.TypeFor[withholdingLedger](),
+	},
+	{
+		// before airdrop, whose file names match airdrop_public.json
+		name:        "public",
+		description: "The aggregates and the allocations bucketed by size written by the distribution command with -public.",
+		files:       []string{"airdrop_public.json"},
+		typ:         reflect.TypeFor[publicAirdrop](),
+	},
+	{
+		name:        "airdrop",
+		description: "The $ATONE allocation of each address, in uatone, written by the distribution command.",
+		files:       []string{"airdrop.json", "airdrop_*.json"},
+		typ:         reflect.TypeFor[map[string]sdk.Int](),
+	},
+	{
+		name:        "airdrop-ndjson",
+		description: "A line of the $ATONE allocations streamed by the distribution command with -output ndjson.",
+		files:       []string{"airdrop.ndjson"},
+		ndjson:      true,
+		typ:         reflect.TypeFor[addressResult](),
+	},
+	{
+		name:        "audit",
+		description: "A line of the -audit log of the distribution command, the detail of the allocation of an account.",
+		files:       []string{"*audit*.ndjson", "*audit*.jsonl"},
+		ndjson:      true,
+		typ:         reflect.TypeFor[auditEntry](),
+	},
+	{
+		name:        "warnings",
+		description: "A line of the run statistics of the warnings, printed by the -ci mode.",
+		files:       []string{"*warnings*.ndjson", "*warnings*.jsonl"},
+		ndjson:      true,
+		typ:         reflect.TypeFor[warningLine](),
+	},
+}
+
+func getArtifactSchema(name string) (artifactSchema, error) {
+	for _, a := range artifactSchemas {
+		if a.name == name {
+			return a, nil
+		}
+	}
+	names := make([]string, len(artifactSchemas))
+	for i, a := range artifactSchemas {
+		names[i] = a.name
+	}
+	return artifactSchema{}, fmt.Errorf("unknown artifact '%s' (expected %s)", name, strings.Join(names, ", "))
+}
+
+// detectArtifactSchema returns the artifact whose file names match file.
+func detectArtifactSchema(file string) (artifactSchema, error) {
+	base := filepath.Base(file)
+	for _, a := range artifactSchemas {
+		for _, pattern := range a.files {
+			if ok, _ := filepath.Match(pattern, base); ok {
+				return a, nil
+			}
+		}
+	}
+	return artifactSchema{}, fmt.Errorf("can't detect the artifact of '%s', use -artifact", base)
+}
+
+// jsonSchema is the subset of JSON Schema used to describe the artifacts.
+type jsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        schemaTypes            `json:"type,omitempty"`
+	Pattern     string                 `json:"pattern,omitempty"`
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	// AdditionalProperties is false for the structs, and the schema of the
+	// values for the maps.
+	AdditionalProperties any         `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema `json:"items,omitempty"`
+}
+
+// schemaTypes is the type keyword, a string for a single type.
+type schemaTypes []string
+
+func (t schemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// The patterns of the sdk.Dec and sdk.Int strings.
+const (
+	decPattern = `^-?[0-9]+(\.[0-9]+)?$`
+	intPattern = `^-?[0-9]+$`
+)
+
+var schemaPatterns = map[string]*regexp.Regexp{
+	decPattern: regexp.MustCompile(decPattern),
+	intPattern: regexp.MustCompile(intPattern),
+}
+
+// schema returns the JSON Schema document of the artifact.
+func (a artifactSchema) schema() *jsonSchema {
+	s := schemaOfType(a.typ)
+	s.Schema = jsonSchemaDraft
+	s.Title = "govbox " + a.name
+	s.Description = a.description
+	return s
+}
+
+// schemaOfType returns the schema of the encoding/json form of the values of
+// type t.
+func schemaOfType(t reflect.Type) *jsonSchema {
+	switch t {
+	case reflect.TypeFor[sdk.Dec]():
+		return &jsonSchema{Type: schemaTypes{"string"}, Pattern: decPattern}
+	case reflect.TypeFor[sdk.Int]():
+		return &jsonSchema{Type: schemaTypes{"string"}, Pattern: intPattern}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := schemaOfType(t.Elem())
+		s.Type = append(s.Type, "null")
+		return s
+	case reflect.String:
+		return &jsonSchema{Type: schemaTypes{"string"}}
+	case reflect.Bool:
+		return &jsonSchema{Type: schemaTypes{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: schemaTypes{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: schemaTypes{"number"}}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: schemaTypes{"array", "null"}, Items: schemaOfType(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: schemaTypes{"object", "null"}, AdditionalProperties: schemaOfType(t.Elem())}
+	case reflect.Struct:
+		s := &jsonSchema{
+			Type:                 schemaTypes{"object"},
+			Properties:           make(map[string]*jsonSchema),
+			AdditionalProperties: false,
+		}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			s.Properties[name] = schemaOfType(f.Type)
+			if !slices.Contains(strings.Split(opts, ","), "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	}
+	return &jsonSchema{}
+}
+
+// writeArtifactSchemas writes the schema of each artifact in
+// dir/<name>.schema.json.
+func writeArtifactSchemas(ctx context.Context, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, a := range artifactSchemas {
+		bz, err := json.MarshalIndent(a.schema(), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		file := filepath.Join(dir, a.name+".schema.json")
+		if err := writeFile(ctx, file, append(bz, '\n')); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// schemaViolation is a value of an artifact that doesn't match its schema,
+// path is the JSON path of the value, e.g. $[3].Vote[0].weight.
+type schemaViolation struct {
+	path    string
+	problem string
+}
+
+func (v schemaViolation) String() string {
+	return v.path + ": " + v.problem
+}
+
+// validate returns the violations of the JSON value v, decoded with
+// UseNumber, against s.
+func (s *jsonSchema) validate(path string, v any) []schemaViolation {
+	if len(s.Type) > 0 && !slices.Contains(s.Type, jsonType(v)) &&
+		!(jsonType(v) == "integer" && slices.Contains(s.Type, "number")) {
+		return []schemaViolation{{path, fmt.Sprintf("expected %s, found %s", strings.Join(s.Type, " or "), jsonType(v))}}
+	}
+	var violations []schemaViolation
+	switch v := v.(type) {
+	case string:
+		if s.Pattern != "" && !schemaPatterns[s.Pattern].MatchString(v) {
+			violations = append(violations, schemaViolation{path, fmt.Sprintf("'%s' doesn't match %s", v, s.Pattern)})
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				violations = append(violations, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	case map[string]any:
+		for _, k := range s.Required {
+			if _, ok := v[k]; !ok {
+				violations = append(violations, schemaViolation{path, fmt.Sprintf("missing required property '%s'", k)})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			if p, ok := s.Properties[k]; ok {
+				violations = append(violations, p.validate(path+"."+k, v[k])...)
+				continue
+			}
+			switch ap := s.AdditionalProperties.(type) {
+			case bool:
+				if !ap {
+					violations = append(violations, schemaViolation{path, fmt.Sprintf("unexpected property '%s'", k)})
+				}
+			case *jsonSchema:
+				violations = append(violations, ap.validate(fmt.Sprintf("%s[%q]", path, k), v[k])...)
+			}
+		}
+	}
+	return violations
+}
+
+// jsonType returns the JSON Schema type of the JSON value v.
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// maxSchemaViolations is the number of violations after which the validation
+// of an artifact stops.
+const maxSchemaViolations = 100
+
+// validateArtifact validates the artifact r against the schema of a. The
+// arrays are validated element by element, so that large artifacts like
+// accounts.json aren't loaded in memory.
+func validateArtifact(ctx context.Context, r io.Reader, a artifactSchema) ([]schemaViolation, error) {
+	var (
+		s          = a.schema()
+		violations []schemaViolation
+	)
+	if a.ndjson {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(nil, 16*1024*1024)
+		for line := 1; sc.Scan() && len(violations) < maxSchemaViolations; line++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if len(strings.TrimSpace(sc.Text())) == 0 {
+				continue
+			}
+			path := fmt.Sprintf("line %d: $", line)
+			v, err := decodeJSONValue(json.NewDecoder(strings.NewReader(sc.Text())))
+			if err != nil {
+				violations = append(violations, schemaViolation{path, err.Error()})
+				continue
+			}
+			violations = append(violations, s.validate(path, v)...)
+		}
+		return violations, sc.Err()
+	}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if slices.Contains(s.Type, "array") {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		if tok != json.Delim('[') {
+			return append(violations, schemaViolation{"$", "expected array"}), nil
+		}
+		for i := 0; dec.More() && len(violations) < maxSchemaViolations; i++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			var v any
+			if err := dec.Decode(&v); err != nil {
+				return nil, fmt.Errorf("invalid JSON: %w", err)
+			}
+			violations = append(violations, s.Items.validate(fmt.Sprintf("$[%d]", i), v)...)
+		}
+		return violations, nil
+	}
+	v, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	return s.validate("$", v), nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (any, error) {
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid JSON: trailing data")
+	}
+	return v, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestValidateArtifact(t *testing.T) {
+	locked := sdk.NewDec(3)
+	accounts, err := json.Marshal([]Account{
+		{
+			Address: "cosmos1a", LiquidAmount: sdk.NewDec(1), StakedAmount: sdk.NewDecWithPrec(15, 1), LockedAmount: &locked,
+			Vote:        govtypes.NewNonSplitVoteOption(govtypes.OptionYes),
+			Delegations: []Delegation{{Amount: sdk.NewDecWithPrec(15, 1), ValidatorAddress: "cosmosvaloper1a"}},
+		},
+		{Address: "cosmos1b", LiquidAmount: sdk.NewDec(1), StakedAmount: sdk.ZeroDec()},
+	})
+	require.NoError(t, err)
+	airdrop, err := json.Marshal(map[string]sdk.Int{"atone1a": sdk.NewInt(5)})
+	require.NoError(t, err)
+	distributed, err := distribution([]Account{
+		{Address: createAccountAddrs(1)[0].String(), LiquidAmount: sdk.NewDec(10 * M), StakedAmount: sdk.ZeroDec()},
+	}, defaultDistriParams(), "")
+	require.NoError(t, err)
+	pub, err := newPublicAirdrop("uatone", distributed, 1)
+	require.NoError(t, err)
+	public, err := json.Marshal(pub)
+	require.NoError(t, err)
+	tests := []struct {
+		name               string
+		artifact           string
+		content            string
+		expectedViolations []string
+	}{
+		{
+			name:     "accounts",
+			artifact: "accounts",
+			content:  string(accounts),
+		},
+		{
+			name:     "accounts violations",
+			artifact: "accounts",
+			content:  `[{"Address":"cosmos1a","Type":"","LiquidAmount":"1","StakedAmount":1,"Vote":null,"Delegations":null,"Extra":true},{"Address":"cosmos1b"}]`,
+			expectedViolations: []string{
+				"$[0]: unexpected property 'Extra'",
+				"$[0].StakedAmount: expected string, found integer",
+				"$[1]: missing required property 'Type'",
+				"$[1]: missing required property 'LiquidAmount'",
+				"$[1]: missing required property 'StakedAmount'",
+				"$[1]: missing required property 'Vote'",
+				"$[1]: missing required property 'Delegations'",
+			},
+		},
+		{
+			name:     "airdrop",
+			artifact: "airdrop",
+			content:  string(airdrop),
+		},
+		{
+			name:               "airdrop violations",
+			artifact:           "airdrop",
+			content:            `{"atone1a":"5.5"}`,
+			expectedViolations: []string{`$["atone1a"]: '5.5' doesn't match ^-?[0-9]+$`},
+		},
+		{
+			name:     "public",
+			artifact: "public",
+			content:  string(public),
+		},
+		{
+			name:     "audit",
+			artifact: "audit",
+			content: `{"address":"cosmos1a","type":"","liquidAmount":"1.0","stakedAmount":"0.0","voteWeights":{"":"1.0"},"amount":"2.0","finalAmount":"2"}
+
+{"address":"cosmos1b","type":"","liquidAmount":"1.0","stakedAmount":"0.0","voteWeights":null,"amount":"2.0","finalAmount":"2","policies":["zero-amount"]}`,
+		},
+		{
+			name:     "warnings violations",
+			artifact: "warnings",
+			content: `{"warning":"duplicate vote","severity":"warning","count":1.5,"examples":["a"]}
+{"warning":`,
+			expectedViolations: []string{
+				"line 1: $.count: expected integer, found number",
+				"line 2: $: invalid JSON: unexpected EOF",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := getArtifactSchema(tt.artifact)
+			require.NoError(t, err)
+
+			violations, err := validateArtifact(context.Background(), strings.NewReader(tt.content), a)
+
+			require.NoError(t, err)
+			var problems []string
+			for _, v := range violations {
+				problems = append(problems, v.String())
+			}
+			assert.Equal(t, tt.expectedViolations, problems)
+		})
+	}
+}
+
+func TestDetectArtifactSchema(t *testing.T) {
+	for file, expected := range map[string]string{
+		"out/accounts.json":        "accounts",
+		"airdrop.json":             "airdrop",
+		"airdrop_v2.json":          "airdrop",
+		"airdrop_withholding.json": "withholding",
+		"airdrop_public.json":      "public",
+		"airdrop.ndjson":           "airdrop-ndjson",
+		"run-audit.ndjson":         "audit",
+		"ci-warnings.jsonl":        "warnings",
+		"validators_map.json":      "",
+	} {
+		a, err := detectArtifactSchema(file)
+		if expected == "" {
+			assert.Error(t, err, file)
+			continue
+		}
+		require.NoError(t, err, file)
+		assert.Equal(t, expected, a.name, file)
+	}
+}
+
+func TestPublishedArtifactSchemas(t *testing.T) {
+	dir := t.TempDir()
+
+	files, err := writeArtifactSchemas(context.Background(), dir)
+
+	require.NoError(t, err)
+	for _, file := range files {
+		bz, err := os.ReadFile(file)
+		require.NoError(t, err)
+		published, err := os.ReadFile(filepath.Join("docs", "schemas", filepath.Base(file)))
+		require.NoError(t, err)
+		assert.Equal(t, string(bz), string(published), "%s is outdated, run `govbox schemas -o docs/schemas`", filepath.Base(file))
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// Kinds of attributionActor.
+const (
+	actorValidator = "validator"
+	actorVoter     = "voter"
+)
+
+// attributionActor is a validator or a direct voter, whose vote is removed
+// from the accounts to measure its impact on the distribution.
+type attributionActor struct {
+	kind    string
+	address string
+	// power is the $ATOM delegated to the validator, or staked by the voter.
+	power sdk.Dec
+	vote  govtypes.VoteOption
+}
+
+// attributionResult is the distribution without the vote of actor.
+type attributionResult struct {
+	actor               attributionActor
+	nonVotersMultiplier sdk.Dec
+	supplyRatio         sdk.Dec
+	// deltaMultiplier and deltaSupplyRatio are the differences with the
+	// distribution with all the votes, so the impact of the vote of the actor
+	// is their opposite.
+	deltaMultiplier  sdk.Dec
+	deltaSupplyRatio sdk.Dec
+}
+
+// attribution ranks the actors by the impact of their vote on the
+// distribution.
+type attribution struct {
+	nonVotersMultiplier sdk.Dec
+	// supplyRatio is the final $ATONE supply over the $ATOM supply.
+	supplyRatio sdk.Dec
+	// results are sorted by decreasing impact on the nonVotersMultiplier.
+	results []attributionResult
+}
+
+// supplyRatio returns the final $ATONE supply of a over its $ATOM supply.
+func (a airdrop) supplyRatio() sdk.Dec {
+	if !a.atom.supply.IsPositive() {
+		return sdk.ZeroDec()
+	}
+	return a.totalSupply().Quo(a.atom.supply)
+}
+
+// attributionActors returns the numValidators most powerful validators that
+// voted, and the numVoters direct voters with the most staked $ATOM.
+func attributionActors(accounts []Account, validators []validatorPower, numValidators, numVoters int) []attributionActor {
+	var actors []attributionActor
+	for _, v := range validators {
+		if len(actors) == numValidators {
+			break
+		}
+		if v.vote == govtypes.OptionEmpty {
+			// removing a missing vote has no impact
+			continue
+		}
+		actors = append(actors, attributionActor{kind: actorValidator, address: v.address, power: v.power, vote: v.vote})
+	}
+	var voters []attributionActor
+	for _, acc := range accounts {
+		if len(acc.Vote) == 0 || !acc.StakedAmount.IsPositive() {
+			continue
+		}
+		voters = append(voters, attributionActor{
+			kind: actorVoter, address: acc.Address, power: acc.StakedAmount, vote: mainVoteOption(acc.Vote),
+		})
+	}
+	slices.SortFunc(voters, func(a, b attributionActor) int {
+		if c := b.power.BigInt().Cmp(a.power.BigInt()); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.address, b.address)
+	})
+	return append(actors, voters[:min(numVoters, len(voters))]...)
+}
+
+// withoutVote returns accounts without the vote of actor: the delegations to
+// a validator lose its vote, and a voter doesn't vote directly anymore. The
+// accounts aren't modified.
+func withoutVote(accounts []Account, actor attributionActor) iter.Seq[Account] {
+	return func(yield func(Account) bool) {
+		for _, acc := range accounts {
+			switch actor.kind {
+			case actorVoter:
+				if acc.Address == actor.address {
+					acc.Vote = nil
+				}
+			case actorValidator:
+				if i := slices.IndexFunc(acc.Delegations, func(d Delegation) bool {
+					return d.ValidatorAddress == actor.address
+				}); i != -1 {
+					acc.Delegations = slices.Clone(acc.Delegations)
+					for j := i; j < len(acc.Delegations); j++ {
+						if acc.Delegations[j].ValidatorAddress == actor.address {
+							acc.Delegations[j].Vote = nil
+						}
+					}
+				}
+			}
+			if !yield(acc) {
+				return
+			}
+		}
+	}
+}
+
+// computeAttribution runs the distribution of accounts for params without the
+// vote of each of the numValidators most powerful validators and numVoters
+// largest direct voters, and ranks them by their impact on the
+// nonVotersMultiplier.
+func computeAttribution(ctx context.Context, accounts []Account, params distriParams, numValidators, numVoters int) (attribution, error) {
+	baseline, err := distribution(accounts, params, "")
+	if err != nil {
+		return attribution{}, err
+	}
+	attr := attribution{
+		nonVotersMultiplier: baseline.nonVotersMultiplier,
+		supplyRatio:         baseline.supplyRatio(),
+	}
+	for _, actor := range attributionActors(accounts, baseline.validators, numValidators, numVoters) {
+		a, err := distributionSeq(ctx, withoutVote(accounts, actor), params, "")
+		if err != nil {
+			return attribution{}, fmt.Errorf("without %s %s: %w", actor.kind, actor.address, err)
+		}
+		attr.results = append(attr.results, attributionResult{
+			actor:               actor,
+			nonVotersMultiplier: a.nonVotersMultiplier,
+			supplyRatio:         a.supplyRatio(),
+			deltaMultiplier:     a.nonVotersMultiplier.Sub(attr.nonVotersMultiplier),
+			deltaSupplyRatio:    a.supplyRatio().Sub(attr.supplyRatio),
+		})
+	}
+	slices.SortStableFunc(attr.results, func(a, b attributionResult) int {
+		return b.deltaMultiplier.Abs().BigInt().Cmp(a.deltaMultiplier.Abs().BigInt())
+	})
+	return attr, nil
+}
+
+func printAttribution(attr attribution) {
+	fmt.Printf("With all the votes: nonVotersMultiplier %.6f, supply ratio %.6f\n\n",
+		attr.nonVotersMultiplier.MustFloat64(), attr.supplyRatio.MustFloat64())
+	table := newMarkdownTable("RANK", "ACTOR", "ADDRESS", "VOTE", "$ATOM", "NON VOTERS MULTIPLIER", "Δ MULTIPLIER", "SUPPLY RATIO", "Δ SUPPLY RATIO")
+	for i, r := range attr.results {
+		table.Append([]string{
+			fmt.Sprint(i + 1),
+			r.actor.kind,
+			r.actor.address,
+			voteOptionLabel(r.actor.vote),
+			humand(r.actor.power),
+			fmt.Sprintf("%.6f", r.nonVotersMultiplier.MustFloat64()),
+			fmt.Sprintf("%+.6f", r.deltaMultiplier.MustFloat64()),
+			fmt.Sprintf("%.6f", r.supplyRatio.MustFloat64()),
+			fmt.Sprintf("%+.6f", r.deltaSupplyRatio.MustFloat64()),
+		})
+	}
+	table.Render()
+	fmt.Println("Δ is the change of the distribution without the vote of the actor.")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestComputeAttribution(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{
+			Address: "a", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000),
+			Delegations: []Delegation{
+				{ValidatorAddress: "val1", Amount: sdk.NewDec(800), Vote: vote(govtypes.OptionYes)},
+				{ValidatorAddress: "val2", Amount: sdk.NewDec(200)},
+			},
+		},
+		{
+			Address: "b", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(500),
+			Delegations: []Delegation{
+				{ValidatorAddress: "val3", Amount: sdk.NewDec(500), Vote: vote(govtypes.OptionNo)},
+			},
+		},
+		{
+			Address: "c", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(300), Vote: vote(govtypes.OptionNo),
+			Delegations: []Delegation{
+				{ValidatorAddress: "val2", Amount: sdk.NewDec(300)},
+			},
+		},
+		{Address: "d", LiquidAmount: sdk.NewDec(2000), StakedAmount: sdk.ZeroDec()},
+	}
+
+	attr, err := computeAttribution(context.Background(), accounts, defaultDistriParams(), 20, 20)
+
+	require.NoError(err)
+	var actors []string
+	for _, r := range attr.results {
+		actors = append(actors, r.actor.kind+" "+r.actor.address)
+		assert.False(r.deltaMultiplier.IsZero(), r.actor.address)
+		assert.Equal(r.nonVotersMultiplier.Sub(attr.nonVotersMultiplier), r.deltaMultiplier)
+		assert.Equal(r.supplyRatio.Sub(attr.supplyRatio), r.deltaSupplyRatio)
+	}
+	// val2 didn't vote so it's not reported
+	assert.ElementsMatch([]string{"validator val1", "validator val3", "voter c"}, actors)
+	for i := 1; i < len(attr.results); i++ {
+		assert.True(attr.results[i-1].deltaMultiplier.Abs().GTE(attr.results[i].deltaMultiplier.Abs()))
+	}
+	// the accounts are not modified
+	assert.Equal(vote(govtypes.OptionYes), accounts[0].Delegations[0].Vote)
+	assert.Equal(vote(govtypes.OptionNo), accounts[1].Delegations[0].Vote)
+	assert.Equal(vote(govtypes.OptionNo), accounts[2].Vote)
+
+	attr, err = computeAttribution(context.Background(), accounts, defaultDistriParams(), 1, 0)
+
+	require.NoError(err)
+	require.Len(attr.results, 1)
+	assert.Equal(attributionActor{
+		kind: actorValidator, address: "val1", power: sdk.NewDec(800), vote: govtypes.OptionYes,
+	}, attr.results[0].actor)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Policies that can be applied to an account during the distribution, in
+// addition to the entity groups policies.
+const (
+	policyZeroAmount = "zero-amount"
+)
+
+// auditEntry records all the decisions taken by the distribution for a single
+// account, so any individual allocation can be traced without re-running.
+type auditEntry struct {
+	Address      string             `json:"address"`
+	Type         string             `json:"type"`
+	LiquidAmount sdk.Dec            `json:"liquidAmount"`
+	StakedAmount sdk.Dec            `json:"stakedAmount"`
+	LockedAmount *sdk.Dec           `json:"lockedAmount,omitempty"`
+	VoteWeights  map[string]sdk.Dec `json:"voteWeights"`
+	// Policies lists the policies applied to the account, in order.
+	Policies    []string           `json:"policies,omitempty"`
+	Multipliers map[string]sdk.Dec `json:"multipliers,omitempty"`
+	// Amount is the airdrop amount before rounding.
+	Amount sdk.Dec `json:"amount"`
+	// FinalAmount is the amount written in the airdrop, zero if excluded.
+	FinalAmount sdk.Int `json:"finalAmount"`
+	// OutputAddress is the address written in the airdrop, may differ from
+	// Address if a prefix conversion occurred.
+	OutputAddress string `json:"outputAddress,omitempty"`
+}
+
+// lockedAmount returns the locked amount of the account of e, zero if nil.
+func (e auditEntry) lockedAmount() sdk.Dec {
+	if e.LockedAmount == nil {
+		return sdk.ZeroDec()
+	}
+	return *e.LockedAmount
+}
+
+func newAuditEntry(acc Account, voteWeights voteMap) auditEntry {
+	e := auditEntry{
+		Address:      acc.Address,
+		Type:         acc.Type,
+		LiquidAmount: acc.LiquidAmount,
+		StakedAmount: acc.StakedAmount,
+		LockedAmount: acc.LockedAmount,
+		VoteWeights:  make(map[string]sdk.Dec, len(voteWeights)),
+		Amount:       sdk.ZeroDec(),
+		FinalAmount:  sdk.ZeroInt(),
+	}
+	for opt, w := range voteWeights {
+		if !w.IsZero() {
+			e.VoteWeights[opt.String()] = w
+		}
+	}
+	return e
+}
+
+// writeAuditLog writes entries in file, one JSON object per line.
+func writeAuditLog(ctx context.Context, file string, entries []auditEntry) error {
+	return writeOutputFile(ctx, file, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
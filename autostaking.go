@@ -7,8 +7,6 @@ import (
 	"slices"
 	"sort"
 
-	h "github.com/dustin/go-humanize"
-
 	tmjson "github.com/cometbft/cometbft/libs/json"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -132,7 +130,7 @@ func autoStaking(genesisPath string) error {
 	for i := 0; i < validatorLen; i++ {
 		fmt.Println("VAL", i, humani(validators[i]))
 	}
-	fmt.Println("count", h.Comma(int64(numStakes)), h.Comma(int64(len(bankGenesisState.Balances))))
+	fmt.Println("count", humanCount(numStakes), humanCount(len(bankGenesisState.Balances)))
 	fmt.Println("amount", human(totalStake), humani(stakeds), human(supply))
 	fmt.Println("staking ratio", totalStake.ToLegacyDec().Quo(supply.ToLegacyDec()))
 	return nil
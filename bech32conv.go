@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/address"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+// A bech32 address is the prefix, the separator '1', and the address bytes
+// regrouped by 5 bits followed by a 6 characters checksum of the prefix and
+// the data. Changing the prefix of an address keeps the 5-bit data as is, so
+// the conversions below only validate it and compute the new checksum, without
+// decoding the address bytes.
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32ChecksumLen is the length of the checksum of a bech32 string.
+const bech32ChecksumLen = 6
+
+// bech32CharsetRev maps the characters of bech32Charset to their 5-bit value,
+// and the other ASCII characters to -1.
+var bech32CharsetRev = func() (rev [128]int8) {
+	for i := range rev {
+		rev[i] = -1
+	}
+	for i, c := range bech32Charset {
+		rev[c] = int8(i)
+	}
+	return rev
+}()
+
+var bech32Generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+// bech32Polymod adds the 5-bit value v to the checksum chk (BIP-173).
+func bech32Polymod(chk uint32, v byte) uint32 {
+	top := chk >> 25
+	chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+	for i, g := range bech32Generator {
+		if (top>>i)&1 == 1 {
+			chk ^= g
+		}
+	}
+	return chk
+}
+
+// bech32PrefixChecksum returns the checksum of the expanded prefix, or false
+// if prefix isn't a lower case bech32 prefix.
+func bech32PrefixChecksum(prefix string) (uint32, bool) {
+	if prefix == "" {
+		return 0, false
+	}
+	chk := uint32(1)
+	for i := 0; i < len(prefix); i++ {
+		c := prefix[i]
+		if c < 33 || c > 126 || ('A' <= c && c <= 'Z') {
+			return 0, false
+		}
+		chk = bech32Polymod(chk, c>>5)
+	}
+	chk = bech32Polymod(chk, 0)
+	for i := 0; i < len(prefix); i++ {
+		chk = bech32Polymod(chk, prefix[i]&31)
+	}
+	return chk, true
+}
+
+// bech32Data returns the data part of addr, without its checksum, if addr is
+// a valid lower case bech32 address whose bytes are a valid address (see
+// sdk.VerifyAddressFormat).
+func bech32Data(addr string) (string, bool) {
+	sep := strings.LastIndexByte(addr, '1')
+	if sep < 1 || sep+1+bech32ChecksumLen > len(addr) || len(addr) > 1023 {
+		return "", false
+	}
+	chk, ok := bech32PrefixChecksum(addr[:sep])
+	if !ok {
+		return "", false
+	}
+	data := addr[sep+1:]
+	var last int8
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if c >= 128 || bech32CharsetRev[c] < 0 {
+			return "", false
+		}
+		if i == len(data)-bech32ChecksumLen-1 {
+			last = bech32CharsetRev[c]
+		}
+		chk = bech32Polymod(chk, byte(bech32CharsetRev[c]))
+	}
+	if chk != 1 {
+		return "", false
+	}
+	// The bits that don't fill a byte must be a zero padding of less than 5
+	// bits.
+	bits := (len(data) - bech32ChecksumLen) * 5
+	if n := bits / 8; n == 0 || n > address.MaxAddrLen {
+		return "", false
+	}
+	if pad := bits % 8; pad >= 5 || last&(1<<pad-1) != 0 {
+		return "", false
+	}
+	return data[:len(data)-bech32ChecksumLen], true
+}
+
+// appendBech32Prefix appends to dst the bech32 address addr, whatever its
+// prefix, encoded with prefix. It doesn't allocate if dst has the capacity
+// for the result. The addresses and prefixes in upper case, and the invalid
+// addresses, go through the decoding and encoding of the sdk, which report
+// the errors.
+func appendBech32Prefix(dst []byte, addr, prefix string) ([]byte, error) {
+	data, ok := bech32Data(addr)
+	chk, prefixOk := bech32PrefixChecksum(prefix)
+	if !ok || !prefixOk {
+		_, bz, err := bech32.DecodeAndConvert(addr)
+		if err != nil {
+			return dst, fmt.Errorf("decode '%s': %w", addr, err)
+		}
+		if err := sdk.VerifyAddressFormat(bz); err != nil {
+			return dst, fmt.Errorf("address '%s': %w", addr, err)
+		}
+		s, err := bech32.ConvertAndEncode(prefix, bz)
+		if err != nil {
+			return dst, fmt.Errorf("encode '%s' with prefix %s: %w", addr, prefix, err)
+		}
+		return append(dst, s...), nil
+	}
+	for i := 0; i < len(data); i++ {
+		chk = bech32Polymod(chk, byte(bech32CharsetRev[data[i]]))
+	}
+	for range bech32ChecksumLen {
+		chk = bech32Polymod(chk, 0)
+	}
+	chk ^= 1
+	dst = append(dst, prefix...)
+	dst = append(dst, '1')
+	dst = append(dst, data...)
+	for i := range bech32ChecksumLen {
+		dst = append(dst, bech32Charset[(chk>>(5*(bech32ChecksumLen-1-i)))&31])
+	}
+	return dst, nil
+}
+
+// convertBech32Batch returns addrs encoded with prefix, whatever their
+// prefixes. The results share a single allocation.
+func convertBech32Batch(addrs []string, prefix string) ([]string, error) {
+	// A converted address is at most len(prefix) longer than the address,
+	// since its prefix has at least 1 character.
+	size := 0
+	for _, addr := range addrs {
+		size += len(addr) + len(prefix)
+	}
+	var (
+		buf       = make([]byte, 0, size)
+		ends      = make([]int, len(addrs))
+		converted = make([]string, len(addrs))
+		err       error
+	)
+	for i, addr := range addrs {
+		if buf, err = appendBech32Prefix(buf, addr, prefix); err != nil {
+			return nil, err
+		}
+		ends[i] = len(buf)
+	}
+	// buf isn't written anymore, so the results can point into it.
+	all := unsafe.String(unsafe.SliceData(buf), len(buf))
+	start := 0
+	for i, end := range ends {
+		converted[i] = all[start:end]
+		start = end
+	}
+	return converted, nil
+}
+
+// bech32CacheChunk is the size of the chunks holding the addresses converted
+// by a bech32Cache.
+const bech32CacheChunk = 64 << 10
+
+// bech32Cache converts addresses to a prefix, and keeps the results so each
+// address is converted once by the runs over the same accounts. The results
+// are stored in shared chunks, so they don't allocate per address.
+type bech32Cache struct {
+	prefix    string
+	converted map[string]string
+	// chunk is the current chunk, only appended to, so the converted
+	// addresses can point into it.
+	chunk []byte
+}
+
+func newBech32Cache(prefix string) *bech32Cache {
+	return &bech32Cache{prefix: prefix, converted: make(map[string]string)}
+}
+
+// convert returns addr encoded with the prefix of c.
+func (c *bech32Cache) convert(addr string) (string, error) {
+	if s, ok := c.converted[addr]; ok {
+		return s, nil
+	}
+	if n := len(addr) + len(c.prefix); cap(c.chunk)-len(c.chunk) < n {
+		c.chunk = make([]byte, 0, max(bech32CacheChunk, n))
+	}
+	start := len(c.chunk)
+	chunk, err := appendBech32Prefix(c.chunk, addr, c.prefix)
+	if err != nil {
+		return "", err
+	}
+	c.chunk = chunk
+	s := unsafe.String(&chunk[start], len(chunk)-start)
+	c.converted[addr] = s
+	return s, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+func TestAppendBech32Prefix(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var addrs []string
+	for i, addr := range createAccountAddrs(10) {
+		addrs = append(addrs, addr.String())
+		// 32 bytes addresses of the module and interchain accounts
+		addrs = append(addrs, sdk.MustBech32ifyAddressBytes("atone", append(addr.Bytes(), make([]byte, 12)...)))
+		// addresses of any length
+		addrs = append(addrs, sdk.MustBech32ifyAddressBytes("a", addr.Bytes()[:i+1]))
+	}
+	// upper case addresses go through the sdk
+	addrs = append(addrs, strings.ToUpper(addrs[0]))
+
+	for _, addr := range addrs {
+		_, bz, err := bech32.DecodeAndConvert(addr)
+		require.NoError(err)
+		for _, prefix := range []string{"cosmos", "atone", "a", "cosmosvaloper", "ATONE"} {
+			expected, err := bech32.ConvertAndEncode(prefix, bz)
+			require.NoError(err)
+
+			converted, err := appendBech32Prefix(nil, addr, prefix)
+
+			require.NoError(err)
+			assert.Equal(expected, string(converted), "%s to %s", addr, prefix)
+		}
+	}
+
+	t.Run("invalid addresses", func(t *testing.T) {
+		addr := addrs[0]
+		next := bech32Charset[(strings.IndexByte(bech32Charset, addr[len(addr)-1])+1)%len(bech32Charset)]
+		for _, invalid := range []string{
+			"",
+			"cosmos1invalid",
+			addr[:len(addr)-1] + string(next), // checksum
+			strings.Replace(addr, "cosmos", "atone", 1), // checksum of the prefix
+			"Cosmos" + addr[len("cosmos"):],             // mixed case
+			sdk.MustBech32ifyAddressBytes("cosmos", nil),
+			sdk.MustBech32ifyAddressBytes("cosmos", make([]byte, 256)),
+		} {
+			_, err := appendBech32Prefix(nil, invalid, "atone")
+			assert.Error(err, invalid)
+		}
+		_, err := appendBech32Prefix(nil, "cosmos1invalid", "atone")
+		assert.ErrorContains(err, "decode 'cosmos1invalid'")
+	})
+
+	t.Run("no allocation", func(t *testing.T) {
+		dst := make([]byte, 0, 64)
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _ = appendBech32Prefix(dst, addrs[0], "atone")
+		})
+		assert.Zero(allocs)
+	})
+}
+
+func TestConvertBech32(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	addr := createAccountAddrs(1)[0]
+
+	converted, err := convertBech32(addr.String(), "cosmos", "atone")
+
+	require.NoError(err)
+	assert.Equal(sdk.MustBech32ifyAddressBytes("atone", addr), converted)
+	_, err = convertBech32(addr.String(), "atone", "cosmos")
+	assert.ErrorContains(err, "expected prefix atone")
+}
+
+func TestConvertBech32Batch(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	addrs := createAccountAddrs(3)
+	batch := []string{addrs[0].String(), sdk.MustBech32ifyAddressBytes("atone", addrs[1]), addrs[2].String()}
+
+	converted, err := convertBech32Batch(batch, "atone")
+
+	require.NoError(err)
+	assert.Equal([]string{
+		sdk.MustBech32ifyAddressBytes("atone", addrs[0]),
+		sdk.MustBech32ifyAddressBytes("atone", addrs[1]),
+		sdk.MustBech32ifyAddressBytes("atone", addrs[2]),
+	}, converted)
+	_, err = convertBech32Batch(append(batch, "invalid"), "atone")
+	assert.ErrorContains(err, "decode 'invalid'")
+}
+
+func TestBech32Cache(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	addrs := createAccountAddrs(2)
+	c := newBech32Cache("atone")
+
+	s1, err := c.convert(addrs[0].String())
+	require.NoError(err)
+	s2, err := c.convert(addrs[1].String())
+	require.NoError(err)
+
+	assert.Equal(sdk.MustBech32ifyAddressBytes("atone", addrs[0]), s1)
+	assert.Equal(sdk.MustBech32ifyAddressBytes("atone", addrs[1]), s2)
+	addr := addrs[0].String()
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = c.convert(addr)
+	})
+	assert.Zero(allocs)
+	_, err = c.convert("invalid")
+	assert.Error(err)
+	assert.Len(c.converted, 2)
+}
+
+func BenchmarkConvertBech32(b *testing.B) {
+	var addrs []string
+	for _, addr := range createAccountAddrs(1000) {
+		addrs = append(addrs, addr.String())
+	}
+	b.Run("sdk", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := range b.N {
+			key, err := newAddrKey(addrs[i%len(addrs)])
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = key.bech32("atone")
+		}
+	})
+	b.Run("append", func(b *testing.B) {
+		b.ReportAllocs()
+		dst := make([]byte, 0, 64)
+		for i := range b.N {
+			if _, err := appendBech32Prefix(dst, addrs[i%len(addrs)], "atone"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cache", func(b *testing.B) {
+		b.ReportAllocs()
+		c := newBech32Cache("atone")
+		for i := range b.N {
+			if _, err := c.convert(addrs[i%len(addrs)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		b.ReportAllocs()
+		// an op is the conversion of an address, as in the other benchmarks
+		for range b.N/len(addrs) + 1 {
+			if _, err := convertBech32Batch(addrs, "atone"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
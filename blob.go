@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/atomone-hub/govbox/airdropblob"
+)
+
+// writeAirdropBlob writes addresses into file using the compact airdropblob
+// format.
+func writeAirdropBlob(ctx context.Context, file, denom string, addresses map[string]sdk.Int) error {
+	blob := airdropblob.Airdrop{Denom: denom}
+	for addr, amt := range addresses {
+		if !amt.IsUint64() {
+			return fmt.Errorf("amount %s of address %s doesn't fit in uint64", amt, addr)
+		}
+		blob.Entries = append(blob.Entries, airdropblob.Entry{
+			Address: addr,
+			Amount:  amt.Uint64(),
+		})
+	}
+	return writeOutputFile(ctx, file, func(w io.Writer) error {
+		if err := airdropblob.Write(w, blob); err != nil {
+			return fmt.Errorf("write blob %s: %w", file, err)
+		}
+		return nil
+	})
+}
+
+// writeAirdropResult writes the protobuf encoding of the airdrop result into
+// file, see airdropblob.Result.
+func writeAirdropResult(ctx context.Context, file, denom string, airdrop airdrop) error {
+	res, err := newAirdropResult(denom, airdrop)
+	if err != nil {
+		return err
+	}
+	return writeFile(ctx, file, airdropblob.MarshalResult(res))
+}
+
+// newAirdropResult returns the params, aggregates and amounts of airdrop.
+func newAirdropResult(denom string, airdrop airdrop) (airdropblob.Result, error) {
+	res := airdropblob.Result{
+		Denom: denom,
+		Params: airdropblob.Params{
+			YesVotesMultiplier: airdrop.params.yesVotesMultiplier.String(),
+			NoVotesMultiplier:  airdrop.params.noVotesMultiplier.String(),
+			Bonus:              airdrop.params.bonus.String(),
+			Malus:              airdrop.params.malus.String(),
+			SupplyFactor:       airdrop.params.supplyFactor.String(),
+			SupplyMintFactor:   airdrop.params.supplyMintFactor.String(),
+		},
+		Aggregates: airdropblob.Aggregates{
+			AtomSupply:          airdrop.atom.supply.String(),
+			AtoneSupply:         airdrop.atone.supply.String(),
+			NonVotersMultiplier: airdrop.nonVotersMultiplier.String(),
+			CommunityPool:       airdrop.communityPool.String(),
+			ReservedAddress:     airdrop.reservedAddr.String(),
+			AtoneVotes:          make(map[string]string, len(airdrop.atone.votes)),
+		},
+	}
+	if airdrop.params.hasStakingIncentives() {
+		res.Aggregates.StakingIncentives = airdrop.stakingIncentives.String()
+	}
+	for opt, amt := range airdrop.atone.votes {
+		res.Aggregates.AtoneVotes[opt.String()] = amt.String()
+	}
+	for addr, amt := range airdrop.addresses {
+		if !amt.IsUint64() {
+			return airdropblob.Result{}, fmt.Errorf("amount %s of address %s doesn't fit in uint64", amt, addr)
+		}
+		res.Entries = append(res.Entries, airdropblob.Entry{
+			Address: addr,
+			Amount:  amt.Uint64(),
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/atomone-hub/atomone/cmd/atomoned/cmd"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// parseGentxs returns the MsgCreateValidator of all the gentx files of dir.
+func parseGentxs(dir string) ([]*stakingtypes.MsgCreateValidator, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var msgs []*stakingtypes.MsgCreateValidator
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		var tx txtypes.Tx
+		err = unmarshaler.Unmarshal(f, &tx)
+		f.Close()
+		if err == nil {
+			// set the cached values of the messages
+			err = tx.UnpackInterfaces(registry)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal gentx %s: %w", file, err)
+		}
+		if len(tx.Body.Messages) != 1 {
+			return nil, fmt.Errorf("gentx %s: expected 1 message, got %d", file, len(tx.Body.Messages))
+		}
+		msg, ok := tx.Body.Messages[0].GetCachedValue().(*stakingtypes.MsgCreateValidator)
+		if !ok {
+			return nil, fmt.Errorf("gentx %s: expected a MsgCreateValidator, got %s", file, tx.Body.Messages[0].TypeUrl)
+		}
+		if err := msg.ValidateBasic(); err != nil {
+			return nil, fmt.Errorf("gentx %s: %w", file, err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// loadGentxs returns the MsgCreateValidator of the gentxs of dir, none if dir
+// is empty. The sdk config is set to the AtomOne prefixes of their addresses.
+func loadGentxs(dir string) ([]*stakingtypes.MsgCreateValidator, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	cmd.InitSDKConfig()
+	return parseGentxs(dir)
+}
+
+// bootstrapValidators adds the validators created by msgs in stakingGen.
+// The self-delegations are deducted from the validator balances of bankGen
+// and moved to the not-bonded pool; the validators are created unbonded and
+// will be bonded by the staking module during InitChain, which keeps the
+// staking pools invariants.
+func bootstrapValidators(
+	msgs []*stakingtypes.MsgCreateValidator, bankGen *banktypes.GenesisState,
+	stakingGen *stakingtypes.GenesisState, genesisTime time.Time, prefix string,
+) error {
+	balanceIdx := make(map[string]int, len(bankGen.Balances))
+	for i, b := range bankGen.Balances {
+		balanceIdx[b.Address] = i
+	}
+	var (
+		bondDenom       = stakingGen.Params.BondDenom
+		notBondedTokens = sdk.ZeroInt()
+	)
+	for _, msg := range msgs {
+		if msg.Value.Denom != bondDenom {
+			return fmt.Errorf("validator %s: self-delegation denom %s doesn't match bond denom %s",
+				msg.ValidatorAddress, msg.Value.Denom, bondDenom)
+		}
+		i, ok := balanceIdx[msg.DelegatorAddress]
+		if !ok {
+			return fmt.Errorf("validator %s: delegator %s has no balance in the airdrop",
+				msg.ValidatorAddress, msg.DelegatorAddress)
+		}
+		balance := bankGen.Balances[i].Coins
+		remaining, neg := balance.SafeSub(msg.Value)
+		if neg {
+			return fmt.Errorf("validator %s: delegator %s balance %s is lower than the self-delegation %s",
+				msg.ValidatorAddress, msg.DelegatorAddress, balance, msg.Value)
+		}
+		bankGen.Balances[i].Coins = remaining
+
+		valAddr, err := sdk.ValAddressFromBech32(msg.ValidatorAddress)
+		if err != nil {
+			return err
+		}
+		val := stakingtypes.Validator{
+			OperatorAddress: msg.ValidatorAddress,
+			ConsensusPubkey: msg.Pubkey,
+			Status:          stakingtypes.Unbonded,
+			Tokens:          sdk.ZeroInt(),
+			DelegatorShares: sdk.ZeroDec(),
+			Description:     msg.Description,
+			UnbondingTime:   time.Unix(0, 0).UTC(),
+		}
+		val.Commission = stakingtypes.NewCommissionWithTime(msg.Commission.Rate,
+			msg.Commission.MaxRate, msg.Commission.MaxChangeRate, genesisTime)
+		val.MinSelfDelegation = msg.MinSelfDelegation
+		val, shares := val.AddTokensFromDel(msg.Value.Amount)
+		stakingGen.Validators = append(stakingGen.Validators, val)
+		stakingGen.Delegations = append(stakingGen.Delegations,
+			stakingtypes.NewDelegation(sdk.MustAccAddressFromBech32(msg.DelegatorAddress), valAddr, shares))
+		notBondedTokens = notBondedTokens.Add(msg.Value.Amount)
+	}
+	if notBondedTokens.IsZero() {
+		return nil
+	}
+	// Move the self-delegations to the not-bonded pool
+	notBondedPoolAddr := sdk.MustBech32ifyAddressBytes(prefix, authtypes.NewModuleAddress(stakingtypes.NotBondedPoolName))
+	bankGen.Balances = append(bankGen.Balances, banktypes.Balance{
+		Address: notBondedPoolAddr,
+		Coins:   sdk.NewCoins(sdk.NewCoin(bondDenom, notBondedTokens)),
+	})
+	stakingGen.Exported = false
+	return nil
+}
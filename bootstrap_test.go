@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+func TestBootstrapValidators(t *testing.T) {
+	var (
+		pk      = ed25519.GenPrivKey().PubKey()
+		accAddr = sdk.AccAddress(pk.Address())
+		valAddr = sdk.ValAddress(pk.Address())
+		now     = time.Now().UTC()
+	)
+	newMsg := func(amt int64) *stakingtypes.MsgCreateValidator {
+		msg, err := stakingtypes.NewMsgCreateValidator(valAddr, pk,
+			sdk.NewInt64Coin("uatone", amt), stakingtypes.NewDescription("val", "", "", "", ""),
+			stakingtypes.NewCommissionRates(sdk.NewDecWithPrec(1, 1), sdk.NewDecWithPrec(2, 1), sdk.NewDecWithPrec(1, 2)),
+			sdk.OneInt())
+		require.NoError(t, err)
+		return msg
+	}
+	newGenesis := func() (*banktypes.GenesisState, *stakingtypes.GenesisState) {
+		bankGen := &banktypes.GenesisState{
+			Balances: []banktypes.Balance{
+				{Address: accAddr.String(), Coins: sdk.NewCoins(sdk.NewInt64Coin("uatone", 100))},
+			},
+			Supply: sdk.NewCoins(sdk.NewInt64Coin("uatone", 100)),
+		}
+		stakingGen := stakingtypes.DefaultGenesisState()
+		stakingGen.Params.BondDenom = "uatone"
+		stakingGen.Exported = true
+		return bankGen, stakingGen
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		bankGen, stakingGen := newGenesis()
+
+		err := bootstrapValidators([]*stakingtypes.MsgCreateValidator{newMsg(60)}, bankGen, stakingGen, now, "atone")
+
+		require.NoError(t, err)
+		assert.False(t, stakingGen.Exported)
+		require.Len(t, stakingGen.Validators, 1)
+		val := stakingGen.Validators[0]
+		assert.Equal(t, valAddr.String(), val.OperatorAddress)
+		assert.Equal(t, stakingtypes.Unbonded, val.Status)
+		assert.Equal(t, sdk.NewInt(60), val.Tokens)
+		assert.Equal(t, now, val.Commission.UpdateTime)
+		require.Len(t, stakingGen.Delegations, 1)
+		assert.Equal(t, sdk.NewDec(60), stakingGen.Delegations[0].Shares)
+		require.Len(t, bankGen.Balances, 2)
+		assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uatone", 40)), bankGen.Balances[0].Coins)
+		assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uatone", 60)), bankGen.Balances[1].Coins)
+		// supply is unchanged
+		assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uatone", 100)), bankGen.Supply)
+	})
+	t.Run("insufficient balance", func(t *testing.T) {
+		bankGen, stakingGen := newGenesis()
+
+		err := bootstrapValidators([]*stakingtypes.MsgCreateValidator{newMsg(101)}, bankGen, stakingGen, now, "atone")
+
+		assert.ErrorContains(t, err, "is lower than the self-delegation")
+	})
+}
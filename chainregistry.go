@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// defaultChainRegistry is the location of the cosmos chain-registry, which
+// can be replaced by a local clone.
+const defaultChainRegistry = "https://raw.githubusercontent.com/cosmos/chain-registry/master"
+
+// registryChain is the part of the chain.json file of the chain-registry
+// used to configure the genesis.
+type registryChain struct {
+	ChainName    string `json:"chain_name"`
+	Bech32Prefix string `json:"bech32_prefix"`
+	Staking      struct {
+		StakingTokens []struct {
+			Denom string `json:"denom"`
+		} `json:"staking_tokens"`
+	} `json:"staking"`
+}
+
+// registryAsset is an asset of the assetlist.json file of the
+// chain-registry.
+type registryAsset struct {
+	Description string `json:"description"`
+	DenomUnits  []struct {
+		Denom    string   `json:"denom"`
+		Exponent uint32   `json:"exponent"`
+		Aliases  []string `json:"aliases"`
+	} `json:"denom_units"`
+	Base    string `json:"base"`
+	Name    string `json:"name"`
+	Display string `json:"display"`
+	Symbol  string `json:"symbol"`
+}
+
+// chainConfig is the configuration of a chain read from the chain-registry.
+type chainConfig struct {
+	// name is the chain name of the chain-registry, which prefixes its chain
+	// ids.
+	name   string
+	prefix string
+	// stakingDenom is the base denom of the staking token.
+	stakingDenom string
+	// assets are the denom metadata of the chain assets, by base denom.
+	assets map[string]banktypes.Metadata
+}
+
+// loadChainConfig reads the chain.json and assetlist.json files of chain from
+// registry, which is either a http(s) URL or a local directory with the
+// chain-registry layout (<registry>/<chain>/chain.json).
+func loadChainConfig(ctx context.Context, registry, chain string) (chainConfig, error) {
+	var c registryChain
+	if err := readRegistryFile(ctx, registry, chain, "chain.json", &c); err != nil {
+		return chainConfig{}, err
+	}
+	if c.Bech32Prefix == "" {
+		return chainConfig{}, fmt.Errorf("chain-registry %s: no bech32_prefix", chain)
+	}
+	if len(c.Staking.StakingTokens) == 0 {
+		return chainConfig{}, fmt.Errorf("chain-registry %s: no staking token", chain)
+	}
+	var assetList struct {
+		Assets []registryAsset `json:"assets"`
+	}
+	if err := readRegistryFile(ctx, registry, chain, "assetlist.json", &assetList); err != nil {
+		return chainConfig{}, err
+	}
+	if c.ChainName == "" {
+		c.ChainName = chain
+	}
+	cfg := chainConfig{
+		name:         c.ChainName,
+		prefix:       c.Bech32Prefix,
+		stakingDenom: c.Staking.StakingTokens[0].Denom,
+		assets:       make(map[string]banktypes.Metadata),
+	}
+	for _, a := range assetList.Assets {
+		cfg.assets[a.Base] = a.metadata()
+	}
+	if _, ok := cfg.assets[cfg.stakingDenom]; !ok {
+		return chainConfig{}, fmt.Errorf("chain-registry %s: staking token %s not in the assetlist", chain, cfg.stakingDenom)
+	}
+	return cfg, nil
+}
+
+func readRegistryFile(ctx context.Context, registry, chain, file string, v any) error {
+	var r io.Reader
+	src := registry + "/" + chain + "/" + file
+	if strings.HasPrefix(registry, "http://") || strings.HasPrefix(registry, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("get %s: %s", src, resp.Status)
+		}
+		r = resp.Body
+	} else {
+		src = filepath.Join(registry, chain, file)
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("cannot json decode %s: %w", src, err)
+	}
+	return nil
+}
+
+func (a registryAsset) metadata() banktypes.Metadata {
+	m := banktypes.Metadata{
+		Description: a.Description,
+		Base:        a.Base,
+		Display:     a.Display,
+		Name:        a.Name,
+		Symbol:      a.Symbol,
+	}
+	for _, u := range a.DenomUnits {
+		m.DenomUnits = append(m.DenomUnits, &banktypes.DenomUnit{
+			Denom:    u.Denom,
+			Exponent: u.Exponent,
+			Aliases:  u.Aliases,
+		})
+	}
+	return m
+}
+
+// displayExponent returns the exponent of the display denom unit of m.
+func displayExponent(m banktypes.Metadata) (uint32, bool) {
+	for _, u := range m.DenomUnits {
+		if u.Denom == m.Display {
+			return u.Exponent, true
+		}
+	}
+	return 0, false
+}
+
+// apply configures denoms with the metadata of the chain assets: the first
+// denom, the staking token, gets the metadata of the staking asset, the others
+// the metadata of the asset with the same display denom, if any. The display
+// exponent must be 6 because the airdrop amounts are computed in micro units.
+func (c chainConfig) apply(denoms []genesisDenom) ([]genesisDenom, error) {
+	denoms = slices.Clone(denoms)
+	for i, d := range denoms {
+		meta, ok := c.assets[c.stakingDenom], i == 0
+		if i > 0 {
+			for _, m := range c.assets {
+				if m.Display == d.ticker {
+					meta, ok = m, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		if exp, ok := displayExponent(meta); !ok || exp != 6 {
+			return nil, fmt.Errorf("chain-registry asset %s: display exponent must be 6", meta.Base)
+		}
+		denoms[i].meta = &meta
+	}
+	return denoms, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testChainJSON = `{
+  "chain_name": "atomone",
+  "bech32_prefix": "atone",
+  "staking": {"staking_tokens": [{"denom": "uatone"}]}
+}`
+	testAssetListJSON = `{
+  "chain_name": "atomone",
+  "assets": [
+    {
+      "description": "The native staking token of AtomOne Hub",
+      "denom_units": [{"denom": "uatone", "exponent": 0}, {"denom": "atone", "exponent": 6}],
+      "base": "uatone",
+      "name": "AtomOne",
+      "display": "atone",
+      "symbol": "ATONE"
+    },
+    {
+      "description": "The fee token of AtomOne Hub",
+      "denom_units": [{"denom": "uphoton", "exponent": 0}, {"denom": "photon", "exponent": 6}],
+      "base": "uphoton",
+      "name": "Photon",
+      "display": "photon",
+      "symbol": "PHOTON"
+    }
+  ]
+}`
+)
+
+func TestLoadChainConfig(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	registry := t.TempDir()
+	require.NoError(os.Mkdir(filepath.Join(registry, "atomone"), 0o700))
+	require.NoError(os.WriteFile(filepath.Join(registry, "atomone", "chain.json"), []byte(testChainJSON), 0o600))
+	require.NoError(os.WriteFile(filepath.Join(registry, "atomone", "assetlist.json"), []byte(testAssetListJSON), 0o600))
+	srv := httptest.NewServer(http.FileServer(http.Dir(registry)))
+	defer srv.Close()
+
+	for _, reg := range []string{registry, srv.URL} {
+		c, err := loadChainConfig(context.Background(), reg, "atomone")
+
+		require.NoError(err)
+		assert.Equal("atomone", c.name)
+		assert.Equal("atone", c.prefix)
+		assert.Equal("uatone", c.stakingDenom)
+		assert.Len(c.assets, 2)
+	}
+
+	_, err := loadChainConfig(context.Background(), registry, "unknown")
+	assert.Error(err)
+	_, err = loadChainConfig(context.Background(), srv.URL, "unknown")
+	assert.ErrorContains(err, "404")
+}
+
+func TestChainConfigApply(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	registry := t.TempDir()
+	require.NoError(os.Mkdir(filepath.Join(registry, "atomone"), 0o700))
+	require.NoError(os.WriteFile(filepath.Join(registry, "atomone", "chain.json"), []byte(testChainJSON), 0o600))
+	require.NoError(os.WriteFile(filepath.Join(registry, "atomone", "assetlist.json"), []byte(testAssetListJSON), 0o600))
+	c, err := loadChainConfig(context.Background(), registry, "atomone")
+	require.NoError(err)
+	denoms := []genesisDenom{
+		{ticker: "govgen"},
+		{ticker: "photon"},
+		{ticker: "other"},
+	}
+
+	denoms, err = c.apply(denoms)
+
+	require.NoError(err)
+	assert.Equal("uatone", denoms[0].base())
+	assert.Equal("ATONE", denoms[0].metadata().Symbol)
+	assert.Len(denoms[0].metadata().DenomUnits, 2)
+	assert.Equal("uphoton", denoms[1].base())
+	assert.Equal("Photon", denoms[1].metadata().Name)
+	// not in the chain-registry, derived from the ticker
+	assert.Equal("uother", denoms[2].base())
+	assert.Len(denoms[2].metadata().DenomUnits, 3)
+
+	c.assets["uatone"].DenomUnits[1].Exponent = 18
+	_, err = c.apply(denoms)
+	assert.ErrorContains(err, "display exponent must be 6")
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+	"github.com/go-echarts/go-echarts/v2/components"
+)
+
+// chartExport defines how the charts are exported. The zero value renders
+// the charts with the default theme in a HTML page opened in the browser (in
+// an interactive session).
+type chartExport struct {
+	// format is the image format of the exported charts (png or svg).
+	format string
+	// dir is the directory where the images are written.
+	dir string
+	// theme is the labels and colors of the charts.
+	theme chartTheme
+}
+
+func (c chartExport) validate() error {
+	switch c.format {
+	case "", "png", "svg":
+		return nil
+	}
+	return fmt.Errorf("unsupported chart export format '%s' (expected png or svg)", c.format)
+}
+
+// renderPage renders page in a temporary HTML file and opens it in the
+// browser (see openBrowser), or exports each of its charts as an image if
+// export.format is set.
+func renderPage(ctx context.Context, page *components.Page, export chartExport) error {
+	f, err := os.CreateTemp("", "chart*.html")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := page.Render(f); err != nil {
+		return err
+	}
+	fmt.Printf("Charts rendered in %s\n", f.Name())
+	if export.format == "" {
+		openBrowser(f.Name())
+		return nil
+	}
+	files, err := exportCharts(ctx, f.Name(), export)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		fmt.Printf("Chart exported in %s\n", file)
+	}
+	return nil
+}
+
+// exportChartsJS re-initializes each chart of the page with the requested
+// renderer and without animation, and returns their title and content (SVG
+// markup or PNG data URL).
+const exportChartsJS = `(() => {
+	const res = [];
+	document.querySelectorAll('.item').forEach(el => {
+		const inst = echarts.getInstanceByDom(el);
+		const opt = inst.getOption();
+		opt.animation = false;
+		inst.dispose();
+		const chart = echarts.init(el, 'white', {renderer: '%[1]s'});
+		chart.setOption(opt);
+		const title = (opt.title && opt.title.length > 0) ? opt.title[0].text : '';
+		if ('%[1]s' === 'svg') {
+			res.push({title: title, data: el.querySelector('svg').outerHTML});
+		} else {
+			res.push({title: title, data: chart.getDataURL({type: 'png', pixelRatio: 2, backgroundColor: '#fff'})});
+		}
+	});
+	return res;
+})()`
+
+var nonAlnumRegexp = regexp.MustCompile("[^a-z0-9]+")
+
+// chartImageFile returns the file of the i-th chart of a page, named after
+// its title, in export.dir.
+func chartImageFile(export chartExport, i int, title string) string {
+	name := strings.Trim(nonAlnumRegexp.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	return filepath.Join(export.dir, fmt.Sprintf("%02d-%s.%s", i+1, name, export.format))
+}
+
+// exportCharts renders the charts of htmlFile in a headless browser and
+// writes them as images in export.dir.
+func exportCharts(ctx context.Context, htmlFile string, export chartExport) ([]string, error) {
+	abs, err := filepath.Abs(htmlFile)
+	if err != nil {
+		return nil, err
+	}
+	renderer := "canvas"
+	if export.format == "svg" {
+		renderer = "svg"
+	}
+	ctx, cancel := chromedp.NewContext(ctx)
+	defer cancel()
+	var charts []struct {
+		Title string `json:"title"`
+		Data  string `json:"data"`
+	}
+	err = chromedp.Run(ctx,
+		chromedp.Navigate("file://"+abs),
+		chromedp.Evaluate(fmt.Sprintf(exportChartsJS, renderer), &charts),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("export charts: %w", err)
+	}
+	if err := os.MkdirAll(export.dir, 0o755); err != nil {
+		return nil, err
+	}
+	var files []string
+	for i, c := range charts {
+		file := chartImageFile(export, i, c.Title)
+		var bz []byte
+		if export.format == "svg" {
+			bz = []byte(c.Data)
+		} else {
+			bz, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(c.Data, "data:image/png;base64,"))
+			if err != nil {
+				return nil, fmt.Errorf("decode png of chart '%s': %w", c.Title, err)
+			}
+		}
+		if err := writeFile(ctx, file, bz); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
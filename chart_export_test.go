@@ -0,0 +1,21 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChartExport(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, format := range []string{"", "png", "svg"} {
+		assert.NoError(chartExport{format: format}.validate(), format)
+	}
+	assert.EqualError(chartExport{format: "jpg"}.validate(), "unsupported chart export format 'jpg' (expected png or svg)")
+
+	export := chartExport{format: "png", dir: "charts"}
+	assert.Equal(filepath.Join("charts", "01-atone-distribution-yes-x1-1.png"),
+		chartImageFile(export, 0, "$ATONE distribution (Yes: x1.1)"))
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// Categories of the vote charts.
+const (
+	chartYes      = "yes"
+	chartNo       = "no"
+	chartNWV      = "nwv"
+	chartAbstain  = "abstain"
+	chartDNV      = "dnv"
+	chartUnstaked = "unstaked"
+)
+
+// chartCategories are the categories of the vote charts, in display order.
+var chartCategories = []string{chartYes, chartNo, chartNWV, chartAbstain, chartDNV, chartUnstaked}
+
+// chartStyle is the label and color of a category in the charts, the empty
+// fields keep their default value.
+type chartStyle struct {
+	Label string `json:"label,omitempty"`
+	Color string `json:"color,omitempty"`
+}
+
+// chartGroup is a group of categories displayed as a single one.
+type chartGroup struct {
+	Label      string   `json:"label"`
+	Color      string   `json:"color,omitempty"`
+	Categories []string `json:"categories"`
+}
+
+// chartTheme configures the labels and colors of the charts, for instance:
+//
+//	{
+//	  "categories": {"yes": {"label": "Approve", "color": "#2b2d42"}},
+//	  "merge": [{"label": "No", "color": "#8d99ae", "categories": ["no", "nwv"]}],
+//	  "palette": ["#2b2d42", "#8d99ae", "#edf2f4"]
+//	}
+//
+// The zero value is the default theme.
+type chartTheme struct {
+	// Categories overrides the style of the vote categories.
+	Categories map[string]chartStyle `json:"categories,omitempty"`
+	// Merge are the categories displayed as a single one in all the vote
+	// charts, in place of the first of them.
+	Merge []chartGroup `json:"merge,omitempty"`
+	// Summary are the groups of the inner ring of the pie charts.
+	Summary []chartGroup `json:"summary,omitempty"`
+	// Palette colors the series of the charts without categories.
+	Palette []string `json:"palette,omitempty"`
+}
+
+var (
+	// defaultChartStyles are the styles of the vote categories of the default
+	// theme.
+	defaultChartStyles = map[string]chartStyle{
+		chartYes:      {Label: "Yes", Color: "#ff8b87"},
+		chartNo:       {Label: "No", Color: "#9FDFBF"},
+		chartNWV:      {Label: "NWV", Color: "#88d8b0"},
+		chartAbstain:  {Label: "Abstain", Color: "#eac086"},
+		chartDNV:      {Label: "DNV", Color: "#ffcd94"},
+		chartUnstaked: {Label: "Unstaked", Color: "#ffe0bd"},
+	}
+	// defaultChartSummary is the inner ring of the pie charts of the default
+	// theme.
+	defaultChartSummary = []chartGroup{
+		{Label: "Yes", Color: "#ff8b87", Categories: []string{chartYes}},
+		{Label: "No+NWV", Color: "#6cac8c", Categories: []string{chartNo, chartNWV}},
+		{Label: "Non voters", Color: "#ffad60", Categories: []string{chartAbstain, chartDNV, chartUnstaked}},
+	}
+)
+
+// parseChartTheme reads a chartTheme from a JSON file.
+func parseChartTheme(path string) (chartTheme, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return chartTheme{}, err
+	}
+	defer f.Close()
+	var t chartTheme
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&t); err != nil {
+		return chartTheme{}, fmt.Errorf("cannot json decode chart theme from file %s: %w", path, err)
+	}
+	if err := t.validate(); err != nil {
+		return chartTheme{}, fmt.Errorf("chart theme %s: %w", path, err)
+	}
+	return t, nil
+}
+
+func (t chartTheme) validate() error {
+	for cat := range t.Categories {
+		if !slices.Contains(chartCategories, cat) {
+			return fmt.Errorf("unknown category '%s'", cat)
+		}
+	}
+	merged := make(map[string]bool)
+	for _, g := range t.Merge {
+		if len(g.Categories) < 2 {
+			return fmt.Errorf("merge '%s' must have at least 2 categories", g.Label)
+		}
+		for _, cat := range g.Categories {
+			if !slices.Contains(chartCategories, cat) {
+				return fmt.Errorf("merge '%s': unknown category '%s'", g.Label, cat)
+			}
+			if merged[cat] {
+				return fmt.Errorf("category '%s' is merged twice", cat)
+			}
+			merged[cat] = true
+		}
+	}
+	for _, g := range t.Summary {
+		for _, cat := range g.Categories {
+			if !slices.Contains(chartCategories, cat) {
+				return fmt.Errorf("summary '%s': unknown category '%s'", g.Label, cat)
+			}
+		}
+	}
+	return nil
+}
+
+// style returns the style of the category cat.
+func (t chartTheme) style(cat string) chartStyle {
+	s, d := t.Categories[cat], defaultChartStyles[cat]
+	if s.Label == "" {
+		s.Label = d.Label
+	}
+	if s.Color == "" {
+		s.Color = d.Color
+	}
+	return s
+}
+
+// series returns the series of the vote charts in display order: each
+// category, except the merged ones which are replaced by their group.
+func (t chartTheme) series() []chartGroup {
+	var series []chartGroup
+	for _, cat := range chartCategories {
+		i := slices.IndexFunc(t.Merge, func(g chartGroup) bool { return slices.Contains(g.Categories, cat) })
+		switch {
+		case i < 0:
+			s := t.style(cat)
+			series = append(series, chartGroup{Label: s.Label, Color: s.Color, Categories: []string{cat}})
+		case t.Merge[i].Categories[0] == cat:
+			g := t.Merge[i]
+			if g.Color == "" {
+				g.Color = t.style(cat).Color
+			}
+			series = append(series, g)
+		}
+	}
+	return series
+}
+
+// summary returns the groups of the inner ring of the pie charts.
+func (t chartTheme) summary() []chartGroup {
+	if t.Summary == nil {
+		return defaultChartSummary
+	}
+	return t.Summary
+}
+
+// globalOpts returns the options applying the palette to a chart.
+func (t chartTheme) globalOpts() []charts.GlobalOpts {
+	if len(t.Palette) == 0 {
+		return nil
+	}
+	return []charts.GlobalOpts{charts.WithColorsOpts(opts.Colors(t.Palette))}
+}
+
+// voteCategory returns the chart category of the vote option o.
+func voteCategory(o govtypes.VoteOption) string {
+	switch o {
+	case govtypes.OptionYes:
+		return chartYes
+	case govtypes.OptionNo:
+		return chartNo
+	case govtypes.OptionNoWithVeto:
+		return chartNWV
+	case govtypes.OptionAbstain:
+		return chartAbstain
+	default:
+		return chartDNV
+	}
+}
+
+// sum returns the sum of the values of the categories of g.
+func (g chartGroup) sum(values map[string]float64) float64 {
+	var sum float64
+	for _, cat := range g.Categories {
+		sum += values[cat]
+	}
+	return sum
+}
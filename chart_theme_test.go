@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestChartThemeSeries(t *testing.T) {
+	assert := assert.New(t)
+
+	series := chartTheme{}.series()
+
+	var labels []string
+	for _, s := range series {
+		labels = append(labels, s.Label)
+	}
+	assert.Equal([]string{"Yes", "No", "NWV", "Abstain", "DNV", "Unstaked"}, labels)
+	assert.Equal(defaultChartSummary, chartTheme{}.summary())
+
+	theme := chartTheme{
+		Categories: map[string]chartStyle{chartYes: {Label: "Approve"}, chartNWV: {Color: "#000000"}},
+		Merge:      []chartGroup{{Label: "Reject", Categories: []string{chartNWV, chartNo}}},
+	}
+
+	series = theme.series()
+
+	assert.Equal([]chartGroup{
+		{Label: "Approve", Color: "#ff8b87", Categories: []string{chartYes}},
+		// in place of nwv, its first category, with its color
+		{Label: "Reject", Color: "#000000", Categories: []string{chartNWV, chartNo}},
+		{Label: "Abstain", Color: "#eac086", Categories: []string{chartAbstain}},
+		{Label: "DNV", Color: "#ffcd94", Categories: []string{chartDNV}},
+		{Label: "Unstaked", Color: "#ffe0bd", Categories: []string{chartUnstaked}},
+	}, series)
+	values := map[string]float64{chartNo: 10, chartNWV: 5}
+	assert.Equal(15.0, series[1].sum(values))
+}
+
+func TestParseChartTheme(t *testing.T) {
+	tests := []struct {
+		name          string
+		json          string
+		expectedTheme chartTheme
+		expectedError string
+	}{
+		{
+			name: "ok",
+			json: `{"categories":{"yes":{"label":"Approve"}},"merge":[{"label":"No","categories":["no","nwv"]}],"palette":["#111111"]}`,
+			expectedTheme: chartTheme{
+				Categories: map[string]chartStyle{chartYes: {Label: "Approve"}},
+				Merge:      []chartGroup{{Label: "No", Categories: []string{chartNo, chartNWV}}},
+				Palette:    []string{"#111111"},
+			},
+		},
+		{
+			name:          "unknown field",
+			json:          `{"colors":{}}`,
+			expectedError: "unknown field",
+		},
+		{
+			name:          "unknown category",
+			json:          `{"categories":{"veto":{}}}`,
+			expectedError: "unknown category 'veto'",
+		},
+		{
+			name:          "category merged twice",
+			json:          `{"merge":[{"label":"a","categories":["no","nwv"]},{"label":"b","categories":["nwv","abstain"]}]}`,
+			expectedError: "category 'nwv' is merged twice",
+		},
+		{
+			name:          "single category merge",
+			json:          `{"merge":[{"label":"a","categories":["no"]}]}`,
+			expectedError: "at least 2 categories",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "theme.json")
+			require.NoError(t, os.WriteFile(file, []byte(tt.json), 0o600))
+
+			theme, err := parseChartTheme(file)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedTheme, theme)
+		})
+	}
+}
+
+func TestNewPieChartTheme(t *testing.T) {
+	assert := assert.New(t)
+	d := distrib{
+		supply: sdk.NewDec(100),
+		votes: voteMap{
+			govtypes.OptionYes:        sdk.NewDec(40),
+			govtypes.OptionNo:         sdk.NewDec(20),
+			govtypes.OptionNoWithVeto: sdk.NewDec(10),
+			govtypes.OptionAbstain:    sdk.NewDec(5),
+			govtypes.OptionEmpty:      sdk.NewDec(15),
+		},
+		unstaked: sdk.NewDec(10),
+	}
+	theme := chartTheme{Merge: []chartGroup{{Label: "Reject", Categories: []string{chartNo, chartNWV}}}}
+
+	pie := newPieChart("pie", d, theme)
+
+	var values []float64
+	for _, d := range pie.MultiSeries[0].Data.([]opts.PieData) {
+		values = append(values, d.Value.(float64))
+	}
+	assert.Equal([]float64{40, 30, 5, 15, 10}, values)
+	values = nil
+	for _, d := range pie.MultiSeries[1].Data.([]opts.PieData) {
+		values = append(values, d.Value.(float64))
+	}
+	assert.Equal([]float64{40, 30, 30}, values)
+}
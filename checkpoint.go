@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// checkpointConfig configures the periodic checkpointing of a distribution.
+type checkpointConfig struct {
+	// file is where the checkpoints are written, empty disables checkpointing.
+	file string
+	// every is the number of accounts between 2 checkpoints.
+	every int
+	// resume is the checkpoint the distribution resumes from, if not nil.
+	resume *distributionCheckpoint
+}
+
+// distributionCheckpoint holds the accumulated state of a distribution after
+// the processing of the first Processed accounts.
+type distributionCheckpoint struct {
+	Processed int `json:"processed"`
+	// NonVotersMultiplier and AtomSupply depend only on the accounts and the
+	// parameters, they ensure that the checkpoint matches the resumed run.
+	NonVotersMultiplier sdk.Dec            `json:"nonVotersMultiplier"`
+	AtomSupply          sdk.Dec            `json:"atomSupply"`
+	Addresses           map[string]sdk.Int `json:"addresses"`
+	AddressesDetail     []addrAmtDetail    `json:"addressesDetail"`
+	Audit               []auditEntry       `json:"audit"`
+	AtoneVotes          voteMap            `json:"atoneVotes"`
+	AtoneSupply         sdk.Dec            `json:"atoneSupply"`
+	AtoneUnstaked       sdk.Dec            `json:"atoneUnstaked"`
+	AtoneLocked         sdk.Dec            `json:"atoneLocked"`
+	EntitySlashes       map[string]sdk.Dec `json:"entitySlashes"`
+	EntitySlashedAtone  map[string]sdk.Dec `json:"entitySlashedAtone"`
+	EntityCommunityPool sdk.Dec            `json:"entityCommunityPool"`
+	OptOutAccounts      int                `json:"optOutAccounts"`
+	OptOutAtom          sdk.Dec            `json:"optOutAtom"`
+	OptOutAtone         sdk.Dec            `json:"optOutAtone"`
+	VoterFloorAccounts  int                `json:"voterFloorAccounts"`
+	VoterFloorTopUp     sdk.Int            `json:"voterFloorTopUp"`
+	RoundingAccounts    int                `json:"roundingAccounts"`
+	RoundingDown        sdk.Dec            `json:"roundingDown"`
+	RoundingUp          sdk.Dec            `json:"roundingUp"`
+	AddressCapAccounts  int                `json:"addressCapAccounts"`
+	AddressCapExcess    sdk.Dec            `json:"addressCapExcess"`
+	HookExcluded        int                `json:"hookExcluded"`
+	HookExcludedAmt     sdk.Dec            `json:"hookExcludedAmt"`
+	HookAdjusted        int                `json:"hookAdjusted"`
+	HookDelta           sdk.Dec            `json:"hookDelta"`
+	TypeMultiplied      map[string]int     `json:"typeMultiplied"`
+	VoteTimeAccounts    int                `json:"voteTimeAccounts"`
+	VoteTimeBonus       sdk.Dec            `json:"voteTimeBonus"`
+	VoteTimeMax         sdk.Dec            `json:"voteTimeMax"`
+	VoteTimeMin         sdk.Dec            `json:"voteTimeMin"`
+	ValidatorLiquid     int                `json:"validatorLiquid"`
+	ValidatorLiquidAtom sdk.Dec            `json:"validatorLiquidAtom"`
+	ValidatorLiquidDiff sdk.Dec            `json:"validatorLiquidDelta"`
+	Withholdings        []withholdingEntry `json:"withholdings,omitempty"`
+}
+
+func newDistributionCheckpoint(a airdrop, processed int) distributionCheckpoint {
+	return distributionCheckpoint{
+		Processed:           processed,
+		NonVotersMultiplier: a.nonVotersMultiplier,
+		AtomSupply:          a.atom.supply,
+		Addresses:           a.addresses,
+		AddressesDetail:     a.addressesDetail,
+		Audit:               a.audit,
+		AtoneVotes:          a.atone.votes,
+		AtoneSupply:         a.atone.supply,
+		AtoneUnstaked:       a.atone.unstaked,
+		AtoneLocked:         a.atone.locked,
+		EntitySlashes:       a.entitySlashes,
+		EntitySlashedAtone:  a.entitySlashedAtone,
+		EntityCommunityPool: a.entityCommunityPool,
+		OptOutAccounts:      a.optOut.accounts,
+		OptOutAtom:          a.optOut.atom,
+		OptOutAtone:         a.optOut.atone,
+		VoterFloorAccounts:  a.voterFloor.accounts,
+		VoterFloorTopUp:     a.voterFloor.topUp,
+		RoundingAccounts:    a.rounding.accounts,
+		RoundingDown:        a.rounding.down,
+		RoundingUp:          a.rounding.up,
+		AddressCapAccounts:  a.addressCap.accounts,
+		AddressCapExcess:    a.addressCap.excess,
+		HookExcluded:        a.hook.excluded,
+		HookExcludedAmt:     a.hook.excludedAmt,
+		HookAdjusted:        a.hook.adjusted,
+		HookDelta:           a.hook.delta,
+		TypeMultiplied:      a.typeMultiplied,
+		VoteTimeAccounts:    a.voteTime.accounts,
+		VoteTimeBonus:       a.voteTime.bonus,
+		VoteTimeMax:         a.voteTime.maxMultiplier,
+		VoteTimeMin:         a.voteTime.minMultiplier,
+		ValidatorLiquid:     a.validatorLiquid.accounts,
+		ValidatorLiquidAtom: a.validatorLiquid.liquid,
+		ValidatorLiquidDiff: a.validatorLiquid.delta,
+		Withholdings:        a.withholdings,
+	}
+}
+
+// restore sets the accumulated state of c into a, whose first pass (the
+// $ATOM distribution and the nonVotersMultiplier) must be already done.
+func (c distributionCheckpoint) restore(a *airdrop) error {
+	if !c.NonVotersMultiplier.Equal(a.nonVotersMultiplier) || !c.AtomSupply.Equal(a.atom.supply) {
+		return fmt.Errorf("checkpoint doesn't match the accounts or the parameters of the distribution")
+	}
+	a.addresses = c.Addresses
+	if a.addresses == nil {
+		a.addresses = make(map[string]sdk.Int)
+	}
+	a.addressesDetail = c.AddressesDetail
+	a.audit = c.Audit
+	a.atone.votes = c.AtoneVotes
+	a.atone.supply = c.AtoneSupply
+	a.atone.unstaked = c.AtoneUnstaked
+	if !c.AtoneLocked.IsNil() {
+		a.atone.locked = c.AtoneLocked
+	}
+	for name, amt := range c.EntitySlashes {
+		a.entitySlashes[name] = amt
+	}
+	for name, amt := range c.EntitySlashedAtone {
+		a.entitySlashedAtone[name] = amt
+	}
+	a.entityCommunityPool = c.EntityCommunityPool
+	a.optOut = optOutStats{accounts: c.OptOutAccounts, atom: c.OptOutAtom, atone: c.OptOutAtone}
+	a.voterFloor = voterFloorStats{accounts: c.VoterFloorAccounts, topUp: c.VoterFloorTopUp}
+	a.rounding.accounts = c.RoundingAccounts
+	a.rounding.down = c.RoundingDown
+	a.rounding.up = c.RoundingUp
+	a.addressCap.accounts = c.AddressCapAccounts
+	a.addressCap.excess = c.AddressCapExcess
+	for typ, n := range c.TypeMultiplied {
+		a.typeMultiplied[typ] = n
+	}
+	if c.VoteTimeAccounts > 0 {
+		a.voteTime = voteTimeStats{
+			accounts:      c.VoteTimeAccounts,
+			bonus:         c.VoteTimeBonus,
+			maxMultiplier: c.VoteTimeMax,
+			minMultiplier: c.VoteTimeMin,
+		}
+	}
+	if c.ValidatorLiquid > 0 {
+		a.validatorLiquid = validatorLiquidStats{
+			accounts: c.ValidatorLiquid,
+			liquid:   c.ValidatorLiquidAtom,
+			delta:    c.ValidatorLiquidDiff,
+		}
+	}
+	a.hook = hookStats{
+		excluded:    c.HookExcluded,
+		excludedAmt: c.HookExcludedAmt,
+		adjusted:    c.HookAdjusted,
+		delta:       c.HookDelta,
+	}
+	a.withholdings = c.Withholdings
+	return nil
+}
+
+func writeCheckpoint(ctx context.Context, file string, c distributionCheckpoint) error {
+	return writeOutputFile(ctx, file, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(c)
+	})
+}
+
+func readCheckpoint(file string) (*distributionCheckpoint, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var c distributionCheckpoint
+	if err := json.NewDecoder(f).Decode(&c); err != nil {
+		return nil, fmt.Errorf("cannot json decode checkpoint from file %s: %w", file, err)
+	}
+	return &c, nil
+}
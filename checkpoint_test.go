@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestDistributionCheckpointResume(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(10), Vote: vote(govtypes.OptionYes)},
+		{Address: "no", LiquidAmount: sdk.NewDec(3), StakedAmount: sdk.NewDec(13), Vote: vote(govtypes.OptionNo)},
+		{Address: "abstain", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(7), Vote: vote(govtypes.OptionAbstain)},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(17), StakedAmount: sdk.ZeroDec()},
+		{Address: "liquid2", LiquidAmount: sdk.NewDec(5), StakedAmount: sdk.ZeroDec()},
+	}
+	var (
+		ctx    = context.Background()
+		params = defaultDistriParams()
+		file   = filepath.Join(t.TempDir(), "checkpoint.json")
+	)
+	expected, err := distribution(accounts, params, "")
+	require.NoError(err)
+
+	airdrop, err := distributionCheckpointed(ctx, slices.Values(accounts), params, "",
+		checkpointConfig{file: file, every: 2})
+
+	require.NoError(err)
+	assert.Equal(expected.addresses, airdrop.addresses)
+	// Last checkpoint is written before the 5th account
+	cp, err := readCheckpoint(file)
+	require.NoError(err)
+	assert.Equal(4, cp.Processed)
+	assert.Len(cp.Addresses, 4)
+
+	resumed, err := distributionCheckpointed(ctx, slices.Values(accounts), params, "",
+		checkpointConfig{resume: cp})
+
+	require.NoError(err)
+	assert.Equal(expected.addresses, resumed.addresses)
+	assert.Len(resumed.audit, len(accounts))
+	assert.Equal(expected.atone.supply, resumed.atone.supply)
+	assert.Equal(expected.communityPool, resumed.communityPool)
+	assert.Equal(expected.totalSupply(), resumed.totalSupply())
+
+	// Checkpoint of other parameters
+	params.noVotesMultiplier = sdk.NewDec(4)
+	_, err = distributionCheckpointed(ctx, slices.Values(accounts), params, "",
+		checkpointConfig{resume: cp})
+	assert.EqualError(err, "checkpoint doesn't match the accounts or the parameters of the distribution")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/browser"
+)
+
+// exitWarnings is the exit code of a CI run with warnings of the -failOn
+// severity or above.
+const exitWarnings = 3
+
+// ciMode is set by the -ci flag: the outputs are only written to files, the
+// numbers and the warnings are machine-readable, and the warnings of the
+// -failOn severity fail the run.
+var ciMode bool
+
+// interactive returns true if govbox can open a browser: not in CI mode, not
+// in a CI environment (the CI variable set by most CI services), and with
+// stdout on a terminal.
+func interactive() bool {
+	if ciMode || os.Getenv("CI") != "" {
+		return false
+	}
+	_, ok := terminalWidth(os.Stdout)
+	return ok
+}
+
+// openBrowser opens file in the browser, unless the session isn't
+// interactive, in which case the file is left for the user to open.
+func openBrowser(file string) {
+	if !interactive() {
+		fmt.Printf("Not opening %s in a browser: non-interactive session\n", file)
+		return
+	}
+	browser.OpenFile(file)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInteractive(t *testing.T) {
+	defer func(f func(io.Writer) (int, bool)) { terminalWidth = f }(terminalWidth)
+	defer func(ci bool) { ciMode = ci }(ciMode)
+	tests := []struct {
+		name     string
+		terminal bool
+		ciEnv    string
+		ciMode   bool
+		expected bool
+	}{
+		{name: "terminal", terminal: true, expected: true},
+		{name: "piped", terminal: false, expected: false},
+		{name: "CI environment", terminal: true, ciEnv: "true", expected: false},
+		{name: "CI mode", terminal: true, ciMode: true, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terminalWidth = func(io.Writer) (int, bool) { return 80, tt.terminal }
+			t.Setenv("CI", tt.ciEnv)
+			ciMode = tt.ciMode
+
+			assert.Equal(t, tt.expected, interactive())
+		})
+	}
+}
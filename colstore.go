@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// Columns of a columnStore, one file each. Accounts are stored row by row in
+// each column with fixed size little-endian integers, variable size data
+// (addresses, delegations) use an offsets column of n+1 entries.
+const (
+	colAddrs    = "addrs.bin"    // concatenated addresses
+	colAddrOffs = "addroffs.bin" // uint64 offset of each address in addrs.bin
+	colTypes    = "types.bin"    // uint16 index in meta types
+	colVotes    = "votes.bin"    // int32 index in meta votes, -1 for no vote
+	colLiquid   = "liquid.bin"   // int64 liquid amount in uatom
+	colStaked   = "staked.bin"   // int64 staked amount in uatom
+	colLocked   = "locked.bin"   // int64 locked vesting amount in uatom
+	colDelOffs  = "deloffs.bin"  // uint64 index of the first delegation in delvals.bin
+	colDelVals  = "delvals.bin"  // uint32 index in meta vals
+	colDelAmts  = "delamts.bin"  // int64 delegation amount in uatom
+	colMeta     = "meta.json"
+)
+
+// columnStoreMeta holds the interned values of a columnStore, and the chain
+// sources of the few merged accounts, as in compactAccounts.
+type columnStoreMeta struct {
+	Len       int                            `json:"len"`
+	Types     []string                       `json:"types"`
+	Vals      []string                       `json:"vals"`
+	ValVotes  []int32                        `json:"valVotes"`
+	ValChains map[uint32]string              `json:"valChains,omitempty"`
+	Votes     []govtypes.WeightedVoteOptions `json:"votes"`
+	Sources   map[int][]SourceAmount         `json:"sources,omitempty"`
+}
+
+// columnStore is an on-disk, memory-mapped, columnar form of a list of
+// Account. It's like compactAccounts (amounts are truncated to the uatom) but
+// the columns are paged in and out by the OS, so algorithms that need several
+// passes over the accounts work on snapshots larger than the RAM.
+type columnStore struct {
+	meta     columnStoreMeta
+	addrs    []byte
+	addrOffs []byte
+	types    []byte
+	votes    []byte
+	liquid   []byte
+	staked   []byte
+	locked   []byte
+	delOffs  []byte
+	delVals  []byte
+	delAmts  []byte
+	// unmaps releases the memory-mapped columns.
+	unmaps []func() error
+}
+
+// buildColumnStore writes the accounts of accountsFile in the columns of dir.
+func buildColumnStore(ctx context.Context, accountsFile, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	var (
+		names = []string{
+			colAddrs, colAddrOffs, colTypes, colVotes, colLiquid,
+			colStaked, colLocked, colDelOffs, colDelVals, colDelAmts,
+		}
+		files   = make(map[string]*os.File, len(names))
+		writers = make(map[string]*bufio.Writer, len(names))
+	)
+	for _, name := range names {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		files[name] = f
+		writers[name] = bufio.NewWriter(f)
+	}
+	var (
+		// interner holds the interned values, its accounts are discarded
+		interner = newCompactAccounts()
+		sources  = make(map[int][]SourceAmount)
+		n        int
+		addrOff  uint64
+		delOff   uint64
+		put      = func(col string, v any) {
+			// bufio.Writer errors are sticky, and checked by Flush
+			binary.Write(writers[col], binary.LittleEndian, v)
+		}
+	)
+	put(colAddrOffs, addrOff)
+	put(colDelOffs, delOff)
+	err := decodeAccounts(ctx, accountsFile, func(acc Account) error {
+		if err := interner.add(acc); err != nil {
+			return err
+		}
+		ca := interner.accounts[0]
+		interner.accounts = interner.accounts[:0]
+		clear(interner.sources)
+		if len(acc.Sources) > 0 {
+			sources[n] = acc.Sources
+		}
+		writers[colAddrs].WriteString(ca.address)
+		addrOff += uint64(len(ca.address))
+		put(colAddrOffs, addrOff)
+		put(colTypes, ca.typ)
+		put(colVotes, ca.vote)
+		put(colLiquid, ca.liquid)
+		put(colStaked, ca.staked)
+		put(colLocked, ca.locked)
+		for _, del := range ca.delegations {
+			put(colDelVals, del.val)
+			put(colDelAmts, del.amount)
+		}
+		delOff += uint64(len(ca.delegations))
+		put(colDelOffs, delOff)
+		n++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := writers[name].Flush(); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+	bz, err := json.Marshal(columnStoreMeta{
+		Len:       n,
+		Types:     interner.types,
+		Vals:      interner.vals,
+		ValVotes:  interner.valVotes,
+		ValChains: interner.valChains,
+		Votes:     interner.votes,
+		Sources:   sources,
+	})
+	if err != nil {
+		return err
+	}
+	// meta.json is written last, so an incomplete store can't be opened.
+	return writeFile(ctx, filepath.Join(dir, colMeta), bz)
+}
+
+// openColumnStore memory-maps the columns of dir, which must be closed after
+// use.
+func openColumnStore(dir string) (*columnStore, error) {
+	bz, err := os.ReadFile(filepath.Join(dir, colMeta))
+	if err != nil {
+		return nil, err
+	}
+	s := &columnStore{}
+	if err := json.Unmarshal(bz, &s.meta); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", colMeta, err)
+	}
+	for col, dst := range map[string]*[]byte{
+		colAddrs: &s.addrs, colAddrOffs: &s.addrOffs, colTypes: &s.types,
+		colVotes: &s.votes, colLiquid: &s.liquid, colStaked: &s.staked, colLocked: &s.locked,
+		colDelOffs: &s.delOffs, colDelVals: &s.delVals, colDelAmts: &s.delAmts,
+	} {
+		data, unmap, err := mmapFile(filepath.Join(dir, col))
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		*dst = data
+		s.unmaps = append(s.unmaps, unmap)
+	}
+	if len(s.liquid) != 8*s.meta.Len || len(s.locked) != 8*s.meta.Len || len(s.addrOffs) != 8*(s.meta.Len+1) || len(s.delOffs) != 8*(s.meta.Len+1) {
+		s.Close()
+		return nil, fmt.Errorf("column store %s is corrupted", dir)
+	}
+	return s, nil
+}
+
+func (s *columnStore) Close() error {
+	var err error
+	for _, unmap := range s.unmaps {
+		if e := unmap(); e != nil {
+			err = e
+		}
+	}
+	s.unmaps = nil
+	return err
+}
+
+func (s *columnStore) Len() int {
+	return s.meta.Len
+}
+
+func (s *columnStore) vote(idx int32) govtypes.WeightedVoteOptions {
+	if idx < 0 {
+		return nil
+	}
+	return s.meta.Votes[idx]
+}
+
+// at materializes the account at index i.
+func (s *columnStore) at(i int) Account {
+	le := binary.LittleEndian
+	var (
+		addrStart = le.Uint64(s.addrOffs[8*i:])
+		addrEnd   = le.Uint64(s.addrOffs[8*(i+1):])
+		delStart  = le.Uint64(s.delOffs[8*i:])
+		delEnd    = le.Uint64(s.delOffs[8*(i+1):])
+	)
+	acc := Account{
+		Address:      string(s.addrs[addrStart:addrEnd]),
+		Type:         s.meta.Types[le.Uint16(s.types[2*i:])],
+		LiquidAmount: sdk.NewDec(int64(le.Uint64(s.liquid[8*i:]))),
+		StakedAmount: sdk.NewDec(int64(le.Uint64(s.staked[8*i:]))),
+		Vote:         s.vote(int32(le.Uint32(s.votes[4*i:]))),
+	}
+	if locked := int64(le.Uint64(s.locked[8*i:])); locked != 0 {
+		lockedDec := sdk.NewDec(locked)
+		acc.LockedAmount = &lockedDec
+	}
+	if delEnd > delStart {
+		acc.Delegations = make([]Delegation, 0, delEnd-delStart)
+	}
+	for j := delStart; j < delEnd; j++ {
+		val := le.Uint32(s.delVals[4*j:])
+		acc.Delegations = append(acc.Delegations, Delegation{
+			Amount:           sdk.NewDec(int64(le.Uint64(s.delAmts[8*j:]))),
+			ValidatorAddress: s.meta.Vals[val],
+			Vote:             s.vote(s.meta.ValVotes[val]),
+			Chain:            s.meta.ValChains[val],
+		})
+	}
+	acc.Sources = s.meta.Sources[i]
+	return acc
+}
+
+// All returns an iterator over the materialized accounts, only one of them is
+// held in memory at a time.
+func (s *columnStore) All() iter.Seq[Account] {
+	return func(yield func(Account) bool) {
+		for i := range s.meta.Len {
+			if !yield(s.at(i)) {
+				return
+			}
+		}
+	}
+}
+
+// loadColumnStore opens the column store of dir, building it first from
+// accountsFile if it doesn't exist or is older than accountsFile.
+func loadColumnStore(ctx context.Context, accountsFile, dir string) (*columnStore, error) {
+	if isURL(accountsFile) {
+		return nil, fmt.Errorf("the column store requires a local accounts file, got %s", accountsFile)
+	}
+	accountsInfo, err := os.Stat(accountsFile)
+	if err != nil {
+		return nil, err
+	}
+	metaInfo, err := os.Stat(filepath.Join(dir, colMeta))
+	if err != nil || metaInfo.ModTime().Before(accountsInfo.ModTime()) {
+		fmt.Printf("Building column store in %s\n", dir)
+		// Remove the meta first, so an interrupted build can't be opened.
+		os.Remove(filepath.Join(dir, colMeta))
+		if err := buildColumnStore(ctx, accountsFile, dir); err != nil {
+			return nil, err
+		}
+	}
+	return openColumnStore(dir)
+}
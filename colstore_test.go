@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestColumnStore(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		voteYes = govtypes.WeightedVoteOptions{{
+			Option: govtypes.OptionYes,
+			Weight: sdk.NewDec(1),
+		}}
+		accounts = []Account{
+			{
+				Address:      "addr1",
+				Type:         "type1",
+				LiquidAmount: sdk.NewDec(10),
+				StakedAmount: sdk.NewDec(50),
+				Vote:         voteYes,
+				Delegations: []Delegation{
+					{Amount: sdk.NewDec(20), ValidatorAddress: "val1", Vote: voteYes},
+					{Amount: sdk.NewDec(30), ValidatorAddress: "val2"},
+				},
+			},
+			{
+				Address:      "addr2",
+				Type:         "type2",
+				LiquidAmount: sdk.MustNewDecFromStr("1.5"),
+				StakedAmount: sdk.ZeroDec(),
+			},
+			{
+				Address:      "addr3",
+				LiquidAmount: sdk.NewDec(5),
+				StakedAmount: sdk.NewDec(20),
+				Delegations:  []Delegation{{Amount: sdk.NewDec(20), ValidatorAddress: "atonevaloper1", Chain: "atone"}},
+				Sources: []SourceAmount{
+					{Chain: "cosmos", Address: "cosmos1addr3", LiquidAmount: sdk.NewDec(5), StakedAmount: sdk.ZeroDec()},
+					{Chain: "atone", Address: "atone1addr3", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(20)},
+				},
+			},
+		}
+		dir          = t.TempDir()
+		accountsFile = filepath.Join(dir, "accounts.json")
+		storeDir     = filepath.Join(dir, "store")
+		ctx          = context.Background()
+	)
+	bz, err := json.Marshal(accounts)
+	require.NoError(err)
+	require.NoError(os.WriteFile(accountsFile, bz, 0o600))
+
+	store, err := loadColumnStore(ctx, accountsFile, storeDir)
+	require.NoError(err)
+	defer store.Close()
+
+	assert.Equal(3, store.Len())
+	got := slices.Collect(store.All())
+	require.Len(got, 3)
+	assert.Equal("addr1", got[0].Address)
+	assert.Equal("type1", got[0].Type)
+	assert.Equal("50.000000000000000000", got[0].StakedAmount.String())
+	assert.Equal(voteYes.String(), got[0].Vote.String())
+	require.Len(got[0].Delegations, 2)
+	assert.Equal("val2", got[0].Delegations[1].ValidatorAddress)
+	assert.Equal("30.000000000000000000", got[0].Delegations[1].Amount.String())
+	assert.Nil(got[0].Delegations[1].Vote)
+	assert.Equal(voteYes.String(), got[0].Delegations[0].Vote.String())
+	// amounts are truncated to the uatom
+	assert.Equal("1.000000000000000000", got[1].LiquidAmount.String())
+	assert.Nil(got[1].Vote)
+	assert.Empty(got[1].Delegations)
+	assert.Empty(got[1].Sources)
+	// the merged accounts keep their sources and chains
+	assert.Equal("atone", got[2].Delegations[0].Chain)
+	require.Len(got[2].Sources, 2)
+	assert.Equal("atone1addr3", got[2].Sources[1].Address)
+	assert.Equal("20.000000000000000000", got[2].Sources[1].StakedAmount.String())
+
+	// The distribution is the same as with the compact accounts
+	compact, err := parseCompactAccounts(ctx, accountsFile)
+	require.NoError(err)
+	expected, err := distributionSeq(ctx, compact.All(), defaultDistriParams(), "")
+	require.NoError(err)
+	airdrop, err := distributionSeq(ctx, store.All(), defaultDistriParams(), "")
+	require.NoError(err)
+	assert.Equal(expected.addresses, airdrop.addresses)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"os"
+	"slices"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// compactFlagUsage is the usage of the -compact flag of the commands loading
+// the accounts.
+const compactFlagUsage = "Hold the accounts in a compact form to reduce memory usage, amounts are truncated to the uatom"
+
+// compactAccounts is a memory efficient representation of a list of Account,
+// for snapshots with millions of accounts.
+// Amounts are stored as int64 in micro-units (uatom), so the fractional part
+// of the amounts (< 1uatom) is truncated. Account types, validator addresses
+// and votes are interned.
+type compactAccounts struct {
+	types    []string
+	typeIdx  map[string]uint16
+	vals     []string
+	valIdx   map[string]uint32
+	valVotes []int32
+	// valChains are the chains of the validators of the delegations merged
+	// from other chains, by index in vals.
+	valChains map[uint32]string
+	votes     []govtypes.WeightedVoteOptions
+	voteIdx   map[string]int32
+	accounts  []compactAccount
+	// sources are the chain sources of the few merged accounts, by index.
+	sources map[int][]SourceAmount
+}
+
+type compactAccount struct {
+	address     string
+	typ         uint16
+	vote        int32 // index in votes, -1 for no vote
+	liquid      int64
+	staked      int64
+	locked      int64
+	delegations []compactDelegation
+}
+
+type compactDelegation struct {
+	val    uint32 // index in vals
+	amount int64
+}
+
+func newCompactAccounts() *compactAccounts {
+	return &compactAccounts{
+		typeIdx:   make(map[string]uint16),
+		valIdx:    make(map[string]uint32),
+		valChains: make(map[uint32]string),
+		voteIdx:   make(map[string]int32),
+		sources:   make(map[int][]SourceAmount),
+	}
+}
+
+func (c *compactAccounts) Len() int {
+	return len(c.accounts)
+}
+
+// add appends acc to the list.
+func (c *compactAccounts) add(acc Account) error {
+	ca := compactAccount{
+		address: acc.Address,
+		vote:    c.internVote(acc.Vote),
+	}
+	typ, ok := c.typeIdx[acc.Type]
+	if !ok {
+		typ = uint16(len(c.types))
+		c.types = append(c.types, acc.Type)
+		c.typeIdx[acc.Type] = typ
+	}
+	ca.typ = typ
+	var err error
+	if ca.liquid, err = decToMicro(acc.LiquidAmount); err != nil {
+		return fmt.Errorf("account %s liquid amount: %w", acc.Address, err)
+	}
+	if ca.staked, err = decToMicro(acc.StakedAmount); err != nil {
+		return fmt.Errorf("account %s staked amount: %w", acc.Address, err)
+	}
+	if ca.locked, err = decToMicro(acc.lockedAmount()); err != nil {
+		return fmt.Errorf("account %s locked amount: %w", acc.Address, err)
+	}
+	if len(acc.Delegations) > 0 {
+		ca.delegations = make([]compactDelegation, len(acc.Delegations))
+	}
+	for i, del := range acc.Delegations {
+		val, ok := c.valIdx[del.ValidatorAddress]
+		if !ok {
+			val = uint32(len(c.vals))
+			c.vals = append(c.vals, del.ValidatorAddress)
+			c.valVotes = append(c.valVotes, c.internVote(del.Vote))
+			c.valIdx[del.ValidatorAddress] = val
+			if del.Chain != "" {
+				c.valChains[val] = del.Chain
+			}
+		}
+		amount, err := decToMicro(del.Amount)
+		if err != nil {
+			return fmt.Errorf("account %s delegation amount: %w", acc.Address, err)
+		}
+		ca.delegations[i] = compactDelegation{val: val, amount: amount}
+	}
+	if len(acc.Sources) > 0 {
+		c.sources[len(c.accounts)] = acc.Sources
+	}
+	c.accounts = append(c.accounts, ca)
+	return nil
+}
+
+func (c *compactAccounts) internVote(vote govtypes.WeightedVoteOptions) int32 {
+	if len(vote) == 0 {
+		return -1
+	}
+	var key strings.Builder
+	for _, o := range vote {
+		fmt.Fprintf(&key, "%d:%s;", o.Option, o.Weight)
+	}
+	idx, ok := c.voteIdx[key.String()]
+	if !ok {
+		idx = int32(len(c.votes))
+		c.votes = append(c.votes, vote)
+		c.voteIdx[key.String()] = idx
+	}
+	return idx
+}
+
+func (c *compactAccounts) vote(idx int32) govtypes.WeightedVoteOptions {
+	if idx < 0 {
+		return nil
+	}
+	return c.votes[idx]
+}
+
+// at materializes the account at index i.
+func (c *compactAccounts) at(i int) Account {
+	ca := c.accounts[i]
+	acc := Account{
+		Address:      ca.address,
+		Type:         c.types[ca.typ],
+		LiquidAmount: sdk.NewDec(ca.liquid),
+		StakedAmount: sdk.NewDec(ca.staked),
+		Vote:         c.vote(ca.vote),
+	}
+	if ca.locked != 0 {
+		locked := sdk.NewDec(ca.locked)
+		acc.LockedAmount = &locked
+	}
+	if len(ca.delegations) > 0 {
+		acc.Delegations = make([]Delegation, len(ca.delegations))
+	}
+	for j, del := range ca.delegations {
+		acc.Delegations[j] = Delegation{
+			Amount:           sdk.NewDec(del.amount),
+			ValidatorAddress: c.vals[del.val],
+			Vote:             c.vote(c.valVotes[del.val]),
+			Chain:            c.valChains[del.val],
+		}
+	}
+	acc.Sources = c.sources[i]
+	return acc
+}
+
+// All returns an iterator over the materialized accounts, only one of them is
+// held in memory at a time.
+func (c *compactAccounts) All() iter.Seq[Account] {
+	return func(yield func(Account) bool) {
+		for i := range c.accounts {
+			if !yield(c.at(i)) {
+				return
+			}
+		}
+	}
+}
+
+func decToMicro(d sdk.Dec) (int64, error) {
+	i := d.TruncateInt()
+	if !i.IsInt64() {
+		return 0, fmt.Errorf("%s overflows int64", d)
+	}
+	return i.Int64(), nil
+}
+
+// decodeAccounts decodes the accounts of path one by one and calls fn for
+// each of them.
+func decodeAccounts(ctx context.Context, path string, fn func(Account) error) (err error) {
+	f, err := openInput(ctx, path)
+	if err != nil {
+		return fmt.Errorf("cannot read %s file, run `%s accounts` to generate it: %w", path, os.Args[0], err)
+	}
+	defer closeInput(f, &err)
+	dec := json.NewDecoder(f)
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("cannot json decode accounts from file %s: %w", path, err)
+	}
+	for dec.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var acc Account
+		if err := dec.Decode(&acc); err != nil {
+			return fmt.Errorf("cannot json decode accounts from file %s: %w", path, err)
+		}
+		if err := fn(acc.withKey()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseCompactAccounts is like parseAccounts but decodes the accounts one by
+// one into a compactAccounts.
+func parseCompactAccounts(ctx context.Context, path string) (*compactAccounts, error) {
+	accounts := newCompactAccounts()
+	if err := decodeAccounts(ctx, path, accounts.add); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// loadAccounts reads the accounts of path, in a compactAccounts if compact is
+// true.
+func loadAccounts(ctx context.Context, path string, compact bool) (iter.Seq[Account], error) {
+	if compact {
+		accounts, err := parseCompactAccounts(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return accounts.All(), nil
+	}
+	accounts, err := parseAccounts(path)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Values(accounts), nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestCompactAccounts(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		voteYes = govtypes.WeightedVoteOptions{{
+			Option: govtypes.OptionYes,
+			Weight: sdk.NewDec(1),
+		}}
+		voteNo = govtypes.WeightedVoteOptions{{
+			Option: govtypes.OptionNo,
+			Weight: sdk.NewDec(1),
+		}}
+		accounts = []Account{
+			{
+				Address:      "addr1",
+				Type:         "type1",
+				LiquidAmount: sdk.NewDec(10),
+				StakedAmount: sdk.NewDec(50),
+				Vote:         voteNo,
+				Delegations: []Delegation{
+					{Amount: sdk.NewDec(20), ValidatorAddress: "val1", Vote: voteYes},
+					{Amount: sdk.NewDec(30), ValidatorAddress: "val2"},
+				},
+			},
+			{
+				Address:      "addr2",
+				Type:         "type2",
+				LiquidAmount: sdk.NewDec(1),
+				StakedAmount: sdk.ZeroDec(),
+				Sources: []SourceAmount{
+					{Chain: "cosmos", Address: "addr2", LiquidAmount: sdk.NewDec(1), StakedAmount: sdk.ZeroDec()},
+				},
+			},
+			{
+				Address:      "addr3",
+				Type:         "type1",
+				LiquidAmount: sdk.ZeroDec(),
+				StakedAmount: sdk.NewDec(10),
+				Delegations: []Delegation{
+					{Amount: sdk.NewDec(7), ValidatorAddress: "val1", Vote: voteYes},
+					{Amount: sdk.NewDec(3), ValidatorAddress: "osmovaloper1", Chain: "osmo"},
+				},
+			},
+		}
+		compact = newCompactAccounts()
+	)
+
+	for _, acc := range accounts {
+		require.NoError(compact.add(acc))
+	}
+
+	assert.Equal(3, compact.Len())
+	assert.Equal([]string{"type1", "type2"}, compact.types)
+	assert.Equal([]string{"val1", "val2", "osmovaloper1"}, compact.vals)
+	assert.Len(compact.votes, 2)
+	assert.Equal(accounts, slices.Collect(compact.All()))
+}
+
+func TestCompactAccountsTruncate(t *testing.T) {
+	compact := newCompactAccounts()
+
+	err := compact.add(Account{
+		Address:      "addr",
+		LiquidAmount: sdk.MustNewDecFromStr("10.9"),
+		StakedAmount: sdk.ZeroDec(),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, sdk.NewDec(10), compact.at(0).LiquidAmount)
+}
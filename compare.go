@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// balanceDiff is a mismatch between the official and the local balance of an
+// address. A nil amount means the address is missing on that side.
+type balanceDiff struct {
+	address  string
+	official *sdk.Int
+	local    *sdk.Int
+}
+
+type compareResult struct {
+	matches int
+	diffs   []balanceDiff
+}
+
+// expectedGenesisBalances returns the $ATONE balances that writeGenesis
+// would produce from airdrop, with the module accounts and the validators
+// created by msgs, whose self-delegations are moved from their balances to
+// the not-bonded pool (see bootstrapValidators).
+func expectedGenesisBalances(airdrop airdrop, prefix string, msgs []*stakingtypes.MsgCreateValidator) (map[string]sdk.Int, error) {
+	var (
+		denom       = atoneDenom(airdrop)
+		balances, _ = genesisBalances([]genesisDenom{denom}, prefix)
+		bankGen     = banktypes.GenesisState{Balances: balances}
+		stakingGen  = stakingtypes.GenesisState{Params: stakingtypes.Params{BondDenom: denom.base()}}
+	)
+	if err := bootstrapValidators(msgs, &bankGen, &stakingGen, time.Time{}, prefix); err != nil {
+		return nil, err
+	}
+	expected := make(map[string]sdk.Int, len(bankGen.Balances))
+	for _, b := range bankGen.Balances {
+		// as in parseOfficialBalances
+		if amt := b.Coins.AmountOf(denom.base()); amt.IsPositive() {
+			expected[b.Address] = amt
+		}
+	}
+	return expected, nil
+}
+
+// parseOfficialBalances reads the denom balances of a genesis file, src can be
+// a local path or an http(s) URL.
+func parseOfficialBalances(src, denom string) (map[string]sdk.Int, error) {
+	var r io.Reader
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		resp, err := http.Get(src)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("get %s: %s", src, resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var genesis struct {
+		AppState struct {
+			Bank struct {
+				Balances []struct {
+					Address string    `json:"address"`
+					Coins   sdk.Coins `json:"coins"`
+				} `json:"balances"`
+			} `json:"bank"`
+		} `json:"app_state"`
+	}
+	if err := json.NewDecoder(r).Decode(&genesis); err != nil {
+		return nil, fmt.Errorf("cannot json decode genesis from %s: %w", src, err)
+	}
+	balances := make(map[string]sdk.Int, len(genesis.AppState.Bank.Balances))
+	for _, b := range genesis.AppState.Bank.Balances {
+		if amt := b.Coins.AmountOf(denom); amt.IsPositive() {
+			balances[b.Address] = amt
+		}
+	}
+	return balances, nil
+}
+
+// compareBalances diffs the official balances against the local ones. Diffs
+// are sorted by address.
+func compareBalances(official, local map[string]sdk.Int) compareResult {
+	var res compareResult
+	for _, addr := range slices.Sorted(maps.Keys(official)) {
+		offAmt := official[addr]
+		locAmt, ok := local[addr]
+		switch {
+		case !ok:
+			res.diffs = append(res.diffs, balanceDiff{address: addr, official: &offAmt})
+		case !offAmt.Equal(locAmt):
+			res.diffs = append(res.diffs, balanceDiff{address: addr, official: &offAmt, local: &locAmt})
+		default:
+			res.matches++
+		}
+	}
+	for _, addr := range slices.Sorted(maps.Keys(local)) {
+		if _, ok := official[addr]; !ok {
+			locAmt := local[addr]
+			res.diffs = append(res.diffs, balanceDiff{address: addr, local: &locAmt})
+		}
+	}
+	slices.SortFunc(res.diffs, func(a, b balanceDiff) int {
+		return strings.Compare(a.address, b.address)
+	})
+	return res
+}
+
+func printCompareResult(res compareResult) {
+	fmt.Printf("%d matching balance(s), %d mismatch(es)\n", res.matches, len(res.diffs))
+	if len(res.diffs) == 0 {
+		return
+	}
+	const maxRows = 50
+	amtStr := func(amt *sdk.Int) string {
+		if amt == nil {
+			return "missing"
+		}
+		return amt.String()
+	}
+	table := newMarkdownTable("ADDRESS", "OFFICIAL", "LOCAL", "DIFF")
+	for i, d := range res.diffs {
+		if i == maxRows {
+			table.Append([]string{fmt.Sprintf("... %d more", len(res.diffs)-maxRows), "", "", ""})
+			break
+		}
+		diff := ""
+		if d.official != nil && d.local != nil {
+			diff = d.local.Sub(*d.official).String()
+		}
+		table.Append([]string{d.address, amtStr(d.official), amtStr(d.local), diff})
+	}
+	table.Render()
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+
+	"github.com/atomone-hub/govbox/airdropblob"
+)
+
+// savedRun is an airdrop result saved by distribution -proto.
+type savedRun struct {
+	// name is the file of the result.
+	name   string
+	params airdropblob.Params
+	// atone is the distributed $ATONE per vote category.
+	atone               distrib
+	nonVotersMultiplier sdk.Dec
+	communityPool       sdk.Dec
+	reservedAddr        sdk.Dec
+	amounts             map[string]sdk.Int
+}
+
+// loadSavedRun reads the airdrop result of file.
+func loadSavedRun(file string) (savedRun, error) {
+	bz, err := os.ReadFile(file)
+	if err != nil {
+		return savedRun{}, err
+	}
+	res, err := airdropblob.UnmarshalResult(bz)
+	if err != nil {
+		return savedRun{}, fmt.Errorf("decode %s: %w", file, err)
+	}
+	run := savedRun{
+		name:    file,
+		params:  res.Params,
+		atone:   distrib{votes: newVoteMap()},
+		amounts: make(map[string]sdk.Int, len(res.Entries)),
+	}
+	dec := func(field, s string) sdk.Dec {
+		if err != nil {
+			return sdk.Dec{}
+		}
+		var d sdk.Dec
+		d, err = sdk.NewDecFromStr(s)
+		if err != nil {
+			err = fmt.Errorf("%s: invalid %s '%s': %w", file, field, s, err)
+		}
+		return d
+	}
+	run.atone.supply = dec("atone supply", res.Aggregates.AtoneSupply)
+	run.nonVotersMultiplier = dec("nonVotersMultiplier", res.Aggregates.NonVotersMultiplier)
+	run.communityPool = dec("community pool", res.Aggregates.CommunityPool)
+	run.reservedAddr = dec("reserved address", res.Aggregates.ReservedAddress)
+	run.atone.unstaked = run.atone.supply
+	for key, amt := range res.Aggregates.AtoneVotes {
+		opt, ok := govtypes.VoteOption_value[key]
+		if !ok {
+			return savedRun{}, fmt.Errorf("%s: unknown vote option '%s'", file, key)
+		}
+		run.atone.votes[govtypes.VoteOption(opt)] = dec("votes", amt)
+		if err == nil {
+			// the distributed $ATONE that isn't a vote is the liquid part
+			run.atone.unstaked = run.atone.unstaked.Sub(run.atone.votes[govtypes.VoteOption(opt)])
+		}
+	}
+	if err != nil {
+		return savedRun{}, err
+	}
+	if !run.atone.supply.IsPositive() {
+		return savedRun{}, fmt.Errorf("%s: nothing distributed", file)
+	}
+	for _, e := range res.Entries {
+		run.amounts[e.Address] = sdk.NewIntFromUint64(e.Amount)
+	}
+	return run, nil
+}
+
+// runsTable is a table of the comparison dashboard, rendered both in
+// Markdown and in HTML.
+type runsTable struct {
+	Title   string
+	Headers []string
+	Rows    [][]string
+}
+
+func (t runsTable) print(w io.Writer) {
+	fmt.Fprintln(w, t.Title)
+	table := newMarkdownTableWriter(w, t.Headers...)
+	table.AppendBulk(t.Rows)
+	table.Render()
+	fmt.Fprintln(w)
+}
+
+// withDelta returns v and its difference with base, formatted by format.
+func withDelta(v, base sdk.Dec, format func(sdk.Dec) string) string {
+	delta := v.Sub(base)
+	switch {
+	case delta.IsZero():
+		return format(v)
+	case delta.IsPositive():
+		return fmt.Sprintf("%s (+%s)", format(v), format(delta))
+	default:
+		return fmt.Sprintf("%s (-%s)", format(v), format(delta.Neg()))
+	}
+}
+
+// runsAggregatesTable compares the params and totals of the runs, with their
+// difference with the first run.
+func runsAggregatesTable(runs []savedRun) runsTable {
+	t := runsTable{Title: "Parameters and totals (difference with the first run)", Headers: []string{"METRIC"}}
+	for _, r := range runs {
+		t.Headers = append(t.Headers, r.name)
+	}
+	var (
+		multiplier = func(d sdk.Dec) string { return fmt.Sprintf("%.4f", d.MustFloat64()) }
+		param      = func(label string, get func(airdropblob.Params) string) {
+			row := []string{label}
+			for _, r := range runs {
+				row = append(row, get(r.params))
+			}
+			t.Rows = append(t.Rows, row)
+		}
+		metric = func(label string, get func(savedRun) sdk.Dec, format func(sdk.Dec) string) {
+			row := []string{label}
+			for _, r := range runs {
+				row = append(row, withDelta(get(r), get(runs[0]), format))
+			}
+			t.Rows = append(t.Rows, row)
+		}
+	)
+	param("Yes multiplier", func(p airdropblob.Params) string { return p.YesVotesMultiplier })
+	param("No multiplier", func(p airdropblob.Params) string { return p.NoVotesMultiplier })
+	param("Bonus", func(p airdropblob.Params) string { return p.Bonus })
+	param("Malus", func(p airdropblob.Params) string { return p.Malus })
+	param("Supply factor", func(p airdropblob.Params) string { return p.SupplyFactor })
+	metric("Non-voters multiplier", func(r savedRun) sdk.Dec { return r.nonVotersMultiplier }, multiplier)
+	metric("Distributed $ATONE", func(r savedRun) sdk.Dec { return r.atone.supply }, humand)
+	metric("Community pool $ATONE", func(r savedRun) sdk.Dec { return r.communityPool }, humand)
+	metric("Reserved address $ATONE", func(r savedRun) sdk.Dec { return r.reservedAddr }, humand)
+	metric("Addresses", func(r savedRun) sdk.Dec { return sdk.NewDec(int64(len(r.amounts))) }, func(d sdk.Dec) string {
+		return humanCount(d.TruncateInt64())
+	})
+	for _, opt := range allVoteOptions {
+		metric(voteOptionLabel(opt)+" share", func(r savedRun) sdk.Dec { return r.atone.votePercentages()[opt] }, humanPercent)
+	}
+	metric("Not staked share", func(r savedRun) sdk.Dec { return r.atone.unstaked.Quo(r.atone.supply) }, humanPercent)
+	return t
+}
+
+// runsChangesTable counts the addresses whose allocation changed in each run
+// compared to the first run.
+func runsChangesTable(runs []savedRun) runsTable {
+	t := runsTable{
+		Title:   "Allocation changes compared to the first run",
+		Headers: []string{"RUN", "ADDED", "REMOVED", "INCREASED", "DECREASED", "UNCHANGED"},
+	}
+	for _, r := range runs[1:] {
+		var added, removed, increased, decreased, unchanged int
+		for addr, amt := range r.amounts {
+			base, ok := runs[0].amounts[addr]
+			switch {
+			case !ok:
+				added++
+			case amt.GT(base):
+				increased++
+			case amt.LT(base):
+				decreased++
+			default:
+				unchanged++
+			}
+		}
+		for addr := range runs[0].amounts {
+			if _, ok := r.amounts[addr]; !ok {
+				removed++
+			}
+		}
+		t.Rows = append(t.Rows, []string{
+			r.name, humanCount(added), humanCount(removed), humanCount(increased), humanCount(decreased), humanCount(unchanged),
+		})
+	}
+	return t
+}
+
+// runsMoversTable lists the top addresses with the largest allocation change
+// between the first run and run.
+func runsMoversTable(base, run savedRun, top int) runsTable {
+	t := runsTable{
+		Title:   fmt.Sprintf("Top %d allocation changes from %s to %s", top, base.name, run.name),
+		Headers: []string{"ADDRESS", "BEFORE", "AFTER", "DIFFERENCE"},
+	}
+	type mover struct {
+		addr          string
+		before, after sdk.Int
+	}
+	var (
+		movers []mover
+		addrs  = slices.Collect(maps.Keys(base.amounts))
+	)
+	for addr := range run.amounts {
+		if _, ok := base.amounts[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	slices.Sort(addrs)
+	for _, addr := range addrs {
+		m := mover{addr: addr, before: sdk.ZeroInt(), after: sdk.ZeroInt()}
+		if amt, ok := base.amounts[addr]; ok {
+			m.before = amt
+		}
+		if amt, ok := run.amounts[addr]; ok {
+			m.after = amt
+		}
+		if !m.before.Equal(m.after) {
+			movers = append(movers, m)
+		}
+	}
+	slices.SortStableFunc(movers, func(a, b mover) int {
+		return b.after.Sub(b.before).Abs().BigInt().Cmp(a.after.Sub(a.before).Abs().BigInt())
+	})
+	for _, m := range movers[:min(top, len(movers))] {
+		t.Rows = append(t.Rows, []string{
+			m.addr, humand(m.before.ToLegacyDec()), humand(m.after.ToLegacyDec()),
+			withDelta(m.after.ToLegacyDec(), m.before.ToLegacyDec(), humand),
+		})
+	}
+	return t
+}
+
+// runsTables returns the delta tables of the comparison of runs.
+func runsTables(runs []savedRun, top int) []runsTable {
+	tables := []runsTable{runsAggregatesTable(runs), runsChangesTable(runs)}
+	for _, r := range runs[1:] {
+		tables = append(tables, runsMoversTable(runs[0], r, top))
+	}
+	return tables
+}
+
+// newRunsBarChart returns a bar chart of the share of each vote category,
+// with one series per run.
+func newRunsBarChart(runs []savedRun, theme chartTheme) *charts.Bar {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(append([]charts.GlobalOpts{
+		charts.WithTitleOpts(opts.Title{Title: "Votes distribution per run"}),
+		charts.WithLegendOpts(opts.Legend{Show: true, Right: "right", Orient: "vertical"}),
+		charts.WithTooltipOpts(opts.Tooltip{
+			Show:      true,
+			Formatter: opts.FuncOpts("function(params){ return params.seriesName+': '+params.value.toFixed(2)+'%'}"),
+		}),
+	}, theme.globalOpts()...)...)
+	series := theme.series()
+	xAxis := make([]string, len(series))
+	for i, s := range series {
+		xAxis[i] = s.Label
+	}
+	bar.SetXAxis(xAxis)
+	for _, r := range runs {
+		values := distribChartValues(r.atone)
+		data := make([]opts.BarData, len(series))
+		for i, s := range series {
+			data[i] = opts.BarData{Name: s.Label, Value: s.sum(values)}
+		}
+		bar.AddSeries(r.name, data)
+	}
+	return bar
+}
+
+// allocationBucket is an allocation size bucket of the comparison dashboard,
+// max is its upper bound in $ATONE, 0 for no upper bound.
+type allocationBucket struct {
+	label string
+	max   int64
+}
+
+var allocationBuckets = []allocationBucket{
+	{"< 10", 10},
+	{"10 - 100", 100},
+	{"100 - 1k", 1_000},
+	{"1k - 10k", 10_000},
+	{"10k - 100k", 100_000},
+	{"100k - 1M", 1_000_000},
+	{"> 1M", 0},
+}
+
+// allocationBucketShares returns the share of the distributed $ATONE of each
+// allocationBuckets, in percent.
+func allocationBucketShares(run savedRun) []float64 {
+	var (
+		amounts = make([]sdk.Int, len(allocationBuckets))
+		total   = sdk.ZeroInt()
+	)
+	for i := range amounts {
+		amounts[i] = sdk.ZeroInt()
+	}
+	for _, amt := range run.amounts {
+		i := slices.IndexFunc(allocationBuckets, func(b allocationBucket) bool {
+			return b.max == 0 || amt.LT(sdk.NewInt(b.max*M))
+		})
+		amounts[i] = amounts[i].Add(amt)
+		total = total.Add(amt)
+	}
+	shares := make([]float64, len(amounts))
+	if total.IsZero() {
+		return shares
+	}
+	for i, amt := range amounts {
+		shares[i] = amt.ToLegacyDec().QuoInt(total).MulInt64(100).MustFloat64()
+	}
+	return shares
+}
+
+// newRunsAllocationCharts returns one bar chart per run of the share of the
+// distributed $ATONE per allocation size, with the same y axis so they can be
+// compared side by side.
+func newRunsAllocationCharts(runs []savedRun, theme chartTheme) []components.Charter {
+	var (
+		shares = make([][]float64, len(runs))
+		maxY   float64
+		xAxis  []string
+	)
+	for i, r := range runs {
+		shares[i] = allocationBucketShares(r)
+		maxY = max(maxY, slices.Max(shares[i]))
+	}
+	for _, b := range allocationBuckets {
+		xAxis = append(xAxis, b.label)
+	}
+	// round the axis up to the next 10%
+	maxY = math.Min(100, math.Ceil(maxY/10)*10)
+	var charters []components.Charter
+	for i, r := range runs {
+		bar := charts.NewBar()
+		bar.SetGlobalOptions(append([]charts.GlobalOpts{
+			charts.WithTitleOpts(opts.Title{Title: "Share per allocation ($ATONE)", Subtitle: r.name}),
+			charts.WithYAxisOpts(opts.YAxis{Min: 0, Max: maxY}),
+			charts.WithTooltipOpts(opts.Tooltip{
+				Show:      true,
+				Formatter: opts.FuncOpts("function(params){ return params.name+': '+params.value.toFixed(2)+'%'}"),
+			}),
+		}, theme.globalOpts()...)...)
+		bar.SetXAxis(xAxis)
+		data := make([]opts.BarData, len(shares[i]))
+		for j, s := range shares[i] {
+			data[j] = opts.BarData{Value: s}
+		}
+		bar.AddSeries(r.name, data)
+		charters = append(charters, bar)
+	}
+	return charters
+}
+
+var runsTablesTemplate = template.Must(template.New("tables").Parse(`
+<style>
+.runs-tables { font-family: sans-serif; margin: 20px; }
+.runs-tables table { border-collapse: collapse; margin-bottom: 20px; }
+.runs-tables th, .runs-tables td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+.runs-tables th:first-child, .runs-tables td:first-child { text-align: left; }
+</style>
+<div class="runs-tables">
+{{- range .}}
+<h3>{{.Title}}</h3>
+<table>
+<tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- end}}
+</div>
+`))
+
+// writeRunsDashboard writes in file the HTML page of the charts followed by
+// the delta tables.
+func writeRunsDashboard(ctx context.Context, file string, page *components.Page, tables []runsTable) error {
+	var buf bytes.Buffer
+	if err := page.Render(&buf); err != nil {
+		return err
+	}
+	var tablesHTML bytes.Buffer
+	if err := runsTablesTemplate.Execute(&tablesHTML, tables); err != nil {
+		return err
+	}
+	html := buf.Bytes()
+	i := bytes.LastIndex(html, []byte("</body>"))
+	if i < 0 {
+		i = len(html)
+	}
+	return writeOutputFile(ctx, file, func(w io.Writer) error {
+		for _, b := range [][]byte{html[:i], tablesHTML.Bytes(), html[i:]} {
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// compareRuns writes in file the comparison dashboard of the runs, and prints
+// its delta tables. The first run is the reference of the differences. If
+// open is true, the dashboard is opened in the browser (see openBrowser).
+func compareRuns(ctx context.Context, file string, runs []savedRun, theme chartTheme, top int, open bool) error {
+	if len(runs) < 2 {
+		return fmt.Errorf("at least 2 runs are required, got %d", len(runs))
+	}
+	names := make(map[string]bool, len(runs))
+	for _, r := range runs {
+		if names[r.name] {
+			return fmt.Errorf("run %s is given twice", r.name)
+		}
+		names[r.name] = true
+	}
+	page := components.NewPage()
+	page.PageTitle = "$ATONE distributions comparison"
+	page.SetLayout(components.PageFlexLayout)
+	page.AddCharts(newRunsBarChart(runs, theme))
+	page.AddCharts(newRunsAllocationCharts(runs, theme)...)
+	tables := runsTables(runs, top)
+	for _, t := range tables {
+		t.print(os.Stdout)
+	}
+	if err := writeRunsDashboard(ctx, file, page, tables); err != nil {
+		return err
+	}
+	fmt.Printf("Comparison dashboard written in %s\n", file)
+	if open {
+		openBrowser(file)
+	}
+	return nil
+}
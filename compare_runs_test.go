@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestCompareRuns(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		dir  = t.TempDir()
+		vote = func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+			return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.OneDec()}}
+		}
+		accounts = []Account{
+			{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1_000 * M), Vote: vote(govtypes.OptionYes)},
+			{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1_000 * M), Vote: vote(govtypes.OptionNo)},
+			{Address: "liquid", LiquidAmount: sdk.NewDec(1_000 * M), StakedAmount: sdk.ZeroDec()},
+		}
+		runs []savedRun
+	)
+	for i, noMultiplier := range []int64{9, 4} {
+		params := defaultDistriParams()
+		params.noVotesMultiplier = sdk.NewDec(noMultiplier)
+		airdrop, err := distribution(accounts, params, "")
+		require.NoError(err)
+		file := filepath.Join(dir, []string{"a.pb", "b.pb"}[i])
+		require.NoError(writeAirdropResult(context.Background(), file, "uatone", airdrop))
+
+		run, err := loadSavedRun(file)
+
+		require.NoError(err)
+		assert.Equal(airdrop.atone.supply.String(), run.atone.supply.String())
+		assert.Equal(airdrop.atone.unstaked.String(), run.atone.unstaked.String())
+		assert.Equal(airdrop.addresses, run.amounts)
+		runs = append(runs, run)
+	}
+
+	tables := runsTables(runs, 2)
+
+	require.Len(tables, 3)
+	assert.Equal([]string{"No multiplier", "9.000000000000000000", "4.000000000000000000"}, tables[0].Rows[1])
+	// the No allocation decreases, and the liquid one with the nonVotersMultiplier
+	assert.Equal([][]string{{runs[1].name, "0", "0", "0", "2", "1"}}, tables[1].Rows)
+	assert.Len(tables[2].Rows, 2)
+	assert.Equal("no", tables[2].Rows[0][0])
+
+	file := filepath.Join(dir, "compare.html")
+	require.NoError(compareRuns(context.Background(), file, runs, chartTheme{}, 2, false))
+	bz, err := os.ReadFile(file)
+	require.NoError(err)
+	assert.Contains(string(bz), "Share per allocation")
+	assert.Contains(string(bz), "<h3>Allocation changes compared to the first run</h3>")
+
+	assert.ErrorContains(compareRuns(context.Background(), file, runs[:1], chartTheme{}, 2, false), "at least 2 runs")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+func TestCompareBalances(t *testing.T) {
+	official := map[string]sdk.Int{
+		"a": sdk.NewInt(1),
+		"b": sdk.NewInt(2),
+		"c": sdk.NewInt(3),
+	}
+	local := map[string]sdk.Int{
+		"a": sdk.NewInt(1),
+		"b": sdk.NewInt(20),
+		"d": sdk.NewInt(4),
+	}
+
+	res := compareBalances(official, local)
+
+	assert.Equal(t, 1, res.matches)
+	require.Len(t, res.diffs, 3)
+	assert.Equal(t, "b", res.diffs[0].address)
+	assert.Equal(t, sdk.NewInt(2), *res.diffs[0].official)
+	assert.Equal(t, sdk.NewInt(20), *res.diffs[0].local)
+	assert.Equal(t, "c", res.diffs[1].address)
+	assert.Nil(t, res.diffs[1].local)
+	assert.Equal(t, "d", res.diffs[2].address)
+	assert.Nil(t, res.diffs[2].official)
+}
+
+func TestParseOfficialBalances(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "genesis.json")
+	err := os.WriteFile(file, []byte(`{
+  "app_state": {
+    "bank": {
+      "balances": [
+        {"address": "a", "coins": [{"denom": "uatone", "amount": "42"}]},
+        {"address": "b", "coins": [{"denom": "uphoton", "amount": "1"}]}
+      ]
+    }
+  }
+}`), 0o644)
+	require.NoError(t, err)
+
+	balances, err := parseOfficialBalances(file, "uatone")
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]sdk.Int{"a": sdk.NewInt(42)}, balances)
+}
+
+func TestExpectedGenesisBalances(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		pk      = ed25519.GenPrivKey().PubKey()
+		valAcc  = sdk.AccAddress(pk.Address())
+		addr    = createAccountAddrs(1)[0]
+		airdrop = airdrop{
+			addresses:     map[string]sdk.Int{valAcc.String(): sdk.NewInt(100), addr.String(): sdk.NewInt(50)},
+			reservedAddr:  sdk.NewDec(10),
+			communityPool: sdk.NewDec(20),
+			params:        defaultDistriParams(),
+		}
+		reservedAddr    = sdk.MustBech32ifyAddressBytes("cosmos", reservedAddrBz)
+		distrModuleAddr = sdk.MustBech32ifyAddressBytes("cosmos", authtypes.NewModuleAddress(distrtypes.ModuleName))
+		notBondedPool   = sdk.MustBech32ifyAddressBytes("cosmos", authtypes.NewModuleAddress(stakingtypes.NotBondedPoolName))
+	)
+	msg, err := stakingtypes.NewMsgCreateValidator(sdk.ValAddress(pk.Address()), pk,
+		sdk.NewInt64Coin("uatone", 60), stakingtypes.NewDescription("val", "", "", "", ""),
+		stakingtypes.NewCommissionRates(sdk.NewDecWithPrec(1, 1), sdk.NewDecWithPrec(2, 1), sdk.NewDecWithPrec(1, 2)),
+		sdk.OneInt())
+	require.NoError(err)
+
+	balances, err := expectedGenesisBalances(airdrop, "cosmos", nil)
+
+	require.NoError(err)
+	assert.Equal(map[string]sdk.Int{
+		valAcc.String(): sdk.NewInt(100),
+		addr.String():   sdk.NewInt(50),
+		reservedAddr:    sdk.NewInt(10),
+		distrModuleAddr: sdk.NewInt(20),
+	}, balances)
+
+	// the self-delegations of the validators are in the not-bonded pool
+	balances, err = expectedGenesisBalances(airdrop, "cosmos", []*stakingtypes.MsgCreateValidator{msg})
+
+	require.NoError(err)
+	assert.Equal(map[string]sdk.Int{
+		valAcc.String(): sdk.NewInt(40),
+		addr.String():   sdk.NewInt(50),
+		reservedAddr:    sdk.NewInt(10),
+		distrModuleAddr: sdk.NewInt(20),
+		notBondedPool:   sdk.NewInt(60),
+	}, balances)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// validatorPower is the $ATOM voting power delegated to a validator, and the
+// vote of the validator.
+type validatorPower struct {
+	address string
+	power   sdk.Dec
+	// vote is the option of the validator vote with the highest weight,
+	// OptionEmpty if the validator didn't vote.
+	vote govtypes.VoteOption
+}
+
+// addValidatorPowers adds the delegations of acc to powers.
+func addValidatorPowers(powers map[string]*validatorPower, acc Account) {
+	for _, del := range acc.Delegations {
+		if del.Chain != "" {
+			// validator of another chain
+			continue
+		}
+		p, ok := powers[del.ValidatorAddress]
+		if !ok {
+			p = &validatorPower{
+				address: del.ValidatorAddress,
+				power:   sdk.ZeroDec(),
+				vote:    mainVoteOption(del.Vote),
+			}
+			powers[del.ValidatorAddress] = p
+		}
+		p.power = p.power.Add(del.Amount)
+	}
+}
+
+// sortValidatorPowers returns powers sorted by decreasing power.
+func sortValidatorPowers(powers map[string]*validatorPower) []validatorPower {
+	sorted := make([]validatorPower, 0, len(powers))
+	for _, p := range powers {
+		sorted = append(sorted, *p)
+	}
+	slices.SortFunc(sorted, func(a, b validatorPower) int {
+		if c := b.power.BigInt().Cmp(a.power.BigInt()); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.address, b.address)
+	})
+	return sorted
+}
+
+// validatorsToReach returns the minimum number of validators, from the most
+// powerful, that hold more than perc of the total power.
+func validatorsToReach(validators []validatorPower, perc sdk.Dec) int {
+	total := sdk.ZeroDec()
+	for _, v := range validators {
+		total = total.Add(v.power)
+	}
+	var (
+		threshold = total.Mul(perc)
+		cumul     = sdk.ZeroDec()
+	)
+	for i, v := range validators {
+		cumul = cumul.Add(v.power)
+		if cumul.GT(threshold) {
+			return i + 1
+		}
+	}
+	return len(validators)
+}
+
+// reportTopValidators is the number of validators listed in the validators
+// section of the report.
+const reportTopValidators = 20
+
+// reportValidatorPowers adds to sec the concentration of the voting power,
+// and the most powerful validators with their cumulative share.
+func reportValidatorPowers(sec *reportSection, validators []validatorPower, infos validatorInfos) {
+	if len(validators) == 0 {
+		return
+	}
+	total := sdk.ZeroDec()
+	for _, v := range validators {
+		total = total.Add(v.power)
+	}
+	sec.text("%d validators hold 1/3 of the voting power, %d hold 1/2",
+		validatorsToReach(validators, sdk.OneDec().QuoInt64(3)), validatorsToReach(validators, sdk.NewDecWithPrec(5, 1)))
+	table := sec.table("#", "VALIDATOR", "VOTE", "$ATOM", "SHARE", "CUMULATIVE")
+	cumul := sdk.ZeroDec()
+	for i, v := range validators[:min(len(validators), reportTopValidators)] {
+		cumul = cumul.Add(v.power)
+		share, cumulShare := sdk.ZeroDec(), sdk.ZeroDec()
+		if total.IsPositive() {
+			share, cumulShare = v.power.Quo(total), cumul.Quo(total)
+		}
+		table.Append([]string{
+			fmt.Sprint(i + 1), infos.label(v.address), voteOptionLabel(v.vote),
+			humand(v.power), humanPercent(share), humanPercent(cumulShare),
+		})
+	}
+	if len(validators) > reportTopValidators {
+		sec.text("%d other validators", len(validators)-reportTopValidators)
+	}
+}
+
+// newValidatorConcentrationChart returns a bar chart of the cumulative share
+// of the voting power held by the validators, from the most powerful, with
+// each bar colored by the validator vote and labeled with its moniker.
+func newValidatorConcentrationChart(validators []validatorPower, infos validatorInfos, theme chartTheme) *charts.Bar {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title: "Voting power concentration per validator",
+			Subtitle: fmt.Sprintf("%d validators hold 1/3 of the voting power, %d hold 1/2",
+				validatorsToReach(validators, sdk.OneDec().QuoInt64(3)), validatorsToReach(validators, sdk.NewDecWithPrec(5, 1))),
+		}),
+		charts.WithLegendOpts(opts.Legend{Show: true, Right: "right", Orient: "vertical"}),
+		charts.WithTooltipOpts(opts.Tooltip{
+			Show:      true,
+			Formatter: opts.FuncOpts("function(params){ return params.name+' ('+params.seriesName+'): '+params.value.toFixed(2)+'%'}"),
+		}),
+		charts.WithXAxisOpts(opts.XAxis{Name: "Validators", AxisLabel: &opts.AxisLabel{Show: false}}),
+		charts.WithYAxisOpts(opts.YAxis{Name: "Cumulative voting power (%)", Max: 100}),
+	)
+	var (
+		xAxis = make([]string, len(validators))
+		total = sdk.ZeroDec()
+		cumul = sdk.ZeroDec()
+		// one series per vote category, so the legend shows the colors
+		series []chartGroup
+		data   [][]opts.BarData
+	)
+	for _, s := range theme.series() {
+		if !slices.Equal(s.Categories, []string{chartUnstaked}) {
+			series = append(series, s)
+			data = append(data, make([]opts.BarData, len(validators)))
+		}
+	}
+	for _, v := range validators {
+		total = total.Add(v.power)
+	}
+	for i, v := range validators {
+		xAxis[i] = infos.label(v.address)
+		cumul = cumul.Add(v.power)
+		for j, s := range series {
+			if slices.Contains(s.Categories, voteCategory(v.vote)) && total.IsPositive() {
+				data[j][i] = opts.BarData{Value: cumul.Quo(total).MulInt64(100).MustFloat64()}
+			} else {
+				data[j][i] = opts.BarData{Value: 0.0}
+			}
+		}
+	}
+	bar.SetXAxis(xAxis)
+	for j, s := range series {
+		bar.AddSeries(s.Label, data[j],
+			charts.WithBarChartOpts(opts.BarChart{Stack: "cumul"}),
+			charts.WithItemStyleOpts(opts.ItemStyle{Color: s.Color}),
+		)
+	}
+	return bar
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestValidatorPowers(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{
+			Address: "a", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(70),
+			Delegations: []Delegation{
+				{ValidatorAddress: "val1", Amount: sdk.NewDec(50), Vote: vote(govtypes.OptionYes)},
+				{ValidatorAddress: "val2", Amount: sdk.NewDec(20)},
+			},
+		},
+		{
+			Address: "b", LiquidAmount: sdk.NewDec(10), StakedAmount: sdk.NewDec(30), Vote: vote(govtypes.OptionNo),
+			Delegations: []Delegation{
+				{ValidatorAddress: "val3", Amount: sdk.NewDec(25), Vote: vote(govtypes.OptionNo)},
+				{ValidatorAddress: "val2", Amount: sdk.NewDec(5)},
+			},
+		},
+	}
+
+	airdrop, err := distribution(accounts, defaultDistriParams(), "")
+
+	require.NoError(err)
+	assert.Equal([]validatorPower{
+		{address: "val1", power: sdk.NewDec(50), vote: govtypes.OptionYes},
+		{address: "val2", power: sdk.NewDec(25), vote: govtypes.OptionEmpty},
+		{address: "val3", power: sdk.NewDec(25), vote: govtypes.OptionNo},
+	}, airdrop.validators)
+	assert.Equal(1, validatorsToReach(airdrop.validators, sdk.OneDec().QuoInt64(3)))
+	assert.Equal(2, validatorsToReach(airdrop.validators, sdk.NewDecWithPrec(5, 1)))
+	assert.NotNil(newValidatorConcentrationChart(airdrop.validators, nil, chartTheme{}))
+}
@@ -1,14 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"io"
+	"iter"
 	"slices"
+	"strings"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/components"
 	"github.com/go-echarts/go-echarts/v2/opts"
-	"github.com/pkg/browser"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
@@ -35,19 +37,61 @@ type airdrop struct {
 	// addresses contains the airdrop amount per address.
 	addresses       map[string]sdk.Int
 	addressesDetail []addrAmtDetail
+	// audit holds the decisions taken for each account.
+	audit []auditEntry
+	// streamed is set when the results of the addresses were streamed (see
+	// distributionStages.stream) instead of kept in addresses,
+	// addressesDetail and audit, which are empty.
+	streamed bool
+	// largest is the largest streamed allocation, for the supply alerts.
+	largest addressResult
 	// nonVotersMultiplier ensures that non-voters don't hold more than 1/3 of
 	// the supply
 	nonVotersMultiplier sdk.Dec
 	// $ATOM distribution
 	atom distrib
+	// $ATOM voting power per validator, by decreasing power
+	validators []validatorPower
 	// $ATONE distribution
 	atone distrib
-	// Amount of $ATOM slashed for the ICF
-	icfSlash sdk.Dec
+	// Amount of $ATOM slashed per entity group
+	entitySlashes map[string]sdk.Dec
+	// Amount of $ATONE the slashed $ATOM would have received, per entity group
+	entitySlashedAtone map[string]sdk.Dec
+	// Amount of $ATONE sent to the community pool by entity groups policies
+	entityCommunityPool sdk.Dec
+	// Allocations of the addresses that declined the airdrop
+	optOut optOutStats
+	// Top-ups given to the voters below the voter floor
+	voterFloor voterFloorStats
+	// Error budget of the rounding of the allocations to uatone
+	rounding roundingStats
+	// Allocations reduced to the address cap
+	addressCap addressCapStats
+	// Decisions of the account hook
+	hook hookStats
+	// Withholding ledger, and the escrow address credited with the withheld
+	// amounts
+	withholdings      []withholdingEntry
+	withholdingEscrow string
+	// escrowAllocation is the allocation of the escrow address when it's
+	// also a recipient of a streamed airdrop, held back to be streamed with
+	// the withheld amounts in a single record.
+	escrowAllocation sdk.Int
+	// Number of accounts per entry of params.typeMultipliers
+	typeMultiplied map[string]int
+	// Aggregate effect of the vote time multipliers
+	voteTime voteTimeStats
+	// Liquid balances of the validator operators given their vote multipliers
+	validatorLiquid validatorLiquidStats
+	// Normalization of the shares by the share bounds
+	shareBounds shareBoundsStats
 	// Amount minted for CP
 	communityPool sdk.Dec
 	// Amount minted for reserved address
 	reservedAddr sdk.Dec
+	// Amount minted for the staking incentives module account
+	stakingIncentives sdk.Dec
 }
 
 type addrAmtDetail struct {
@@ -58,7 +102,86 @@ type addrAmtDetail struct {
 	AbsDetail    amtDetail `json:"absDetail"`
 	DnvDetail    amtDetail `json:"dnvDetail"`
 	LiquidDetail amtDetail `json:"liquidDetail"`
-	Total        sdk.Dec   `json:"total"`
+	LockedDetail amtDetail `json:"lockedDetail"`
+	// TypeMultiplier is the multiplier of the account type, already applied
+	// to the AtoneAmt of each detail.
+	TypeMultiplier sdk.Dec `json:"typeMultiplier"`
+	Total          sdk.Dec `json:"total"`
+	// Sources are the amounts of each chain of the account, if it's merged
+	// from several chains.
+	Sources []SourceAmount `json:"sources,omitempty"`
+	// Validators is the breakdown of the staked allocation by delegation.
+	Validators []validatorDetail `json:"validators,omitempty"`
+}
+
+// validatorDetail is the part of the allocation of an address that comes from
+// one of its delegations.
+type validatorDetail struct {
+	ValidatorAddress string `json:"validatorAddress"`
+	// Chain is the prefix of the chain of a delegation merged from another
+	// chain, empty for the hub.
+	Chain string `json:"chain,omitempty"`
+	// Vote is the vote applied to the delegation: the direct vote of the
+	// delegator, or else the vote of the validator, empty if none voted or
+	// if the delegation is on another chain.
+	Vote     govtypes.WeightedVoteOptions `json:"vote,omitempty"`
+	AtomAmt  sdk.Dec                      `json:"atomAmt"`
+	AtoneAmt sdk.Dec                      `json:"atoneAmt"`
+}
+
+// validatorDetails splits the final $ATONE amount of each vote option of acc
+// between its delegations, pro rata of their $ATOM amount with that option.
+// The last delegation of an option gets the remainder of the rounding, so the
+// details sum to the staked allocation.
+func validatorDetails(acc Account, atoneAmts voteMap) []validatorDetail {
+	if len(acc.Delegations) == 0 {
+		return nil
+	}
+	var (
+		details  = make([]validatorDetail, len(acc.Delegations))
+		votes    = make([]govtypes.WeightedVoteOptions, len(acc.Delegations))
+		delTotal = sdk.ZeroDec()
+		atomAmts = newVoteMap()
+		given    = newVoteMap()
+		last     = make(map[govtypes.VoteOption]int)
+	)
+	for _, del := range acc.Delegations {
+		delTotal = delTotal.Add(del.Amount)
+	}
+	for i, del := range acc.Delegations {
+		vote := del.Vote
+		if len(acc.Vote) > 0 && del.Chain == "" {
+			vote = acc.Vote
+		}
+		atom := del.Amount
+		if delTotal.IsPositive() && !delTotal.Equal(acc.StakedAmount) {
+			// The staked amount is reduced (e.g. partial slash)
+			atom = atom.Mul(acc.StakedAmount).Quo(delTotal)
+		}
+		details[i] = validatorDetail{ValidatorAddress: del.ValidatorAddress, Chain: del.Chain, Vote: vote, AtomAmt: atom, AtoneAmt: sdk.ZeroDec()}
+		votes[i] = vote
+		if len(votes[i]) == 0 {
+			votes[i] = govtypes.NewNonSplitVoteOption(govtypes.OptionEmpty)
+		}
+		for _, o := range votes[i] {
+			atomAmts.add(o.Option, atom.Mul(o.Weight))
+			last[o.Option] = i
+		}
+	}
+	for i := range details {
+		for _, o := range votes[i] {
+			if !atomAmts[o.Option].IsPositive() {
+				continue
+			}
+			amt := atoneAmts[o.Option].Mul(details[i].AtomAmt.Mul(o.Weight)).Quo(atomAmts[o.Option])
+			if last[o.Option] == i {
+				amt = atoneAmts[o.Option].Sub(given[o.Option])
+			}
+			given.add(o.Option, amt)
+			details[i].AtoneAmt = details[i].AtoneAmt.Add(amt)
+		}
+	}
+	return details
 }
 
 type amtDetail struct {
@@ -76,6 +199,9 @@ type distrib struct {
 	votes voteMap
 	// unstaked is part of the distrib for unstaked amounts.
 	unstaked sdk.Dec
+	// locked is the part of unstaked that is still vesting (see
+	// applyVestingLocks).
+	locked sdk.Dec
 }
 
 type distriParams struct {
@@ -85,11 +211,75 @@ type distriParams struct {
 	malus              sdk.Dec
 	supplyFactor       sdk.Dec
 	supplyMintFactor   sdk.Dec
+	// targetSupply, if set, is the final $ATONE supply (in uatone) for which
+	// the supplyFactor is solved, see runTargetSupply.
+	targetSupply sdk.Int
+	// yesRatio, if set, is the ratio of the per-$ATOM allocation of the Yes
+	// votes over the one of the non-voters, for which the multiplier of the
+	// Yes votes is solved, see runYesRatio.
+	yesRatio sdk.Dec
+	// entityGroups are the addresses that get a specific policy (slash...)
+	entityGroups []entityGroup
+	// optOut are the addresses that declined the airdrop, their allocation is
+	// handled according to optOutPolicy (community-pool or burn).
+	optOut       []string
+	optOutPolicy string
+	// voteBuckets maps the vote options to the aligned, opposed and neutral
+	// buckets, nil means defaultVoteBuckets (prop848 interpretation).
+	voteBuckets voteBuckets
+	// abstainMultiplier, if not nil, is the multiplier of Abstain instead of
+	// the nonVotersMultiplier, and abstainBonusMalus, if not nil, its bonus
+	// (>1) or malus (<1).
+	abstainMultiplier sdk.Dec
+	abstainBonusMalus sdk.Dec
+	// voterFloor is the minimum $ATONE allocation (in uatone) of the addresses
+	// that voted Yes, No or NoWithVeto, funded from voterFloorPool
+	// (community-pool or reserved-address). Zero or nil disables it.
+	voterFloor     sdk.Int
+	voterFloorPool string
+	// addressCap is the maximum $ATONE allocation (in uatone) per address,
+	// the excess is removed from the supply. Zero or nil disables it.
+	addressCap sdk.Int
+	// typeMultipliers are applied to the allocations of the accounts of the
+	// given types, composed with the vote multipliers.
+	typeMultipliers typeMultipliers
+	// voteTiming, if not nil, rewards the early direct voters over the
+	// last-minute ones.
+	voteTiming *voteTiming
+	// hook, if not nil, can exclude or adjust the allocation of each account.
+	hook accountHook
+	// withholding, if not nil, withholds a part of the final allocations to
+	// its escrow address, recorded in the withholding ledger.
+	withholding withholdingModule
+	// dustPolicy is what to do with the net amount lost by the rounding of
+	// the allocations (none or community-pool).
+	dustPolicy string
+	// shareBounds, if set, are the min and max shares of the distribution per
+	// category, enforced by the shareFactors solved by runShareBounds.
+	shareBounds  shareBounds
+	shareFactors map[string]sdk.Dec
+	// lockedMultiplier, if not nil, is the factor applied to the liquid
+	// multiplier for the locked vesting amounts, nil gives them the liquid
+	// multiplier.
+	lockedMultiplier sdk.Dec
+	// stakingIncentivesPerc, if positive, is the share of the minted supply
+	// sent to the staking incentives module account instead of the community
+	// pool and the reserved address.
+	stakingIncentivesPerc sdk.Dec
+	// validatorLiquid, if true, gives the liquid amount of the validator
+	// operator accounts that voted the multipliers of their own vote instead
+	// of the liquid multiplier of the non-voters. The nonVotersMultiplier is
+	// solved without it.
+	validatorLiquid bool
 }
 
 func (d distriParams) String() string {
-	return fmt.Sprintf("Yes x%.1f / No x%.1f",
+	s := fmt.Sprintf("Yes x%.1f / No x%.1f",
 		d.yesVotesMultiplier.MustFloat64(), d.noVotesMultiplier.MustFloat64())
+	if d.hasAbstainMultiplier() {
+		s += fmt.Sprintf(" / Abstain x%.1f", d.abstainMultiplier.MustFloat64())
+	}
+	return s
 }
 
 func defaultDistriParams() distriParams {
@@ -100,6 +290,7 @@ func defaultDistriParams() distriParams {
 		malus:              sdk.NewDecWithPrec(97, 2),       // -3% malus
 		supplyFactor:       sdk.NewDecWithPrec(1, 1),        // Decrease final supply by a factor of 10
 		supplyMintFactor:   sdk.OneDec().Quo(sdk.NewDec(9)), // 1/9 of the total supply is minted for the CP and a reserved address
+		entityGroups:       defaultEntityGroups(),
 	}
 }
 
@@ -112,91 +303,514 @@ func (d distrib) votePercentages() map[govtypes.VoteOption]sdk.Dec {
 }
 
 func distribution(accounts []Account, params distriParams, prefix string) (airdrop, error) {
+	return distributionSeq(context.Background(), slices.Values(accounts), params, prefix)
+}
+
+// distributionSeq is like distribution but iterates over accounts, which
+// allows to feed the accounts from a compactAccounts.
+func distributionSeq(ctx context.Context, accounts iter.Seq[Account], params distriParams, prefix string) (airdrop, error) {
+	return distributionCheckpointed(ctx, accounts, params, prefix, checkpointConfig{})
+}
+
+// distributionCheckpointed is like distributionSeq but periodically writes the
+// accumulated state in a checkpoint, and can resume from one.
+func distributionCheckpointed(ctx context.Context, accounts iter.Seq[Account], params distriParams, prefix string, cp checkpointConfig) (airdrop, error) {
+	return newDistributionStages(accounts).run(ctx, params, prefix, cp)
+}
+
+// distributionStages splits the distribution of accounts in 3 stages:
+//   - aggregate: the $ATOM distribution, which doesn't depend on the params;
+//   - solve: the nonVotersMultiplier, which depends on the multipliers, the
+//     vote buckets and the address cap;
+//   - allocate: the $ATONE allocation of each account.
+//
+// The results of the first 2 stages are cached, so parameter sweeps over the
+// same accounts only run the allocate stage for each set of params.
+type distributionStages struct {
+	accounts iter.Seq[Account]
+	// atom and validators are the results of the aggregate stage, atom is
+	// nil until it has run.
+	atom       *distrib
+	validators []validatorPower
+	// count is the number of accounts, known after the aggregate stage.
+	count int
+	// solved holds the results of the solve stage that required a pass over
+	// the accounts (address cap), or that were precomputed (see
+	// ComputeAllocation), by solveKey.
+	solved map[string]solvedMultiplier
+	// stream, if set, receives the allocation of each address as soon as the
+	// allocate stage has computed it, and the results of the addresses
+	// aren't kept in the airdrop.
+	stream func(addressResult) error
+	// subset is true when the accounts are only a part of the distribution
+	// (e.g. a single account), so the pools can't fund the voter floor.
+	subset bool
+	// addresses caches the addresses converted to the prefix of the runs,
+	// unless noAddressCache is set to save its memory.
+	addresses      *bech32Cache
+	noAddressCache bool
+}
+
+type solvedMultiplier struct {
+	nonVotersMultiplier sdk.Dec
+	addressCap          addressCapStats
+}
+
+func newDistributionStages(accounts iter.Seq[Account]) *distributionStages {
+	return &distributionStages{
+		accounts: accounts,
+		solved:   make(map[string]solvedMultiplier),
+	}
+}
+
+// convertAddress returns addr encoded with prefix, converted once for all
+// the runs of s.
+func (s *distributionStages) convertAddress(addr, prefix string) (string, error) {
+	if s.noAddressCache || s.stream != nil {
+		// A stream has a single run
+		bz, err := appendBech32Prefix(nil, addr, prefix)
+		return string(bz), err
+	}
+	if s.addresses == nil || s.addresses.prefix != prefix {
+		s.addresses = newBech32Cache(prefix)
+	}
+	return s.addresses.convert(addr)
+}
+
+// solveKey returns the params that determine the nonVotersMultiplier solved
+// with an address cap.
+func (d distriParams) solveKey() string {
+	var buckets []string
+	for _, opt := range allVoteOptions {
+		buckets = append(buckets, d.voteBucket(opt))
+	}
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s/%s/%s/%s/%s", d.yesVotesMultiplier, d.noVotesMultiplier,
+		d.bonus, d.malus, d.supplyFactor, d.addressCap, strings.Join(buckets, ","),
+		d.abstainMultiplier, d.abstainBonusMalus, d.lockedMultiplier)
+}
+
+// aggregate returns the $ATOM distribution of the accounts, and records the
+// voting power per validator.
+func (s *distributionStages) aggregate(ctx context.Context) (distrib, error) {
+	if s.atom != nil {
+		return *s.atom, nil
+	}
+	// The $ATOM is accumulated with the fixed-point kernel
+	fixedAtom := newFixedDistrib()
+	powers := make(map[string]*validatorPower)
+	progress.startStage("aggregate", 0)
+	count := 0
+	for acc := range s.accounts {
+		if err := ctx.Err(); err != nil {
+			return fixedAtom.distrib(), err
+		}
+		count++
+		progress.advance(1)
+		if err := fixedAtom.add(acc); err != nil {
+			return fixedAtom.distrib(), err
+		}
+		addValidatorPowers(powers, acc)
+	}
+	atom := fixedAtom.distrib()
+	s.atom = &atom
+	s.validators = sortValidatorPowers(powers)
+	s.count = count
+	progress.finishStage()
+	progress.setAggregate("$ATOM supply", humand(atom.supply))
+	return atom, nil
+}
+
+// solve returns the nonVotersMultiplier that gives 33% of the distribution to
+// the non-voters (neutral bucket).
+func (s *distributionStages) solve(ctx context.Context, atom distrib, params distriParams) (solvedMultiplier, error) {
+	key := params.solveKey()
+	if cached, ok := s.solved[key]; ok {
+		return cached, nil
+	}
+	var (
+		bucketAtomAmts = map[string]sdk.Dec{
+			voteBucketOpposed: sdk.ZeroDec(),
+			voteBucketAligned: sdk.ZeroDec(),
+			voteBucketNeutral: sdk.ZeroDec(),
+			voteBucketFixed:   sdk.ZeroDec(),
+		}
+		targetNonVotersPerc = sdk.NewDecWithPrec(33, 2)
+	)
+	for _, opt := range []govtypes.VoteOption{
+		govtypes.OptionYes, govtypes.OptionNo, govtypes.OptionNoWithVeto,
+		govtypes.OptionAbstain, govtypes.OptionEmpty,
+	} {
+		b := params.voteBucket(opt)
+		bucketAtomAmts[b] = bucketAtomAmts[b].Add(atom.votes[opt])
+	}
+	var (
+		opposedAtoneTotalAmt = bucketAtomAmts[voteBucketOpposed].Mul(params.yesVotesMultiplier)
+		alignedAtoneTotalAmt = bucketAtomAmts[voteBucketAligned].Mul(params.noVotesMultiplier)
+		fixedAtoneTotalAmt   = sdk.ZeroDec()
+		noVotersAtomTotalAmt = bucketAtomAmts[voteBucketNeutral].Add(atom.unstaked)
+	)
+	if params.hasLockedMultiplier() {
+		// The locked amounts only weigh their share of the liquid multiplier
+		noVotersAtomTotalAmt = noVotersAtomTotalAmt.Sub(atom.locked).Add(atom.locked.Mul(params.lockedMultiplier))
+	}
+	if params.hasAbstainMultiplier() {
+		fixedAtoneTotalAmt = bucketAtomAmts[voteBucketFixed].Mul(params.abstainMultiplier)
+	}
+	if !noVotersAtomTotalAmt.IsPositive() {
+		return solvedMultiplier{}, fmt.Errorf("solve: no $ATOM of non-voters nor liquid (%s), the nonVotersMultiplier can't be solved", noVotersAtomTotalAmt)
+	}
+	// Formula is:
+	// nonVotersMultiplier = (t x (opposedAtone + alignedAtone + fixedAtone)) / ((1 - t) x nonVoterAtom)
+	// where t is the targetNonVotersPerc
+	solved := solvedMultiplier{
+		nonVotersMultiplier: targetNonVotersPerc.Mul(opposedAtoneTotalAmt.Add(alignedAtoneTotalAmt).Add(fixedAtoneTotalAmt)).
+			Quo((sdk.OneDec().Sub(targetNonVotersPerc)).Mul(noVotersAtomTotalAmt)),
+		addressCap: addressCapStats{excess: sdk.ZeroDec(), residual: sdk.ZeroDec()},
+	}
+	if !params.hasAddressCap() {
+		return solved, nil
+	}
+	// The closed-form formula doesn't hold with capped allocations, use it
+	// as the starting point of the solver.
+	m, stats, err := solveNonVotersMultiplier(ctx, s.accounts, params, solved.nonVotersMultiplier, targetNonVotersPerc)
+	if err != nil {
+		return solved, err
+	}
+	solved = solvedMultiplier{nonVotersMultiplier: m, addressCap: stats}
+	s.solved[key] = solved
+	return solved, nil
+}
+
+// run computes the distribution of the accounts for params, reusing the
+// cached aggregate and solve stages. See distributionCheckpointed for cp.
+func (s *distributionStages) run(ctx context.Context, params distriParams, prefix string, cp checkpointConfig) (airdrop, error) {
+	if params.hasYesRatio() {
+		return s.runYesRatio(ctx, params, prefix, cp)
+	}
+	if params.hasTargetSupply() {
+		return s.runTargetSupply(ctx, params, prefix, cp)
+	}
+	if params.hasShareBounds() && params.shareFactors == nil {
+		return s.runShareBounds(ctx, params, prefix, cp)
+	}
+	accounts := s.accounts
+	atom, err := s.aggregate(ctx)
+	if err != nil {
+		return airdrop{}, err
+	}
+	solved, err := s.solve(ctx, atom, params)
+	if err != nil {
+		return airdrop{}, err
+	}
+	if err := checkDecs("", "solve", namedDec{"nonVotersMultiplier", solved.nonVotersMultiplier}); err != nil {
+		return airdrop{}, err
+	}
+	progress.setAggregate("nonVotersMultiplier", solved.nonVotersMultiplier.String())
 	airdrop := airdrop{
-		params:    params,
-		addresses: make(map[string]sdk.Int),
-		icfSlash:  sdk.ZeroDec(),
-		atom: distrib{
-			supply:   sdk.ZeroDec(),
-			votes:    newVoteMap(),
-			unstaked: sdk.ZeroDec(),
-		},
+		params:              params,
+		addresses:           make(map[string]sdk.Int),
+		streamed:            s.stream != nil,
+		entitySlashes:       make(map[string]sdk.Dec),
+		entitySlashedAtone:  make(map[string]sdk.Dec),
+		entityCommunityPool: sdk.ZeroDec(),
+		optOut:              optOutStats{atom: sdk.ZeroDec(), atone: sdk.ZeroDec()},
+		voterFloor:          voterFloorStats{topUp: sdk.ZeroInt()},
+		rounding:            newRoundingStats(),
+		hook:                hookStats{excludedAmt: sdk.ZeroDec(), delta: sdk.ZeroDec()},
+		typeMultiplied:      make(map[string]int),
+		voteTime:            newVoteTimeStats(),
+		validatorLiquid:     validatorLiquidStats{liquid: sdk.ZeroDec(), delta: sdk.ZeroDec()},
+		atom:                atom,
+		validators:          s.validators,
 		atone: distrib{
 			supply:   sdk.ZeroDec(),
 			votes:    newVoteMap(),
 			unstaked: sdk.ZeroDec(),
+			locked:   sdk.ZeroDec(),
 		},
+		nonVotersMultiplier: solved.nonVotersMultiplier,
+		addressCap:          solved.addressCap,
 	}
-	for _, acc := range accounts {
-		var (
-			voteWeights = acc.voteWeights()
-
-			// Detail of vote distribution for $ATOM
-			yesAtomAmt        = voteWeights[govtypes.OptionYes].Mul(acc.StakedAmount)
-			noAtomAmt         = voteWeights[govtypes.OptionNo].Mul(acc.StakedAmount)
-			noWithVetoAtomAmt = voteWeights[govtypes.OptionNoWithVeto].Mul(acc.StakedAmount)
-			abstainAtomAmt    = voteWeights[govtypes.OptionAbstain].Mul(acc.StakedAmount)
-			noVoteAtomAmt     = voteWeights[govtypes.OptionEmpty].Mul(acc.StakedAmount)
-		)
-		// increment $ATOM votes
-		airdrop.atom.votes.add(govtypes.OptionYes, yesAtomAmt)
-		airdrop.atom.votes.add(govtypes.OptionNo, noAtomAmt)
-		airdrop.atom.votes.add(govtypes.OptionNoWithVeto, noWithVetoAtomAmt)
-		airdrop.atom.votes.add(govtypes.OptionAbstain, abstainAtomAmt)
-		airdrop.atom.votes.add(govtypes.OptionEmpty, noVoteAtomAmt)
-		// increment $ATOM supply
-		airdrop.atom.supply = airdrop.atom.supply.Add(acc.StakedAmount.Add(acc.LiquidAmount))
-		airdrop.atom.unstaked = airdrop.atom.unstaked.Add(acc.LiquidAmount)
-	}
-
-	// Compute nonVotersMultiplier to have non-voters <= 33%
 	var (
-		yesAtoneTotalAmt     = airdrop.atom.votes[govtypes.OptionYes].Mul(params.yesVotesMultiplier)
-		noAtoneTotalAmt      = airdrop.atom.votes[govtypes.OptionNo].Add(airdrop.atom.votes[govtypes.OptionNoWithVeto]).Mul(params.noVotesMultiplier)
-		noVotersAtomTotalAmt = airdrop.atom.votes[govtypes.OptionAbstain].Add(airdrop.atom.votes[govtypes.OptionEmpty]).Add(airdrop.atom.unstaked)
-		targetNonVotersPerc  = sdk.NewDecWithPrec(33, 2)
+		yesMultiplier, yesBonusMalus               = params.voteMultiplier(govtypes.OptionYes, airdrop.nonVotersMultiplier)
+		noMultiplier, noBonusMalus                 = params.voteMultiplier(govtypes.OptionNo, airdrop.nonVotersMultiplier)
+		noWithVetoMultiplier, noWithVetoBonusMalus = params.voteMultiplier(govtypes.OptionNoWithVeto, airdrop.nonVotersMultiplier)
+		abstainMultiplier, abstainBonusMalus       = params.voteMultiplier(govtypes.OptionAbstain, airdrop.nonVotersMultiplier)
+		noVoteMultiplier, noVoteBonusMalus         = params.voteMultiplier(govtypes.OptionEmpty, airdrop.nonVotersMultiplier)
 	)
-	// Formula is:
-	// nonVotersMultiplier = (t x (yesAtone + noAtone)) / ((1 - t) x nonVoterAtom)
-	// where t is the targetNonVotersPerc
-	airdrop.nonVotersMultiplier = targetNonVotersPerc.Mul(yesAtoneTotalAmt.Add(noAtoneTotalAmt)).
-		Quo((sdk.OneDec().Sub(targetNonVotersPerc)).Mul(noVotersAtomTotalAmt))
+	err = checkDecs("", "multipliers",
+		namedDec{"yes multiplier", yesMultiplier}, namedDec{"yes bonus/malus", yesBonusMalus},
+		namedDec{"no multiplier", noMultiplier}, namedDec{"no bonus/malus", noBonusMalus},
+		namedDec{"noWithVeto multiplier", noWithVetoMultiplier}, namedDec{"noWithVeto bonus/malus", noWithVetoBonusMalus},
+		namedDec{"abstain multiplier", abstainMultiplier}, namedDec{"abstain bonus/malus", abstainBonusMalus},
+		namedDec{"didNotVote multiplier", noVoteMultiplier}, namedDec{"didNotVote bonus/malus", noVoteBonusMalus},
+		namedDec{"supplyFactor", params.supplyFactor},
+	)
+	if err != nil {
+		return airdrop, err
+	}
+
+	var (
+		// Liquid amount gets the same multiplier as those who didn't vote.
+		liquidMultiplier = airdrop.nonVotersMultiplier.Mul(params.malus).Mul(params.shareFactor("liquid"))
 
-	for _, acc := range accounts {
-		if slices.Contains(icfWallets, acc.Address) {
-			// Slash ICF
-			airdrop.icfSlash = airdrop.icfSlash.Add(acc.LiquidAmount).Add(acc.StakedAmount)
+		// Locked vesting amount gets its share of the liquid multiplier.
+		lockedMultiplier = liquidMultiplier.Mul(params.lockedFactor())
+
+		// Apply airdrop multipliers, with the default vote buckets:
+		// Yes:         x yesVotesMultiplier
+		// No:         	x noVotesMultiplier
+		// NoWithVeto: 	x noVotesMultiplier x bonus
+		// Abstain:    	x nonVotersMultiplier (or abstainMultiplier x abstainBonusMalus)
+		// Didn't vote: x nonVotersMultiplier x malus
+		// The share factors are 1 without share bounds.
+		kernel = newAllocationKernel([5][]sdk.Dec{
+			govtypes.OptionYes:        {yesMultiplier, yesBonusMalus, params.shareFactor("yes"), params.supplyFactor},
+			govtypes.OptionNo:         {noMultiplier, noBonusMalus, params.shareFactor("no"), params.supplyFactor},
+			govtypes.OptionNoWithVeto: {noWithVetoMultiplier, noWithVetoBonusMalus, params.shareFactor("noWithVeto"), params.supplyFactor},
+			govtypes.OptionAbstain:    {abstainMultiplier, abstainBonusMalus, params.shareFactor("abstain"), params.supplyFactor},
+			govtypes.OptionEmpty:      {noVoteMultiplier, noVoteBonusMalus, params.shareFactor("didNotVote"), params.supplyFactor},
+		},
+			[]sdk.Dec{liquidMultiplier, params.supplyFactor},
+			[]sdk.Dec{lockedMultiplier, params.supplyFactor})
+	)
+	var operatorKeys map[addrKey]bool
+	if params.validatorLiquid {
+		operatorKeys = validatorOperatorKeys(s.validators)
+	}
+	groupsByAddr, err := entityGroupsByAddr(params.entityGroups)
+	if err != nil {
+		return airdrop, err
+	}
+	for _, g := range params.entityGroups {
+		airdrop.entitySlashes[g.Name] = sdk.ZeroDec()
+		airdrop.entitySlashedAtone[g.Name] = sdk.ZeroDec()
+	}
+	if params.hasVoterFloor() {
+		if err := validateVoterFloorPool(params.voterFloorPool); err != nil {
+			return airdrop, err
+		}
+	}
+	if err := validateDustPolicy(params.dustPolicy); err != nil {
+		return airdrop, err
+	}
+	optedOut := make(map[string]bool, len(params.optOut))
+	if len(params.optOut) > 0 {
+		if err := validateOptOutPolicy(params.optOutPolicy); err != nil {
+			return airdrop, err
+		}
+		for _, addr := range params.optOut {
+			optedOut[addr] = true
+		}
+	}
+	var processed, skip int
+	if cp.resume != nil {
+		if err := cp.resume.restore(&airdrop); err != nil {
+			return airdrop, err
+		}
+		skip = cp.resume.Processed
+	}
+	var escrowKey addrKey
+	if params.withholding != nil && airdrop.streamed && !s.subset {
+		escrowKey, err = newAddrKey(params.withholding.escrowAddress())
+		if err != nil {
+			return airdrop, fmt.Errorf("withholding escrow address: %w", err)
+		}
+	}
+	// record keeps the decisions taken for an account, unless they are
+	// streamed.
+	record := func(audit auditEntry) {
+		if !airdrop.streamed {
+			airdrop.audit = append(airdrop.audit, audit)
+		}
+	}
+	progress.startStage("allocate", s.count)
+	for acc := range accounts {
+		if err := ctx.Err(); err != nil {
+			return airdrop, err
+		}
+		progress.advance(1)
+		if processed%progressAggregateEvery == 0 {
+			progress.setAggregate("$ATONE distributed", humand(airdrop.atone.supply))
+		}
+		if processed < skip {
+			// Already processed before the checkpoint
+			processed++
 			continue
 		}
+		if cp.file != "" && cp.every > 0 && processed > skip && processed%cp.every == 0 {
+			err := writeCheckpoint(ctx, cp.file, newDistributionCheckpoint(airdrop, processed))
+			if err != nil {
+				return airdrop, fmt.Errorf("checkpoint: %w", err)
+			}
+		}
+		processed++
+		err := checkDecs(acc.Address, "input",
+			namedDec{"liquid amount", acc.LiquidAmount}, namedDec{"staked amount", acc.StakedAmount},
+			namedDec{"locked amount", acc.lockedAmount()})
+		if err != nil {
+			return airdrop, err
+		}
+		voteWeights := acc.voteWeights()
+		audit := newAuditEntry(acc, voteWeights)
+		group, inGroup := groupsByAddr[acc.Address]
+		if inGroup {
+			audit.Policies = append(audit.Policies, group.auditPolicy())
+			switch group.Policy {
+			case entityPolicySlash:
+				airdrop.entitySlashes[group.Name] = airdrop.entitySlashes[group.Name].
+					Add(acc.LiquidAmount).Add(acc.lockedAmount()).Add(acc.StakedAmount)
+				airdrop.entitySlashedAtone[group.Name] = airdrop.entitySlashedAtone[group.Name].
+					Add(params.accountAirdropAmt(acc, voteWeights, airdrop.nonVotersMultiplier))
+				record(audit)
+				continue
+			case entityPolicyPartialSlash:
+				slashed := acc.LiquidAmount.Add(acc.lockedAmount()).Add(acc.StakedAmount).Mul(group.SlashPercent)
+				airdrop.entitySlashes[group.Name] = airdrop.entitySlashes[group.Name].Add(slashed)
+				airdrop.entitySlashedAtone[group.Name] = airdrop.entitySlashedAtone[group.Name].Add(
+					params.accountAirdropAmt(acc, voteWeights, airdrop.nonVotersMultiplier).Mul(group.SlashPercent))
+				// vote weights are ratios, so only the amounts need to be reduced.
+				keep := sdk.OneDec().Sub(group.SlashPercent)
+				acc.LiquidAmount = acc.LiquidAmount.Mul(keep)
+				if acc.LockedAmount != nil {
+					locked := acc.LockedAmount.Mul(keep)
+					acc.LockedAmount = &locked
+				}
+				acc.StakedAmount = acc.StakedAmount.Mul(keep)
+			}
+		}
 
+		alloc, err := kernel.allocate(acc, voteWeights)
+		if err != nil {
+			return airdrop, err
+		}
 		var (
-			voteWeights       = acc.voteWeights()
-			yesAtomAmt        = voteWeights[govtypes.OptionYes].Mul(acc.StakedAmount)
-			noAtomAmt         = voteWeights[govtypes.OptionNo].Mul(acc.StakedAmount)
-			noWithVetoAtomAmt = voteWeights[govtypes.OptionNoWithVeto].Mul(acc.StakedAmount)
-			abstainAtomAmt    = voteWeights[govtypes.OptionAbstain].Mul(acc.StakedAmount)
-			noVoteAtomAmt     = voteWeights[govtypes.OptionEmpty].Mul(acc.StakedAmount)
-			// Apply airdrop multipliers:
-			// Yes:         x yesVotesMultiplier
-			// No:         	x noVotesMultiplier
-			// NoWithVeto: 	x noVotesMultiplier x bonus
-			// Abstain:    	x nonVotersMultiplier
-			// Didn't vote: x nonVotersMultiplier x malus
-			yesAirdropAmt        = yesAtomAmt.Mul(params.yesVotesMultiplier).Mul(params.supplyFactor)
-			noAirdropAmt         = noAtomAmt.Mul(params.noVotesMultiplier).Mul(params.supplyFactor)
-			noWithVetoAirdropAmt = noWithVetoAtomAmt.Mul(params.noVotesMultiplier).Mul(params.bonus).Mul(params.supplyFactor)
-			abstainAirdropAmt    = abstainAtomAmt.Mul(airdrop.nonVotersMultiplier).Mul(params.supplyFactor)
-			noVoteAirdropAmt     = noVoteAtomAmt.Mul(airdrop.nonVotersMultiplier).Mul(params.malus).Mul(params.supplyFactor)
-
-			// Liquid amount gets the same multiplier as those who didn't vote.
-			liquidMultiplier = airdrop.nonVotersMultiplier.Mul(params.malus)
-
-			// total airdrop for this account
-			liquidAirdropAmt = acc.LiquidAmount.Mul(liquidMultiplier).Mul(params.supplyFactor)
-			stakedAirdropAmt = yesAirdropAmt.Add(noAirdropAmt).Add(noWithVetoAirdropAmt).
-						Add(abstainAirdropAmt).Add(noVoteAirdropAmt)
-			airdropAmt = liquidAirdropAmt.Add(stakedAirdropAmt)
+			yesAtomAmt        = alloc.atom[govtypes.OptionYes]
+			noAtomAmt         = alloc.atom[govtypes.OptionNo]
+			noWithVetoAtomAmt = alloc.atom[govtypes.OptionNoWithVeto]
+			abstainAtomAmt    = alloc.atom[govtypes.OptionAbstain]
+			noVoteAtomAmt     = alloc.atom[govtypes.OptionEmpty]
+
+			yesAirdropAmt        = alloc.atone[govtypes.OptionYes]
+			noAirdropAmt         = alloc.atone[govtypes.OptionNo]
+			noWithVetoAirdropAmt = alloc.atone[govtypes.OptionNoWithVeto]
+			abstainAirdropAmt    = alloc.atone[govtypes.OptionAbstain]
+			noVoteAirdropAmt     = alloc.atone[govtypes.OptionEmpty]
+
+			liquidAirdropAmt = alloc.liquid
+			lockedAirdropAmt = alloc.locked
+			airdropAmt       = alloc.total
+		)
+		if len(acc.Vote) > 0 && operatorKeys != nil && isValidatorOperator(acc, operatorKeys) {
+			// The liquid amount of the validator gets the multipliers of its vote
+			votedAmt := kernel.votedLiquid(acc)
+			audit.Policies = append(audit.Policies, policyValidatorLiquid)
+			airdrop.validatorLiquid.accounts++
+			airdrop.validatorLiquid.liquid = airdrop.validatorLiquid.liquid.Add(acc.LiquidAmount)
+			airdrop.validatorLiquid.delta = airdrop.validatorLiquid.delta.Add(votedAmt.Sub(liquidAirdropAmt))
+			airdropAmt = airdropAmt.Sub(liquidAirdropAmt).Add(votedAmt)
+			liquidAirdropAmt = votedAmt
+		}
+		err = checkDecs(acc.Address, "allocate",
+			namedDec{"yes allocation", yesAirdropAmt}, namedDec{"no allocation", noAirdropAmt},
+			namedDec{"noWithVeto allocation", noWithVetoAirdropAmt}, namedDec{"abstain allocation", abstainAirdropAmt},
+			namedDec{"didNotVote allocation", noVoteAirdropAmt}, namedDec{"liquid allocation", liquidAirdropAmt},
+			namedDec{"locked allocation", lockedAirdropAmt},
 		)
+		if err != nil {
+			return airdrop, err
+		}
+		if inGroup && group.Policy == entityPolicyCommunityPool {
+			airdrop.entitySlashes[group.Name] = airdrop.entitySlashes[group.Name].
+				Add(acc.LiquidAmount).Add(acc.lockedAmount()).Add(acc.StakedAmount)
+			airdrop.entityCommunityPool = airdrop.entityCommunityPool.Add(airdropAmt)
+			audit.Amount = airdropAmt
+			record(audit)
+			continue
+		}
+		if optedOut[acc.Address] {
+			audit.Policies = append(audit.Policies, policyOptOut+"-"+params.optOutPolicy)
+			airdrop.optOut.accounts++
+			airdrop.optOut.atom = airdrop.optOut.atom.Add(acc.LiquidAmount).Add(acc.lockedAmount()).Add(acc.StakedAmount)
+			airdrop.optOut.atone = airdrop.optOut.atone.Add(airdropAmt)
+			audit.Amount = airdropAmt
+			record(audit)
+			continue
+		}
+		// scale multiplies each part of the allocation by ratio, and returns the
+		// new allocation.
+		scale := func(ratio sdk.Dec) sdk.Dec {
+			yesAirdropAmt = yesAirdropAmt.Mul(ratio)
+			noAirdropAmt = noAirdropAmt.Mul(ratio)
+			noWithVetoAirdropAmt = noWithVetoAirdropAmt.Mul(ratio)
+			abstainAirdropAmt = abstainAirdropAmt.Mul(ratio)
+			noVoteAirdropAmt = noVoteAirdropAmt.Mul(ratio)
+			liquidAirdropAmt = liquidAirdropAmt.Mul(ratio)
+			lockedAirdropAmt = lockedAirdropAmt.Mul(ratio)
+			return yesAirdropAmt.Add(noAirdropAmt).Add(noWithVetoAirdropAmt).
+				Add(abstainAirdropAmt).Add(noVoteAirdropAmt).Add(liquidAirdropAmt).Add(lockedAirdropAmt)
+		}
+		typeMultiplier := sdk.OneDec()
+		if key, mult, ok := params.typeMultipliers.get(acc.Type); ok {
+			if err := checkDecs(acc.Address, "type multiplier", namedDec{key, mult}); err != nil {
+				return airdrop, err
+			}
+			typeMultiplier = mult
+			airdrop.typeMultiplied[key]++
+			airdropAmt = scale(typeMultiplier)
+		}
+		voteTimeMultiplier := sdk.OneDec()
+		if params.voteTiming != nil && acc.Vote != nil {
+			if mult, ok := params.voteTiming.multiplier(acc.Address); ok {
+				if err := checkDecs(acc.Address, "vote time", namedDec{"vote time multiplier", mult}); err != nil {
+					return airdrop, err
+				}
+				voteTimeMultiplier = mult
+				multipliedAmt := scale(mult)
+				airdrop.voteTime.add(mult, airdropAmt, multipliedAmt)
+				airdropAmt = multipliedAmt
+			}
+		}
+		if params.hook != nil {
+			res, err := params.hook.adjust(hookRequest{
+				Address:      acc.Address,
+				Type:         acc.Type,
+				LiquidAmount: acc.LiquidAmount,
+				StakedAmount: acc.StakedAmount,
+				LockedAmount: acc.lockedAmount(),
+				VoteWeights:  audit.VoteWeights,
+				Amount:       airdropAmt,
+			})
+			if err != nil {
+				return airdrop, err
+			}
+			if res.Exclude {
+				audit.Policies = append(audit.Policies, res.auditPolicy())
+				airdrop.hook.excluded++
+				airdrop.hook.excludedAmt = airdrop.hook.excludedAmt.Add(airdropAmt)
+				audit.Amount = airdropAmt
+				record(audit)
+				continue
+			}
+			if !res.Multiplier.IsNil() && !res.Multiplier.Equal(sdk.OneDec()) {
+				if res.Multiplier.IsNegative() {
+					return airdrop, fmt.Errorf("hook: negative multiplier %s for %s", res.Multiplier, acc.Address)
+				}
+				audit.Policies = append(audit.Policies, res.auditPolicy())
+				adjustedAmt := scale(res.Multiplier)
+				airdrop.hook.adjusted++
+				airdrop.hook.delta = airdrop.hook.delta.Add(adjustedAmt.Sub(airdropAmt))
+				airdropAmt = adjustedAmt
+			}
+		}
+		if params.hasAddressCap() && airdropAmt.GT(params.addressCap.ToLegacyDec()) {
+			// Scale down each part of the allocation to reach the cap
+			cappedAmt := scale(params.addressCap.ToLegacyDec().Quo(airdropAmt))
+			audit.Policies = append(audit.Policies, policyAddressCap)
+			airdrop.addressCap.accounts++
+			airdrop.addressCap.excess = airdrop.addressCap.excess.Add(airdropAmt.Sub(cappedAmt))
+			airdropAmt = cappedAmt
+		}
 		// increment airdrop votes
 		airdrop.atone.votes.add(govtypes.OptionYes, yesAirdropAmt)
 		airdrop.atone.votes.add(govtypes.OptionNo, noAirdropAmt)
@@ -205,80 +819,211 @@ func distribution(accounts []Account, params distriParams, prefix string) (airdr
 		airdrop.atone.votes.add(govtypes.OptionEmpty, noVoteAirdropAmt)
 		// increment airdrop supply
 		airdrop.atone.supply = airdrop.atone.supply.Add(airdropAmt)
-		airdrop.atone.unstaked = airdrop.atone.unstaked.Add(liquidAirdropAmt)
+		airdrop.atone.unstaked = airdrop.atone.unstaked.Add(liquidAirdropAmt).Add(lockedAirdropAmt)
+		airdrop.atone.locked = airdrop.atone.locked.Add(lockedAirdropAmt)
+		audit.Multipliers = map[string]sdk.Dec{
+			"yes":          yesMultiplier.Mul(yesBonusMalus),
+			"no":           noMultiplier.Mul(noBonusMalus),
+			"noWithVeto":   noWithVetoMultiplier.Mul(noWithVetoBonusMalus),
+			"abstain":      abstainMultiplier.Mul(abstainBonusMalus),
+			"didNotVote":   noVoteMultiplier.Mul(noVoteBonusMalus),
+			"liquid":       liquidMultiplier,
+			"supplyFactor": params.supplyFactor,
+		}
+		if !typeMultiplier.Equal(sdk.OneDec()) {
+			audit.Multipliers["accountType"] = typeMultiplier
+		}
+		if !voteTimeMultiplier.Equal(sdk.OneDec()) {
+			audit.Multipliers["voteTime"] = voteTimeMultiplier
+		}
+		if acc.lockedAmount().IsPositive() {
+			audit.Multipliers["locked"] = lockedMultiplier
+		}
+		for cat, f := range params.shareFactors {
+			if cat != "liquid" {
+				// liquidMultiplier already includes its factor
+				audit.Multipliers[cat] = audit.Multipliers[cat].Mul(f)
+			}
+		}
+		err = checkDecs(acc.Address, "final",
+			namedDec{"yes allocation", yesAirdropAmt}, namedDec{"no allocation", noAirdropAmt},
+			namedDec{"noWithVeto allocation", noWithVetoAirdropAmt}, namedDec{"abstain allocation", abstainAirdropAmt},
+			namedDec{"didNotVote allocation", noVoteAirdropAmt}, namedDec{"liquid allocation", liquidAirdropAmt},
+			namedDec{"locked allocation", lockedAirdropAmt}, namedDec{"allocation", airdropAmt},
+		)
+		if err != nil {
+			return airdrop, err
+		}
+		audit.Amount = airdropAmt
 		// add address and amount (skipping 0 balance)
-		if amtInt := airdropAmt.RoundInt(); !amtInt.IsZero() {
+		amtInt := airdropAmt.RoundInt()
+		airdrop.rounding.add(airdropAmt, amtInt)
+		if params.hasVoterFloor() && acc.votedActive() && amtInt.LT(params.voterFloor) {
+			audit.Policies = append(audit.Policies, policyVoterFloor)
+			airdrop.voterFloor.accounts++
+			airdrop.voterFloor.topUp = airdrop.voterFloor.topUp.Add(params.voterFloor.Sub(amtInt))
+			amtInt = params.voterFloor
+		}
+		if amtInt.IsZero() {
+			audit.Policies = append(audit.Policies, policyZeroAmount)
+		} else {
 			addr := acc.Address
 			if prefix != "" {
-				// Derive address from "cosmos" to prefix parameter
-				var err error
-				addr, err = convertBech32(acc.Address, "cosmos", prefix)
+				// Derive address to prefix parameter, whatever the prefix of
+				// the account address
+				addr, err = s.convertAddress(acc.Address, prefix)
 				if err != nil {
 					return airdrop, err
 				}
 			}
-			// Fill with "cosmos" prefixed address
-			airdrop.addresses[addr] = amtInt
-			ad := addrAmtDetail{
-				Address: addr,
-				YesDetail: amtDetail{
-					AtomAmt:    yesAtomAmt,
-					Multiplier: params.yesVotesMultiplier,
-					BonusMalus: sdk.OneDec(),
-					Factor:     params.supplyFactor,
-					AtoneAmt:   yesAirdropAmt,
-				},
-				NoDetail: amtDetail{
-					AtomAmt:    noAtomAmt,
-					Multiplier: params.noVotesMultiplier,
-					BonusMalus: sdk.OneDec(),
-					Factor:     params.supplyFactor,
-					AtoneAmt:   noAirdropAmt,
-				},
-				NWVDetail: amtDetail{
-					AtomAmt:    noWithVetoAtomAmt,
-					Multiplier: params.noVotesMultiplier,
-					BonusMalus: params.bonus,
-					Factor:     params.supplyFactor,
-					AtoneAmt:   noWithVetoAirdropAmt,
-				},
-				AbsDetail: amtDetail{
-					AtomAmt:    abstainAtomAmt,
-					Multiplier: airdrop.nonVotersMultiplier,
-					BonusMalus: sdk.OneDec(),
-					Factor:     params.supplyFactor,
-					AtoneAmt:   abstainAirdropAmt,
-				},
-				DnvDetail: amtDetail{
-					AtomAmt:    noVoteAtomAmt,
-					Multiplier: airdrop.nonVotersMultiplier,
-					BonusMalus: params.malus,
-					Factor:     params.supplyFactor,
-					AtoneAmt:   noVoteAirdropAmt,
-				},
-				LiquidDetail: amtDetail{
-					AtomAmt:    acc.LiquidAmount,
-					Multiplier: airdrop.nonVotersMultiplier,
-					BonusMalus: params.malus,
-					Factor:     params.supplyFactor,
-					AtoneAmt:   liquidAirdropAmt,
-				},
-				Total: airdropAmt,
+			if params.withholding != nil {
+				net, e, err := airdrop.withhold(params, addr, acc.Type, amtInt)
+				if err != nil {
+					return airdrop, err
+				}
+				if e != nil {
+					audit.Policies = append(audit.Policies, policyWithholding)
+					amtInt = net
+				}
 			}
-			airdrop.addressesDetail = append(airdrop.addressesDetail, ad)
-			amt := yesAirdropAmt.Add(noAirdropAmt).Add(noWithVetoAirdropAmt).Add(abstainAirdropAmt).Add(noVoteAirdropAmt).Add(liquidAirdropAmt)
+			if escrowKey != "" && acc.hasKey(escrowKey) {
+				airdrop.escrowAllocation = amtInt
+			} else if !amtInt.IsZero() {
+				if err := airdrop.addResult(s.stream, addr, amtInt); err != nil {
+					return airdrop, err
+				}
+			}
+			audit.FinalAmount = amtInt
+			audit.OutputAddress = addr
+			amt := yesAirdropAmt.Add(noAirdropAmt).Add(noWithVetoAirdropAmt).Add(abstainAirdropAmt).Add(noVoteAirdropAmt).
+				Add(liquidAirdropAmt).Add(lockedAirdropAmt)
 			if !amt.Equal(airdropAmt) {
-				panic(fmt.Sprintf("WRONG %+v\n", ad))
+				return airdrop, fmt.Errorf("account %s: final: the allocation parts sum to %s instead of %s", acc.Address, amt, airdropAmt)
+			}
+			if !airdrop.streamed {
+				ad := addrAmtDetail{
+					Address: addr,
+					YesDetail: amtDetail{
+						AtomAmt:    yesAtomAmt,
+						Multiplier: yesMultiplier,
+						BonusMalus: yesBonusMalus,
+						Factor:     params.supplyFactor,
+						AtoneAmt:   yesAirdropAmt,
+					},
+					NoDetail: amtDetail{
+						AtomAmt:    noAtomAmt,
+						Multiplier: noMultiplier,
+						BonusMalus: noBonusMalus,
+						Factor:     params.supplyFactor,
+						AtoneAmt:   noAirdropAmt,
+					},
+					NWVDetail: amtDetail{
+						AtomAmt:    noWithVetoAtomAmt,
+						Multiplier: noWithVetoMultiplier,
+						BonusMalus: noWithVetoBonusMalus,
+						Factor:     params.supplyFactor,
+						AtoneAmt:   noWithVetoAirdropAmt,
+					},
+					AbsDetail: amtDetail{
+						AtomAmt:    abstainAtomAmt,
+						Multiplier: abstainMultiplier,
+						BonusMalus: abstainBonusMalus,
+						Factor:     params.supplyFactor,
+						AtoneAmt:   abstainAirdropAmt,
+					},
+					DnvDetail: amtDetail{
+						AtomAmt:    noVoteAtomAmt,
+						Multiplier: noVoteMultiplier,
+						BonusMalus: noVoteBonusMalus,
+						Factor:     params.supplyFactor,
+						AtoneAmt:   noVoteAirdropAmt,
+					},
+					LiquidDetail: amtDetail{
+						AtomAmt:    acc.LiquidAmount,
+						Multiplier: airdrop.nonVotersMultiplier,
+						BonusMalus: params.malus,
+						Factor:     params.supplyFactor,
+						AtoneAmt:   liquidAirdropAmt,
+					},
+					LockedDetail: amtDetail{
+						AtomAmt:    acc.lockedAmount(),
+						Multiplier: airdrop.nonVotersMultiplier.Mul(params.lockedFactor()),
+						BonusMalus: params.malus,
+						Factor:     params.supplyFactor,
+						AtoneAmt:   lockedAirdropAmt,
+					},
+					TypeMultiplier: typeMultiplier,
+					Total:          airdropAmt,
+					Sources:        acc.Sources,
+					Validators: validatorDetails(acc, voteMap{
+						govtypes.OptionYes:        yesAirdropAmt,
+						govtypes.OptionNo:         noAirdropAmt,
+						govtypes.OptionNoWithVeto: noWithVetoAirdropAmt,
+						govtypes.OptionAbstain:    abstainAirdropAmt,
+						govtypes.OptionEmpty:      noVoteAirdropAmt,
+					}),
+				}
+				airdrop.addressesDetail = append(airdrop.addressesDetail, ad)
 			}
 		}
+		record(audit)
+	}
+	progress.finishStage()
+	progress.setAggregate("$ATONE distributed", humand(airdrop.atone.supply))
+	if params.withholding != nil && !s.subset {
+		addr, err := airdrop.creditEscrow(params, prefix, s.stream)
+		if err != nil {
+			return airdrop, err
+		}
+		airdrop.withholdingEscrow = addr
 	}
 	// Compute minted part
 	minted := airdrop.atone.supply.Mul(params.supplyMintFactor)
-	airdrop.communityPool = minted.Quo(sdk.NewDec(2))
-	airdrop.reservedAddr = minted.Quo(sdk.NewDec(2))
+	mintedCommunityPool, mintedReservedAddr, stakingIncentives := params.mintedShares(minted)
+	airdrop.communityPool = mintedCommunityPool.Add(airdrop.entityCommunityPool)
+	if params.optOutPolicy == optOutPolicyCommunityPool {
+		airdrop.communityPool = airdrop.communityPool.Add(airdrop.optOut.atone)
+	}
+	if params.dustPolicy == dustPolicyCommunityPool {
+		airdrop.rounding.communityPool = airdrop.rounding.dust()
+		airdrop.communityPool = airdrop.communityPool.Add(airdrop.rounding.communityPool)
+	}
+	airdrop.reservedAddr = mintedReservedAddr
+	airdrop.stakingIncentives = stakingIncentives
+	if s.subset {
+		return airdrop, nil
+	}
+	if err := airdrop.fundVoterFloor(); err != nil {
+		return airdrop, err
+	}
 	return airdrop, nil
 }
 
+// totalSupply returns the final $ATONE supply: the distributed amount, the
+// voter floor top-ups, the community pool, the reserved address and the
+// staking incentives. The dust sent to the community pool is already part of
+// the distributed amount.
+func (a airdrop) totalSupply() sdk.Dec {
+	supply := a.atone.supply.Sub(a.rounding.communityPool).Add(a.voterFloor.topUp.ToLegacyDec()).
+		Add(a.communityPool).Add(a.reservedAddr)
+	if !a.stakingIncentives.IsNil() {
+		supply = supply.Add(a.stakingIncentives)
+	}
+	return supply
+}
+
+// nonVotersAmount returns the distributed $ATONE of the non-voters: the
+// neutral bucket and the unstaked amounts.
+func (a airdrop) nonVotersAmount() sdk.Dec {
+	amt := a.atone.unstaked
+	for _, opt := range allVoteOptions {
+		if a.params.voteBucket(opt) == voteBucketNeutral {
+			amt = amt.Add(a.atone.votes[opt])
+		}
+	}
+	return amt
+}
+
 // convenient type for manipulating vote counts.
 type voteMap map[govtypes.VoteOption]sdk.Dec
 
@@ -309,116 +1054,217 @@ func (m voteMap) add(v govtypes.VoteOption, d sdk.Dec) {
 	m[v] = m[v].Add(d)
 }
 
-func printAirdropsStats(chartMode bool, airdrops []airdrop) error {
+// printAirdropsStats prints the report of airdrops, or renders them as charts
+// if chartMode is true, with the sections selected by cfg. The report is
+// written into reportFile if it's not empty, into w otherwise. infos label the
+// validators, and turnout, if not nil, compares the $ATOM votes with the gov
+// tally params.
+func printAirdropsStats(ctx context.Context, w io.Writer, chartMode bool, export chartExport, airdrops []airdrop, infos validatorInfos,
+	turnout *turnoutCheck, cfg reportConfig, reportFile string,
+) error {
 	if chartMode {
-		f, err := os.CreateTemp("", "chart*.html")
-		if err != nil {
-			return err
-		}
-		defer f.Close()
 		page := components.NewPage()
 		page.PageTitle = "$ATONE distributions"
-		page.AddCharts(
-			newBarChart(airdrops),
-			newPieChart("$ATOM distribution", airdrops[0].atom),
-		)
-		for _, airdrop := range airdrops {
+		if cfg.selected(sectionPerVote) {
 			page.AddCharts(
-				newPieChart(fmt.Sprintf("$ATONE distribution %s", airdrop.params), airdrop.atone),
+				newBarChart(airdrops, export.theme),
+				newPieChart("$ATOM distribution", airdrops[0].atom, export.theme),
 			)
 		}
-		page.Render(f)
-		fmt.Printf("Charts rendered in %s\n", f.Name())
-		browser.OpenFile(f.Name())
-		return nil
+		if cfg.selected(sectionConcentration) && !airdrops[0].streamed {
+			page.AddCharts(newHolderBucketsChart(airdrops, export.theme))
+		}
+		if cfg.selected(sectionValidators) {
+			page.AddCharts(newValidatorConcentrationChart(airdrops[0].validators, infos, export.theme))
+		}
+		if len(airdrops) > 1 && cfg.selected(sectionSummary) {
+			page.AddCharts(newSweepLineCharts(airdrops, export.theme)...)
+		}
+		if cfg.selected(sectionPerVote) {
+			for _, airdrop := range airdrops {
+				page.AddCharts(
+					newPieChart(fmt.Sprintf("$ATONE distribution %s", airdrop.params), airdrop.atone, export.theme),
+				)
+			}
+		}
+		return renderPage(ctx, page, export)
 	}
+	r, err := newAirdropsReport(airdrops, infos, turnout)
+	if err != nil {
+		return err
+	}
+	return writeReport(ctx, w, reportFile, r, cfg)
+}
 
-	printDistrib := func(d distrib) {
-		table := newMarkdownTable("", "TOTAL", "DID NOT VOTE", "YES", "NO", "NOWITHVETO", "ABSTAIN", "NOT STAKED")
-		table.Append([]string{
-			"Distributed",
-			humand(d.supply),
-			humand(d.votes[govtypes.OptionEmpty]),
-			humand(d.votes[govtypes.OptionYes]),
-			humand(d.votes[govtypes.OptionNo]),
-			humand(d.votes[govtypes.OptionNoWithVeto]),
-			humand(d.votes[govtypes.OptionAbstain]),
-			humand(d.unstaked),
-		})
-		votePercs := d.votePercentages()
-		table.Append([]string{
-			"Percentage over total",
-			"",
-			humanPercentI(votePercs[govtypes.OptionEmpty]),
-			humanPercentI(votePercs[govtypes.OptionYes]),
-			humanPercentI(votePercs[govtypes.OptionNo]),
-			humanPercentI(votePercs[govtypes.OptionNoWithVeto]),
-			humanPercentI(votePercs[govtypes.OptionAbstain]),
-			humanPercentI(d.unstaked.Quo(d.supply)),
-		})
-		table.Render()
-		fmt.Println()
+// newAirdropsReport builds the report of airdrops. In the sections holding
+// the blocks of each airdrop, they are introduced by the airdrop params.
+func newAirdropsReport(airdrops []airdrop, infos validatorInfos, turnout *turnoutCheck) (*report, error) {
+	var (
+		r          = newReport()
+		summary    = r.section(sectionSummary)
+		perVote    = r.section(sectionPerVote)
+		exclusions = r.section(sectionExclusions)
+	)
+	if turnout != nil {
+		reportTurnout(summary, *turnout)
 	}
-	fmt.Println("$ATOM distribution")
-	printDistrib(airdrops[0].atom)
+	perVote.text("$ATOM distribution")
+	reportDistrib(perVote, airdrops[0].atom)
 	for _, airdrop := range airdrops {
-		fmt.Printf("$ATONE distribution (params: %s) (ratio: x%.3f, nonVotersMultiplier: %.3f, icfSlash: %s $ATOM)\n",
+		var slashes []string
+		for _, g := range airdrop.params.entityGroups {
+			slashes = append(slashes, fmt.Sprintf("%s %s: %s $ATOM", g.Name, g.Policy, humand(airdrop.entitySlashes[g.Name])))
+		}
+		summary.text("$ATONE distribution (params: %s) (ratio: x%.3f, nonVotersMultiplier: %.3f, %s)",
 			airdrop.params,
 			airdrop.atone.supply.Quo(airdrop.atom.supply).MustFloat64(),
 			airdrop.nonVotersMultiplier.MustFloat64(),
-			humand(airdrop.icfSlash),
+			strings.Join(slashes, ", "),
 		)
-		printDistrib(airdrop.atone)
-		fmt.Printf(
-			"ATONE TOTAL SUPPLY = DISTRIBUTED(%s) + COMMUNITY_POOL(%s) + RESERVED_ADDRESS(%s) = %s\n",
-			humand(airdrop.atone.supply), humand(airdrop.communityPool), humand(airdrop.reservedAddr),
-			humand(airdrop.atone.supply.Add(airdrop.communityPool).Add(airdrop.reservedAddr)),
+		if !airdrop.entityCommunityPool.IsZero() {
+			summary.text("%s $ATONE sent to the community pool by entity groups policies", humand(airdrop.entityCommunityPool))
+		}
+		if airdrop.params.hasYesRatio() {
+			if text := yesRatioText(airdrop); text != "" {
+				summary.text("%s", text)
+			}
+		}
+		if airdrop.params.hasTargetSupply() {
+			summary.text("%s", targetSupplyText(airdrop))
+		}
+		if len(airdrop.params.typeMultipliers) > 0 {
+			reportTypeMultipliers(summary, airdrop)
+		}
+		if airdrop.atom.locked.IsPositive() {
+			reportVestingLocks(summary, airdrop)
+		}
+		reportRounding(summary, airdrop)
+		var stakingIncentives string
+		if airdrop.params.hasStakingIncentives() {
+			stakingIncentives = fmt.Sprintf(" + STAKING_INCENTIVES(%s)", humand(airdrop.stakingIncentives))
+		}
+		summary.text(
+			"ATONE TOTAL SUPPLY = DISTRIBUTED(%s) + VOTER_FLOOR(%s) + COMMUNITY_POOL(%s) + RESERVED_ADDRESS(%s)%s = %s",
+			humand(airdrop.atone.supply.Sub(airdrop.rounding.communityPool)), humand(airdrop.voterFloor.topUp.ToLegacyDec()),
+			humand(airdrop.communityPool), humand(airdrop.reservedAddr), stakingIncentives, humand(airdrop.totalSupply()),
 		)
+
+		perVote.text("$ATONE distribution (params: %s)", airdrop.params)
+		reportDistrib(perVote, airdrop.atone)
+		if airdrop.params.hasVoterFloor() {
+			reportVoterFloor(perVote, airdrop)
+		}
+		if airdrop.params.voteTiming != nil {
+			reportVoteTiming(perVote, airdrop)
+		}
+		if airdrop.params.validatorLiquid {
+			reportValidatorLiquid(perVote, airdrop)
+		}
+		if airdrop.params.hasShareBounds() {
+			reportShareBounds(perVote, airdrop)
+		}
+
+		if len(airdrops) > 1 {
+			exclusions.text("$ATONE distribution (params: %s)", airdrop.params)
+		}
+		if airdrop.optOut.accounts > 0 {
+			reportOptOut(exclusions, airdrop)
+		}
+		if airdrop.params.hook != nil {
+			reportHook(exclusions, airdrop)
+		}
+		if airdrop.params.withholding != nil {
+			reportWithholding(exclusions, airdrop)
+		}
+		if airdrop.params.hasAddressCap() {
+			reportAddressCap(exclusions, airdrop)
+		}
+		if airdrop.hasEntitySlashes() {
+			if err := reportSlashAccountings(exclusions, airdrop); err != nil {
+				return nil, err
+			}
+		}
+		if airdrop.streamed {
+			exclusions.text("Reconciliation of the accounts: not available, the results of the accounts were streamed")
+			continue
+		}
+		rec := newReconciliation(airdrop)
+		reportReconciliation(exclusions, rec)
+		if rec.unexplained() {
+			warnings.add(warnUnreconciled, airdrop.params.String())
+		}
 	}
-	return nil
+	reportHolderBuckets(r.section(sectionConcentration), airdrops)
+	reportValidatorPowers(r.section(sectionValidators), airdrops[0].validators, infos)
+	return r, nil
 }
 
-func newBarChart(airdrops []airdrop) *charts.Bar {
+// reportDistrib adds the table of the parts of d per vote to sec.
+func reportDistrib(sec *reportSection, d distrib) {
+	table := sec.table("", "TOTAL", "DID NOT VOTE", "YES", "NO", "NOWITHVETO", "ABSTAIN", "NOT STAKED")
+	table.Append([]string{
+		"Distributed",
+		humand(d.supply),
+		humand(d.votes[govtypes.OptionEmpty]),
+		humand(d.votes[govtypes.OptionYes]),
+		humand(d.votes[govtypes.OptionNo]),
+		humand(d.votes[govtypes.OptionNoWithVeto]),
+		humand(d.votes[govtypes.OptionAbstain]),
+		humand(d.unstaked),
+	})
+	votePercs := d.votePercentages()
+	table.Append([]string{
+		"Percentage over total",
+		"",
+		humanPercentI(votePercs[govtypes.OptionEmpty]),
+		humanPercentI(votePercs[govtypes.OptionYes]),
+		humanPercentI(votePercs[govtypes.OptionNo]),
+		humanPercentI(votePercs[govtypes.OptionNoWithVeto]),
+		humanPercentI(votePercs[govtypes.OptionAbstain]),
+		humanPercentI(d.unstaked.Quo(d.supply)),
+	})
+}
+
+// distribChartValues returns the share of d of each chart category, in
+// percent.
+func distribChartValues(d distrib) map[string]float64 {
+	var (
+		votePercs  = d.votePercentages()
+		oneHundred = sdk.NewDec(100)
+		values     = map[string]float64{
+			chartUnstaked: d.unstaked.Quo(d.supply).Mul(oneHundred).MustFloat64(),
+		}
+	)
+	for _, opt := range allVoteOptions {
+		values[voteCategory(opt)] = votePercs[opt].Mul(oneHundred).MustFloat64()
+	}
+	return values
+}
+
+func newBarChart(airdrops []airdrop, theme chartTheme) *charts.Bar {
 	bar := charts.NewBar()
-	bar.SetGlobalOptions(
+	bar.SetGlobalOptions(append([]charts.GlobalOpts{
 		charts.WithTitleOpts(opts.Title{Title: "Votes distribution"}),
 		charts.WithLegendOpts(opts.Legend{Show: true, Right: "right", Orient: "vertical"}),
 		charts.WithTooltipOpts(opts.Tooltip{
 			Show:      true,
 			Formatter: opts.FuncOpts("function(params){ return params.value.toFixed(2)+'%'}"),
 		}),
-	)
+	}, theme.globalOpts()...)...)
 
-	bar.SetXAxis([]string{"Yes", "No", "NWV", "Abstain", "DNV", "Unstaked"})
+	series := theme.series()
+	xAxis := make([]string, len(series))
+	for i, s := range series {
+		xAxis[i] = s.Label
+	}
+	bar.SetXAxis(xAxis)
 	generateData := func(d distrib) []opts.BarData {
 		var (
-			votePercs  = d.votePercentages()
-			data       = make([]opts.BarData, 6)
-			oneHundred = sdk.NewDec(100)
+			values = distribChartValues(d)
+			data   = make([]opts.BarData, len(series))
 		)
-		data[0] = opts.BarData{
-			Name:  "Yes",
-			Value: votePercs[govtypes.OptionYes].Mul(oneHundred).MustFloat64(),
-		}
-		data[1] = opts.BarData{
-			Name:  "No",
-			Value: votePercs[govtypes.OptionNo].Mul(oneHundred).MustFloat64(),
-		}
-		data[2] = opts.BarData{
-			Name:  "NWV",
-			Value: votePercs[govtypes.OptionNoWithVeto].Mul(oneHundred).MustFloat64(),
-		}
-		data[3] = opts.BarData{
-			Name:  "Abstain",
-			Value: votePercs[govtypes.OptionAbstain].Mul(oneHundred).MustFloat64(),
-		}
-		data[4] = opts.BarData{
-			Name:  "DNV",
-			Value: votePercs[govtypes.OptionEmpty].Mul(oneHundred).MustFloat64(),
-		}
-		data[5] = opts.BarData{
-			Name:  "Unstaked",
-			Value: d.unstaked.Quo(d.supply).Mul(oneHundred).MustFloat64(),
+		for i, s := range series {
+			data[i] = opts.BarData{Name: s.Label, Value: s.sum(values)}
 		}
 		return data
 	}
@@ -429,7 +1275,9 @@ func newBarChart(airdrops []airdrop) *charts.Bar {
 	return bar
 }
 
-func newPieChart(title string, d distrib) *charts.Pie {
+// newPieChart returns a pie chart of d, with an outer ring of the vote
+// categories and an inner ring of their summary groups.
+func newPieChart(title string, d distrib, theme chartTheme) *charts.Pie {
 	pie := charts.NewPie()
 	pie.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
@@ -444,55 +1292,23 @@ func newPieChart(title string, d distrib) *charts.Pie {
 		}),
 	)
 	var (
-		data       = make([]opts.PieData, 6)
-		dataSum    = make([]opts.PieData, 3)
-		votePercs  = d.votePercentages()
-		oneHundred = sdk.NewDec(100)
+		values  = distribChartValues(d)
+		data    []opts.PieData
+		dataSum []opts.PieData
 	)
-	data[0] = opts.PieData{
-		Name:      "Yes",
-		ItemStyle: &opts.ItemStyle{Color: "#ff8b87"},
-		Value:     votePercs[govtypes.OptionYes].Mul(oneHundred).MustFloat64(),
-	}
-	dataSum[0] = opts.PieData{
-		Name:      "Yes",
-		ItemStyle: &opts.ItemStyle{Color: "#ff8b87"},
-		Value:     data[0].Value,
-	}
-	data[1] = opts.PieData{
-		Name:      "No",
-		ItemStyle: &opts.ItemStyle{Color: "#9FDFBF"},
-		Value:     votePercs[govtypes.OptionNo].Mul(oneHundred).MustFloat64(),
-	}
-	data[2] = opts.PieData{
-		Name:      "NWV",
-		ItemStyle: &opts.ItemStyle{Color: "#88d8b0"},
-		Value:     votePercs[govtypes.OptionNoWithVeto].Mul(oneHundred).MustFloat64(),
-	}
-	dataSum[1] = opts.PieData{
-		Name:      "No+NWV",
-		ItemStyle: &opts.ItemStyle{Color: "#6cac8c"},
-		Value:     data[1].Value.(float64) + data[2].Value.(float64),
-	}
-	data[3] = opts.PieData{
-		Name:      "Abstain",
-		ItemStyle: &opts.ItemStyle{Color: "#eac086"},
-		Value:     votePercs[govtypes.OptionAbstain].Mul(oneHundred).MustFloat64(),
-	}
-	data[4] = opts.PieData{
-		Name:      "DNV",
-		ItemStyle: &opts.ItemStyle{Color: "#ffcd94"},
-		Value:     votePercs[govtypes.OptionEmpty].Mul(oneHundred).MustFloat64(),
-	}
-	data[5] = opts.PieData{
-		Name:      "Unstaked",
-		ItemStyle: &opts.ItemStyle{Color: "#ffe0bd"},
-		Value:     d.unstaked.Quo(d.supply).Mul(oneHundred).MustFloat64(),
-	}
-	dataSum[2] = opts.PieData{
-		Name:      "Non voters",
-		ItemStyle: &opts.ItemStyle{Color: "#ffad60"},
-		Value:     data[3].Value.(float64) + data[4].Value.(float64) + data[5].Value.(float64),
+	for _, s := range theme.series() {
+		data = append(data, opts.PieData{
+			Name:      s.Label,
+			ItemStyle: &opts.ItemStyle{Color: s.Color},
+			Value:     s.sum(values),
+		})
+	}
+	for _, g := range theme.summary() {
+		dataSum = append(dataSum, opts.PieData{
+			Name:      g.Label,
+			ItemStyle: &opts.ItemStyle{Color: g.Color},
+			Value:     g.sum(values),
+		})
 	}
 	pie.AddSeries("pie", data,
 		charts.WithLabelOpts(opts.Label{
@@ -514,3 +1330,65 @@ func newPieChart(title string, d distrib) *charts.Pie {
 	)
 	return pie
 }
+
+// newSweepLineCharts returns line charts that plot the final $ATONE supply,
+// the $ATONE/$ATOM ratio and the non-voters share as functions of the No
+// multiplier, with one line per Yes multiplier. Useful when airdrops is the
+// result of a parameter sweep (several -yesMultipliers and -noMultipliers).
+func newSweepLineCharts(airdrops []airdrop, theme chartTheme) []components.Charter {
+	var (
+		yesKeys, noKeys []string
+		byParams        = make(map[[2]string]airdrop)
+	)
+	for _, a := range airdrops {
+		y, n := a.params.yesVotesMultiplier.String(), a.params.noVotesMultiplier.String()
+		if !slices.Contains(yesKeys, y) {
+			yesKeys = append(yesKeys, y)
+		}
+		if !slices.Contains(noKeys, n) {
+			noKeys = append(noKeys, n)
+		}
+		byParams[[2]string{y, n}] = a
+	}
+	xAxis := make([]string, len(noKeys))
+	for i, n := range noKeys {
+		xAxis[i] = fmt.Sprintf("x%.1f", sdk.MustNewDecFromStr(n).MustFloat64())
+	}
+	newLine := func(title, yAxisName string, value func(airdrop) float64) *charts.Line {
+		line := charts.NewLine()
+		line.SetGlobalOptions(append([]charts.GlobalOpts{
+			charts.WithTitleOpts(opts.Title{Title: title}),
+			charts.WithLegendOpts(opts.Legend{Show: true, Right: "right", Orient: "vertical"}),
+			charts.WithTooltipOpts(opts.Tooltip{Show: true, Trigger: "axis"}),
+			charts.WithXAxisOpts(opts.XAxis{Name: "No multiplier"}),
+			charts.WithYAxisOpts(opts.YAxis{Name: yAxisName}),
+		}, theme.globalOpts()...)...)
+		line.SetXAxis(xAxis)
+		for _, y := range yesKeys {
+			data := make([]opts.LineData, len(noKeys))
+			for i, n := range noKeys {
+				a, ok := byParams[[2]string{y, n}]
+				if !ok {
+					data[i] = opts.LineData{Value: "-"}
+					continue
+				}
+				data[i] = opts.LineData{Value: value(a)}
+			}
+			line.AddSeries(fmt.Sprintf("Yes x%.1f", sdk.MustNewDecFromStr(y).MustFloat64()), data)
+		}
+		return line
+	}
+	oneHundred := sdk.NewDec(100)
+	return []components.Charter{
+		newLine("Final $ATONE supply", "$ATONE", func(a airdrop) float64 {
+			return a.totalSupply().QuoInt64(M).MustFloat64()
+		}),
+		newLine("$ATONE/$ATOM ratio", "ratio", func(a airdrop) float64 {
+			return a.atone.supply.Quo(a.atom.supply).MustFloat64()
+		}),
+		newLine("Non-voters share of $ATONE", "%", func(a airdrop) float64 {
+			nonVoters := a.atone.votes[govtypes.OptionAbstain].Add(a.atone.votes[govtypes.OptionEmpty]).Add(a.atone.unstaked)
+			return nonVoters.Quo(a.atone.supply).Mul(oneHundred).MustFloat64()
+		}),
+	}
+}
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -379,3 +385,716 @@ func TestDistribution(t *testing.T) {
 		})
 	}
 }
+
+func TestDistributionAudit(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	accounts := []Account{
+		{
+			Address:      icfWallets[0],
+			LiquidAmount: sdk.NewDec(1000),
+			StakedAmount: sdk.ZeroDec(),
+		},
+		{
+			Address:      "dust",
+			LiquidAmount: sdk.NewDec(1),
+			StakedAmount: sdk.ZeroDec(),
+		},
+		{
+			Address:      "yes",
+			LiquidAmount: sdk.ZeroDec(),
+			StakedAmount: sdk.NewDec(100),
+			Vote: govtypes.WeightedVoteOptions{{
+				Option: govtypes.OptionYes,
+				Weight: sdk.NewDec(1),
+			}},
+		},
+	}
+
+	airdrop, err := distribution(accounts, defaultDistriParams(), "")
+
+	require.NoError(err)
+	require.Len(airdrop.audit, 3)
+	assert.Equal([]string{"icf-slash"}, airdrop.audit[0].Policies)
+	assert.True(airdrop.audit[0].FinalAmount.IsZero())
+	assert.Equal([]string{policyZeroAmount}, airdrop.audit[1].Policies)
+	assert.True(airdrop.audit[1].FinalAmount.IsZero())
+	assert.Empty(airdrop.audit[2].Policies)
+	assert.Equal(int64(10), airdrop.audit[2].FinalAmount.Int64())
+	assert.Equal("yes", airdrop.audit[2].OutputAddress)
+	assert.Equal(sdk.OneDec(), airdrop.audit[2].VoteWeights[govtypes.OptionYes.String()])
+}
+
+func TestDistributionEntityGroups(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	voteNo := govtypes.WeightedVoteOptions{{
+		Option: govtypes.OptionNo,
+		Weight: sdk.NewDec(1),
+	}}
+	accounts := []Account{
+		{Address: "slashed", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: voteNo},
+		{Address: "partial", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: voteNo},
+		{Address: "cp", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: voteNo},
+		{Address: "regular", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: voteNo},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.ZeroDec()},
+	}
+	params := defaultDistriParams()
+	params.entityGroups = []entityGroup{
+		{Name: "A", Policy: entityPolicySlash, Addresses: []string{"slashed"}},
+		{Name: "B", Policy: entityPolicyPartialSlash, SlashPercent: sdk.NewDecWithPrec(25, 2), Addresses: []string{"partial"}},
+		{Name: "C", Policy: entityPolicyCommunityPool, Addresses: []string{"cp"}},
+	}
+
+	airdrop, err := distribution(accounts, params, "")
+
+	require.NoError(err)
+	require.Len(airdrop.addresses, 3)
+	assert.Equal(sdk.NewInt(68), airdrop.addresses["partial"]) // 75 x 9 x 0.1
+	assert.Equal(sdk.NewInt(90), airdrop.addresses["regular"]) // 100 x 9 x 0.1
+	assert.Equal(sdk.NewDec(100), airdrop.entitySlashes["A"])
+	assert.Equal(sdk.NewDec(25), airdrop.entitySlashes["B"])
+	assert.Equal(sdk.NewDec(100), airdrop.entitySlashes["C"])
+	assert.Equal(sdk.NewDec(90), airdrop.entityCommunityPool)
+	assert.Equal(sdk.NewDecWithPrec(1575, 1), airdrop.atone.supply.Sub(airdrop.atone.unstaked))
+	assert.Equal(sdk.NewDec(90), airdrop.entitySlashedAtone["A"])             // 100 x 9 x 0.1
+	assert.Equal(sdk.NewDecWithPrec(225, 1), airdrop.entitySlashedAtone["B"]) // 25 x 9 x 0.1
+	assert.Equal(sdk.ZeroDec(), airdrop.entitySlashedAtone["C"])
+}
+
+func TestSimulateSlashAccounting(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	voteNo := govtypes.WeightedVoteOptions{{
+		Option: govtypes.OptionNo,
+		Weight: sdk.NewDec(1),
+	}}
+	accounts := []Account{
+		{Address: "slashed", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: voteNo},
+		{Address: "regular", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: voteNo},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.ZeroDec()},
+	}
+	params := defaultDistriParams()
+	params.entityGroups = []entityGroup{
+		{Name: "A", Policy: entityPolicySlash, Addresses: []string{"slashed"}},
+	}
+	airdrop, err := distribution(accounts, params, "")
+	require.NoError(err)
+	require.True(airdrop.hasEntitySlashes())
+	slashed := airdrop.slashedAtone()
+	assert.Equal(sdk.NewDec(90), slashed)
+
+	burn, err := simulateSlashAccounting(airdrop, slashAccountingBurn)
+	require.NoError(err)
+	assert.Equal(airdrop.atone.supply, burn.distributed)
+	assert.Equal(airdrop.totalSupply(), burn.totalSupply)
+
+	voters, err := simulateSlashAccounting(airdrop, slashAccountingVoters)
+	require.NoError(err)
+	assert.Equal(airdrop.atone.supply.Add(slashed), voters.distributed)
+	assert.Equal(burn.totalSupply.Add(slashed.Mul(sdk.OneDec().Add(params.supplyMintFactor))), voters.totalSupply)
+	assert.True(voters.ratio.GT(burn.ratio))
+	assert.True(voters.nonVotersPerc.LT(burn.nonVotersPerc))
+
+	cp, err := simulateSlashAccounting(airdrop, slashAccountingCommunityPool)
+	require.NoError(err)
+	assert.Equal(burn.distributed, cp.distributed)
+	assert.Equal(burn.communityPool.Add(slashed), cp.communityPool)
+	assert.Equal(burn.totalSupply.Add(slashed), cp.totalSupply)
+
+	_, err = simulateSlashAccounting(airdrop, "foo")
+	assert.EqualError(err, "unknown slash accounting 'foo'")
+}
+
+func TestDistributionOptOut(t *testing.T) {
+	voteNo := govtypes.WeightedVoteOptions{{
+		Option: govtypes.OptionNo,
+		Weight: sdk.NewDec(1),
+	}}
+	accounts := []Account{
+		{Address: "optout", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: voteNo},
+		{Address: "regular", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: voteNo},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.ZeroDec()},
+	}
+	tests := []struct {
+		policy                string
+		expectedCommunityPool bool
+	}{
+		{policy: optOutPolicyCommunityPool, expectedCommunityPool: true},
+		{policy: optOutPolicyBurn, expectedCommunityPool: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			params := defaultDistriParams()
+			params.optOut = []string{"optout"}
+			params.optOutPolicy = tt.policy
+
+			airdrop, err := distribution(accounts, params, "")
+
+			require.NoError(t, err)
+			assert.NotContains(t, airdrop.addresses, "optout")
+			assert.Equal(t, sdk.NewInt(90), airdrop.addresses["regular"])
+			assert.Equal(t, 1, airdrop.optOut.accounts)
+			assert.Equal(t, sdk.NewDec(100), airdrop.optOut.atom)
+			assert.Equal(t, sdk.NewDec(90), airdrop.optOut.atone)
+			assert.Equal(t, []string{"opt-out-" + tt.policy}, airdrop.audit[0].Policies)
+			minted := airdrop.atone.supply.Mul(params.supplyMintFactor).QuoInt64(2)
+			if tt.expectedCommunityPool {
+				assert.Equal(t, minted.Add(sdk.NewDec(90)), airdrop.communityPool)
+			} else {
+				assert.Equal(t, minted, airdrop.communityPool)
+			}
+		})
+	}
+	t.Run("unknown policy", func(t *testing.T) {
+		params := defaultDistriParams()
+		params.optOut = []string{"optout"}
+		params.optOutPolicy = "foo"
+
+		_, err := distribution(accounts, params, "")
+
+		assert.EqualError(t, err, "unknown opt-out policy 'foo'")
+	})
+}
+
+func TestDistributionVoterFloor(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "small-yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(10), Vote: vote(govtypes.OptionYes)},
+		{Address: "small-abstain", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(10), Vote: vote(govtypes.OptionAbstain)},
+		{Address: "big-no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(10_000), Vote: vote(govtypes.OptionNo)},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(1_000), StakedAmount: sdk.ZeroDec()},
+	}
+	params := defaultDistriParams()
+	params.voterFloor = sdk.NewInt(50)
+	params.voterFloorPool = voterFloorPoolReservedAddress
+	noFloor, err := distribution(accounts, defaultDistriParams(), "")
+	require.NoError(err)
+
+	airdrop, err := distribution(accounts, params, "")
+
+	require.NoError(err)
+	assert.Equal(sdk.NewInt(1), noFloor.addresses["small-yes"])
+	assert.Equal(sdk.NewInt(50), airdrop.addresses["small-yes"])
+	// abstain isn't an active vote option
+	assert.Equal(noFloor.addresses["small-abstain"], airdrop.addresses["small-abstain"])
+	assert.Equal(sdk.NewInt(9_000), airdrop.addresses["big-no"])
+	assert.Equal(1, airdrop.voterFloor.accounts)
+	assert.Equal(sdk.NewInt(49), airdrop.voterFloor.topUp)
+	assert.Equal(noFloor.reservedAddr.Sub(sdk.NewDec(49)), airdrop.reservedAddr)
+	assert.Equal(noFloor.communityPool, airdrop.communityPool)
+	assert.Equal([]string{policyVoterFloor}, airdrop.audit[0].Policies)
+
+	// pool too small
+	params.voterFloor = sdk.NewInt(1_000_000)
+	_, err = distribution(accounts, params, "")
+	assert.ErrorContains(err, "voter floor: reserved-address pool")
+}
+
+func TestDistributionVoteBuckets(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: vote(govtypes.OptionYes)},
+		{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: vote(govtypes.OptionNo)},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.ZeroDec()},
+	}
+	params := defaultDistriParams()
+	// Reward Yes instead of No
+	params.voteBuckets = defaultVoteBuckets()
+	params.voteBuckets[govtypes.OptionYes] = voteBucketAligned
+	params.voteBuckets[govtypes.OptionNo] = voteBucketOpposed
+	params.voteBuckets[govtypes.OptionNoWithVeto] = voteBucketOpposed
+	defaultAirdrop, err := distribution(accounts, defaultDistriParams(), "")
+	require.NoError(err)
+
+	airdrop, err := distribution(accounts, params, "")
+
+	require.NoError(err)
+	assert.Equal(sdk.NewInt(10), defaultAirdrop.addresses["yes"])
+	assert.Equal(sdk.NewInt(90), defaultAirdrop.addresses["no"])
+	assert.Equal(sdk.NewInt(90), airdrop.addresses["yes"]) // 100 x 9 x 0.1
+	assert.Equal(sdk.NewInt(10), airdrop.addresses["no"])  // 100 x 1 x 0.1
+	assert.Equal(defaultAirdrop.nonVotersMultiplier, airdrop.nonVotersMultiplier)
+}
+
+func TestParseVoteBuckets(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "buckets.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"yes": "aligned", "no": "opposed"}`), 0o644))
+
+	buckets, err := parseVoteBuckets(file)
+
+	require.NoError(t, err)
+	expected := defaultVoteBuckets()
+	expected[govtypes.OptionYes] = voteBucketAligned
+	expected[govtypes.OptionNo] = voteBucketOpposed
+	assert.Equal(t, expected, buckets)
+
+	require.NoError(t, os.WriteFile(file, []byte(`{"yes": "foo"}`), 0o644))
+	_, err = parseVoteBuckets(file)
+	assert.EqualError(t, err, "unknown vote bucket 'foo' for option 'yes'")
+}
+
+func TestDistributionDust(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(10), Vote: vote(govtypes.OptionYes)},
+		{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(13), Vote: vote(govtypes.OptionNo)},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(17), StakedAmount: sdk.ZeroDec()},
+	}
+	params := defaultDistriParams()
+	params.dustPolicy = dustPolicyCommunityPool
+	noDust, err := distribution(accounts, defaultDistriParams(), "")
+	require.NoError(err)
+
+	airdrop, err := distribution(accounts, params, "")
+
+	require.NoError(err)
+	assert.Equal(noDust.addresses, airdrop.addresses)
+	assert.Equal(noDust.rounding.dust(), airdrop.rounding.dust())
+	assert.True(noDust.rounding.communityPool.IsZero())
+	assert.Equal(2, airdrop.rounding.accounts)
+	assert.False(airdrop.rounding.dust().IsZero())
+	distributed := sdk.ZeroInt()
+	for _, amt := range airdrop.addresses {
+		distributed = distributed.Add(amt)
+	}
+	// The distributed amounts plus the dust match the computed distribution
+	assert.Equal(airdrop.atone.supply, distributed.ToLegacyDec().Add(airdrop.rounding.dust()))
+	assert.Equal(noDust.communityPool.Add(airdrop.rounding.dust()), airdrop.communityPool)
+	assert.Equal(airdrop.atone.supply, airdrop.totalSupply().Sub(airdrop.communityPool).Sub(airdrop.reservedAddr).Add(airdrop.rounding.dust()))
+	assert.Equal(noDust.totalSupply(), airdrop.totalSupply())
+
+	params.dustPolicy = "foo"
+	_, err = distribution(accounts, params, "")
+	assert.EqualError(err, "unknown dust policy 'foo'")
+}
+
+func TestDistributionAddressCap(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "whale-no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(10_000), Vote: vote(govtypes.OptionNo)},
+		{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: vote(govtypes.OptionNo)},
+		{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1_000), Vote: vote(govtypes.OptionYes)},
+		{Address: "abstain", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(500), Vote: vote(govtypes.OptionAbstain)},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(2_000), StakedAmount: sdk.ZeroDec()},
+	}
+	uncapped, err := distribution(accounts, defaultDistriParams(), "")
+	require.NoError(err)
+	params := defaultDistriParams()
+	params.addressCap = sdk.NewInt(1_000)
+
+	airdrop, err := distribution(accounts, params, "")
+
+	require.NoError(err)
+	assert.Equal(sdk.NewInt(9_000), uncapped.addresses["whale-no"])
+	assert.Equal(sdk.NewInt(1_000), airdrop.addresses["whale-no"])
+	assert.Equal([]string{policyAddressCap}, airdrop.audit[0].Policies)
+	assert.Equal(1, airdrop.addressCap.accounts)
+	assert.True(airdrop.addressCap.converged)
+	assert.Greater(airdrop.addressCap.iterations, 1)
+	assert.True(airdrop.addressCap.residual.Abs().LT(sdk.NewDecWithPrec(1, 9)), airdrop.addressCap.residual)
+	// Non-voters get less since the voters share is reduced by the cap
+	assert.True(airdrop.nonVotersMultiplier.LT(uncapped.nonVotersMultiplier))
+	assert.Equal(uncapped.addresses["no"], airdrop.addresses["no"])
+	assert.True(airdrop.addresses["liquid"].LT(uncapped.addresses["liquid"]))
+	for _, amt := range airdrop.addresses {
+		assert.True(amt.LTE(params.addressCap), amt)
+	}
+
+	// A cap that doesn't bind keeps the closed-form multiplier
+	params.addressCap = sdk.NewInt(1_000_000)
+	airdrop, err = distribution(accounts, params, "")
+	require.NoError(err)
+	assert.Equal(uncapped.addresses, airdrop.addresses)
+	assert.Zero(airdrop.addressCap.accounts)
+	assert.True(airdrop.addressCap.converged)
+}
+
+func TestDistributionTypeMultipliers(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	const (
+		baseType    = "/cosmos.auth.v1beta1.BaseAccount"
+		vestingType = "/cosmos.vesting.v1beta1.ContinuousVestingAccount"
+		delayedType = "/cosmos.vesting.v1beta1.DelayedVestingAccount"
+	)
+	vote := govtypes.WeightedVoteOptions{{Option: govtypes.OptionNo, Weight: sdk.NewDec(1)}}
+	accounts := []Account{
+		{Address: "base", Type: baseType, LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.NewDec(100), Vote: vote},
+		{Address: "vesting", Type: vestingType, LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.NewDec(100), Vote: vote},
+		{Address: "delayed", Type: delayedType, LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.NewDec(100), Vote: vote},
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "types.json")
+	err := os.WriteFile(file, []byte(`{"ContinuousVestingAccount": "0.5", "`+delayedType+`": "0"}`), 0o644)
+	require.NoError(err)
+	params := defaultDistriParams()
+	params.typeMultipliers, err = parseTypeMultipliers(file)
+	require.NoError(err)
+
+	airdrop, err := distribution(accounts, params, "")
+
+	require.NoError(err)
+	base := airdrop.addresses["base"]
+	assert.Equal(base.QuoRaw(2), airdrop.addresses["vesting"])
+	assert.NotContains(airdrop.addresses, "delayed")
+	assert.Equal(map[string]int{"ContinuousVestingAccount": 1, delayedType: 1}, airdrop.typeMultiplied)
+	assert.Equal(sdk.NewDecWithPrec(5, 1), airdrop.audit[1].Multipliers["accountType"])
+	assert.NotContains(airdrop.audit[0].Multipliers, "accountType")
+	// Detail shows the combined effect
+	require.Len(airdrop.addressesDetail, 2)
+	assert.Equal(sdk.OneDec(), airdrop.addressesDetail[0].TypeMultiplier)
+	detail := airdrop.addressesDetail[1]
+	assert.Equal(sdk.NewDecWithPrec(5, 1), detail.TypeMultiplier)
+	assert.Equal(airdrop.addressesDetail[0].NoDetail.AtoneAmt.QuoInt64(2), detail.NoDetail.AtoneAmt)
+
+	require.NoError(os.WriteFile(file, []byte(`{"ModuleAccount": "-1"}`), 0o644))
+	_, err = parseTypeMultipliers(file)
+	assert.EqualError(err, "invalid multiplier for account type 'ModuleAccount'")
+}
+
+func TestDistributionStages(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: vote(govtypes.OptionYes)},
+		{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000), Vote: vote(govtypes.OptionNo)},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.ZeroDec()},
+	}
+	var passes int
+	seq := func(yield func(Account) bool) {
+		passes++
+		for _, acc := range accounts {
+			if !yield(acc) {
+				return
+			}
+		}
+	}
+	var (
+		ctx     = context.Background()
+		stages  = newDistributionStages(seq)
+		params  = defaultDistriParams()
+		params2 = defaultDistriParams()
+	)
+	params2.bonus = sdk.NewDecWithPrec(110, 2)
+	params2.malus = sdk.NewDecWithPrec(90, 2)
+
+	for _, p := range []distriParams{params, params2} {
+		airdrop, err := stages.run(ctx, p, "", checkpointConfig{})
+		require.NoError(err)
+		expected, err := distribution(accounts, p, "")
+		require.NoError(err)
+		assert.Equal(expected.addresses, airdrop.addresses)
+		assert.Equal(expected.nonVotersMultiplier, airdrop.nonVotersMultiplier)
+	}
+	// 1 aggregate pass and 1 allocate pass per params
+	assert.Equal(3, passes)
+
+	// The address cap solver results are cached too
+	params.addressCap = sdk.NewInt(50)
+	passes = 0
+	_, err := stages.run(ctx, params, "", checkpointConfig{})
+	require.NoError(err)
+	solverPasses := passes - 1
+	assert.Positive(solverPasses)
+	passes = 0
+	_, err = stages.run(ctx, params, "", checkpointConfig{})
+	require.NoError(err)
+	assert.Equal(1, passes)
+}
+
+func TestDistributionVoteTiming(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	var (
+		start = time.Date(2023, 11, 10, 0, 0, 0, 0, time.UTC)
+		end   = start.Add(100 * time.Hour)
+		times = map[string]time.Time{
+			"early":  start,
+			"middle": start.Add(50 * time.Hour),
+			"late":   end,
+			// not a direct voter, ignored
+			"liquid": start,
+		}
+		accounts = []Account{
+			{Address: "early", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000), Vote: vote(govtypes.OptionNo)},
+			{Address: "middle", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000), Vote: vote(govtypes.OptionNo)},
+			{Address: "late", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000), Vote: vote(govtypes.OptionNo)},
+			{Address: "liquid", LiquidAmount: sdk.NewDec(1000), StakedAmount: sdk.ZeroDec()},
+		}
+		params = defaultDistriParams()
+	)
+	noTiming, err := distribution(accounts, params, "")
+	require.NoError(err)
+	params.voteTiming, err = newVoteTiming(times, start, end, voteTimeDecayLinear, sdk.NewDecWithPrec(1, 1), 0)
+	require.NoError(err)
+
+	airdrop, err := distribution(accounts, params, "")
+
+	require.NoError(err)
+	assert.Equal(sdk.NewInt(990), airdrop.addresses["early"])  // 900 x 1.1
+	assert.Equal(sdk.NewInt(945), airdrop.addresses["middle"]) // 900 x 1.05
+	assert.Equal(sdk.NewInt(900), airdrop.addresses["late"])
+	assert.Equal(noTiming.addresses["liquid"], airdrop.addresses["liquid"])
+	assert.Equal(3, airdrop.voteTime.accounts)
+	assert.Equal(sdk.NewDec(135), airdrop.voteTime.bonus)
+	assert.Equal(sdk.NewDecWithPrec(11, 1), airdrop.voteTime.maxMultiplier)
+	assert.Equal(sdk.OneDec(), airdrop.voteTime.minMultiplier)
+
+	// exponential decay halves the bonus every half-life
+	params.voteTiming, err = newVoteTiming(times, start, end, voteTimeDecayExponential, sdk.NewDecWithPrec(1, 1), 50*time.Hour)
+	require.NoError(err)
+	mult, ok := params.voteTiming.multiplier("middle")
+	assert.True(ok)
+	assert.Equal(sdk.NewDecWithPrec(105, 2), mult)
+	mult, ok = params.voteTiming.multiplier("late")
+	assert.True(ok)
+	assert.Equal(sdk.NewDecWithPrec(1025, 3), mult)
+	_, ok = params.voteTiming.multiplier("unknown")
+	assert.False(ok)
+
+	_, err = newVoteTiming(times, start, end, "foo", sdk.NewDecWithPrec(1, 1), 0)
+	assert.EqualError(err, "unknown vote time decay 'foo'")
+}
+
+func TestDistributionAbstainMultiplier(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	var (
+		accounts = []Account{
+			{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000), Vote: vote(govtypes.OptionYes)},
+			{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000), Vote: vote(govtypes.OptionNo)},
+			{Address: "abstain", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000), Vote: vote(govtypes.OptionAbstain)},
+			{Address: "liquid", LiquidAmount: sdk.NewDec(1000), StakedAmount: sdk.ZeroDec()},
+		}
+		params = defaultDistriParams()
+	)
+	shared, err := distribution(accounts, params, "")
+	require.NoError(err)
+	// Abstain shares the nonVotersMultiplier with the liquid amounts
+	assert.Equal(shared.nonVotersMultiplier, shared.addressesDetail[2].AbsDetail.Multiplier)
+	params.abstainMultiplier, params.abstainBonusMalus, err = parseAbstainParams("2", "1.1")
+	require.NoError(err)
+
+	airdrop, err := distribution(accounts, params, "")
+
+	require.NoError(err)
+	assert.Equal("Yes x1.0 / No x9.0 / Abstain x2.0", airdrop.params.String())
+	assert.Equal(sdk.NewInt(100), airdrop.addresses["yes"])
+	assert.Equal(sdk.NewInt(900), airdrop.addresses["no"])
+	assert.Equal(sdk.NewInt(220), airdrop.addresses["abstain"]) // 1000 x 2 x 1.1 x 0.1
+	// Abstain is no longer part of the non-voters, whose multiplier is solved
+	// with the Abstain allocation counted as voters.
+	assert.Equal(sdk.NewDecWithPrec(33, 2).MulInt64(12000).Quo(sdk.NewDecWithPrec(67, 2).MulInt64(1000)),
+		airdrop.nonVotersMultiplier)
+	assert.Zero(airdrop.atone.votes[govtypes.OptionEmpty].TruncateInt64())
+	assert.Equal(sdk.NewDec(220), airdrop.atone.votes[govtypes.OptionAbstain])
+
+	_, _, err = parseAbstainParams("-1", "")
+	assert.EqualError(err, "invalid abstainMultiplier '-1'")
+	_, _, err = parseAbstainParams("", "x")
+	assert.EqualError(err, "invalid abstainBonusMalus 'x'")
+}
+
+func TestDistributionValidatorLiquid(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		vals     = createValidatorAddrs(2)
+		operator = sdk.AccAddress(vals[0]).String()
+		idle     = sdk.AccAddress(vals[1]).String()
+		voteNo   = govtypes.WeightedVoteOptions{{Option: govtypes.OptionNo, Weight: sdk.OneDec()}}
+		accounts = []Account{
+			{
+				Address: operator, LiquidAmount: sdk.NewDec(1000), StakedAmount: sdk.NewDec(100), Vote: voteNo,
+				Delegations: []Delegation{{Amount: sdk.NewDec(100), ValidatorAddress: vals[0].String(), Vote: voteNo}},
+			},
+			{
+				// operator that didn't vote
+				Address: idle, LiquidAmount: sdk.NewDec(1000), StakedAmount: sdk.NewDec(100),
+				Delegations: []Delegation{{Amount: sdk.NewDec(100), ValidatorAddress: vals[1].String()}},
+			},
+			{Address: "voter", LiquidAmount: sdk.NewDec(1000), StakedAmount: sdk.NewDec(100), Vote: voteNo},
+			{Address: "liquid", LiquidAmount: sdk.NewDec(5000), StakedAmount: sdk.ZeroDec()},
+		}
+		params = defaultDistriParams()
+	)
+	params.entityGroups = nil
+	base, err := distribution(accounts, params, "")
+	require.NoError(err)
+	params.validatorLiquid = true
+
+	airdrop, err := distribution(accounts, params, "")
+
+	require.NoError(err)
+	// The liquid amount of the operator that voted gets the No multiplier
+	// instead of the non-voters malus.
+	liquidAmt := sdk.NewDec(1000).Mul(base.nonVotersMultiplier.Mul(params.malus)).Mul(params.supplyFactor)
+	votedAmt := sdk.NewDec(1000).Mul(params.noVotesMultiplier).Mul(params.supplyFactor)
+	assert.Equal(1, airdrop.validatorLiquid.accounts)
+	assert.Equal(sdk.NewDec(1000), airdrop.validatorLiquid.liquid)
+	assert.Equal(votedAmt.Sub(liquidAmt).String(), airdrop.validatorLiquid.delta.String())
+	assert.Equal(base.addresses[operator].ToLegacyDec().Add(votedAmt).Sub(liquidAmt).RoundInt(), airdrop.addresses[operator])
+	assert.Contains(airdrop.audit[0].Policies, policyValidatorLiquid)
+	for _, addr := range []string{idle, "voter", "liquid"} {
+		assert.Equal(base.addresses[addr], airdrop.addresses[addr], addr)
+	}
+	assert.Equal(base.nonVotersMultiplier, airdrop.nonVotersMultiplier)
+}
+
+func TestDistributionDecGuards(t *testing.T) {
+	vote := govtypes.WeightedVoteOptions{{Option: govtypes.OptionNo, Weight: sdk.NewDec(1)}}
+	tests := []struct {
+		name          string
+		accounts      []Account
+		params        func(*distriParams)
+		expectedError string
+	}{
+		{
+			name: "negative liquid amount",
+			accounts: []Account{
+				{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: vote},
+				{Address: "liquid", LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.ZeroDec()},
+				{Address: "neg", LiquidAmount: sdk.NewDec(-1), StakedAmount: sdk.ZeroDec()},
+			},
+			expectedError: "account neg: input: liquid amount is negative (-1.000000000000000000)",
+		},
+		{
+			name: "nothing to solve",
+			accounts: []Account{
+				{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: vote},
+			},
+			expectedError: "solve: no $ATOM of non-voters nor liquid",
+		},
+		{
+			name: "negative bonus",
+			accounts: []Account{
+				{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: vote},
+				{Address: "liquid", LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.ZeroDec()},
+			},
+			params:        func(p *distriParams) { p.bonus = sdk.NewDec(-1) },
+			expectedError: "multipliers: noWithVeto bonus/malus is negative (-1.000000000000000000)",
+		},
+		{
+			name: "unset supply factor",
+			accounts: []Account{
+				{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: vote},
+				{Address: "liquid", LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.ZeroDec()},
+			},
+			params:        func(p *distriParams) { p.supplyFactor = sdk.Dec{} },
+			expectedError: "multipliers: supplyFactor is unset",
+		},
+		{
+			name: "negative type multiplier",
+			accounts: []Account{
+				{Address: "no", Type: "/cosmos.auth.v1beta1.BaseAccount", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: vote},
+				{Address: "liquid", LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.ZeroDec()},
+			},
+			params:        func(p *distriParams) { p.typeMultipliers = typeMultipliers{"BaseAccount": sdk.NewDec(-1)} },
+			expectedError: "account no: type multiplier: BaseAccount is negative",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := defaultDistriParams()
+			if tt.params != nil {
+				tt.params(&params)
+			}
+
+			_, err := distribution(tt.accounts, params, "")
+
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestValidatorDetails(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs    = createAccountAddrs(3)
+		yes      = govtypes.NewNonSplitVoteOption(govtypes.OptionYes)
+		no       = govtypes.NewNonSplitVoteOption(govtypes.OptionNo)
+		accounts = []Account{
+			// inherits the vote of the first validator only
+			{
+				Address: addrs[0].String(), LiquidAmount: sdk.NewDec(10), StakedAmount: sdk.NewDec(300),
+				Delegations: []Delegation{
+					{Amount: sdk.NewDec(100), ValidatorAddress: "val1", Vote: yes},
+					{Amount: sdk.NewDec(200), ValidatorAddress: "val2"},
+				},
+			},
+			// the direct vote applies to all the delegations
+			{
+				Address: addrs[1].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(300), Vote: no,
+				Delegations: []Delegation{
+					{Amount: sdk.NewDec(100), ValidatorAddress: "val1", Vote: yes},
+					{Amount: sdk.NewDec(200), ValidatorAddress: "val2"},
+				},
+			},
+			// no delegation
+			{Address: addrs[2].String(), LiquidAmount: sdk.NewDec(10), StakedAmount: sdk.ZeroDec()},
+		}
+		params = defaultDistriParams()
+	)
+	params.entityGroups = nil
+	airdrop, err := distribution(accounts, params, "atone")
+	require.NoError(err)
+	require.Len(airdrop.addressesDetail, 3)
+
+	d := airdrop.addressesDetail[0]
+	require.Len(d.Validators, 2)
+	assert.Equal(validatorDetail{
+		ValidatorAddress: "val1", Vote: yes, AtomAmt: sdk.NewDec(100), AtoneAmt: d.YesDetail.AtoneAmt,
+	}, d.Validators[0])
+	assert.Equal(validatorDetail{
+		ValidatorAddress: "val2", AtomAmt: sdk.NewDec(200), AtoneAmt: d.DnvDetail.AtoneAmt,
+	}, d.Validators[1])
+
+	d = airdrop.addressesDetail[1]
+	require.Len(d.Validators, 2)
+	assert.Equal(no, d.Validators[0].Vote)
+	assert.Equal(no, d.Validators[1].Vote)
+	assert.Equal(d.NoDetail.AtoneAmt, d.Validators[0].AtoneAmt.Add(d.Validators[1].AtoneAmt))
+	assert.Equal(d.NoDetail.AtoneAmt.QuoInt64(3), d.Validators[0].AtoneAmt)
+
+	assert.Empty(airdrop.addressesDetail[2].Validators)
+
+	var buf bytes.Buffer
+	require.NoError(writeAirdropValidatorDetail(&buf, airdrop))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(lines, 5)
+	assert.Equal("address,validator,vote,atomAmt,atoneAmt", lines[0])
+	v := airdrop.addressesDetail[0]
+	assert.Equal(strings.Join([]string{
+		v.Address, "val1", "yes:1.000000000000000000", "100.000000000000000000", v.Validators[0].AtoneAmt.String(),
+	}, ","), lines[1])
+	assert.Equal(strings.Join([]string{
+		v.Address, "val2", "", "200.000000000000000000", v.Validators[1].AtoneAmt.String(),
+	}, ","), lines[2])
+}
@@ -0,0 +1,26 @@
+// Command govbox turns a Cosmos Hub snapshot into the AtomOne genesis, and
+// provides the tools to analyze the governance data of the snapshot.
+//
+// Usage:
+//
+//	go run . COMMAND PATH
+//
+// Where PATH is a directory containing the snapshot files (votes.json,
+// delegations.json, active_validators.json, prop.json, balances.json and
+// auth_genesis.json), see SNAPSHOT-EXTRACT.md.
+//
+// The airdrop is computed in 3 steps, which are also the main entry points of
+// the code:
+//
+//   - getAccounts builds the list of accounts from the parsed snapshot files
+//     (see parseDelegationsByAddr, parseVotesByAddr, parseValidatorsByAddr,
+//     parseBalancesByAddr and parseAccountTypesPerAddr). Each account holds
+//     its liquid and staked amounts, its direct vote and the votes of its
+//     validators.
+//   - distribution computes the $ATONE airdrop of these accounts for a set of
+//     distriParams (see defaultDistriParams).
+//   - writeGenesis writes the airdrops in the genesis, genesisBalances returns
+//     the bank balances it uses.
+//
+// See the examples of example_test.go for usage references.
+package main
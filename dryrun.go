@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	h "github.com/dustin/go-humanize"
+)
+
+// Rough per-record costs used to estimate the resources of a distribution
+// run. They only aim at giving the order of magnitude, not a precise figure.
+const (
+	// Decoding throughput of accounts.json, in bytes per second.
+	dryRunDecodeRate = 40 << 20
+	// Memory held per account and per delegation by parseAccounts, mostly
+	// the big.Int of the sdk.Dec amounts.
+	dryRunAccountMem    = 600
+	dryRunDelegationMem = 250
+	// Memory held per account and per delegation by compactAccounts.
+	dryRunCompactAccountMem    = 80
+	dryRunCompactDelegationMem = 16
+	// Time and memory of the distribution of one account, for each set of
+	// parameters (airdrop amount, detail and audit entry).
+	dryRunDistribTime = 25 * time.Microsecond
+	dryRunDistribMem  = 1500
+)
+
+// dryRunPlan is the estimate of the resources required by a distribution
+// run.
+type dryRunPlan struct {
+	fileSize    int64
+	accounts    int
+	delegations int
+	compact     bool
+	numParams   int
+	duration    time.Duration
+	peakMemory  uint64
+	outputs     []string
+}
+
+// countAccounts counts the accounts and delegations of accounts.json, by
+// matching their JSON keys instead of decoding them, which is much faster.
+// It relies on the indented format of accounts.json, one key per line. The
+// Sources of the merged accounts also have an Address key, deeper, so only
+// the keys indented as the first one, the one of the first account, count.
+func countAccounts(ctx context.Context, r io.Reader) (accounts, delegations int, err error) {
+	var (
+		accountKey    = []byte(`"Address":`)
+		delegationKey = []byte(`"ValidatorAddress":`)
+		accountIndent = -1
+		scanner       = bufio.NewScanner(r)
+	)
+	scanner.Buffer(make([]byte, 1<<20), 1<<20)
+	for i := 0; scanner.Scan(); i++ {
+		if i%100_000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return 0, 0, err
+			}
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		indent := len(scanner.Bytes()) - len(bytes.TrimLeft(scanner.Bytes(), " \t"))
+		switch {
+		case bytes.HasPrefix(line, accountKey):
+			if accountIndent < 0 {
+				accountIndent = indent
+			}
+			if indent == accountIndent {
+				accounts++
+			}
+		case bytes.HasPrefix(line, delegationKey):
+			delegations++
+		}
+	}
+	return accounts, delegations, scanner.Err()
+}
+
+// planDistribution scans accountsFile and estimates the resources of the
+// distribution of numParams sets of parameters.
+func planDistribution(ctx context.Context, accountsFile string, compact bool, numParams int) (_ dryRunPlan, err error) {
+	f, err := openInput(ctx, accountsFile)
+	if err != nil {
+		return dryRunPlan{}, err
+	}
+	defer closeInput(f, &err)
+	// The size is counted while scanning, because accountsFile can be a URL
+	r := &countingReader{r: f}
+	accounts, delegations, err := countAccounts(ctx, r)
+	if err != nil {
+		return dryRunPlan{}, err
+	}
+	plan := dryRunPlan{
+		fileSize:    r.n,
+		accounts:    accounts,
+		delegations: delegations,
+		compact:     compact,
+		numParams:   numParams,
+	}
+	plan.estimate()
+	return plan, nil
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (p *dryRunPlan) estimate() {
+	accountMem, delegationMem := uint64(dryRunAccountMem), uint64(dryRunDelegationMem)
+	if p.compact {
+		accountMem, delegationMem = dryRunCompactAccountMem, dryRunCompactDelegationMem
+	}
+	p.peakMemory = uint64(p.accounts)*accountMem + uint64(p.delegations)*delegationMem +
+		uint64(p.numParams)*uint64(p.accounts)*dryRunDistribMem
+	p.duration = time.Duration(p.fileSize)*time.Second/dryRunDecodeRate +
+		time.Duration(p.numParams*p.accounts)*dryRunDistribTime
+}
+
+func printDryRun(p dryRunPlan) {
+	mode := "default"
+	if p.compact {
+		mode = "compact"
+	}
+	fmt.Println("Dry run")
+	table := newMarkdownTable("FILE SIZE", "ACCOUNTS", "DELEGATIONS", "MODE", "PARAMETER SETS", "EST. TIME", "EST. PEAK MEMORY")
+	table.Append([]string{
+		h.Bytes(uint64(p.fileSize)),
+		humanCount(p.accounts),
+		humanCount(p.delegations),
+		mode,
+		fmt.Sprint(p.numParams),
+		p.duration.Round(time.Second).String(),
+		h.Bytes(p.peakMemory),
+	})
+	table.Render()
+	fmt.Println()
+	if len(p.outputs) == 0 {
+		fmt.Println("No output file would be produced")
+		return
+	}
+	fmt.Println("Output files that would be produced:")
+	for _, o := range p.outputs {
+		fmt.Printf("- %s\n", o)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestPlanDistribution(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	accounts := []Account{
+		{
+			Address: "cosmos1a", LiquidAmount: sdk.NewDec(1), StakedAmount: sdk.NewDec(2),
+			Delegations: []Delegation{
+				{Amount: sdk.NewDec(1), ValidatorAddress: "cosmosvaloper1a"},
+				{Amount: sdk.NewDec(1), ValidatorAddress: "cosmosvaloper1b"},
+			},
+			// merged from 2 chains, counted once
+			Sources: []SourceAmount{
+				{Chain: "cosmos", Address: "cosmos1a", LiquidAmount: sdk.NewDec(1), StakedAmount: sdk.NewDec(1)},
+				{Chain: "atone", Address: "atone1a", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1)},
+			},
+		},
+		{Address: "cosmos1b", LiquidAmount: sdk.NewDec(1), StakedAmount: sdk.ZeroDec()},
+	}
+	bz, err := json.MarshalIndent(accounts, "", "  ")
+	require.NoError(err)
+	file := filepath.Join(t.TempDir(), "accounts.json")
+	require.NoError(os.WriteFile(file, bz, 0o644))
+
+	plan, err := planDistribution(context.Background(), file, false, 2)
+
+	require.NoError(err)
+	assert.Equal(int64(len(bz)), plan.fileSize)
+	assert.Equal(2, plan.accounts)
+	assert.Equal(2, plan.delegations)
+	assert.Positive(plan.duration)
+	compactPlan, err := planDistribution(context.Background(), file, true, 2)
+	require.NoError(err)
+	assert.Less(compactPlan.peakMemory, plan.peakMemory)
+}
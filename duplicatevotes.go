@@ -0,0 +1,23 @@
+package main
+
+import "fmt"
+
+// Policies for the voters with several entries in votes.json, which some
+// exports contain, e.g. when they merge the vote changes of the voters.
+const (
+	// duplicateVotesKeepLast keeps the last entry of the voter, the latest vote
+	// of an export ordered by height.
+	duplicateVotesKeepLast  = "keep-last"
+	duplicateVotesKeepFirst = "keep-first"
+	// duplicateVotesError refuses the export.
+	duplicateVotesError = "error"
+)
+
+func validateDuplicateVotesPolicy(policy string) error {
+	switch policy {
+	case duplicateVotesKeepLast, duplicateVotesKeepFirst, duplicateVotesError:
+		return nil
+	}
+	return fmt.Errorf("unknown duplicate votes policy '%s' (expected %s, %s or %s)", policy,
+		duplicateVotesKeepLast, duplicateVotesKeepFirst, duplicateVotesError)
+}
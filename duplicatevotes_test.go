@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestParseVotesByAddrDuplicates(t *testing.T) {
+	datapath := t.TempDir()
+	votes := `[
+	{"proposal_id":"848","voter":"a","option":"VOTE_OPTION_YES"},
+	{"proposal_id":"848","voter":"b","option":"VOTE_OPTION_NO"},
+	{"proposal_id":"848","voter":"a","option":"VOTE_OPTION_ABSTAIN"},
+	{"proposal_id":"848","voter":"a","option":"VOTE_OPTION_NO_WITH_VETO"}
+]`
+	require.NoError(t, os.WriteFile(filepath.Join(datapath, "votes.json"), []byte(votes), 0o600))
+	tests := []struct {
+		policy        string
+		expectedVoteA govtypes.VoteOption
+		expectedError string
+	}{
+		{policy: duplicateVotesKeepLast, expectedVoteA: govtypes.OptionNoWithVeto},
+		{policy: duplicateVotesKeepFirst, expectedVoteA: govtypes.OptionYes},
+		{policy: duplicateVotesError, expectedError: "duplicate vote of a"},
+		{policy: "keep-all", expectedError: "unknown duplicate votes policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			defer func(w *warningsRegistry) { warnings = w }(warnings)
+			warnings = newWarningsRegistry()
+
+			votesByAddr, err := parseVotesByAddrDuplicates(context.Background(), datapath, tt.policy)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(err, tt.expectedError)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(map[string]govtypes.WeightedVoteOptions{
+				"a": govtypes.NewNonSplitVoteOption(tt.expectedVoteA),
+				"b": govtypes.NewNonSplitVoteOption(govtypes.OptionNo),
+			}, votesByAddr)
+			assert.Equal(2, warnings.get(warnVoteDuplicate))
+		})
+	}
+}
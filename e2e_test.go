@@ -0,0 +1,253 @@
+//go:build e2e
+
+package main
+
+// End-to-end test of the whole pipeline against a local chain, which requires
+// docker:
+//
+//	go test -tags e2e -run TestE2E -timeout 30m .
+//
+// The images can be changed with the GOVBOX_E2E_GAIA_IMAGE and
+// GOVBOX_E2E_ATOMONE_IMAGE environment variables.
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	e2eGaiaImage    = "ghcr.io/cosmos/gaia:v15.2.0"
+	e2eAtomOneImage = "ghcr.io/atomone-hub/atomone:v1.0.0"
+	// e2eMnemonic is the mnemonic of the validator of both chains, so the
+	// atomone gentx is signed by an airdrop recipient.
+	e2eMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art"
+	// e2eTxFlags are the common flags of the txs.
+	e2eTxFlags = "--keyring-backend test --chain-id gaia-e2e --fees 5000uatom -y"
+)
+
+// e2eContainer is a container kept alive by a sleep entrypoint, in which the
+// node commands are run with exec.
+type e2eContainer struct {
+	t    *testing.T
+	name string
+}
+
+func startE2EContainer(t *testing.T, image, name string) *e2eContainer {
+	t.Helper()
+	c := &e2eContainer{t: t, name: name}
+	exec.Command("docker", "rm", "-f", name).Run()
+	c.docker("run", "-d", "--name", name, "--entrypoint", "sleep", image, "infinity")
+	t.Cleanup(func() { exec.Command("docker", "rm", "-f", name).Run() })
+	return c
+}
+
+// docker runs a docker command and returns its output, the test fails if the
+// command fails.
+func (c *e2eContainer) docker(args ...string) string {
+	c.t.Helper()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		c.t.Fatalf("docker %s: %v\n%s%s", strings.Join(args, " "), err, stdout.String(), stderr.String())
+	}
+	return stdout.String()
+}
+
+// sh runs script in the container.
+func (c *e2eContainer) sh(script string) string {
+	c.t.Helper()
+	return c.docker("exec", c.name, "sh", "-ec", script)
+}
+
+// height returns the latest height of the node of the container, started
+// with cli, 0 if it's not ready.
+func (c *e2eContainer) height(cli string) int {
+	out, _ := exec.Command("docker", "exec", c.name, cli, "status").CombinedOutput()
+	m := regexp.MustCompile(`"latest_block_height":"(\d+)"`).FindSubmatch(out)
+	if m == nil {
+		return 0
+	}
+	h, _ := strconv.Atoi(string(m[1]))
+	return h
+}
+
+// waitBlocks waits until the node of the container, started with cli, has
+// produced n more blocks.
+func (c *e2eContainer) waitBlocks(cli string, n int) {
+	c.t.Helper()
+	target := c.height(cli) + n
+	for deadline := time.Now().Add(2 * time.Minute); time.Now().Before(deadline); time.Sleep(time.Second) {
+		if c.height(cli) >= target {
+			return
+		}
+	}
+	c.t.Fatalf("%s: height %d not reached", c.name, target)
+}
+
+// extractE2ESnapshot writes in datapath the input files of the accounts
+// command from the gaiad export, like the jq commands of SNAPSHOT-EXTRACT.md.
+func extractE2ESnapshot(t *testing.T, exportFile, datapath string, proposalID string) {
+	t.Helper()
+	bz, err := os.ReadFile(exportFile)
+	require.NoError(t, err)
+	var export struct {
+		AppState struct {
+			Auth json.RawMessage `json:"auth"`
+			Bank struct {
+				Balances json.RawMessage `json:"balances"`
+			} `json:"bank"`
+			Staking struct {
+				Params struct {
+					MaxValidators int `json:"max_validators"`
+				} `json:"params"`
+				Validators  []map[string]any `json:"validators"`
+				Delegations json.RawMessage  `json:"delegations"`
+			} `json:"staking"`
+			Gov struct {
+				Votes []map[string]any `json:"votes"`
+			} `json:"gov"`
+		} `json:"app_state"`
+	}
+	require.NoError(t, json.Unmarshal(bz, &export))
+	state := export.AppState
+	// The export has gov v1 votes, convert them to v1beta1
+	var votes []map[string]any
+	for _, v := range state.Gov.Votes {
+		if v["proposal_id"] == proposalID {
+			delete(v, "metadata")
+			votes = append(votes, v)
+		}
+	}
+	var validators []map[string]any
+	for _, v := range state.Staking.Validators {
+		if v["status"] == "BOND_STATUS_BONDED" {
+			validators = append(validators, v)
+		}
+	}
+	slices.SortFunc(validators, func(a, b map[string]any) int {
+		ta, _ := sdk.NewIntFromString(a["tokens"].(string))
+		tb, _ := sdk.NewIntFromString(b["tokens"].(string))
+		return tb.BigInt().Cmp(ta.BigInt())
+	})
+	validators = validators[:min(len(validators), state.Staking.Params.MaxValidators)]
+	for file, v := range map[string]any{
+		"votes.json":             votes,
+		"delegations.json":       state.Staking.Delegations,
+		"active_validators.json": validators,
+		"balances.json":          state.Bank.Balances,
+		"auth_genesis.json":      state.Auth,
+	} {
+		bz, err := json.Marshal(v)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(datapath, file), bz, 0o600))
+	}
+}
+
+func TestE2E(t *testing.T) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is required")
+	}
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		ctx          = context.Background()
+		dir          = t.TempDir()
+		datapath     = filepath.Join(dir, "snapshot")
+		gentxDir     = filepath.Join(dir, "gentxs")
+		exportFile   = filepath.Join(dir, "export.json")
+		genesisFile  = filepath.Join(dir, "genesis.json")
+		gaiaImage    = cmp.Or(os.Getenv("GOVBOX_E2E_GAIA_IMAGE"), e2eGaiaImage)
+		atomOneImage = cmp.Or(os.Getenv("GOVBOX_E2E_ATOMONE_IMAGE"), e2eAtomOneImage)
+	)
+	require.NoError(os.Mkdir(datapath, 0o700))
+
+	// Start a single node gaia chain
+	gaia := startE2EContainer(t, gaiaImage, "govbox-e2e-gaia")
+	gaia.sh(fmt.Sprintf(`
+		gaiad init e2e --chain-id gaia-e2e
+		cd ~/.gaia/config
+		sed -i 's/"stake"/"uatom"/g; s/"voting_period": "[0-9]*s"/"voting_period": "600s"/' genesis.json
+		sed -i 's/timeout_commit = "5s"/timeout_commit = "1s"/' config.toml
+		sed -i 's/minimum-gas-prices = ""/minimum-gas-prices = "0uatom"/' app.toml
+		echo "%s" | gaiad keys add val --recover --keyring-backend test
+		gaiad keys add voter --keyring-backend test
+		gaiad genesis add-genesis-account val 100000000000uatom --keyring-backend test
+		gaiad genesis add-genesis-account voter 50000000000uatom --keyring-backend test
+		gaiad genesis gentx val 10000000000uatom --chain-id gaia-e2e --keyring-backend test
+		gaiad genesis collect-gentxs`, e2eMnemonic))
+	gaia.docker("exec", "-d", gaia.name, "gaiad", "start")
+	gaia.waitBlocks("gaiad", 2)
+
+	// Delegate, submit a proposal and vote: the validator votes Yes, the
+	// delegator overrides it with No.
+	valoper := strings.TrimSpace(gaia.sh("gaiad keys show val --bech val -a --keyring-backend test"))
+	steps := []string{
+		fmt.Sprintf("gaiad tx staking delegate %s 20000000000uatom --from voter %s", valoper, e2eTxFlags),
+		fmt.Sprintf("gaiad tx gov submit-legacy-proposal --title e2e --description e2e --type Text --deposit 10000000uatom --from val %s", e2eTxFlags),
+		fmt.Sprintf("gaiad tx gov vote 1 yes --from val %s", e2eTxFlags),
+		fmt.Sprintf("gaiad tx gov vote 1 no --from voter %s", e2eTxFlags),
+	}
+	for _, step := range steps {
+		gaia.sh(step)
+		gaia.waitBlocks("gaiad", 2)
+	}
+
+	// Export the state of the stopped node, the proposal is still in voting
+	// period so the votes are in the export.
+	gaia.docker("restart", gaia.name)
+	gaia.sh("gaiad export --output-document /tmp/export.json")
+	gaia.docker("cp", gaia.name+":/tmp/export.json", exportFile)
+	extractE2ESnapshot(t, exportFile, datapath, "1")
+
+	// Create the atomone gentx of the validator
+	atomone := startE2EContainer(t, atomOneImage, "govbox-e2e-atomone")
+	atomone.sh(fmt.Sprintf(`
+		atomoned init e2e --chain-id atomone-1
+		cd ~/.atomone/config
+		sed -i 's/"stake"/"uatone"/g' genesis.json
+		sed -i 's/timeout_commit = "5s"/timeout_commit = "1s"/' config.toml
+		sed -i 's/minimum-gas-prices = ""/minimum-gas-prices = "0uatone"/' app.toml
+		echo "%s" | atomoned keys add val --recover --keyring-backend test
+		atomoned genesis add-genesis-account val 1000000000uatone --keyring-backend test
+		mkdir /tmp/gentxs
+		atomoned genesis gentx val 1000000uatone --chain-id atomone-1 --keyring-backend test --output-document /tmp/gentxs/gentx.json`,
+		e2eMnemonic))
+	atomone.docker("cp", atomone.name+":/tmp/gentxs", gentxDir)
+
+	// Run the pipeline
+	require.NoError(accountsCmd().ParseAndRun(ctx, []string{datapath}))
+	accounts, err := parseAccounts(filepath.Join(datapath, "accounts.json"))
+	require.NoError(err)
+	assert.NotEmpty(accounts)
+	f, err := os.Create(genesisFile)
+	require.NoError(err)
+	stdout := os.Stdout
+	os.Stdout = f
+	err = genesisCmd().ParseAndRun(ctx, []string{"-gentxs", gentxDir, "genesis-atomone-orig.json", datapath})
+	os.Stdout = stdout
+	f.Close()
+	require.NoError(err)
+
+	// Boot a chain with the generated genesis
+	atomone.docker("cp", genesisFile, atomone.name+":/root/.atomone/config/genesis.json")
+	atomone.docker("exec", "-d", atomone.name, "atomoned", "start")
+	atomone.waitBlocks("atomoned", 3)
+}
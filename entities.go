@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Policies applicable to an entity group.
+const (
+	// entityPolicySlash excludes the group addresses from the airdrop.
+	entityPolicySlash = "slash"
+	// entityPolicyPartialSlash removes a percentage of the group addresses
+	// balances before computing their airdrop.
+	entityPolicyPartialSlash = "partial-slash"
+	// entityPolicyCommunityPool sends the airdrop of the group addresses to the
+	// community pool.
+	entityPolicyCommunityPool = "community-pool"
+)
+
+// entityGroup is a list of addresses owned by a same entity (ICF, AiB,
+// exchanges, bridges...) that share a same distribution policy.
+type entityGroup struct {
+	Name      string   `json:"name"`
+	Policy    string   `json:"policy"`
+	Addresses []string `json:"addresses"`
+	// SlashPercent is the part of the balances slashed by the partial-slash
+	// policy, between 0 and 1.
+	SlashPercent sdk.Dec `json:"slashPercent,omitempty"`
+}
+
+func (g entityGroup) validate() error {
+	switch g.Policy {
+	case entityPolicySlash, entityPolicyCommunityPool:
+	case entityPolicyPartialSlash:
+		if g.SlashPercent.IsNil() || g.SlashPercent.IsNegative() || g.SlashPercent.GT(sdk.OneDec()) {
+			return fmt.Errorf("entity group '%s': slashPercent must be between 0 and 1", g.Name)
+		}
+	default:
+		return fmt.Errorf("entity group '%s': unknown policy '%s'", g.Name, g.Policy)
+	}
+	return nil
+}
+
+// auditPolicy returns the policy name used in the audit log.
+func (g entityGroup) auditPolicy() string {
+	return strings.ToLower(g.Name) + "-" + g.Policy
+}
+
+// defaultEntityGroups only slashes the ICF.
+func defaultEntityGroups() []entityGroup {
+	return []entityGroup{{
+		Name:      "ICF",
+		Policy:    entityPolicySlash,
+		Addresses: icfWallets,
+	}}
+}
+
+// entityGroupsByAddr indexes groups by address.
+func entityGroupsByAddr(groups []entityGroup) (map[string]entityGroup, error) {
+	m := make(map[string]entityGroup)
+	for _, g := range groups {
+		for _, addr := range g.Addresses {
+			if other, ok := m[addr]; ok {
+				return nil, fmt.Errorf("address %s is in both entity groups '%s' and '%s'", addr, other.Name, g.Name)
+			}
+			m[addr] = g
+		}
+	}
+	return m, nil
+}
+
+// parseEntityGroups reads the entity groups from a JSON file, for instance:
+//
+//	[
+//	  {"name": "ICF", "policy": "slash", "addresses": ["cosmos1..."]},
+//	  {"name": "AiB", "policy": "partial-slash", "slashPercent": "0.5", "addresses": ["cosmos1..."]},
+//	  {"name": "Exchanges", "policy": "community-pool", "addresses": ["cosmos1..."]}
+//	]
+func parseEntityGroups(path string) (_ []entityGroup, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var groups []entityGroup
+	if err := json.NewDecoder(f).Decode(&groups); err != nil {
+		return nil, fmt.Errorf("cannot json decode entity groups from file %s: %w", path, err)
+	}
+	for _, g := range groups {
+		if err := g.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
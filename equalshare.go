@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// equalShare is the experimental one-address-one-share strategy: each
+// qualifying address gets the same $ATONE amount, whatever its stake, so the
+// egalitarian designs can be contrasted with the stake-weighted ones. The
+// sybil mitigation filters exclude the addresses below a minimum stake, and
+// the ones that started staking less than a minimum age before the snapshot.
+// The policies of the stake-weighted distribution (entity groups, opt-out,
+// hook...) don't apply.
+type equalShare struct {
+	// amount is the allocation of each qualifying address, in uatone. Zero
+	// splits the $ATONE distributed by the stake-weighted run equally.
+	amount sdk.Int
+	// minStake is the minimum staked amount of a qualifying address, in uatom.
+	minStake sdk.Dec
+	// stakeSince holds the time since which each address has been staking,
+	// the stake age filter is disabled if it's nil.
+	stakeSince  map[string]time.Time
+	minStakeAge time.Duration
+	// snapshot is the time the stake age is measured at.
+	snapshot time.Time
+}
+
+// equalShareResult is the outcome of the equal-share strategy.
+type equalShareResult struct {
+	amount    sdk.Int
+	qualified int
+	// excludedStake and excludedAge are the addresses excluded by the minimum
+	// stake and the minimum stake age filters.
+	excludedStake int
+	excludedAge   int
+}
+
+// stakeSinceEntry is an entry of the indexer export of the staking start
+// times.
+type stakeSinceEntry struct {
+	Address string    `json:"address"`
+	Since   time.Time `json:"since"`
+}
+
+// parseStakeSince reads the time since which the addresses have been staking
+// without interruption from an indexer export, a JSON array like
+// [{"address": "cosmos1...", "since": "2023-01-12T10:00:00Z"}].
+func parseStakeSince(path string) (_ map[string]time.Time, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var entries []stakeSinceEntry
+	if err := json.NewDecoder(f).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("cannot json decode stake times from file %s: %w", path, err)
+	}
+	since := make(map[string]time.Time, len(entries))
+	for _, e := range entries {
+		since[e.Address] = e.Since
+	}
+	return since, nil
+}
+
+// loadEqualShare returns the equal-share strategy of the flags. The snapshot
+// time of the stake age filter is the end of the voting period of
+// <datapath>/prop.json.
+func loadEqualShare(datapath, amount, minStake, stakeSinceFile string, minStakeAge time.Duration) (equalShare, error) {
+	amt, err := sdk.NewDecFromStr(amount)
+	if err != nil || amt.IsNegative() {
+		return equalShare{}, fmt.Errorf("invalid equalShareAmount '%s'", amount)
+	}
+	stake, err := sdk.NewDecFromStr(minStake)
+	if err != nil || stake.IsNegative() {
+		return equalShare{}, fmt.Errorf("invalid equalShareMinStake '%s'", minStake)
+	}
+	es := equalShare{amount: amt.MulInt64(M).TruncateInt(), minStake: stake.MulInt64(M)}
+	if stakeSinceFile == "" {
+		if minStakeAge > 0 {
+			return equalShare{}, fmt.Errorf("-minStakeAge requires -stakeSince")
+		}
+		return es, nil
+	}
+	es.stakeSince, err = parseStakeSince(stakeSinceFile)
+	if err != nil {
+		return equalShare{}, err
+	}
+	es.minStakeAge = minStakeAge
+	prop, err := parseProp(datapath)
+	if err != nil {
+		return equalShare{}, fmt.Errorf("stake age snapshot time: %w", err)
+	}
+	es.snapshot = prop.VotingEndTime
+	return es, nil
+}
+
+// qualifies returns true if acc passes the filters of es, and which filter
+// excluded it otherwise.
+func (es equalShare) qualifies(acc Account) (ok, stakeFilter, ageFilter bool) {
+	total := acc.LiquidAmount.Add(acc.StakedAmount).Add(acc.lockedAmount())
+	if !total.IsPositive() || acc.StakedAmount.LT(es.minStake) {
+		return false, true, false
+	}
+	if es.stakeSince != nil {
+		since, ok := es.stakeSince[acc.Address]
+		if !ok || !acc.StakedAmount.IsPositive() || es.snapshot.Sub(since) < es.minStakeAge {
+			return false, false, true
+		}
+	}
+	return true, false, false
+}
+
+// computeEqualShare applies es to the accounts. If es has no amount, the
+// distributed $ATONE is split equally between the qualifying addresses.
+func computeEqualShare(ctx context.Context, accounts iter.Seq[Account], es equalShare, distributed sdk.Dec) (equalShareResult, error) {
+	res := equalShareResult{amount: es.amount}
+	for acc := range accounts {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		ok, stakeFilter, ageFilter := es.qualifies(acc)
+		switch {
+		case ok:
+			res.qualified++
+		case stakeFilter:
+			res.excludedStake++
+		case ageFilter:
+			res.excludedAge++
+		}
+	}
+	if res.qualified == 0 {
+		return res, fmt.Errorf("equal share: no qualifying address")
+	}
+	if res.amount.IsZero() {
+		res.amount = distributed.QuoInt64(int64(res.qualified)).TruncateInt()
+	}
+	return res, nil
+}
+
+// allocationStats describes how an allocation is spread between the
+// addresses.
+type allocationStats struct {
+	addresses int
+	total     sdk.Int
+	median    sdk.Int
+	// top1Perc is the share of the total held by the top 1% addresses.
+	top1Perc sdk.Dec
+	// gini is the Gini coefficient of the allocations, from 0 when all the
+	// addresses get the same amount to 1 when a single one gets everything.
+	gini sdk.Dec
+}
+
+// newAllocationStats returns the stats of the amounts.
+func newAllocationStats(amounts []sdk.Int) allocationStats {
+	s := allocationStats{addresses: len(amounts), total: sdk.ZeroInt(), median: sdk.ZeroInt(), top1Perc: sdk.ZeroDec(), gini: sdk.ZeroDec()}
+	if len(amounts) == 0 {
+		return s
+	}
+	amounts = slices.SortedFunc(slices.Values(amounts), func(a, b sdk.Int) int { return a.BigInt().Cmp(b.BigInt()) })
+	// With the amounts x_i sorted in ascending order:
+	// gini = 2 x sum(i x x_i) / (n x sum(x_i)) - (n+1) / n
+	weighted := sdk.ZeroInt()
+	for i, amt := range amounts {
+		s.total = s.total.Add(amt)
+		weighted = weighted.Add(amt.MulRaw(int64(i + 1)))
+	}
+	n := int64(len(amounts))
+	s.median = amounts[(n-1)/2]
+	if !s.total.IsPositive() {
+		return s
+	}
+	s.gini = weighted.MulRaw(2).ToLegacyDec().Quo(s.total.MulRaw(n).ToLegacyDec()).
+		Sub(sdk.NewDec(n + 1).QuoInt64(n))
+	top, topAmt := max(n/100, 1), sdk.ZeroInt()
+	for _, amt := range amounts[n-top:] {
+		topAmt = topAmt.Add(amt)
+	}
+	s.top1Perc = topAmt.ToLegacyDec().Quo(s.total.ToLegacyDec())
+	return s
+}
+
+// stats returns the allocation stats of the equal-share strategy.
+func (r equalShareResult) stats() allocationStats {
+	amounts := make([]sdk.Int, r.qualified)
+	for i := range amounts {
+		amounts[i] = r.amount
+	}
+	return newAllocationStats(amounts)
+}
+
+func printEqualShare(es equalShare, r equalShareResult) {
+	fmt.Printf("Equal share (experimental): %s addresses qualify for %s $ATONE each, %s excluded by the minimum stake (%s $ATOM)",
+		humanCount(r.qualified), human(r.amount), humanCount(r.excludedStake), humand(es.minStake))
+	if es.stakeSince != nil {
+		fmt.Printf(", %s by the minimum stake age (%s)", humanCount(r.excludedAge), es.minStakeAge)
+	}
+	fmt.Print("\n\n")
+}
+
+// printStrategiesComparison compares the stake-weighted airdrops with the
+// equal-share strategy.
+func printStrategiesComparison(airdrops []airdrop, r equalShareResult) {
+	fmt.Println("Comparison of the stake-weighted and equal-share strategies")
+	table := newMarkdownTable("STRATEGY", "ADDRESSES", "$ATONE", "MEDIAN", "TOP 1% SHARE", "GINI")
+	appendStats := func(name string, s allocationStats) {
+		table.Append([]string{
+			name, humanCount(s.addresses), human(s.total), human(s.median),
+			humanPercent(s.top1Perc), fmt.Sprintf("%.4f", s.gini.MustFloat64()),
+		})
+	}
+	for _, a := range airdrops {
+		appendStats(fmt.Sprintf("stake-weighted (%s)", a.params), newAllocationStats(slices.Collect(maps.Values(a.addresses))))
+	}
+	appendStats("equal share", r.stats())
+	table.Render()
+	fmt.Println()
+}
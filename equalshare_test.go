@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestNewAllocationStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		amounts        []int64
+		expectedMedian int64
+		expectedTop1   sdk.Dec
+		expectedGini   sdk.Dec
+	}{
+		{
+			name:           "empty",
+			expectedTop1:   sdk.ZeroDec(),
+			expectedGini:   sdk.ZeroDec(),
+			expectedMedian: 0,
+		},
+		{
+			name:           "equal",
+			amounts:        []int64{5, 5, 5, 5},
+			expectedMedian: 5,
+			expectedTop1:   sdk.NewDecWithPrec(25, 2),
+			expectedGini:   sdk.ZeroDec(),
+		},
+		{
+			name:           "single holder",
+			amounts:        []int64{4, 0, 0, 0},
+			expectedMedian: 0,
+			expectedTop1:   sdk.OneDec(),
+			expectedGini:   sdk.NewDecWithPrec(75, 2),
+		},
+		{
+			name:           "unsorted",
+			amounts:        []int64{3, 1, 2},
+			expectedMedian: 2,
+			expectedTop1:   sdk.NewDecWithPrec(5, 1),
+			// 2 x (1 + 4 + 9) / (3 x 6) - 4/3
+			expectedGini: sdk.NewDec(28).Quo(sdk.NewDec(18)).Sub(sdk.NewDec(4).QuoInt64(3)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			var amounts []sdk.Int
+			total := int64(0)
+			for _, a := range tt.amounts {
+				amounts = append(amounts, sdk.NewInt(a))
+				total += a
+			}
+
+			s := newAllocationStats(amounts)
+
+			assert.Equal(len(tt.amounts), s.addresses)
+			assert.Equal(sdk.NewInt(total), s.total)
+			assert.Equal(sdk.NewInt(tt.expectedMedian), s.median)
+			assert.Equal(tt.expectedTop1.String(), s.top1Perc.String())
+			assert.Equal(tt.expectedGini.String(), s.gini.String())
+		})
+	}
+}
+
+func TestComputeEqualShare(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs    = createAccountAddrs(5)
+		snapshot = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		accounts = []Account{
+			{Address: addrs[0].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(10 * M)},
+			{Address: addrs[1].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(2 * M)},
+			// below the minimum stake
+			{Address: addrs[2].String(), LiquidAmount: sdk.NewDec(100 * M), StakedAmount: sdk.NewDec(M / 2)},
+			// staking for too short
+			{Address: addrs[3].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(5 * M)},
+			// no staking start time
+			{Address: addrs[4].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(5 * M)},
+		}
+		es = equalShare{
+			amount:   sdk.ZeroInt(),
+			minStake: sdk.NewDec(M),
+			stakeSince: map[string]time.Time{
+				addrs[0].String(): snapshot.Add(-365 * 24 * time.Hour),
+				addrs[1].String(): snapshot.Add(-31 * 24 * time.Hour),
+				addrs[2].String(): snapshot.Add(-365 * 24 * time.Hour),
+				addrs[3].String(): snapshot.Add(-24 * time.Hour),
+			},
+			minStakeAge: 30 * 24 * time.Hour,
+			snapshot:    snapshot,
+		}
+	)
+
+	res, err := computeEqualShare(context.Background(), slices.Values(accounts), es, sdk.NewDec(1001))
+
+	require.NoError(err)
+	assert.Equal(equalShareResult{amount: sdk.NewInt(500), qualified: 2, excludedStake: 1, excludedAge: 2}, res)
+	s := res.stats()
+	assert.Equal(2, s.addresses)
+	assert.Equal(sdk.NewInt(1000), s.total)
+	assert.True(s.gini.IsZero())
+
+	// fixed amount, without the stake age filter
+	es.amount = sdk.NewInt(7)
+	es.stakeSince = nil
+	res, err = computeEqualShare(context.Background(), slices.Values(accounts), es, sdk.NewDec(1001))
+	require.NoError(err)
+	assert.Equal(equalShareResult{amount: sdk.NewInt(7), qualified: 4, excludedStake: 1}, res)
+
+	es.minStake = sdk.NewDec(1000 * M)
+	_, err = computeEqualShare(context.Background(), slices.Values(accounts), es, sdk.NewDec(1001))
+	assert.EqualError(err, "equal share: no qualifying address")
+}
+
+func TestLoadEqualShare(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	es, err := loadEqualShare(t.TempDir(), "1.5", "2", "", 0)
+	require.NoError(err)
+	assert.Equal(sdk.NewInt(1_500_000), es.amount)
+	assert.Equal(sdk.NewDec(2*M), es.minStake)
+	assert.Nil(es.stakeSince)
+
+	_, err = loadEqualShare(t.TempDir(), "-1", "0", "", 0)
+	assert.EqualError(err, "invalid equalShareAmount '-1'")
+	_, err = loadEqualShare(t.TempDir(), "0", "0", "", time.Hour)
+	assert.EqualError(err, "-minStakeAge requires -stakeSince")
+}
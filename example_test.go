@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+func Example_getAccounts() {
+	var (
+		valAddr   = sdk.ValAddress("validator___________")
+		delegator = sdk.AccAddress("delegator___________").String()
+		holder    = sdk.AccAddress("holder______________").String()
+		voteYes   = govtypes.NewNonSplitVoteOption(govtypes.OptionYes)
+	)
+	// The validator has voted Yes, the delegator hasn't voted so it inherits
+	// the vote of its validator.
+	delegsByAddr := map[string][]stakingtypes.Delegation{
+		delegator: {{
+			DelegatorAddress: delegator,
+			ValidatorAddress: valAddr.String(),
+			Shares:           sdk.NewDec(1000),
+		}},
+	}
+	valsByAddr := map[string]govtypes.ValidatorGovInfo{
+		valAddr.String(): govtypes.NewValidatorGovInfo(valAddr, sdk.NewInt(1000), sdk.NewDec(1000), sdk.ZeroDec(), voteYes),
+	}
+	balancesByAddr := map[string]sdk.Coin{
+		delegator: sdk.NewInt64Coin("uatom", 50),
+		holder:    sdk.NewInt64Coin("uatom", 200),
+	}
+	accountTypesPerAddr := map[string]string{
+		delegator: "/cosmos.auth.v1beta1.BaseAccount",
+		holder:    "/cosmos.auth.v1beta1.BaseAccount",
+	}
+
+	accounts, err := getAccounts(context.Background(), delegsByAddr, nil, valsByAddr, balancesByAddr, accountTypesPerAddr, "cosmos")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, acc := range accounts {
+		fmt.Printf("liquid=%s staked=%s delegations=%d\n",
+			acc.LiquidAmount.TruncateInt(), acc.StakedAmount.TruncateInt(), len(acc.Delegations))
+	}
+	// Unordered output:
+	// liquid=50 staked=1000 delegations=1
+	// liquid=200 staked=0 delegations=0
+}
+
+func Example_distribution() {
+	accounts := []Account{
+		{
+			Address:      "yes",
+			LiquidAmount: sdk.ZeroDec(),
+			StakedAmount: sdk.NewDec(1000),
+			Vote:         govtypes.NewNonSplitVoteOption(govtypes.OptionYes),
+		},
+		{
+			Address:      "no",
+			LiquidAmount: sdk.ZeroDec(),
+			StakedAmount: sdk.NewDec(1000),
+			Vote:         govtypes.NewNonSplitVoteOption(govtypes.OptionNo),
+		},
+		{
+			Address:      "holder",
+			LiquidAmount: sdk.NewDec(1000),
+			StakedAmount: sdk.ZeroDec(),
+		},
+	}
+	params := defaultDistriParams()
+	// fake addresses can't be converted to another bech32 prefix, so the
+	// prefix is left empty.
+	airdrop, err := distribution(accounts, params, "")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, addr := range []string{"yes", "no", "holder"} {
+		fmt.Printf("%s: %s uatone\n", addr, airdrop.addresses[addr])
+	}
+	// Output:
+	// yes: 100 uatone
+	// no: 900 uatone
+	// holder: 478 uatone
+}
+
+func Example_genesisBalances() {
+	var (
+		addrYes, _ = convertBech32(icfWallets[0], "cosmos", "atone")
+		accounts   = []Account{
+			{
+				Address:      icfWallets[0],
+				LiquidAmount: sdk.ZeroDec(),
+				StakedAmount: sdk.NewDec(1000),
+				Vote:         govtypes.NewNonSplitVoteOption(govtypes.OptionYes),
+			},
+			{
+				Address:      icfWallets[1],
+				LiquidAmount: sdk.NewDec(1000),
+				StakedAmount: sdk.ZeroDec(),
+			},
+		}
+		params = defaultDistriParams()
+	)
+	params.entityGroups = nil
+	airdrop, err := distribution(accounts, params, "atone")
+	if err != nil {
+		panic(err)
+	}
+
+	// genesisBalances returns the balances that writeGenesis puts in the bank
+	// genesis, including the reserved address and the community pool.
+	balances, communityPool := genesisBalances([]genesisDenom{atoneDenom(airdrop)}, "atone")
+
+	for _, b := range balances {
+		if b.Address == addrYes {
+			fmt.Println("voter:", b.Coins)
+		}
+	}
+	fmt.Println("balances:", len(balances))
+	fmt.Println("community pool:", communityPool)
+	// Output:
+	// voter: 100uatone
+	// balances: 4
+	// community pool: 8uatone
+}
@@ -0,0 +1,594 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// decCrossCheck is set by the -decCrossCheck flag: the hot loops compute each
+// account with both the fixed-point kernel and sdk.Dec, and fail on the first
+// difference.
+var decCrossCheck bool
+
+// fixedPrecision is the 10^18 scale of sdk.Dec.
+const fixedPrecision = 1_000_000_000_000_000_000
+
+// fixedPrecision2Hi and fixedPrecision2Lo are 10^36, the scale applied to the
+// dividend of sdk.Dec.Quo.
+var fixedPrecision2Hi, fixedPrecision2Lo = bits.Mul64(fixedPrecision, fixedPrecision)
+
+// fixedDec is a decimal with the 18 decimals of sdk.Dec, held on the stack in
+// a 128-bit magnitude and a sign instead of a heap allocated big.Int. Its
+// operations round exactly like the sdk.Dec ones, so both give the same
+// results, and report an overflow beyond the 128 bits for the callers to fall
+// back to sdk.Dec.
+type fixedDec struct {
+	// hi and lo are the magnitude of the value times 10^18.
+	hi, lo uint64
+	// neg is false for zero.
+	neg bool
+}
+
+// fixedFromDec returns d as a fixedDec, false if d is nil or doesn't fit.
+func fixedFromDec(d sdk.Dec) (fixedDec, bool) {
+	if d.IsNil() {
+		return fixedDec{}, false
+	}
+	// BigIntMut doesn't copy d, which is only read.
+	i := d.BigIntMut()
+	if i.BitLen() > 128 {
+		return fixedDec{}, false
+	}
+	f := fixedDec{neg: i.Sign() < 0}
+	if bits.UintSize == 64 {
+		// read the words of i, which avoids a copy on the 64-bit platforms
+		switch ws := i.Bits(); len(ws) {
+		case 2:
+			f.hi = uint64(ws[1])
+			fallthrough
+		case 1:
+			f.lo = uint64(ws[0])
+		}
+		return f, true
+	}
+	var buf [16]byte
+	i.FillBytes(buf[:])
+	f.hi, f.lo = binary.BigEndian.Uint64(buf[:8]), binary.BigEndian.Uint64(buf[8:])
+	return f, true
+}
+
+// dec returns f as a sdk.Dec.
+func (f fixedDec) dec() sdk.Dec {
+	d := sdk.ZeroDec()
+	if f.isZero() {
+		return d
+	}
+	i := d.BigIntMut()
+	if bits.UintSize == 64 {
+		ws := []big.Word{big.Word(f.lo), big.Word(f.hi)}
+		if f.hi == 0 {
+			ws = ws[:1]
+		}
+		i.SetBits(ws)
+	} else {
+		var buf [16]byte
+		binary.BigEndian.PutUint64(buf[:8], f.hi)
+		binary.BigEndian.PutUint64(buf[8:], f.lo)
+		i.SetBytes(buf[:])
+	}
+	if f.neg {
+		i.Neg(i)
+	}
+	return d
+}
+
+func (f fixedDec) isZero() bool {
+	return f.hi == 0 && f.lo == 0
+}
+
+// signed returns the magnitude hi, lo with the sign neg, zero being positive.
+func signed(hi, lo uint64, neg bool) fixedDec {
+	return fixedDec{hi: hi, lo: lo, neg: neg && (hi != 0 || lo != 0)}
+}
+
+// add returns f + g, false on overflow.
+func (f fixedDec) add(g fixedDec) (fixedDec, bool) {
+	if f.neg == g.neg {
+		lo, c := bits.Add64(f.lo, g.lo, 0)
+		hi, c := bits.Add64(f.hi, g.hi, c)
+		return signed(hi, lo, f.neg), c == 0
+	}
+	// opposite signs: subtract the smaller magnitude from the larger one
+	if f.hi < g.hi || (f.hi == g.hi && f.lo < g.lo) {
+		f, g = g, f
+	}
+	lo, b := bits.Sub64(f.lo, g.lo, 0)
+	hi, _ := bits.Sub64(f.hi, g.hi, b)
+	return signed(hi, lo, f.neg), true
+}
+
+// mul returns f x g rounded like sdk.Dec.Mul, false on overflow.
+func (f fixedDec) mul(g fixedDec) (fixedDec, bool) {
+	return chopFixed(mul128(f.hi, f.lo, g.hi, g.lo), f.neg != g.neg)
+}
+
+// quo returns f / g rounded like sdk.Dec.Quo, false on overflow or if g is
+// zero.
+func (f fixedDec) quo(g fixedDec) (fixedDec, bool) {
+	if g.isZero() {
+		return fixedDec{}, false
+	}
+	// sdk.Dec.Quo truncates f x 10^36 / g, then rounds the 18 extra decimals.
+	u := mul128(f.hi, f.lo, fixedPrecision2Hi, fixedPrecision2Lo)
+	var q [4]uint64
+	if g.hi == 0 {
+		q, _ = div256by64(u, g.lo)
+	} else {
+		q = div256by128(u, g.hi, g.lo)
+	}
+	return chopFixed(q, f.neg != g.neg)
+}
+
+// chopFixed returns the 256-bit magnitude u divided by 10^18 with the banker's
+// rounding of sdk.Dec, false if it doesn't fit in 128 bits.
+func chopFixed(u [4]uint64, neg bool) (fixedDec, bool) {
+	q, r := div256by64(u, fixedPrecision)
+	if r > fixedPrecision/2 || (r == fixedPrecision/2 && q[0]&1 == 1) {
+		var c uint64
+		for i := range q {
+			q[i], c = bits.Add64(q[i], 1, 0)
+			if c == 0 {
+				break
+			}
+		}
+	}
+	if q[2] != 0 || q[3] != 0 {
+		return fixedDec{}, false
+	}
+	return signed(q[1], q[0], neg), true
+}
+
+// mul128 returns the 256-bit product of the 128-bit ahi, alo and bhi, blo, as
+// little-endian 64-bit words.
+func mul128(ahi, alo, bhi, blo uint64) (p [4]uint64) {
+	h00, l00 := bits.Mul64(alo, blo)
+	h01, l01 := bits.Mul64(alo, bhi)
+	h10, l10 := bits.Mul64(ahi, blo)
+	h11, l11 := bits.Mul64(ahi, bhi)
+	var c1, c2, c3, c4 uint64
+	p[0] = l00
+	p[1], c1 = bits.Add64(h00, l01, 0)
+	p[1], c2 = bits.Add64(p[1], l10, 0)
+	p[2], c3 = bits.Add64(h01, h10, c1)
+	p[2], c4 = bits.Add64(p[2], l11, c2)
+	p[3] = h11 + c3 + c4
+	return p
+}
+
+// div256by64 returns the quotient and the remainder of u by the non-zero v.
+func div256by64(u [4]uint64, v uint64) (q [4]uint64, r uint64) {
+	for i := len(u) - 1; i >= 0; i-- {
+		if r == 0 && u[i] < v {
+			// skip the division of the leading digits smaller than v
+			r = u[i]
+			continue
+		}
+		q[i], r = bits.Div64(r, u[i], v)
+	}
+	return q, r
+}
+
+// div256by128 returns the quotient of u by vhi, vlo, with vhi non-zero, with
+// the long division of Knuth's Algorithm D (TAOCP vol. 2, 4.3.1) on 64-bit
+// digits.
+func div256by128(u [4]uint64, vhi, vlo uint64) (q [4]uint64) {
+	// normalize the divisor so that its top bit is set, Go shifts by 64 give 0
+	s := uint(bits.LeadingZeros64(vhi))
+	vn1 := vhi<<s | vlo>>(64-s)
+	vn0 := vlo << s
+	un := [5]uint64{
+		u[0] << s,
+		u[1]<<s | u[0]>>(64-s),
+		u[2]<<s | u[1]>>(64-s),
+		u[3]<<s | u[2]>>(64-s),
+		u[3] >> (64 - s),
+	}
+	for j := 2; j >= 0; j-- {
+		// estimate the quotient digit from the 2 top digits of the remainder
+		var (
+			qhat, rhat uint64
+			rhatOver   bool
+		)
+		if un[j+2] >= vn1 {
+			qhat = ^uint64(0)
+			var c uint64
+			rhat, c = bits.Add64(un[j+1], vn1, 0)
+			rhatOver = c != 0
+		} else {
+			qhat, rhat = bits.Div64(un[j+2], un[j+1], vn1)
+		}
+		for !rhatOver {
+			ph, pl := bits.Mul64(qhat, vn0)
+			if ph < rhat || (ph == rhat && pl <= un[j]) {
+				break
+			}
+			qhat--
+			var c uint64
+			rhat, c = bits.Add64(rhat, vn1, 0)
+			rhatOver = c != 0
+		}
+		// subtract qhat x v, and add v back if qhat was still one too large
+		p1h, p0 := bits.Mul64(qhat, vn0)
+		p2, p1l := bits.Mul64(qhat, vn1)
+		p1, c := bits.Add64(p1l, p1h, 0)
+		p2 += c
+		var b uint64
+		un[j], b = bits.Sub64(un[j], p0, 0)
+		un[j+1], b = bits.Sub64(un[j+1], p1, b)
+		un[j+2], b = bits.Sub64(un[j+2], p2, b)
+		if b != 0 {
+			qhat--
+			un[j], c = bits.Add64(un[j], vn0, 0)
+			un[j+1], c = bits.Add64(un[j+1], vn1, c)
+			un[j+2] += c
+		}
+		q[j] = qhat
+	}
+	return q
+}
+
+// fixedVoteWeights is Account.voteWeights with the kernel, indexed by vote
+// option. It returns false if an amount doesn't fit or the result would
+// differ from voteWeights.
+func fixedVoteWeights(acc Account) ([5]fixedDec, bool) {
+	var v [5]fixedDec
+	staked, ok := fixedFromDec(acc.StakedAmount)
+	if !ok {
+		return v, false
+	}
+	if staked.isZero() {
+		v[govtypes.OptionEmpty] = fixedDec{lo: fixedPrecision}
+		return v, true
+	}
+	if len(acc.Vote) == 0 {
+		for _, del := range acc.Delegations {
+			amt, ok := fixedFromDec(del.Amount)
+			if !ok {
+				return v, false
+			}
+			delPerc, ok := amt.quo(staked)
+			if !ok {
+				return v, false
+			}
+			if len(del.Vote) == 0 {
+				if v[govtypes.OptionEmpty], ok = v[govtypes.OptionEmpty].add(delPerc); !ok {
+					return v, false
+				}
+				continue
+			}
+			for _, vote := range del.Vote {
+				w, ok := fixedFromDec(vote.Weight)
+				if !ok || int(vote.Option) >= len(v) {
+					return v, false
+				}
+				if w, ok = w.mul(delPerc); !ok {
+					return v, false
+				}
+				if v[vote.Option], ok = v[vote.Option].add(w); !ok {
+					return v, false
+				}
+			}
+		}
+		return v, true
+	}
+	for _, del := range acc.Delegations {
+		if del.Chain != "" {
+			// the direct voters with stake on other chains are left to
+			// voteWeights
+			return v, false
+		}
+	}
+	for _, vote := range acc.Vote {
+		w, ok := fixedFromDec(vote.Weight)
+		if !ok || int(vote.Option) >= len(v) {
+			return v, false
+		}
+		v[vote.Option] = w
+	}
+	return v, true
+}
+
+// fixedAtomVotes returns the staked $ATOM of acc per vote option with the
+// kernel, false if an amount doesn't fit.
+func fixedAtomVotes(acc Account) ([5]fixedDec, bool) {
+	weights, ok := fixedVoteWeights(acc)
+	if !ok {
+		return weights, false
+	}
+	staked, _ := fixedFromDec(acc.StakedAmount)
+	for i := range weights {
+		if weights[i], ok = weights[i].mul(staked); !ok {
+			return weights, false
+		}
+	}
+	return weights, true
+}
+
+// decAtomVotes is fixedAtomVotes with sdk.Dec.
+func decAtomVotes(acc Account) [5]sdk.Dec {
+	weights := acc.voteWeights()
+	var amts [5]sdk.Dec
+	for _, opt := range allVoteOptions {
+		amts[opt] = weights[opt].Mul(acc.StakedAmount)
+	}
+	return amts
+}
+
+// fixedDistrib is a distrib accumulated with the kernel by the aggregate
+// stage. The accounts beyond its 128 bits are accumulated in spill with
+// sdk.Dec.
+type fixedDistrib struct {
+	supply, unstaked, locked fixedDec
+	votes                    [5]fixedDec
+	spill                    distrib
+}
+
+func newFixedDistrib() *fixedDistrib {
+	return &fixedDistrib{spill: distrib{
+		supply:   sdk.ZeroDec(),
+		votes:    newVoteMap(),
+		unstaked: sdk.ZeroDec(),
+		locked:   sdk.ZeroDec(),
+	}}
+}
+
+// add adds the $ATOM of acc to d. In cross-check mode, it returns an error
+// if the kernel and sdk.Dec disagree on the votes of acc.
+func (d *fixedDistrib) add(acc Account) error {
+	votes, ok := fixedAtomVotes(acc)
+	if decCrossCheck && ok {
+		decVotes := decAtomVotes(acc)
+		for _, opt := range allVoteOptions {
+			if err := crossCheck(acc.Address, "$ATOM "+voteOptionLabel(opt), votes[opt], decVotes[opt]); err != nil {
+				return err
+			}
+		}
+	}
+	if ok {
+		if next, ok := d.addFixed(acc, votes); ok {
+			*d = next
+			return nil
+		}
+	}
+	// beyond the kernel, add the account to the sdk.Dec spill
+	decVotes := decAtomVotes(acc)
+	for _, opt := range allVoteOptions {
+		d.spill.votes.add(opt, decVotes[opt])
+	}
+	locked := acc.lockedAmount()
+	d.spill.supply = d.spill.supply.Add(acc.StakedAmount.Add(acc.LiquidAmount).Add(locked))
+	d.spill.unstaked = d.spill.unstaked.Add(acc.LiquidAmount).Add(locked)
+	d.spill.locked = d.spill.locked.Add(locked)
+	return nil
+}
+
+// addFixed returns d with the votes and the amounts of acc added, false if
+// they don't fit.
+func (d fixedDistrib) addFixed(acc Account, votes [5]fixedDec) (fixedDistrib, bool) {
+	staked, ok1 := fixedFromDec(acc.StakedAmount)
+	liquid, ok2 := fixedFromDec(acc.LiquidAmount)
+	locked, ok3 := fixedFromDec(acc.lockedAmount())
+	if !ok1 || !ok2 || !ok3 {
+		return d, false
+	}
+	var ok bool
+	for i := range votes {
+		if d.votes[i], ok = d.votes[i].add(votes[i]); !ok {
+			return d, false
+		}
+	}
+	for _, amt := range []fixedDec{staked, liquid, locked} {
+		if d.supply, ok = d.supply.add(amt); !ok {
+			return d, false
+		}
+	}
+	for _, amt := range []fixedDec{liquid, locked} {
+		if d.unstaked, ok = d.unstaked.add(amt); !ok {
+			return d, false
+		}
+	}
+	if d.locked, ok = d.locked.add(locked); !ok {
+		return d, false
+	}
+	return d, true
+}
+
+// distrib returns d as a distrib. The additions being exact, it's the one
+// accumulated with sdk.Dec.
+func (d *fixedDistrib) distrib() distrib {
+	dist := distrib{
+		supply:   d.supply.dec().Add(d.spill.supply),
+		votes:    newVoteMap(),
+		unstaked: d.unstaked.dec().Add(d.spill.unstaked),
+		locked:   d.locked.dec().Add(d.spill.locked),
+	}
+	for _, opt := range allVoteOptions {
+		dist.votes[opt] = d.votes[opt].dec().Add(d.spill.votes[opt])
+	}
+	return dist
+}
+
+// accountAllocation is the $ATONE allocation of an account computed by the
+// allocate stage, before the policies.
+type accountAllocation struct {
+	// atom and atone are the staked $ATOM and its $ATONE allocation, indexed
+	// by vote option.
+	atom, atone    [5]sdk.Dec
+	liquid, locked sdk.Dec
+	// total is the allocation of the account.
+	total sdk.Dec
+}
+
+// allocationKernel computes the allocation of the accounts for the
+// multipliers of a run.
+type allocationKernel struct {
+	// chains are the multipliers of the staked $ATOM of each vote option, in
+	// the order they are applied, and liquid and locked the ones of the
+	// liquid and locked amounts.
+	chains         [5][]sdk.Dec
+	liquid, locked []sdk.Dec
+	// fixedChains, fixedLiquid and fixedLocked are the multipliers for the
+	// kernel, nil if one of them doesn't fit.
+	fixedChains              [5][]fixedDec
+	fixedLiquid, fixedLocked []fixedDec
+}
+
+func newAllocationKernel(chains [5][]sdk.Dec, liquid, locked []sdk.Dec) *allocationKernel {
+	k := &allocationKernel{chains: chains, liquid: liquid, locked: locked}
+	toFixed := func(ms []sdk.Dec) []fixedDec {
+		fs := make([]fixedDec, len(ms))
+		for i, m := range ms {
+			f, ok := fixedFromDec(m)
+			if !ok {
+				return nil
+			}
+			fs[i] = f
+		}
+		return fs
+	}
+	for i := range chains {
+		k.fixedChains[i] = toFixed(chains[i])
+	}
+	k.fixedLiquid, k.fixedLocked = toFixed(liquid), toFixed(locked)
+	return k
+}
+
+// allocate returns the allocation of acc with voteWeights, with the kernel
+// unless an amount doesn't fit. In cross-check mode, it returns an error if
+// the kernel and sdk.Dec disagree.
+func (k *allocationKernel) allocate(acc Account, voteWeights voteMap) (accountAllocation, error) {
+	a, ok := k.allocateFixed(acc, voteWeights)
+	if !ok {
+		return k.allocateDec(acc, voteWeights), nil
+	}
+	if decCrossCheck {
+		d := k.allocateDec(acc, voteWeights)
+		for _, opt := range allVoteOptions {
+			name := voteOptionLabel(opt)
+			if err := crossCheckDec(acc.Address, "$ATOM "+name, a.atom[opt], d.atom[opt]); err != nil {
+				return a, err
+			}
+			if err := crossCheckDec(acc.Address, "$ATONE "+name, a.atone[opt], d.atone[opt]); err != nil {
+				return a, err
+			}
+		}
+		for _, c := range []struct {
+			name         string
+			fixed, exact sdk.Dec
+		}{{"liquid", a.liquid, d.liquid}, {"locked", a.locked, d.locked}, {"total", a.total, d.total}} {
+			if err := crossCheckDec(acc.Address, c.name, c.fixed, c.exact); err != nil {
+				return a, err
+			}
+		}
+	}
+	return a, nil
+}
+
+func (k *allocationKernel) allocateFixed(acc Account, voteWeights voteMap) (accountAllocation, bool) {
+	var a accountAllocation
+	if k.fixedLiquid == nil || k.fixedLocked == nil {
+		return a, false
+	}
+	staked, ok1 := fixedFromDec(acc.StakedAmount)
+	liquid, ok2 := fixedFromDec(acc.LiquidAmount)
+	locked, ok3 := fixedFromDec(acc.lockedAmount())
+	if !ok1 || !ok2 || !ok3 {
+		return a, false
+	}
+	var (
+		atom, atone [5]fixedDec
+		total       fixedDec
+		ok          bool
+	)
+	for _, opt := range allVoteOptions {
+		w, ok := fixedFromDec(voteWeights[opt])
+		if !ok || k.fixedChains[opt] == nil {
+			return a, false
+		}
+		if atom[opt], ok = w.mul(staked); !ok {
+			return a, false
+		}
+		if atone[opt], ok = mulFixedChain(atom[opt], k.fixedChains[opt]); !ok {
+			return a, false
+		}
+		if total, ok = total.add(atone[opt]); !ok {
+			return a, false
+		}
+	}
+	if liquid, ok = mulFixedChain(liquid, k.fixedLiquid); !ok {
+		return a, false
+	}
+	if locked, ok = mulFixedChain(locked, k.fixedLocked); !ok {
+		return a, false
+	}
+	if total, ok = total.add(liquid); !ok {
+		return a, false
+	}
+	if total, ok = total.add(locked); !ok {
+		return a, false
+	}
+	for _, opt := range allVoteOptions {
+		a.atom[opt], a.atone[opt] = atom[opt].dec(), atone[opt].dec()
+	}
+	a.liquid, a.locked, a.total = liquid.dec(), locked.dec(), total.dec()
+	return a, true
+}
+
+func (k *allocationKernel) allocateDec(acc Account, voteWeights voteMap) accountAllocation {
+	var a accountAllocation
+	staked := sdk.ZeroDec()
+	for _, opt := range allVoteOptions {
+		a.atom[opt] = voteWeights[opt].Mul(acc.StakedAmount)
+		a.atone[opt] = mulDecChain(a.atom[opt], k.chains[opt])
+		staked = staked.Add(a.atone[opt])
+	}
+	a.liquid = mulDecChain(acc.LiquidAmount, k.liquid)
+	a.locked = mulDecChain(acc.lockedAmount(), k.locked)
+	a.total = a.liquid.Add(a.locked).Add(staked)
+	return a
+}
+
+func mulFixedChain(f fixedDec, ms []fixedDec) (fixedDec, bool) {
+	ok := true
+	for _, m := range ms {
+		if f, ok = f.mul(m); !ok {
+			return f, false
+		}
+	}
+	return f, true
+}
+
+func mulDecChain(d sdk.Dec, ms []sdk.Dec) sdk.Dec {
+	for _, m := range ms {
+		d = d.Mul(m)
+	}
+	return d
+}
+
+// crossCheck returns an error if the kernel value f differs from the sdk.Dec
+// value d of name.
+func crossCheck(account, name string, f fixedDec, d sdk.Dec) error {
+	return crossCheckDec(account, name, f.dec(), d)
+}
+
+func crossCheckDec(account, name string, f, d sdk.Dec) error {
+	if !f.Equal(d) {
+		return fmt.Errorf("dec cross-check: %s of %s is %s with the fixed-point kernel and %s with sdk.Dec", name, account, f, d)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestFixedDec(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	// raw returns the sdk.Dec of the integer i x 10^-18
+	raw := func(i int64) sdk.Dec { return sdk.NewDecFromBigIntWithPrec(big.NewInt(i), 18) }
+	atto := raw(1)
+	values := []sdk.Dec{
+		sdk.ZeroDec(),
+		atto,
+		atto.Neg(),
+		sdk.NewDecWithPrec(5, 1),
+		raw(15),
+		raw(25),
+		raw(-35),
+		sdk.OneDec(),
+		sdk.NewDec(3),
+		sdk.NewDec(-7),
+		sdk.MustNewDecFromStr("0.333333333333333333"),
+		sdk.MustNewDecFromStr("1234567.891011121314151617"),
+		sdk.NewDec(1e18),
+		// the largest magnitude of the kernel
+		sdk.NewDecFromBigIntWithPrec(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1)), 18),
+	}
+	r := rand.New(rand.NewSource(1))
+	for range 500 {
+		i := new(big.Int).Rand(r, new(big.Int).Lsh(big.NewInt(1), uint(r.Intn(128)+1)))
+		if r.Intn(2) == 0 {
+			i.Neg(i)
+		}
+		values = append(values, sdk.NewDecFromBigIntWithPrec(i, 18))
+	}
+	// check returns an error if the kernel result f, ok differs from d, or
+	// if it doesn't report an overflow of d.
+	check := func(op string, a, b sdk.Dec, f fixedDec, ok bool, d sdk.Dec) error {
+		fits := d.BigIntMut().BitLen() <= 128
+		switch {
+		case ok != fits:
+			return fmt.Errorf("%s %s %s: kernel ok=%t, sdk.Dec %s", a, op, b, ok, d)
+		case ok && !f.dec().Equal(d):
+			return fmt.Errorf("%s %s %s: kernel %s, sdk.Dec %s", a, op, b, f.dec(), d)
+		}
+		return nil
+	}
+	for _, a := range values {
+		fa, ok := fixedFromDec(a)
+		require.True(ok, a)
+		require.True(fa.dec().Equal(a), a)
+		for _, b := range values {
+			fb, _ := fixedFromDec(b)
+			f, ok := fa.add(fb)
+			require.NoError(check("+", a, b, f, ok, a.Add(b)))
+			f, ok = fa.mul(fb)
+			require.NoError(check("x", a, b, f, ok, a.Mul(b)))
+			if b.IsZero() {
+				_, ok = fa.quo(fb)
+				assert.False(ok)
+				continue
+			}
+			f, ok = fa.quo(fb)
+			require.NoError(check("/", a, b, f, ok, a.Quo(b)))
+		}
+	}
+
+	// the banker's rounding of the 19th decimal
+	half, _ := fixedFromDec(sdk.NewDecWithPrec(5, 1))
+	for _, tt := range []struct{ value, expected string }{
+		{"0.000000000000000001", "0.000000000000000000"},
+		{"0.000000000000000003", "0.000000000000000002"},
+		{"0.000000000000000005", "0.000000000000000002"},
+		{"-0.000000000000000003", "-0.000000000000000002"},
+	} {
+		f, _ := fixedFromDec(sdk.MustNewDecFromStr(tt.value))
+		f, ok := f.mul(half)
+		require.True(ok)
+		assert.Equal(tt.expected, f.dec().String(), tt.value)
+	}
+
+	_, ok := fixedFromDec(sdk.NewDec(1e18).MulInt64(1e18).MulInt64(1e18))
+	assert.False(ok)
+	_, ok = fixedFromDec(sdk.Dec{})
+	assert.False(ok)
+}
+
+func TestDistributionDecCrossCheck(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	defer func(c bool) { decCrossCheck = c }(decCrossCheck)
+	decCrossCheck = true
+	var (
+		r        = rand.New(rand.NewSource(1))
+		addrs    = createAccountAddrs(200)
+		vals     = createValidatorAddrs(5)
+		accounts []Account
+		supply   = sdk.ZeroDec()
+	)
+	splitVote := govtypes.WeightedVoteOptions{
+		{Option: govtypes.OptionYes, Weight: sdk.MustNewDecFromStr("0.3")},
+		{Option: govtypes.OptionNo, Weight: sdk.MustNewDecFromStr("0.7")},
+	}
+	for i, addr := range addrs {
+		acc := Account{
+			Address:      addr.String(),
+			LiquidAmount: sdk.NewDecWithPrec(r.Int63n(1e12), int64(r.Intn(7))),
+			StakedAmount: sdk.ZeroDec(),
+		}
+		for j := range r.Intn(4) {
+			del := Delegation{
+				Amount:           sdk.NewDecWithPrec(r.Int63n(1e12)+1, int64(r.Intn(7))),
+				ValidatorAddress: vals[j].String(),
+			}
+			if j%2 == 0 {
+				del.Vote = splitVote
+			}
+			acc.Delegations = append(acc.Delegations, del)
+			acc.StakedAmount = acc.StakedAmount.Add(del.Amount)
+		}
+		if i%3 == 0 && acc.StakedAmount.IsPositive() {
+			acc.Vote = govtypes.WeightedVoteOptions{{Option: govtypes.OptionNoWithVeto, Weight: sdk.OneDec()}}
+		}
+		accounts = append(accounts, acc)
+		supply = supply.Add(acc.LiquidAmount).Add(acc.StakedAmount)
+	}
+	// beyond the 128 bits of the kernel, computed with sdk.Dec
+	whale := sdk.NewDec(1e18).MulInt64(1e6)
+	accounts = append(accounts, Account{
+		Address:      createAccountAddrs(1)[0].String(),
+		LiquidAmount: sdk.ZeroDec(),
+		StakedAmount: whale,
+		Delegations:  []Delegation{{Amount: whale, ValidatorAddress: vals[0].String(), Vote: splitVote}},
+	})
+	supply = supply.Add(whale)
+
+	params := defaultDistriParams()
+	params.entityGroups = nil
+	airdrop, err := distribution(accounts, params, "")
+	require.NoError(err)
+	assert.Equal(supply.String(), airdrop.atom.supply.String())
+	assert.Equal(whale.Mul(sdk.MustNewDecFromStr("0.3")).String(), airdrop.atom.votes[govtypes.OptionYes].Sub(
+		yesStake(accounts[:len(accounts)-1])).String())
+
+	// a kernel result different from sdk.Dec is an error
+	err = crossCheck(accounts[0].Address, "total", fixedDec{lo: 1}, sdk.ZeroDec())
+	assert.ErrorContains(err, "dec cross-check: total of "+accounts[0].Address)
+}
+
+// yesStake returns the staked $ATOM of the yes votes of accounts.
+func yesStake(accounts []Account) sdk.Dec {
+	yes := sdk.ZeroDec()
+	for _, acc := range accounts {
+		yes = yes.Add(acc.voteWeights()[govtypes.OptionYes].Mul(acc.StakedAmount))
+	}
+	return yes
+}
+
+func BenchmarkAllocationKernel(b *testing.B) {
+	acc := Account{
+		LiquidAmount: sdk.NewDec(1234567890),
+		StakedAmount: sdk.NewDec(3000000000),
+		Delegations: []Delegation{
+			{Amount: sdk.NewDec(1000000000), Vote: govtypes.WeightedVoteOptions{{Option: govtypes.OptionYes, Weight: sdk.OneDec()}}},
+			{Amount: sdk.NewDec(2000000000)},
+		},
+	}
+	multipliers := []sdk.Dec{sdk.MustNewDecFromStr("1.234"), sdk.MustNewDecFromStr("1.1"), sdk.OneDec(), sdk.MustNewDecFromStr("0.99")}
+	kernel := newAllocationKernel([5][]sdk.Dec{multipliers, multipliers, multipliers, multipliers, multipliers},
+		multipliers[:2], multipliers[:2])
+	voteWeights := acc.voteWeights()
+	b.Run("aggregate/fixed", func(b *testing.B) {
+		for range b.N {
+			fixedAtomVotes(acc)
+		}
+	})
+	b.Run("aggregate/sdk.Dec", func(b *testing.B) {
+		for range b.N {
+			decAtomVotes(acc)
+		}
+	})
+	b.Run("allocate/fixed", func(b *testing.B) {
+		for range b.N {
+			kernel.allocateFixed(acc, voteWeights)
+		}
+	})
+	b.Run("allocate/sdk.Dec", func(b *testing.B) {
+		for range b.N {
+			kernel.allocateDec(acc, voteWeights)
+		}
+	})
+}
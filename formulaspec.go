@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const formulaSpecVersion = 1
+
+// Operators of the specExpr.
+const (
+	// specOpConst is the decimal Value, specOpVar the input or formula Name
+	// and specOpParam the parameter Name.
+	specOpConst = "const"
+	specOpVar   = "var"
+	specOpParam = "param"
+	// specOpAdd and specOpMul take any number of Args, evaluated from left to
+	// right, specOpSub and specOpQuo take 2 Args.
+	specOpAdd = "add"
+	specOpSub = "sub"
+	specOpMul = "mul"
+	specOpQuo = "quo"
+	// specOpRound rounds its Arg to an integer.
+	specOpRound = "round"
+	// The comparisons and the logical operators return 1 (true) or 0
+	// (false), any value but 0 is true.
+	specOpGT  = "gt"
+	specOpLT  = "lt"
+	specOpAnd = "and"
+	specOpOr  = "or"
+	// specOpIf returns its 2nd Arg if its 1st is true, else its 3rd.
+	specOpIf = "if"
+	// specOpIn is true if the address of the account is in the list Name.
+	specOpIn = "in"
+)
+
+// specExpr is a node of the expression AST of the formulas, see the specOp
+// constants.
+type specExpr struct {
+	Op    string     `json:"op"`
+	Name  string     `json:"name,omitempty"`
+	Value string     `json:"value,omitempty"`
+	Args  []specExpr `json:"args,omitempty"`
+}
+
+func specConst(v string) specExpr                 { return specExpr{Op: specOpConst, Value: v} }
+func specVar(name string) specExpr                { return specExpr{Op: specOpVar, Name: name} }
+func specParam(name string) specExpr              { return specExpr{Op: specOpParam, Name: name} }
+func specIn(list string) specExpr                 { return specExpr{Op: specOpIn, Name: list} }
+func specOp(op string, args ...specExpr) specExpr { return specExpr{Op: op, Args: args} }
+
+// specFormula defines the variable Name as the value of Expr.
+type specFormula struct {
+	Name string   `json:"name"`
+	Expr specExpr `json:"expr"`
+}
+
+// specDecimal describes the arithmetic of the formulas.
+type specDecimal struct {
+	// Precision is the number of decimals of the fixed-point numbers.
+	Precision int `json:"precision"`
+	// Rounding applies to the results of mul and quo, rounded to Precision
+	// decimals, and of round, rounded to an integer.
+	Rounding string `json:"rounding"`
+}
+
+// specResults summarizes the allocations of the spec, for an independent
+// implementation to compare its outputs.
+type specResults struct {
+	Addresses   int     `json:"addresses"`
+	Distributed sdk.Int `json:"distributed"`
+	// Digest is the hex SHA-256 of the allocations sorted by address, one
+	// "<address> <amount>\n" line per address.
+	Digest string `json:"digest"`
+}
+
+// formulaSpec is a machine-readable description of the computation of the
+// allocations of a distribution: its parameters, the lists of addresses with
+// a policy, and the formulas executed for each account in order, each one
+// able to use the inputs and the previous formulas. The allocation of an
+// account is the last formula, the accounts with a zero allocation are left
+// out of the outputs.
+type formulaSpec struct {
+	Version int         `json:"version"`
+	Decimal specDecimal `json:"decimal"`
+	// Inputs are the per-account values, with their description.
+	Inputs map[string]string `json:"inputs"`
+	// Prefix is the bech32 prefix of the output addresses, empty to keep the
+	// prefix of the accounts.
+	Prefix   string              `json:"prefix,omitempty"`
+	Params   map[string]sdk.Dec  `json:"params"`
+	Lists    map[string][]string `json:"lists"`
+	Formulas []specFormula       `json:"formulas"`
+	Results  specResults         `json:"results"`
+}
+
+// specInputs are the inputs of the formulas of formulaSpec.
+var specInputs = map[string]string{
+	"address":           "address of the account, tested by the in operator",
+	"staked":            "staked $ATOM amount",
+	"liquid":            "liquid $ATOM amount",
+	"weight.yes":        "share of the staked amount voting Yes, directly or through the delegations (by share of each delegation) if the account didn't vote",
+	"weight.no":         "share of the staked amount voting No, same as weight.yes",
+	"weight.noWithVeto": "share of the staked amount voting NoWithVeto, same as weight.yes",
+	"weight.abstain":    "share of the staked amount voting Abstain, same as weight.yes",
+	"weight.didNotVote": "share of the staked amount that didn't vote, 1 if nothing is staked",
+	"votedActive":       "1 if the account voted Yes, No or NoWithVeto directly with a positive weight, else 0",
+}
+
+// newFormulaSpec returns the formulaSpec of the allocations of airdrop, whose
+// addresses are converted to prefix. The account types multipliers, the vote
+// timing, the hook and the vesting locks depend on data outside the spec, so
+// they aren't supported.
+func newFormulaSpec(airdrop airdrop, prefix string) (formulaSpec, error) {
+	params := airdrop.params
+	switch {
+	case len(params.typeMultipliers) > 0:
+		return formulaSpec{}, errors.New("the formula spec doesn't support the account type multipliers")
+	case params.voteTiming != nil:
+		return formulaSpec{}, errors.New("the formula spec doesn't support the vote timing")
+	case params.hook != nil:
+		return formulaSpec{}, errors.New("the formula spec doesn't support the hook")
+	case params.validatorLiquid:
+		return formulaSpec{}, errors.New("the formula spec doesn't support the validator liquid policy")
+	case airdrop.atom.locked.IsPositive():
+		return formulaSpec{}, errors.New("the formula spec doesn't support the vesting locks")
+	}
+	s := formulaSpec{
+		Version: formulaSpecVersion,
+		Decimal: specDecimal{Precision: sdk.Precision, Rounding: "half-even"},
+		Inputs:  specInputs,
+		Prefix:  prefix,
+		Params: map[string]sdk.Dec{
+			"supplyFactor":        params.supplyFactor,
+			"nonVotersMultiplier": airdrop.nonVotersMultiplier,
+			"malus":               params.malus,
+			"shareFactor.liquid":  params.shareFactor("liquid"),
+			"addressCap":          sdk.ZeroDec(),
+			"voterFloor":          sdk.ZeroDec(),
+		},
+		Lists: make(map[string][]string),
+	}
+	if params.hasAddressCap() {
+		s.Params["addressCap"] = params.addressCap.ToLegacyDec()
+	}
+	if params.hasVoterFloor() {
+		s.Params["voterFloor"] = params.voterFloor.ToLegacyDec()
+	}
+	// keep is the part of the balances kept by the partial-slash groups,
+	// excluded is true for the accounts without allocation.
+	var (
+		keep     = specConst("1")
+		excluded []specExpr
+	)
+	for _, g := range slices.Backward(params.entityGroups) {
+		list := "entity." + g.Name
+		s.Lists[list] = g.Addresses
+		switch g.Policy {
+		case entityPolicyPartialSlash:
+			s.Params["keep."+g.Name] = sdk.OneDec().Sub(g.SlashPercent)
+			keep = specOp(specOpIf, specIn(list), specParam("keep."+g.Name), keep)
+		default:
+			excluded = append(excluded, specIn(list))
+		}
+	}
+	if len(params.optOut) > 0 {
+		s.Lists["optOut"] = params.optOut
+		excluded = append(excluded, specIn("optOut"))
+	}
+	s.Formulas = []specFormula{
+		{Name: "keep", Expr: keep},
+		{Name: "excluded", Expr: specOp(specOpOr, append(excluded, specConst("0"))...)},
+		{Name: "stakedKept", Expr: specOp(specOpMul, specVar("staked"), specVar("keep"))},
+		{Name: "liquidKept", Expr: specOp(specOpMul, specVar("liquid"), specVar("keep"))},
+	}
+	var parts []string
+	for _, opt := range allVoteOptions {
+		key := voteOptionNames[opt]
+		mult, bonusMalus := params.voteMultiplier(opt, airdrop.nonVotersMultiplier)
+		s.Params["multiplier."+key] = mult
+		s.Params["bonusMalus."+key] = bonusMalus
+		s.Params["shareFactor."+key] = params.shareFactor(key)
+		s.Formulas = append(s.Formulas, specFormula{
+			Name: "amount." + key,
+			Expr: specOp(specOpMul,
+				specVar("weight."+key), specVar("stakedKept"), specParam("multiplier."+key),
+				specParam("bonusMalus."+key), specParam("shareFactor."+key), specParam("supplyFactor"),
+			),
+		})
+		parts = append(parts, "amount."+key)
+	}
+	s.Formulas = append(s.Formulas,
+		specFormula{
+			Name: "liquidMultiplier",
+			Expr: specOp(specOpMul, specParam("nonVotersMultiplier"), specParam("malus"), specParam("shareFactor.liquid")),
+		},
+		specFormula{
+			Name: "amount.liquid",
+			Expr: specOp(specOpMul, specVar("liquidKept"), specVar("liquidMultiplier"), specParam("supplyFactor")),
+		},
+	)
+	parts = append(parts, "amount.liquid")
+	var amount, capped []specExpr
+	for _, p := range parts {
+		amount = append(amount, specVar(p))
+		capped = append(capped, specOp(specOpMul, specVar(p), specVar("capRatio")))
+	}
+	s.Formulas = append(s.Formulas,
+		specFormula{Name: "amount", Expr: specOp(specOpAdd, amount...)},
+		// each part is scaled down to reach the address cap
+		specFormula{Name: "capRatio", Expr: specOp(specOpIf,
+			specOp(specOpAnd,
+				specOp(specOpGT, specParam("addressCap"), specConst("0")),
+				specOp(specOpGT, specVar("amount"), specParam("addressCap")),
+			),
+			specOp(specOpQuo, specParam("addressCap"), specVar("amount")),
+			specConst("1"),
+		)},
+		specFormula{Name: "capped", Expr: specOp(specOpAdd, capped...)},
+		specFormula{Name: "rounded", Expr: specOp(specOpRound, specVar("capped"))},
+		specFormula{Name: "allocation", Expr: specOp(specOpIf,
+			specVar("excluded"),
+			specConst("0"),
+			specOp(specOpIf,
+				specOp(specOpAnd,
+					specOp(specOpGT, specParam("voterFloor"), specConst("0")),
+					specVar("votedActive"),
+					specOp(specOpLT, specVar("rounded"), specParam("voterFloor")),
+				),
+				specParam("voterFloor"),
+				specVar("rounded"),
+			),
+		)},
+	)
+	s.Results = newSpecResults(airdrop.addresses)
+	return s, nil
+}
+
+func newSpecResults(addresses map[string]sdk.Int) specResults {
+	r := specResults{Addresses: len(addresses), Distributed: sdk.ZeroInt()}
+	h := sha256.New()
+	for _, addr := range slices.Sorted(maps.Keys(addresses)) {
+		r.Distributed = r.Distributed.Add(addresses[addr])
+		fmt.Fprintf(h, "%s %s\n", addr, addresses[addr])
+	}
+	r.Digest = hex.EncodeToString(h.Sum(nil))
+	return r
+}
+
+// accountSpecInputs returns the inputs of the formulas for acc.
+func accountSpecInputs(acc Account) map[string]sdk.Dec {
+	in := map[string]sdk.Dec{
+		"staked":      acc.StakedAmount,
+		"liquid":      acc.LiquidAmount,
+		"votedActive": sdk.ZeroDec(),
+	}
+	weights := acc.voteWeights()
+	for key, opt := range voteOptionKeys {
+		in["weight."+key] = weights[opt]
+	}
+	if acc.votedActive() {
+		in["votedActive"] = sdk.OneDec()
+	}
+	return in
+}
+
+// allocation executes the formulas of s for the account address with the
+// inputs in, and returns its allocation.
+func (s formulaSpec) allocation(address string, in map[string]sdk.Dec) (sdk.Int, error) {
+	vars := maps.Clone(in)
+	for _, f := range s.Formulas {
+		v, err := s.eval(f.Expr, address, vars)
+		if err != nil {
+			return sdk.Int{}, fmt.Errorf("formula '%s': %w", f.Name, err)
+		}
+		vars[f.Name] = v
+	}
+	if len(s.Formulas) == 0 {
+		return sdk.Int{}, errors.New("no formulas")
+	}
+	return vars[s.Formulas[len(s.Formulas)-1].Name].TruncateInt(), nil
+}
+
+func (s formulaSpec) eval(e specExpr, address string, vars map[string]sdk.Dec) (sdk.Dec, error) {
+	boolDec := func(b bool) sdk.Dec {
+		if b {
+			return sdk.OneDec()
+		}
+		return sdk.ZeroDec()
+	}
+	switch e.Op {
+	case specOpConst:
+		return sdk.NewDecFromStr(e.Value)
+	case specOpVar:
+		v, ok := vars[e.Name]
+		if !ok || v.IsNil() {
+			return sdk.Dec{}, fmt.Errorf("unknown variable '%s'", e.Name)
+		}
+		return v, nil
+	case specOpParam:
+		v, ok := s.Params[e.Name]
+		if !ok || v.IsNil() {
+			return sdk.Dec{}, fmt.Errorf("unknown param '%s'", e.Name)
+		}
+		return v, nil
+	case specOpIn:
+		list, ok := s.Lists[e.Name]
+		if !ok {
+			return sdk.Dec{}, fmt.Errorf("unknown list '%s'", e.Name)
+		}
+		return boolDec(slices.Contains(list, address)), nil
+	}
+	args := make([]sdk.Dec, len(e.Args))
+	for i, a := range e.Args {
+		v, err := s.eval(a, address, vars)
+		if err != nil {
+			return sdk.Dec{}, err
+		}
+		args[i] = v
+	}
+	arity := map[string]int{
+		specOpSub: 2, specOpQuo: 2, specOpGT: 2, specOpLT: 2, specOpRound: 1, specOpIf: 3,
+	}
+	if n, ok := arity[e.Op]; ok && len(args) != n {
+		return sdk.Dec{}, fmt.Errorf("%s takes %d args, got %d", e.Op, n, len(args))
+	}
+	switch e.Op {
+	case specOpAdd, specOpMul:
+		if len(args) == 0 {
+			return sdk.Dec{}, fmt.Errorf("%s without args", e.Op)
+		}
+		v := args[0]
+		for _, a := range args[1:] {
+			if e.Op == specOpAdd {
+				v = v.Add(a)
+			} else {
+				v = v.Mul(a)
+			}
+		}
+		return v, nil
+	case specOpSub:
+		return args[0].Sub(args[1]), nil
+	case specOpQuo:
+		if args[1].IsZero() {
+			return sdk.Dec{}, errors.New("division by zero")
+		}
+		return args[0].Quo(args[1]), nil
+	case specOpRound:
+		return sdk.NewDecFromInt(args[0].RoundInt()), nil
+	case specOpGT:
+		return boolDec(args[0].GT(args[1])), nil
+	case specOpLT:
+		return boolDec(args[0].LT(args[1])), nil
+	case specOpAnd:
+		return boolDec(!slices.ContainsFunc(args, sdk.Dec.IsZero)), nil
+	case specOpOr:
+		return boolDec(slices.ContainsFunc(args, func(d sdk.Dec) bool { return !d.IsZero() })), nil
+	case specOpIf:
+		if !args[0].IsZero() {
+			return args[1], nil
+		}
+		return args[2], nil
+	}
+	return sdk.Dec{}, fmt.Errorf("unknown operator '%s'", e.Op)
+}
+
+// checkFormulaSpec executes s for each account and returns an error if the
+// allocations differ from the addresses of the spec results.
+func checkFormulaSpec(ctx context.Context, accounts iter.Seq[Account], s formulaSpec) error {
+	addresses := make(map[string]sdk.Int)
+	for acc := range accounts {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		amt, err := s.allocation(acc.Address, accountSpecInputs(acc))
+		if err != nil {
+			return fmt.Errorf("%s: %w", acc.Address, err)
+		}
+		if amt.IsZero() {
+			continue
+		}
+		addr := acc.Address
+		if s.Prefix != "" {
+			key, err := acc.addrKey()
+			if err != nil {
+				return err
+			}
+			addr = key.bech32(s.Prefix)
+		}
+		addresses[addr] = amt
+	}
+	if r := newSpecResults(addresses); r.Digest != s.Results.Digest {
+		return fmt.Errorf("the formula spec gives %d addresses and %s uatone (digest %s), instead of %d addresses and %s uatone (digest %s)",
+			r.Addresses, r.Distributed, r.Digest, s.Results.Addresses, s.Results.Distributed, s.Results.Digest)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestFormulaSpec(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs = createAccountAddrs(8)
+		vote  = func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+			return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.OneDec()}}
+		}
+		dec = sdk.MustNewDecFromStr
+	)
+	accounts := []Account{
+		{Address: addrs[0].String(), LiquidAmount: dec("0"), StakedAmount: dec("100.5"), Vote: vote(govtypes.OptionYes)},
+		{Address: addrs[1].String(), LiquidAmount: dec("0"), StakedAmount: dec("333.333333"), Vote: govtypes.WeightedVoteOptions{
+			{Option: govtypes.OptionNo, Weight: dec("0.3")}, {Option: govtypes.OptionNoWithVeto, Weight: dec("0.7")},
+		}},
+		// inherited votes
+		{Address: addrs[2].String(), LiquidAmount: dec("7"), StakedAmount: dec("30"), Delegations: []Delegation{
+			{Amount: dec("10"), Vote: vote(govtypes.OptionAbstain)},
+			{Amount: dec("20")},
+		}},
+		{Address: addrs[3].String(), LiquidAmount: dec("1000"), StakedAmount: dec("0")},
+		// capped
+		{Address: addrs[4].String(), LiquidAmount: dec("0"), StakedAmount: dec("100000000"), Vote: vote(govtypes.OptionNo)},
+		// partial-slash, slash and opt-out
+		{Address: addrs[5].String(), LiquidAmount: dec("50"), StakedAmount: dec("50"), Vote: vote(govtypes.OptionNo)},
+		{Address: addrs[6].String(), LiquidAmount: dec("50"), StakedAmount: dec("50"), Vote: vote(govtypes.OptionNo)},
+		{Address: addrs[7].String(), LiquidAmount: dec("50"), StakedAmount: dec("50"), Vote: vote(govtypes.OptionNo)},
+	}
+	params := defaultDistriParams()
+	params.entityGroups = []entityGroup{
+		{Name: "a", Policy: entityPolicyPartialSlash, SlashPercent: dec("0.3"), Addresses: []string{addrs[5].String()}},
+		{Name: "b", Policy: entityPolicySlash, Addresses: []string{addrs[6].String()}},
+	}
+	params.optOut = []string{addrs[7].String()}
+	params.optOutPolicy = optOutPolicyBurn
+	params.addressCap = sdk.NewInt(50_000_000)
+	// the yes voter gets the floor
+	params.voterFloor = sdk.NewInt(20)
+	params.voterFloorPool = voterFloorPoolCommunityPool
+	airdrop, err := distribution(accounts, params, "atone")
+	require.NoError(err)
+
+	spec, err := newFormulaSpec(airdrop, "atone")
+
+	require.NoError(err)
+	assert.Equal(len(airdrop.addresses), spec.Results.Addresses)
+	assert.Equal(1, airdrop.addressCap.accounts)
+	assert.Equal(1, airdrop.voterFloor.accounts)
+	require.NoError(checkFormulaSpec(context.Background(), slices.Values(accounts), spec))
+	// the JSON spec gives the same allocations
+	bz, err := json.Marshal(spec)
+	require.NoError(err)
+	var decoded formulaSpec
+	require.NoError(json.Unmarshal(bz, &decoded))
+	require.NoError(checkFormulaSpec(context.Background(), slices.Values(accounts), decoded))
+
+	decoded.Params["supplyFactor"] = dec("0.2")
+	err = checkFormulaSpec(context.Background(), slices.Values(accounts), decoded)
+	assert.ErrorContains(err, "the formula spec gives")
+
+	airdrop.params.typeMultipliers = typeMultipliers{"ModuleAccount": dec("0")}
+	_, err = newFormulaSpec(airdrop, "atone")
+	assert.ErrorContains(err, "account type multipliers")
+}
+
+func TestFormulaSpecEval(t *testing.T) {
+	dec := sdk.MustNewDecFromStr
+	s := formulaSpec{
+		Params: map[string]sdk.Dec{"p": dec("3")},
+		Lists:  map[string][]string{"l": {"a"}},
+	}
+	vars := map[string]sdk.Dec{"x": dec("2.5")}
+	tests := []struct {
+		name          string
+		expr          specExpr
+		expectedValue string
+		expectedError string
+	}{
+		{
+			name:          "mul",
+			expr:          specOp(specOpMul, specVar("x"), specParam("p"), specConst("2")),
+			expectedValue: "15.000000000000000000",
+		},
+		{
+			name:          "quo rounded half-even",
+			expr:          specOp(specOpQuo, specConst("1"), specParam("p")),
+			expectedValue: "0.333333333333333333",
+		},
+		{
+			name:          "round half-even",
+			expr:          specOp(specOpRound, specVar("x")),
+			expectedValue: "2.000000000000000000",
+		},
+		{
+			name:          "if in",
+			expr:          specOp(specOpIf, specIn("l"), specConst("1"), specConst("2")),
+			expectedValue: "1.000000000000000000",
+		},
+		{
+			name:          "and",
+			expr:          specOp(specOpAnd, specOp(specOpGT, specVar("x"), specConst("1")), specOp(specOpLT, specVar("x"), specConst("2"))),
+			expectedValue: "0.000000000000000000",
+		},
+		{
+			name:          "unknown variable",
+			expr:          specVar("y"),
+			expectedError: "unknown variable 'y'",
+		},
+		{
+			name:          "wrong arity",
+			expr:          specOp(specOpSub, specVar("x")),
+			expectedError: "sub takes 2 args, got 1",
+		},
+		{
+			name:          "unknown operator",
+			expr:          specOp("pow", specVar("x")),
+			expectedError: "unknown operator 'pow'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := s.eval(tt.expr, "a", vars)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedValue, v.String())
+		})
+	}
+}
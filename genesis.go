@@ -1,14 +1,12 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"maps"
 	"net/http"
 	"os"
-	"slices"
-	"strings"
 
 	tmjson "github.com/cometbft/cometbft/libs/json"
 	tmtypes "github.com/cometbft/cometbft/types"
@@ -20,19 +18,31 @@ import (
 	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 )
 
+// reservedAddrBz is the reserved address that receives half of the minted
+// $ATONE.
+// hex:    0x000000000000000000000000000000000000bda0
+// bech32: atone1qqqqqqqqqqqqqqqqqqqqqqqqqqqqp0dqtalx52
+var reservedAddrBz = []byte("\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xbd\xa0")
+
 const constitutionLink = "https://raw.githubusercontent.com/atomone-hub/genesis/af652e0bc2bf1579350648770bf1f7b2d51d4884/CONSTITUTION.md"
 
-// writeGenesis reads airdrop and fills the related modules accordingly in the
-// genesisFile.
+// writeGenesis reads the airdrops of denoms and fills the related modules
+// accordingly in the genesisFile. The first denom is the staking token
+// ($ATONE), the others are additional tokens distributed with their own rules
+// from the same accounts. The addresses use the bech32 prefix. If gentxDir is
+// not empty, the validators created by the gentxs of this directory are added
+// to the staking genesis (see bootstrapValidators). The chain id, genesis time
+// and initial height of tmpl replace the ones of genesisFile.
 //
 // Note about JSON encoding: the genesisDoc, the appState and the modules
 // genesis use different encoding primitives (it would too simple otherwise!):
 // - genesisDoc uses tmjson "github.com/cometbft/cometbft/libs/json"
 // - appState uses standard "encoding/json"
 // - modules genesis use protoJSON (represented as cdc)
-func writeGenesis(genesisFile string, airdrop airdrop) error {
+func writeGenesis(ctx context.Context, genesisFile string, denoms []genesisDenom, prefix, gentxDir string, tmpl genesisTemplate) error {
 	bz, err := os.ReadFile(genesisFile)
 	if err != nil {
 		return fmt.Errorf("readfile %s: %w", genesisFile, err)
@@ -41,6 +51,7 @@ func writeGenesis(genesisFile string, airdrop airdrop) error {
 	if err := tmjson.Unmarshal(bz, &genesisState); err != nil {
 		return fmt.Errorf("unmarshal genesis doc: %w", err)
 	}
+	tmpl.apply(&genesisState)
 	var appState map[string]json.RawMessage
 	if err := json.Unmarshal(genesisState.AppState, &appState); err != nil {
 		return fmt.Errorf("unmarshal appstate: %w", err)
@@ -61,100 +72,86 @@ func writeGenesis(genesisFile string, airdrop airdrop) error {
 	if err := cdc.UnmarshalJSON(appState["gov"], &govGen); err != nil {
 		return fmt.Errorf("umarshal gov genesis: %w", err)
 	}
+	var stakingGen stakingtypes.GenesisState
+	if err := cdc.UnmarshalJSON(appState["staking"], &stakingGen); err != nil {
+		return fmt.Errorf("umarshal staking genesis: %w", err)
+	}
 
 	// Reset supply, balances and accounts
 	bankGen.Supply = sdk.NewCoins()
 	bankGen.Balances = nil
 	authGen.Accounts = nil
-	// Add airdrop.addresses to balances and accounts
-	const ticker = "atone"
-	for _, addr := range slices.Sorted(maps.Keys(airdrop.addresses)) {
+	// Add the airdrops, the reserved address and the module accounts to
+	// balances, and all but the distribution module account to accounts. The
+	// staking incentives module account is unknown to the chain, so it's
+	// added as a module account.
+	balances, communityPoolCoins := genesisBalances(denoms, prefix)
+	var (
+		distrModuleAddr      = sdk.MustBech32ifyAddressBytes(prefix, authtypes.NewModuleAddress(distrtypes.ModuleName))
+		incentivesModuleAddr = stakingIncentivesModuleAddr(prefix)
+	)
+	for _, b := range balances {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		// update bank genesis
-		amt := airdrop.addresses[addr]
-		coins := sdk.NewCoins(sdk.NewCoin("u"+ticker, amt))
-		bankGen.Balances = append(bankGen.Balances, banktypes.Balance{
-			Address: addr,
-			Coins:   coins,
-		})
-		bankGen.Supply = bankGen.Supply.Add(coins...)
-
+		bankGen.Balances = append(bankGen.Balances, b)
+		bankGen.Supply = bankGen.Supply.Add(b.Coins...)
+		if b.Address == distrModuleAddr {
+			continue
+		}
 		// update auth genesis
-		acc := &authtypes.BaseAccount{Address: addr}
+		var acc authtypes.GenesisAccount = &authtypes.BaseAccount{Address: b.Address}
+		if b.Address == incentivesModuleAddr {
+			acc = &authtypes.ModuleAccount{
+				BaseAccount: &authtypes.BaseAccount{Address: b.Address},
+				Name:        stakingIncentivesModuleName,
+			}
+		}
 		any, err := codectypes.NewAnyWithValue(acc)
 		if err != nil {
 			return fmt.Errorf("newAny from base account: %w", err)
 		}
 		authGen.Accounts = append(authGen.Accounts, any)
 	}
-	// Add reserved address
-	// hex:    0x000000000000000000000000000000000000bda0
-	// bech32: atone1qqqqqqqqqqqqqqqqqqqqqqqqqqqqp0dqtalx52
-	reservedAddrBz := []byte("\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xbd\xa0")
-	reservedAddrCoins := sdk.NewCoins(sdk.NewCoin("u"+ticker, airdrop.reservedAddr.RoundInt()))
-	reservedAddr := sdk.MustBech32ifyAddressBytes("atone", reservedAddrBz)
-	bankGen.Balances = append(bankGen.Balances, banktypes.Balance{
-		Address: reservedAddr,
-		Coins:   reservedAddrCoins,
-	})
-	bankGen.Supply = bankGen.Supply.Add(reservedAddrCoins...)
-	// add auth reserved address
-	reservedAcc := &authtypes.BaseAccount{Address: reservedAddr}
-	any, err := codectypes.NewAnyWithValue(reservedAcc)
-	if err != nil {
-		return fmt.Errorf("newAny from base account: %w", err)
-	}
-	authGen.Accounts = append(authGen.Accounts, any)
 
 	// setup community pool
-	communityPoolCoins := sdk.NewCoins(sdk.NewCoin("u"+ticker, airdrop.communityPool.RoundInt()))
 	distrGen.FeePool = distrtypes.FeePool{
 		CommunityPool: sdk.NewDecCoinsFromCoins(communityPoolCoins...),
 	}
-	// same amount must be distributed to the distribution module account
-	distrModuleAddr := sdk.MustBech32ifyAddressBytes("atone", authtypes.NewModuleAddress(distrtypes.ModuleName))
-	bankGen.Balances = append(bankGen.Balances, banktypes.Balance{
-		Address: distrModuleAddr,
-		Coins:   communityPoolCoins,
-	})
-	bankGen.Supply = bankGen.Supply.Add(communityPoolCoins...)
 
 	// setup bank params and denoms
 	bankGen.Params = banktypes.Params{
 		DefaultSendEnabled: true,
 		SendEnabled:        []*banktypes.SendEnabled{},
 	}
-	bankGen.DenomMetadata = []banktypes.Metadata{
-		{
-			Display:     ticker,
-			Symbol:      strings.ToUpper(ticker),
-			Base:        "u" + ticker,
-			Name:        "AtomOne Atone",
-			Description: "The native staking token of AtomOne Hub",
-			DenomUnits: []*banktypes.DenomUnit{
-				{
-					Aliases:  []string{"micro" + ticker},
-					Denom:    "u" + ticker,
-					Exponent: 0,
-				},
-				{
-					Aliases:  []string{"milli" + ticker},
-					Denom:    "m" + ticker,
-					Exponent: 3,
-				},
-				{
-					Aliases:  []string{ticker},
-					Denom:    ticker,
-					Exponent: 6,
-				},
-			},
-		},
+	bankGen.DenomMetadata = nil
+	for _, d := range denoms {
+		bankGen.DenomMetadata = append(bankGen.DenomMetadata, d.metadata())
+	}
+
+	// setup staking bond denom and bootstrap validators
+	stakingGen.Params.BondDenom = denoms[0].base()
+	if gentxDir != "" {
+		msgs, err := loadGentxs(gentxDir)
+		if err != nil {
+			return err
+		}
+		if err := bootstrapValidators(msgs, &bankGen, &stakingGen, genesisState.GenesisTime, prefix); err != nil {
+			return err
+		}
 	}
 
 	// Update constitution
-	resp, err := http.Get(constitutionLink)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, constitutionLink, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 	bz, err = io.ReadAll(resp.Body)
 	if err != nil {
 		return err
@@ -163,10 +160,6 @@ func writeGenesis(genesisFile string, airdrop airdrop) error {
 
 	//-----------------------------------------
 	// Update the  genesis
-	appState["bank"], err = cdc.MarshalJSON(&bankGen)
-	if err != nil {
-		return fmt.Errorf("marshal bank genesis: %w", err)
-	}
 	appState["distribution"], err = cdc.MarshalJSON(&distrGen)
 	if err != nil {
 		return fmt.Errorf("marshal distribution genesis: %w", err)
@@ -175,18 +168,18 @@ func writeGenesis(genesisFile string, airdrop airdrop) error {
 	if err != nil {
 		return fmt.Errorf("marshal gov genesis: %w", err)
 	}
-	appState["auth"], err = cdc.MarshalJSON(&authGen)
+	appState["staking"], err = cdc.MarshalJSON(&stakingGen)
 	if err != nil {
-		return fmt.Errorf("marshal auth genesis: %w", err)
+		return fmt.Errorf("marshal staking genesis: %w", err)
 	}
-	genesisState.AppState, err = json.MarshalIndent(appState, "", "  ")
+	appState["auth"], err = cdc.MarshalJSON(&authGen)
 	if err != nil {
-		return err
+		return fmt.Errorf("marshal auth genesis: %w", err)
 	}
-	bz, err = tmjson.MarshalIndent(genesisState, "", "  ")
-	if err != nil {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
-	fmt.Println(string(bz))
-	return nil
+	// The bank genesis is streamed to limit the memory usage, because of the
+	// numerous balances.
+	return streamGenesis(os.Stdout, genesisState, appState, bankGen)
 }
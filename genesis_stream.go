@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	tmjson "github.com/cometbft/cometbft/libs/json"
+	tmtypes "github.com/cometbft/cometbft/types"
+
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// bankGenesisPlaceholder temporarily replaces the bank genesis in the app
+// state, so the rest of the genesis can be marshaled before the balances are
+// streamed in place of it.
+const bankGenesisPlaceholder = `"__GOVBOX_BANK_GENESIS__"`
+
+// streamGenesis writes genesisDoc into w, with appState as app state. Unlike
+// a plain MarshalIndent of the whole genesis, the bank balances are not
+// marshaled in memory all at once: the bank genesis metadata (params,
+// supply...) are written first, then balances are streamed one by one.
+func streamGenesis(w io.Writer, genesisDoc tmtypes.GenesisDoc, appState map[string]json.RawMessage, bankGen banktypes.GenesisState) error {
+	balances := bankGen.Balances
+	bankGen.Balances = nil
+	bankMeta, err := cdc.MarshalJSON(&bankGen)
+	if err != nil {
+		return fmt.Errorf("marshal bank genesis: %w", err)
+	}
+	appState["bank"] = json.RawMessage(bankGenesisPlaceholder)
+	genesisDoc.AppState, err = json.MarshalIndent(appState, "", "  ")
+	if err != nil {
+		return err
+	}
+	bz, err := tmjson.MarshalIndent(genesisDoc, "", "  ")
+	if err != nil {
+		return err
+	}
+	genesisDoc.AppState = nil
+	idx := bytes.Index(bz, []byte(bankGenesisPlaceholder))
+	if idx == -1 {
+		return errors.New("bank genesis placeholder not found")
+	}
+	// Use the placeholder line indentation as prefix of the bank genesis
+	indent := string(bz[bytes.LastIndexByte(bz[:idx], '\n')+1 : idx])
+	if i := bytes.IndexByte([]byte(indent), '"'); i >= 0 {
+		indent = indent[:i]
+	}
+	bw := bufio.NewWriter(w)
+	bw.Write(bz[:idx])
+	if err := writeBankGenesis(bw, indent, bankMeta, balances); err != nil {
+		return err
+	}
+	bw.Write(bz[idx+len(bankGenesisPlaceholder):])
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+// writeBankGenesis writes the fields of bankMeta, followed by the streamed
+// balances, as an indented JSON object.
+func writeBankGenesis(w *bufio.Writer, indent string, bankMeta []byte, balances []banktypes.Balance) error {
+	fieldIndent := indent + "  "
+	writeField := func(key string, value []byte) error {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, value, fieldIndent, "  "); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s%q: %s,\n", fieldIndent, key, buf.Bytes())
+		return nil
+	}
+	w.WriteString("{\n")
+	dec := json.NewDecoder(bytes.NewReader(bankMeta))
+	if _, err := dec.Token(); err != nil { // skip '{'
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		if key == "balances" {
+			continue
+		}
+		if err := writeField(key.(string), value); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "%s\"balances\": [", fieldIndent)
+	balanceIndent := fieldIndent + "  "
+	for i := range balances {
+		bz, err := cdc.MarshalJSON(&balances[i])
+		if err != nil {
+			return fmt.Errorf("marshal balance %s: %w", balances[i].Address, err)
+		}
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, bz, balanceIndent, "  "); err != nil {
+			return err
+		}
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		fmt.Fprintf(w, "\n%s%s", balanceIndent, buf.Bytes())
+	}
+	if len(balances) > 0 {
+		fmt.Fprintf(w, "\n%s", fieldIndent)
+	}
+	fmt.Fprintf(w, "]\n%s}", indent)
+	return nil
+}
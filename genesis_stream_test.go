@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	tmjson "github.com/cometbft/cometbft/libs/json"
+	tmtypes "github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+func TestStreamGenesis(t *testing.T) {
+	require := require.New(t)
+	bankGen := banktypes.GenesisState{
+		Params: banktypes.DefaultParams(),
+		Balances: []banktypes.Balance{
+			{Address: "atone1a", Coins: sdk.NewCoins(sdk.NewInt64Coin("uatone", 1))},
+			{Address: "atone1b", Coins: sdk.NewCoins(sdk.NewInt64Coin("uatone", 2))},
+		},
+		Supply: sdk.NewCoins(sdk.NewInt64Coin("uatone", 3)),
+	}
+	genesisDoc := tmtypes.GenesisDoc{ChainID: "atomone-1"}
+	appState := map[string]json.RawMessage{
+		"gov": json.RawMessage(`{"constitution":"x"}`),
+	}
+	var buf bytes.Buffer
+
+	err := streamGenesis(&buf, genesisDoc, appState, bankGen)
+
+	require.NoError(err)
+	// Compare with the non-streamed output
+	expectedBank, err := cdc.MarshalJSON(&bankGen)
+	require.NoError(err)
+	var res tmtypes.GenesisDoc
+	require.NoError(tmjson.Unmarshal(buf.Bytes(), &res))
+	assert.Equal(t, "atomone-1", res.ChainID)
+	var resAppState map[string]json.RawMessage
+	require.NoError(json.Unmarshal(res.AppState, &resAppState))
+	assert.JSONEq(t, `{"constitution":"x"}`, string(resAppState["gov"]))
+	assert.JSONEq(t, string(expectedBank), string(resAppState["bank"]))
+
+	t.Run("no balances", func(t *testing.T) {
+		bankGen.Balances = nil
+		buf.Reset()
+
+		err := streamGenesis(&buf, genesisDoc, appState, bankGen)
+
+		require.NoError(err)
+		assert.True(t, json.Valid(buf.Bytes()))
+	})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	tmtypes "github.com/cometbft/cometbft/types"
+
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+)
+
+// chainNamesByPrefix maps the bech32 prefixes to the name of their chain,
+// which prefixes the chain ids, when the chain isn't read from the
+// chain-registry.
+var chainNamesByPrefix = map[string]string{
+	"atone":  "atomone",
+	"cosmos": "cosmoshub",
+	"govgen": "govgen",
+}
+
+// genesisTemplate holds the fields of the genesis doc that are templated
+// instead of being copied from the input genesis. The zero values keep the
+// input genesis values.
+type genesisTemplate struct {
+	ChainID       string    `json:"chain_id"`
+	GenesisTime   time.Time `json:"genesis_time"`
+	InitialHeight int64     `json:"initial_height"`
+}
+
+// parseGenesisTemplate reads a genesis template from a JSON file like
+// {"chain_id":"atomone-1","genesis_time":"2024-07-01T15:00:00Z","initial_height":1}.
+func parseGenesisTemplate(path string) (_ genesisTemplate, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return genesisTemplate{}, err
+	}
+	defer closeInput(f, &err)
+	var t genesisTemplate
+	if err := json.NewDecoder(f).Decode(&t); err != nil {
+		return genesisTemplate{}, fmt.Errorf("cannot json decode genesis template from file %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// validate checks that the chain id of the template is a valid revision
+// chain id of chainName (e.g. atomone-1 for atomone), so the genesis matches
+// its bech32 prefix.
+func (t genesisTemplate) validate(chainName string) error {
+	if t.InitialHeight < 0 {
+		return fmt.Errorf("initial height %d can't be negative", t.InitialHeight)
+	}
+	if t.ChainID == "" {
+		return nil
+	}
+	if len(t.ChainID) > tmtypes.MaxChainIDLen {
+		return fmt.Errorf("chain id '%s' is longer than %d characters", t.ChainID, tmtypes.MaxChainIDLen)
+	}
+	if !clienttypes.IsRevisionFormat(t.ChainID) {
+		return fmt.Errorf("chain id '%s' doesn't end with a revision number (e.g. %s-1)", t.ChainID, chainName)
+	}
+	if !strings.HasPrefix(t.ChainID, chainName+"-") {
+		return fmt.Errorf("chain id '%s' doesn't match the chain %s of the bech32 prefix", t.ChainID, chainName)
+	}
+	return nil
+}
+
+// apply sets the templated fields in doc.
+func (t genesisTemplate) apply(doc *tmtypes.GenesisDoc) {
+	if t.ChainID != "" {
+		doc.ChainID = t.ChainID
+	}
+	if !t.GenesisTime.IsZero() {
+		doc.GenesisTime = t.GenesisTime.UTC()
+	}
+	if t.InitialHeight != 0 {
+		doc.InitialHeight = t.InitialHeight
+	}
+}
+
+// templateChainName returns the chain name expected in the chain id of a
+// genesis with prefix, or an error if prefix has no known chain.
+func templateChainName(prefix string, chainCfg *chainConfig) (string, error) {
+	if chainCfg != nil {
+		return chainCfg.name, nil
+	}
+	name, ok := chainNamesByPrefix[prefix]
+	if !ok {
+		return "", fmt.Errorf("no chain known for the bech32 prefix '%s', use -chain", prefix)
+	}
+	return name, nil
+}
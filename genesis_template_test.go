@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tmtypes "github.com/cometbft/cometbft/types"
+)
+
+func TestGenesisTemplateValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		tmpl          genesisTemplate
+		chainName     string
+		expectedError string
+	}{
+		{
+			name:      "ok",
+			tmpl:      genesisTemplate{ChainID: "atomone-1", InitialHeight: 1},
+			chainName: "atomone",
+		},
+		{
+			name:      "ok testnet",
+			tmpl:      genesisTemplate{ChainID: "atomone-testnet-1"},
+			chainName: "atomone",
+		},
+		{
+			name: "no chain id",
+			tmpl: genesisTemplate{InitialHeight: 42},
+		},
+		{
+			name:          "other chain",
+			tmpl:          genesisTemplate{ChainID: "cosmoshub-4"},
+			chainName:     "atomone",
+			expectedError: "doesn't match the chain atomone",
+		},
+		{
+			name:          "no revision",
+			tmpl:          genesisTemplate{ChainID: "atomone"},
+			chainName:     "atomone",
+			expectedError: "doesn't end with a revision number",
+		},
+		{
+			name:          "negative height",
+			tmpl:          genesisTemplate{ChainID: "atomone-1", InitialHeight: -1},
+			chainName:     "atomone",
+			expectedError: "can't be negative",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tmpl.validate(tt.chainName)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGenesisTemplateApply(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	file := filepath.Join(t.TempDir(), "genesis_config.json")
+	require.NoError(os.WriteFile(file, []byte(`{"chain_id":"atomone-1","genesis_time":"2024-07-01T17:00:00+02:00"}`), 0o600))
+	tmpl, err := parseGenesisTemplate(file)
+	require.NoError(err)
+	doc := tmtypes.GenesisDoc{ChainID: "cosmoshub-4", InitialHeight: 18010658}
+
+	tmpl.apply(&doc)
+
+	assert.Equal("atomone-1", doc.ChainID)
+	assert.Equal(time.Date(2024, 7, 1, 15, 0, 0, 0, time.UTC), doc.GenesisTime)
+	// not templated
+	assert.EqualValues(18010658, doc.InitialHeight)
+
+	name, err := templateChainName("atone", nil)
+	require.NoError(err)
+	assert.Equal("atomone", name)
+	name, err = templateChainName("atone", &chainConfig{name: "atomonetestnet", prefix: "atone"})
+	require.NoError(err)
+	assert.Equal("atomonetestnet", name)
+	_, err = templateChainName("unknown", nil)
+	assert.ErrorContains(err, "use -chain")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// parseGovernorDelegations reads the governance delegations from a JSON file,
+// which is expected to be an object mapping delegator addresses to governor
+// addresses.
+func parseGovernorDelegations(path string) (_ map[string]string, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var govByDelegator map[string]string
+	if err := json.NewDecoder(f).Decode(&govByDelegator); err != nil {
+		return nil, fmt.Errorf("cannot json decode governor delegations from file %s: %w", path, err)
+	}
+	return govByDelegator, nil
+}
+
+// governorAccAddr returns the account address of governor, using the bech32
+// prefix of delegator, so it can be matched against the voters addresses.
+func governorAccAddr(governor, delegator string) (string, error) {
+	hrp, _, err := bech32.DecodeAndConvert(delegator)
+	if err != nil {
+		return "", fmt.Errorf("delegator %s: %w", delegator, err)
+	}
+	_, bz, err := bech32.DecodeAndConvert(governor)
+	if err != nil {
+		return "", fmt.Errorf("governor %s: %w", governor, err)
+	}
+	return bech32.ConvertAndEncode(hrp, bz)
+}
+
+// applyGovernorDelegations replaces the vote inheritance of accounts: instead
+// of inheriting the votes of their validators, the non-voters inherit the vote
+// of their governor, for their whole staked amount, as in the AtomOne
+// governance. The governor address is stored in the ValidatorAddress of a
+// single Delegation. Accounts without governor don't inherit any vote, their
+// validator delegations are kept without vote.
+// It returns the number of accounts delegating to a governor.
+func applyGovernorDelegations(accounts []Account, govByDelegator map[string]string,
+	votesByAddr map[string]govtypes.WeightedVoteOptions,
+) (int, error) {
+	var delegating int
+	for i, acc := range accounts {
+		governor, ok := govByDelegator[acc.Address]
+		if !ok || acc.StakedAmount.IsZero() {
+			// Keep the validator delegations, so the staked amount is still
+			// accounted as a non-vote, but without the validators votes.
+			for j := range acc.Delegations {
+				acc.Delegations[j].Vote = nil
+			}
+			continue
+		}
+		govAddr, err := governorAccAddr(governor, acc.Address)
+		if err != nil {
+			return 0, err
+		}
+		accounts[i].Delegations = []Delegation{{
+			Amount:           acc.StakedAmount,
+			ValidatorAddress: governor,
+			Vote:             votesByAddr[govAddr],
+		}}
+		delegating++
+	}
+	return delegating, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestApplyGovernorDelegations(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		govAccAddr = sdk.AccAddress("governor____________").String()
+		govAddr, _ = bech32.ConvertAndEncode("atonegov", sdk.AccAddress("governor____________"))
+		del1       = sdk.AccAddress("del1________________").String()
+		del2       = sdk.AccAddress("del2________________").String()
+		del3       = sdk.AccAddress("del3________________").String()
+		valVote    = govtypes.NewNonSplitVoteOption(govtypes.OptionYes)
+		govVote    = govtypes.NewNonSplitVoteOption(govtypes.OptionNo)
+		valDeleg   = []Delegation{{Amount: sdk.NewDec(10), ValidatorAddress: "val", Vote: valVote}}
+	)
+	accounts := []Account{
+		{Address: del1, StakedAmount: sdk.NewDec(10), Delegations: valDeleg},
+		{Address: del2, StakedAmount: sdk.NewDec(10), Delegations: valDeleg},
+		{Address: del3, StakedAmount: sdk.NewDec(10), Delegations: valDeleg, Vote: valVote},
+	}
+	govByDelegator := map[string]string{del1: govAddr, del3: govAddr}
+	votesByAddr := map[string]govtypes.WeightedVoteOptions{govAccAddr: govVote, del3: valVote}
+
+	delegating, err := applyGovernorDelegations(accounts, govByDelegator, votesByAddr)
+
+	require.NoError(err)
+	assert.Equal(2, delegating)
+	// del1 inherits the governor vote
+	assert.Equal(sdk.OneDec(), accounts[0].voteWeights()[govtypes.OptionNo])
+	// del2 has no governor and doesn't inherit the validator vote
+	assert.Nil(accounts[1].Delegations[0].Vote)
+	assert.Equal(sdk.OneDec(), accounts[1].voteWeights()[govtypes.OptionEmpty])
+	// del3 direct vote takes precedence
+	assert.Equal(sdk.OneDec(), accounts[2].voteWeights()[govtypes.OptionYes])
+
+	_, err = applyGovernorDelegations(accounts, map[string]string{del1: "invalid"}, votesByAddr)
+	assert.ErrorContains(err, "governor invalid")
+}
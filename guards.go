@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// namedDec is a value checked by checkDecs, with the name reported in the
+// error.
+type namedDec struct {
+	name  string
+	value sdk.Dec
+}
+
+// decGuardError reports a value of the distribution math that is unset or
+// negative, with the account and the step that produced it.
+type decGuardError struct {
+	// account is empty for the steps that aren't specific to an account.
+	account string
+	step    string
+	name    string
+	value   sdk.Dec
+}
+
+func (e decGuardError) Error() string {
+	var v string
+	if e.value.IsNil() {
+		v = "unset"
+	} else {
+		v = "negative (" + e.value.String() + ")"
+	}
+	if e.account == "" {
+		return fmt.Sprintf("%s: %s is %s", e.step, e.name, v)
+	}
+	return fmt.Sprintf("account %s: %s: %s is %s", e.account, e.step, e.name, v)
+}
+
+// checkDecs returns a decGuardError for the first of values which is nil or
+// negative. A sdk.Dec can't be NaN or infinite, so they are otherwise valid.
+func checkDecs(account, step string, values ...namedDec) error {
+	for _, v := range values {
+		if v.value.IsNil() || v.value.IsNegative() {
+			return decGuardError{account: account, step: step, name: v.name, value: v.value}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// holderBucket groups the accounts by their $ATOM holding (liquid+staked).
+type holderBucket struct {
+	label string
+	// max is the exclusive upper bound in $ATOM, zero means unbounded.
+	max int64
+}
+
+var holderBuckets = []holderBucket{
+	{label: "<1", max: 1},
+	{label: "1-10", max: 10},
+	{label: "10-100", max: 100},
+	{label: "100-1k", max: 1_000},
+	{label: "1k-10k", max: 10_000},
+	{label: ">10k"},
+}
+
+// holderBucketIndex returns the index in holderBuckets of an uatom amount.
+func holderBucketIndex(uatom sdk.Dec) int {
+	for i, b := range holderBuckets {
+		if b.max != 0 && uatom.LT(sdk.NewDec(b.max*M)) {
+			return i
+		}
+	}
+	return len(holderBuckets) - 1
+}
+
+// holderBucketShares returns the share of the $ATOM and of the $ATONE held by
+// each holder bucket, the bucket of an account being determined by its $ATOM
+// amount.
+func holderBucketShares(airdrop airdrop) (atom, atone []sdk.Dec) {
+	var (
+		atomAmts   = make([]sdk.Dec, len(holderBuckets))
+		atoneAmts  = make([]sdk.Dec, len(holderBuckets))
+		totalAtom  = sdk.ZeroDec()
+		totalAtone = sdk.ZeroDec()
+	)
+	for i := range holderBuckets {
+		atomAmts[i] = sdk.ZeroDec()
+		atoneAmts[i] = sdk.ZeroDec()
+	}
+	for _, e := range airdrop.audit {
+		var (
+			atomAmt  = e.LiquidAmount.Add(e.lockedAmount()).Add(e.StakedAmount)
+			atoneAmt = e.FinalAmount.ToLegacyDec()
+			i        = holderBucketIndex(atomAmt)
+		)
+		atomAmts[i] = atomAmts[i].Add(atomAmt)
+		atoneAmts[i] = atoneAmts[i].Add(atoneAmt)
+		totalAtom = totalAtom.Add(atomAmt)
+		totalAtone = totalAtone.Add(atoneAmt)
+	}
+	share := func(amts []sdk.Dec, total sdk.Dec) []sdk.Dec {
+		for i := range amts {
+			if !total.IsZero() {
+				amts[i] = amts[i].Quo(total)
+			}
+		}
+		return amts
+	}
+	return share(atomAmts, totalAtom), share(atoneAmts, totalAtone)
+}
+
+// reportHolderBuckets adds the table of the $ATOM and $ATONE shares of each
+// holder bucket to sec.
+func reportHolderBuckets(sec *reportSection, airdrops []airdrop) {
+	if airdrops[0].streamed {
+		sec.text("Share per holder size: not available, the results of the accounts were streamed")
+		return
+	}
+	var (
+		headers   = []string{"HOLDING ($ATOM)", "$ATOM SHARE"}
+		atom, _   = holderBucketShares(airdrops[0])
+		atoneCols [][]sdk.Dec
+	)
+	for _, airdrop := range airdrops {
+		_, atone := holderBucketShares(airdrop)
+		atoneCols = append(atoneCols, atone)
+		if len(airdrops) > 1 {
+			headers = append(headers, "$ATONE SHARE "+airdrop.params.String())
+		} else {
+			headers = append(headers, "$ATONE SHARE")
+		}
+	}
+	sec.text("Share per holder size")
+	table := sec.table(headers...)
+	for i, b := range holderBuckets {
+		row := []string{b.label, humanPercent(atom[i])}
+		for _, atone := range atoneCols {
+			row = append(row, humanPercent(atone[i]))
+		}
+		table.Append(row)
+	}
+}
+
+// newHolderBucketsChart returns a stacked bar chart comparing the $ATOM and
+// the $ATONE shares of each holder bucket, for each airdrop.
+func newHolderBucketsChart(airdrops []airdrop, theme chartTheme) *charts.Bar {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(append([]charts.GlobalOpts{
+		charts.WithTitleOpts(opts.Title{Title: "Share per holder size ($ATOM)"}),
+		charts.WithLegendOpts(opts.Legend{Show: true, Right: "right", Orient: "vertical"}),
+		charts.WithTooltipOpts(opts.Tooltip{
+			Show:      true,
+			Formatter: opts.FuncOpts("function(params){ return params.seriesName+': '+params.value.toFixed(2)+'%'}"),
+		}),
+	}, theme.globalOpts()...)...)
+	var (
+		xAxis      = []string{"$ATOM"}
+		shares     [][]sdk.Dec
+		oneHundred = sdk.NewDec(100)
+	)
+	atomShares, _ := holderBucketShares(airdrops[0])
+	shares = append(shares, atomShares)
+	for _, airdrop := range airdrops {
+		_, atoneShares := holderBucketShares(airdrop)
+		xAxis = append(xAxis, "$ATONE "+airdrop.params.String())
+		shares = append(shares, atoneShares)
+	}
+	bar.SetXAxis(xAxis)
+	for i, b := range holderBuckets {
+		data := make([]opts.BarData, len(shares))
+		for j := range shares {
+			data[j] = opts.BarData{Value: shares[j][i].Mul(oneHundred).MustFloat64()}
+		}
+		bar.AddSeries(b.label, data, charts.WithBarChartOpts(opts.BarChart{Stack: "share"}))
+	}
+	return bar
+}
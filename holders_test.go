@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestHolderBucketShares(t *testing.T) {
+	entry := func(uatom, uatone int64) auditEntry {
+		return auditEntry{
+			LiquidAmount: sdk.NewDec(uatom),
+			StakedAmount: sdk.ZeroDec(),
+			FinalAmount:  sdk.NewInt(uatone),
+		}
+	}
+	airdrop := airdrop{
+		audit: []auditEntry{
+			entry(500_000, 100),          // <1
+			entry(500_000, 100),          // <1
+			entry(1_000_000, 0),          // 1-10
+			entry(99_998_000_000, 1_800), // >10k
+		},
+	}
+
+	// the locked amount counts in the $ATOM of the account
+	liquid, locked := sdk.NewDec(400_000), sdk.NewDec(600_000)
+	airdrop.audit[2].LiquidAmount, airdrop.audit[2].LockedAmount = liquid, &locked
+
+	atom, atone := holderBucketShares(airdrop)
+
+	assert.Equal(t, []sdk.Dec{
+		sdk.NewDecWithPrec(1, 5), sdk.NewDecWithPrec(1, 5), sdk.ZeroDec(),
+		sdk.ZeroDec(), sdk.ZeroDec(), sdk.NewDecWithPrec(99998, 5),
+	}, atom)
+	assert.Equal(t, []sdk.Dec{
+		sdk.NewDecWithPrec(1, 1), sdk.ZeroDec(), sdk.ZeroDec(),
+		sdk.ZeroDec(), sdk.ZeroDec(), sdk.NewDecWithPrec(9, 1),
+	}, atone)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const policyHook = "hook"
+
+// accountHook is invoked for each account that receives an allocation, and
+// may exclude the account or adjust its allocation. It allows to experiment
+// one-off policies without modifying distribution.
+type accountHook interface {
+	adjust(hookRequest) (hookResponse, error)
+}
+
+// hookRequest describes an account and its computed allocation.
+type hookRequest struct {
+	Address      string             `json:"address"`
+	Type         string             `json:"type"`
+	LiquidAmount sdk.Dec            `json:"liquidAmount"`
+	StakedAmount sdk.Dec            `json:"stakedAmount"`
+	LockedAmount sdk.Dec            `json:"lockedAmount"`
+	VoteWeights  map[string]sdk.Dec `json:"voteWeights"`
+	Amount       sdk.Dec            `json:"amount"`
+}
+
+// hookResponse is the decision of the hook for an account.
+type hookResponse struct {
+	// Exclude removes the allocation of the account from the supply.
+	Exclude bool `json:"exclude,omitempty"`
+	// Multiplier, if set, is applied to the allocation of the account.
+	Multiplier sdk.Dec `json:"multiplier"`
+	// Policy is an optional name of the decision, recorded in the audit log.
+	Policy string `json:"policy,omitempty"`
+}
+
+// hookStats records the decisions of the hook.
+type hookStats struct {
+	excluded int
+	// excludedAmt is the $ATONE allocation of the excluded accounts.
+	excludedAmt sdk.Dec
+	adjusted    int
+	// delta is the $ATONE added (or removed if negative) by the adjustments.
+	delta sdk.Dec
+}
+
+// auditPolicy returns the name of the decision r, for the audit log.
+func (r hookResponse) auditPolicy() string {
+	if r.Policy != "" {
+		return policyHook + "-" + r.Policy
+	}
+	if r.Exclude {
+		return policyHook + "-exclude"
+	}
+	return policyHook + "-adjust"
+}
+
+// execHook is an accountHook that delegates the decisions to an external
+// process. The process receives one JSON hookRequest per line on its stdin,
+// and must answer with one JSON hookResponse per line on its stdout, e.g.
+// {"exclude":true} or {"multiplier":"0.5"}.
+type execHook struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	enc   *json.Encoder
+	dec   *json.Decoder
+}
+
+// startExecHook starts command with the shell.
+func startExecHook(ctx context.Context, command string) (*execHook, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start hook '%s': %w", command, err)
+	}
+	return &execHook{
+		cmd:   cmd,
+		stdin: stdin,
+		enc:   json.NewEncoder(stdin),
+		dec:   json.NewDecoder(stdout),
+	}, nil
+}
+
+func (h *execHook) adjust(req hookRequest) (hookResponse, error) {
+	if err := h.enc.Encode(req); err != nil {
+		return hookResponse{}, fmt.Errorf("hook: write request for %s: %w", req.Address, err)
+	}
+	var res hookResponse
+	if err := h.dec.Decode(&res); err != nil {
+		return hookResponse{}, fmt.Errorf("hook: read response for %s: %w", req.Address, err)
+	}
+	return res, nil
+}
+
+// Close closes the stdin of the process and waits for its termination.
+func (h *execHook) Close() error {
+	if err := h.stdin.Close(); err != nil {
+		return err
+	}
+	return h.cmd.Wait()
+}
+
+func reportHook(sec *reportSection, airdrop airdrop) {
+	s := airdrop.hook
+	sec.text("Hook: %d accounts excluded (%s $ATONE), %d accounts adjusted (%s $ATONE)",
+		s.excluded, humand(s.excludedAmt), s.adjusted, humand(s.delta))
+}
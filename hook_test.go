@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestDistributionHook(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "excluded", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: vote(govtypes.OptionNo)},
+		{Address: "doubled", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: vote(govtypes.OptionNo)},
+		{Address: "unchanged", LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.ZeroDec()},
+	}
+	noHook, err := distribution(accounts, defaultDistriParams(), "")
+	require.NoError(err)
+	hook, err := startExecHook(context.Background(), `while read -r l; do
+  case "$l" in
+    *'"address":"excluded"'*) echo '{"exclude":true}';;
+    *'"address":"doubled"'*) echo '{"multiplier":"2","policy":"double"}';;
+    *) echo '{}';;
+  esac
+done`)
+	require.NoError(err)
+	params := defaultDistriParams()
+	params.hook = hook
+
+	airdrop, err := distribution(accounts, params, "")
+
+	require.NoError(err)
+	require.NoError(hook.Close())
+	assert.NotContains(airdrop.addresses, "excluded")
+	assert.Equal(noHook.addresses["doubled"].MulRaw(2), airdrop.addresses["doubled"])
+	assert.Equal(noHook.addresses["unchanged"], airdrop.addresses["unchanged"])
+	assert.Equal([]string{"hook-exclude"}, airdrop.audit[0].Policies)
+	assert.Equal([]string{"hook-double"}, airdrop.audit[1].Policies)
+	assert.Empty(airdrop.audit[2].Policies)
+	assert.Equal(1, airdrop.hook.excluded)
+	assert.Equal(noHook.audit[0].Amount, airdrop.hook.excludedAmt)
+	assert.Equal(1, airdrop.hook.adjusted)
+	assert.Equal(noHook.audit[1].Amount, airdrop.hook.delta)
+	assert.Equal(noHook.atone.supply.Sub(noHook.audit[0].Amount).Add(noHook.audit[1].Amount), airdrop.atone.supply)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	icatypes "github.com/cosmos/ibc-go/v7/modules/apps/27-interchain-accounts/types"
+)
+
+const interchainAccountType = "/ibc.applications.interchain_accounts.v1.InterchainAccount"
+
+// interchainAccount is an ICA hosted on the snapshot chain, with the owner
+// that controls it from the controller chain.
+type interchainAccount struct {
+	Address string
+	// Owner is the account owner of the ICA metadata, usually an address of
+	// the controller chain.
+	Owner string
+	// Controller is the bech32 prefix of Owner, which identifies the
+	// controller chain, empty if Owner isn't a bech32 address.
+	Controller string
+	// Recipient is the address receiving the allocation of the ICA, empty if
+	// the ICA isn't mapped by its controller chain.
+	Recipient string
+}
+
+// parseInterchainAccounts returns the interchain accounts of the
+// auth_genesis.json file of path, sorted by address.
+func parseInterchainAccounts(path string) (_ []interchainAccount, err error) {
+	f, err := openInput(context.Background(), joinInput(path, "auth_genesis.json"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var genesis authtypes.GenesisState
+	if err := unmarshaler.Unmarshal(f, &genesis); err != nil {
+		return nil, err
+	}
+	var icas []interchainAccount
+	for _, any := range genesis.Accounts {
+		if any.GetTypeUrl() != interchainAccountType {
+			continue
+		}
+		var acc authtypes.GenesisAccount
+		if err := registry.UnpackAny(any, &acc); err != nil {
+			return nil, err
+		}
+		ica, ok := acc.(*icatypes.InterchainAccount)
+		if !ok {
+			return nil, fmt.Errorf("unexpected interchain account type %T", acc)
+		}
+		icas = append(icas, interchainAccount{
+			Address:    ica.GetAddress().String(),
+			Owner:      ica.AccountOwner,
+			Controller: controllerPrefix(ica.AccountOwner),
+		})
+	}
+	slices.SortFunc(icas, func(a, b interchainAccount) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+	return icas, nil
+}
+
+// controllerPrefix returns the bech32 prefix of the ICA owner, or an empty
+// string if owner isn't a bech32 address.
+func controllerPrefix(owner string) string {
+	hrp, _, err := bech32.DecodeAndConvert(owner)
+	if err != nil {
+		return ""
+	}
+	return hrp
+}
+
+// parseICAControllers reads the mapping of the ICA owners to the addresses
+// receiving their allocation, as supplied by the controller chains. The file
+// is expected to be a JSON object mapping the owner addresses of the
+// controller chains to the recipient addresses.
+func parseICAControllers(path string) (_ map[string]string, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var recipientByOwner map[string]string
+	if err := json.NewDecoder(f).Decode(&recipientByOwner); err != nil {
+		return nil, fmt.Errorf("cannot json decode ICA controllers from file %s: %w", path, err)
+	}
+	return recipientByOwner, nil
+}
+
+// redirectInterchainAccounts moves the balance, delegations and vote of the
+// ICAs whose owner is in recipientByOwner to the recipient address, so
+// getAccounts, which ignores the ICAs, allocates them to the recipient.
+// The recipient amounts are summed with its own ones, and its own vote takes
+// precedence over the vote of the ICA. The recipient of each ICA is set in
+// icas, and the number of redirected ICAs is returned.
+func redirectInterchainAccounts(icas []interchainAccount, recipientByOwner map[string]string,
+	delegsByAddr map[string][]stakingtypes.Delegation,
+	votesByAddr map[string]govtypes.WeightedVoteOptions,
+	balancesByAddr map[string]sdk.Coin,
+	accountTypesByAddr map[string]string,
+) (int, error) {
+	var redirected int
+	for i, ica := range icas {
+		recipient, ok := recipientByOwner[ica.Owner]
+		if !ok {
+			continue
+		}
+		// Use the prefix of the ICA, so the recipient matches the other
+		// addresses of the snapshot.
+		hrp, _, err := bech32.DecodeAndConvert(ica.Address)
+		if err != nil {
+			return 0, fmt.Errorf("interchain account %s: %w", ica.Address, err)
+		}
+		_, bz, err := bech32.DecodeAndConvert(recipient)
+		if err != nil {
+			return 0, fmt.Errorf("recipient of ICA owner %s: %w", ica.Owner, err)
+		}
+		recipient, err = bech32.ConvertAndEncode(hrp, bz)
+		if err != nil {
+			return 0, err
+		}
+		if recipient == ica.Address {
+			return 0, fmt.Errorf("ICA owner %s: the recipient can't be the interchain account", ica.Owner)
+		}
+		if t := accountTypesByAddr[recipient]; t == interchainAccountType || t == "/cosmos.auth.v1beta1.ModuleAccount" {
+			return 0, fmt.Errorf("ICA owner %s: recipient %s is a %s", ica.Owner, recipient, t)
+		}
+		if balance, ok := balancesByAddr[ica.Address]; ok {
+			if prev, ok := balancesByAddr[recipient]; ok {
+				balance = prev.Add(balance)
+			}
+			balancesByAddr[recipient] = balance
+			delete(balancesByAddr, ica.Address)
+		}
+		for _, deleg := range delegsByAddr[ica.Address] {
+			deleg.DelegatorAddress = recipient
+			delegsByAddr[recipient] = append(delegsByAddr[recipient], deleg)
+		}
+		delete(delegsByAddr, ica.Address)
+		if vote, ok := votesByAddr[ica.Address]; ok {
+			if _, ok := votesByAddr[recipient]; !ok {
+				votesByAddr[recipient] = vote
+			}
+			delete(votesByAddr, ica.Address)
+		}
+		if _, ok := accountTypesByAddr[recipient]; !ok {
+			// The recipient may not exist on the snapshot chain, record it to
+			// not warn about a balance without auth account.
+			accountTypesByAddr[recipient] = ""
+		}
+		icas[i].Recipient = recipient
+		redirected++
+	}
+	return redirected, nil
+}
+
+// printInterchainAccounts writes a table of the interchain accounts with
+// their controller and recipient into w.
+func printInterchainAccounts(w io.Writer, icas []interchainAccount) {
+	table := newMarkdownTableWriter(w, "Interchain account", "Controller", "Owner", "Recipient")
+	for _, ica := range icas {
+		controller := ica.Controller
+		if controller == "" {
+			controller = "?"
+		}
+		recipient := ica.Recipient
+		if recipient == "" {
+			recipient = "ignored"
+		}
+		table.Append([]string{ica.Address, controller, ica.Owner, recipient})
+	}
+	table.Render()
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	icatypes "github.com/cosmos/ibc-go/v7/modules/apps/27-interchain-accounts/types"
+)
+
+func TestInterchainAccounts(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	warnings = newWarningsRegistry()
+	var (
+		addrs    = createAccountAddrs(4)
+		datapath = t.TempDir()
+		valAddr  = sdk.ValAddress("validator___________")
+		voteYes  = govtypes.NewNonSplitVoteOption(govtypes.OptionYes)
+		voteNo   = govtypes.NewNonSplitVoteOption(govtypes.OptionNo)
+		// addrs[0] and addrs[1] are ICAs, addrs[2] is the recipient of
+		// addrs[0] on the snapshot chain, addrs[3] the recipient of addrs[1]
+		// which doesn't exist on the snapshot chain.
+		owner0, _ = bech32.ConvertAndEncode("stride", []byte("owner0______________"))
+		owner1, _ = bech32.ConvertAndEncode("osmo", []byte("owner1______________"))
+	)
+	// parseInterchainAccounts sorts the ICAs by address
+	slices.SortFunc(addrs, func(a, b sdk.AccAddress) int { return strings.Compare(a.String(), b.String()) })
+	var authGen authtypes.GenesisState
+	for _, acc := range []authtypes.GenesisAccount{
+		icatypes.NewInterchainAccount(authtypes.NewBaseAccountWithAddress(addrs[0]), owner0),
+		icatypes.NewInterchainAccount(authtypes.NewBaseAccountWithAddress(addrs[1]), owner1),
+		authtypes.NewBaseAccountWithAddress(addrs[2]),
+	} {
+		any, err := codectypes.NewAnyWithValue(acc)
+		require.NoError(err)
+		authGen.Accounts = append(authGen.Accounts, any)
+	}
+	bz, err := cdc.MarshalJSON(&authGen)
+	require.NoError(err)
+	require.NoError(os.WriteFile(filepath.Join(datapath, "auth_genesis.json"), bz, 0o600))
+
+	icas, err := parseInterchainAccounts(datapath)
+
+	require.NoError(err)
+	require.Len(icas, 2)
+	assert.Equal(interchainAccount{Address: addrs[0].String(), Owner: owner0, Controller: "stride"}, icas[0])
+	assert.Equal("osmo", icas[1].Controller)
+
+	typesByAddr, err := parseAccountTypesPerAddr(datapath)
+	require.NoError(err)
+	var (
+		delegsByAddr = map[string][]stakingtypes.Delegation{
+			addrs[0].String(): {{DelegatorAddress: addrs[0].String(), ValidatorAddress: valAddr.String(), Shares: sdk.NewDec(100)}},
+			addrs[2].String(): {{DelegatorAddress: addrs[2].String(), ValidatorAddress: valAddr.String(), Shares: sdk.NewDec(50)}},
+		}
+		votesByAddr = map[string]govtypes.WeightedVoteOptions{
+			addrs[0].String(): voteNo,
+			addrs[1].String(): voteNo,
+			addrs[2].String(): voteYes,
+		}
+		balancesByAddr = map[string]sdk.Coin{
+			addrs[0].String(): sdk.NewInt64Coin("uatom", 10),
+			addrs[1].String(): sdk.NewInt64Coin("uatom", 20),
+			addrs[2].String(): sdk.NewInt64Coin("uatom", 5),
+		}
+		valsByAddr = map[string]govtypes.ValidatorGovInfo{
+			valAddr.String(): govtypes.NewValidatorGovInfo(valAddr, sdk.NewInt(1000), sdk.NewDec(1000), sdk.ZeroDec(), nil),
+		}
+		// the recipients can use the prefix of their chain
+		recipient1, _    = bech32.ConvertAndEncode("atone", addrs[3])
+		recipientByOwner = map[string]string{
+			owner0: addrs[2].String(),
+			owner1: recipient1,
+		}
+	)
+
+	redirected, err := redirectInterchainAccounts(icas, recipientByOwner, delegsByAddr, votesByAddr, balancesByAddr, typesByAddr)
+
+	require.NoError(err)
+	assert.Equal(2, redirected)
+	assert.Equal(addrs[2].String(), icas[0].Recipient)
+	assert.Equal(addrs[3].String(), icas[1].Recipient)
+	accounts, err := getAccounts(context.Background(), delegsByAddr, votesByAddr, valsByAddr, balancesByAddr, typesByAddr, "cosmos")
+	require.NoError(err)
+	require.Len(accounts, 2)
+	for _, acc := range accounts {
+		switch acc.Address {
+		case addrs[2].String():
+			// the recipient vote takes precedence
+			assert.Equal(voteYes, acc.Vote)
+			assert.Equal(sdk.NewDec(15), acc.LiquidAmount)
+			assert.Equal(sdk.NewDec(150), acc.StakedAmount)
+			assert.Len(acc.Delegations, 2)
+		case addrs[3].String():
+			// without delegation the vote doesn't matter
+			assert.Equal(sdk.NewDec(20), acc.LiquidAmount)
+			assert.True(acc.StakedAmount.IsZero())
+		default:
+			t.Errorf("unexpected account %s", acc.Address)
+		}
+	}
+	assert.Zero(warnings.len())
+
+	_, err = redirectInterchainAccounts(icas, map[string]string{owner0: addrs[1].String()},
+		delegsByAddr, votesByAddr, balancesByAddr, typesByAddr)
+	assert.ErrorContains(err, "InterchainAccount")
+}
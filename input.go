@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// inputDrainLimit is the maximum number of unread bytes downloaded when an
+// input URL is closed, to verify its checksum when the decoders stop before
+// the end of the file (trailing whitespace...).
+const inputDrainLimit = 64 << 10
+
+// noChecksum is set by the -noChecksum flag: the input URLs without a
+// .sha256 checksum are read unverified, with a warning, instead of failing.
+var noChecksum bool
+
+// isURL returns true if path is a http(s):// or s3:// URL instead of a local
+// path.
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://") ||
+		strings.HasPrefix(path, "s3://")
+}
+
+// joinInput returns the path of the file name of the input directory dir,
+// which is either a local directory or a URL.
+func joinInput(dir, name string) string {
+	if isURL(dir) {
+		return strings.TrimSuffix(dir, "/") + "/" + name
+	}
+	return filepath.Join(dir, name)
+}
+
+// outputDir returns the directory of the outputs derived from the inputs of
+// datapath: datapath itself, or the current directory if it's a URL.
+func outputDir(datapath string) string {
+	if isURL(datapath) {
+		return "."
+	}
+	return datapath
+}
+
+// s3HTTPURL returns the https URL of the s3://<bucket>/<key> URL, in the
+// region of the AWS_REGION environment variable if set. The requests aren't
+// signed, so private buckets require a presigned https URL instead.
+func s3HTTPURL(s3URL string) (string, error) {
+	u, err := url.Parse(s3URL)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid s3 URL %s: missing bucket", s3URL)
+	}
+	host := u.Host + ".s3.amazonaws.com"
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		host = fmt.Sprintf("%s.s3.%s.amazonaws.com", u.Host, region)
+	}
+	return (&url.URL{Scheme: "https", Host: host, Path: u.Path}).String(), nil
+}
+
+// openInput opens the input file path, which is either a local file or a
+// URL. The URLs are streamed, so the file is never fully held on disk nor in
+// memory. The content is verified against the <path>.sha256 file next to the
+// URL (in the sha256sum format) while it's read, and a mismatch is returned
+// as a read error, or by Close if the reader stopped before the end of the
+// file (see closeInput). A URL without checksum is an error, unless
+// noChecksum is set. A missing file wraps fs.ErrNotExist for both local files
+// and URLs.
+func openInput(ctx context.Context, path string) (io.ReadCloser, error) {
+	if !isURL(path) {
+		return os.Open(path)
+	}
+	src := path
+	if strings.HasPrefix(path, "s3://") {
+		var err error
+		src, err = s3HTTPURL(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	expected, err := fetchChecksum(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpGet(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	if expected == nil {
+		if !noChecksum {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s has no .sha256 checksum, use -noChecksum to read it unverified", path)
+		}
+		warnings.add(warnNoChecksum, path)
+		return resp.Body, nil
+	}
+	return &checksumReader{
+		r:        resp.Body,
+		h:        sha256.New(),
+		expected: expected,
+		size:     resp.ContentLength,
+		path:     path,
+	}, nil
+}
+
+// httpGet gets src, an error wrapping fs.ErrNotExist is returned if the
+// status is 404.
+func httpGet(ctx context.Context, src string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("get %s: %s: %w", src, resp.Status, fs.ErrNotExist)
+	}
+	resp.Body.Close()
+	return nil, fmt.Errorf("get %s: %s", src, resp.Status)
+}
+
+// fetchChecksum returns the sha256 checksum of src from src.sha256, nil if
+// there's none.
+func fetchChecksum(ctx context.Context, src string) ([]byte, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	u.Path += ".sha256"
+	resp, err := httpGet(ctx, u.String())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	line, err := bufio.NewReader(io.LimitReader(resp.Body, 1024)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	// sha256sum format: "<hex>  <filename>"
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty checksum file %s", u)
+	}
+	sum, err := hex.DecodeString(fields[0])
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("invalid sha256 checksum in %s", u)
+	}
+	return sum, nil
+}
+
+// checksumReader verifies the checksum of the content of r once it's fully
+// read, which is detected with the EOF or the content length.
+type checksumReader struct {
+	r        io.ReadCloser
+	h        hash.Hash
+	expected []byte
+	// size is the content length, -1 if unknown.
+	size     int64
+	n        int64
+	verified bool
+	path     string
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.h.Write(p[:n])
+	c.n += int64(n)
+	if err == io.EOF || (c.size >= 0 && c.n == c.size) {
+		if verr := c.verify(); verr != nil {
+			return n, verr
+		}
+	}
+	return n, err
+}
+
+func (c *checksumReader) verify() error {
+	if c.verified {
+		return nil
+	}
+	c.verified = true
+	if sum := c.h.Sum(nil); !bytes.Equal(sum, c.expected) {
+		return fmt.Errorf("checksum mismatch for %s: got %x, expected %x", c.path, sum, c.expected)
+	}
+	return nil
+}
+
+// Close closes the underlying reader, after having read the remaining bytes,
+// up to inputDrainLimit, to verify the checksum. It returns an error if more
+// bytes are left, since the checksum can't be verified.
+func (c *checksumReader) Close() error {
+	var err error
+	if !c.verified {
+		// one more byte than the limit, so the EOF of a rest of exactly
+		// inputDrainLimit bytes is read
+		_, err = io.CopyN(io.Discard, c, inputDrainLimit+1)
+		if err == io.EOF {
+			err = nil
+		}
+		if err == nil && !c.verified {
+			err = fmt.Errorf("checksum of %s not verified: more than %d bytes left unread", c.path, inputDrainLimit)
+		}
+	}
+	return errors.Join(err, c.r.Close())
+}
+
+// closeInput closes the input f and sets *err to the error of Close if it's
+// nil, so the checksum mismatches detected when the end of the input is
+// drained aren't lost. The readers of the inputs defer it with their named
+// error result, since their decoders can stop before the end of the input.
+func closeInput(f io.Closer, err *error) {
+	if cerr := f.Close(); *err == nil {
+		*err = cerr
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenInputURL(t *testing.T) {
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	warnings = newWarningsRegistry()
+	content := `[{"address":"cosmos1"}]`
+	big := strings.Repeat(" ", inputDrainLimit+3) + content
+	files := map[string]string{
+		"/snap/ok.json":             content,
+		"/snap/ok.json.sha256":      fmt.Sprintf("%x  ok.json\n", sha256.Sum256([]byte(content))),
+		"/snap/bad.json":            content,
+		"/snap/bad.json.sha256":     fmt.Sprintf("%x  bad.json\n", sha256.Sum256([]byte("other"))),
+		"/snap/nosum.json":          content,
+		"/snap/invalid.json":        content,
+		"/snap/invalid.json.sha256": "xyz\n",
+		"/snap/big.json":            big,
+		"/snap/big.json.sha256":     fmt.Sprintf("%x  big.json\n", sha256.Sum256([]byte(big))),
+		// the decoders stop at the end of the JSON value, before the
+		// trailing newlines
+		"/snap/balances.json":        `[{"address":"cosmos1","coins":[{"denom":"uatom","amount":"1"}]}]` + "\n\n",
+		"/snap/balances.json.sha256": fmt.Sprintf("%x  balances.json\n", sha256.Sum256([]byte("other"))),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		// without content length, the end of the content is only known
+		// with the EOF
+		w.Header().Set("Transfer-Encoding", "chunked")
+		io.WriteString(w, strings.TrimRight(s, "\n"))
+		w.(http.Flusher).Flush()
+		io.WriteString(w, s[len(strings.TrimRight(s, "\n")):])
+	}))
+	defer srv.Close()
+	dir := srv.URL + "/snap/"
+	read := func(name string) (string, error) {
+		f, err := openInput(context.Background(), joinInput(dir, name))
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		bz, err := io.ReadAll(f)
+		return string(bz), err
+	}
+
+	t.Run("checksum ok", func(t *testing.T) {
+		s, err := read("ok.json")
+		require.NoError(t, err)
+		assert.Equal(t, content, s)
+	})
+	t.Run("checksum mismatch", func(t *testing.T) {
+		_, err := read("bad.json")
+		assert.ErrorContains(t, err, "checksum mismatch")
+	})
+	t.Run("checksum mismatch on partial read", func(t *testing.T) {
+		f, err := openInput(context.Background(), joinInput(dir, "bad.json"))
+		require.NoError(t, err)
+		_, err = f.Read(make([]byte, 2))
+		require.NoError(t, err)
+		assert.ErrorContains(t, f.Close(), "checksum mismatch")
+	})
+	t.Run("checksum mismatch after the decoded value", func(t *testing.T) {
+		_, err := parseBalancesByAddr(context.Background(), dir, "uatom")
+		assert.ErrorContains(t, err, "checksum mismatch")
+	})
+	t.Run("invalid checksum", func(t *testing.T) {
+		_, err := read("invalid.json")
+		assert.ErrorContains(t, err, "invalid sha256 checksum")
+	})
+	t.Run("checksum not verified on partial read", func(t *testing.T) {
+		f, err := openInput(context.Background(), joinInput(dir, "big.json"))
+		require.NoError(t, err)
+		_, err = f.Read(make([]byte, 2))
+		require.NoError(t, err)
+		assert.ErrorContains(t, f.Close(), "checksum of "+dir+"big.json not verified")
+	})
+	t.Run("no checksum", func(t *testing.T) {
+		_, err := read("nosum.json")
+		assert.ErrorContains(t, err, "has no .sha256 checksum")
+		assert.Zero(t, warnings.get(warnNoChecksum))
+	})
+	t.Run("no checksum allowed", func(t *testing.T) {
+		defer func(b bool) { noChecksum = b }(noChecksum)
+		noChecksum = true
+		s, err := read("nosum.json")
+		require.NoError(t, err)
+		assert.Equal(t, content, s)
+		assert.Equal(t, 1, warnings.get(warnNoChecksum))
+	})
+	t.Run("not found", func(t *testing.T) {
+		_, err := read("missing.json")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
+
+func TestInputPaths(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("https://host/snap/votes.json", joinInput("https://host/snap/", "votes.json"))
+	assert.Equal("s3://bucket/snap/votes.json", joinInput("s3://bucket/snap", "votes.json"))
+	assert.Equal("snap/votes.json", joinInput("snap", "votes.json"))
+	assert.Equal(".", outputDir("https://host/snap"))
+	assert.Equal("snap", outputDir("snap"))
+}
+
+func TestS3HTTPURL(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	t.Setenv("AWS_REGION", "")
+	u, err := s3HTTPURL("s3://bucket/snap/votes.json")
+	require.NoError(err)
+	assert.Equal("https://bucket.s3.amazonaws.com/snap/votes.json", u)
+	t.Setenv("AWS_REGION", "eu-west-1")
+	u, err = s3HTTPURL("s3://bucket/snap/votes.json")
+	require.NoError(err)
+	assert.Equal("https://bucket.s3.eu-west-1.amazonaws.com/snap/votes.json", u)
+	_, err = s3HTTPURL("s3:///votes.json")
+	assert.Error(err)
+}
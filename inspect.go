@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// delegationDetail explains the part of an account allocation that comes
+// from one of its delegations.
+type delegationDetail struct {
+	validator string
+	moniker   string
+	identity  string
+	amount    sdk.Dec
+	// vote is the validator vote.
+	vote govtypes.WeightedVoteOptions
+	// overridden is true if the delegator voted directly with another main
+	// option than its validator.
+	overridden bool
+	// atone is the part of the final amount attributable to the delegation.
+	atone sdk.Dec
+	// share is atone over the final amount.
+	share sdk.Dec
+}
+
+// inspectDelegations splits the final amount of acc between its delegations
+// and its liquid amount, proportionally to their $ATONE computed with the
+// multipliers of the audit entry, so the adjustments applied to the whole
+// account (slash, cap, voter floor...) are spread evenly.
+func inspectDelegations(acc Account, audit auditEntry, infos validatorInfos) ([]delegationDetail, sdk.Dec) {
+	var (
+		details   = make([]delegationDetail, len(acc.Delegations))
+		final     = audit.FinalAmount.ToLegacyDec()
+		liquidAmt = sdk.ZeroDec()
+		total     = sdk.ZeroDec()
+		factor    = audit.Multipliers["supplyFactor"]
+	)
+	if audit.Multipliers != nil {
+		liquidAmt = acc.LiquidAmount.Mul(audit.Multipliers["liquid"]).Mul(factor)
+		if locked, ok := audit.Multipliers["locked"]; ok {
+			liquidAmt = liquidAmt.Add(acc.lockedAmount().Mul(locked).Mul(factor))
+		}
+		total = liquidAmt
+	}
+	delegatorOption := mainVoteOption(acc.Vote)
+	for i, del := range acc.Delegations {
+		d := delegationDetail{
+			validator: del.ValidatorAddress,
+			moniker:   infos.moniker(del.ValidatorAddress),
+			identity:  infos[del.ValidatorAddress].identity,
+			amount:    del.Amount,
+			vote:      del.Vote,
+			atone:     sdk.ZeroDec(),
+			share:     sdk.ZeroDec(),
+		}
+		if len(acc.Vote) > 0 && len(del.Vote) > 0 {
+			d.overridden = mainVoteOption(del.Vote) != delegatorOption
+		}
+		if audit.Multipliers != nil {
+			// The direct vote applies to all the delegations of the hub
+			vote := del.Vote
+			if len(acc.Vote) > 0 && del.Chain == "" {
+				vote = acc.Vote
+			}
+			if len(vote) == 0 {
+				vote = govtypes.NewNonSplitVoteOption(govtypes.OptionEmpty)
+			}
+			for _, o := range vote {
+				mult := audit.Multipliers[voteOptionNames[o.Option]]
+				d.atone = d.atone.Add(del.Amount.Mul(o.Weight).Mul(mult).Mul(factor))
+			}
+			total = total.Add(d.atone)
+		}
+		details[i] = d
+	}
+	if total.IsZero() {
+		for i := range details {
+			details[i].atone = sdk.ZeroDec()
+		}
+		return details, sdk.ZeroDec()
+	}
+	for i := range details {
+		details[i].share = details[i].atone.Quo(total)
+		details[i].atone = details[i].share.Mul(final)
+	}
+	return details, liquidAmt.Quo(total).Mul(final)
+}
+
+func formatVote(vote govtypes.WeightedVoteOptions) string {
+	if len(vote) == 0 {
+		return "DNV"
+	}
+	var s []string
+	for _, o := range vote {
+		s = append(s, fmt.Sprintf("%s %s", voteOptionLabel(o.Option), humanPercent(o.Weight)))
+	}
+	return strings.Join(s, ", ")
+}
+
+func printInspect(acc Account, audit auditEntry, details []delegationDetail, liquidAtone sdk.Dec) {
+	fmt.Printf("Account %s (%s)\n", acc.Address, acc.Type)
+	fmt.Printf("Liquid %s $ATOM, staked %s $ATOM, vote: %s\n", humand(acc.LiquidAmount), humand(acc.StakedAmount), formatVote(acc.Vote))
+	if acc.lockedAmount().IsPositive() {
+		fmt.Printf("Locked vesting %s $ATOM\n", humand(acc.lockedAmount()))
+	}
+	if len(audit.Policies) > 0 {
+		fmt.Printf("Policies: %s\n", strings.Join(audit.Policies, ", "))
+	}
+	fmt.Printf("Final amount: %s uatone (%s $ATONE)\n\n", audit.FinalAmount, human(audit.FinalAmount))
+	table := newMarkdownTable("VALIDATOR", "MONIKER", "IDENTITY", "$ATOM", "VALIDATOR VOTE", "OVERRIDDEN", "$ATONE", "SHARE")
+	for _, d := range details {
+		table.Append([]string{
+			d.validator,
+			d.moniker,
+			d.identity,
+			humand(d.amount),
+			formatVote(d.vote),
+			fmt.Sprint(d.overridden),
+			humand(d.atone),
+			humanPercent(d.share),
+		})
+	}
+	liquidShare := sdk.ZeroDec()
+	if audit.FinalAmount.IsPositive() {
+		liquidShare = liquidAtone.QuoInt(audit.FinalAmount)
+	}
+	table.Append([]string{"(liquid)", "", "", humand(acc.LiquidAmount), "", "", humand(liquidAtone), humanPercent(liquidShare)})
+	table.Render()
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// Files of an inspectIndex. Each indexed input has a keys file, the
+// concatenated keys, and an entries file of inspectIndexEntrySize records
+// sorted by key: the offset and length of the key in the keys file, and the
+// offset and length of the JSON value in the input file.
+const (
+	idxAccounts   = "accounts"
+	idxValidators = "validators"
+	idxMeta       = "meta.json"
+
+	inspectIndexEntrySize = 32
+)
+
+// inspectIndexMeta holds the $ATOM distribution of the indexed accounts,
+// which the allocation of a single account depends on.
+type inspectIndexMeta struct {
+	Accounts int     `json:"accounts"`
+	Supply   sdk.Dec `json:"supply"`
+	Unstaked sdk.Dec `json:"unstaked"`
+	// Locked is nil in the indexes built before the vesting locks.
+	Locked sdk.Dec            `json:"locked"`
+	Votes  map[string]sdk.Dec `json:"votes"`
+	// Validators is false if there was no active_validators.json to index.
+	Validators bool `json:"validators"`
+}
+
+func (m inspectIndexMeta) atom() distrib {
+	atom := distrib{supply: m.Supply, unstaked: m.Unstaked, locked: m.Locked, votes: newVoteMap()}
+	if atom.locked.IsNil() {
+		atom.locked = sdk.ZeroDec()
+	}
+	for opt, name := range voteOptionNames {
+		if v, ok := m.Votes[name]; ok {
+			atom.votes[opt] = v
+		}
+	}
+	return atom
+}
+
+// inspectIndex maps the addresses of accounts.json, and the operator
+// addresses of active_validators.json, to the bytes of their JSON value, so
+// inspect reads a single account from the raw files instead of loading all of
+// them. The index and the inputs are memory-mapped.
+type inspectIndex struct {
+	meta       inspectIndexMeta
+	accounts   indexedInput
+	validators indexedInput
+	unmaps     []func() error
+}
+
+// indexedInput is an input file and its index.
+type indexedInput struct {
+	data, keys, entries []byte
+}
+
+// throttledReader limits the read throughput of r to rate bytes per second,
+// so building an index doesn't starve the other users of the disk.
+type throttledReader struct {
+	r     io.Reader
+	rate  int64
+	start time.Time
+	n     int64
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.n += int64(n)
+	if t.rate > 0 {
+		expected := time.Duration(float64(t.n) / float64(t.rate) * float64(time.Second))
+		if d := expected - time.Since(t.start); d > 0 {
+			time.Sleep(d)
+		}
+	}
+	return n, err
+}
+
+// indexEntry locates the JSON value of key in an input file.
+type indexEntry struct {
+	key      string
+	off, len uint64
+}
+
+// buildJSONIndex indexes the values of the JSON array of file in dir/name,
+// decode reads the next value of dec and returns its key. The file is read
+// at most at rate bytes per second, 0 disables the limit.
+func buildJSONIndex(ctx context.Context, file, dir, name string, rate int64, decode func(dec *json.Decoder) (string, error)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(&throttledReader{r: bufio.NewReader(f), rate: rate, start: time.Now()})
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("cannot json decode %s: %w", file, err)
+	}
+	var entries []indexEntry
+	for dec.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// The offsets are those of the decoder, which may include the
+		// separator before the value, see indexedInput.lookup.
+		start := dec.InputOffset()
+		key, err := decode(dec)
+		if err != nil {
+			return fmt.Errorf("cannot json decode %s: %w", file, err)
+		}
+		entries = append(entries, indexEntry{key: key, off: uint64(start), len: uint64(dec.InputOffset() - start)})
+	}
+	slices.SortFunc(entries, func(a, b indexEntry) int { return cmp.Compare(a.key, b.key) })
+	keysFile, err := os.Create(filepath.Join(dir, name+".keys"))
+	if err != nil {
+		return err
+	}
+	defer keysFile.Close()
+	entriesFile, err := os.Create(filepath.Join(dir, name+".idx"))
+	if err != nil {
+		return err
+	}
+	defer entriesFile.Close()
+	var (
+		keys   = bufio.NewWriter(keysFile)
+		w      = bufio.NewWriter(entriesFile)
+		keyOff uint64
+	)
+	for _, e := range entries {
+		keys.WriteString(e.key)
+		// bufio.Writer errors are sticky, and checked by Flush
+		binary.Write(w, binary.LittleEndian, [4]uint64{keyOff, uint64(len(e.key)), e.off, e.len})
+		keyOff += uint64(len(e.key))
+	}
+	if err := keys.Flush(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// buildInspectIndex indexes in dir the accounts.json and active_validators.json
+// of datapath, see buildJSONIndex for rate.
+func buildInspectIndex(ctx context.Context, datapath, dir string, rate int64) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	var (
+		meta     inspectIndexMeta
+		buildErr error
+	)
+	// The accounts are aggregated while they're indexed, in a single pass.
+	accounts := func(yield func(Account) bool) {
+		buildErr = buildJSONIndex(ctx, filepath.Join(datapath, "accounts.json"), dir, idxAccounts, rate, func(dec *json.Decoder) (string, error) {
+			var acc Account
+			if err := dec.Decode(&acc); err != nil {
+				return "", err
+			}
+			meta.Accounts++
+			if !yield(acc) {
+				return "", context.Cause(ctx)
+			}
+			return acc.Address, nil
+		})
+	}
+	atom, err := newDistributionStages(accounts).aggregate(ctx)
+	if err := cmp.Or(err, buildErr); err != nil {
+		return err
+	}
+	meta.Supply, meta.Unstaked, meta.Locked, meta.Votes = atom.supply, atom.unstaked, atom.locked, make(map[string]sdk.Dec)
+	for opt, v := range atom.votes {
+		meta.Votes[voteOptionNames[opt]] = v
+	}
+	err = buildJSONIndex(ctx, filepath.Join(datapath, "active_validators.json"), dir, idxValidators, rate, func(dec *json.Decoder) (string, error) {
+		var val stakingtypes.Validator
+		if err := unmarshaler.UnmarshalNext(dec, &val); err != nil {
+			return "", err
+		}
+		return val.OperatorAddress, nil
+	})
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		meta.Validators = true
+	}
+	bz, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	// meta.json is written last, so an incomplete index can't be opened.
+	return writeFile(ctx, filepath.Join(dir, idxMeta), bz)
+}
+
+// openInspectIndex memory-maps the index of dir and the inputs of datapath,
+// which must be closed after use.
+func openInspectIndex(datapath, dir string) (*inspectIndex, error) {
+	bz, err := os.ReadFile(filepath.Join(dir, idxMeta))
+	if err != nil {
+		return nil, err
+	}
+	x := &inspectIndex{}
+	if err := json.Unmarshal(bz, &x.meta); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", idxMeta, err)
+	}
+	inputs := map[string]*indexedInput{idxAccounts: &x.accounts}
+	if x.meta.Validators {
+		inputs[idxValidators] = &x.validators
+	}
+	files := map[string]string{idxAccounts: "accounts.json", idxValidators: "active_validators.json"}
+	for name, in := range inputs {
+		for file, dst := range map[string]*[]byte{
+			filepath.Join(datapath, files[name]): &in.data,
+			filepath.Join(dir, name+".keys"):     &in.keys,
+			filepath.Join(dir, name+".idx"):      &in.entries,
+		} {
+			data, unmap, err := mmapFile(file)
+			if err != nil {
+				x.Close()
+				return nil, err
+			}
+			*dst = data
+			x.unmaps = append(x.unmaps, unmap)
+		}
+	}
+	if len(x.accounts.entries) != inspectIndexEntrySize*x.meta.Accounts {
+		x.Close()
+		return nil, fmt.Errorf("inspect index %s is corrupted", dir)
+	}
+	return x, nil
+}
+
+// loadInspectIndex opens the inspect index of dir, building it first from
+// datapath if it doesn't exist or is older than the inputs.
+func loadInspectIndex(ctx context.Context, datapath, dir string, rate int64) (*inspectIndex, error) {
+	if isURL(datapath) {
+		return nil, fmt.Errorf("the inspect index requires local inputs, got %s", datapath)
+	}
+	metaInfo, err := os.Stat(filepath.Join(dir, idxMeta))
+	outdated := err != nil
+	for _, file := range []string{"accounts.json", "active_validators.json"} {
+		info, err := os.Stat(filepath.Join(datapath, file))
+		if err == nil && !outdated && metaInfo.ModTime().Before(info.ModTime()) {
+			outdated = true
+		}
+	}
+	if outdated {
+		fmt.Printf("Building inspect index in %s\n", dir)
+		// Remove the meta first, so an interrupted build can't be opened.
+		os.Remove(filepath.Join(dir, idxMeta))
+		if err := buildInspectIndex(ctx, datapath, dir, rate); err != nil {
+			return nil, err
+		}
+	}
+	return openInspectIndex(datapath, dir)
+}
+
+func (x *inspectIndex) Close() error {
+	var err error
+	for _, unmap := range x.unmaps {
+		if e := unmap(); e != nil {
+			err = e
+		}
+	}
+	x.unmaps = nil
+	return err
+}
+
+// lookup returns the JSON value of key, and false if key isn't indexed.
+func (in indexedInput) lookup(key string) ([]byte, bool) {
+	le := binary.LittleEndian
+	entry := func(i int) []byte { return in.entries[inspectIndexEntrySize*i:] }
+	keyAt := func(i int) string {
+		off, n := le.Uint64(entry(i)), le.Uint64(entry(i)[8:])
+		return string(in.keys[off : off+n])
+	}
+	n := len(in.entries) / inspectIndexEntrySize
+	i := sort.Search(n, func(i int) bool { return keyAt(i) >= key })
+	if i == n || keyAt(i) != key {
+		return nil, false
+	}
+	off, size := le.Uint64(entry(i)[16:]), le.Uint64(entry(i)[24:])
+	// Skip the separator the decoder offsets may include
+	return bytes.TrimLeft(in.data[off:off+size], ", \t\r\n"), true
+}
+
+// account returns the account of address, and false if it doesn't exist.
+func (x *inspectIndex) account(address string) (Account, bool, error) {
+	bz, ok := x.accounts.lookup(address)
+	if !ok {
+		return Account{}, false, nil
+	}
+	var acc Account
+	if err := json.Unmarshal(bz, &acc); err != nil {
+		return Account{}, false, fmt.Errorf("cannot json decode account %s: %w", address, err)
+	}
+	return acc, true, nil
+}
+
+// validatorInfos returns the validatorInfos of the validators of acc's
+// delegations.
+func (x *inspectIndex) validatorInfos(acc Account) (validatorInfos, error) {
+	infos := make(validatorInfos)
+	for _, del := range acc.Delegations {
+		bz, ok := x.validators.lookup(del.ValidatorAddress)
+		if !ok {
+			continue
+		}
+		var val stakingtypes.Validator
+		if err := unmarshaler.Unmarshal(bytes.NewReader(bz), &val); err != nil {
+			return nil, fmt.Errorf("cannot json decode validator %s: %w", del.ValidatorAddress, err)
+		}
+		infos[val.OperatorAddress] = validatorInfo{
+			moniker:  strings.TrimSpace(val.Description.Moniker),
+			identity: strings.TrimSpace(val.Description.Identity),
+		}
+	}
+	return infos, nil
+}
+
+// inspectAccount returns the account of address and its audit entry for
+// params, computed from the $ATOM distribution of the index instead of a
+// pass over all the accounts.
+func (x *inspectIndex) inspectAccount(ctx context.Context, address string, params distriParams, prefix string) (Account, auditEntry, error) {
+	if params.hasAddressCap() || params.hasTargetSupply() || params.hasYesRatio() || params.hasShareBounds() {
+		return Account{}, auditEntry{}, errors.New("the inspect index doesn't support the params that require a pass over all the accounts (address cap, target supply, Yes ratio, share bounds)")
+	}
+	acc, ok, err := x.account(address)
+	if err != nil {
+		return Account{}, auditEntry{}, err
+	}
+	if !ok {
+		return Account{}, auditEntry{}, fmt.Errorf("account %s not found", address)
+	}
+	stages := newDistributionStages(slices.Values([]Account{acc}))
+	atom := x.meta.atom()
+	stages.atom = &atom
+	stages.subset = true
+	airdrop, err := stages.run(ctx, params, prefix, checkpointConfig{})
+	if err != nil {
+		return Account{}, auditEntry{}, err
+	}
+	return acc, airdrop.audit[0], nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestInspectIndex(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		no       = govtypes.NewNonSplitVoteOption(govtypes.OptionNo)
+		yes      = govtypes.NewNonSplitVoteOption(govtypes.OptionYes)
+		datapath = t.TempDir()
+		dir      = filepath.Join(t.TempDir(), "index")
+	)
+	accounts := []Account{
+		{
+			Address: "inherited", LiquidAmount: sdk.NewDec(1_000), StakedAmount: sdk.NewDec(3_000),
+			Delegations: []Delegation{
+				{ValidatorAddress: "val-no", Amount: sdk.NewDec(1_000), Vote: no},
+				{ValidatorAddress: "val-dnv", Amount: sdk.NewDec(2_000)},
+			},
+		},
+		{Address: "direct", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(2_000), Vote: yes},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100_000), StakedAmount: sdk.ZeroDec()},
+	}
+	bz, err := json.MarshalIndent(accounts, "", "  ")
+	require.NoError(err)
+	require.NoError(os.WriteFile(filepath.Join(datapath, "accounts.json"), bz, 0o600))
+	validators := `[
+  {"operator_address": "val-no", "description": {"moniker": " Validator No ", "identity": "ABCD"}},
+  {"operator_address": "val-yes", "description": {"moniker": "Validator Yes"}}
+]`
+	require.NoError(os.WriteFile(filepath.Join(datapath, "active_validators.json"), []byte(validators), 0o600))
+	airdrop, err := distribution(accounts, defaultDistriParams(), "")
+	require.NoError(err)
+
+	index, err := loadInspectIndex(context.Background(), datapath, dir, 0)
+
+	require.NoError(err)
+	defer index.Close()
+	for i, acc := range accounts {
+		indexed, audit, err := index.inspectAccount(context.Background(), acc.Address, defaultDistriParams(), "")
+		require.NoError(err)
+		assert.Equal(acc, indexed)
+		assert.Equal(airdrop.audit[i].FinalAmount, audit.FinalAmount, acc.Address)
+		assert.Equal(airdrop.audit[i].Multipliers, audit.Multipliers, acc.Address)
+	}
+	infos, err := index.validatorInfos(accounts[0])
+	require.NoError(err)
+	assert.Equal(validatorInfos{"val-no": {moniker: "Validator No", identity: "ABCD"}}, infos)
+	_, _, err = index.inspectAccount(context.Background(), "unknown", defaultDistriParams(), "")
+	assert.ErrorContains(err, "account unknown not found")
+	params := defaultDistriParams()
+	params.addressCap = sdk.NewInt(1)
+	_, _, err = index.inspectAccount(context.Background(), "direct", params, "")
+	assert.ErrorContains(err, "doesn't support")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestInspectDelegations(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		no  = govtypes.NewNonSplitVoteOption(govtypes.OptionNo)
+		yes = govtypes.NewNonSplitVoteOption(govtypes.OptionYes)
+	)
+	accounts := []Account{
+		{
+			Address: "inherited", LiquidAmount: sdk.NewDec(1_000), StakedAmount: sdk.NewDec(3_000),
+			Delegations: []Delegation{
+				{ValidatorAddress: "val-no", Amount: sdk.NewDec(1_000), Vote: no},
+				{ValidatorAddress: "val-dnv", Amount: sdk.NewDec(2_000)},
+			},
+		},
+		{
+			Address: "direct", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(2_000), Vote: yes,
+			Delegations: []Delegation{
+				{ValidatorAddress: "val-no", Amount: sdk.NewDec(1_000), Vote: no},
+				{ValidatorAddress: "val-yes", Amount: sdk.NewDec(1_000), Vote: yes},
+			},
+		},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100_000), StakedAmount: sdk.ZeroDec()},
+	}
+	infos := validatorInfos{"val-no": {moniker: "Validator No", identity: "ABCD"}}
+	airdrop, err := distribution(accounts, defaultDistriParams(), "")
+	require.NoError(err)
+
+	details, liquidAtone := inspectDelegations(accounts[0], airdrop.audit[0], infos)
+
+	require.Len(details, 2)
+	assert.Equal("Validator No", details[0].moniker)
+	assert.Equal("ABCD", details[0].identity)
+	// missing moniker
+	assert.Equal("-", details[1].moniker)
+	assert.False(details[0].overridden)
+	final := airdrop.audit[0].FinalAmount.ToLegacyDec()
+	total := details[0].atone.Add(details[1].atone).Add(liquidAtone)
+	assert.True(total.Sub(final).Abs().LT(sdk.NewDecWithPrec(1, 9)), total)
+	// The No delegation gets x9 while the DNV one gets the non-voters multiplier
+	assert.True(details[0].atone.GT(details[1].atone))
+
+	details, liquidAtone = inspectDelegations(accounts[1], airdrop.audit[1], infos)
+
+	require.Len(details, 2)
+	assert.True(details[0].overridden)
+	assert.False(details[1].overridden)
+	assert.True(liquidAtone.IsZero())
+	// Both delegations vote Yes with the direct vote
+	assert.Equal(details[0].atone, details[1].atone)
+	assert.Equal(sdk.NewDecWithPrec(5, 1), details[0].share)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// lspPropagation are the statistics of the propagation of the vote of a
+// liquid staking provider to its derivative holders.
+type lspPropagation struct {
+	lsp  string
+	vote govtypes.WeightedVoteOptions
+	// holders is the number of holders the stake was split among, zero if
+	// the provider didn't vote.
+	holders int
+	// added is the number of holders that weren't in the accounts.
+	added  int
+	staked sdk.Dec
+}
+
+// parseLSPHolders reads the snapshot of the derivative holders of the liquid
+// staking providers. The file is expected to be a JSON object mapping each
+// provider address to an object mapping its holder addresses, with any bech32
+// prefix, to their derivative amount:
+//
+//	{"cosmos1lsp...": {"stride1holder...": "1000", "cosmos1holder...": "250"}}
+func parseLSPHolders(path string) (_ map[string]map[string]sdk.Dec, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var holdersByLSP map[string]map[string]sdk.Dec
+	if err := json.NewDecoder(f).Decode(&holdersByLSP); err != nil {
+		return nil, fmt.Errorf("cannot json decode LSP holders from file %s: %w", path, err)
+	}
+	return holdersByLSP, nil
+}
+
+// propagateLSPVotes splits the stake of the liquid staking providers that
+// voted among their derivative holders, pro rata of their derivative amount,
+// so the holders inherit the vote of the provider (a second level of vote
+// inheritance) instead of being absent from the distribution. The split
+// delegations carry the vote of the provider, so it doesn't override the
+// inheritance of the other delegations of a holder, and the direct vote of a
+// holder takes precedence. The holders that aren't in accounts are added with
+// the prefix address. The liquid amount of the providers is left untouched,
+// as is the stake of the providers that didn't vote.
+func propagateLSPVotes(accounts []Account, holdersByLSP map[string]map[string]sdk.Dec, prefix string) ([]Account, []lspPropagation, error) {
+	idxByKey := make(map[addrKey]int, len(accounts))
+	for i, acc := range accounts {
+		if key, err := acc.addrKey(); err == nil {
+			idxByKey[key] = i
+		}
+	}
+	var props []lspPropagation
+	for _, lspAddr := range slices.Sorted(maps.Keys(holdersByLSP)) {
+		lspKey, err := newAddrKey(lspAddr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("liquid staking provider %s: %w", lspAddr, err)
+		}
+		i, ok := idxByKey[lspKey]
+		if !ok {
+			return nil, nil, fmt.Errorf("liquid staking provider %s isn't in the accounts", lspAddr)
+		}
+		p := lspPropagation{lsp: lspAddr, vote: accounts[i].Vote, staked: sdk.ZeroDec()}
+		if len(accounts[i].Vote) == 0 {
+			props = append(props, p)
+			continue
+		}
+		var (
+			holders []string
+			keys    []addrKey
+			total   = sdk.ZeroDec()
+		)
+		for _, h := range slices.Sorted(maps.Keys(holdersByLSP[lspAddr])) {
+			amt := holdersByLSP[lspAddr][h]
+			if amt.IsNil() || amt.IsNegative() {
+				return nil, nil, fmt.Errorf("liquid staking provider %s: invalid amount '%s' for holder %s", lspAddr, amt, h)
+			}
+			if amt.IsZero() {
+				continue
+			}
+			key, err := newAddrKey(h)
+			if err != nil {
+				return nil, nil, fmt.Errorf("liquid staking provider %s: holder %s: %w", lspAddr, h, err)
+			}
+			if key == lspKey {
+				return nil, nil, fmt.Errorf("liquid staking provider %s can't be its own holder", lspAddr)
+			}
+			holders = append(holders, h)
+			keys = append(keys, key)
+			total = total.Add(amt)
+		}
+		if !total.IsPositive() {
+			return nil, nil, fmt.Errorf("liquid staking provider %s has no holder amount", lspAddr)
+		}
+		lsp := accounts[i]
+		// given is the amount of each delegation already split
+		given := make([]sdk.Dec, len(lsp.Delegations))
+		for di := range given {
+			given[di] = sdk.ZeroDec()
+		}
+		for j, h := range holders {
+			k, ok := idxByKey[keys[j]]
+			if !ok {
+				k = len(accounts)
+				idxByKey[keys[j]] = k
+				accounts = append(accounts, Account{
+					Address:      keys[j].bech32(prefix),
+					LiquidAmount: sdk.ZeroDec(),
+					StakedAmount: sdk.ZeroDec(),
+					key:          keys[j],
+				})
+				p.added++
+			}
+			holder := &accounts[k]
+			holder.Delegations = slices.Clip(holder.Delegations)
+			for di, d := range lsp.Delegations {
+				amt := d.Amount.Mul(holdersByLSP[lspAddr][h]).Quo(total)
+				if j == len(holders)-1 {
+					// the last holder gets the remainder of the rounding
+					amt = d.Amount.Sub(given[di])
+				}
+				given[di] = given[di].Add(amt)
+				holder.Delegations = append(holder.Delegations, Delegation{
+					Amount:           amt,
+					ValidatorAddress: d.ValidatorAddress,
+					Vote:             lsp.Vote,
+				})
+				holder.StakedAmount = holder.StakedAmount.Add(amt)
+				p.staked = p.staked.Add(amt)
+			}
+			p.holders++
+		}
+		accounts[i].StakedAmount = sdk.ZeroDec()
+		accounts[i].Delegations = nil
+		props = append(props, p)
+	}
+	return accounts, props, nil
+}
+
+func printLSPPropagations(props []lspPropagation) {
+	table := newMarkdownTable("LIQUID STAKING PROVIDER", "VOTE", "HOLDERS", "ADDED", "STAKED")
+	for _, p := range props {
+		vote := "did not vote, not propagated"
+		if len(p.vote) > 0 {
+			vote = formatVote(p.vote)
+		}
+		table.Append([]string{
+			p.lsp,
+			vote,
+			humanCount(p.holders),
+			humanCount(p.added),
+			human(p.staked.TruncateInt()),
+		})
+	}
+	table.Render()
+	fmt.Println()
+}
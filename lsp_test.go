@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestPropagateLSPVotes(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs    = createAccountAddrs(5)
+		yes      = govtypes.NewNonSplitVoteOption(govtypes.OptionYes)
+		no       = govtypes.NewNonSplitVoteOption(govtypes.OptionNo)
+		accounts = []Account{
+			{
+				Address: addrs[0].String(), LiquidAmount: sdk.NewDec(10), StakedAmount: sdk.NewDec(150), Vote: yes,
+				Delegations: []Delegation{
+					{Amount: sdk.NewDec(100), ValidatorAddress: "val1"},
+					{Amount: sdk.NewDec(50), ValidatorAddress: "val2"},
+				},
+			},
+			{
+				Address: addrs[1].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(30),
+				Delegations: []Delegation{{Amount: sdk.NewDec(30), ValidatorAddress: "val1", Vote: no}},
+			},
+			{
+				// LSP that didn't vote
+				Address: addrs[3].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(40),
+				Delegations: []Delegation{{Amount: sdk.NewDec(40), ValidatorAddress: "val1", Vote: no}},
+			},
+		}
+		strideHolder = sdk.MustBech32ifyAddressBytes("stride", addrs[2])
+		holdersByLSP = map[string]map[string]sdk.Dec{
+			addrs[0].String(): {
+				addrs[1].String(): sdk.NewDec(1),
+				strideHolder:      sdk.NewDec(2),
+				addrs[4].String(): sdk.ZeroDec(),
+			},
+			addrs[3].String(): {addrs[1].String(): sdk.NewDec(1)},
+		}
+	)
+
+	accounts, props, err := propagateLSPVotes(accounts, holdersByLSP, "cosmos")
+
+	require.NoError(err)
+	require.Len(accounts, 4)
+	// the LSP keeps its liquid amount
+	assert.Equal(sdk.NewDec(10), accounts[0].LiquidAmount)
+	assert.True(accounts[0].StakedAmount.IsZero())
+	assert.Empty(accounts[0].Delegations)
+	third := sdk.NewDec(100).Quo(sdk.NewDec(3))
+	assert.Equal(sdk.NewDec(30).Add(third).Add(sdk.NewDec(50).Quo(sdk.NewDec(3))), accounts[1].StakedAmount)
+	assert.Equal([]Delegation{
+		{Amount: sdk.NewDec(30), ValidatorAddress: "val1", Vote: no},
+		{Amount: third, ValidatorAddress: "val1", Vote: yes},
+		{Amount: sdk.NewDec(50).Quo(sdk.NewDec(3)), ValidatorAddress: "val2", Vote: yes},
+	}, accounts[1].Delegations)
+	// the added holder gets the remainder, so the stake is fully split
+	assert.Equal(addrs[2].String(), accounts[3].Address)
+	assert.Equal(sdk.NewDec(100).Sub(third), accounts[3].Delegations[0].Amount)
+	assert.Equal(sdk.NewDec(150), accounts[1].StakedAmount.Sub(sdk.NewDec(30)).Add(accounts[3].StakedAmount))
+	assert.Equal(sdk.OneDec(), accounts[3].voteWeights()[govtypes.OptionYes])
+	// the LSP that didn't vote is untouched
+	assert.Equal(sdk.NewDec(40), accounts[2].StakedAmount)
+	// the providers are sorted by address
+	assert.ElementsMatch([]lspPropagation{
+		{lsp: addrs[0].String(), vote: yes, holders: 2, added: 1, staked: sdk.NewDec(150)},
+		{lsp: addrs[3].String(), staked: sdk.ZeroDec()},
+	}, props)
+
+	_, _, err = propagateLSPVotes(accounts, map[string]map[string]sdk.Dec{addrs[4].String(): {}}, "cosmos")
+	assert.ErrorContains(err, "isn't in the accounts")
+}
@@ -1,20 +1,29 @@
 package main
 
 import (
+	"bufio"
 	"context"
-	"encoding/csv"
+	"crypto/ecdh"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"iter"
 	"log"
 	"maps"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	dbm "github.com/cometbft/cometbft-db"
+	"github.com/peterbourgon/ff/v3"
 	"github.com/peterbourgon/ff/v3/ffcli"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -45,23 +54,91 @@ func main() {
 	// resAddr := []byte("\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0d\xa0")
 	// addr2 := sdk.MustBech32ifyAddressBytes("cosmos", resAddr)
 	// fmt.Println(addr2)
+	rootFs := flag.NewFlagSet("govbox", flag.ExitOnError)
+	locale := rootFs.String("locale", "en", "Locale of the numbers in the tables and reports (en, fr, de, es, it, pt, ch, ja)")
+	machine := rootFs.Bool("machine", false, "Print exact numbers without humanization (no digit grouping, no % sign), for scripts")
+	ci := rootFs.Bool("ci", false, "CI mode: file-only outputs (never opens a browser), machine-readable numbers and warnings (JSON lines on stderr), and exit code 3 on warnings of -failOn severity")
+	progressUI := rootFs.String("progressUI", "", "Listen address (e.g. localhost:8765) of a local web page showing the progress of the run: stages, throughput, ETA and intermediate aggregates")
+	noChecksumFlag := rootFs.Bool("noChecksum", false, "Read the input URLs without a .sha256 checksum unverified, with a warning, instead of failing")
+	failOn := rootFs.String("failOn", severityWarning, "With -ci, minimum severity of the warnings that fail the run (info, warning or error)")
 	rootCmd := &ffcli.Command{
-		ShortUsage: "govbox <subcommand> <path>",
+		ShortUsage: "govbox [-locale <locale>] [-machine] [-ci] [-progressUI <addr>] <subcommand> <path>",
 		ShortHelp:  "Set of commands for GovGen proposals.",
+		FlagSet:    rootFs,
+		Options:    []ff.Option{ff.WithEnvVarPrefix("GOVBOX")},
 		Subcommands: []*ffcli.Command{
-			tallyCmd(), accountsCmd(), genesisCmd(), autoStakingCmd(),
+			tallyCmd(), accountsCmd(), genesisCmd(), patchGenesisCmd(), autoStakingCmd(),
 			distributionCmd(), top20Cmd(), propJSONCmd(),
 			signTxCmd(), vestingCmd(), depositThrottlingCmd(),
-			tallyGenesisCmd(), shrinkVotesCmd(),
+			tallyGenesisCmd(), shrinkVotesCmd(), overridesCmd(),
+			checkAddressesCmd(), sybilCmd(), reportCmd(), compareOfficialCmd(),
+			importDBCmd(),
+			importTxVotesCmd(),
+			sendTxsCmd(),
+			exportVotesCmd(),
+			inspectCmd(),
+			optimizeCmd(),
+			photonCmd(),
+			validatorOverlapCmd(),
+			compareRunsCmd(),
+			openSealedCmd(),
+			snapshotsCmd(),
+			attributionCmd(),
+			schemasCmd(),
+			validateCmd(),
+			splitExportCmd(),
+			signCmd(),
+			verifySignatureCmd(),
 		},
 		Exec: func(ctx context.Context, args []string) error {
 			return flag.ErrHelp
 		},
 	}
-	err := rootCmd.ParseAndRun(context.Background(), os.Args[1:])
+	// Ctrl-C cancels ctx, which stops the long-running stages and prevents
+	// incomplete output files from being written.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err := rootCmd.Parse(os.Args[1:])
+	if err == nil {
+		ciMode = *ci
+		noChecksum = *noChecksumFlag
+		err = setNumberFormat(*locale, *machine || ciMode)
+	}
+	if err == nil && ciMode {
+		_, err = parseSeverity(*failOn)
+	}
+	if err == nil && *progressUI != "" {
+		progress = newProgressTracker()
+		var url string
+		url, err = serveProgressUI(ctx, *progressUI, progress)
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "Progress UI on %s\n", url)
+		}
+	}
+	if err == nil {
+		err = rootCmd.Run(ctx)
+	}
+	if ciMode {
+		if err := warnings.printJSON(os.Stderr); err != nil {
+			log.Print(err)
+		}
+	} else {
+		warnings.print(os.Stderr)
+	}
+	if errors.Is(err, context.Canceled) {
+		stop()
+		fmt.Fprintln(os.Stderr, "interrupted, no output file written by the interrupted stage")
+		os.Exit(130)
+	}
 	if err != nil && err != flag.ErrHelp {
 		log.Fatal(err)
 	}
+	if ciMode {
+		if kinds := warnings.reaches(*failOn); len(kinds) > 0 {
+			fmt.Fprintf(os.Stderr, "%d kind(s) of warnings of severity %s or above\n", len(kinds), *failOn)
+			os.Exit(exitWarnings)
+		}
+	}
 }
 
 func shrinkVotesCmd() *ffcli.Command {
@@ -126,51 +203,129 @@ func tallyCmd() *ffcli.Command {
 				return flag.ErrHelp
 			}
 			datapath := args[0]
-			votesByAddr, err := parseVotesByAddr(datapath)
+			votesByAddr, err := parseVotesByAddr(ctx, datapath)
+			if err != nil {
+				return err
+			}
+			valsByAddr, err := parseValidatorsByAddr(ctx, datapath, votesByAddr)
 			if err != nil {
 				return err
 			}
-			valsByAddr, err := parseValidatorsByAddr(datapath, votesByAddr)
+			delegsByAddr, err := parseDelegationsByAddr(ctx, datapath)
 			if err != nil {
 				return err
 			}
-			delegsByAddr, err := parseDelegationsByAddr(datapath)
+			prop, err := parseProp(datapath)
 			if err != nil {
 				return err
 			}
 			results, totalVotingPower := tally(votesByAddr, valsByAddr, delegsByAddr)
-			printTallyResults(results, totalVotingPower, parseProp(datapath))
+			printTallyResults(results, totalVotingPower, prop)
 			return nil
 		},
 	}
 }
 
 func accountsCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("accounts", flag.ContinueOnError)
+	strict := fs.Bool("strict", false, "Refuse to proceed if the input files contain invalid bech32 addresses")
+	governorsFile := fs.String("governors", "", "JSON file mapping delegators to governors, the non-voters inherit the vote of their governor instead of their validators")
+	signalsFile := fs.String("signals", "", "CSV file of signed off-chain signal votes, counted for the addresses that didn't vote on-chain")
+	signal := fs.String("signal", "", "Identifier of the off-chain signal, part of the data signed by the voters of -signals")
+	icaControllersFile := fs.String("icaControllers", "", "JSON file mapping the ICA owners of the controller chains to the addresses receiving the allocation of their interchain accounts")
+	vestingLock := fs.Bool("vestingLock", false, "Move the amounts still vesting from the liquid amount of the vesting accounts to their locked amount")
+	snapshotTimeFlag := fs.String("snapshotTime", "", "With -vestingLock, RFC3339 block time of the snapshot at which the vesting amounts are computed, default to the voting end time of <path>/prop.json")
+	vestingTime := fs.String("vestingTime", "", "Deprecated alias of -snapshotTime")
+	mergeChains := fs.String("mergeChains", "", "Comma-separated list of the paths of other chains snapshots, whose accounts.json are merged into the accounts of the same key")
+	lspHolders := fs.String("lspHolders", "", "JSON file of the derivative holders of the liquid staking providers, among which the stake of the providers that voted is split with their vote")
+	duplicateVotes := fs.String("duplicateVotes", duplicateVotesKeepLast, "What to do with the voters with several entries in votes.json (keep-last, keep-first or error)")
 	return &ffcli.Command{
 		Name:       "accounts",
 		ShortUsage: "govbox accounts <path>",
 		ShortHelp:  "Consolidate the data in <path> into a single file <path>/accounts.json",
+		LongHelp: `Also writes <path>/validators_mapping.json, which maps the operator address
+of each validator to its account address, moniker, identity and vote.
+
+With -governors, the vote inheritance follows the governance delegations of
+AtomOne instead of the validator delegations.
+
+With -signals, the off-chain signal votes of a CSV file with the header
+voter,option,pubkey,signature are added to the on-chain votes. The signature is
+the base64 ADR-036 (signArbitrary) signature of '<signal>:<option>', e.g.
+'prop848:VOTE_OPTION_YES', and the invalid signatures are ignored. The on-chain
+vote of an address takes precedence over its off-chain signal.
+
+The interchain accounts (ICA) are ignored, unless their owner is mapped by
+-icaControllers: their balance, delegations and vote are then moved to the
+recipient address supplied by the controller chain. The owner and controller
+chain prefix of each ICA are listed in a table.
+
+With -vestingLock, the part of the balance of the vesting accounts that is
+still vesting (minus the delegated vesting, already staked) is moved from
+their LiquidAmount to their LockedAmount, which the distribution command
+multiplies according to -lockedMultiplier.
+
+With -mergeChains, the accounts.json files of the snapshots of other chains
+are merged by key: the addresses of the same pubkey with different bech32
+prefixes are one account, whose Sources keep the amounts of each chain. The
+accounts only present on the other chains are added with the cosmos prefix.
+The votes of the other chains are ignored, and their delegations are tagged
+with their chain: they count as not voting, even for a direct voter of the
+hub, and their validators aren't part of the hub validators.
+
+Some exports contain several votes for the same voter. -duplicateVotes keeps
+the last one (keep-last, the latest vote of an export ordered by height), the
+first one (keep-first), or refuses the export (error). The duplicates are
+counted in the warnings.
+
+With -lspHolders, the stake of the liquid staking providers that voted is
+split among their derivative holders, pro rata of their derivative amount
+in the holder snapshot. The holders inherit the vote of the provider on that
+stake (a second level of vote inheritance) instead of being absent from the
+distribution, and their own direct vote takes precedence. The holders of
+other chains are added with the cosmos prefix.`,
+		FlagSet: fs,
 		Exec: func(ctx context.Context, args []string) error {
 			if len(args) == 0 {
 				return flag.ErrHelp
 			}
 			var (
 				datapath     = args[0]
-				accountsFile = filepath.Join(datapath, "accounts.json")
+				accountsFile = filepath.Join(outputDir(datapath), "accounts.json")
 			)
-			votesByAddr, err := parseVotesByAddr(datapath)
+			if err := checkInputSchemas(datapath); err != nil {
+				return err
+			}
+			if *strict {
+				issues, err := checkAddresses(datapath, "cosmos")
+				if err != nil {
+					return err
+				}
+				if len(issues) > 0 {
+					printAddrIssues(issues)
+					return fmt.Errorf("strict mode: %d invalid address(es), run `%s check-addresses` to fix them", len(issues), os.Args[0])
+				}
+			}
+			if err := validateDuplicateVotesPolicy(*duplicateVotes); err != nil {
+				return err
+			}
+			sources := []voteSource{govVoteSource{datapath: datapath, duplicates: *duplicateVotes}}
+			if *signalsFile != "" {
+				sources = append(sources, signalVoteSource{file: *signalsFile, signal: *signal})
+			}
+			votesByAddr, err := collectVotes(ctx, sources...)
 			if err != nil {
 				return err
 			}
-			valsByAddr, err := parseValidatorsByAddr(datapath, votesByAddr)
+			valsByAddr, err := parseValidatorsByAddr(ctx, datapath, votesByAddr)
 			if err != nil {
 				return err
 			}
-			delegsByAddr, err := parseDelegationsByAddr(datapath)
+			delegsByAddr, err := parseDelegationsByAddr(ctx, datapath)
 			if err != nil {
 				return err
 			}
-			balancesByAddr, err := parseBalancesByAddr(datapath, "uatom")
+			balancesByAddr, err := parseBalancesByAddr(ctx, datapath, "uatom")
 			if err != nil {
 				return err
 			}
@@ -178,28 +333,193 @@ func accountsCmd() *ffcli.Command {
 			if err != nil {
 				return err
 			}
+			supply, ok, err := parseSupply(datapath, "uatom")
+			if err != nil {
+				return err
+			}
+			if ok {
+				if err := reconcileSupply(balancesByAddr, accountTypesByAddr, supply); err != nil {
+					return err
+				}
+				fmt.Printf("balances match the total supply of %s\n", human(supply))
+			}
+			icas, err := parseInterchainAccounts(datapath)
+			if err != nil {
+				return err
+			}
+			if *icaControllersFile != "" {
+				recipientByOwner, err := parseICAControllers(*icaControllersFile)
+				if err != nil {
+					return err
+				}
+				redirected, err := redirectInterchainAccounts(icas, recipientByOwner, delegsByAddr, votesByAddr, balancesByAddr, accountTypesByAddr)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%d/%d interchain accounts redirected to their controller recipient\n", redirected, len(icas))
+			}
+			if len(icas) > 0 {
+				printInterchainAccounts(os.Stdout, icas)
+			}
 
-			accounts := getAccounts(delegsByAddr, votesByAddr, valsByAddr, balancesByAddr, accountTypesByAddr)
+			accounts, err := getAccounts(ctx, delegsByAddr, votesByAddr, valsByAddr, balancesByAddr, accountTypesByAddr, "cosmos")
+			if err != nil {
+				return err
+			}
+			if *governorsFile != "" {
+				govByDelegator, err := parseGovernorDelegations(*governorsFile)
+				if err != nil {
+					return err
+				}
+				delegating, err := applyGovernorDelegations(accounts, govByDelegator, votesByAddr)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%d accounts inherit the vote of a governor\n", delegating)
+			}
+			if *vestingLock {
+				if *snapshotTimeFlag == "" {
+					*snapshotTimeFlag = *vestingTime
+				}
+				t, err := snapshotTime(datapath, *snapshotTimeFlag)
+				if err != nil {
+					return err
+				}
+				lockedByAddr, err := parseLockedByAddr(datapath, "uatom", t)
+				if err != nil {
+					return err
+				}
+				locked := applyVestingLocks(accounts, lockedByAddr)
+				fmt.Printf("%d accounts with a locked vesting amount at %s\n", locked, t.Format(time.RFC3339))
+			}
+			if *mergeChains != "" {
+				var chains [][]Account
+				for _, path := range strings.Split(*mergeChains, ",") {
+					chainAccounts, err := parseAccounts(joinInput(path, "accounts.json"))
+					if err != nil {
+						return err
+					}
+					chains = append(chains, chainAccounts)
+				}
+				var merges []chainMerge
+				accounts, merges = mergeChainAccounts(accounts, chains, "cosmos")
+				printChainMerges(merges)
+			}
+			if *lspHolders != "" {
+				holdersByLSP, err := parseLSPHolders(*lspHolders)
+				if err != nil {
+					return err
+				}
+				var props []lspPropagation
+				accounts, props, err = propagateLSPVotes(accounts, holdersByLSP, "cosmos")
+				if err != nil {
+					return err
+				}
+				printLSPPropagations(props)
+			}
 
 			bz, err := json.MarshalIndent(accounts, "", "  ")
 			if err != nil {
 				return err
 			}
-			if err := os.WriteFile(accountsFile, bz, 0o666); err != nil {
+			if err := writeFile(ctx, accountsFile, bz); err != nil {
 				return err
 			}
 			fmt.Printf("%s file created.\n", accountsFile)
 
+			mappings, err := parseValidatorMappings(ctx, datapath, votesByAddr)
+			if err != nil {
+				return err
+			}
+			mappingsFile := filepath.Join(outputDir(datapath), "validators_mapping.json")
+			if err := writeValidatorMappings(ctx, mappingsFile, mappings); err != nil {
+				return err
+			}
+			fmt.Printf("%s file created.\n", mappingsFile)
+
+			return nil
+		},
+	}
+}
+
+func checkAddressesCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("check-addresses", flag.ContinueOnError)
+	prefix := fs.String("prefix", "cosmos", "Expected bech32 prefix of the addresses")
+	fixCase := fs.Bool("fix-case", false, "Rewrite the input files with mixed case addresses lower cased, when it makes them valid")
+	strict := fs.Bool("strict", false, "Return an error if invalid addresses remain")
+	return &ffcli.Command{
+		Name:       "check-addresses",
+		ShortUsage: "govbox check-addresses <path>",
+		ShortHelp:  "Validate the bech32 addresses of all the input files in <path>",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return flag.ErrHelp
+			}
+			datapath := args[0]
+			issues, err := checkAddresses(datapath, *prefix)
+			if err != nil {
+				return err
+			}
+			printAddrIssues(issues)
+			if *fixCase {
+				numFixed, err := fixAddresses(ctx, datapath, issues)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%d address(es) fixed\n", numFixed)
+				issues = slices.DeleteFunc(issues, func(i addrIssue) bool { return i.fix != "" })
+			}
+			if *strict && len(issues) > 0 {
+				return fmt.Errorf("strict mode: %d invalid address(es)", len(issues))
+			}
 			return nil
 		},
 	}
 }
 
 func genesisCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("genesis", flag.ContinueOnError)
+	profileName := fs.String("profile", "", "Use the parameters of a known historic run (prop848, govgen)")
+	entitiesFile := fs.String("entities", "", "JSON file of entity groups and their policies, replaces the default ICF slash")
+	optOutFile := fs.String("optout", "", "JSON file of addresses that declined the airdrop")
+	optOutPolicy := fs.String("optoutPolicy", optOutPolicyCommunityPool, "What to do with the opted-out allocations (community-pool or burn)")
+	voterFloor := fs.String("voterFloor", "0", "Minimum $ATONE allocation of the addresses that voted Yes, No or NoWithVeto")
+	voterFloorPool := fs.String("voterFloorPool", voterFloorPoolCommunityPool, "Pool that funds the voter floor (community-pool or reserved-address)")
+	addressCap := fs.String("addressCap", "0", "Maximum $ATONE allocation per address, the excess is removed from the supply")
+	targetSupply := fs.String("targetSupply", "0", "Final $ATONE supply, the supplyFactor is solved to reach it instead of being set, 0 disables it")
+	yesRatio := fs.String("yesRatio", "0", "Ratio of the per-$ATOM allocation of the Yes voters over the one of the non-voters, the Yes multiplier is solved to reach it instead of being set, 0 disables it")
+	stakingIncentives := fs.String("stakingIncentives", "0", "Share of the minted supply (0 to 1) sent to the staking_incentives module account to reward the early stakers, instead of the community pool and the reserved address")
+	typeMultipliersFile := fs.String("typeMultipliers", "", "JSON file of multipliers per account type (type URL or short name), composed with the vote multipliers")
+	voteTimesFile := fs.String("voteTimes", "", "JSON file of the vote txs timestamps exported from an indexer ([{\"voter\":\"cosmos1...\",\"timestamp\":\"...\"}]), rewards the early direct voters with a bonus multiplier")
+	voteTimeDecay := fs.String("voteTimeDecay", voteTimeDecayLinear, "With -voteTimes, decay curve of the bonus over the voting period (linear or exponential)")
+	voteTimeBonus := fs.String("voteTimeBonus", "0.05", "With -voteTimes, bonus of the votes cast at the start of the voting period")
+	voteTimeHalfLife := fs.Duration("voteTimeHalfLife", 72*time.Hour, "With -voteTimes and the exponential decay, duration after which the bonus is halved")
+	hookCmd := fs.String("hook", "", "Shell command invoked with one JSON line per account on stdin, answering one JSON line per account to exclude it ({\"exclude\":true}) or adjust it ({\"multiplier\":\"0.5\"})")
+	dustPolicy := fs.String("dustPolicy", dustPolicyNone, "What to do with the amount lost by the rounding of the allocations to uatone (none or community-pool)")
+	voteBucketsFile := fs.String("voteBuckets", "", "JSON file mapping vote options to the aligned, opposed and neutral buckets (default to the prop848 interpretation)")
+	shareBoundsFile := fs.String("shareBounds", "", "JSON file of min and max shares of the distribution per vote category ({\"yes\":{\"min\":\"0.05\"}}), enforced by normalizing the allocations")
+	abstainMultiplier := fs.String("abstainMultiplier", "", "Multiplier of the Abstain votes, instead of the nonVotersMultiplier shared with the non-voters")
+	abstainBonusMalus := fs.String("abstainBonusMalus", "", "Bonus (>1) or malus (<1) of the Abstain votes")
+	lockedMultiplier := fs.String("lockedMultiplier", "", "Factor of the liquid multiplier for the locked vesting amounts of the accounts (see accounts -vestingLock), 0 excludes them, default to the liquid multiplier")
+	compact := fs.Bool("compact", false, compactFlagUsage)
+	gentxDir := fs.String("gentxs", "", "Directory of gentx files used to bootstrap the initial validator set")
+	screenList := fs.String("screenList", "", "JSON file of addresses to screen the airdrop against ([{\"address\":\"cosmos1...\",\"reason\":\"...\"}]), the flagged addresses are reported in <path>/screening_report.csv for a manual review")
+	screenCache := fs.String("screenCache", "", "With -screenList, JSON file caching the screening results across runs")
+	extraDenomFile := fs.String("extraDenom", "", "JSON file of the rules of an additional denom (e.g. a gas token) distributed from the same accounts, like {\"ticker\":\"photon\",\"noVotesMultiplier\":\"1\"}, the unset parameters keep their $ATONE value")
+	xlsxMode := fs.Bool("xlsx", false, "Also outputs <path>/airdrop.xlsx, a spreadsheet workbook of the $ATONE airdrop (summary, votes, top holders and detail per address)")
+	xlsxMaxRows := fs.Int("xlsxMaxRows", 100_000, "With -xlsx, maximum number of addresses of the detail sheet, the largest allocations are kept")
+	chain := fs.String("chain", "", "Name of the chain in the chain-registry (e.g. atomone), configures the bech32 prefix and the denoms metadata")
+	chainRegistry := fs.String("chainRegistry", defaultChainRegistry, "With -chain, URL or local directory of the chain-registry")
+	genesisConfig := fs.String("genesisConfig", "", "JSON file of the chain_id, genesis_time and initial_height of the genesis, the unset ones keep the values of <genesis.json>")
+	chainID := fs.String("chainID", "", "Chain id of the genesis, overrides the one of -genesisConfig, must match the chain of the bech32 prefix (e.g. atomone-1)")
+	genesisTime := fs.String("genesisTime", "", "RFC3339 genesis time, overrides the one of -genesisConfig")
+	initialHeight := fs.Int64("initialHeight", 0, "Initial height of the genesis, overrides the one of -genesisConfig")
 	return &ffcli.Command{
 		Name:       "genesis",
 		ShortUsage: "govbox genesis <genesis.json> <path>",
 		ShortHelp:  "Outputs an updated version of <genesis.json> with the airdrop",
+		FlagSet:    fs,
 		Exec: func(ctx context.Context, args []string) error {
 			if len(args) != 2 {
 				return flag.ErrHelp
@@ -207,17 +527,256 @@ func genesisCmd() *ffcli.Command {
 			var (
 				genesisFile  = args[0]
 				datapath     = args[1]
-				accountsFile = filepath.Join(datapath, "accounts.json")
+				accountsFile = joinInput(datapath, "accounts.json")
+				params       = defaultDistriParams()
 			)
-			accounts, err := parseAccounts(accountsFile)
+			if *profileName != "" {
+				p, err := getProfile(*profileName)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Using profile %s\n", p)
+				params = p.params
+			}
+			if *entitiesFile != "" {
+				groups, err := parseEntityGroups(*entitiesFile)
+				if err != nil {
+					return err
+				}
+				params.entityGroups = groups
+			}
+			if *optOutFile != "" {
+				addrs, err := parseOptOut(*optOutFile)
+				if err != nil {
+					return err
+				}
+				params.optOut = addrs
+				params.optOutPolicy = *optOutPolicy
+			}
+			floor, err := sdk.NewDecFromStr(*voterFloor)
+			if err != nil {
+				return fmt.Errorf("invalid voterFloor '%s': %w", *voterFloor, err)
+			}
+			params.voterFloor = floor.MulInt64(M).TruncateInt()
+			params.voterFloorPool = *voterFloorPool
+			params.dustPolicy = *dustPolicy
+			if *typeMultipliersFile != "" {
+				m, err := parseTypeMultipliers(*typeMultipliersFile)
+				if err != nil {
+					return err
+				}
+				params.typeMultipliers = m
+			}
+			if *voteTimesFile != "" {
+				params.voteTiming, err = loadVoteTiming(datapath, *voteTimesFile, *voteTimeDecay, *voteTimeBonus, *voteTimeHalfLife)
+				if err != nil {
+					return err
+				}
+			}
+			if *hookCmd != "" {
+				hook, err := startExecHook(ctx, *hookCmd)
+				if err != nil {
+					return err
+				}
+				defer hook.Close()
+				params.hook = hook
+			}
+			capAmt, err := sdk.NewDecFromStr(*addressCap)
+			if err != nil {
+				return fmt.Errorf("invalid addressCap '%s': %w", *addressCap, err)
+			}
+			params.addressCap = capAmt.MulInt64(M).TruncateInt()
+			targetAmt, err := sdk.NewDecFromStr(*targetSupply)
+			if err != nil {
+				return fmt.Errorf("invalid targetSupply '%s': %w", *targetSupply, err)
+			}
+			params.targetSupply = targetAmt.MulInt64(M).TruncateInt()
+			params.yesRatio, err = parseYesRatio(*yesRatio)
+			if err != nil {
+				return err
+			}
+			params.stakingIncentivesPerc, err = parseStakingIncentivesPerc(*stakingIncentives)
+			if err != nil {
+				return err
+			}
+			if *voteBucketsFile != "" {
+				buckets, err := parseVoteBuckets(*voteBucketsFile)
+				if err != nil {
+					return err
+				}
+				params.voteBuckets = buckets
+			}
+			if *shareBoundsFile != "" {
+				bounds, err := parseShareBounds(*shareBoundsFile)
+				if err != nil {
+					return err
+				}
+				params.shareBounds = bounds
+			}
+			params.abstainMultiplier, params.abstainBonusMalus, err = parseAbstainParams(*abstainMultiplier, *abstainBonusMalus)
+			if err != nil {
+				return err
+			}
+			params.lockedMultiplier, err = parseLockedMultiplier(*lockedMultiplier)
+			if err != nil {
+				return err
+			}
+			prefix := "atone"
+			var chainCfg *chainConfig
+			if *chain != "" {
+				c, err := loadChainConfig(ctx, *chainRegistry, *chain)
+				if err != nil {
+					return err
+				}
+				chainCfg = &c
+				prefix = c.prefix
+			}
+			var tmpl genesisTemplate
+			if *genesisConfig != "" {
+				tmpl, err = parseGenesisTemplate(*genesisConfig)
+				if err != nil {
+					return err
+				}
+			}
+			if *chainID != "" {
+				tmpl.ChainID = *chainID
+			}
+			if *genesisTime != "" {
+				tmpl.GenesisTime, err = time.Parse(time.RFC3339, *genesisTime)
+				if err != nil {
+					return fmt.Errorf("invalid genesisTime '%s': %w", *genesisTime, err)
+				}
+			}
+			if *initialHeight != 0 {
+				tmpl.InitialHeight = *initialHeight
+			}
+			var chainName string
+			if tmpl.ChainID != "" {
+				chainName, err = templateChainName(prefix, chainCfg)
+				if err != nil {
+					return err
+				}
+			}
+			if err := tmpl.validate(chainName); err != nil {
+				return err
+			}
+			accounts, err := loadAccounts(ctx, accountsFile, *compact)
+			if err != nil {
+				return err
+			}
+			airdrop, err := distributionSeq(ctx, accounts, params, prefix)
+			if err != nil {
+				return err
+			}
+			if airdrop.params.hasYesRatio() {
+				printYesRatio(os.Stderr, airdrop)
+			}
+			if airdrop.params.hasTargetSupply() {
+				printTargetSupply(os.Stderr, airdrop)
+			}
+			if *screenList != "" {
+				var screener addressScreener
+				screener, err = parseScreeningList(*screenList)
+				if err != nil {
+					return err
+				}
+				var cache *cachedScreener
+				if *screenCache != "" {
+					cache, err = newCachedScreener(screener, *screenCache)
+					if err != nil {
+						return err
+					}
+					screener = cache
+				}
+				flagged, err := screenAirdrop(ctx, screener, airdrop)
+				if err != nil {
+					return err
+				}
+				if cache != nil {
+					if err := cache.save(ctx); err != nil {
+						return err
+					}
+				}
+				printScreening(flagged, len(airdrop.addresses))
+				reportFile := filepath.Join(outputDir(datapath), "screening_report.csv")
+				if err := writeScreeningReport(ctx, reportFile, flagged); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "'%s' has been created/updated, review the flagged addresses\n", reportFile)
+			}
+			denoms := []genesisDenom{atoneDenom(airdrop)}
+			if *extraDenomFile != "" {
+				rules, err := parseDenomRules(*extraDenomFile)
+				if err != nil {
+					return err
+				}
+				extraAirdrop, err := distributionSeq(ctx, accounts, rules.apply(airdrop.params), prefix)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "%s: %s distributed, %s community pool, %s reserved address\n",
+					strings.ToUpper(rules.Ticker), humand(extraAirdrop.atone.supply),
+					humand(extraAirdrop.communityPool), humand(extraAirdrop.reservedAddr))
+				denoms = append(denoms, rules.denom(extraAirdrop))
+			}
+			if chainCfg != nil {
+				denoms, err = chainCfg.apply(denoms)
+				if err != nil {
+					return err
+				}
+			}
+			if *xlsxMode {
+				workbookFile := filepath.Join(outputDir(datapath), "airdrop.xlsx")
+				if err := writeAirdropWorkbook(ctx, workbookFile, airdrop, *xlsxMaxRows); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", workbookFile)
+			}
+			return writeGenesis(ctx, genesisFile, denoms, prefix, *gentxDir, tmpl)
+		},
+	}
+}
+
+func patchGenesisCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("patch-genesis", flag.ContinueOnError)
+	offset := fs.String("offset", correctionOffsetCommunityPool, "Where the difference of the corrected balances is taken from or sent to (community-pool or supply)")
+	changelogFile := fs.String("changelog", "genesis_changelog.md", "Markdown file of the changelog of the corrected addresses")
+	return &ffcli.Command{
+		Name:       "patch-genesis",
+		ShortUsage: "govbox patch-genesis <genesis.json> <corrections.json>",
+		ShortHelp:  "Outputs a published <genesis.json> with only the balances of <corrections.json> modified",
+		LongHelp: `Applies a handful of corrections to a genesis published by the genesis
+command, keeping every other byte identical, so the diff with the published
+genesis is minimal and can be reviewed.
+
+<corrections.json> is an array of corrected balances, like
+[{"address":"atone1...","coins":"1000uatone","reason":"missed exchange tag"}].
+An empty "coins" removes the account. The addresses can use any bech32 prefix.`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 2 {
+				return flag.ErrHelp
+			}
+			published, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("readfile %s: %w", args[0], err)
+			}
+			corrections, err := parseGenesisCorrections(args[1])
+			if err != nil {
+				return err
+			}
+			patch, err := patchGenesis(os.Stdout, published, corrections, *offset)
 			if err != nil {
 				return err
 			}
-			airdrop, err := distribution(accounts, defaultDistriParams(), "atone")
+			err = writeOutputFile(ctx, *changelogFile, func(w io.Writer) error {
+				return writeGenesisChangelog(w, args[0], patch)
+			})
 			if err != nil {
 				return err
 			}
-			return writeGenesis(genesisFile, airdrop)
+			fmt.Fprintf(os.Stderr, "⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", *changelogFile)
+			return nil
 		},
 	}
 }
@@ -241,9 +800,67 @@ func autoStakingCmd() *ffcli.Command {
 func distributionCmd() *ffcli.Command {
 	fs := flag.NewFlagSet("distribution", flag.ContinueOnError)
 	chartMode := fs.Bool("chart", false, "Outputs a chart instead of Markdown tables")
+	chartFormat := fs.String("chartExport", "", "With -chart, export the charts as images instead of opening the browser (png or svg)")
+	chartDir := fs.String("chartDir", ".", "Directory where the charts are exported")
+	chartThemeFile := fs.String("chartTheme", "", "JSON file configuring the labels and colors of the charts, and the categories to merge (e.g. No and NWV)")
+	sections := fs.String("sections", "all", "Comma-separated list of the sections of the report (summary, per-vote, concentration, validators, exclusions), or all")
+	reportFormat := fs.String("reportFormat", reportFormatTerminal, "Format of the report (terminal, markdown, or json and html with -reportFile)")
+	reportFile := fs.String("reportFile", "", "Write the report in this file instead of the standard output")
 	yesMultipliers := fs.String("yesMultipliers", "1", "List of possible comma-seperated Yes multipliers")
 	noMultipliers := fs.String("noMultipliers", "9", "List of possible comma-separated No multipliers")
 	prefix := fs.String("prefix", "", "Cosmos address prefix (by default it is unchanged: \"cosmos\")")
+	auditFile := fs.String("audit", "", "Outputs in this file one JSON line per account with the decisions taken by the distribution")
+	profileName := fs.String("profile", "", "Use the parameters of a known historic run (prop848, govgen), explicit flags take precedence")
+	entitiesFile := fs.String("entities", "", "JSON file of entity groups and their policies, replaces the default ICF slash")
+	optOutFile := fs.String("optout", "", "JSON file of addresses that declined the airdrop")
+	optOutPolicy := fs.String("optoutPolicy", optOutPolicyCommunityPool, "What to do with the opted-out allocations (community-pool or burn)")
+	voterFloor := fs.String("voterFloor", "0", "Minimum $ATONE allocation of the addresses that voted Yes, No or NoWithVeto")
+	voterFloorPool := fs.String("voterFloorPool", voterFloorPoolCommunityPool, "Pool that funds the voter floor (community-pool or reserved-address)")
+	addressCap := fs.String("addressCap", "0", "Maximum $ATONE allocation per address, the excess is removed from the supply")
+	targetSupply := fs.String("targetSupply", "0", "Final $ATONE supply, the supplyFactor is solved to reach it instead of being set, 0 disables it")
+	yesRatio := fs.String("yesRatio", "0", "Ratio of the per-$ATOM allocation of the Yes voters over the one of the non-voters, the Yes multiplier is solved to reach it instead of being set, 0 disables it")
+	stakingIncentives := fs.String("stakingIncentives", "0", "Share of the minted supply (0 to 1) sent to the staking_incentives module account to reward the early stakers, instead of the community pool and the reserved address")
+	typeMultipliersFile := fs.String("typeMultipliers", "", "JSON file of multipliers per account type (type URL or short name), composed with the vote multipliers")
+	voteTimesFile := fs.String("voteTimes", "", "JSON file of the vote txs timestamps exported from an indexer ([{\"voter\":\"cosmos1...\",\"timestamp\":\"...\"}]), rewards the early direct voters with a bonus multiplier")
+	voteTimeDecay := fs.String("voteTimeDecay", voteTimeDecayLinear, "With -voteTimes, decay curve of the bonus over the voting period (linear or exponential)")
+	voteTimeBonus := fs.String("voteTimeBonus", "0.05", "With -voteTimes, bonus of the votes cast at the start of the voting period")
+	voteTimeHalfLife := fs.Duration("voteTimeHalfLife", 72*time.Hour, "With -voteTimes and the exponential decay, duration after which the bonus is halved")
+	hookCmd := fs.String("hook", "", "Shell command invoked with one JSON line per account on stdin, answering one JSON line per account to exclude it ({\"exclude\":true}) or adjust it ({\"multiplier\":\"0.5\"})")
+	withholdingList := fs.String("withholding", "", "JSON file of the addresses whose allocation is partly withheld to an escrow address, with their rate, also outputs <path>/airdrop_withholding.json")
+	dustPolicy := fs.String("dustPolicy", dustPolicyNone, "What to do with the amount lost by the rounding of the allocations to uatone (none or community-pool)")
+	voteBucketsFile := fs.String("voteBuckets", "", "JSON file mapping vote options to the aligned, opposed and neutral buckets (default to the prop848 interpretation)")
+	shareBoundsFile := fs.String("shareBounds", "", "JSON file of min and max shares of the distribution per vote category ({\"yes\":{\"min\":\"0.05\"}}), enforced by normalizing the allocations")
+	abstainMultiplier := fs.String("abstainMultiplier", "", "Multiplier of the Abstain votes, instead of the nonVotersMultiplier shared with the non-voters")
+	abstainBonusMalus := fs.String("abstainBonusMalus", "", "Bonus (>1) or malus (<1) of the Abstain votes")
+	lockedMultiplier := fs.String("lockedMultiplier", "", "Factor of the liquid multiplier for the locked vesting amounts of the accounts (see accounts -vestingLock), 0 excludes them, default to the liquid multiplier")
+	compact := fs.Bool("compact", false, compactFlagUsage)
+	columnStoreDir := fs.String("columnStore", "", "Read the accounts from a memory-mapped columnar store in this directory, built from <path>/accounts.json if missing or outdated, for snapshots larger than the RAM (amounts are truncated to the uatom)")
+	blobMode := fs.Bool("blob", false, "Also outputs <path>/airdrop.blob, a compressed binary form of airdrop.json")
+	pubkeysMode := fs.Bool("pubkeys", false, "Also outputs <path>/airdrop_pubkeys.json, the pubkeys of the airdrop recipients from <path>/auth_genesis.json")
+	protoMode := fs.Bool("proto", false, "Also outputs <path>/airdrop_result.pb, the protobuf encoding of the airdrop result (params, amounts, aggregates)")
+	specMode := fs.Bool("spec", false, "Also outputs <path>/airdrop_spec.json, a machine-readable spec of the computation (params, formulas, address lists) checked against the results, for independent implementations to reproduce them")
+	checkpointFile := fs.String("checkpoint", "", "Periodically write the state of the distribution in this file, to resume it with -resume-from after a crash")
+	checkpointEvery := fs.Int("checkpointEvery", 1_000_000, "With -checkpoint, number of accounts between 2 checkpoints")
+	resumeFrom := fs.String("resume-from", "", "Resume the distribution from this checkpoint file")
+	prefixAliases := fs.String("prefixAliases", "", "Comma-separated list of bech32 prefixes, also outputs <path>/airdrop_<prefix>.json with the addresses of airdrop.json converted to each prefix")
+	dryRun := fs.Bool("dry-run", false, "Only estimate the run time and peak memory from <path>/accounts.json, and list the outputs that would be produced")
+	alertAddressPerc := fs.String("alertAddressPerc", defaultAlertAddressPerc, "Warn if a single address, other than the reserved address, holds more than this share of the final supply, 0 disables it")
+	alertNonVotersPerc := fs.String("alertNonVotersPerc", defaultAlertNonVotersPerc, "Warn if the non-voters hold more than this share of the distribution, 0 disables it")
+	ciMode := fs.Bool("ci", false, "Fail without writing the outputs if a supply alert is raised")
+	chain := fs.String("chain", "", "Name of the chain in the chain-registry (e.g. atomone), sets the address prefix unless -prefix is set")
+	chainRegistry := fs.String("chainRegistry", defaultChainRegistry, "With -chain, URL or local directory of the chain-registry")
+	output := fs.String("output", outputJSON, "Format of the per-address results: json writes <path>/airdrop.json, ndjson streams <path>/airdrop.ndjson with one JSON object per address as they are computed, without keeping them in memory")
+	outputFile := fs.String("outputFile", "", "With -output ndjson, file where the results are streamed instead of <path>/airdrop.ndjson, - for the standard output (requires -reportFile)")
+	publicMode := fs.Bool("public", false, "Publication mode: instead of the per-address outputs, writes <path>/airdrop_public.json with only the aggregates and the allocations bucketed by size, and <path>/airdrop_sealed.json with the full detail encrypted for the -auditors keys")
+	auditorKeys := fs.String("auditors", "", "With -public, comma-separated list of PEM files of the X25519 public keys of the auditors")
+	publicMinBucket := fs.Int("publicMinBucket", 10, "With -public, minimum number of addresses per allocation bucket, the smaller buckets are merged")
+	equalShareMode := fs.Bool("equalShare", false, "Experimental: also compute a one-address-one-share distribution, where each qualifying address gets the same amount, and compare it with the stake-weighted ones")
+	equalShareAmount := fs.String("equalShareAmount", "0", "With -equalShare, $ATONE allocation of each qualifying address, 0 splits the $ATONE distributed by the first stake-weighted run equally")
+	equalShareMinStake := fs.String("equalShareMinStake", "0", "With -equalShare, minimum staked $ATOM of a qualifying address")
+	stakeSinceFile := fs.String("stakeSince", "", "With -equalShare, JSON file of the time since which the addresses have been staking, exported from an indexer ([{\"address\":\"cosmos1...\",\"since\":\"...\"}])")
+	minStakeAge := fs.Duration("minStakeAge", 0, "With -equalShare and -stakeSince, minimum staking duration of a qualifying address at the end of the voting period of <path>/prop.json")
+	validatorLiquid := fs.Bool("validatorLiquid", false, "Give the liquid balance of the validator operator accounts the multipliers of their own vote instead of the non-voters malus")
+	crossCheck := fs.Bool("decCrossCheck", false, "Also compute each account with sdk.Dec and fail if it differs from the fixed-point kernel of the hot loops, slower")
 
 	cmd := &ffcli.Command{
 		Name:       "distribution",
@@ -256,83 +873,1004 @@ func distributionCmd() *ffcli.Command {
 				return flag.ErrHelp
 			}
 			fs.Parse(args)
-			// Build distribution parameters from yes and no multipliers
-			var distriParamss []distriParams
-			for _, y := range strings.Split(*yesMultipliers, ",") {
-				for _, n := range strings.Split(*noMultipliers, ",") {
-					distriParams := defaultDistriParams()
-					distriParams.yesVotesMultiplier = sdk.MustNewDecFromStr(y)
-					distriParams.noVotesMultiplier = sdk.MustNewDecFromStr(n)
-					distriParamss = append(distriParamss, distriParams)
-				}
-			}
-			var (
-				datapath          = fs.Arg(0)
-				accountsFile      = filepath.Join(datapath, "accounts.json")
-				airdropFile       = filepath.Join(datapath, "airdrop.json")
-				airdropDetailFile = filepath.Join(datapath, "airdrop_detail.csv")
-				airdrops          []airdrop
-			)
-			accounts, err := parseAccounts(accountsFile)
-			if err != nil {
+			decCrossCheck = *crossCheck
+			export := chartExport{format: *chartFormat, dir: *chartDir}
+			if err := export.validate(); err != nil {
 				return err
 			}
-			for _, params := range distriParamss {
-				airdrop, err := distribution(accounts, params, *prefix)
+			if *chartThemeFile != "" {
+				theme, err := parseChartTheme(*chartThemeFile)
 				if err != nil {
 					return err
 				}
-				airdrops = append(airdrops, airdrop)
+				export.theme = theme
 			}
-			if err := printAirdropsStats(*chartMode, airdrops); err != nil {
+			if err := validateOutputFormat(*output); err != nil {
 				return err
 			}
-			if len(airdrops) == 1 {
-				// Write airdrop.json only if a single distriParamss
-				bz, err := json.MarshalIndent(airdrops[0].addresses, "", "  ")
+			reportCfg, err := parseReportConfig(*sections, *reportFormat)
+			if err != nil {
+				return err
+			}
+			if (reportCfg.format == reportFormatJSON || reportCfg.format == reportFormatHTML) && *reportFile == "" && !*chartMode {
+				// The standard output also holds the other messages of the
+				// command, which would make the report unreadable.
+				return fmt.Errorf("-reportFormat %s requires -reportFile", reportCfg.format)
+			}
+			if *output == outputNDJSON {
+				if *prefixAliases != "" || *blobMode || *protoMode || *specMode || *pubkeysMode || *auditFile != "" || *equalShareMode {
+					return fmt.Errorf("-output %s doesn't keep the per-address results required by -prefixAliases, -blob, -proto, -spec, -pubkeys, -audit and -equalShare", outputNDJSON)
+				}
+				if *outputFile == "-" && (*reportFile == "" || *chartMode) {
+					return fmt.Errorf("-outputFile - requires -reportFile, without -chart, to keep the report out of the results")
+				}
+			} else if *outputFile != "" {
+				return fmt.Errorf("-outputFile requires -output %s", outputNDJSON)
+			}
+			var auditors []*ecdh.PublicKey
+			if *publicMode {
+				if *output == outputNDJSON || *prefixAliases != "" || *blobMode || *protoMode || *specMode || *pubkeysMode || *auditFile != "" {
+					return fmt.Errorf("-public doesn't support the per-address outputs (-output %s, -prefixAliases, -blob, -proto, -spec, -pubkeys, -audit)", outputNDJSON)
+				}
+				if *auditorKeys == "" {
+					return fmt.Errorf("-public requires -auditors")
+				}
+				keys, err := parseAuditorKeys(strings.Split(*auditorKeys, ","))
 				if err != nil {
 					return err
 				}
-				if err := os.WriteFile(airdropFile, bz, 0o666); err != nil {
+				auditors = keys
+			}
+			baseParams := defaultDistriParams()
+			if *profileName != "" {
+				p, err := getProfile(*profileName)
+				if err != nil {
 					return err
 				}
-				fmt.Printf("⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", airdropFile)
-
-				f, err := os.Create(airdropDetailFile)
+				fmt.Fprintf(os.Stderr, "Using profile %s\n", p)
+				baseParams = p.params
+				if !isFlagSet(fs, "prefix") {
+					*prefix = p.prefix
+				}
+				if !isFlagSet(fs, "yesMultipliers") {
+					*yesMultipliers = p.params.yesVotesMultiplier.String()
+				}
+				if !isFlagSet(fs, "noMultipliers") {
+					*noMultipliers = p.params.noVotesMultiplier.String()
+				}
+			}
+			if *chain != "" && !isFlagSet(fs, "prefix") {
+				c, err := loadChainConfig(ctx, *chainRegistry, *chain)
 				if err != nil {
 					return err
 				}
-				defer f.Close()
-				w := csv.NewWriter(f)
-				w.Write([]string{
-					"address", "factor",
-					"yesAtomAmt", "yesMultiplier", "yesBonusMalus", "yesAtoneAmt",
-					"noAtomAmt", "noMultiplier", "noBonusMalus", "noAtoneAmt",
-					"nwvAtomAmt", "nwvMultiplier", "nwvBonusMalus", "nwvAtoneAmt",
-					"absAtomAmt", "absMultiplier", "absBonusMalus", "absAtoneAmt",
-					"dnvAtomAmt", "dnvMultiplier", "dnvBonusMalus", "dnvAtoneAmt",
-					"liquidAtomAmt", "liquidMultiplier", "liquidBonusMalus", "liquidAtoneAmt",
-					"totalAtoneAmt",
-				})
-				for _, v := range airdrops[0].addressesDetail {
-					w.Write([]string{
-						v.Address, v.YesDetail.Factor.String(),
-						v.YesDetail.AtomAmt.String(), v.YesDetail.Multiplier.String(), v.YesDetail.BonusMalus.String(), v.YesDetail.AtoneAmt.String(),
-						v.NoDetail.AtomAmt.String(), v.NoDetail.Multiplier.String(), v.NoDetail.BonusMalus.String(), v.NoDetail.AtoneAmt.String(),
-						v.NWVDetail.AtomAmt.String(), v.NWVDetail.Multiplier.String(), v.NWVDetail.BonusMalus.String(), v.NWVDetail.AtoneAmt.String(),
-						v.AbsDetail.AtomAmt.String(), v.AbsDetail.Multiplier.String(), v.AbsDetail.BonusMalus.String(), v.AbsDetail.AtoneAmt.String(),
-						v.DnvDetail.AtomAmt.String(), v.DnvDetail.Multiplier.String(), v.DnvDetail.BonusMalus.String(), v.DnvDetail.AtoneAmt.String(),
-						v.LiquidDetail.AtomAmt.String(), v.LiquidDetail.Multiplier.String(), v.LiquidDetail.BonusMalus.String(), v.LiquidDetail.AtoneAmt.String(),
-						v.Total.String(),
-					})
+				*prefix = c.prefix
+			}
+			if *entitiesFile != "" {
+				groups, err := parseEntityGroups(*entitiesFile)
+				if err != nil {
+					return err
 				}
-				w.Flush()
-				fmt.Printf("⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", airdropDetailFile)
+				baseParams.entityGroups = groups
+			}
+			if *optOutFile != "" {
+				addrs, err := parseOptOut(*optOutFile)
+				if err != nil {
+					return err
+				}
+				baseParams.optOut = addrs
+				baseParams.optOutPolicy = *optOutPolicy
+			}
+			floor, err := sdk.NewDecFromStr(*voterFloor)
+			if err != nil {
+				return fmt.Errorf("invalid voterFloor '%s': %w", *voterFloor, err)
+			}
+			baseParams.voterFloor = floor.MulInt64(M).TruncateInt()
+			baseParams.voterFloorPool = *voterFloorPool
+			baseParams.dustPolicy = *dustPolicy
+			if *typeMultipliersFile != "" {
+				m, err := parseTypeMultipliers(*typeMultipliersFile)
+				if err != nil {
+					return err
+				}
+				baseParams.typeMultipliers = m
+			}
+			if *voteTimesFile != "" {
+				baseParams.voteTiming, err = loadVoteTiming(fs.Arg(0), *voteTimesFile, *voteTimeDecay, *voteTimeBonus, *voteTimeHalfLife)
+				if err != nil {
+					return err
+				}
+			}
+			if *hookCmd != "" {
+				hook, err := startExecHook(ctx, *hookCmd)
+				if err != nil {
+					return err
+				}
+				defer hook.Close()
+				baseParams.hook = hook
+			}
+			if *withholdingList != "" {
+				baseParams.withholding, err = parseListWithholding(*withholdingList)
+				if err != nil {
+					return err
+				}
+			}
+			capAmt, err := sdk.NewDecFromStr(*addressCap)
+			if err != nil {
+				return fmt.Errorf("invalid addressCap '%s': %w", *addressCap, err)
+			}
+			baseParams.addressCap = capAmt.MulInt64(M).TruncateInt()
+			targetAmt, err := sdk.NewDecFromStr(*targetSupply)
+			if err != nil {
+				return fmt.Errorf("invalid targetSupply '%s': %w", *targetSupply, err)
+			}
+			baseParams.targetSupply = targetAmt.MulInt64(M).TruncateInt()
+			baseParams.yesRatio, err = parseYesRatio(*yesRatio)
+			if err != nil {
+				return err
+			}
+			baseParams.stakingIncentivesPerc, err = parseStakingIncentivesPerc(*stakingIncentives)
+			if err != nil {
+				return err
+			}
+			if *voteBucketsFile != "" {
+				buckets, err := parseVoteBuckets(*voteBucketsFile)
+				if err != nil {
+					return err
+				}
+				baseParams.voteBuckets = buckets
+			}
+			if *shareBoundsFile != "" {
+				bounds, err := parseShareBounds(*shareBoundsFile)
+				if err != nil {
+					return err
+				}
+				baseParams.shareBounds = bounds
+			}
+			baseParams.abstainMultiplier, baseParams.abstainBonusMalus, err = parseAbstainParams(*abstainMultiplier, *abstainBonusMalus)
+			if err != nil {
+				return err
+			}
+			baseParams.lockedMultiplier, err = parseLockedMultiplier(*lockedMultiplier)
+			if err != nil {
+				return err
+			}
+			baseParams.validatorLiquid = *validatorLiquid
+			alerts, err := parseSupplyAlerts(*alertAddressPerc, *alertNonVotersPerc)
+			if err != nil {
+				return err
+			}
+			var equal equalShare
+			if *equalShareMode {
+				equal, err = loadEqualShare(fs.Arg(0), *equalShareAmount, *equalShareMinStake, *stakeSinceFile, *minStakeAge)
+				if err != nil {
+					return err
+				}
+			}
+			// Build distribution parameters from yes and no multipliers
+			var distriParamss []distriParams
+			for _, y := range strings.Split(*yesMultipliers, ",") {
+				for _, n := range strings.Split(*noMultipliers, ",") {
+					distriParams := baseParams
+					distriParams.yesVotesMultiplier = sdk.MustNewDecFromStr(y)
+					distriParams.noVotesMultiplier = sdk.MustNewDecFromStr(n)
+					distriParamss = append(distriParamss, distriParams)
+				}
+			}
+			var (
+				datapath          = fs.Arg(0)
+				accountsFile      = joinInput(datapath, "accounts.json")
+				airdropFile       = filepath.Join(outputDir(datapath), "airdrop.json")
+				airdropNDJSONFile = filepath.Join(outputDir(datapath), "airdrop.ndjson")
+				// status receives the messages, on the standard error if the
+				// results are streamed to the standard output.
+				status            io.Writer = os.Stdout
+				airdropDetailFile           = filepath.Join(outputDir(datapath), "airdrop_detail.csv")
+				validatorsFile              = filepath.Join(outputDir(datapath), "airdrop_detail_validators.csv")
+				airdropBlobFile             = filepath.Join(outputDir(datapath), "airdrop.blob")
+				airdropResultFile           = filepath.Join(outputDir(datapath), "airdrop_result.pb")
+				airdropSpecFile             = filepath.Join(outputDir(datapath), "airdrop_spec.json")
+				pubkeysFile                 = filepath.Join(outputDir(datapath), "airdrop_pubkeys.json")
+				publicFile                  = filepath.Join(outputDir(datapath), "airdrop_public.json")
+				sealedFile                  = filepath.Join(outputDir(datapath), "airdrop_sealed.json")
+				withholdingFile             = filepath.Join(outputDir(datapath), "airdrop_withholding.json")
+				airdrops          []airdrop
+			)
+			if *outputFile == "-" {
+				status = os.Stderr
+			} else if *outputFile != "" {
+				airdropNDJSONFile = *outputFile
+			}
+			if *dryRun {
+				plan, err := planDistribution(ctx, accountsFile, *compact, len(distriParamss))
+				if err != nil {
+					return err
+				}
+				if len(distriParamss) == 1 && *publicMode {
+					plan.outputs = append(plan.outputs, publicFile, sealedFile)
+				} else if len(distriParamss) == 1 {
+					if *output == outputNDJSON {
+						plan.outputs = append(plan.outputs, airdropNDJSONFile)
+					} else {
+						plan.outputs = append(plan.outputs, airdropFile, airdropDetailFile, validatorsFile)
+					}
+					for _, alias := range parsePrefixAliases(*prefixAliases) {
+						plan.outputs = append(plan.outputs, filepath.Join(outputDir(datapath), fmt.Sprintf("airdrop_%s.json", alias)))
+					}
+					if *blobMode {
+						plan.outputs = append(plan.outputs, airdropBlobFile)
+					}
+					if *protoMode {
+						plan.outputs = append(plan.outputs, airdropResultFile)
+					}
+					if *specMode {
+						plan.outputs = append(plan.outputs, airdropSpecFile)
+					}
+					if *pubkeysMode {
+						plan.outputs = append(plan.outputs, pubkeysFile)
+					}
+					if *auditFile != "" {
+						plan.outputs = append(plan.outputs, *auditFile)
+					}
+					if *withholdingList != "" {
+						plan.outputs = append(plan.outputs, withholdingFile)
+					}
+				}
+				if *chartMode && export.format != "" {
+					plan.outputs = append(plan.outputs, fmt.Sprintf("%s charts in %s", export.format, export.dir))
+				} else if !*chartMode && *reportFile != "" {
+					plan.outputs = append(plan.outputs, *reportFile)
+				}
+				printDryRun(plan)
+				return nil
+			}
+			var accounts iter.Seq[Account]
+			if *columnStoreDir != "" {
+				store, err := loadColumnStore(ctx, accountsFile, *columnStoreDir)
+				if err != nil {
+					return err
+				}
+				defer store.Close()
+				accounts = store.All()
+			} else {
+				accounts, err = loadAccounts(ctx, accountsFile, *compact)
+				if err != nil {
+					return err
+				}
+			}
+			cp := checkpointConfig{file: *checkpointFile, every: *checkpointEvery}
+			if (cp.file != "" || *resumeFrom != "") && len(distriParamss) > 1 {
+				return fmt.Errorf("-checkpoint and -resume-from require a single set of parameters")
+			}
+			if *resumeFrom != "" {
+				cp.resume, err = readCheckpoint(*resumeFrom)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Resuming from %s after %d accounts\n", *resumeFrom, cp.resume.Processed)
+			}
+			// The stages are shared by the distriParamss, so the $ATOM aggregation
+			// runs only once.
+			stages := newDistributionStages(accounts)
+			// The column store is for snapshots larger than the RAM, which
+			// wouldn't hold the converted addresses either.
+			stages.noAddressCache = *columnStoreDir != ""
+			compute := func() error {
+				for _, params := range distriParamss {
+					airdrop, err := stages.run(ctx, params, *prefix, cp)
+					if err != nil {
+						return err
+					}
+					airdrops = append(airdrops, airdrop)
+				}
+				var infos validatorInfos
+				if *chartMode || reportCfg.selected(sectionValidators) {
+					infos, err = parseValidatorInfos(ctx, datapath)
+					if err != nil {
+						return err
+					}
+				}
+				turnout, err := loadTurnoutCheck(ctx, datapath, airdrops[0].atom)
+				if err != nil {
+					return err
+				}
+				if err := printAirdropsStats(ctx, status, *chartMode, export, airdrops, infos, turnout, reportCfg, *reportFile); err != nil {
+					return err
+				}
+				if *equalShareMode {
+					res, err := computeEqualShare(ctx, accounts, equal, airdrops[0].atone.supply)
+					if err != nil {
+						return err
+					}
+					printEqualShare(equal, res)
+					printStrategiesComparison(airdrops, res)
+				}
+				return checkSupplyAlerts(status, airdrops, alerts, *ciMode)
+			}
+			if *output == outputNDJSON {
+				if len(distriParamss) > 1 || cp.file != "" || cp.resume != nil {
+					return fmt.Errorf("-output %s requires a single set of parameters and doesn't support -checkpoint and -resume-from", outputNDJSON)
+				}
+				if *outputFile == "-" {
+					// The results already written can't be withdrawn if
+					// compute fails.
+					w := bufio.NewWriter(os.Stdout)
+					stages.stream = ndjsonStream(w)
+					if err = compute(); err == nil {
+						err = w.Flush()
+					}
+				} else {
+					// The file is only renamed once compute succeeded, so the
+					// alerts of -ci still prevent the output.
+					err = writeOutputFile(ctx, airdropNDJSONFile, func(w io.Writer) error {
+						stages.stream = ndjsonStream(w)
+						return compute()
+					})
+				}
+			} else {
+				err = compute()
+			}
+			if err != nil {
+				return err
+			}
+			if len(airdrops) == 1 && *publicMode {
+				pub, err := newPublicAirdrop("uatone", airdrops[0], *publicMinBucket)
+				if err != nil {
+					return err
+				}
+				if err := writePublicAirdrop(ctx, publicFile, pub); err != nil {
+					return err
+				}
+				fmt.Printf("'%s' has been created/updated\n", publicFile)
+				if err := writeSealedAirdrop(ctx, sealedFile, "uatone", airdrops[0], auditors); err != nil {
+					return err
+				}
+				fmt.Printf("'%s' has been created/updated, readable by %d auditor(s) with `%s open-sealed`\n", sealedFile, len(auditors), os.Args[0])
+				printPublication(os.Stdout, []string{publicFile, sealedFile})
+				return nil
+			}
+			if len(airdrops) == 1 {
+				// Write airdrop.json only if a single distriParamss
+				if *output == outputNDJSON {
+					if *outputFile != "-" {
+						fmt.Printf("⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", airdropNDJSONFile)
+					}
+				} else {
+					bz, err := json.MarshalIndent(airdrops[0].addresses, "", "  ")
+					if err != nil {
+						return err
+					}
+					if err := writeFile(ctx, airdropFile, bz); err != nil {
+						return err
+					}
+					fmt.Printf("⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", airdropFile)
+				}
+
+				for _, alias := range parsePrefixAliases(*prefixAliases) {
+					aliased, err := aliasAddresses(airdrops[0].addresses, alias)
+					if err != nil {
+						return err
+					}
+					bz, err := json.MarshalIndent(aliased, "", "  ")
+					if err != nil {
+						return err
+					}
+					aliasFile := filepath.Join(outputDir(datapath), fmt.Sprintf("airdrop_%s.json", alias))
+					if err := writeFile(ctx, aliasFile, bz); err != nil {
+						return err
+					}
+					fmt.Printf("⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", aliasFile)
+				}
+				if *blobMode {
+					if err := writeAirdropBlob(ctx, airdropBlobFile, "uatone", airdrops[0].addresses); err != nil {
+						return err
+					}
+					fmt.Printf("⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", airdropBlobFile)
+				}
+				if *protoMode {
+					if err := writeAirdropResult(ctx, airdropResultFile, "uatone", airdrops[0]); err != nil {
+						return err
+					}
+					fmt.Printf("⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", airdropResultFile)
+				}
+				if *specMode {
+					spec, err := newFormulaSpec(airdrops[0], *prefix)
+					if err != nil {
+						return err
+					}
+					if err := checkFormulaSpec(ctx, accounts, spec); err != nil {
+						return err
+					}
+					bz, err := json.MarshalIndent(spec, "", "  ")
+					if err != nil {
+						return err
+					}
+					if err := writeFile(ctx, airdropSpecFile, bz); err != nil {
+						return err
+					}
+					fmt.Printf("⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", airdropSpecFile)
+				}
+				if *pubkeysMode {
+					pubkeysByAddr, err := parsePubkeysByAddr(datapath)
+					if err != nil {
+						return err
+					}
+					pubkeys, missing := airdropPubkeys(airdrops[0], pubkeysByAddr)
+					if err := writePubkeys(ctx, pubkeysFile, pubkeys); err != nil {
+						return err
+					}
+					printPubkeysStats(len(pubkeys), missing)
+					fmt.Printf("⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", pubkeysFile)
+				}
+
+				// The detail of the streamed results isn't kept
+				if *output != outputNDJSON {
+					err = writeOutputFile(ctx, airdropDetailFile, func(w io.Writer) error {
+						return writeAirdropDetail(w, airdrops[0])
+					})
+					if err != nil {
+						return err
+					}
+					fmt.Printf("⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", airdropDetailFile)
+
+					err = writeOutputFile(ctx, validatorsFile, func(w io.Writer) error {
+						return writeAirdropValidatorDetail(w, airdrops[0])
+					})
+					if err != nil {
+						return err
+					}
+					fmt.Printf("⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", validatorsFile)
+				}
+
+				if *auditFile != "" {
+					if err := writeAuditLog(ctx, *auditFile, airdrops[0].audit); err != nil {
+						return err
+					}
+					fmt.Printf("'%s' has been created/updated\n", *auditFile)
+				}
+				if *withholdingList != "" {
+					bz, err := json.MarshalIndent(withholdingLedgerOf(airdrops[0]), "", "  ")
+					if err != nil {
+						return err
+					}
+					if err := writeFile(ctx, withholdingFile, bz); err != nil {
+						return err
+					}
+					fmt.Fprintf(status, "'%s' has been created/updated\n", withholdingFile)
+				}
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func overridesCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("overrides", flag.ContinueOnError)
+	chartMode := fs.Bool("chart", false, "Outputs a chart instead of Markdown tables")
+	chartFormat := fs.String("chartExport", "", "With -chart, export the charts as images instead of opening the browser (png or svg)")
+	chartDir := fs.String("chartDir", ".", "Directory where the charts are exported")
+	chartThemeFile := fs.String("chartTheme", "", "JSON file configuring the labels and colors of the charts, and the categories to merge (e.g. No and NWV)")
+	return &ffcli.Command{
+		Name:       "overrides",
+		ShortUsage: "govbox overrides <path>",
+		ShortHelp:  "Report delegators who overrode their validator vote in <path>/accounts.json",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return flag.ErrHelp
+			}
+			accounts, err := parseAccounts(joinInput(args[0], "accounts.json"))
+			if err != nil {
+				return err
+			}
+			export := chartExport{format: *chartFormat, dir: *chartDir}
+			if err := export.validate(); err != nil {
+				return err
+			}
+			if *chartThemeFile != "" {
+				theme, err := parseChartTheme(*chartThemeFile)
+				if err != nil {
+					return err
+				}
+				export.theme = theme
+			}
+			return printOverrideStats(ctx, *chartMode, export, computeOverrideStats(accounts))
+		},
+	}
+}
+
+func sybilCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("sybil", flag.ContinueOnError)
+	address := fs.String("address", "", "Address of the attacker (default to the richest account)")
+	splits := fs.String("splits", "2,10,100,1000", "List of comma-separated number of wallets the attacker splits its balance into")
+	profileName := fs.String("profile", "", "Use the parameters of a known historic run (prop848, govgen)")
+	return &ffcli.Command{
+		Name:       "sybil",
+		ShortUsage: "govbox sybil <path>",
+		ShortHelp:  "Simulate an attacker splitting its balance into multiple wallets and report the gain",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return flag.ErrHelp
+			}
+			params := defaultDistriParams()
+			if *profileName != "" {
+				p, err := getProfile(*profileName)
+				if err != nil {
+					return err
+				}
+				params = p.params
+			}
+			var numSplits []int
+			for _, s := range strings.Split(*splits, ",") {
+				n, err := strconv.Atoi(s)
+				if err != nil {
+					return fmt.Errorf("invalid splits '%s': %w", s, err)
+				}
+				numSplits = append(numSplits, n)
+			}
+			accounts, err := parseAccounts(joinInput(args[0], "accounts.json"))
+			if err != nil {
+				return err
+			}
+			attacker := largestAccount(accounts)
+			if *address != "" {
+				attacker = slices.IndexFunc(accounts, func(a Account) bool { return a.Address == *address })
+				if attacker == -1 {
+					return fmt.Errorf("address %s not found", *address)
+				}
+			}
+			results, err := simulateSybil(ctx, accounts, attacker, params, numSplits)
+			if err != nil {
+				return err
+			}
+			printSybilResults(accounts[attacker], results)
+			return nil
+		},
+	}
+}
+
+func optimizeCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("optimize", flag.ContinueOnError)
+	targets := fs.String("targets", "", "Comma-separated list of targets on the metrics nonVotersPerc, supply and votersRatio, like \"nonVotersPerc<=0.33,supply=100000000,votersRatio>=2\" (required)")
+	ranges := fs.String("ranges", "", "Comma-separated list of ranges of the searched parameters yes, no, bonus, malus and supplyFactor, like \"yes=0.5:2,bonus=1:1\" (min=max fixes a parameter)")
+	iterations := fs.Int("iterations", 500, "Number of iterations of the simulated annealing, each runs a distribution")
+	top := fs.Int("top", 5, "Number of parameter sets reported")
+	seed := fs.Int64("seed", 1, "Seed of the random moves, for reproducible runs")
+	profileName := fs.String("profile", "", "Use the parameters of a known historic run (prop848, govgen) for the parameters that are not searched")
+	entitiesFile := fs.String("entities", "", "JSON file of entity groups and their policies, replaces the default ICF slash")
+	compact := fs.Bool("compact", false, compactFlagUsage)
+	return &ffcli.Command{
+		Name:       "optimize",
+		ShortUsage: "govbox optimize -targets <targets> <path>",
+		ShortHelp:  "Search the distribution parameters that meet some targets with a simulated annealing",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 || *targets == "" {
+				return flag.ErrHelp
+			}
+			params := defaultDistriParams()
+			if *profileName != "" {
+				p, err := getProfile(*profileName)
+				if err != nil {
+					return err
+				}
+				params = p.params
+			}
+			if *entitiesFile != "" {
+				groups, err := parseEntityGroups(*entitiesFile)
+				if err != nil {
+					return err
+				}
+				params.entityGroups = groups
+			}
+			var (
+				cfg = optimizeConfig{iterations: *iterations, top: *top, seed: *seed}
+				err error
+			)
+			cfg.targets, err = parseOptimizeTargets(*targets)
+			if err != nil {
+				return err
+			}
+			cfg.space, err = parseOptimizeRanges(*ranges)
+			if err != nil {
+				return err
+			}
+			accounts, err := loadAccounts(ctx, joinInput(args[0], "accounts.json"), *compact)
+			if err != nil {
+				return err
+			}
+			results, err := optimizeDistriParams(ctx, newDistributionStages(accounts), params, cfg)
+			if err != nil {
+				return err
+			}
+			printOptimizeResults(cfg, results)
+			return nil
+		},
+	}
+}
+
+func reportCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	tagsFile := fs.String("tags", "", "JSON file of tags per address (required)")
+	groupBy := fs.String("group-by", "", "Tag used to aggregate the airdrop (required)")
+	profileName := fs.String("profile", "", "Use the parameters of a known historic run (prop848, govgen)")
+	entitiesFile := fs.String("entities", "", "JSON file of entity groups and their policies, replaces the default ICF slash")
+	return &ffcli.Command{
+		Name:       "report",
+		ShortUsage: "govbox report -tags <file> -group-by <tag> <path>",
+		ShortHelp:  "Aggregate the airdrop per value of an address tag",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 || *tagsFile == "" || *groupBy == "" {
+				return flag.ErrHelp
+			}
+			params := defaultDistriParams()
+			if *profileName != "" {
+				p, err := getProfile(*profileName)
+				if err != nil {
+					return err
+				}
+				params = p.params
+			}
+			if *entitiesFile != "" {
+				groups, err := parseEntityGroups(*entitiesFile)
+				if err != nil {
+					return err
+				}
+				params.entityGroups = groups
+			}
+			tags, err := parseTags(*tagsFile)
+			if err != nil {
+				return err
+			}
+			accounts, err := parseAccounts(joinInput(args[0], "accounts.json"))
+			if err != nil {
+				return err
+			}
+			airdrop, err := distributionSeq(ctx, slices.Values(accounts), params, "")
+			if err != nil {
+				return err
+			}
+			printTagReport(*groupBy, groupByTag(airdrop.audit, tags, *groupBy))
+			return nil
+		},
+	}
+}
+
+func inspectCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	profileName := fs.String("profile", "", "Use the parameters of a known historic run (prop848, govgen)")
+	compact := fs.Bool("compact", false, compactFlagUsage)
+	indexDir := fs.String("index", "", "Read the account from an index of <path>/accounts.json and <path>/active_validators.json in this directory, built once if missing or outdated, instead of loading all the accounts")
+	indexRate := fs.Int64("indexRate", 0, "With -index, maximum read throughput of the index build in MB/s, 0 disables the limit")
+	return &ffcli.Command{
+		Name:       "inspect",
+		ShortUsage: "govbox inspect <path> <cosmos address>",
+		ShortHelp:  "Explain the $ATONE allocation of an account of <path>/accounts.json, delegation by delegation",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 2 {
+				return flag.ErrHelp
+			}
+			var (
+				datapath = args[0]
+				address  = args[1]
+				params   = defaultDistriParams()
+				prefix   string
+			)
+			if *profileName != "" {
+				p, err := getProfile(*profileName)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Using profile %s\n", p)
+				params = p.params
+				prefix = p.prefix
+			}
+			if *indexDir != "" {
+				index, err := loadInspectIndex(ctx, datapath, *indexDir, *indexRate*1_000_000)
+				if err != nil {
+					return err
+				}
+				defer index.Close()
+				acc, audit, err := index.inspectAccount(ctx, address, params, prefix)
+				if err != nil {
+					return err
+				}
+				infos, err := index.validatorInfos(acc)
+				if err != nil {
+					return err
+				}
+				details, liquidAtone := inspectDelegations(acc, audit, infos)
+				printInspect(acc, audit, details, liquidAtone)
+				return nil
+			}
+			accounts, err := loadAccounts(ctx, joinInput(datapath, "accounts.json"), *compact)
+			if err != nil {
+				return err
+			}
+			airdrop, err := distributionSeq(ctx, accounts, params, prefix)
+			if err != nil {
+				return err
+			}
+			i := slices.IndexFunc(airdrop.audit, func(e auditEntry) bool { return e.Address == address })
+			if i == -1 {
+				return fmt.Errorf("account %s not found", address)
+			}
+			var acc Account
+			for a := range accounts {
+				if a.Address == address {
+					acc = a
+					break
+				}
+			}
+			infos, err := parseValidatorInfos(ctx, datapath)
+			if err != nil {
+				return err
+			}
+			details, liquidAtone := inspectDelegations(acc, airdrop.audit[i], infos)
+			printInspect(acc, airdrop.audit[i], details, liquidAtone)
+			return nil
+		},
+	}
+}
+
+func exportVotesCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("export-votes", flag.ContinueOnError)
+	compact := fs.Bool("compact", false, compactFlagUsage)
+	return &ffcli.Command{
+		Name:       "export-votes",
+		ShortUsage: "govbox export-votes <path>",
+		ShortHelp:  "Export the votes of <path>/accounts.json into <path>/votes_long.csv, one row per account and vote option",
+		LongHelp: `The columns are address, account_type, vote_option (yes, no, noWithVeto,
+abstain or didNotVote), source (direct, inherited from the validators, or none),
+weight and staked_amount_for_option (weight x staked amount, in uatom).`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return flag.ErrHelp
+			}
+			accounts, err := loadAccounts(ctx, joinInput(args[0], "accounts.json"), *compact)
+			if err != nil {
+				return err
+			}
+			file := filepath.Join(outputDir(args[0]), "votes_long.csv")
+			rows, err := writeVotesLong(ctx, file, accounts)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%d rows written in %s\n", rows, file)
+			return nil
+		},
+	}
+}
+
+func sendTxsCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("send-txs", flag.ContinueOnError)
+	from := fs.String("from", "", "Address of the funding account (required)")
+	denom := fs.String("denom", "uatone", "Denom of the sent amounts")
+	chunk := fs.Int("chunk", 500, "Maximum number of outputs per transaction")
+	current := fs.String("current", "", "JSON file of the current balances per address, only the missing amounts are sent")
+	gasBase := fs.Uint64("gasBase", 100_000, "Gas estimate of a transaction without output")
+	gasPerOutput := fs.Uint64("gasPerOutput", 25_000, "Gas estimate of each output")
+	gasPrice := fs.String("gasPrice", "0.025uatone", "Gas price used to compute the fees")
+	memo := fs.String("memo", "", "Memo of the transactions")
+	out := fs.String("out", "", "Directory of the generated transactions (default to <path>/txs)")
+	keyFile := fs.String("key", "", "File of the hex encoded secp256k1 private key of -from, to sign the transactions offline")
+	chainID := fs.String("chainID", "", "Chain ID used for the offline signing")
+	accountNumber := fs.Uint64("accountNumber", 0, "Account number of -from used for the offline signing")
+	sequence := fs.Uint64("sequence", 0, "Sequence of -from for the first transaction, used for the offline signing")
+	return &ffcli.Command{
+		Name:       "send-txs",
+		ShortUsage: "govbox send-txs -from <addr> <path>",
+		ShortHelp:  "Convert <path>/airdrop.json into batches of MsgMultiSend transactions",
+		LongHelp: `Generate one MsgMultiSend transaction per chunk of addresses, to fund the
+airdrop on a testnet. With -current, the amounts already held are deducted, so
+the command can be rerun after a partial execution to send only what's missing.
+Transactions are unsigned unless -key is provided.`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 || *from == "" {
+				return flag.ErrHelp
+			}
+			price, err := sdk.ParseDecCoin(*gasPrice)
+			if err != nil {
+				return fmt.Errorf("gasPrice: %w", err)
+			}
+			target, err := parseAmountsFile(filepath.Join(args[0], "airdrop.json"))
+			if err != nil {
+				return err
+			}
+			currentBalances := make(map[string]sdk.Int)
+			if *current != "" {
+				currentBalances, err = parseAmountsFile(*current)
+				if err != nil {
+					return err
+				}
+			}
+			deltas := airdropDeltas(target, currentBalances)
+			txs, err := airdropTxs(deltas, sendTxsParams{
+				from:         *from,
+				denom:        *denom,
+				chunk:        *chunk,
+				gasBase:      *gasBase,
+				gasPerOutput: *gasPerOutput,
+				gasPrice:     price,
+				memo:         *memo,
+			})
+			if err != nil {
+				return err
+			}
+			if *keyFile != "" {
+				if *chainID == "" {
+					return fmt.Errorf("-chainID is required to sign the transactions")
+				}
+				privKey, err := readPrivKey(*keyFile)
+				if err != nil {
+					return err
+				}
+				err = signTxsOffline(txs, privKey, *chainID, *accountNumber, *sequence)
+				if err != nil {
+					return err
+				}
+			}
+			dir := *out
+			if dir == "" {
+				dir = filepath.Join(args[0], "txs")
+			}
+			if _, err := writeTxs(ctx, dir, txs); err != nil {
+				return err
+			}
+			printTxsStats(deltas, txs, dir)
+			fmt.Printf("⚠ '%s' has been created/updated ⚠\n", dir)
+			return nil
+		},
+	}
+}
+
+func importDBCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("import-db", flag.ContinueOnError)
+	height := fs.Int64("height", 0, "Height of the state of the application db to read (default to the latest)")
+	proposalID := fs.Uint64("proposal", 848, "ID of the proposal whose votes are extracted")
+	denom := fs.String("denom", "uatom", "Denom of the extracted balances")
+	snapshot := fs.Bool("snapshot", false, "Read the chunks of a state-sync snapshot, <node data dir> being its <height>/<format> directory (e.g. data/snapshots/18010657/3)")
+	return &ffcli.Command{
+		Name:       "import-db",
+		ShortUsage: "govbox import-db [-snapshot] <node data dir> <path>",
+		ShortHelp:  "Extract the files required by the accounts command from <node data dir>/application.db or a state-sync snapshot into <path>",
+		LongHelp: `Read the staking, gov, bank and auth states directly from the application
+database of a node, or from the chunks of a state-sync snapshot with
+-snapshot, instead of relying on JSON module exports. The stores of a
+snapshot are restored in memory.`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 2 {
+				return flag.ErrHelp
+			}
+			if *snapshot {
+				cms, err := restoreSnapshotChunks(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Read state-sync snapshot at height %s\n", filepath.Base(filepath.Dir(args[0])))
+				return extractSnapshot(ctx, cms, *proposalID, *denom, args[1])
+			}
+			db, err := dbm.NewDB("application", dbm.GoLevelDBBackend, args[0])
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			cms, err := openSnapshotStore(db, *height)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Reading application db at height %d\n", cms.LastCommitID().Version)
+			return extractSnapshot(ctx, cms, *proposalID, *denom, args[1])
+		},
+	}
+}
+
+func importTxVotesCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("import-tx-votes", flag.ContinueOnError)
+	proposalID := fs.Uint64("proposal", 848, "ID of the proposal whose votes are extracted")
+	txsFile := fs.String("txs", "", "Indexer export of the vote txs, a JSON array in the format of the tx_search RPC results")
+	rpcURL := fs.String("rpc", "", "RPC endpoint of a node with the tx index, queried instead of -txs")
+	fromHeight := fs.Int64("fromHeight", 0, "With -rpc, first height of the block range (e.g. the start of the voting period)")
+	toHeight := fs.Int64("toHeight", 0, "With -rpc, last height of the block range (e.g. the end of the voting period)")
+	return &ffcli.Command{
+		Name:       "import-tx-votes",
+		ShortUsage: "govbox import-tx-votes [-txs <file> | -rpc <url> -fromHeight <h> -toHeight <h>] <path>",
+		ShortHelp:  "Complete <path>/votes.json with the MsgVote and MsgVoteWeighted of the txs",
+		LongHelp: `The gov genesis exports taken after the proposal pruning can miss some
+votes. This command extracts the votes from the txs of a block range, either
+from an indexer export or from the tx_search endpoint of a node, and adds the
+missing voters to <path>/votes.json (created if it doesn't exist). The votes
+of the state take precedence, and the last tx vote of a voter is its final
+vote.`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return flag.ErrHelp
+			}
+			var (
+				txs []rawTx
+				err error
+			)
+			switch {
+			case *txsFile != "" && *rpcURL != "":
+				return fmt.Errorf("-txs and -rpc are mutually exclusive")
+			case *txsFile != "":
+				txs, err = parseRawTxs(*txsFile)
+			case *rpcURL != "":
+				if *fromHeight <= 0 || *toHeight < *fromHeight {
+					return fmt.Errorf("-rpc requires a valid -fromHeight and -toHeight range")
+				}
+				txs, err = fetchRawTxs(ctx, *rpcURL, *fromHeight, *toHeight)
+			default:
+				return fmt.Errorf("-txs or -rpc is required")
+			}
+			if err != nil {
+				return err
+			}
+			return importTxVotes(ctx, args[0], *proposalID, txs)
+		},
+	}
+}
+
+func compareOfficialCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("compare-official", flag.ContinueOnError)
+	official := fs.String("official", "", "Path or URL of the officially published genesis (required)")
+	profileName := fs.String("profile", "prop848", "Parameters of the local recomputation")
+	compact := fs.Bool("compact", false, compactFlagUsage)
+	gentxDir := fs.String("gentxs", "", "Directory of the gentx files of the official genesis, whose validators are bootstrapped as in the genesis command")
+	return &ffcli.Command{
+		Name:       "compare-official",
+		ShortUsage: "govbox compare-official -official <genesis.json|url> <path>",
+		ShortHelp:  "Diff the balances of the official genesis against a local recomputation from <path>/accounts.json",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 || *official == "" {
+				return flag.ErrHelp
+			}
+			p, err := getProfile(*profileName)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Using profile %s\n", p)
+			prefix := p.prefix
+			if prefix == "" {
+				prefix = "cosmos"
+			}
+			accounts, err := loadAccounts(ctx, joinInput(args[0], "accounts.json"), *compact)
+			if err != nil {
+				return err
+			}
+			airdrop, err := distributionSeq(ctx, accounts, p.params, p.prefix)
+			if err != nil {
+				return err
+			}
+			officialBalances, err := parseOfficialBalances(*official, "uatone")
+			if err != nil {
+				return err
+			}
+			msgs, err := loadGentxs(*gentxDir)
+			if err != nil {
+				return err
+			}
+			expected, err := expectedGenesisBalances(airdrop, prefix, msgs)
+			if err != nil {
+				return err
+			}
+			res := compareBalances(officialBalances, expected)
+			printCompareResult(res)
+			if len(res.diffs) > 0 {
+				return fmt.Errorf("%d balance(s) differ from the official genesis", len(res.diffs))
 			}
 			return nil
 		},
 	}
-	return cmd
 }
 
 func top20Cmd() *ffcli.Command {
@@ -454,20 +1992,98 @@ func signTxCmd() *ffcli.Command {
 	}
 }
 
+func photonCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("photon", flag.ContinueOnError)
+	portion := fs.String("portion", "0.5", "Share of each $ATONE allocation converted into $PHOTON")
+	curve := fs.String("curve", photonCurveAtomOne, "Curve of the conversion rate (atomone or fixed)")
+	rate := fs.String("rate", "1", "With -curve fixed, $PHOTON minted per $ATONE burned")
+	maxSupply := fs.String("maxSupply", photonMaxSupply.QuoRaw(M).String(), "Maximum $PHOTON supply")
+	profileName := fs.String("profile", "", "Use the parameters of a known historic run (prop848, govgen)")
+	entitiesFile := fs.String("entities", "", "JSON file of entity groups and their policies, replaces the default ICF slash")
+	compact := fs.Bool("compact", false, compactFlagUsage)
+	return &ffcli.Command{
+		Name:       "photon",
+		ShortUsage: "govbox photon <path>",
+		ShortHelp:  "Simulate the conversion of a portion of the $ATONE allocations into $PHOTON (burn-to-mint)",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return flag.ErrHelp
+			}
+			params := defaultDistriParams()
+			if *profileName != "" {
+				p, err := getProfile(*profileName)
+				if err != nil {
+					return err
+				}
+				params = p.params
+			}
+			if *entitiesFile != "" {
+				groups, err := parseEntityGroups(*entitiesFile)
+				if err != nil {
+					return err
+				}
+				params.entityGroups = groups
+			}
+			cfg := photonConfig{curve: *curve}
+			var err error
+			cfg.portion, err = sdk.NewDecFromStr(*portion)
+			if err != nil {
+				return fmt.Errorf("invalid portion '%s': %w", *portion, err)
+			}
+			cfg.rate, err = sdk.NewDecFromStr(*rate)
+			if err != nil {
+				return fmt.Errorf("invalid rate '%s': %w", *rate, err)
+			}
+			maxSupplyAmt, err := sdk.NewDecFromStr(*maxSupply)
+			if err != nil {
+				return fmt.Errorf("invalid maxSupply '%s': %w", *maxSupply, err)
+			}
+			cfg.maxSupply = maxSupplyAmt.MulInt64(M).TruncateInt()
+			if err := cfg.validate(); err != nil {
+				return err
+			}
+			accounts, err := loadAccounts(ctx, joinInput(args[0], "accounts.json"), *compact)
+			if err != nil {
+				return err
+			}
+			airdrop, err := distributionSeq(ctx, accounts, params, "")
+			if err != nil {
+				return err
+			}
+			sim, err := simulatePhoton(airdrop, cfg)
+			if err != nil {
+				return err
+			}
+			printPhotonSimulation(cfg, sim)
+			return nil
+		},
+	}
+}
+
 func vestingCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("vesting", flag.ContinueOnError)
+	snapshotTimeFlag := fs.String("snapshotTime", "", "RFC3339 block time of the snapshot, default to the voting end time of <path>/prop.json")
 	return &ffcli.Command{
 		Name:       "vesting",
 		ShortUsage: "govbox vesting <path>",
 		ShortHelp:  "Report vesting accounts analysis",
+		FlagSet:    fs,
 		Exec: func(ctx context.Context, args []string) error {
 			if len(args) == 0 {
 				return flag.ErrHelp
 			}
 			datapath := args[0]
-			err := analyzeVestingAccounts(datapath)
+			t, err := snapshotTime(datapath, *snapshotTimeFlag)
+			if err != nil {
+				return err
+			}
+			a, err := analyzeVestingAccounts(datapath, t)
 			if err != nil {
 				return err
 			}
+			fmt.Printf("%d/%d valid vesting accounts at %s, total of %s freed\n", a.stillVesting, a.vesting, t.Format(time.RFC3339), a.totalVesting)
+			fmt.Printf("%d vesting account(s) with more than %s vesting\n", a.highCap, vestingHighCap)
 			return nil
 		},
 	}
@@ -483,3 +2099,417 @@ func depositThrottlingCmd() *ffcli.Command {
 		},
 	}
 }
+
+// isFlagSet returns true if the flag name has been explicitly set.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	var set bool
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func validatorOverlapCmd() *ffcli.Command {
+	return &ffcli.Command{
+		Name:       "validator-overlap",
+		ShortUsage: "govbox validator-overlap <path> <gentxs>",
+		ShortHelp:  "Compare the validators of the snapshot in <path> and their votes with the AtomOne genesis validators of the gentxs directory",
+		LongHelp: `A gentx matches a validator of the snapshot active set if it's signed with the
+same operator key, whatever the bech32 prefix, or else if it has the same
+identity (e.g. Keybase).`,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) < 2 {
+				return flag.ErrHelp
+			}
+			datapath := args[0]
+			votesByAddr, err := parseVotesByAddr(ctx, datapath)
+			if err != nil {
+				return err
+			}
+			vals, err := parseValidators(ctx, datapath)
+			if err != nil {
+				return err
+			}
+			gentxs, err := loadGentxs(args[1])
+			if err != nil {
+				return err
+			}
+			overlap, err := computeValidatorOverlap(vals, votesByAddr, gentxs)
+			if err != nil {
+				return err
+			}
+			printValidatorOverlap(overlap)
+			return nil
+		},
+	}
+}
+
+func compareRunsCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("compare-runs", flag.ContinueOnError)
+	outputFile := fs.String("o", "compare_runs.html", "HTML file of the comparison dashboard")
+	chartThemeFile := fs.String("chartTheme", "", "JSON file configuring the labels and colors of the charts, and the categories to merge (e.g. No and NWV)")
+	top := fs.Int("top", 20, "Number of addresses with the largest allocation change listed per run")
+	open := fs.Bool("open", false, "Open the dashboard in the browser")
+	return &ffcli.Command{
+		Name:       "compare-runs",
+		ShortUsage: "govbox compare-runs <airdrop_result.pb> <airdrop_result.pb>...",
+		ShortHelp:  "Compare airdrop results saved by distribution -proto in a single HTML dashboard",
+		LongHelp: `The dashboard has the votes distribution of each run, their share per
+allocation size side by side on the same axis, and the tables of the
+differences with the first run: params and totals, allocation changes and the
+addresses with the largest changes.`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) < 2 {
+				return flag.ErrHelp
+			}
+			fs.Parse(args)
+			var theme chartTheme
+			if *chartThemeFile != "" {
+				var err error
+				theme, err = parseChartTheme(*chartThemeFile)
+				if err != nil {
+					return err
+				}
+			}
+			var runs []savedRun
+			for _, file := range fs.Args() {
+				run, err := loadSavedRun(file)
+				if err != nil {
+					return err
+				}
+				runs = append(runs, run)
+			}
+			return compareRuns(ctx, *outputFile, runs, theme, *top, *open)
+		},
+	}
+}
+
+func openSealedCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("open-sealed", flag.ContinueOnError)
+	keyFile := fs.String("key", "", "PEM file of the X25519 private key of the auditor")
+	outDir := fs.String("o", ".", "Directory of the decrypted files")
+	return &ffcli.Command{
+		Name:       "open-sealed",
+		ShortUsage: "govbox open-sealed -key <auditor.pem> <airdrop_sealed.json>",
+		ShortHelp:  "Decrypt the full detail of an airdrop published by distribution -public",
+		LongHelp: `Writes airdrop_result.pb, readable by compare-runs, and airdrop_detail.csv
+into the -o directory.`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return flag.ErrHelp
+			}
+			fs.Parse(args)
+			if *keyFile == "" || fs.NArg() != 1 {
+				return flag.ErrHelp
+			}
+			priv, err := parseAuditorPrivateKey(*keyFile)
+			if err != nil {
+				return err
+			}
+			files, err := openSealedAirdrop(ctx, fs.Arg(0), priv, *outDir)
+			if err != nil {
+				return err
+			}
+			for _, f := range files {
+				fmt.Printf("%s file created.\n", f)
+			}
+			return nil
+		},
+	}
+}
+
+func snapshotsCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("snapshots", flag.ContinueOnError)
+	download := fs.String("download", "", "Name of the snapshot to download")
+	mirror := fs.String("mirror", defaultSnapshotMirror, "URL of the mirror of the pre-packaged exports")
+	outDir := fs.String("o", ".", "With -download, directory of the downloaded files")
+	strict := fs.Bool("strict", false, "With -download, refuse the files without a .sha256 checksum on the mirror, even with -noChecksum")
+	return &ffcli.Command{
+		Name:       "snapshots",
+		ShortUsage: "govbox snapshots [-download <name> -o <path>]",
+		ShortHelp:  "List the known snapshot heights, and download their pre-packaged exports",
+		LongHelp: `The downloaded files are the inputs of the accounts command. Each file is
+verified against the <file>.sha256 checksum of the mirror, which must exist
+unless -noChecksum is set.
+
+Only the prop848 snapshot has published exports on the mirror for now.`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			fs.Parse(args)
+			if *download == "" {
+				printKnownSnapshots(os.Stdout)
+				return nil
+			}
+			s, err := getKnownSnapshot(*download)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(*outDir, 0o755); err != nil {
+				return err
+			}
+			files, err := downloadSnapshot(ctx, *mirror, s, *outDir, *strict)
+			if err != nil {
+				return err
+			}
+			for _, f := range files {
+				fmt.Printf("%s file created.\n", f)
+			}
+			return nil
+		},
+	}
+}
+
+func attributionCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("attribution", flag.ContinueOnError)
+	numValidators := fs.Int("validators", 20, "Number of the most powerful validators that voted to report")
+	numVoters := fs.Int("voters", 20, "Number of the direct voters with the most staked $ATOM to report")
+	profileName := fs.String("profile", "", "Use the parameters of a known historic run (prop848, govgen)")
+	return &ffcli.Command{
+		Name:       "attribution",
+		ShortUsage: "govbox attribution <path>",
+		ShortHelp:  "Rank the validators and large voters by the impact of their vote on the distribution",
+		LongHelp: `Runs the distribution again without the vote of each of the most powerful
+validators and direct voters, and ranks them by the change of the
+nonVotersMultiplier and of the supply ratio (the $ATONE supply over the $ATOM
+supply). Without the vote of a validator, its delegators that didn't vote
+directly count as non-voters.`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return flag.ErrHelp
+			}
+			params := defaultDistriParams()
+			if *profileName != "" {
+				p, err := getProfile(*profileName)
+				if err != nil {
+					return err
+				}
+				params = p.params
+			}
+			accounts, err := parseAccounts(joinInput(args[0], "accounts.json"))
+			if err != nil {
+				return err
+			}
+			attr, err := computeAttribution(ctx, accounts, params, *numValidators, *numVoters)
+			if err != nil {
+				return err
+			}
+			printAttribution(attr)
+			return nil
+		},
+	}
+}
+
+func schemasCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("schemas", flag.ContinueOnError)
+	outDir := fs.String("o", ".", "Directory of the schema files")
+	return &ffcli.Command{
+		Name:       "schemas",
+		ShortUsage: "govbox schemas [-o <dir>]",
+		ShortHelp:  "Write the JSON Schema of each artifact produced by govbox in <dir>/<artifact>.schema.json",
+		LongHelp: `The schemas are generated from the Go types of the artifacts, the published
+ones are in docs/schemas. For the artifacts of one JSON record per line
+(airdrop-ndjson, audit, warnings), the schema is the schema of a line. The
+CSV artifacts (airdrop_detail.csv, airdrop_detail_validators.csv) have no
+schema, their columns are named by their header.`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			files, err := writeArtifactSchemas(ctx, *outDir)
+			if err != nil {
+				return err
+			}
+			for _, f := range files {
+				fmt.Printf("%s file created.\n", f)
+			}
+			return nil
+		},
+	}
+}
+
+func validateCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	artifact := fs.String("artifact", "", "Artifact of <file> (accounts, withholding, public, airdrop, airdrop-ndjson, audit or warnings), detected from the file name by default")
+	return &ffcli.Command{
+		Name:       "validate",
+		ShortUsage: "govbox validate [-artifact <name>] <file>",
+		ShortHelp:  "Check that <file> matches the JSON Schema of its artifact",
+		LongHelp: fmt.Sprintf(`Prints the values that don't match the schema with their JSON path, up to
+%d of them.`, maxSchemaViolations),
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return flag.ErrHelp
+			}
+			var (
+				a   artifactSchema
+				err error
+			)
+			if *artifact != "" {
+				a, err = getArtifactSchema(*artifact)
+			} else {
+				a, err = detectArtifactSchema(args[0])
+			}
+			if err != nil {
+				return err
+			}
+			f, err := openInput(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			violations, err := validateArtifact(ctx, f, a)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+			for _, v := range violations {
+				fmt.Println(v)
+			}
+			if len(violations) > 0 {
+				return fmt.Errorf("%s doesn't match the %s schema", args[0], a.name)
+			}
+			fmt.Printf("%s matches the %s schema\n", args[0], a.name)
+			return nil
+		},
+	}
+}
+
+func splitExportCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("split-export", flag.ContinueOnError)
+	proposalID := fs.String("proposal", "", "Id of the proposal of the votes and of prop.json")
+	return &ffcli.Command{
+		Name:       "split-export",
+		ShortUsage: "govbox split-export -proposal <id> <export.json> [<path>]",
+		ShortHelp:  "Extract the input files of govbox from a state export into <path> (default to the current directory)",
+		LongHelp: `Streams <export.json>, the output of gaiad export, and writes the files of
+the jq commands of SNAPSHOT-EXTRACT.md: auth_genesis.json, balances.json,
+supply.json, delegations.json, active_validators.json (the bonded validators
+sorted by tokens, up to max_validators), votes.json (the votes of -proposal)
+prop.json and gov_params.json (the quorum and thresholds of the tally). The
+gov v1 votes and proposal are converted to v1beta1.
+<export.json> can also be an https:// or s3:// URL. If the votes must come
+from the block before the tally, split that export into another directory
+and copy its votes.json.`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) < 1 || len(args) > 2 || *proposalID == "" {
+				return flag.ErrHelp
+			}
+			datapath := "."
+			if len(args) == 2 {
+				datapath = args[1]
+			}
+			f, err := openInput(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			stats, err := splitExport(ctx, f, datapath, *proposalID)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s balances, %s delegations, %s active validators out of %s bonded, %s votes of proposal %s\n",
+				humanCount(stats.balances), humanCount(stats.delegations), humanCount(stats.active),
+				humanCount(stats.validators), humanCount(stats.votes), *proposalID)
+			for _, file := range splitExportFiles {
+				fmt.Printf("%s file created.\n", filepath.Join(datapath, file))
+			}
+			return nil
+		},
+	}
+}
+
+func signCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("sign", flag.ContinueOnError)
+	keyFile := fs.String("key", "", "Secret key file of the maintainer")
+	keygen := fs.String("keygen", "", "Instead of signing, generate a key pair into <name>.key and <name>.pub")
+	comment := fs.String("comment", "", "Trusted comment of the signatures (default to the timestamp and the file name)")
+	return &ffcli.Command{
+		Name:       "sign",
+		ShortUsage: "govbox sign -key <name.key> <file>... | govbox sign -keygen <name>",
+		ShortHelp:  "Sign the published artifacts (genesis, airdrop.json...) with a maintainer key",
+		LongHelp: `Writes the signature of each <file> into <file>.minisig, checked by
+verify-signature. The keys and the signatures use the minisign format, so
+the signatures can also be verified with minisign -Vm <file> -p <name.pub>.
+The secret key generated by -keygen isn't encrypted, keep it offline and
+publish <name>.pub.`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return flag.ErrHelp
+			}
+			fs.Parse(args)
+			if *keygen != "" {
+				secretFile, publicFile, err := generateSigningKeys(ctx, *keygen)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s file created, keep it secret.\n%s file created.\n", secretFile, publicFile)
+				return nil
+			}
+			if *keyFile == "" || fs.NArg() == 0 {
+				return flag.ErrHelp
+			}
+			key, err := parseSigningKey(*keyFile)
+			if err != nil {
+				return err
+			}
+			for _, file := range fs.Args() {
+				sigFile, err := signFile(ctx, key, file, *comment)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s file created.\n", sigFile)
+			}
+			return nil
+		},
+	}
+}
+
+func verifySignatureCmd() *ffcli.Command {
+	fs := flag.NewFlagSet("verify-signature", flag.ContinueOnError)
+	pubKeys := fs.String("pubkeys", "", "Comma-separated list of the public key files of the maintainers")
+	return &ffcli.Command{
+		Name:       "verify-signature",
+		ShortUsage: "govbox verify-signature -pubkeys <a.pub,b.pub> <file>...",
+		ShortHelp:  "Verify the maintainer signatures of the published artifacts",
+		LongHelp: `Verifies that <file>.minisig is a signature of each <file> by one of the
+-pubkeys. <file> can also be an https:// or s3:// URL, with its signature
+next to it.`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return flag.ErrHelp
+			}
+			fs.Parse(args)
+			if *pubKeys == "" || fs.NArg() == 0 {
+				return flag.ErrHelp
+			}
+			keys, err := parseVerifyingKeys(strings.Split(*pubKeys, ","))
+			if err != nil {
+				return err
+			}
+			var failed int
+			for _, file := range fs.Args() {
+				key, comment, err := verifyFileSignature(ctx, file, file+signatureExt, keys)
+				if err != nil {
+					fmt.Println(err)
+					failed++
+					continue
+				}
+				fmt.Printf("%s is signed by %s (key %s, %s)\n", file, key.file, key.id, comment)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d signatures failed the verification", failed, fs.NArg())
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+// SourceAmount is the part of a merged account that comes from the snapshot
+// of one chain (see mergeChainAccounts).
+type SourceAmount struct {
+	// Chain is the bech32 prefix of the account address on the chain.
+	Chain        string
+	Address      string
+	LiquidAmount sdk.Dec
+	StakedAmount sdk.Dec
+	LockedAmount *sdk.Dec `json:",omitempty"`
+}
+
+// chainMerge are the statistics of the merge of the accounts of a chain.
+type chainMerge struct {
+	chain    string
+	accounts int
+	// merged is the number of accounts whose key was already known, the
+	// other ones are added.
+	merged int
+	liquid sdk.Dec
+	staked sdk.Dec
+}
+
+// sourceAmount returns the amounts of acc as a SourceAmount.
+func sourceAmount(acc Account) SourceAmount {
+	hrp, _, _ := bech32.DecodeAndConvert(acc.Address)
+	return SourceAmount{
+		Chain:        hrp,
+		Address:      acc.Address,
+		LiquidAmount: acc.LiquidAmount,
+		StakedAmount: acc.StakedAmount,
+		LockedAmount: acc.LockedAmount,
+	}
+}
+
+// mergeChainAccounts merges the accounts of the snapshots of other chains
+// into accounts: the balances of the addresses with the same key (the same
+// pubkey with a different bech32 prefix) are added to the account, and the
+// others are added as new accounts with the prefix address. The amounts of
+// each chain are kept in the Sources of the merged accounts.
+//
+// The votes of the other chains are on other proposals, so their direct votes
+// are dropped, and their delegations are tagged with their Chain: they don't
+// inherit the vote of their validator nor the direct vote of the account, and
+// their validators aren't hub validators.
+func mergeChainAccounts(accounts []Account, chains [][]Account, prefix string) ([]Account, []chainMerge) {
+	idxByKey := make(map[addrKey]int, len(accounts))
+	for i, acc := range accounts {
+		if key, err := acc.addrKey(); err == nil {
+			idxByKey[key] = i
+		}
+	}
+	merges := make([]chainMerge, len(chains))
+	for c, chainAccounts := range chains {
+		m := &merges[c]
+		m.liquid, m.staked = sdk.ZeroDec(), sdk.ZeroDec()
+		for _, acc := range chainAccounts {
+			key, err := acc.addrKey()
+			if err != nil {
+				warnings.add(warnInvalidAddr, acc.Address)
+				continue
+			}
+			src := sourceAmount(acc)
+			if m.chain == "" {
+				m.chain = src.Chain
+			}
+			m.accounts++
+			m.liquid = m.liquid.Add(acc.LiquidAmount)
+			m.staked = m.staked.Add(acc.StakedAmount)
+			delegations := slices.Clone(acc.Delegations)
+			for j := range delegations {
+				delegations[j].Vote = nil
+				delegations[j].Chain = src.Chain
+			}
+			i, ok := idxByKey[key]
+			if !ok {
+				idxByKey[key] = len(accounts)
+				accounts = append(accounts, Account{
+					Address:      key.bech32(prefix),
+					Type:         acc.Type,
+					LiquidAmount: acc.LiquidAmount,
+					StakedAmount: acc.StakedAmount,
+					LockedAmount: acc.LockedAmount,
+					Delegations:  delegations,
+					Sources:      []SourceAmount{src},
+					key:          key,
+				})
+				continue
+			}
+			m.merged++
+			target := &accounts[i]
+			if len(target.Sources) == 0 {
+				target.Sources = []SourceAmount{sourceAmount(*target)}
+			}
+			target.Sources = append(target.Sources, src)
+			target.LiquidAmount = target.LiquidAmount.Add(acc.LiquidAmount)
+			target.StakedAmount = target.StakedAmount.Add(acc.StakedAmount)
+			if acc.LockedAmount != nil {
+				locked := target.lockedAmount().Add(*acc.LockedAmount)
+				target.LockedAmount = &locked
+			}
+			target.Delegations = append(slices.Clip(target.Delegations), delegations...)
+		}
+	}
+	return accounts, merges
+}
+
+func printChainMerges(merges []chainMerge) {
+	table := newMarkdownTable("CHAIN", "ACCOUNTS", "MERGED", "ADDED", "LIQUID", "STAKED")
+	for _, m := range merges {
+		table.Append([]string{
+			m.chain,
+			humanCount(m.accounts),
+			humanCount(m.merged),
+			humanCount(m.accounts - m.merged),
+			human(m.liquid.TruncateInt()),
+			human(m.staked.TruncateInt()),
+		})
+	}
+	table.Render()
+	fmt.Println()
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestMergeChainAccounts(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	warnings = newWarningsRegistry()
+	var (
+		addrs = createAccountAddrs(3)
+		yes   = govtypes.NewNonSplitVoteOption(govtypes.OptionYes)
+		key0  = addrKey(addrs[0])
+		key2  = addrKey(addrs[2])
+	)
+	accounts := []Account{
+		{
+			Address: key0.bech32("cosmos"), LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.NewDec(50), Vote: yes,
+			Delegations: []Delegation{{ValidatorAddress: "cosmosvaloper1", Amount: sdk.NewDec(50), Vote: yes}},
+		},
+		{Address: addrs[1].String(), LiquidAmount: sdk.NewDec(10), StakedAmount: sdk.ZeroDec()},
+	}
+	osmosis := []Account{
+		{
+			Address: key0.bech32("osmo"), LiquidAmount: sdk.NewDec(20), StakedAmount: sdk.NewDec(30), Vote: yes,
+			Delegations: []Delegation{{ValidatorAddress: "osmovaloper1", Amount: sdk.NewDec(30), Vote: yes}},
+		},
+		{Address: key2.bech32("osmo"), LiquidAmount: sdk.NewDec(5), StakedAmount: sdk.ZeroDec()},
+		{Address: "invalid", LiquidAmount: sdk.NewDec(1), StakedAmount: sdk.ZeroDec()},
+	}
+	juno := []Account{
+		{Address: key2.bech32("juno"), LiquidAmount: sdk.NewDec(7), StakedAmount: sdk.ZeroDec()},
+	}
+
+	merged, merges := mergeChainAccounts(accounts, [][]Account{osmosis, juno}, "cosmos")
+
+	require.Len(merged, 3)
+	acc := merged[0]
+	assert.Equal(sdk.NewDec(120), acc.LiquidAmount)
+	assert.Equal(sdk.NewDec(80), acc.StakedAmount)
+	assert.Equal(yes, acc.Vote)
+	require.Len(acc.Delegations, 2)
+	assert.Equal(yes, acc.Delegations[0].Vote)
+	// the votes of the other chains are ignored
+	assert.Nil(acc.Delegations[1].Vote)
+	assert.Empty(acc.Delegations[0].Chain)
+	assert.Equal("osmo", acc.Delegations[1].Chain)
+	assert.Equal(yes, osmosis[0].Delegations[0].Vote)
+	assert.Equal([]SourceAmount{
+		{Chain: "cosmos", Address: key0.bech32("cosmos"), LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.NewDec(50)},
+		{Chain: "osmo", Address: key0.bech32("osmo"), LiquidAmount: sdk.NewDec(20), StakedAmount: sdk.NewDec(30)},
+	}, acc.Sources)
+	assert.Empty(merged[1].Sources)
+	// the account only present on other chains is added once
+	added := merged[2]
+	assert.Equal(key2.bech32("cosmos"), added.Address)
+	assert.Equal(sdk.NewDec(12), added.LiquidAmount)
+	assert.Nil(added.Vote)
+	assert.Len(added.Sources, 2)
+	assert.Equal([]chainMerge{
+		{chain: "osmo", accounts: 2, merged: 1, liquid: sdk.NewDec(25), staked: sdk.NewDec(30)},
+		{chain: "juno", accounts: 1, merged: 1, liquid: sdk.NewDec(7), staked: sdk.ZeroDec()},
+	}, merges)
+	assert.Equal(1, warnings.get(warnInvalidAddr))
+
+	airdrop, err := distribution(merged, defaultDistriParams(), "")
+
+	require.NoError(err)
+	require.NotEmpty(airdrop.addressesDetail)
+	require.Equal(acc.Address, airdrop.addressesDetail[0].Address)
+	assert.Equal(acc.Sources, airdrop.addressesDetail[0].Sources)
+}
+
+func TestMergeChainAccountsHubVoter(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs = createAccountAddrs(2)
+		yes   = govtypes.NewNonSplitVoteOption(govtypes.OptionYes)
+		key0  = addrKey(addrs[0])
+	)
+	accounts := []Account{
+		{
+			Address: key0.bech32("cosmos"), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(50), Vote: yes,
+			Delegations: []Delegation{{ValidatorAddress: "cosmosvaloper1", Amount: sdk.NewDec(50)}},
+		},
+		{
+			Address: addrs[1].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100),
+			Delegations: []Delegation{{ValidatorAddress: "cosmosvaloper1", Amount: sdk.NewDec(100), Vote: yes}},
+		},
+	}
+	osmosis := []Account{{
+		Address: key0.bech32("osmo"), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(30),
+		Delegations: []Delegation{{ValidatorAddress: "osmovaloper1", Amount: sdk.NewDec(30)}},
+	}}
+	merged, _ := mergeChainAccounts(accounts, [][]Account{osmosis}, "cosmos")
+	acc := merged[0]
+
+	// the direct vote only applies to the stake on the hub
+	weights := acc.voteWeights()
+	assert.Equal(sdk.NewDec(50).QuoInt64(80), weights[govtypes.OptionYes])
+	assert.Equal(sdk.NewDec(30).QuoInt64(80), weights[govtypes.OptionEmpty])
+	_, ok := fixedVoteWeights(acc)
+	assert.False(ok)
+
+	params := defaultDistriParams()
+	params.entityGroups = nil
+	airdrop, err := distribution(merged, params, "")
+
+	require.NoError(err)
+	assert.Equal(sdk.NewDec(150), airdrop.atom.votes[govtypes.OptionYes])
+	assert.Equal(sdk.NewDec(30), airdrop.atom.votes[govtypes.OptionEmpty])
+	// the validators of the other chains aren't hub validators
+	require.Len(airdrop.validators, 1)
+	assert.Equal("cosmosvaloper1", airdrop.validators[0].address)
+	assert.Equal(sdk.NewDec(150), airdrop.validators[0].power)
+	detail := airdrop.addressesDetail[slices.IndexFunc(airdrop.addressesDetail, func(d addrAmtDetail) bool {
+		return d.Address == acc.Address
+	})]
+	require.Len(detail.Validators, 2)
+	assert.Equal(yes, detail.Validators[0].Vote)
+	assert.Equal("osmo", detail.Validators[1].Chain)
+	assert.Nil(detail.Validators[1].Vote)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+)
+
+// genesisDenom is a denomination of the genesis, with its own airdrop.
+type genesisDenom struct {
+	// ticker is the display denom, the base denom is "u"+ticker.
+	ticker      string
+	name        string
+	description string
+	airdrop     airdrop
+	// meta overrides the metadata derived from the ticker, when the denom is
+	// configured from the chain-registry.
+	meta *banktypes.Metadata
+}
+
+func (d genesisDenom) base() string {
+	if d.meta != nil {
+		return d.meta.Base
+	}
+	return "u" + d.ticker
+}
+
+// atoneDenom returns the genesisDenom of the $ATONE airdrop, the staking
+// token.
+func atoneDenom(airdrop airdrop) genesisDenom {
+	return genesisDenom{
+		ticker:      "atone",
+		name:        "AtomOne Atone",
+		description: "The native staking token of AtomOne Hub",
+		airdrop:     airdrop,
+	}
+}
+
+// denomRules are the distribution rules of an additional genesis denom
+// (e.g. a gas token), the nil parameters keep the value of the $ATONE
+// distribution.
+type denomRules struct {
+	Ticker             string  `json:"ticker"`
+	Name               string  `json:"name"`
+	Description        string  `json:"description"`
+	YesVotesMultiplier sdk.Dec `json:"yesVotesMultiplier"`
+	NoVotesMultiplier  sdk.Dec `json:"noVotesMultiplier"`
+	Bonus              sdk.Dec `json:"bonus"`
+	Malus              sdk.Dec `json:"malus"`
+	SupplyFactor       sdk.Dec `json:"supplyFactor"`
+	SupplyMintFactor   sdk.Dec `json:"supplyMintFactor"`
+}
+
+// parseDenomRules reads the rules of an additional genesis denom from a JSON
+// file, for instance:
+//
+//	{
+//	  "ticker": "photon",
+//	  "name": "AtomOne Photon",
+//	  "description": "The fee token of AtomOne Hub",
+//	  "yesVotesMultiplier": "1",
+//	  "noVotesMultiplier": "1",
+//	  "supplyMintFactor": "0"
+//	}
+func parseDenomRules(path string) (_ denomRules, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return denomRules{}, err
+	}
+	defer closeInput(f, &err)
+	var r denomRules
+	if err := json.NewDecoder(f).Decode(&r); err != nil {
+		return denomRules{}, fmt.Errorf("cannot json decode denom rules from file %s: %w", path, err)
+	}
+	if r.Ticker == "" || r.Ticker != strings.ToLower(r.Ticker) {
+		return denomRules{}, fmt.Errorf("denom rules %s: ticker must be a non-empty lowercase string", path)
+	}
+	if r.Ticker == "atone" {
+		return denomRules{}, fmt.Errorf("denom rules %s: ticker atone is already the staking token", path)
+	}
+	return r, nil
+}
+
+// apply returns params updated with the non-nil parameters of r. The target
+// supply and the Yes ratio are the ones of $ATONE, so they're not applied to
+// the other denoms, which keep the solved supplyFactor and Yes multiplier
+// instead.
+func (r denomRules) apply(params distriParams) distriParams {
+	params.targetSupply = sdk.Int{}
+	params.yesRatio = sdk.Dec{}
+	for _, p := range []struct {
+		v   sdk.Dec
+		dst *sdk.Dec
+	}{
+		{r.YesVotesMultiplier, &params.yesVotesMultiplier},
+		{r.NoVotesMultiplier, &params.noVotesMultiplier},
+		{r.Bonus, &params.bonus},
+		{r.Malus, &params.malus},
+		{r.SupplyFactor, &params.supplyFactor},
+		{r.SupplyMintFactor, &params.supplyMintFactor},
+	} {
+		if !p.v.IsNil() {
+			*p.dst = p.v
+		}
+	}
+	return params
+}
+
+func (r denomRules) denom(airdrop airdrop) genesisDenom {
+	name := r.Name
+	if name == "" {
+		name = "AtomOne " + strings.ToUpper(r.Ticker[:1]) + r.Ticker[1:]
+	}
+	return genesisDenom{
+		ticker:      r.Ticker,
+		name:        name,
+		description: r.Description,
+		airdrop:     airdrop,
+	}
+}
+
+// genesisBalances returns the balances of the airdrops of denoms, the
+// reserved address, the distribution module account (community pool) and the
+// staking incentives module account if any, and the community pool coins. An
+// address of several airdrops gets a single balance with the coins of each
+// denom.
+func genesisBalances(denoms []genesisDenom, prefix string) ([]banktypes.Balance, sdk.Coins) {
+	var (
+		coinsByAddr     = make(map[string]sdk.Coins)
+		communityPool   = sdk.NewCoins()
+		reservedAddr    = sdk.MustBech32ifyAddressBytes(prefix, reservedAddrBz)
+		distrModuleAddr = sdk.MustBech32ifyAddressBytes(prefix, authtypes.NewModuleAddress(distrtypes.ModuleName))
+	)
+	for _, d := range denoms {
+		for addr, amt := range d.airdrop.addresses {
+			coinsByAddr[addr] = coinsByAddr[addr].Add(sdk.NewCoin(d.base(), amt))
+		}
+		coinsByAddr[reservedAddr] = coinsByAddr[reservedAddr].
+			Add(sdk.NewCoin(d.base(), d.airdrop.reservedAddr.RoundInt()))
+		communityPool = communityPool.Add(sdk.NewCoin(d.base(), d.airdrop.communityPool.RoundInt()))
+		if d.airdrop.params.hasStakingIncentives() {
+			incentivesAddr := stakingIncentivesModuleAddr(prefix)
+			coinsByAddr[incentivesAddr] = coinsByAddr[incentivesAddr].
+				Add(sdk.NewCoin(d.base(), d.airdrop.stakingIncentives.RoundInt()))
+		}
+	}
+	// same amount as the community pool must be distributed to the
+	// distribution module account
+	coinsByAddr[distrModuleAddr] = coinsByAddr[distrModuleAddr].Add(communityPool...)
+	balances := make([]banktypes.Balance, 0, len(coinsByAddr))
+	for _, addr := range slices.Sorted(maps.Keys(coinsByAddr)) {
+		balances = append(balances, banktypes.Balance{Address: addr, Coins: coinsByAddr[addr]})
+	}
+	return balances, communityPool
+}
+
+func (d genesisDenom) metadata() banktypes.Metadata {
+	if d.meta != nil {
+		return *d.meta
+	}
+	return banktypes.Metadata{
+		Display:     d.ticker,
+		Symbol:      strings.ToUpper(d.ticker),
+		Base:        d.base(),
+		Name:        d.name,
+		Description: d.description,
+		DenomUnits: []*banktypes.DenomUnit{
+			{
+				Aliases:  []string{"micro" + d.ticker},
+				Denom:    "u" + d.ticker,
+				Exponent: 0,
+			},
+			{
+				Aliases:  []string{"milli" + d.ticker},
+				Denom:    "m" + d.ticker,
+				Exponent: 3,
+			},
+			{
+				Aliases:  []string{d.ticker},
+				Denom:    d.ticker,
+				Exponent: 6,
+			},
+		},
+	}
+}
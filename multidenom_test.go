@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestGenesisDenoms(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	var (
+		addrYes, _ = convertBech32(icfWallets[0], "cosmos", "atone")
+		addrNo, _  = convertBech32(icfWallets[1], "cosmos", "atone")
+		accounts   = []Account{
+			{Address: icfWallets[0], LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000), Vote: vote(govtypes.OptionYes)},
+			{Address: icfWallets[1], LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000), Vote: vote(govtypes.OptionNo)},
+			{Address: icfWallets[2], LiquidAmount: sdk.NewDec(1000), StakedAmount: sdk.ZeroDec()},
+		}
+		params    = defaultDistriParams()
+		rulesFile = filepath.Join(t.TempDir(), "photon.json")
+	)
+	params.entityGroups = nil
+	err := os.WriteFile(rulesFile, []byte(`{"ticker":"photon","noVotesMultiplier":"1","supplyMintFactor":"0"}`), 0o600)
+	require.NoError(err)
+	rules, err := parseDenomRules(rulesFile)
+	require.NoError(err)
+	photonParams := rules.apply(params)
+	assert.Equal(sdk.OneDec(), photonParams.noVotesMultiplier)
+	assert.Equal(params.yesVotesMultiplier, photonParams.yesVotesMultiplier)
+	assert.True(photonParams.supplyMintFactor.IsZero())
+
+	atone, err := distribution(accounts, params, "atone")
+	require.NoError(err)
+	photon, err := distribution(accounts, photonParams, "atone")
+	require.NoError(err)
+
+	balances, communityPool := genesisBalances([]genesisDenom{atoneDenom(atone), rules.denom(photon)}, "atone")
+
+	var (
+		reservedAddr    = sdk.MustBech32ifyAddressBytes("atone", reservedAddrBz)
+		distrModuleAddr = sdk.MustBech32ifyAddressBytes("atone", authtypes.NewModuleAddress(distrtypes.ModuleName))
+		coinsByAddr     = make(map[string]sdk.Coins)
+	)
+	for _, b := range balances {
+		coinsByAddr[b.Address] = b.Coins
+	}
+	require.Len(coinsByAddr, 5)
+	assert.Equal(sdk.NewCoins(sdk.NewInt64Coin("uatone", 100), sdk.NewInt64Coin("uphoton", 100)), coinsByAddr[addrYes])
+	assert.Equal(sdk.NewCoins(sdk.NewInt64Coin("uatone", 900), sdk.NewInt64Coin("uphoton", 100)), coinsByAddr[addrNo])
+	// no minted photon
+	assert.Equal(sdk.NewCoins(sdk.NewCoin("uatone", atone.reservedAddr.RoundInt())), coinsByAddr[reservedAddr])
+	assert.Equal(communityPool, coinsByAddr[distrModuleAddr])
+	assert.Equal(sdk.NewCoins(sdk.NewCoin("uatone", atone.communityPool.RoundInt())), communityPool)
+	assert.False(communityPool.IsZero())
+	assert.Equal("AtomOne Photon", rules.denom(photon).metadata().Name)
+
+	err = os.WriteFile(rulesFile, []byte(`{"ticker":"atone"}`), 0o600)
+	require.NoError(err)
+	_, err = parseDenomRules(rulesFile)
+	assert.Error(err)
+}
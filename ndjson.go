@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Output formats of the per-address results of the distribution.
+const (
+	// outputJSON writes <path>/airdrop.json once the distribution is
+	// complete.
+	outputJSON = "json"
+	// outputNDJSON streams <path>/airdrop.ndjson, one addressResult per line,
+	// as the allocations are computed.
+	outputNDJSON = "ndjson"
+)
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case outputJSON, outputNDJSON:
+		return nil
+	}
+	return fmt.Errorf("unknown output format '%s' (expected %s or %s)", format, outputJSON, outputNDJSON)
+}
+
+// addressResult is the allocation of an address, streamed by the
+// distribution as soon as it's computed.
+type addressResult struct {
+	Address string  `json:"address"`
+	Amount  sdk.Int `json:"amount"`
+}
+
+// ndjsonStream returns a distributionStages stream that writes each result
+// in w, one JSON object per line.
+func ndjsonStream(w io.Writer) func(addressResult) error {
+	enc := json.NewEncoder(w)
+	return func(r addressResult) error {
+		return enc.Encode(r)
+	}
+}
+
+// addResult adds the allocation amt of addr to a, or sends it to stream if
+// the results of a are streamed.
+func (a *airdrop) addResult(stream func(addressResult) error, addr string, amt sdk.Int) error {
+	if !a.streamed {
+		a.addresses[addr] = amt
+		return nil
+	}
+	r := addressResult{Address: addr, Amount: amt}
+	if a.largest.Amount.IsNil() || amt.GT(a.largest.Amount) {
+		a.largest = r
+	}
+	if err := stream(r); err != nil {
+		return fmt.Errorf("stream %s: %w", addr, err)
+	}
+	return nil
+}
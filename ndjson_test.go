@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestNDJSONStream(t *testing.T) {
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100 * M), Vote: vote(govtypes.OptionYes)},
+		{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100 * M), Vote: vote(govtypes.OptionNo)},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100 * M), StakedAmount: sdk.ZeroDec()},
+		{Address: "empty", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.ZeroDec()},
+	}
+	// decode returns the results streamed in buf, in order.
+	decode := func(t *testing.T, buf *bytes.Buffer) []addressResult {
+		t.Helper()
+		var results []addressResult
+		scanner := bufio.NewScanner(buf)
+		for scanner.Scan() {
+			var r addressResult
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+			results = append(results, r)
+		}
+		return results
+	}
+	tests := []struct {
+		name   string
+		params func(*distriParams)
+	}{
+		{
+			name:   "default",
+			params: func(*distriParams) {},
+		},
+		{
+			name: "target supply streams only the solved run",
+			params: func(p *distriParams) {
+				p.targetSupply = sdk.NewInt(1000 * M)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			params := defaultDistriParams()
+			tt.params(&params)
+			expected, err := newDistributionStages(slices.Values(accounts)).run(context.Background(), params, "", checkpointConfig{})
+			require.NoError(err)
+			var buf bytes.Buffer
+			stages := newDistributionStages(slices.Values(accounts))
+			stages.stream = ndjsonStream(&buf)
+
+			airdrop, err := stages.run(context.Background(), params, "", checkpointConfig{})
+
+			require.NoError(err)
+			results := decode(t, &buf)
+			// in the accounts order, without the zero allocations
+			require.Len(results, len(expected.addresses))
+			assert.Equal([]string{"yes", "no", "liquid"}, []string{results[0].Address, results[1].Address, results[2].Address})
+			for _, r := range results {
+				assert.Equal(expected.addresses[r.Address], r.Amount, r.Address)
+			}
+			// the results aren't kept in memory
+			assert.True(airdrop.streamed)
+			assert.Empty(airdrop.addresses)
+			assert.Empty(airdrop.addressesDetail)
+			assert.Empty(airdrop.audit)
+			assert.Equal(expected.atone.supply, airdrop.atone.supply)
+			assert.Equal(addressResult{Address: "no", Amount: expected.addresses["no"]}, airdrop.largest)
+		})
+	}
+
+	t.Run("validate format", func(t *testing.T) {
+		assert.NoError(t, validateOutputFormat(outputJSON))
+		assert.NoError(t, validateOutputFormat(outputNDJSON))
+		assert.ErrorContains(t, validateOutputFormat("csv"), "unknown output format 'csv'")
+	})
+}
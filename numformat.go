@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// numberFormat is how the numbers are printed in the tables and reports.
+type numberFormat struct {
+	// thousands is the digit group separator.
+	thousands string
+	// decimal is the decimal separator.
+	decimal string
+	// machine disables the humanization: no digit grouping, '.' decimal
+	// separator, exact amounts and percentages without the % sign.
+	machine bool
+}
+
+// numberFormats holds the number formats per locale.
+var numberFormats = map[string]numberFormat{
+	"en": {thousands: ",", decimal: "."},
+	"fr": {thousands: "\u202f", decimal: ","}, // narrow no-break space
+	"de": {thousands: ".", decimal: ","},
+	"es": {thousands: ".", decimal: ","},
+	"it": {thousands: ".", decimal: ","},
+	"pt": {thousands: ".", decimal: ","},
+	"ch": {thousands: "'", decimal: "."},
+	"ja": {thousands: ",", decimal: "."},
+}
+
+// numFormat is the number format in use, set by the -locale and -machine
+// flags of the root command.
+var numFormat = numberFormats["en"]
+
+func setNumberFormat(locale string, machine bool) error {
+	f, ok := numberFormats[locale]
+	if !ok {
+		return fmt.Errorf("unknown locale '%s', available locales are: %s",
+			locale, strings.Join(slices.Sorted(maps.Keys(numberFormats)), ", "))
+	}
+	f.machine = machine
+	numFormat = f
+	return nil
+}
+
+// formatInt formats i with the digits grouped by thousands.
+func (f numberFormat) formatInt(i int64) string {
+	s := strconv.FormatInt(i, 10)
+	if f.machine {
+		return s
+	}
+	sign := ""
+	if i < 0 {
+		sign, s = "-", s[1:]
+	}
+	// the first group has 1 to 3 digits, the others have 3
+	first := (len(s)-1)%3 + 1
+	groups := []string{s[:first]}
+	for n := first; n < len(s); n += 3 {
+		groups = append(groups, s[n:n+3])
+	}
+	return sign + strings.Join(groups, f.thousands)
+}
+
+// formatFloat formats v with prec decimals.
+func (f numberFormat) formatFloat(v float64, prec int) string {
+	if f.machine {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strings.Replace(strconv.FormatFloat(v, 'f', prec, 64), ".", f.decimal, 1)
+}
+
+// formatMicro formats the micro-units amount d in units, rounded for humans
+// and exact for machines.
+func (f numberFormat) formatMicro(d sdk.Dec) string {
+	d = d.QuoInt64(M)
+	if f.machine {
+		s := d.String()
+		s = strings.TrimRight(s, "0")
+		return strings.TrimSuffix(s, ".")
+	}
+	return f.formatInt(d.RoundInt64())
+}
+
+// formatPercent formats the ratio d as a percentage with prec decimals.
+func (f numberFormat) formatPercent(d sdk.Dec, prec int, sep string) string {
+	v := d.Mul(sdk.NewDec(100)).MustFloat64()
+	if f.machine {
+		return f.formatFloat(v, prec)
+	}
+	return f.formatFloat(v, prec) + sep + "%"
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestNumberFormat(t *testing.T) {
+	require := require.New(t)
+	defer setNumberFormat("en", false)
+	var (
+		amt  = sdk.NewDec(1_234_567_890_400_000) // uatom
+		perc = sdk.NewDecWithPrec(33456, 5)
+	)
+	tests := []struct {
+		locale      string
+		machine     bool
+		expAmt      string
+		expCount    string
+		expPercent  string
+		expPercentI string
+	}{
+		{locale: "en", expAmt: "1,234,567,890", expCount: "-1,000", expPercent: "33.46 %", expPercentI: "33%"},
+		{locale: "fr", expAmt: "1\u202f234\u202f567\u202f890", expCount: "-1\u202f000", expPercent: "33,46 %", expPercentI: "33%"},
+		{locale: "de", expAmt: "1.234.567.890", expCount: "-1.000", expPercent: "33,46 %", expPercentI: "33%"},
+		{locale: "ch", expAmt: "1'234'567'890", expCount: "-1'000", expPercent: "33.46 %", expPercentI: "33%"},
+		{locale: "fr", machine: true, expAmt: "1234567890.4", expCount: "-1000", expPercent: "33.456", expPercentI: "33.456"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			require.NoError(setNumberFormat(tt.locale, tt.machine))
+
+			assert.Equal(t, tt.expAmt, humand(amt))
+			assert.Equal(t, tt.expAmt, human(amt.TruncateInt()))
+			assert.Equal(t, tt.expCount, humanCount(-1000))
+			assert.Equal(t, tt.expPercent, humanPercent(perc))
+			assert.Equal(t, tt.expPercentI, humanPercentI(perc))
+		})
+	}
+
+	assert.EqualError(t, setNumberFormat("xx", false),
+		"unknown locale 'xx', available locales are: ch, de, en, es, fr, it, ja, pt")
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Metrics of a distribution that can be targeted by the optimizer.
+const (
+	// metricNonVotersPerc is the share of the distributed $ATONE held by the
+	// non-voters.
+	metricNonVotersPerc = "nonVotersPerc"
+	// metricSupply is the final $ATONE supply, in $ATONE.
+	metricSupply = "supply"
+	// metricVotersRatio is the ratio of the distributed $ATONE held by the
+	// voters over the one held by the non-voters.
+	metricVotersRatio = "votersRatio"
+)
+
+var optimizeMetrics = []string{metricNonVotersPerc, metricSupply, metricVotersRatio}
+
+// optimizeTarget is a constraint on a metric of the distribution, with op one
+// of "<=", ">=" or "=" (equal within optimizeTolerance).
+type optimizeTarget struct {
+	metric string
+	op     string
+	value  float64
+}
+
+func (t optimizeTarget) String() string {
+	return fmt.Sprintf("%s%s%g", t.metric, t.op, t.value)
+}
+
+// parseOptimizeTargets parses a comma-separated list of targets, like
+// "nonVotersPerc<=0.33,supply=100000000,votersRatio>=2".
+func parseOptimizeTargets(s string) ([]optimizeTarget, error) {
+	var targets []optimizeTarget
+	for _, str := range strings.Split(s, ",") {
+		var t optimizeTarget
+		for _, op := range []string{"<=", ">=", "="} {
+			if metric, value, ok := strings.Cut(str, op); ok {
+				t.metric, t.op = strings.TrimSpace(metric), op
+				v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid target '%s': %w", str, err)
+				}
+				t.value = v
+				break
+			}
+		}
+		if t.op == "" {
+			return nil, fmt.Errorf("invalid target '%s': expected <metric><op><value> with op <=, >= or =", str)
+		}
+		if !slices.Contains(optimizeMetrics, t.metric) {
+			return nil, fmt.Errorf("invalid target '%s': unknown metric, must be one of %s", str, strings.Join(optimizeMetrics, ", "))
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// optimizeTolerance is the relative tolerance of the "=" targets.
+const optimizeTolerance = 0.01
+
+// penalty returns how far v is from meeting t, relative to the target value,
+// 0 if t is met.
+func (t optimizeTarget) penalty(v float64) float64 {
+	var d float64
+	switch t.op {
+	case "<=":
+		d = max(0, v-t.value)
+	case ">=":
+		d = max(0, t.value-v)
+	case "=":
+		d = math.Abs(v - t.value)
+	}
+	if t.value != 0 {
+		d /= math.Abs(t.value)
+	}
+	if t.op == "=" {
+		d = max(0, d-optimizeTolerance)
+	}
+	return d
+}
+
+// optimizeParam is a parameter searched by the optimizer, within [min,max].
+type optimizeParam struct {
+	name     string
+	min, max float64
+	field    func(*distriParams) *sdk.Dec
+}
+
+// defaultOptimizeParams returns the searched parameters with their default
+// range.
+func defaultOptimizeParams() []optimizeParam {
+	return []optimizeParam{
+		{"yes", 0.5, 5, func(p *distriParams) *sdk.Dec { return &p.yesVotesMultiplier }},
+		{"no", 1, 20, func(p *distriParams) *sdk.Dec { return &p.noVotesMultiplier }},
+		{"bonus", 1, 1.2, func(p *distriParams) *sdk.Dec { return &p.bonus }},
+		{"malus", 0.8, 1, func(p *distriParams) *sdk.Dec { return &p.malus }},
+		{"supplyFactor", 0.01, 1, func(p *distriParams) *sdk.Dec { return &p.supplyFactor }},
+	}
+}
+
+// parseOptimizeRanges returns the default searched parameters with the ranges
+// of s, a comma-separated list like "yes=0.5:2,no=5:10". A range with min=max
+// fixes the parameter.
+func parseOptimizeRanges(s string) ([]optimizeParam, error) {
+	params := defaultOptimizeParams()
+	if s == "" {
+		return params, nil
+	}
+	for _, str := range strings.Split(s, ",") {
+		name, rng, ok := strings.Cut(str, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid range '%s': expected <param>=<min>:<max>", str)
+		}
+		i := slices.IndexFunc(params, func(p optimizeParam) bool { return p.name == name })
+		if i == -1 {
+			return nil, fmt.Errorf("invalid range '%s': unknown parameter %s", str, name)
+		}
+		minStr, maxStr, ok := strings.Cut(rng, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid range '%s': expected <param>=<min>:<max>", str)
+		}
+		lo, err := strconv.ParseFloat(minStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range '%s': %w", str, err)
+		}
+		hi, err := strconv.ParseFloat(maxStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range '%s': %w", str, err)
+		}
+		if lo <= 0 || lo > hi {
+			return nil, fmt.Errorf("invalid range '%s': must be 0 < min <= max", str)
+		}
+		params[i].min, params[i].max = lo, hi
+	}
+	return params, nil
+}
+
+// optimizeResult is a set of parameters evaluated by the optimizer.
+type optimizeResult struct {
+	params  distriParams
+	metrics map[string]float64
+	// cost is the sum of the penalties of the targets, 0 if all are met.
+	cost float64
+}
+
+// airdropMetrics returns the optimizeMetrics of a.
+func airdropMetrics(a airdrop) map[string]float64 {
+	var (
+		nonVoters = a.nonVotersAmount()
+		voters    = a.atone.supply.Sub(nonVoters)
+		m         = map[string]float64{
+			metricSupply: a.totalSupply().QuoInt64(M).MustFloat64(),
+		}
+	)
+	if a.atone.supply.IsPositive() {
+		m[metricNonVotersPerc] = nonVoters.Quo(a.atone.supply).MustFloat64()
+	}
+	if nonVoters.IsPositive() {
+		m[metricVotersRatio] = voters.Quo(nonVoters).MustFloat64()
+	}
+	return m
+}
+
+// optimizeConfig configures the simulated annealing of optimizeDistriParams.
+type optimizeConfig struct {
+	space      []optimizeParam
+	targets    []optimizeTarget
+	iterations int
+	// top is the number of results returned.
+	top  int
+	seed int64
+}
+
+// Temperatures of the simulated annealing, relative to the cost, which also
+// scale the moves of the parameters.
+const (
+	optimizeStartTemp = 1.0
+	optimizeEndTemp   = 1e-3
+	// optimizeMinStep is the minimum step of the moves, relative to the
+	// range of the parameter.
+	optimizeMinStep = 0.005
+)
+
+// optimizeDistriParams searches the parameters of cfg.space that meet
+// cfg.targets, starting from base, with a simulated annealing: at each
+// iteration a parameter is moved randomly, with a step that decreases with
+// the temperature, and the move is kept if it reduces the cost, or with a
+// probability that decreases with the temperature otherwise, which allows to
+// escape the local minimums. It returns the cfg.top best distinct parameter
+// sets evaluated, by increasing cost.
+//
+// The distributions are run on stages, so the $ATOM aggregation is only done
+// once.
+func optimizeDistriParams(ctx context.Context, stages *distributionStages, base distriParams, cfg optimizeConfig) ([]optimizeResult, error) {
+	var (
+		rng       = rand.New(rand.NewSource(cfg.seed))
+		evaluated = make(map[string]optimizeResult)
+	)
+	evaluate := func(x []float64) (optimizeResult, error) {
+		key := fmt.Sprint(x)
+		if r, ok := evaluated[key]; ok {
+			return r, nil
+		}
+		params := base
+		for i, p := range cfg.space {
+			*p.field(&params) = sdk.MustNewDecFromStr(strconv.FormatFloat(x[i], 'f', 3, 64))
+		}
+		airdrop, err := stages.run(ctx, params, "", checkpointConfig{})
+		if err != nil {
+			return optimizeResult{}, err
+		}
+		r := optimizeResult{params: params, metrics: airdropMetrics(airdrop)}
+		for _, t := range cfg.targets {
+			v, ok := r.metrics[t.metric]
+			if !ok {
+				// undefined metric, e.g. ratio without non-voters
+				r.cost += 1
+				continue
+			}
+			r.cost += t.penalty(v)
+		}
+		evaluated[key] = r
+		return r, nil
+	}
+	// Start from the middle of the ranges
+	x := make([]float64, len(cfg.space))
+	for i, p := range cfg.space {
+		x[i] = roundParam((p.min + p.max) / 2)
+	}
+	cur, err := evaluate(x)
+	if err != nil {
+		return nil, err
+	}
+	// Only the parameters with a range are moved
+	var free []int
+	for i, p := range cfg.space {
+		if p.min < p.max {
+			free = append(free, i)
+		}
+	}
+	for k := 0; k < cfg.iterations && len(free) > 0; k++ {
+		temp := optimizeStartTemp * math.Pow(optimizeEndTemp/optimizeStartTemp, float64(k)/float64(cfg.iterations))
+		i := free[rng.Intn(len(free))]
+		p := cfg.space[i]
+		next := slices.Clone(x)
+		step := rng.NormFloat64() * (p.max - p.min) * max(temp, optimizeMinStep) / 2
+		next[i] = roundParam(min(max(x[i]+step, p.min), p.max))
+		r, err := evaluate(next)
+		if err != nil {
+			return nil, err
+		}
+		if r.cost <= cur.cost || rng.Float64() < math.Exp((cur.cost-r.cost)/temp) {
+			x, cur = next, r
+		}
+	}
+	results := make([]optimizeResult, 0, len(evaluated))
+	for _, r := range evaluated {
+		results = append(results, r)
+	}
+	slices.SortFunc(results, func(a, b optimizeResult) int {
+		// the parameters break the ties for a deterministic output
+		return cmp.Or(cmp.Compare(a.cost, b.cost), strings.Compare(a.params.solveKey(), b.params.solveKey()))
+	})
+	return results[:min(len(results), cfg.top)], nil
+}
+
+// roundParam rounds v to the precision of the searched parameters.
+func roundParam(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+func printOptimizeResults(cfg optimizeConfig, results []optimizeResult) {
+	fmt.Printf("Targets: ")
+	for i, t := range cfg.targets {
+		if i > 0 {
+			fmt.Print(", ")
+		}
+		fmt.Print(t)
+	}
+	fmt.Println()
+	headers := []string{"RANK"}
+	for _, p := range cfg.space {
+		headers = append(headers, strings.ToUpper(p.name))
+	}
+	for _, m := range optimizeMetrics {
+		headers = append(headers, strings.ToUpper(m))
+	}
+	headers = append(headers, "COST", "TARGETS MET")
+	table := newMarkdownTable(headers...)
+	for i, r := range results {
+		row := []string{fmt.Sprint(i + 1)}
+		for _, p := range cfg.space {
+			params := r.params
+			row = append(row, p.field(&params).String())
+		}
+		for _, m := range optimizeMetrics {
+			v, ok := r.metrics[m]
+			switch {
+			case !ok:
+				row = append(row, "-")
+			case m == metricNonVotersPerc:
+				row = append(row, humanPercent(sdk.MustNewDecFromStr(strconv.FormatFloat(v, 'f', 6, 64))))
+			case m == metricSupply:
+				row = append(row, humanCount(int64(v)))
+			default:
+				row = append(row, fmt.Sprintf("%.3f", v))
+			}
+		}
+		met := "✗"
+		if r.cost == 0 {
+			met = "✓"
+		}
+		row = append(row, fmt.Sprintf("%.4f", r.cost), met)
+		table.Append(row)
+	}
+	table.Render()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestParseOptimizeTargets(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	targets, err := parseOptimizeTargets("nonVotersPerc<=0.33,supply=100000000,votersRatio>=2")
+
+	require.NoError(err)
+	assert.Equal([]optimizeTarget{
+		{metric: metricNonVotersPerc, op: "<=", value: 0.33},
+		{metric: metricSupply, op: "=", value: 100_000_000},
+		{metric: metricVotersRatio, op: ">=", value: 2},
+	}, targets)
+	_, err = parseOptimizeTargets("unknown<=1")
+	assert.ErrorContains(err, "unknown metric")
+	_, err = parseOptimizeTargets("supply")
+	assert.ErrorContains(err, "expected <metric><op><value>")
+	_, err = parseOptimizeTargets("supply=abc")
+	assert.Error(err)
+}
+
+func TestOptimizeTargetPenalty(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Zero(optimizeTarget{op: "<=", value: 0.33}.penalty(0.3))
+	assert.InDelta(0.1, optimizeTarget{op: "<=", value: 0.5}.penalty(0.55), 1e-9)
+	assert.Zero(optimizeTarget{op: ">=", value: 2}.penalty(3))
+	assert.InDelta(0.5, optimizeTarget{op: ">=", value: 2}.penalty(1), 1e-9)
+	// "=" is met within optimizeTolerance
+	assert.Zero(optimizeTarget{op: "=", value: 100}.penalty(100.5))
+	assert.InDelta(0.09, optimizeTarget{op: "=", value: 100}.penalty(90), 1e-9)
+}
+
+func TestParseOptimizeRanges(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	space, err := parseOptimizeRanges("yes=1:1,supplyFactor=0.1:0.5")
+
+	require.NoError(err)
+	yes := space[slices.IndexFunc(space, func(p optimizeParam) bool { return p.name == "yes" })]
+	assert.Equal(1.0, yes.min)
+	assert.Equal(1.0, yes.max)
+	factor := space[slices.IndexFunc(space, func(p optimizeParam) bool { return p.name == "supplyFactor" })]
+	assert.Equal(0.1, factor.min)
+	assert.Equal(0.5, factor.max)
+	_, err = parseOptimizeRanges("unknown=1:2")
+	assert.ErrorContains(err, "unknown parameter")
+	_, err = parseOptimizeRanges("yes=2:1")
+	assert.ErrorContains(err, "min <= max")
+}
+
+func TestOptimizeDistriParams(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100 * M), Vote: vote(govtypes.OptionYes)},
+		{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100 * M), Vote: vote(govtypes.OptionNo)},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100 * M), StakedAmount: sdk.ZeroDec()},
+	}
+	space, err := parseOptimizeRanges("yes=1:1,no=9:9,bonus=1.03:1.03,malus=0.97:0.97")
+	require.NoError(err)
+	targets, err := parseOptimizeTargets("supply=100,nonVotersPerc<=0.34")
+	require.NoError(err)
+	cfg := optimizeConfig{space: space, targets: targets, iterations: 200, top: 3, seed: 1}
+
+	results, err := optimizeDistriParams(context.Background(), newDistributionStages(slices.Values(accounts)), defaultDistriParams(), cfg)
+
+	require.NoError(err)
+	require.Len(results, 3)
+	best := results[0]
+	assert.Zero(best.cost)
+	assert.InEpsilon(100, best.metrics[metricSupply], optimizeTolerance)
+	assert.LessOrEqual(best.metrics[metricNonVotersPerc], 0.34)
+	// only supplyFactor is searched
+	assert.Equal(sdk.NewDec(9), best.params.noVotesMultiplier)
+	for i := 1; i < len(results); i++ {
+		assert.LessOrEqual(results[i-1].cost, results[i].cost)
+	}
+	// same seed, same results
+	again, err := optimizeDistriParams(context.Background(), newDistributionStages(slices.Values(accounts)), defaultDistriParams(), cfg)
+	require.NoError(err)
+	assert.Equal(best.params.supplyFactor, again[0].params.supplyFactor)
+}
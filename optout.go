@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Policies applicable to the allocations of opted-out addresses.
+const (
+	// optOutPolicyCommunityPool sends the opted-out allocations to the
+	// community pool.
+	optOutPolicyCommunityPool = "community-pool"
+	// optOutPolicyBurn removes the opted-out allocations from the supply.
+	optOutPolicyBurn = "burn"
+
+	policyOptOut = "opt-out"
+)
+
+// optOutStats records the allocations of the addresses that declined the
+// airdrop.
+type optOutStats struct {
+	accounts int
+	// atom is the $ATOM amount (liquid+staked) of the opted-out addresses.
+	atom sdk.Dec
+	// atone is the $ATONE allocation that was redirected.
+	atone sdk.Dec
+}
+
+func validateOptOutPolicy(policy string) error {
+	switch policy {
+	case optOutPolicyCommunityPool, optOutPolicyBurn:
+		return nil
+	}
+	return fmt.Errorf("unknown opt-out policy '%s'", policy)
+}
+
+// parseOptOut reads the opted-out addresses from a JSON file, which is
+// expected to be an array of "cosmos" addresses.
+func parseOptOut(path string) (_ []string, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var addrs []string
+	if err := json.NewDecoder(f).Decode(&addrs); err != nil {
+		return nil, fmt.Errorf("cannot json decode opt-out addresses from file %s: %w", path, err)
+	}
+	return addrs, nil
+}
+
+func reportOptOut(sec *reportSection, airdrop airdrop) {
+	sec.text("Opt-out")
+	dest := "sent to the community pool"
+	if airdrop.params.optOutPolicy == optOutPolicyBurn {
+		dest = "burned"
+	}
+	table := sec.table("ADDRESSES", "$ATOM", "$ATONE", "POLICY")
+	table.Append([]string{
+		fmt.Sprint(airdrop.optOut.accounts),
+		humand(airdrop.optOut.atom),
+		humand(airdrop.optOut.atone),
+		dest,
+	})
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeOutputFile creates file with the content written by write. The content
+// is first written into a temporary file of the same directory, which is
+// renamed to file only if write succeeded and ctx wasn't cancelled meanwhile,
+// so an interruption never leaves an incomplete output file behind.
+func writeOutputFile(ctx context.Context, file string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp) // no-op once renamed
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// writeOutputFiles is writeOutputFile for several files written together,
+// write receives their writers in the same order. None of the files is created
+// if write fails.
+func writeOutputFiles(ctx context.Context, files []string, write func(ws []io.Writer) error) error {
+	ws := make([]io.Writer, 0, len(files))
+	var open func(i int) error
+	open = func(i int) error {
+		if i == len(files) {
+			return write(ws)
+		}
+		return writeOutputFile(ctx, files[i], func(w io.Writer) error {
+			ws = append(ws, w)
+			return open(i + 1)
+		})
+	}
+	return open(0)
+}
+
+// writeFile is like os.WriteFile but uses writeOutputFile.
+func writeFile(ctx context.Context, file string, bz []byte) error {
+	return writeOutputFile(ctx, file, func(w io.Writer) error {
+		_, err := w.Write(bz)
+		return err
+	})
+}
+
+// writeAirdropDetail writes the CSV detail of the allocation of each address
+// of airdrop into out.
+func writeAirdropDetail(out io.Writer, airdrop airdrop) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{
+		"address", "factor",
+		"yesAtomAmt", "yesMultiplier", "yesBonusMalus", "yesAtoneAmt",
+		"noAtomAmt", "noMultiplier", "noBonusMalus", "noAtoneAmt",
+		"nwvAtomAmt", "nwvMultiplier", "nwvBonusMalus", "nwvAtoneAmt",
+		"absAtomAmt", "absMultiplier", "absBonusMalus", "absAtoneAmt",
+		"dnvAtomAmt", "dnvMultiplier", "dnvBonusMalus", "dnvAtoneAmt",
+		"liquidAtomAmt", "liquidMultiplier", "liquidBonusMalus", "liquidAtoneAmt",
+		"lockedAtomAmt", "lockedMultiplier", "lockedBonusMalus", "lockedAtoneAmt",
+		"typeMultiplier", "totalAtoneAmt",
+	})
+	for _, v := range airdrop.addressesDetail {
+		w.Write([]string{
+			v.Address, v.YesDetail.Factor.String(),
+			v.YesDetail.AtomAmt.String(), v.YesDetail.Multiplier.String(), v.YesDetail.BonusMalus.String(), v.YesDetail.AtoneAmt.String(),
+			v.NoDetail.AtomAmt.String(), v.NoDetail.Multiplier.String(), v.NoDetail.BonusMalus.String(), v.NoDetail.AtoneAmt.String(),
+			v.NWVDetail.AtomAmt.String(), v.NWVDetail.Multiplier.String(), v.NWVDetail.BonusMalus.String(), v.NWVDetail.AtoneAmt.String(),
+			v.AbsDetail.AtomAmt.String(), v.AbsDetail.Multiplier.String(), v.AbsDetail.BonusMalus.String(), v.AbsDetail.AtoneAmt.String(),
+			v.DnvDetail.AtomAmt.String(), v.DnvDetail.Multiplier.String(), v.DnvDetail.BonusMalus.String(), v.DnvDetail.AtoneAmt.String(),
+			v.LiquidDetail.AtomAmt.String(), v.LiquidDetail.Multiplier.String(), v.LiquidDetail.BonusMalus.String(), v.LiquidDetail.AtoneAmt.String(),
+			v.LockedDetail.AtomAmt.String(), v.LockedDetail.Multiplier.String(), v.LockedDetail.BonusMalus.String(), v.LockedDetail.AtoneAmt.String(),
+			v.TypeMultiplier.String(), v.Total.String(),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeAirdropValidatorDetail writes the CSV breakdown of the allocation of
+// each address of airdrop by delegation validator into out, one row per
+// address and validator. The vote is the vote applied to the delegation, as
+// option:weight pairs separated by spaces, empty if none voted.
+func writeAirdropValidatorDetail(out io.Writer, airdrop airdrop) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"address", "validator", "vote", "atomAmt", "atoneAmt"})
+	for _, v := range airdrop.addressesDetail {
+		for _, d := range v.Validators {
+			var vote []string
+			for _, o := range d.Vote {
+				vote = append(vote, voteOptionNames[o.Option]+":"+o.Weight.String())
+			}
+			w.Write([]string{v.Address, d.ValidatorAddress, strings.Join(vote, " "), d.AtomAmt.String(), d.AtoneAmt.String()})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteOutputFile(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "out.json")
+
+		err := writeFile(context.Background(), file, []byte("content"))
+
+		require.NoError(t, err)
+		bz, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(bz))
+		entries, _ := os.ReadDir(dir)
+		assert.Len(t, entries, 1, "temporary file must be removed")
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "out.json")
+		ctx, cancel := context.WithCancel(context.Background())
+
+		err := writeOutputFile(ctx, file, func(w io.Writer) error {
+			w.Write([]byte("partial"))
+			cancel()
+			return nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		entries, _ := os.ReadDir(dir)
+		assert.Empty(t, entries)
+	})
+	t.Run("write error keeps existing file", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "out.json")
+		require.NoError(t, os.WriteFile(file, []byte("previous"), 0o644))
+
+		err := writeOutputFile(context.Background(), file, func(w io.Writer) error {
+			return errors.New("oops")
+		})
+
+		assert.EqualError(t, err, "oops")
+		bz, _ := os.ReadFile(file)
+		assert.Equal(t, "previous", string(bz))
+		entries, _ := os.ReadDir(dir)
+		assert.Len(t, entries, 1)
+	})
+}
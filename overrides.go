@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// voteFlip represents a delegator vote that differs from its validator vote.
+type voteFlip struct {
+	validator govtypes.VoteOption
+	delegator govtypes.VoteOption
+}
+
+func (f voteFlip) String() string {
+	return fmt.Sprintf("%s → %s", voteOptionLabel(f.validator), voteOptionLabel(f.delegator))
+}
+
+type overrideStats struct {
+	// number of delegators who voted directly
+	directVoters int
+	// number of delegators who overrode at least one of their validator votes
+	overriders int
+	// stake of the delegations whose validator vote was overridden
+	overriddenStake sdk.Dec
+	// stake of the delegations of direct voters whose validator didn't vote
+	noValidatorVoteStake sdk.Dec
+	// stake of the delegations of direct voters that match the validator vote
+	sameVoteStake sdk.Dec
+	// stake and count per vote flip
+	flipStakes map[voteFlip]sdk.Dec
+	flipCounts map[voteFlip]int
+}
+
+// mainVoteOption returns the vote option with the highest weight, or
+// OptionEmpty if there's no vote.
+func mainVoteOption(vote govtypes.WeightedVoteOptions) govtypes.VoteOption {
+	var (
+		option = govtypes.OptionEmpty
+		weight = sdk.ZeroDec()
+	)
+	for _, v := range vote {
+		if v.Weight.GT(weight) {
+			option = v.Option
+			weight = v.Weight
+		}
+	}
+	return option
+}
+
+func voteOptionLabel(o govtypes.VoteOption) string {
+	switch o {
+	case govtypes.OptionYes:
+		return "Yes"
+	case govtypes.OptionNo:
+		return "No"
+	case govtypes.OptionNoWithVeto:
+		return "NWV"
+	case govtypes.OptionAbstain:
+		return "Abstain"
+	default:
+		return "DNV"
+	}
+}
+
+func computeOverrideStats(accounts []Account) overrideStats {
+	s := overrideStats{
+		overriddenStake:      sdk.ZeroDec(),
+		noValidatorVoteStake: sdk.ZeroDec(),
+		sameVoteStake:        sdk.ZeroDec(),
+		flipStakes:           make(map[voteFlip]sdk.Dec),
+		flipCounts:           make(map[voteFlip]int),
+	}
+	for _, acc := range accounts {
+		if len(acc.Vote) == 0 || len(acc.Delegations) == 0 {
+			continue
+		}
+		s.directVoters++
+		var (
+			delegatorOption = mainVoteOption(acc.Vote)
+			overrode        bool
+		)
+		for _, del := range acc.Delegations {
+			if del.Chain != "" {
+				// not a hub validator
+				continue
+			}
+			if len(del.Vote) == 0 {
+				s.noValidatorVoteStake = s.noValidatorVoteStake.Add(del.Amount)
+				continue
+			}
+			validatorOption := mainVoteOption(del.Vote)
+			if validatorOption == delegatorOption {
+				s.sameVoteStake = s.sameVoteStake.Add(del.Amount)
+				continue
+			}
+			overrode = true
+			flip := voteFlip{validator: validatorOption, delegator: delegatorOption}
+			if _, ok := s.flipStakes[flip]; !ok {
+				s.flipStakes[flip] = sdk.ZeroDec()
+			}
+			s.flipStakes[flip] = s.flipStakes[flip].Add(del.Amount)
+			s.flipCounts[flip]++
+			s.overriddenStake = s.overriddenStake.Add(del.Amount)
+		}
+		if overrode {
+			s.overriders++
+		}
+	}
+	return s
+}
+
+// sortedFlips returns the flips sorted by descending stake.
+func (s overrideStats) sortedFlips() []voteFlip {
+	flips := make([]voteFlip, 0, len(s.flipStakes))
+	for f := range s.flipStakes {
+		flips = append(flips, f)
+	}
+	sort.Slice(flips, func(i, j int) bool {
+		return s.flipStakes[flips[i]].GT(s.flipStakes[flips[j]])
+	})
+	return flips
+}
+
+func printOverrideStats(ctx context.Context, chartMode bool, export chartExport, s overrideStats) error {
+	if chartMode {
+		page := components.NewPage()
+		page.PageTitle = "Delegator overrides"
+		page.AddCharts(newOverridesBarChart(s, export.theme))
+		return renderPage(ctx, page, export)
+	}
+	totalStake := s.overriddenStake.Add(s.sameVoteStake).Add(s.noValidatorVoteStake)
+	// The percentages are zero without stake, e.g. a snapshot without
+	// delegations.
+	percentOf := func(d, total sdk.Dec) string {
+		if total.IsZero() {
+			return humanPercent(sdk.ZeroDec())
+		}
+		return humanPercent(d.Quo(total))
+	}
+	table := newMarkdownTable("", "DELEGATORS", "$ATOM", "PERCENTAGE")
+	table.Append([]string{"Direct voters with delegations", humanCount(s.directVoters), humand(totalStake), ""})
+	table.Append([]string{
+		"Overrode validator vote", humanCount(s.overriders), humand(s.overriddenStake),
+		percentOf(s.overriddenStake, totalStake),
+	})
+	table.Append([]string{"Same vote as validator", "", humand(s.sameVoteStake), percentOf(s.sameVoteStake, totalStake)})
+	table.Append([]string{"Validator didn't vote", "", humand(s.noValidatorVoteStake), percentOf(s.noValidatorVoteStake, totalStake)})
+	table.Render()
+	fmt.Println()
+
+	table = newMarkdownTable("VALIDATOR → DELEGATOR", "DELEGATIONS", "$ATOM", "PERCENTAGE OF OVERRIDES")
+	for _, flip := range s.sortedFlips() {
+		table.Append([]string{
+			flip.String(),
+			humanCount(s.flipCounts[flip]),
+			humand(s.flipStakes[flip]),
+			percentOf(s.flipStakes[flip], s.overriddenStake),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+func newOverridesBarChart(s overrideStats, theme chartTheme) *charts.Bar {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(append([]charts.GlobalOpts{
+		charts.WithTitleOpts(opts.Title{Title: "Validator votes overridden by delegators"}),
+		charts.WithLegendOpts(opts.Legend{Show: true, Right: "right", Orient: "vertical"}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: true}),
+	}, theme.globalOpts()...)...)
+	var (
+		flips  = s.sortedFlips()
+		xaxis  = make([]string, len(flips))
+		stakes = make([]opts.BarData, len(flips))
+		counts = make([]opts.BarData, len(flips))
+	)
+	for i, flip := range flips {
+		xaxis[i] = flip.String()
+		stakes[i] = opts.BarData{Value: s.flipStakes[flip].QuoInt64(M).RoundInt64()}
+		counts[i] = opts.BarData{Value: s.flipCounts[flip]}
+	}
+	bar.SetXAxis(xaxis)
+	bar.AddSeries("$ATOM", stakes)
+	bar.AddSeries("Delegations", counts)
+	return bar
+}
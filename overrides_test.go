@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestOverrideStatsZeroStake(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		yes = govtypes.WeightedVoteOptions{{Option: govtypes.OptionYes, Weight: sdk.OneDec()}}
+		no  = govtypes.WeightedVoteOptions{{Option: govtypes.OptionNo, Weight: sdk.OneDec()}}
+	)
+	accounts := []Account{
+		// a direct voter without delegations is skipped
+		{Address: "cosmos1a", Vote: yes, LiquidAmount: sdk.NewDec(10)},
+		// a direct voter overriding an empty delegation
+		{Address: "cosmos1b", Vote: yes, Delegations: []Delegation{{Amount: sdk.ZeroDec(), Vote: no}}},
+	}
+
+	s := computeOverrideStats(accounts)
+
+	assert.Equal(1, s.directVoters)
+	assert.Equal(1, s.overriders)
+	assert.True(s.overriddenStake.IsZero())
+	require.NotPanics(func() {
+		require.NoError(printOverrideStats(context.Background(), false, chartExport{}, s))
+	})
+	require.NotPanics(func() {
+		require.NoError(printOverrideStats(context.Background(), false, chartExport{}, computeOverrideStats(nil)))
+	})
+}
@@ -1,15 +1,16 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/cosmos/gogoproto/jsonpb"
-	h "github.com/dustin/go-humanize"
 
 	"github.com/cosmos/cosmos-sdk/codec"
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
@@ -39,6 +40,8 @@ func init() {
 	authtypes.RegisterInterfaces(registry)
 	vestingtypes.RegisterInterfaces(registry)
 	icatypes.RegisterInterfaces(registry)
+	stakingtypes.RegisterInterfaces(registry)
+	banktypes.RegisterInterfaces(registry)
 	marshaler = jsonpb.Marshaler{AnyResolver: registry}
 	unmarshaler = jsonpb.Unmarshaler{AnyResolver: registry}
 	// FIXME: replace marshaler and unmarshaler by cdc?
@@ -48,59 +51,71 @@ func init() {
 const M = 1_000_000 // 1 million
 
 func human(i sdk.Int) string {
+	if numFormat.machine {
+		return numFormat.formatMicro(i.ToLegacyDec())
+	}
 	M := sdk.NewInt(M)
-	return h.Comma(i.Quo(M).Int64())
+	return numFormat.formatInt(i.Quo(M).Int64())
 }
 
 func humani(i int64) string {
-	return h.Comma(i / M)
+	if numFormat.machine {
+		return numFormat.formatMicro(sdk.NewDec(i))
+	}
+	return numFormat.formatInt(i / M)
 }
 
 func humand(d sdk.Dec) string {
-	M := sdk.NewDec(1_000_000)
-	return h.Comma(d.Quo(M).RoundInt64())
+	return numFormat.formatMicro(d)
+}
+
+// humanCount formats a number of items (accounts, votes...).
+func humanCount[T int | int64](n T) string {
+	return numFormat.formatInt(int64(n))
 }
 
 func humanPercentI(d sdk.Dec) string {
-	return fmt.Sprintf("%d%%", d.Mul(sdk.NewDec(100)).RoundInt64())
+	return numFormat.formatPercent(d, 0, "")
 }
 
 func humanPercent(d sdk.Dec) string {
-	return fmt.Sprintf("%.2f %%", d.Mul(sdk.NewDec(100)).MustFloat64())
+	return numFormat.formatPercent(d, 2, " ")
 }
 
 // convertBech32 derive addr from src to dst bech32 prefix.
 func convertBech32(addr, src, dst string) (string, error) {
-	sdkAddr, err := sdk.GetFromBech32(addr, src)
-	if err != nil {
-		return "", fmt.Errorf("GetFromBech32 '%s': %v", addr, err)
+	if sep := strings.LastIndexByte(addr, '1'); sep < 0 || !strings.EqualFold(addr[:sep], src) {
+		return "", fmt.Errorf("convert '%s': expected prefix %s", addr, src)
 	}
-	addr, err = sdk.Bech32ifyAddressBytes(dst, sdkAddr)
+	bz, err := appendBech32Prefix(nil, addr, dst)
 	if err != nil {
-		return "", fmt.Errorf("Bech32ifyAddressBytes '%s': %v", addr, err)
+		return "", err
 	}
-	return addr, nil
+	return string(bz), nil
 }
 
-func parseAccounts(path string) ([]Account, error) {
-	f, err := os.Open(path)
+func parseAccounts(path string) (_ []Account, err error) {
+	f, err := openInput(context.Background(), path)
 	if err != nil {
 		return nil, fmt.Errorf("cannot read %s file, run `%s accounts` to generate it: %w", path, os.Args[0], err)
 	}
-	defer f.Close()
+	defer closeInput(f, &err)
 	var accounts []Account
 	if err := json.NewDecoder(f).Decode(&accounts); err != nil {
 		return nil, fmt.Errorf("cannot json decode accounts from file %s: %w", path, err)
 	}
+	for i := range accounts {
+		accounts[i] = accounts[i].withKey()
+	}
 	return accounts, nil
 }
 
-func parseAccountTypesPerAddr(path string) (map[string]string, error) {
-	f, err := os.Open(filepath.Join(path, "auth_genesis.json"))
+func parseAccountTypesPerAddr(path string) (_ map[string]string, err error) {
+	f, err := openInput(context.Background(), joinInput(path, "auth_genesis.json"))
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	defer closeInput(f, &err)
 	var genesis authtypes.GenesisState
 	err = unmarshaler.Unmarshal(f, &genesis)
 	if err != nil {
@@ -110,138 +125,241 @@ func parseAccountTypesPerAddr(path string) (map[string]string, error) {
 	for i, any := range genesis.Accounts {
 		var acc authtypes.GenesisAccount
 		registry.UnpackAny(any, &acc)
-		accountTypesByAddr[acc.GetAddress().String()] = genesis.Accounts[i].GetTypeUrl()
+		addAccountType(accountTypesByAddr, acc.GetAddress().String(), genesis.Accounts[i].GetTypeUrl())
 	}
-	fmt.Printf("%s accounts\n", h.Comma(int64(len(accountTypesByAddr))))
+	fmt.Printf("%s accounts\n", humanCount(len(accountTypesByAddr)))
 	return accountTypesByAddr, nil
 }
 
-func analyzeVestingAccounts(path string) error {
-	f, err := os.Open(filepath.Join(path, "auth_genesis.json"))
+// vestingAnalysis counts the vesting accounts of auth_genesis.json, of which
+// stillVesting are still vesting at the snapshot time.
+type vestingAnalysis struct {
+	vesting      int
+	stillVesting int
+	totalVesting sdk.Coins
+	// highCap is the number of accounts with more than vestingHighCap
+	// vesting.
+	highCap int
+}
+
+var vestingHighCap = sdk.NewCoins(sdk.NewInt64Coin("uatom", 10000000000))
+
+// analyzeVestingAccounts analyzes the vesting accounts of path at the
+// snapshot block time t, and prints the accounts with more than
+// vestingHighCap vesting.
+func analyzeVestingAccounts(path string, t time.Time) (_ vestingAnalysis, err error) {
+	var a vestingAnalysis
+	f, err := openInput(context.Background(), joinInput(path, "auth_genesis.json"))
 	if err != nil {
-		return err
+		return a, err
 	}
-	defer f.Close()
+	defer closeInput(f, &err)
 	var genesis authtypes.GenesisState
 	err = unmarshaler.Unmarshal(f, &genesis)
 	if err != nil {
-		return err
+		return a, err
 	}
-	var (
-		now             = time.Now()
-		numVesting      int
-		numStillVesting int
-		totalVesting    sdk.Coins
-		blocktime             = time.Unix(1700946028, 0) // time of prop848 2023-11-25 22:00:28 +0100 CET
-		highCapInt      int64 = 10000000000
-		highCap               = sdk.NewCoins(sdk.NewInt64Coin("uatom", highCapInt))
-		numHighCap      int
-	)
 	for i, any := range genesis.Accounts {
 		var acc authtypes.GenesisAccount
 		registry.UnpackAny(any, &acc)
 		if strings.Contains(genesis.Accounts[i].GetTypeUrl(), "Vesting") {
-			numVesting++
+			a.vesting++
 			switch v := acc.(type) {
 			case *vestingtypes.ContinuousVestingAccount:
 				d := time.Unix(v.EndTime, 0)
-				if d.After(now) {
-					numStillVesting++
-					vestingAmt := v.GetVestingCoins(blocktime)
-					totalVesting = totalVesting.Add(vestingAmt...)
-					if vestingAmt.IsAllGT(highCap) {
-						numHighCap++
+				if d.After(t) {
+					a.stillVesting++
+					vestingAmt := v.GetVestingCoins(t)
+					a.totalVesting = a.totalVesting.Add(vestingAmt...)
+					if vestingAmt.IsAllGT(vestingHighCap) {
+						a.highCap++
 						fmt.Println("CONT VEST", acc.GetAddress().String(), d, vestingAmt)
 					}
 				}
 			case *vestingtypes.DelayedVestingAccount:
 				d := time.Unix(v.EndTime, 0)
-				if d.After(now) {
-					numStillVesting++
-					totalVesting = totalVesting.Add(v.OriginalVesting...)
-					if v.OriginalVesting.IsAllGT(highCap) {
-						numHighCap++
+				if d.After(t) {
+					a.stillVesting++
+					a.totalVesting = a.totalVesting.Add(v.OriginalVesting...)
+					if v.OriginalVesting.IsAllGT(vestingHighCap) {
+						a.highCap++
 						fmt.Println("DEL VEST", acc.GetAddress().String(), d, v.OriginalVesting)
 					}
 				}
 			}
 		}
 	}
-	fmt.Printf("%d/%d valid vesting accounts, total of %s freed\n", numStillVesting, numVesting, totalVesting)
-	fmt.Printf("%d vesting account(s) with more than %s vesting\n", numHighCap, highCap)
-	return nil
+	return a, nil
+}
+
+// unmarshalVote unmarshals the JSON vote bz, in the proto JSON format of the
+// gov module or, if it fails, with the vote options of the other tools
+// normalized (see normalizeVoteJSON).
+func unmarshalVote(bz []byte) (govtypes.Vote, error) {
+	var vote govtypes.Vote
+	err := unmarshaler.Unmarshal(bytes.NewReader(bz), &vote)
+	if err == nil {
+		return vote, nil
+	}
+	normalized, nerr := normalizeVoteJSON(bz)
+	if errors.Is(nerr, errUnknownVoteOption) {
+		return vote, nerr
+	}
+	if nerr != nil {
+		return vote, err
+	}
+	vote = govtypes.Vote{}
+	if err := unmarshaler.Unmarshal(bytes.NewReader(normalized), &vote); err != nil {
+		return vote, err
+	}
+	return vote, nil
 }
 
-func parseVotesByAddr(path string) (map[string]govtypes.WeightedVoteOptions, error) {
-	f, err := os.Open(filepath.Join(path, "votes.json"))
+func parseVotesByAddr(ctx context.Context, path string) (map[string]govtypes.WeightedVoteOptions, error) {
+	return parseVotesByAddrDuplicates(ctx, path, duplicateVotesKeepLast)
+}
+
+// parseVotesByAddrDuplicates is parseVotesByAddr with the policy for the
+// voters with several entries (see duplicateVotesKeepLast...), which are
+// counted and reported in the warnings.
+func parseVotesByAddrDuplicates(ctx context.Context, path, policy string) (_ map[string]govtypes.WeightedVoteOptions, err error) {
+	if err := validateDuplicateVotesPolicy(policy); err != nil {
+		return nil, err
+	}
+	f, err := openInput(ctx, joinInput(path, "votes.json"))
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	defer closeInput(f, &err)
 	// XXX workaround to unmarshal votes because proto doesn't support top-level array
 	dec := json.NewDecoder(f)
 	_, err = dec.Token()
 	if err != nil {
 		return nil, err
 	}
-	votesByAddr := make(map[string]govtypes.WeightedVoteOptions)
+	var (
+		votesByAddr = make(map[string]govtypes.WeightedVoteOptions)
+		duplicates  int
+	)
 	for dec.More() {
-		var vote govtypes.Vote
-		err := unmarshaler.UnmarshalNext(dec, &vote)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		vote, err := unmarshalVote(raw)
+		if errors.Is(err, errUnknownVoteOption) {
+			var v struct {
+				Voter string `json:"voter"`
+			}
+			json.Unmarshal(raw, &v)
+			warnings.add(warnVoteUnknownOption, v.Voter)
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
+		if len(vote.Options) == 0 && vote.Option != govtypes.OptionEmpty {
+			// SDK < v0.43 votes, without weighted options
+			vote.Options = govtypes.NewNonSplitVoteOption(vote.Option)
+		}
+		if _, ok := votesByAddr[vote.Voter]; ok {
+			if policy == duplicateVotesError {
+				return nil, fmt.Errorf("duplicate vote of %s in %s", vote.Voter, joinInput(path, "votes.json"))
+			}
+			duplicates++
+			warnings.add(warnVoteDuplicate, vote.Voter)
+			if policy == duplicateVotesKeepFirst {
+				continue
+			}
+		}
 		votesByAddr[vote.Voter] = vote.Options
 	}
-	fmt.Printf("%s votes\n", h.Comma(int64(len(votesByAddr))))
+	fmt.Printf("%s votes\n", humanCount(len(votesByAddr)))
+	if duplicates > 0 {
+		fmt.Printf("%s duplicate votes resolved with %s\n", humanCount(duplicates), policy)
+	}
 	return votesByAddr, nil
 }
 
-func parseDelegationsByAddr(path string) (map[string][]stakingtypes.Delegation, error) {
-	f, err := os.Open(filepath.Join(path, "delegations.json"))
+func parseDelegationsByAddr(ctx context.Context, path string) (_ map[string][]stakingtypes.Delegation, err error) {
+	f, err := openInput(ctx, joinInput(path, "delegations.json"))
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	var delegs []stakingtypes.Delegation
-	err = json.NewDecoder(f).Decode(&delegs)
-	if err != nil {
+	defer closeInput(f, &err)
+	dec := json.NewDecoder(f)
+	if _, err := dec.Token(); err != nil {
 		return nil, err
 	}
-	delegsByAddr := make(map[string][]stakingtypes.Delegation)
-	for _, d := range delegs {
+	var (
+		delegsByAddr = make(map[string][]stakingtypes.Delegation)
+		n            int
+	)
+	for dec.More() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var d stakingtypes.Delegation
+		if err := dec.Decode(&d); err != nil {
+			return nil, err
+		}
 		delegsByAddr[d.DelegatorAddress] = append(delegsByAddr[d.DelegatorAddress], d)
+		n++
 	}
-	fmt.Printf("%s delegations for %s delegators\n", h.Comma(int64(len(delegs))),
-		h.Comma(int64(len(delegsByAddr))))
+	fmt.Printf("%s delegations for %s delegators\n", humanCount(n),
+		humanCount(len(delegsByAddr)))
 	return delegsByAddr, nil
 }
 
-func parseValidatorsByAddr(path string, votesByAddr map[string]govtypes.WeightedVoteOptions) (map[string]govtypes.ValidatorGovInfo, error) {
-	f, err := os.Open(filepath.Join(path, "active_validators.json"))
+// iterateValidators calls fn for each validator of active_validators.json.
+func iterateValidators(ctx context.Context, path string, fn func(stakingtypes.Validator) error) (err error) {
+	f, err := openInput(ctx, joinInput(path, "active_validators.json"))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer f.Close()
+	defer closeInput(f, &err)
 	// XXX workaround to unmarshal validators because proto doesn't support top-level array
 	dec := json.NewDecoder(f)
 	_, err = dec.Token()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	valsByAddr := make(map[string]govtypes.ValidatorGovInfo)
 	for dec.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		var val stakingtypes.Validator
 		err := unmarshaler.UnmarshalNext(dec, &val)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(val); err != nil {
+			return err
 		}
+	}
+	return nil
+}
+
+// parseValidators returns the validators of active_validators.json.
+func parseValidators(ctx context.Context, path string) ([]stakingtypes.Validator, error) {
+	var vals []stakingtypes.Validator
+	err := iterateValidators(ctx, path, func(val stakingtypes.Validator) error {
+		vals = append(vals, val)
+		return nil
+	})
+	return vals, err
+}
 
-		valAddr, err := sdk.ValAddressFromBech32(val.OperatorAddress)
+func parseValidatorsByAddr(ctx context.Context, path string, votesByAddr map[string]govtypes.WeightedVoteOptions) (map[string]govtypes.ValidatorGovInfo, error) {
+	valsByAddr := make(map[string]govtypes.ValidatorGovInfo)
+	err := iterateValidators(ctx, path, func(val stakingtypes.Validator) error {
+		accAddr, err := validatorAccAddr(val.OperatorAddress)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		accAddr := sdk.AccAddress(valAddr.Bytes()).String()
 		valsByAddr[val.OperatorAddress] = govtypes.NewValidatorGovInfo(
 			val.GetOperator(),
 			val.GetBondedTokens(),
@@ -249,46 +367,60 @@ func parseValidatorsByAddr(path string, votesByAddr map[string]govtypes.Weighted
 			sdk.ZeroDec(),
 			votesByAddr[accAddr],
 		)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	fmt.Printf("%d validators\n", len(valsByAddr))
 	return valsByAddr, nil
 }
 
-func parseProp(path string) govtypes.Proposal {
-	f, err := os.Open(filepath.Join(path, "prop.json"))
+func parseProp(path string) (_ govtypes.Proposal, err error) {
+	f, err := openInput(context.Background(), joinInput(path, "prop.json"))
 	if err != nil {
-		panic(err)
+		return govtypes.Proposal{}, err
 	}
-	defer f.Close()
+	defer closeInput(f, &err)
 	var prop govtypes.Proposal
-	err = unmarshaler.Unmarshal(f, &prop)
-	if err != nil {
-		panic(err)
+	if err := unmarshaler.Unmarshal(f, &prop); err != nil {
+		return govtypes.Proposal{}, fmt.Errorf("unmarshal prop: %w", err)
 	}
-	return prop
+	return prop, nil
 }
 
-func parseBalancesByAddr(path, denom string) (map[string]sdk.Coin, error) {
-	f, err := os.Open(filepath.Join(path, "balances.json"))
+func parseBalancesByAddr(ctx context.Context, path, denom string) (_ map[string]sdk.Coin, err error) {
+	f, err := openInput(ctx, joinInput(path, "balances.json"))
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	var balances []banktypes.Balance
-	err = json.NewDecoder(f).Decode(&balances)
-	if err != nil {
+	defer closeInput(f, &err)
+	dec := json.NewDecoder(f)
+	if _, err := dec.Token(); err != nil {
 		return nil, err
 	}
 	balancesByAddr := make(map[string]sdk.Coin)
-	for _, b := range balances {
+	for dec.More() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var b banktypes.Balance
+		if err := dec.Decode(&b); err != nil {
+			return nil, err
+		}
 		for _, c := range b.Coins {
 			// Filter denom
 			if c.Denom == denom {
+				if _, ok := balancesByAddr[b.Address]; ok {
+					// Keep the first balance, adding them would double count it
+					warnings.add(warnBalanceDuplicate, b.Address)
+					break
+				}
 				balancesByAddr[b.Address] = c
 				break
 			}
 		}
 	}
-	fmt.Printf("%s account balances\n", h.Comma(int64(len(balancesByAddr))))
+	fmt.Printf("%s account balances\n", humanCount(len(balancesByAddr)))
 	return balancesByAddr, nil
 }
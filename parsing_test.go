@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// fuzzParser writes each fuzzed input in the file name of a temporary
+// snapshot directory, and calls parse on it, which must return an error
+// instead of panicking on malformed inputs.
+func fuzzParser(f *testing.F, name string, seeds []string, parse func(datapath string) error) {
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, bz []byte) {
+		defer func(w *warningsRegistry) { warnings = w }(warnings)
+		warnings = newWarningsRegistry()
+		datapath := t.TempDir()
+		if err := os.WriteFile(filepath.Join(datapath, name), bz, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		parse(datapath)
+	})
+}
+
+func FuzzParseVotesByAddr(f *testing.F) {
+	fuzzParser(f, "votes.json", []string{
+		`[{"proposal_id":"848","voter":"cosmos1a","options":[{"option":"VOTE_OPTION_YES","weight":"1.000000000000000000"}]}]`,
+		`[{"proposal_id":"848","voter":"cosmos1a","option":"VOTE_OPTION_NO"}]`,
+		`[{"voter":"cosmos1a","options":[{"option":"VOTE_OPTION_YES","weight":"1e999"}]}]`,
+		`[{"voter":"cosmos1a","options":[{"option":42}]}]`,
+		`[{"voter":`,
+		`{}`,
+		`[]`,
+	}, func(datapath string) error {
+		_, err := parseVotesByAddr(context.Background(), datapath)
+		return err
+	})
+}
+
+func FuzzParseDelegationsByAddr(f *testing.F) {
+	fuzzParser(f, "delegations.json", []string{
+		`[{"delegator_address":"cosmos1a","validator_address":"cosmosvaloper1a","shares":"1000.000000000000000000"}]`,
+		`[{"delegator_address":"cosmos1a","validator_address":"cosmosvaloper1a","shares":"-1"}]`,
+		`[{"delegator_address":"cosmos1a","shares":"99999999999999999999999999999999999999999999999999999999999999999999999999999999999"}]`,
+		`[{"shares":1}]`,
+		`[{"delegator_address":`,
+		`null`,
+	}, func(datapath string) error {
+		_, err := parseDelegationsByAddr(context.Background(), datapath)
+		return err
+	})
+}
+
+func FuzzParseBalancesByAddr(f *testing.F) {
+	fuzzParser(f, "balances.json", []string{
+		`[{"address":"cosmos1a","coins":[{"denom":"uatom","amount":"1000"}]}]`,
+		`[{"address":"cosmos1a","coins":[{"denom":"uatom","amount":"-1"}]},{"address":"cosmos1a","coins":[{"denom":"uatom","amount":"2"}]}]`,
+		`[{"address":"cosmos1a","coins":[{"denom":"uatom","amount":"1.5"}]}]`,
+		`[{"address":"cosmos1a","coins":[{"denom":"uatom"}]}]`,
+		`[{"address":"cosmos1a","coins":{}}]`,
+		`[{"address":`,
+	}, func(datapath string) error {
+		_, err := parseBalancesByAddr(context.Background(), datapath, "uatom")
+		return err
+	})
+}
+
+func TestParseCanceled(t *testing.T) {
+	datapath := t.TempDir()
+	for name, content := range map[string]string{
+		"delegations.json": `[{"delegator_address":"cosmos1a","validator_address":"cosmosvaloper1a","shares":"1"}]`,
+		"balances.json":    `[{"address":"cosmos1a","coins":[{"denom":"uatom","amount":"1"}]}]`,
+	} {
+		if err := os.WriteFile(filepath.Join(datapath, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := parseDelegationsByAddr(ctx, datapath)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = parseBalancesByAddr(ctx, datapath, "uatom")
+	assert.ErrorIs(t, err, context.Canceled)
+	addr := createAccountAddrs(1)[0].String()
+	_, err = getAccounts(ctx, nil, nil, nil, map[string]sdk.Coin{addr: sdk.NewInt64Coin("uatom", 1)}, nil, "cosmos")
+	assert.ErrorIs(t, err, context.Canceled)
+}
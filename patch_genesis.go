@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	tmjson "github.com/cometbft/cometbft/libs/json"
+	tmtypes "github.com/cometbft/cometbft/types"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+)
+
+// Where the difference between the corrected balances and the published ones
+// is taken from (or sent to).
+const (
+	// correctionOffsetCommunityPool balances the corrections with the
+	// community pool, so the supply is unchanged.
+	correctionOffsetCommunityPool = "community-pool"
+	// correctionOffsetSupply mints or burns the difference.
+	correctionOffsetSupply = "supply"
+)
+
+// genesisCorrection replaces the balance of an address in a published
+// genesis.
+type genesisCorrection struct {
+	// Address can use any bech32 prefix, it's converted to the one of the
+	// genesis.
+	Address string `json:"address"`
+	// Coins is the corrected balance, like "1000uatone,10uphoton", empty to
+	// remove the account.
+	Coins  string `json:"coins"`
+	Reason string `json:"reason"`
+}
+
+// parseGenesisCorrections reads the corrections from a JSON file, which is
+// expected to be an array of genesisCorrection.
+func parseGenesisCorrections(path string) (_ []genesisCorrection, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var corrections []genesisCorrection
+	if err := json.NewDecoder(f).Decode(&corrections); err != nil {
+		return nil, fmt.Errorf("cannot json decode genesis corrections from file %s: %w", path, err)
+	}
+	return corrections, nil
+}
+
+// genesisChange is a balance modified by patchGenesis.
+type genesisChange struct {
+	address       string
+	before, after sdk.Coins
+	reason        string
+}
+
+// genesisPatch is the outcome of patchGenesis.
+type genesisPatch struct {
+	changes []genesisChange
+	// offset is the sum of the balance differences, taken from the community
+	// pool or the supply according to the offset policy. Negative amounts
+	// mean the balances decreased.
+	offset       map[string]sdk.Int
+	offsetPolicy string
+	// sumBefore and sumAfter are the sha256 checksums of the published and
+	// the patched genesis.
+	sumBefore, sumAfter []byte
+}
+
+// patchGenesis writes in w the published genesis with only the balances of
+// corrections modified, and the community pool or the supply adjusted
+// accordingly (see offsetPolicy). Every other byte is kept identical, which
+// is checked beforehand by re-encoding the unmodified genesis: it fails if
+// the published genesis wasn't encoded by writeGenesis.
+func patchGenesis(w io.Writer, published []byte, corrections []genesisCorrection, offsetPolicy string) (genesisPatch, error) {
+	if offsetPolicy != correctionOffsetCommunityPool && offsetPolicy != correctionOffsetSupply {
+		return genesisPatch{}, fmt.Errorf("unknown offset policy '%s'", offsetPolicy)
+	}
+	var genesisDoc tmtypes.GenesisDoc
+	if err := tmjson.Unmarshal(published, &genesisDoc); err != nil {
+		return genesisPatch{}, fmt.Errorf("unmarshal genesis doc: %w", err)
+	}
+	var appState map[string]json.RawMessage
+	if err := json.Unmarshal(genesisDoc.AppState, &appState); err != nil {
+		return genesisPatch{}, fmt.Errorf("unmarshal appstate: %w", err)
+	}
+	var authGen authtypes.GenesisState
+	if err := cdc.UnmarshalJSON(appState["auth"], &authGen); err != nil {
+		return genesisPatch{}, fmt.Errorf("umarshal auth genesis: %w", err)
+	}
+	var bankGen banktypes.GenesisState
+	if err := cdc.UnmarshalJSON(appState["bank"], &bankGen); err != nil {
+		return genesisPatch{}, fmt.Errorf("umarshal bank genesis: %w", err)
+	}
+	var distrGen distrtypes.GenesisState
+	if err := cdc.UnmarshalJSON(appState["distribution"], &distrGen); err != nil {
+		return genesisPatch{}, fmt.Errorf("umarshal distribution genesis: %w", err)
+	}
+	encode := func(w io.Writer) error {
+		state := maps.Clone(appState)
+		var err error
+		state["auth"], err = cdc.MarshalJSON(&authGen)
+		if err != nil {
+			return fmt.Errorf("marshal auth genesis: %w", err)
+		}
+		state["distribution"], err = cdc.MarshalJSON(&distrGen)
+		if err != nil {
+			return fmt.Errorf("marshal distribution genesis: %w", err)
+		}
+		return streamGenesis(w, genesisDoc, state, bankGen)
+	}
+
+	// Make sure the unmodified genesis is encoded identically
+	patch := genesisPatch{offset: make(map[string]sdk.Int), offsetPolicy: offsetPolicy}
+	sum := sha256.Sum256(published)
+	patch.sumBefore = sum[:]
+	h := sha256.New()
+	if err := encode(h); err != nil {
+		return genesisPatch{}, err
+	}
+	if !bytes.Equal(h.Sum(nil), patch.sumBefore) {
+		return genesisPatch{}, errors.New("the published genesis can't be re-encoded identically, it wasn't produced by the genesis command or it was modified afterwards")
+	}
+
+	if len(bankGen.Balances) == 0 {
+		return genesisPatch{}, errors.New("the published genesis has no balances")
+	}
+	prefix, _, err := bech32.DecodeAndConvert(bankGen.Balances[0].Address)
+	if err != nil {
+		return genesisPatch{}, fmt.Errorf("decode balance address: %w", err)
+	}
+	distrModuleAddr := sdk.MustBech32ifyAddressBytes(prefix, authtypes.NewModuleAddress(distrtypes.ModuleName))
+	for _, c := range corrections {
+		key, err := newAddrKey(c.Address)
+		if err != nil {
+			return genesisPatch{}, err
+		}
+		addr := key.bech32(prefix)
+		if addr == distrModuleAddr {
+			return genesisPatch{}, fmt.Errorf("correction of %s: the distribution module account can't be corrected", c.Address)
+		}
+		if slices.ContainsFunc(patch.changes, func(ch genesisChange) bool { return ch.address == addr }) {
+			return genesisPatch{}, fmt.Errorf("correction of %s: duplicate address", c.Address)
+		}
+		after, err := sdk.ParseCoinsNormalized(c.Coins)
+		if err != nil {
+			return genesisPatch{}, fmt.Errorf("correction of %s: invalid coins '%s': %w", c.Address, c.Coins, err)
+		}
+		change := genesisChange{address: addr, before: sdk.NewCoins(), after: after, reason: c.Reason}
+		i, found := slices.BinarySearchFunc(bankGen.Balances, addr, func(b banktypes.Balance, addr string) int {
+			return strings.Compare(b.Address, addr)
+		})
+		switch {
+		case found && after.IsZero():
+			change.before = bankGen.Balances[i].Coins
+			bankGen.Balances = slices.Delete(bankGen.Balances, i, i+1)
+			authGen.Accounts = removeGenesisAccount(authGen.Accounts, addr)
+		case found:
+			change.before = bankGen.Balances[i].Coins
+			bankGen.Balances[i].Coins = after
+		case !after.IsZero():
+			bankGen.Balances = slices.Insert(bankGen.Balances, i, banktypes.Balance{Address: addr, Coins: after})
+			authGen.Accounts, err = insertGenesisAccount(authGen.Accounts, addr)
+		}
+		if err != nil {
+			return genesisPatch{}, fmt.Errorf("correction of %s: %w", c.Address, err)
+		}
+		for _, denom := range coinsDenoms(change.before, change.after) {
+			d := change.after.AmountOf(denom).Sub(change.before.AmountOf(denom))
+			if o, ok := patch.offset[denom]; ok {
+				d = d.Add(o)
+			}
+			patch.offset[denom] = d
+		}
+		patch.changes = append(patch.changes, change)
+	}
+
+	for _, denom := range slices.Sorted(maps.Keys(patch.offset)) {
+		d := patch.offset[denom]
+		switch offsetPolicy {
+		case correctionOffsetSupply:
+			bankGen.Supply, err = addSignedCoin(bankGen.Supply, denom, d)
+		case correctionOffsetCommunityPool:
+			i, found := slices.BinarySearchFunc(bankGen.Balances, distrModuleAddr, func(b banktypes.Balance, addr string) int {
+				return strings.Compare(b.Address, addr)
+			})
+			if !found {
+				return genesisPatch{}, errors.New("the published genesis has no distribution module account")
+			}
+			bankGen.Balances[i].Coins, err = addSignedCoin(bankGen.Balances[i].Coins, denom, d.Neg())
+			if err != nil {
+				break
+			}
+			pool := distrGen.FeePool.CommunityPool
+			if !d.IsPositive() {
+				distrGen.FeePool.CommunityPool = pool.Add(sdk.NewDecCoin(denom, d.Neg()))
+				break
+			}
+			pool, neg := pool.SafeSub(sdk.NewDecCoins(sdk.NewDecCoin(denom, d)))
+			if neg {
+				err = fmt.Errorf("not enough %s in the community pool", denom)
+				break
+			}
+			distrGen.FeePool.CommunityPool = pool
+		}
+		if err != nil {
+			return genesisPatch{}, fmt.Errorf("offset of %s%s: %w", d, denom, err)
+		}
+	}
+
+	h.Reset()
+	if err := encode(io.MultiWriter(w, h)); err != nil {
+		return genesisPatch{}, err
+	}
+	patch.sumAfter = h.Sum(nil)
+	return patch, nil
+}
+
+// removeGenesisAccount removes the account of addr from accounts.
+func removeGenesisAccount(accounts []*codectypes.Any, addr string) []*codectypes.Any {
+	return slices.DeleteFunc(accounts, func(acc *codectypes.Any) bool {
+		return genesisAccountAddr(acc) == addr
+	})
+}
+
+// insertGenesisAccount inserts a base account for addr in accounts, at its
+// place in the address order of the accounts of the genesis command.
+func insertGenesisAccount(accounts []*codectypes.Any, addr string) ([]*codectypes.Any, error) {
+	i, _ := slices.BinarySearchFunc(accounts, addr, func(acc *codectypes.Any, addr string) int {
+		return strings.Compare(genesisAccountAddr(acc), addr)
+	})
+	any, err := codectypes.NewAnyWithValue(&authtypes.BaseAccount{Address: addr})
+	if err != nil {
+		return nil, fmt.Errorf("newAny from base account: %w", err)
+	}
+	return slices.Insert(accounts, i, any), nil
+}
+
+// genesisAccountAddr returns the bech32 address of the genesis account acc.
+// Unlike GetAddress, it doesn't depend on the bech32 prefix of the sdk config.
+func genesisAccountAddr(acc *codectypes.Any) string {
+	switch a := acc.GetCachedValue().(type) {
+	case *authtypes.BaseAccount:
+		return a.Address
+	case *authtypes.ModuleAccount:
+		return a.Address
+	}
+	return ""
+}
+
+// addSignedCoin adds amt of denom to coins, amt can be negative.
+func addSignedCoin(coins sdk.Coins, denom string, amt sdk.Int) (sdk.Coins, error) {
+	if !amt.IsNegative() {
+		return coins.Add(sdk.NewCoin(denom, amt)), nil
+	}
+	res, neg := coins.SafeSub(sdk.NewCoin(denom, amt.Neg()))
+	if neg {
+		return nil, fmt.Errorf("insufficient %s: %s", denom, coins)
+	}
+	return res, nil
+}
+
+// coinsDenoms returns the sorted denoms of a and b.
+func coinsDenoms(a, b sdk.Coins) []string {
+	denoms := append(a.Denoms(), b.Denoms()...)
+	slices.Sort(denoms)
+	return slices.Compact(denoms)
+}
+
+// signedCoins formats the signed amounts of m, like "+10uatone,-5uphoton".
+func signedCoins(m map[string]sdk.Int) string {
+	var s []string
+	for _, denom := range slices.Sorted(maps.Keys(m)) {
+		amt := m[denom]
+		if amt.IsZero() {
+			continue
+		}
+		sign := "+"
+		if amt.IsNegative() {
+			sign = ""
+		}
+		s = append(s, sign+amt.String()+denom)
+	}
+	if len(s) == 0 {
+		return "0"
+	}
+	return strings.Join(s, ",")
+}
+
+// writeGenesisChangelog writes the changes of patch in w, as a markdown
+// document.
+func writeGenesisChangelog(w io.Writer, genesisFile string, patch genesisPatch) error {
+	fmt.Fprintf(w, "# Genesis changelog\n\n")
+	fmt.Fprintf(w, "Corrections applied to `%s`:\n\n", genesisFile)
+	fmt.Fprintf(w, "- published sha256: `%x`\n", patch.sumBefore)
+	fmt.Fprintf(w, "- corrected sha256: `%x`\n\n", patch.sumAfter)
+	table := newMarkdownTableWriter(w, "ADDRESS", "BEFORE", "AFTER", "DELTA", "REASON")
+	for _, c := range patch.changes {
+		delta := make(map[string]sdk.Int)
+		for _, denom := range coinsDenoms(c.before, c.after) {
+			delta[denom] = c.after.AmountOf(denom).Sub(c.before.AmountOf(denom))
+		}
+		table.Append([]string{c.address, c.before.String(), c.after.String(), signedCoins(delta), c.reason})
+	}
+	table.Render()
+	dest, offset := "supply", patch.offset
+	if patch.offsetPolicy == correctionOffsetCommunityPool {
+		// the community pool funds the increases
+		dest, offset = "community pool", make(map[string]sdk.Int, len(patch.offset))
+		for denom, amt := range patch.offset {
+			offset[denom] = amt.Neg()
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d address(es) corrected, %s: %s\n", len(patch.changes), dest, signedCoins(offset))
+	return err
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	tmjson "github.com/cometbft/cometbft/libs/json"
+	tmtypes "github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+)
+
+// publishedGenesis returns a genesis encoded like writeGenesis, with a
+// balance of 100uatone for each address of addrs, and a community pool of
+// 1000uatone.
+func publishedGenesis(t *testing.T, addrs []string) []byte {
+	t.Helper()
+	distrModuleAddr := sdk.MustBech32ifyAddressBytes("atone", authtypes.NewModuleAddress(distrtypes.ModuleName))
+	var (
+		authGen  = authtypes.DefaultGenesisState()
+		bankGen  = banktypes.DefaultGenesisState()
+		distrGen = distrtypes.DefaultGenesisState()
+		pool     = sdk.NewCoins(sdk.NewInt64Coin("uatone", 1000))
+	)
+	bankGen.Supply = pool
+	bankGen.Balances = append(bankGen.Balances, banktypes.Balance{Address: distrModuleAddr, Coins: pool})
+	for _, addr := range addrs {
+		coins := sdk.NewCoins(sdk.NewInt64Coin("uatone", 100))
+		bankGen.Balances = append(bankGen.Balances, banktypes.Balance{Address: addr, Coins: coins})
+		bankGen.Supply = bankGen.Supply.Add(coins...)
+	}
+	slices.SortFunc(bankGen.Balances, func(a, b banktypes.Balance) int { return strings.Compare(a.Address, b.Address) })
+	for _, b := range bankGen.Balances {
+		if b.Address == distrModuleAddr {
+			continue
+		}
+		any, err := codectypes.NewAnyWithValue(&authtypes.BaseAccount{Address: b.Address})
+		require.NoError(t, err)
+		authGen.Accounts = append(authGen.Accounts, any)
+	}
+	distrGen.FeePool.CommunityPool = sdk.NewDecCoinsFromCoins(pool...)
+	appState := map[string]json.RawMessage{
+		"gov": json.RawMessage(`{"constitution":"<x>"}`),
+	}
+	var err error
+	appState["auth"], err = cdc.MarshalJSON(authGen)
+	require.NoError(t, err)
+	appState["distribution"], err = cdc.MarshalJSON(distrGen)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, streamGenesis(&buf, tmtypes.GenesisDoc{ChainID: "atomone-1"}, appState, *bankGen))
+	return buf.Bytes()
+}
+
+func TestPatchGenesis(t *testing.T) {
+	addrs := createAccountAddrs(3)
+	atoneAddrs := make([]string, len(addrs))
+	for i, addr := range addrs {
+		atoneAddrs[i] = sdk.MustBech32ifyAddressBytes("atone", addr)
+	}
+	published := publishedGenesis(t, atoneAddrs[:2])
+	decode := func(t *testing.T, bz []byte) (banktypes.GenesisState, authtypes.GenesisState, distrtypes.GenesisState) {
+		t.Helper()
+		var doc tmtypes.GenesisDoc
+		require.NoError(t, tmjson.Unmarshal(bz, &doc))
+		var appState map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(doc.AppState, &appState))
+		var (
+			bankGen  banktypes.GenesisState
+			authGen  authtypes.GenesisState
+			distrGen distrtypes.GenesisState
+		)
+		require.NoError(t, cdc.UnmarshalJSON(appState["bank"], &bankGen))
+		require.NoError(t, cdc.UnmarshalJSON(appState["auth"], &authGen))
+		require.NoError(t, cdc.UnmarshalJSON(appState["distribution"], &distrGen))
+		return bankGen, authGen, distrGen
+	}
+
+	t.Run("no corrections", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		patch, err := patchGenesis(&buf, published, nil, correctionOffsetCommunityPool)
+
+		require.NoError(t, err)
+		assert.Equal(t, string(published), buf.String())
+		assert.Equal(t, patch.sumBefore, patch.sumAfter)
+	})
+
+	t.Run("community pool offset", func(t *testing.T) {
+		require := require.New(t)
+		assert := assert.New(t)
+		var buf bytes.Buffer
+		corrections := []genesisCorrection{
+			// cosmos prefix is converted
+			{Address: addrs[0].String(), Coins: "40uatone", Reason: "missed exchange tag"},
+			{Address: atoneAddrs[1], Coins: "", Reason: "opted out"},
+			{Address: atoneAddrs[2], Coins: "10uatone", Reason: "missed voter"},
+		}
+
+		patch, err := patchGenesis(&buf, published, corrections, correctionOffsetCommunityPool)
+
+		require.NoError(err)
+		bankGen, authGen, distrGen := decode(t, buf.Bytes())
+		balances := make(map[string]sdk.Coins)
+		for _, b := range bankGen.Balances {
+			balances[b.Address] = b.Coins
+		}
+		assert.Equal(sdk.NewCoins(sdk.NewInt64Coin("uatone", 40)), balances[atoneAddrs[0]])
+		assert.NotContains(balances, atoneAddrs[1])
+		assert.Equal(sdk.NewCoins(sdk.NewInt64Coin("uatone", 10)), balances[atoneAddrs[2]])
+		assert.True(slices.IsSortedFunc(bankGen.Balances, func(a, b banktypes.Balance) int { return strings.Compare(a.Address, b.Address) }))
+		// 60+100-10 moved to the community pool, supply unchanged
+		assert.Equal(sdk.NewCoins(sdk.NewInt64Coin("uatone", 1200)), bankGen.Supply)
+		assert.Equal(sdk.NewDecCoins(sdk.NewInt64DecCoin("uatone", 1150)), distrGen.FeePool.CommunityPool)
+		var accAddrs []string
+		for _, acc := range authGen.Accounts {
+			accAddrs = append(accAddrs, genesisAccountAddr(acc))
+		}
+		assert.ElementsMatch([]string{atoneAddrs[0], atoneAddrs[2]}, accAddrs)
+		assert.Equal(sdk.NewInt(-150), patch.offset["uatone"])
+		// only the corrected lines differ: the amounts of the 2 corrected
+		// balances, of the distribution module account and of the community
+		// pool, and the address of the inserted balance and account
+		var changed int
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if !bytes.Contains(published, []byte(line)) {
+				changed++
+			}
+		}
+		assert.Equal(6, changed)
+
+		var changelog bytes.Buffer
+		require.NoError(writeGenesisChangelog(&changelog, "genesis.json", patch))
+		assert.Contains(changelog.String(), "missed exchange tag")
+		assert.Contains(changelog.String(), "-60uatone")
+		assert.Contains(changelog.String(), "3 address(es) corrected, community pool: +150uatone")
+	})
+
+	t.Run("supply offset", func(t *testing.T) {
+		var buf bytes.Buffer
+		corrections := []genesisCorrection{{Address: atoneAddrs[0], Coins: "150uatone"}}
+
+		_, err := patchGenesis(&buf, published, corrections, correctionOffsetSupply)
+
+		require.NoError(t, err)
+		bankGen, _, distrGen := decode(t, buf.Bytes())
+		assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uatone", 1250)), bankGen.Supply)
+		assert.Equal(t, sdk.NewDecCoins(sdk.NewInt64DecCoin("uatone", 1000)), distrGen.FeePool.CommunityPool)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			published     []byte
+			corrections   []genesisCorrection
+			expectedError string
+		}{
+			{
+				name:          "re-indented genesis",
+				published:     bytes.ReplaceAll(published, []byte("  "), []byte("\t")),
+				expectedError: "can't be re-encoded identically",
+			},
+			{
+				name:          "duplicate address",
+				corrections:   []genesisCorrection{{Address: atoneAddrs[0]}, {Address: addrs[0].String()}},
+				expectedError: "duplicate address",
+			},
+			{
+				name:          "insufficient community pool",
+				corrections:   []genesisCorrection{{Address: atoneAddrs[0], Coins: "2000uatone"}},
+				expectedError: "insufficient uatone",
+			},
+			{
+				name:          "invalid coins",
+				corrections:   []genesisCorrection{{Address: atoneAddrs[0], Coins: "x"}},
+				expectedError: "invalid coins",
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if tt.published == nil {
+					tt.published = published
+				}
+				var buf bytes.Buffer
+
+				_, err := patchGenesis(&buf, tt.published, tt.corrections, correctionOffsetCommunityPool)
+
+				assert.ErrorContains(t, err, tt.expectedError)
+				assert.Zero(t, buf.Len())
+			})
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Curves of the $ATONE to $PHOTON conversion rate.
+const (
+	// photonCurveAtomOne is the burn-to-mint rate of the AtomOne x/photon
+	// module: (photonMaxSupply - photonSupply) / atoneSupply, which decreases
+	// as $PHOTON is minted, so the $PHOTON supply never exceeds its maximum.
+	photonCurveAtomOne = "atomone"
+	// photonCurveFixed is a constant rate, until the maximum $PHOTON supply is
+	// reached.
+	photonCurveFixed = "fixed"
+)
+
+// photonMaxSupply is the maximum $PHOTON supply of AtomOne, in uphoton.
+var photonMaxSupply = sdk.NewInt(1_000_000_000 * M)
+
+// photonConfig configures the simulation of the conversion of the airdrop
+// allocations into $PHOTON.
+type photonConfig struct {
+	// portion is the share of each allocation converted.
+	portion sdk.Dec
+	curve   string
+	// rate is the conversion rate of the fixed curve.
+	rate sdk.Dec
+	// maxSupply is the maximum $PHOTON supply, in uphoton.
+	maxSupply sdk.Int
+}
+
+func (c photonConfig) validate() error {
+	if c.portion.IsNegative() || c.portion.GT(sdk.OneDec()) {
+		return fmt.Errorf("invalid photon portion %s, must be between 0 and 1", c.portion)
+	}
+	switch c.curve {
+	case photonCurveAtomOne:
+	case photonCurveFixed:
+		if !c.rate.IsPositive() {
+			return fmt.Errorf("invalid photon rate %s, must be positive", c.rate)
+		}
+	default:
+		return fmt.Errorf("unknown photon curve '%s'", c.curve)
+	}
+	if !c.maxSupply.IsPositive() {
+		return fmt.Errorf("invalid photon max supply %s, must be positive", c.maxSupply)
+	}
+	return nil
+}
+
+// photonQuantiles are the conversion progressions at which the rate is
+// reported.
+var photonQuantiles = []sdk.Dec{
+	sdk.ZeroDec(), sdk.NewDecWithPrec(25, 2), sdk.NewDecWithPrec(5, 1), sdk.NewDecWithPrec(75, 2), sdk.OneDec(),
+}
+
+// photonSimulation is the outcome of simulatePhoton.
+type photonSimulation struct {
+	// atoneSupply is the $ATONE supply before the conversions, and
+	// atoneBurned the amount burned by them.
+	atoneSupply, atoneBurned sdk.Int
+	// photonMinted is the $PHOTON supply after the conversions.
+	photonMinted sdk.Int
+	// photons is the $PHOTON minted per address.
+	photons map[string]sdk.Int
+	// rates is the conversion rate at each of photonQuantiles.
+	rates []sdk.Dec
+}
+
+// averageRate returns the $PHOTON minted per $ATONE burned.
+func (s photonSimulation) averageRate() sdk.Dec {
+	if s.atoneBurned.IsZero() {
+		return sdk.ZeroDec()
+	}
+	return s.photonMinted.ToLegacyDec().QuoInt(s.atoneBurned)
+}
+
+// simulatePhoton converts cfg.portion of the allocation of each address of a
+// into $PHOTON, one address after the other in address order, and returns the
+// projected supplies of both tokens. The community pool and the reserved
+// address are not converted, but they count in the $ATONE supply.
+func simulatePhoton(a airdrop, cfg photonConfig) (photonSimulation, error) {
+	if err := cfg.validate(); err != nil {
+		return photonSimulation{}, err
+	}
+	var (
+		atoneSupply  = a.totalSupply().TruncateInt()
+		photonSupply = sdk.ZeroInt()
+		sim          = photonSimulation{
+			atoneSupply: atoneSupply,
+			atoneBurned: sdk.ZeroInt(),
+			photons:     make(map[string]sdk.Int),
+		}
+		addrs   = slices.Sorted(maps.Keys(a.addresses))
+		toBurn  = sdk.ZeroInt()
+		nextQ   = 0
+		burnsAt = make([]sdk.Int, len(photonQuantiles))
+	)
+	for _, addr := range addrs {
+		toBurn = toBurn.Add(cfg.portion.MulInt(a.addresses[addr]).TruncateInt())
+	}
+	for i, q := range photonQuantiles {
+		burnsAt[i] = q.MulInt(toBurn).TruncateInt()
+	}
+	rate := func() sdk.Dec {
+		switch {
+		case cfg.curve == photonCurveFixed:
+			return cfg.rate
+		case atoneSupply.IsZero():
+			return sdk.ZeroDec()
+		}
+		return cfg.maxSupply.Sub(photonSupply).ToLegacyDec().QuoInt(atoneSupply)
+	}
+	for _, addr := range addrs {
+		for nextQ < len(burnsAt) && sim.atoneBurned.GTE(burnsAt[nextQ]) {
+			sim.rates = append(sim.rates, rate())
+			nextQ++
+		}
+		burn := cfg.portion.MulInt(a.addresses[addr]).TruncateInt()
+		if burn.IsZero() {
+			continue
+		}
+		mint := rate().MulInt(burn).TruncateInt()
+		// the fixed rate stops at the maximum supply
+		mint = sdk.MinInt(mint, cfg.maxSupply.Sub(photonSupply))
+		atoneSupply = atoneSupply.Sub(burn)
+		photonSupply = photonSupply.Add(mint)
+		sim.atoneBurned = sim.atoneBurned.Add(burn)
+		sim.photons[addr] = mint
+	}
+	for ; nextQ < len(burnsAt); nextQ++ {
+		sim.rates = append(sim.rates, rate())
+	}
+	sim.photonMinted = photonSupply
+	return sim, nil
+}
+
+func printPhotonSimulation(cfg photonConfig, sim photonSimulation) {
+	fmt.Printf("Conversion of %s of each allocation, %s curve\n", humanPercent(cfg.portion), cfg.curve)
+	table := newMarkdownTable("", "$ATONE", "$PHOTON")
+	table.Append([]string{"Supply before", human(sim.atoneSupply), "0"})
+	table.Append([]string{"Burned / Minted", human(sim.atoneBurned), human(sim.photonMinted)})
+	table.Append([]string{"Supply after", human(sim.atoneSupply.Sub(sim.atoneBurned)), human(sim.photonMinted)})
+	table.Append([]string{"Max supply", "-", human(cfg.maxSupply)})
+	table.Append([]string{"Minted / Max", "-", humanPercent(sim.photonMinted.ToLegacyDec().QuoInt(cfg.maxSupply))})
+	table.Render()
+	fmt.Printf("Average rate: %.6f $PHOTON per $ATONE, %s converting addresses\n",
+		sim.averageRate().MustFloat64(), humanCount(len(sim.photons)))
+	table = newMarkdownTable("CONVERTED", "RATE")
+	for i, q := range photonQuantiles {
+		table.Append([]string{humanPercentI(q), fmt.Sprintf("%.6f", sim.rates[i].MustFloat64())})
+	}
+	table.Render()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestSimulatePhoton(t *testing.T) {
+	a := airdrop{
+		addresses: map[string]sdk.Int{
+			"a": sdk.NewInt(100),
+			"b": sdk.NewInt(300),
+		},
+		atone:         distrib{supply: sdk.NewDec(400)},
+		rounding:      roundingStats{communityPool: sdk.ZeroDec()},
+		voterFloor:    voterFloorStats{topUp: sdk.ZeroInt()},
+		communityPool: sdk.NewDec(100),
+		reservedAddr:  sdk.ZeroDec(),
+	}
+
+	t.Run("atomone curve", func(t *testing.T) {
+		require := require.New(t)
+		assert := assert.New(t)
+		cfg := photonConfig{portion: sdk.NewDecWithPrec(5, 1), curve: photonCurveAtomOne, maxSupply: sdk.NewInt(1000)}
+
+		sim, err := simulatePhoton(a, cfg)
+
+		require.NoError(err)
+		assert.Equal(sdk.NewInt(500), sim.atoneSupply)
+		assert.Equal(sdk.NewInt(200), sim.atoneBurned)
+		// a burns 50 at 1000/500=2, b burns 150 at (1000-100)/450=2
+		assert.Equal(sdk.NewInt(100), sim.photons["a"])
+		assert.Equal(sdk.NewInt(300), sim.photons["b"])
+		assert.Equal(sdk.NewInt(400), sim.photonMinted)
+		assert.Equal(sdk.NewDec(2), sim.averageRate())
+		if assert.Len(sim.rates, len(photonQuantiles)) {
+			assert.Equal(sdk.NewDec(2), sim.rates[0])
+			// (1000-400)/300
+			assert.Equal(sdk.NewDec(2), sim.rates[len(sim.rates)-1])
+		}
+	})
+
+	t.Run("fixed curve capped", func(t *testing.T) {
+		require := require.New(t)
+		assert := assert.New(t)
+		cfg := photonConfig{portion: sdk.OneDec(), curve: photonCurveFixed, rate: sdk.NewDec(10), maxSupply: sdk.NewInt(2000)}
+
+		sim, err := simulatePhoton(a, cfg)
+
+		require.NoError(err)
+		assert.Equal(sdk.NewInt(400), sim.atoneBurned)
+		assert.Equal(sdk.NewInt(1000), sim.photons["a"])
+		// b would mint 3000, but only 1000 remain
+		assert.Equal(sdk.NewInt(1000), sim.photons["b"])
+		assert.Equal(sdk.NewInt(2000), sim.photonMinted)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := simulatePhoton(a, photonConfig{portion: sdk.NewDec(2), curve: photonCurveAtomOne, maxSupply: sdk.NewInt(1)})
+		assert.ErrorContains(err, "invalid photon portion")
+		_, err = simulatePhoton(a, photonConfig{portion: sdk.OneDec(), curve: "x", maxSupply: sdk.NewInt(1)})
+		assert.ErrorContains(err, "unknown photon curve")
+		_, err = simulatePhoton(a, photonConfig{portion: sdk.OneDec(), curve: photonCurveFixed, rate: sdk.ZeroDec(), maxSupply: sdk.NewInt(1)})
+		assert.ErrorContains(err, "invalid photon rate")
+	})
+}
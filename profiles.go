@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// profile pins the parameters and the snapshot metadata of a historic run, so
+// it remains reproducible as the defaults evolve.
+type profile struct {
+	name        string
+	description string
+	// snapshot metadata
+	chainID         string
+	proposalID      uint64
+	preTallyHeight  int64
+	tallyHeight     int64
+	snapshotTime    time.Time
+	snapshotDataURL string
+	// prefix of the addresses in the airdrop
+	prefix string
+	params distriParams
+}
+
+func (p profile) String() string {
+	return fmt.Sprintf("%s (%s proposal %d, heights %d-%d, %s)", p.name, p.chainID,
+		p.proposalID, p.preTallyHeight, p.tallyHeight, p.snapshotTime.UTC().Format(time.RFC3339))
+}
+
+// profiles holds the known historic runs.
+var profiles = map[string]profile{
+	"govgen": {
+		name:            "govgen",
+		description:     "$ATONE distribution as voted in GovGen PROP 001, addresses with the cosmos prefix",
+		chainID:         "cosmoshub-4",
+		proposalID:      848,
+		preTallyHeight:  18010657,
+		tallyHeight:     18010658,
+		snapshotTime:    time.Unix(1700946028, 0),
+		snapshotDataURL: "https://atomone.fra1.digitaloceanspaces.com/cosmoshub-4/prop848/",
+		prefix:          "",
+		params:          prop848DistriParams(),
+	},
+	"prop848": {
+		name:            "prop848",
+		description:     "$ATONE distribution of the AtomOne genesis, addresses with the atone prefix",
+		chainID:         "cosmoshub-4",
+		proposalID:      848,
+		preTallyHeight:  18010657,
+		tallyHeight:     18010658,
+		snapshotTime:    time.Unix(1700946028, 0),
+		snapshotDataURL: "https://atomone.fra1.digitaloceanspaces.com/cosmoshub-4/prop848/",
+		prefix:          "atone",
+		params:          prop848DistriParams(),
+	},
+}
+
+// prop848DistriParams returns a frozen copy of the parameters used for the
+// prop848 distributions, unlike defaultDistriParams which may evolve.
+func prop848DistriParams() distriParams {
+	return distriParams{
+		yesVotesMultiplier: sdk.OneDec(),
+		noVotesMultiplier:  sdk.NewDec(9),
+		bonus:              sdk.NewDecWithPrec(103, 2),
+		malus:              sdk.NewDecWithPrec(97, 2),
+		supplyFactor:       sdk.NewDecWithPrec(1, 1),
+		supplyMintFactor:   sdk.OneDec().Quo(sdk.NewDec(9)),
+		voteBuckets: voteBuckets{
+			govtypes.OptionYes:        voteBucketOpposed,
+			govtypes.OptionNo:         voteBucketAligned,
+			govtypes.OptionNoWithVeto: voteBucketAligned,
+			govtypes.OptionAbstain:    voteBucketNeutral,
+			govtypes.OptionEmpty:      voteBucketNeutral,
+		},
+		entityGroups: []entityGroup{{
+			Name:   "ICF",
+			Policy: entityPolicySlash,
+			Addresses: []string{
+				"cosmos1z8mzakma7vnaajysmtkwt4wgjqr2m84tzvyfkz",
+				"cosmos1unc788q8md2jymsns24eyhua58palg5kc7cstv",
+				"cosmos1sufkm72dw7ua9crpfhhp0dqpyuggtlhdse98e7",
+				"cosmos1z6czaavlk6kjd48rpf58kqqw9ssad2uaxnazgl",
+				"cosmos17u903qxqc6dzn3chvmc9zzp9fl4xja0pwggfj7",
+			},
+		}},
+	}
+}
+
+func getProfile(name string) (profile, error) {
+	p, ok := profiles[name]
+	if !ok {
+		return profile{}, fmt.Errorf("unknown profile '%s', available profiles are: %s",
+			name, strings.Join(slices.Sorted(maps.Keys(profiles)), ", "))
+	}
+	return p, nil
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// progress tracks the stages of a long run for the progress UI, nil if the
+// -progressUI flag isn't set. Its methods do nothing on a nil tracker, so the
+// stages don't have to check it.
+var progress *progressTracker
+
+// progressAggregateEvery is the number of items between 2 updates of the
+// intermediate aggregates of a stage.
+const progressAggregateEvery = 10_000
+
+// progressTracker records the pipeline stages of the run, the number of items
+// (accounts...) they processed, and the intermediate aggregates.
+type progressTracker struct {
+	mu         sync.Mutex
+	now        func() time.Time
+	started    time.Time
+	stages     []*progressStage
+	aggregates []progressAggregate
+}
+
+type progressStage struct {
+	name string
+	// total is the number of items of the stage, 0 if unknown.
+	total    int
+	done     int
+	started  time.Time
+	finished time.Time
+}
+
+type progressAggregate struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{now: time.Now, started: time.Now()}
+}
+
+// startStage starts the stage name of total items, 0 if unknown. A stage
+// started again, e.g. by a solver iteration, is reset.
+func (p *progressTracker) startStage(name string, total int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, s := range p.stages {
+		if s.name == name {
+			p.stages = append(p.stages[:i], p.stages[i+1:]...)
+			break
+		}
+	}
+	p.stages = append(p.stages, &progressStage{name: name, total: total, started: p.now()})
+}
+
+// advance adds n processed items to the current stage.
+func (p *progressTracker) advance(n int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s := p.current(); s != nil {
+		s.done += n
+	}
+}
+
+// finishStage finishes the current stage.
+func (p *progressTracker) finishStage() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s := p.current(); s != nil {
+		s.finished = p.now()
+	}
+}
+
+// current returns the last started stage if it's not finished.
+func (p *progressTracker) current() *progressStage {
+	if len(p.stages) == 0 {
+		return nil
+	}
+	s := p.stages[len(p.stages)-1]
+	if !s.finished.IsZero() {
+		return nil
+	}
+	return s
+}
+
+// setAggregate sets the intermediate aggregate name, in the order of the
+// first call.
+func (p *progressTracker) setAggregate(name, value string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.aggregates {
+		if p.aggregates[i].Name == name {
+			p.aggregates[i].Value = value
+			return
+		}
+	}
+	p.aggregates = append(p.aggregates, progressAggregate{Name: name, Value: value})
+}
+
+// progressSnapshot is the state of the run served to the progress UI.
+type progressSnapshot struct {
+	Elapsed    string                  `json:"elapsed"`
+	Stages     []progressStageSnapshot `json:"stages"`
+	Aggregates []progressAggregate     `json:"aggregates"`
+}
+
+type progressStageSnapshot struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Done    int    `json:"done"`
+	Total   int    `json:"total,omitempty"`
+	Elapsed string `json:"elapsed"`
+	// Throughput is the number of items per second.
+	Throughput float64 `json:"throughput"`
+	// ETA is the estimated remaining time of a running stage of known total.
+	ETA string `json:"eta,omitempty"`
+}
+
+func (p *progressTracker) snapshot() progressSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	snap := progressSnapshot{
+		Elapsed:    now.Sub(p.started).Round(time.Second).String(),
+		Stages:     make([]progressStageSnapshot, len(p.stages)),
+		Aggregates: append([]progressAggregate(nil), p.aggregates...),
+	}
+	for i, s := range p.stages {
+		end, status := now, "running"
+		if !s.finished.IsZero() {
+			end, status = s.finished, "done"
+		}
+		elapsed := end.Sub(s.started)
+		ss := progressStageSnapshot{
+			Name:    s.name,
+			Status:  status,
+			Done:    s.done,
+			Total:   s.total,
+			Elapsed: elapsed.Round(time.Second).String(),
+		}
+		if elapsed > 0 {
+			ss.Throughput = float64(s.done) / elapsed.Seconds()
+		}
+		if status == "running" && s.total > 0 && ss.Throughput > 0 {
+			remaining := time.Duration(float64(s.total-s.done) / ss.Throughput * float64(time.Second))
+			ss.ETA = remaining.Round(time.Second).String()
+		}
+		snap.Stages[i] = ss
+	}
+	return snap
+}
+
+// progressPage polls /progress.json every second and renders the stages and
+// the aggregates.
+const progressPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>govbox progress</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { padding: 0.3em 1em; text-align: left; border-bottom: 1px solid #ddd; }
+.bar { width: 200px; height: 0.8em; background: #eee; }
+.bar div { height: 100%; background: #4a90d9; }
+.done .bar div { background: #5cb85c; }
+</style>
+</head>
+<body>
+<h1>govbox <small id="elapsed"></small></h1>
+<table>
+<thead><tr><th>STAGE</th><th>PROGRESS</th><th>DONE</th><th>TOTAL</th><th>THROUGHPUT</th><th>ELAPSED</th><th>ETA</th></tr></thead>
+<tbody id="stages"></tbody>
+</table>
+<table>
+<thead><tr><th>AGGREGATE</th><th>VALUE</th></tr></thead>
+<tbody id="aggregates"></tbody>
+</table>
+<script>
+const cell = (tr, text) => { const td = tr.insertCell(); td.textContent = text; return td; };
+async function refresh() {
+  let p;
+  try {
+    p = await (await fetch("progress.json")).json();
+  } catch (e) {
+    document.getElementById("elapsed").textContent = "(run ended)";
+    return;
+  }
+  document.getElementById("elapsed").textContent = p.elapsed;
+  const stages = document.getElementById("stages");
+  stages.innerHTML = "";
+  for (const s of p.stages) {
+    const tr = stages.insertRow();
+    tr.className = s.status;
+    cell(tr, s.name);
+    const bar = cell(tr, "");
+    if (s.total) {
+      bar.innerHTML = '<div class="bar"><div></div></div>';
+      bar.firstChild.firstChild.style.width = Math.min(100, 100 * s.done / s.total) + "%";
+    } else {
+      bar.textContent = s.status;
+    }
+    cell(tr, s.done.toLocaleString());
+    cell(tr, s.total ? s.total.toLocaleString() : "");
+    cell(tr, Math.round(s.throughput).toLocaleString() + "/s");
+    cell(tr, s.elapsed);
+    cell(tr, s.eta || "");
+  }
+  const aggregates = document.getElementById("aggregates");
+  aggregates.innerHTML = "";
+  for (const a of p.aggregates || []) {
+    const tr = aggregates.insertRow();
+    cell(tr, a.name);
+    cell(tr, a.value);
+  }
+}
+refresh();
+setInterval(refresh, 1000);
+</script>
+</body>
+</html>
+`
+
+// serveProgressUI serves the progress UI of p on addr until ctx is done, and
+// returns the URL of the page.
+func serveProgressUI(ctx context.Context, addr string, p *progressTracker) (string, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("progress UI: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, progressPage)
+	})
+	mux.HandleFunc("/progress.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p.snapshot())
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "progress UI: %v\n", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	return "http://" + ln.Addr().String(), nil
+}
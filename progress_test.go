@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestProgressTracker(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := newProgressTracker()
+	p.now = func() time.Time { return now }
+	p.started = now
+
+	p.startStage("aggregate", 0)
+	p.advance(100)
+	now = now.Add(10 * time.Second)
+	p.finishStage()
+	p.setAggregate("$ATOM supply", "1,000")
+	p.startStage("allocate", 100)
+	p.advance(25)
+	now = now.Add(5 * time.Second)
+	p.setAggregate("$ATONE distributed", "10")
+	p.setAggregate("$ATONE distributed", "20")
+
+	snap := p.snapshot()
+	assert.Equal("15s", snap.Elapsed)
+	require.Len(snap.Stages, 2)
+	assert.Equal(progressStageSnapshot{
+		Name: "aggregate", Status: "done", Done: 100, Elapsed: "10s", Throughput: 10,
+	}, snap.Stages[0])
+	assert.Equal(progressStageSnapshot{
+		Name: "allocate", Status: "running", Done: 25, Total: 100, Elapsed: "5s", Throughput: 5, ETA: "15s",
+	}, snap.Stages[1])
+	assert.Equal([]progressAggregate{
+		{Name: "$ATOM supply", Value: "1,000"},
+		{Name: "$ATONE distributed", Value: "20"},
+	}, snap.Aggregates)
+
+	// A stage started again is reset
+	p.startStage("aggregate", 0)
+	snap = p.snapshot()
+	require.Len(snap.Stages, 2)
+	assert.Equal("allocate", snap.Stages[0].Name)
+	assert.Equal(progressStageSnapshot{Name: "aggregate", Status: "running", Elapsed: "0s"}, snap.Stages[1])
+}
+
+func TestProgressTrackerNil(t *testing.T) {
+	var p *progressTracker
+	assert.NotPanics(t, func() {
+		p.startStage("aggregate", 10)
+		p.advance(1)
+		p.setAggregate("a", "b")
+		p.finishStage()
+	})
+}
+
+func TestServeProgressUI(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := newProgressTracker()
+	p.startStage("allocate", 10)
+	p.advance(3)
+
+	url, err := serveProgressUI(ctx, "127.0.0.1:0", p)
+	require.NoError(err)
+
+	resp, err := http.Get(url + "/progress.json")
+	require.NoError(err)
+	defer resp.Body.Close()
+	var snap progressSnapshot
+	require.NoError(json.NewDecoder(resp.Body).Decode(&snap))
+	require.Len(snap.Stages, 1)
+	assert.Equal("allocate", snap.Stages[0].Name)
+	assert.Equal(3, snap.Stages[0].Done)
+	assert.Equal(10, snap.Stages[0].Total)
+
+	resp, err = http.Get(url)
+	require.NoError(err)
+	defer resp.Body.Close()
+	assert.Equal("text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+}
+
+func TestDistributionProgress(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	defer func(p *progressTracker) { progress = p }(progress)
+	progress = newProgressTracker()
+	var (
+		addrs    = createAccountAddrs(2)
+		accounts = []Account{
+			{Address: addrs[0].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: govtypes.NewNonSplitVoteOption(govtypes.OptionYes)},
+			{Address: addrs[1].String(), LiquidAmount: sdk.NewDec(20), StakedAmount: sdk.ZeroDec()},
+		}
+		params = defaultDistriParams()
+	)
+	params.entityGroups = nil
+	airdrop, err := distribution(accounts, params, "atone")
+	require.NoError(err)
+
+	snap := progress.snapshot()
+	require.Len(snap.Stages, 2)
+	assert.Equal("aggregate", snap.Stages[0].Name)
+	assert.Equal(2, snap.Stages[0].Done)
+	assert.Equal("allocate", snap.Stages[1].Name)
+	assert.Equal("done", snap.Stages[1].Status)
+	assert.Equal(2, snap.Stages[1].Done)
+	assert.Equal(2, snap.Stages[1].Total)
+	require.Len(snap.Aggregates, 3)
+	assert.Equal(progressAggregate{Name: "$ATOM supply", Value: humand(sdk.NewDec(120))}, snap.Aggregates[0])
+	assert.Equal("nonVotersMultiplier", snap.Aggregates[1].Name)
+	assert.Equal(progressAggregate{Name: "$ATONE distributed", Value: humand(airdrop.atone.supply)}, snap.Aggregates[2])
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+)
+
+// parsePubkeysByAddr returns the pubkeys of the accounts of
+// <path>/auth_genesis.json that have one, i.e. that have sent at least one
+// transaction.
+func parsePubkeysByAddr(path string) (_ map[string]cryptotypes.PubKey, err error) {
+	f, err := openInput(context.Background(), joinInput(path, "auth_genesis.json"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var genesis authtypes.GenesisState
+	if err := unmarshaler.Unmarshal(f, &genesis); err != nil {
+		return nil, err
+	}
+	pubkeysByAddr := make(map[string]cryptotypes.PubKey)
+	for _, any := range genesis.Accounts {
+		var acc authtypes.GenesisAccount
+		if err := registry.UnpackAny(any, &acc); err != nil {
+			return nil, err
+		}
+		if pk := acc.GetPubKey(); pk != nil {
+			pubkeysByAddr[acc.GetAddress().String()] = pk
+		}
+	}
+	return pubkeysByAddr, nil
+}
+
+// airdropPubkeys returns the pubkeys of the airdrop recipients indexed by their
+// airdrop address, and the number of recipients without pubkey.
+func airdropPubkeys(airdrop airdrop, pubkeysByAddr map[string]cryptotypes.PubKey) (map[string]cryptotypes.PubKey, int) {
+	var (
+		pubkeys = make(map[string]cryptotypes.PubKey)
+		missing int
+	)
+	for _, e := range airdrop.audit {
+		if e.OutputAddress == "" {
+			// not an airdrop recipient
+			continue
+		}
+		pk, ok := pubkeysByAddr[e.Address]
+		if !ok {
+			missing++
+			continue
+		}
+		pubkeys[e.OutputAddress] = pk
+	}
+	return pubkeys, missing
+}
+
+// writePubkeys writes in file the pubkeys as a JSON object, with addresses as
+// keys and proto JSON encoded pubkeys as values, like:
+//
+//	{"atone1...": {"@type": "/cosmos.crypto.secp256k1.PubKey", "key": "A..."}}
+func writePubkeys(ctx context.Context, file string, pubkeys map[string]cryptotypes.PubKey) error {
+	m := make(map[string]json.RawMessage, len(pubkeys))
+	for addr, pk := range pubkeys {
+		bz, err := cdc.MarshalInterfaceJSON(pk)
+		if err != nil {
+			return fmt.Errorf("marshal pubkey of %s: %w", addr, err)
+		}
+		m[addr] = bz
+	}
+	bz, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(ctx, file, bz)
+}
+
+func printPubkeysStats(numPubkeys, missing int) {
+	total := numPubkeys + missing
+	fmt.Printf("%s/%s airdrop recipients have no on-chain pubkey (never sent a tx)\n",
+		humanCount(missing), humanCount(total))
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+)
+
+func TestAirdropPubkeys(t *testing.T) {
+	pk := secp256k1.GenPrivKey().PubKey()
+	airdrop := airdrop{
+		audit: []auditEntry{
+			{Address: "cosmos1a", OutputAddress: "atone1a"},
+			{Address: "cosmos1b", OutputAddress: "atone1b"},
+			{Address: "cosmos1c"}, // not a recipient
+		},
+	}
+	pubkeysByAddr := map[string]cryptotypes.PubKey{
+		"cosmos1a": pk,
+		"cosmos1c": pk,
+	}
+
+	pubkeys, missing := airdropPubkeys(airdrop, pubkeysByAddr)
+
+	assert.Equal(t, map[string]cryptotypes.PubKey{"atone1a": pk}, pubkeys)
+	assert.Equal(t, 1, missing)
+
+	file := filepath.Join(t.TempDir(), "pubkeys.json")
+	require.NoError(t, writePubkeys(context.Background(), file, pubkeys))
+	bz, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(bz), `"atone1a": {`)
+	assert.Contains(t, string(bz), `"@type": "/cosmos.crypto.secp256k1.PubKey"`)
+}
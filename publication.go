@@ -0,0 +1,451 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/atomone-hub/govbox/airdropblob"
+)
+
+// publicAirdrop is the aggregation-only form of an airdrop, without any
+// per-address detail, suitable for a public release.
+type publicAirdrop struct {
+	Denom      string
+	Params     airdropblob.Params
+	Aggregates airdropblob.Aggregates
+	Addresses  int
+	// MinBucketSize is the minimum number of addresses of the non-empty
+	// buckets, the smaller ones are merged with their neighbours.
+	MinBucketSize int
+	Buckets       []publicBucket
+}
+
+// publicBucket is a range of allocations in $ATONE, with the number of
+// addresses and the total amount in the range.
+type publicBucket struct {
+	Range     string
+	Addresses int
+	Amount    sdk.Int
+}
+
+// newPublicAirdrop returns the aggregates of airdrop, and the allocations
+// bucketed by size. The buckets of allocationBuckets with less than
+// minBucketSize addresses are merged, starting from the largest allocations,
+// so no bucket singles out a few addresses.
+func newPublicAirdrop(denom string, airdrop airdrop, minBucketSize int) (publicAirdrop, error) {
+	res, err := newAirdropResult(denom, airdrop)
+	if err != nil {
+		return publicAirdrop{}, err
+	}
+	var (
+		counts  = make([]int, len(allocationBuckets))
+		amounts = make([]sdk.Int, len(allocationBuckets))
+	)
+	for i := range amounts {
+		amounts[i] = sdk.ZeroInt()
+	}
+	for _, amt := range airdrop.addresses {
+		i := slices.IndexFunc(allocationBuckets, func(b allocationBucket) bool {
+			return b.max == 0 || amt.LT(sdk.NewInt(b.max*M))
+		})
+		counts[i]++
+		amounts[i] = amounts[i].Add(amt)
+	}
+	// spans are the merged ranges of allocationBuckets, from the largest
+	// allocations.
+	type span struct {
+		lo, hi    int
+		addresses int
+		amount    sdk.Int
+	}
+	var (
+		spans []span
+		cur   = span{hi: len(allocationBuckets) - 1, amount: sdk.ZeroInt()}
+	)
+	for i := len(allocationBuckets) - 1; i >= 0; i-- {
+		cur.lo = i
+		cur.addresses += counts[i]
+		cur.amount = cur.amount.Add(amounts[i])
+		if cur.addresses == 0 || cur.addresses >= minBucketSize {
+			spans = append(spans, cur)
+			cur = span{hi: i - 1, amount: sdk.ZeroInt()}
+		}
+	}
+	// The smallest allocations are too few, merge them with the previous
+	// spans.
+	for cur.addresses > 0 && cur.addresses < minBucketSize && len(spans) > 0 {
+		last := spans[len(spans)-1]
+		spans = spans[:len(spans)-1]
+		cur.hi = last.hi
+		cur.addresses += last.addresses
+		cur.amount = cur.amount.Add(last.amount)
+	}
+	if cur.addresses > 0 {
+		spans = append(spans, cur)
+	}
+	var buckets []publicBucket
+	for _, s := range slices.Backward(spans) {
+		buckets = append(buckets, publicBucket{
+			Range:     bucketRange(s.lo, s.hi),
+			Addresses: s.addresses,
+			Amount:    s.amount,
+		})
+	}
+	return publicAirdrop{
+		Denom:         res.Denom,
+		Params:        res.Params,
+		Aggregates:    res.Aggregates,
+		Addresses:     len(airdrop.addresses),
+		MinBucketSize: minBucketSize,
+		Buckets:       buckets,
+	}, nil
+}
+
+// bucketRange returns the label of the allocation range from the bucket lo to
+// the bucket hi of allocationBuckets.
+func bucketRange(lo, hi int) string {
+	switch {
+	case lo == 0 && allocationBuckets[hi].max == 0:
+		return "all"
+	case lo == 0:
+		return "< " + shortAmount(allocationBuckets[hi].max)
+	case allocationBuckets[hi].max == 0:
+		return "> " + shortAmount(allocationBuckets[lo-1].max)
+	}
+	return shortAmount(allocationBuckets[lo-1].max) + " - " + shortAmount(allocationBuckets[hi].max)
+}
+
+// shortAmount formats n with the k and M suffixes, like the labels of
+// allocationBuckets.
+func shortAmount(n int64) string {
+	switch {
+	case n >= 1_000_000 && n%1_000_000 == 0:
+		return fmt.Sprintf("%dM", n/1_000_000)
+	case n >= 1_000 && n%1_000 == 0:
+		return fmt.Sprintf("%dk", n/1_000)
+	}
+	return fmt.Sprint(n)
+}
+
+// writePublicAirdrop writes the aggregation-only form of airdrop into file.
+func writePublicAirdrop(ctx context.Context, file string, pub publicAirdrop) error {
+	bz, err := json.MarshalIndent(pub, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(ctx, file, bz)
+}
+
+// publicationFiles returns the files to publish for artifacts: each artifact
+// followed by its signature, written by the sign command.
+func publicationFiles(artifacts []string) []string {
+	files := make([]string, 0, 2*len(artifacts))
+	for _, a := range artifacts {
+		files = append(files, a, a+signatureExt)
+	}
+	return files
+}
+
+// printPublication writes into w the command signing artifacts, and the
+// files to publish once they are signed.
+func printPublication(w io.Writer, artifacts []string) {
+	fmt.Fprintf(w, "⚠ Sign them with `%s sign -key <name.key> %s`, and update S3 with: ⚠\n",
+		os.Args[0], strings.Join(artifacts, " "))
+	for _, f := range publicationFiles(artifacts) {
+		fmt.Fprintf(w, "- %s\n", f)
+	}
+}
+
+// sealedArtifact holds a payload encrypted for a set of auditors. The payload
+// is encrypted with AES-256-GCM by a random data key, and the data key is
+// wrapped for each auditor X25519 key with an ephemeral ECDH exchange.
+type sealedArtifact struct {
+	Version    int
+	Recipients []sealedRecipient
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+// sealedRecipient holds the data key of a sealedArtifact wrapped for an
+// auditor.
+type sealedRecipient struct {
+	// PublicKey is the X25519 public key of the auditor, to find its entry.
+	PublicKey    []byte
+	EphemeralKey []byte
+	Nonce        []byte
+	WrappedKey   []byte
+}
+
+// parseAuditorKeys reads the X25519 public keys of the auditors from PEM
+// files, as produced by:
+//
+//	openssl genpkey -algorithm X25519 -out auditor.pem
+//	openssl pkey -in auditor.pem -pubout -out auditor.pub.pem
+func parseAuditorKeys(files []string) ([]*ecdh.PublicKey, error) {
+	var keys []*ecdh.PublicKey
+	for _, file := range files {
+		block, err := readPEM(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse auditor key %s: %w", file, err)
+		}
+		pub, ok := key.(*ecdh.PublicKey)
+		if !ok || pub.Curve() != ecdh.X25519() {
+			return nil, fmt.Errorf("auditor key %s isn't a X25519 public key", file)
+		}
+		keys = append(keys, pub)
+	}
+	return keys, nil
+}
+
+// parseAuditorPrivateKey reads the X25519 private key of an auditor from a
+// PKCS#8 PEM file.
+func parseAuditorPrivateKey(file string) (*ecdh.PrivateKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse auditor key %s: %w", file, err)
+	}
+	priv, ok := key.(*ecdh.PrivateKey)
+	if !ok || priv.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("auditor key %s isn't a X25519 private key", file)
+	}
+	return priv, nil
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	bz, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(bz)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", file)
+	}
+	return block, nil
+}
+
+// sealForAuditors encrypts payload so it can only be decrypted by the private
+// keys of auditors.
+func sealForAuditors(payload []byte, auditors []*ecdh.PublicKey) (sealedArtifact, error) {
+	if len(auditors) == 0 {
+		return sealedArtifact{}, errors.New("no auditor key")
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return sealedArtifact{}, err
+	}
+	nonce, ciphertext, err := aesGCMSeal(dataKey, payload)
+	if err != nil {
+		return sealedArtifact{}, err
+	}
+	a := sealedArtifact{Version: 1, Nonce: nonce, Ciphertext: ciphertext}
+	for _, auditor := range auditors {
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return sealedArtifact{}, err
+		}
+		kek, err := sealKEK(ephemeral, auditor)
+		if err != nil {
+			return sealedArtifact{}, err
+		}
+		nonce, wrapped, err := aesGCMSeal(kek, dataKey)
+		if err != nil {
+			return sealedArtifact{}, err
+		}
+		a.Recipients = append(a.Recipients, sealedRecipient{
+			PublicKey:    auditor.Bytes(),
+			EphemeralKey: ephemeral.PublicKey().Bytes(),
+			Nonce:        nonce,
+			WrappedKey:   wrapped,
+		})
+	}
+	return a, nil
+}
+
+// open decrypts the payload of a with the private key of one of its
+// auditors.
+func (a sealedArtifact) open(priv *ecdh.PrivateKey) ([]byte, error) {
+	if a.Version != 1 {
+		return nil, fmt.Errorf("unsupported sealed artifact version %d", a.Version)
+	}
+	pub := priv.PublicKey().Bytes()
+	i := slices.IndexFunc(a.Recipients, func(r sealedRecipient) bool {
+		return bytes.Equal(r.PublicKey, pub)
+	})
+	if i == -1 {
+		return nil, errors.New("the artifact isn't sealed for this key")
+	}
+	r := a.Recipients[i]
+	ephemeral, err := ecdh.X25519().NewPublicKey(r.EphemeralKey)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := sealKEK(priv, ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := aesGCMOpen(kek, r.Nonce, r.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	return aesGCMOpen(dataKey, a.Nonce, a.Ciphertext)
+}
+
+// sealKEK derives the key encrypting the data key from the ECDH secret of
+// priv and pub. Both sides obtain the same key: the ephemeral side when
+// sealing, the auditor side when opening.
+func sealKEK(priv *ecdh.PrivateKey, pub *ecdh.PublicKey) ([]byte, error) {
+	secret, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+	// Bind the key to both public keys, ordered so both sides agree.
+	keys := [][]byte{priv.PublicKey().Bytes(), pub.Bytes()}
+	slices.SortFunc(keys, bytes.Compare)
+	h := sha256.New()
+	h.Write([]byte("govbox sealed artifact v1"))
+	h.Write(secret)
+	h.Write(keys[0])
+	h.Write(keys[1])
+	return h.Sum(nil), nil
+}
+
+func aesGCMSeal(key, plaintext []byte) (nonce, ciphertext []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, nil), nil
+}
+
+func aesGCMOpen(key, nonce, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// sealedAuditFiles are the files of the payload of the sealed artifact of an
+// airdrop.
+const (
+	sealedResultFile = "airdrop_result.pb"
+	sealedDetailFile = "airdrop_detail.csv"
+)
+
+// writeSealedAirdrop writes into file the full detail of airdrop, encrypted
+// for auditors. The payload is a zip archive of the airdrop result (see
+// writeAirdropResult) and the CSV detail per address.
+func writeSealedAirdrop(ctx context.Context, file, denom string, airdrop airdrop, auditors []*ecdh.PublicKey) error {
+	res, err := newAirdropResult(denom, airdrop)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(sealedResultFile)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(airdropblob.MarshalResult(res)); err != nil {
+		return err
+	}
+	w, err = zw.Create(sealedDetailFile)
+	if err != nil {
+		return err
+	}
+	if err := writeAirdropDetail(w, airdrop); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	sealed, err := sealForAuditors(buf.Bytes(), auditors)
+	if err != nil {
+		return err
+	}
+	bz, err := json.MarshalIndent(sealed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(ctx, file, bz)
+}
+
+// openSealedAirdrop decrypts the sealed artifact file with the auditor key
+// priv, and extracts its files into dir.
+func openSealedAirdrop(ctx context.Context, file string, priv *ecdh.PrivateKey, dir string) ([]string, error) {
+	bz, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var sealed sealedArtifact
+	if err := json.Unmarshal(bz, &sealed); err != nil {
+		return nil, fmt.Errorf("cannot json decode sealed artifact %s: %w", file, err)
+	}
+	payload, err := sealed.open(priv)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", file, err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(payload), int64(len(payload)))
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range zr.File {
+		if f.Name != sealedResultFile && f.Name != sealedDetailFile {
+			return nil, fmt.Errorf("unexpected file '%s' in %s", f.Name, file)
+		}
+		out := filepath.Join(dir, f.Name)
+		err := writeOutputFile(ctx, out, func(w io.Writer) error {
+			r, err := f.Open()
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+			_, err = io.Copy(w, r)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, out)
+	}
+	return files, nil
+}
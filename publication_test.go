@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+
+	"github.com/atomone-hub/govbox/airdropblob"
+)
+
+func TestPublicAirdrop(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	accounts := []Account{{Address: "a", LiquidAmount: sdk.NewDec(1_000 * M), StakedAmount: sdk.ZeroDec()}}
+	airdrop, err := distribution(accounts, defaultDistriParams(), "")
+	require.NoError(err)
+	airdrop.addresses = make(map[string]sdk.Int)
+	for i, n := range []struct {
+		count  int
+		amount int64
+	}{{12, 5}, {3, 500}, {10, 50_000}, {1, 5_000_000}} {
+		for j := 0; j < n.count; j++ {
+			airdrop.addresses[fmt.Sprintf("addr%d-%d", i, j)] = sdk.NewInt(n.amount * M)
+		}
+	}
+	tests := []struct {
+		name            string
+		minBucketSize   int
+		expectedBuckets []publicBucket
+	}{
+		{
+			name:          "merge the largest and smallest",
+			minBucketSize: 10,
+			expectedBuckets: []publicBucket{
+				{Range: "< 1k", Addresses: 15, Amount: sdk.NewInt((12*5 + 3*500) * M)},
+				{Range: "1k - 10k", Addresses: 0, Amount: sdk.ZeroInt()},
+				{Range: "> 10k", Addresses: 11, Amount: sdk.NewInt((10*50_000 + 5_000_000) * M)},
+			},
+		},
+		{
+			name:          "smallest merged with all the others",
+			minBucketSize: 13,
+			expectedBuckets: []publicBucket{
+				{Range: "all", Addresses: 26, Amount: sdk.NewInt((12*5 + 3*500 + 10*50_000 + 5_000_000) * M)},
+			},
+		},
+		{
+			name:          "no merge",
+			minBucketSize: 1,
+			expectedBuckets: []publicBucket{
+				{Range: "< 10", Addresses: 12, Amount: sdk.NewInt(12 * 5 * M)},
+				{Range: "10 - 100", Addresses: 0, Amount: sdk.ZeroInt()},
+				{Range: "100 - 1k", Addresses: 3, Amount: sdk.NewInt(3 * 500 * M)},
+				{Range: "1k - 10k", Addresses: 0, Amount: sdk.ZeroInt()},
+				{Range: "10k - 100k", Addresses: 10, Amount: sdk.NewInt(10 * 50_000 * M)},
+				{Range: "100k - 1M", Addresses: 0, Amount: sdk.ZeroInt()},
+				{Range: "> 1M", Addresses: 1, Amount: sdk.NewInt(5_000_000 * M)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub, err := newPublicAirdrop("uatone", airdrop, tt.minBucketSize)
+
+			require.NoError(err)
+			assert.Equal(26, pub.Addresses)
+			assert.Equal(tt.expectedBuckets, pub.Buckets)
+		})
+	}
+}
+
+func TestSealedAirdrop(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		dir      = t.TempDir()
+		accounts = []Account{
+			{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1_000 * M), Vote: govtypes.NewNonSplitVoteOption(govtypes.OptionYes)},
+			{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1_000 * M), Vote: govtypes.NewNonSplitVoteOption(govtypes.OptionNo)},
+			{Address: "liquid", LiquidAmount: sdk.NewDec(1_000 * M), StakedAmount: sdk.ZeroDec()},
+		}
+		writeKey = func(name string, typ string, der []byte) string {
+			file := filepath.Join(dir, name)
+			require.NoError(os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600))
+			return file
+		}
+		pubFiles  []string
+		privFiles []string
+	)
+	for i := range 2 {
+		priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+		require.NoError(err)
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		require.NoError(err)
+		privFiles = append(privFiles, writeKey(fmt.Sprintf("auditor%d.pem", i), "PRIVATE KEY", der))
+		der, err = x509.MarshalPKIXPublicKey(priv.PublicKey())
+		require.NoError(err)
+		pubFiles = append(pubFiles, writeKey(fmt.Sprintf("auditor%d.pub.pem", i), "PUBLIC KEY", der))
+	}
+	auditors, err := parseAuditorKeys(pubFiles)
+	require.NoError(err)
+	airdrop, err := distribution(accounts, defaultDistriParams(), "")
+	require.NoError(err)
+	file := filepath.Join(dir, "airdrop_sealed.json")
+
+	require.NoError(writeSealedAirdrop(context.Background(), file, "uatone", airdrop, auditors))
+
+	for i, privFile := range privFiles {
+		priv, err := parseAuditorPrivateKey(privFile)
+		require.NoError(err)
+		out := filepath.Join(dir, fmt.Sprint(i))
+		require.NoError(os.Mkdir(out, 0o700))
+		files, err := openSealedAirdrop(context.Background(), file, priv, out)
+		require.NoError(err)
+		assert.Len(files, 2)
+		bz, err := os.ReadFile(filepath.Join(out, sealedResultFile))
+		require.NoError(err)
+		res, err := airdropblob.UnmarshalResult(bz)
+		require.NoError(err)
+		assert.Len(res.Entries, len(airdrop.addresses))
+		assert.FileExists(filepath.Join(out, sealedDetailFile))
+	}
+	// another key can't open it
+	other, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(err)
+	_, err = openSealedAirdrop(context.Background(), file, other, dir)
+	assert.ErrorContains(err, "isn't sealed for this key")
+	_, err = sealForAuditors([]byte("detail"), nil)
+	assert.ErrorContains(err, "no auditor key")
+}
+
+func TestPublicationFiles(t *testing.T) {
+	assert := assert.New(t)
+	artifacts := []string{"out/airdrop_public.json", "out/airdrop_sealed.json"}
+
+	assert.Equal([]string{
+		"out/airdrop_public.json", "out/airdrop_public.json.minisig",
+		"out/airdrop_sealed.json", "out/airdrop_sealed.json.minisig",
+	}, publicationFiles(artifacts))
+	var buf bytes.Buffer
+	printPublication(&buf, artifacts)
+	assert.Contains(buf.String(), "sign -key <name.key> out/airdrop_public.json out/airdrop_sealed.json")
+	assert.Contains(buf.String(), "- out/airdrop_sealed.json.minisig\n")
+}
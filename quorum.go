@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// govTallyParams are the tally parameters of the gov module at the snapshot
+// height.
+type govTallyParams struct {
+	// quorum is the minimum turnout, over the bonded stake.
+	quorum sdk.Dec
+	// threshold is the minimum ratio of Yes over the non-abstain votes.
+	threshold sdk.Dec
+	// vetoThreshold is the maximum ratio of NoWithVeto over the votes.
+	vetoThreshold sdk.Dec
+}
+
+// tallyParamsJSON is the JSON of the tally params in gov_params.json, which
+// is the one of the gov v1 params, the v1beta1 tally params, or one of them
+// nested in a "params" or "tally_params" field as in the gov params query.
+type tallyParamsJSON struct {
+	Quorum        string           `json:"quorum"`
+	Threshold     string           `json:"threshold"`
+	VetoThreshold string           `json:"veto_threshold"`
+	Params        *tallyParamsJSON `json:"params"`
+	TallyParams   *tallyParamsJSON `json:"tally_params"`
+}
+
+func (j tallyParamsJSON) tallyParams() (govTallyParams, error) {
+	switch {
+	case j.Quorum == "" && j.Params != nil:
+		return j.Params.tallyParams()
+	case j.Quorum == "" && j.TallyParams != nil:
+		return j.TallyParams.tallyParams()
+	}
+	var (
+		p   govTallyParams
+		err error
+	)
+	for _, f := range []struct {
+		name  string
+		value string
+		dec   *sdk.Dec
+	}{
+		{"quorum", j.Quorum, &p.quorum},
+		{"threshold", j.Threshold, &p.threshold},
+		{"veto_threshold", j.VetoThreshold, &p.vetoThreshold},
+	} {
+		*f.dec, err = sdk.NewDecFromStr(f.value)
+		if err != nil {
+			return govTallyParams{}, fmt.Errorf("invalid %s '%s': %w", f.name, f.value, err)
+		}
+	}
+	return p, nil
+}
+
+// parseTallyParams reads the tally params of <path>/gov_params.json. It
+// returns nil if the file doesn't exist, since it was not part of the
+// inputs of the first snapshots.
+func parseTallyParams(ctx context.Context, path string) (_ *govTallyParams, err error) {
+	f, err := openInput(ctx, joinInput(path, "gov_params.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var j tallyParamsJSON
+	if err := json.NewDecoder(f).Decode(&j); err != nil {
+		return nil, fmt.Errorf("gov_params.json: %w", err)
+	}
+	p, err := j.tallyParams()
+	if err != nil {
+		return nil, fmt.Errorf("gov_params.json: %w", err)
+	}
+	return &p, nil
+}
+
+// turnoutCheck compares the turnout and the votes of the $ATOM distribution
+// with the tally params. It only reads the distribution, so it doesn't change
+// the allocations and gives the same result for every run of the same
+// snapshot.
+type turnoutCheck struct {
+	params govTallyParams
+	// turnout is the ratio of the voting stake over the bonded stake, yes
+	// the ratio of Yes over the non-abstain votes, and veto the ratio of
+	// NoWithVeto over the votes.
+	turnout sdk.Dec
+	yes     sdk.Dec
+	veto    sdk.Dec
+}
+
+// newTurnoutCheck returns the turnoutCheck of atom. The turnout is over
+// bonded, the tokens of the bonded validators as in the quorum of the gov
+// module, while the votes are the ones of the accounts of atom. The votes of
+// the module and interchain accounts, which aren't in atom, are missing, so
+// the turnout is an approximation of the one of the tally.
+func newTurnoutCheck(atom distrib, bonded sdk.Dec, params govTallyParams) turnoutCheck {
+	var (
+		staked  = atom.supply.Sub(atom.unstaked)
+		voted   = staked.Sub(atom.votes[govtypes.OptionEmpty])
+		nonAbst = voted.Sub(atom.votes[govtypes.OptionAbstain])
+		c       = turnoutCheck{params: params, turnout: sdk.ZeroDec(), yes: sdk.ZeroDec(), veto: sdk.ZeroDec()}
+	)
+	if bonded.IsPositive() {
+		c.turnout = voted.Quo(bonded)
+	}
+	if voted.IsPositive() {
+		c.veto = atom.votes[govtypes.OptionNoWithVeto].Quo(voted)
+	}
+	if nonAbst.IsPositive() {
+		c.yes = atom.votes[govtypes.OptionYes].Quo(nonAbst)
+	}
+	return c
+}
+
+// quorumReached, vetoed and passed follow the tally of the gov module.
+func (c turnoutCheck) quorumReached() bool { return c.turnout.GTE(c.params.quorum) }
+func (c turnoutCheck) vetoed() bool        { return c.veto.GT(c.params.vetoThreshold) }
+func (c turnoutCheck) passed() bool {
+	return c.quorumReached() && !c.vetoed() && c.yes.GT(c.params.threshold)
+}
+
+// checkProposalStatus adds a warning if the final status of prop isn't the
+// outcome of c. The proposals still in voting period aren't checked.
+func (c turnoutCheck) checkProposalStatus(prop govtypes.Proposal) {
+	switch prop.Status {
+	case govtypes.StatusPassed, govtypes.StatusRejected:
+		if c.passed() != (prop.Status == govtypes.StatusPassed) {
+			warnings.add(warnTallyOutcome, fmt.Sprintf("proposal %d %s", prop.ProposalId, prop.Status))
+		}
+	}
+}
+
+// loadTurnoutCheck returns the turnoutCheck of atom with the tally params of
+// path, nil if path has no gov_params.json, and checks the status of the
+// proposal of <path>/prop.json.
+func loadTurnoutCheck(ctx context.Context, path string, atom distrib) (*turnoutCheck, error) {
+	params, err := parseTallyParams(ctx, path)
+	if err != nil || params == nil {
+		return nil, err
+	}
+	bonded := sdk.ZeroDec()
+	err = iterateValidators(ctx, path, func(val stakingtypes.Validator) error {
+		bonded = bonded.Add(val.GetBondedTokens().ToLegacyDec())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := newTurnoutCheck(atom, bonded, *params)
+	prop, err := parseProp(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		c.checkProposalStatus(prop)
+	}
+	return &c, nil
+}
+
+func reportTurnout(sec *reportSection, c turnoutCheck) {
+	outcome := func(ok bool, yes, no string) string {
+		if ok {
+			return yes
+		}
+		return no
+	}
+	sec.text("Turnout vs gov tally params (turnout over the bonded stake, without the votes of the module and interchain accounts)")
+	table := sec.table("", "COMPUTED", "PARAM", "")
+	table.Append([]string{"Turnout / quorum", humanPercent(c.turnout), humanPercent(c.params.quorum),
+		outcome(c.quorumReached(), "reached", "not reached")})
+	table.Append([]string{"Yes / threshold", humanPercent(c.yes), humanPercent(c.params.threshold),
+		outcome(c.yes.GT(c.params.threshold), "above", "below")})
+	table.Append([]string{"NoWithVeto / veto threshold", humanPercent(c.veto), humanPercent(c.params.vetoThreshold),
+		outcome(c.vetoed(), "vetoed", "not vetoed")})
+	sec.text("Computed outcome: %s", outcome(c.passed(), "passed", "rejected"))
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestParseTallyParams(t *testing.T) {
+	expected := &govTallyParams{
+		quorum: sdk.NewDecWithPrec(4, 1), threshold: sdk.NewDecWithPrec(5, 1), vetoThreshold: sdk.NewDecWithPrec(334, 3),
+	}
+	tests := []struct {
+		name          string
+		json          string
+		expected      *govTallyParams
+		expectedError string
+	}{
+		{
+			name:     "tally params",
+			json:     `{"quorum": "0.4", "threshold": "0.5", "veto_threshold": "0.334"}`,
+			expected: expected,
+		},
+		{
+			name:     "v1beta1 query",
+			json:     `{"voting_params": {}, "tally_params": {"quorum": "0.4", "threshold": "0.5", "veto_threshold": "0.334"}}`,
+			expected: expected,
+		},
+		{
+			name:     "v1 query",
+			json:     `{"params": {"quorum": "0.4", "threshold": "0.5", "veto_threshold": "0.334", "min_deposit": []}}`,
+			expected: expected,
+		},
+		{
+			name:          "invalid",
+			json:          `{"quorum": "0.4", "threshold": "0.5"}`,
+			expectedError: "gov_params.json: invalid veto_threshold '': decimal string cannot be empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			dir := t.TempDir()
+			require.NoError(os.WriteFile(filepath.Join(dir, "gov_params.json"), []byte(tt.json), 0o600))
+
+			params, err := parseTallyParams(context.Background(), dir)
+
+			if tt.expectedError != "" {
+				assert.EqualError(err, tt.expectedError)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tt.expected, params)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		params, err := parseTallyParams(context.Background(), t.TempDir())
+
+		require.NoError(t, err)
+		assert.Nil(t, params)
+	})
+}
+
+func TestTurnoutCheck(t *testing.T) {
+	params := govTallyParams{
+		quorum: sdk.NewDecWithPrec(4, 1), threshold: sdk.NewDecWithPrec(5, 1), vetoThreshold: sdk.NewDecWithPrec(334, 3),
+	}
+	// newAtom returns a distrib of 1000 staked, and 500 unstaked, $ATOM
+	// with the votes yes, no, nwv and abstain, the rest didn't vote.
+	newAtom := func(yes, no, nwv, abstain int64) distrib {
+		d := distrib{supply: sdk.NewDec(1500), unstaked: sdk.NewDec(500), votes: newVoteMap()}
+		d.votes.add(govtypes.OptionYes, sdk.NewDec(yes))
+		d.votes.add(govtypes.OptionNo, sdk.NewDec(no))
+		d.votes.add(govtypes.OptionNoWithVeto, sdk.NewDec(nwv))
+		d.votes.add(govtypes.OptionAbstain, sdk.NewDec(abstain))
+		d.votes.add(govtypes.OptionEmpty, sdk.NewDec(1000-yes-no-nwv-abstain))
+		return d
+	}
+	tests := []struct {
+		name           string
+		atom           distrib
+		expectedQuorum bool
+		expectedVetoed bool
+		expectedPassed bool
+	}{
+		{
+			name:           "passed",
+			atom:           newAtom(300, 100, 0, 200),
+			expectedQuorum: true,
+			expectedPassed: true,
+		},
+		{
+			name: "no quorum",
+			atom: newAtom(300, 0, 0, 0),
+		},
+		{
+			name:           "abstain counted in the quorum",
+			atom:           newAtom(200, 100, 0, 300),
+			expectedQuorum: true,
+			expectedPassed: true,
+		},
+		{
+			name:           "threshold not reached",
+			atom:           newAtom(200, 200, 0, 100),
+			expectedQuorum: true,
+		},
+		{
+			name:           "vetoed",
+			atom:           newAtom(300, 0, 250, 100),
+			expectedQuorum: true,
+			expectedVetoed: true,
+		},
+		{
+			name: "no votes",
+			atom: newAtom(0, 0, 0, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			c := newTurnoutCheck(tt.atom, sdk.NewDec(1000), params)
+
+			assert.Equal(tt.expectedQuorum, c.quorumReached(), "turnout %s", c.turnout)
+			assert.Equal(tt.expectedVetoed, c.vetoed(), "veto %s", c.veto)
+			assert.Equal(tt.expectedPassed, c.passed(), "yes %s", c.yes)
+		})
+	}
+
+	t.Run("turnout over the bonded stake", func(t *testing.T) {
+		assert := assert.New(t)
+
+		// 600 votes over 1500 bonded
+		c := newTurnoutCheck(newAtom(300, 100, 0, 200), sdk.NewDec(1500), params)
+
+		assert.Equal(sdk.NewDecWithPrec(4, 1), c.turnout)
+		assert.True(c.quorumReached())
+		c = newTurnoutCheck(newAtom(300, 100, 0, 200), sdk.NewDec(1501), params)
+		assert.False(c.quorumReached())
+		c = newTurnoutCheck(newAtom(300, 100, 0, 200), sdk.ZeroDec(), params)
+		assert.True(c.turnout.IsZero())
+	})
+
+	t.Run("proposal status", func(t *testing.T) {
+		assert := assert.New(t)
+		defer func(r *warningsRegistry) { warnings = r }(warnings)
+		warnings = newWarningsRegistry()
+		c := newTurnoutCheck(newAtom(300, 100, 0, 200), sdk.NewDec(1000), params)
+
+		c.checkProposalStatus(govtypes.Proposal{ProposalId: 1, Status: govtypes.StatusPassed})
+		c.checkProposalStatus(govtypes.Proposal{ProposalId: 2, Status: govtypes.StatusVotingPeriod})
+		assert.Zero(warnings.get(warnTallyOutcome))
+		c.checkProposalStatus(govtypes.Proposal{ProposalId: 3, Status: govtypes.StatusRejected})
+		assert.Equal(1, warnings.get(warnTallyOutcome))
+
+		var sec reportSection
+		reportTurnout(&sec, c)
+		assert.Equal("Turnout vs gov tally params (turnout over the bonded stake, without the votes of the module and interchain accounts)", sec.Blocks[0].Text)
+		assert.Len(sec.Blocks[1].Table.Rows, 3)
+		assert.Equal("Computed outcome: passed", sec.Blocks[2].Text)
+	})
+}
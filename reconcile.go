@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	icatypes "github.com/cosmos/ibc-go/v7/modules/apps/27-interchain-accounts/types"
+	ibctransfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+)
+
+// isVestingType returns true if typeURL is the type of a vesting account.
+func isVestingType(typeURL string) bool {
+	return strings.Contains(typeURL, "Vesting")
+}
+
+// addAccountType records the type of the account addr in typesByAddr.
+// A vesting account embeds a BaseAccount with the same address, so when some
+// derived data holds both, the vesting type is kept whatever their order,
+// otherwise the last type wins. Duplicates are reported in the warnings.
+func addAccountType(typesByAddr map[string]string, addr, typeURL string) {
+	prev, ok := typesByAddr[addr]
+	if !ok {
+		typesByAddr[addr] = typeURL
+		return
+	}
+	if isVestingType(prev) != isVestingType(typeURL) {
+		warnings.add(warnVestingBaseDuplicate, addr)
+	} else {
+		warnings.add(warnAccountDuplicate, addr)
+	}
+	if isVestingType(prev) && !isVestingType(typeURL) {
+		return
+	}
+	typesByAddr[addr] = typeURL
+}
+
+// parseSupply returns the bank total supply of denom from the supply.json file
+// of path, and false if the file doesn't exist.
+func parseSupply(path, denom string) (_ sdk.Int, _ bool, err error) {
+	f, err := openInput(context.Background(), joinInput(path, "supply.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return sdk.Int{}, false, nil
+	}
+	if err != nil {
+		return sdk.Int{}, false, err
+	}
+	defer closeInput(f, &err)
+	var supply sdk.Coins
+	if err := json.NewDecoder(f).Decode(&supply); err != nil {
+		return sdk.Int{}, false, fmt.Errorf("cannot json decode supply from file %s: %w", filepath.Join(path, "supply.json"), err)
+	}
+	return supply.AmountOf(denom), true, nil
+}
+
+// moduleNames are the names of the module accounts of the Cosmos Hub, used
+// to label their balances.
+var moduleNames = []string{
+	authtypes.FeeCollectorName,
+	distrtypes.ModuleName,
+	govtypes.ModuleName,
+	minttypes.ModuleName,
+	stakingtypes.BondedPoolName,
+	stakingtypes.NotBondedPoolName,
+	ibctransfertypes.ModuleName,
+	icatypes.ModuleName,
+}
+
+// moduleName returns the name of the module account addr, or addr if it's
+// not a known module.
+func moduleName(addr string) string {
+	_, bz, err := bech32.DecodeAndConvert(addr)
+	if err != nil {
+		return addr
+	}
+	for _, name := range moduleNames {
+		if bytes.Equal(authtypes.NewModuleAddress(name), bz) {
+			return name
+		}
+	}
+	return addr
+}
+
+// supplyBreakdown splits the sum of the balances between the regular
+// accounts and the module accounts, for comparison with the bank supply.
+type supplyBreakdown struct {
+	numAccounts int
+	accounts    sdk.Int
+	// modules holds the balance per module account name.
+	modules map[string]sdk.Int
+	supply  sdk.Int
+}
+
+func newSupplyBreakdown(balancesByAddr map[string]sdk.Coin, accountTypesByAddr map[string]string, supply sdk.Int) supplyBreakdown {
+	b := supplyBreakdown{
+		accounts: sdk.ZeroInt(),
+		modules:  make(map[string]sdk.Int),
+		supply:   supply,
+	}
+	for addr, bal := range balancesByAddr {
+		if accountTypesByAddr[addr] != "/cosmos.auth.v1beta1.ModuleAccount" {
+			b.numAccounts++
+			b.accounts = b.accounts.Add(bal.Amount)
+			continue
+		}
+		name := moduleName(addr)
+		if amt, ok := b.modules[name]; ok {
+			b.modules[name] = amt.Add(bal.Amount)
+		} else {
+			b.modules[name] = bal.Amount
+		}
+	}
+	return b
+}
+
+// total returns the sum of the balances of the accounts and the modules.
+func (b supplyBreakdown) total() sdk.Int {
+	total := b.accounts
+	for _, amt := range b.modules {
+		total = total.Add(amt)
+	}
+	return total
+}
+
+// String returns the breakdown as a table.
+func (b supplyBreakdown) String() string {
+	var buf strings.Builder
+	table := newMarkdownTableWriter(&buf, "", "AMOUNT")
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.Append([]string{fmt.Sprintf("%s accounts", humanCount(b.numAccounts)), b.accounts.String()})
+	for _, name := range slices.Sorted(maps.Keys(b.modules)) {
+		table.Append([]string{"module " + name, b.modules[name].String()})
+	}
+	table.Append([]string{"total", b.total().String()})
+	table.Append([]string{"bank supply", b.supply.String()})
+	table.Append([]string{"diff", b.total().Sub(b.supply).String()})
+	table.Render()
+	return buf.String()
+}
+
+// reconcileSupply returns an error with the detailed breakdown of the
+// balances if the sum of the balances of the accounts and the module accounts
+// doesn't match the bank total supply, which means that some balances are
+// missing (partial or truncated export) or double counted.
+func reconcileSupply(balancesByAddr map[string]sdk.Coin, accountTypesByAddr map[string]string, supply sdk.Int) error {
+	b := newSupplyBreakdown(balancesByAddr, accountTypesByAddr, supply)
+	if total := b.total(); !total.Equal(supply) {
+		return fmt.Errorf("sum of balances %s doesn't match the bank total supply %s (diff %s):\n%s",
+			total, supply, total.Sub(supply), b)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingtypes "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+func TestParseVestingDuplicatedByBaseAccount(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	warnings = newWarningsRegistry()
+	var (
+		addrs    = createAccountAddrs(2)
+		datapath = t.TempDir()
+		base     = authtypes.NewBaseAccountWithAddress(addrs[0])
+		vesting  = vestingtypes.NewDelayedVestingAccountRaw(
+			vestingtypes.NewBaseVestingAccount(base, sdk.NewCoins(sdk.NewInt64Coin("uatom", 10)), 42))
+		other = authtypes.NewBaseAccountWithAddress(addrs[1])
+	)
+	var authGen authtypes.GenesisState
+	// The base account of the vesting account is present before and after it
+	for _, acc := range []authtypes.GenesisAccount{base, vesting, base, other} {
+		any, err := codectypes.NewAnyWithValue(acc)
+		require.NoError(err)
+		authGen.Accounts = append(authGen.Accounts, any)
+	}
+	bz, err := cdc.MarshalJSON(&authGen)
+	require.NoError(err)
+	require.NoError(os.WriteFile(filepath.Join(datapath, "auth_genesis.json"), bz, 0o600))
+
+	typesByAddr, err := parseAccountTypesPerAddr(datapath)
+
+	require.NoError(err)
+	assert.Equal(map[string]string{
+		addrs[0].String(): "/cosmos.vesting.v1beta1.DelayedVestingAccount",
+		addrs[1].String(): "/cosmos.auth.v1beta1.BaseAccount",
+	}, typesByAddr)
+	assert.Equal(2, warnings.get(warnVestingBaseDuplicate))
+	assert.Zero(warnings.get(warnAccountDuplicate))
+}
+
+func TestParseBalancesDuplicate(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	warnings = newWarningsRegistry()
+	var (
+		addrs    = createAccountAddrs(2)
+		datapath = t.TempDir()
+		balances = []banktypes.Balance{
+			{Address: addrs[0].String(), Coins: sdk.NewCoins(sdk.NewInt64Coin("uatom", 10))},
+			{Address: addrs[1].String(), Coins: sdk.NewCoins(sdk.NewInt64Coin("uatom", 20))},
+			{Address: addrs[0].String(), Coins: sdk.NewCoins(sdk.NewInt64Coin("uatom", 10))},
+		}
+	)
+	require.NoError(writeJSONFile(context.Background(), filepath.Join(datapath, "balances.json"), balances))
+	require.NoError(writeJSONFile(context.Background(), filepath.Join(datapath, "supply.json"),
+		sdk.NewCoins(sdk.NewInt64Coin("uatom", 30), sdk.NewInt64Coin("uother", 1))))
+
+	balancesByAddr, err := parseBalancesByAddr(context.Background(), datapath, "uatom")
+
+	require.NoError(err)
+	assert.Equal(map[string]sdk.Coin{
+		addrs[0].String(): sdk.NewInt64Coin("uatom", 10),
+		addrs[1].String(): sdk.NewInt64Coin("uatom", 20),
+	}, balancesByAddr)
+	assert.Equal(1, warnings.get(warnBalanceDuplicate))
+	supply, ok, err := parseSupply(datapath, "uatom")
+	require.NoError(err)
+	require.True(ok)
+	assert.Equal(sdk.NewInt(30), supply)
+	assert.NoError(reconcileSupply(balancesByAddr, nil, supply))
+}
+
+func TestReconcileSupply(t *testing.T) {
+	assert := assert.New(t)
+	var (
+		addrs          = createAccountAddrs(2)
+		distrAddr      = sdk.AccAddress(authtypes.NewModuleAddress(distrtypes.ModuleName)).String()
+		bondedPoolAddr = sdk.AccAddress(authtypes.NewModuleAddress(stakingtypes.BondedPoolName)).String()
+		balancesByAddr = map[string]sdk.Coin{
+			addrs[0].String(): sdk.NewInt64Coin("uatom", 10),
+			addrs[1].String(): sdk.NewInt64Coin("uatom", 20),
+			distrAddr:         sdk.NewInt64Coin("uatom", 5),
+			bondedPoolAddr:    sdk.NewInt64Coin("uatom", 100),
+		}
+		typesByAddr = map[string]string{
+			addrs[0].String(): "/cosmos.auth.v1beta1.BaseAccount",
+			addrs[1].String(): "/cosmos.vesting.v1beta1.DelayedVestingAccount",
+			distrAddr:         "/cosmos.auth.v1beta1.ModuleAccount",
+			bondedPoolAddr:    "/cosmos.auth.v1beta1.ModuleAccount",
+		}
+	)
+
+	assert.NoError(reconcileSupply(balancesByAddr, typesByAddr, sdk.NewInt(135)))
+	err := reconcileSupply(balancesByAddr, typesByAddr, sdk.NewInt(140))
+
+	if assert.Error(err) {
+		assert.Equal(`sum of balances 135 doesn't match the bank total supply 140 (diff -5):
+|                           | AMOUNT |
+|---------------------------|--------|
+|                2 accounts |     30 |
+| module bonded_tokens_pool |    100 |
+|       module distribution |      5 |
+|                     total |    135 |
+|               bank supply |    140 |
+|                      diff |     -5 |
+`, err.Error())
+	}
+	b := newSupplyBreakdown(balancesByAddr, typesByAddr, sdk.NewInt(140))
+	assert.Equal(2, b.numAccounts)
+	assert.Equal(sdk.NewInt(30), b.accounts)
+	assert.Equal(map[string]sdk.Int{
+		distrtypes.ModuleName:       sdk.NewInt(5),
+		stakingtypes.BondedPoolName: sdk.NewInt(100),
+	}, b.modules)
+
+	_, ok, err := parseSupply(t.TempDir(), "uatom")
+	assert.NoError(err)
+	assert.False(ok)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"maps"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// reconciliationStage is a step of the accounts from the input of the
+// distribution to its output, with their $ATOM and $ATONE amounts.
+type reconciliationStage struct {
+	name     string
+	accounts int
+	// atom is nil for the output stages, and atone for the input.
+	atom  sdk.Dec
+	atone sdk.Dec
+}
+
+// reconciliation explains the accounts and the amounts between the input and
+// the output of a distribution: the input accounts are either excluded by a
+// policy, zero-rounded or written, and the written allocations plus the
+// withheld amounts are the output addresses.
+type reconciliation struct {
+	stages []reconciliationStage
+	// unexplainedAccounts and unexplainedAmount are the accounts and the
+	// $ATONE amount lost between the written allocations and the output
+	// addresses, e.g. 2 accounts written to the same output address.
+	unexplainedAccounts int
+	unexplainedAmount   sdk.Int
+}
+
+// newReconciliation builds the reconciliation of a from its audit log, the
+// decisions taken for each input account. An account with no final amount is
+// excluded by its last policy, unless it's zero-rounded.
+func newReconciliation(a airdrop) reconciliation {
+	var (
+		input       = reconciliationStage{name: "Input accounts", atom: sdk.ZeroDec()}
+		zeroRounded = reconciliationStage{name: "Zero-rounded", atom: sdk.ZeroDec(), atone: sdk.ZeroDec()}
+		written     = reconciliationStage{name: "Written", atom: sdk.ZeroDec(), atone: sdk.ZeroDec()}
+		excluded    = make(map[string]*reconciliationStage)
+		writtenAmt  = sdk.ZeroInt()
+		writtenAddr = make(map[string]bool)
+	)
+	for _, e := range a.audit {
+		atom := e.LiquidAmount.Add(e.StakedAmount)
+		if e.LockedAmount != nil {
+			atom = atom.Add(*e.LockedAmount)
+		}
+		input.accounts++
+		input.atom = input.atom.Add(atom)
+		stage := &written
+		switch {
+		case slices.Contains(e.Policies, policyZeroAmount):
+			stage = &zeroRounded
+		case e.FinalAmount.IsNil() || e.FinalAmount.IsZero():
+			policy := "unknown"
+			if len(e.Policies) > 0 {
+				policy = e.Policies[len(e.Policies)-1]
+			}
+			if excluded[policy] == nil {
+				excluded[policy] = &reconciliationStage{
+					name: "Excluded by " + policy, atom: sdk.ZeroDec(), atone: sdk.ZeroDec(),
+				}
+			}
+			stage = excluded[policy]
+		default:
+			writtenAmt = writtenAmt.Add(e.FinalAmount)
+			writtenAddr[e.OutputAddress] = true
+		}
+		stage.accounts++
+		stage.atom = stage.atom.Add(atom)
+		if stage == &written {
+			stage.atone = stage.atone.Add(e.FinalAmount.ToLegacyDec())
+		} else {
+			stage.atone = stage.atone.Add(e.Amount)
+		}
+	}
+	r := reconciliation{stages: []reconciliationStage{input}}
+	for _, policy := range slices.Sorted(maps.Keys(excluded)) {
+		r.stages = append(r.stages, *excluded[policy])
+	}
+	r.stages = append(r.stages, zeroRounded, written)
+	if len(a.withholdings) > 0 {
+		r.stages = append(r.stages, reconciliationStage{
+			name: "Withheld to escrow", accounts: len(a.withholdings), atone: a.totalWithheld().ToLegacyDec(),
+		})
+		writtenAmt = writtenAmt.Add(a.totalWithheld())
+	}
+	output := reconciliationStage{name: "Output addresses", accounts: len(a.addresses)}
+	outputAmt := sdk.ZeroInt()
+	for _, amt := range a.addresses {
+		outputAmt = outputAmt.Add(amt)
+	}
+	output.atone = outputAmt.ToLegacyDec()
+	r.stages = append(r.stages, output)
+
+	expectedAddrs := written.accounts
+	if len(a.withholdings) > 0 && !writtenAddr[a.withholdingEscrow] {
+		expectedAddrs++
+	}
+	r.unexplainedAccounts = expectedAddrs - len(a.addresses)
+	r.unexplainedAmount = writtenAmt.Sub(outputAmt)
+	return r
+}
+
+// unexplained returns true if accounts or amounts are lost between the
+// stages.
+func (r reconciliation) unexplained() bool {
+	return r.unexplainedAccounts != 0 || !r.unexplainedAmount.IsZero()
+}
+
+func reportReconciliation(sec *reportSection, r reconciliation) {
+	sec.text("Reconciliation of the accounts between the stages")
+	table := sec.table("STAGE", "ACCOUNTS", "$ATOM", "$ATONE")
+	for _, s := range r.stages {
+		var atom, atone string
+		if !s.atom.IsNil() {
+			atom = humand(s.atom)
+		}
+		if !s.atone.IsNil() {
+			atone = humand(s.atone)
+		}
+		table.Append([]string{s.name, humanCount(s.accounts), atom, atone})
+	}
+	if r.unexplained() {
+		sec.text("⚠ %d account(s) and %s $ATONE unexplained between the written allocations and the output addresses ⚠",
+			r.unexplainedAccounts, human(r.unexplainedAmount))
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestReconciliation(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs    = createAccountAddrs(4)
+		yes      = govtypes.NewNonSplitVoteOption(govtypes.OptionYes)
+		accounts = []Account{
+			{Address: addrs[0].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100), Vote: yes},
+			// slashed
+			{Address: addrs[1].String(), LiquidAmount: sdk.NewDec(50), StakedAmount: sdk.NewDec(50), Vote: yes},
+			// opted-out
+			{Address: addrs[2].String(), LiquidAmount: sdk.NewDec(20), StakedAmount: sdk.ZeroDec()},
+			// zero-rounded
+			{Address: addrs[3].String(), LiquidAmount: sdk.NewDecWithPrec(1, 1), StakedAmount: sdk.ZeroDec()},
+		}
+		params = defaultDistriParams()
+	)
+	params.entityGroups = []entityGroup{{Name: "a", Policy: entityPolicySlash, Addresses: []string{addrs[1].String()}}}
+	params.optOut = []string{addrs[2].String()}
+	params.optOutPolicy = optOutPolicyCommunityPool
+	airdrop, err := distribution(accounts, params, "atone")
+	require.NoError(err)
+
+	r := newReconciliation(airdrop)
+
+	var (
+		names    []string
+		counts   []int
+		atomAmts []sdk.Dec
+	)
+	for _, s := range r.stages {
+		names = append(names, s.name)
+		counts = append(counts, s.accounts)
+		atomAmts = append(atomAmts, s.atom)
+	}
+	assert.Equal([]string{
+		"Input accounts", "Excluded by a-slash", "Excluded by opt-out-community-pool",
+		"Zero-rounded", "Written", "Output addresses",
+	}, names)
+	assert.Equal([]int{4, 1, 1, 1, 1, 1}, counts)
+	assert.Equal([]sdk.Dec{
+		sdk.MustNewDecFromStr("220.1"), sdk.NewDec(100), sdk.NewDec(20), sdk.NewDecWithPrec(1, 1), sdk.NewDec(100), {},
+	}, atomAmts)
+	assert.Equal(airdrop.optOut.atone, r.stages[2].atone)
+	assert.Equal(r.stages[4].atone, r.stages[5].atone)
+	assert.False(r.unexplained())
+
+	// 2 accounts of the same key are written to the same output address
+	airdrop.audit = append(airdrop.audit, airdrop.audit[0])
+
+	r = newReconciliation(airdrop)
+
+	assert.True(r.unexplained())
+	assert.Equal(1, r.unexplainedAccounts)
+	assert.Equal(airdrop.audit[0].FinalAmount, r.unexplainedAmount)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+)
+
+// reportSectionID identifies a section of the distribution report.
+type reportSectionID int
+
+// Sections of the distribution report, in the order they are rendered.
+const (
+	// sectionSummary holds the supplies, the solved parameters and the
+	// policies applied to all the accounts.
+	sectionSummary reportSectionID = iota
+	// sectionPerVote holds the distributions per vote, and the policies
+	// depending on the votes.
+	sectionPerVote
+	// sectionConcentration holds the shares per holder size.
+	sectionConcentration
+	// sectionValidators holds the $ATOM voting power of the validators.
+	sectionValidators
+	// sectionExclusions holds the amounts removed from the distribution and
+	// the reconciliation of the accounts.
+	sectionExclusions
+	numReportSections
+)
+
+// reportSectionNames are the names of the sections in -sections and in the
+// json and html reports.
+var reportSectionNames = [numReportSections]string{
+	sectionSummary:       "summary",
+	sectionPerVote:       "per-vote",
+	sectionConcentration: "concentration",
+	sectionValidators:    "validators",
+	sectionExclusions:    "exclusions",
+}
+
+var reportSectionTitles = [numReportSections]string{
+	sectionSummary:       "Summary",
+	sectionPerVote:       "Per vote",
+	sectionConcentration: "Concentration",
+	sectionValidators:    "Validators",
+	sectionExclusions:    "Exclusions",
+}
+
+func (id reportSectionID) String() string {
+	return reportSectionNames[id]
+}
+
+// Formats of the distribution report.
+const (
+	// reportFormatTerminal renders the tables aligned and colored on a
+	// terminal, and as markdown when piped.
+	reportFormatTerminal = "terminal"
+	reportFormatMarkdown = "markdown"
+	reportFormatJSON     = "json"
+	reportFormatHTML     = "html"
+)
+
+var reportFormats = []string{reportFormatTerminal, reportFormatMarkdown, reportFormatJSON, reportFormatHTML}
+
+// reportTable is a table of a report section.
+type reportTable struct {
+	Headers []string   `json:"headers"`
+	Rows    [][]string `json:"rows"`
+}
+
+func (t *reportTable) Append(row []string) {
+	t.Rows = append(t.Rows, row)
+}
+
+// reportBlock is either a line of text or a table.
+type reportBlock struct {
+	Text  string       `json:"text,omitempty"`
+	Table *reportTable `json:"table,omitempty"`
+}
+
+// reportSection is a named section of a report, made of blocks.
+type reportSection struct {
+	id     reportSectionID
+	Name   string        `json:"name"`
+	Title  string        `json:"title"`
+	Blocks []reportBlock `json:"blocks"`
+}
+
+// text adds a line of text to s.
+func (s *reportSection) text(format string, a ...any) {
+	s.Blocks = append(s.Blocks, reportBlock{Text: fmt.Sprintf(format, a...)})
+}
+
+// table adds a table to s, whose rows are added with Append.
+func (s *reportSection) table(headers ...string) *reportTable {
+	t := &reportTable{Headers: headers}
+	s.Blocks = append(s.Blocks, reportBlock{Table: t})
+	return t
+}
+
+// report is a list of sections, built in full and filtered at rendering, so
+// the selected sections don't change what is computed.
+type report struct {
+	Sections []*reportSection `json:"sections"`
+}
+
+func newReport() *report {
+	r := &report{}
+	for id := range numReportSections {
+		r.Sections = append(r.Sections, &reportSection{id: id, Name: id.String(), Title: reportSectionTitles[id]})
+	}
+	return r
+}
+
+// section returns the section id of r, which is built by newReport with all
+// the sections in order.
+func (r *report) section(id reportSectionID) *reportSection {
+	return r.Sections[id]
+}
+
+// reportConfig selects the sections and the format of a report.
+type reportConfig struct {
+	// sections are the selected sections, all if empty.
+	sections []reportSectionID
+	format   string
+}
+
+// parseReportConfig parses the comma-separated list of sections, "all" or
+// empty for all the sections, and the format.
+func parseReportConfig(sections, format string) (reportConfig, error) {
+	c := reportConfig{format: format}
+	if !slices.Contains(reportFormats, format) {
+		return c, fmt.Errorf("unknown report format '%s', available formats are: %s", format, strings.Join(reportFormats, ", "))
+	}
+	if sections == "" || sections == "all" {
+		return c, nil
+	}
+	for _, s := range strings.Split(sections, ",") {
+		s = strings.TrimSpace(s)
+		id := slices.Index(reportSectionNames[:], s)
+		if id < 0 {
+			return c, fmt.Errorf("unknown report section '%s', available sections are: %s", s, strings.Join(reportSectionNames[:], ", "))
+		}
+		c.sections = append(c.sections, reportSectionID(id))
+	}
+	return c, nil
+}
+
+// selected returns true if the section id is selected by c.
+func (c reportConfig) selected(id reportSectionID) bool {
+	return len(c.sections) == 0 || slices.Contains(c.sections, id)
+}
+
+// render writes the sections of r selected by c and not empty into w, in the
+// format of c.
+func (r *report) render(w io.Writer, c reportConfig) error {
+	var sections []*reportSection
+	for _, s := range r.Sections {
+		if c.selected(s.id) && len(s.Blocks) > 0 {
+			sections = append(sections, s)
+		}
+	}
+	switch c.format {
+	case reportFormatJSON:
+		bz, err := json.MarshalIndent(report{Sections: sections}, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", bz)
+		return err
+	case reportFormatHTML:
+		return reportHTMLTemplate.Execute(w, sections)
+	default:
+		for _, s := range sections {
+			renderReportSection(w, s, c.format == reportFormatMarkdown)
+		}
+		return nil
+	}
+}
+
+// renderReportSection writes s as markdown into w, with the tables rendered
+// for the terminal unless markdown is true. A blank line follows the tables
+// and the last line of the section.
+func renderReportSection(w io.Writer, s *reportSection, markdown bool) {
+	fmt.Fprintf(w, "## %s\n\n", s.Title)
+	for i, b := range s.Blocks {
+		if b.Table == nil {
+			fmt.Fprintln(w, b.Text)
+			if i == len(s.Blocks)-1 {
+				fmt.Fprintln(w)
+			}
+			continue
+		}
+		t := newMarkdownTableWriter(w, b.Table.Headers...)
+		t.AppendBulk(b.Table.Rows)
+		if markdown {
+			t.renderMarkdown()
+		} else {
+			t.Render()
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+var reportHTMLTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>$ATONE distribution report</title>
+<style>
+body { font-family: sans-serif; margin: 20px; }
+table { border-collapse: collapse; margin-bottom: 20px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+{{- range .}}
+<h2 id="{{.Name}}">{{.Title}}</h2>
+{{- range .Blocks}}
+{{- if .Table}}
+<table>
+<tr>{{range .Table.Headers}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Table.Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- else}}
+<p>{{.Text}}</p>
+{{- end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// writeReport renders r into file, or into w if file is empty.
+func writeReport(ctx context.Context, w io.Writer, file string, r *report, c reportConfig) error {
+	if file == "" {
+		return r.render(w, c)
+	}
+	if err := writeOutputFile(ctx, file, func(w io.Writer) error { return r.render(w, c) }); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s file created.\n", file)
+	return nil
+}
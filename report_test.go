@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestParseReportConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	c, err := parseReportConfig("all", reportFormatTerminal)
+	assert.NoError(err)
+	for id := range numReportSections {
+		assert.True(c.selected(id), id)
+	}
+	c, err = parseReportConfig("summary, validators", reportFormatJSON)
+	assert.NoError(err)
+	assert.Equal([]reportSectionID{sectionSummary, sectionValidators}, c.sections)
+	assert.False(c.selected(sectionPerVote))
+
+	_, err = parseReportConfig("summary,votes", reportFormatTerminal)
+	assert.EqualError(err, "unknown report section 'votes', available sections are: summary, per-vote, concentration, validators, exclusions")
+	_, err = parseReportConfig("all", "pdf")
+	assert.EqualError(err, "unknown report format 'pdf', available formats are: terminal, markdown, json, html")
+}
+
+func TestAirdropsReport(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs = createAccountAddrs(3)
+		vals  = createValidatorAddrs(2)
+	)
+	accounts := []Account{
+		{
+			Address:      addrs[0].String(),
+			LiquidAmount: sdk.ZeroDec(),
+			StakedAmount: sdk.NewDec(1000 * M),
+			Vote:         govtypes.NewNonSplitVoteOption(govtypes.OptionYes),
+			Delegations:  []Delegation{{Amount: sdk.NewDec(1000 * M), ValidatorAddress: vals[0].String()}},
+		},
+		{
+			Address:      addrs[1].String(),
+			LiquidAmount: sdk.ZeroDec(),
+			StakedAmount: sdk.NewDec(500 * M),
+			Delegations: []Delegation{{
+				Amount: sdk.NewDec(500 * M), ValidatorAddress: vals[1].String(),
+				Vote: govtypes.NewNonSplitVoteOption(govtypes.OptionNo),
+			}},
+		},
+		{Address: addrs[2].String(), LiquidAmount: sdk.NewDec(5 * M), StakedAmount: sdk.ZeroDec()},
+	}
+	params := defaultDistriParams()
+	params.entityGroups = nil
+	a, err := distribution(accounts, params, "")
+	require.NoError(err)
+	infos := validatorInfos{vals[0].String(): {moniker: "val0"}}
+
+	r, err := newAirdropsReport([]airdrop{a}, infos, nil)
+	require.NoError(err)
+
+	for _, s := range r.Sections {
+		assert.NotEmpty(s.Blocks, s.Name)
+	}
+	render := func(sections, format string) string {
+		c, err := parseReportConfig(sections, format)
+		require.NoError(err)
+		var buf bytes.Buffer
+		require.NoError(r.render(&buf, c))
+		return buf.String()
+	}
+
+	t.Run("markdown", func(t *testing.T) {
+		out := render("all", reportFormatMarkdown)
+
+		for _, title := range reportSectionTitles {
+			assert.Contains(out, "## "+title+"\n")
+		}
+		assert.Contains(out, "$ATOM distribution\n|")
+		assert.Contains(out, "ATONE TOTAL SUPPLY = ")
+		assert.Contains(out, "| 1 | val0 ")
+	})
+
+	t.Run("selected sections", func(t *testing.T) {
+		out := render("validators,exclusions", reportFormatTerminal)
+
+		assert.True(strings.HasPrefix(out, "## Validators\n"), out)
+		assert.Contains(out, "## Exclusions\n")
+		assert.NotContains(out, "## Summary")
+		assert.NotContains(out, "$ATOM distribution")
+		assert.Contains(out, "Reconciliation of the accounts between the stages")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out := render("concentration", reportFormatJSON)
+
+		var decoded report
+		require.NoError(json.Unmarshal([]byte(out), &decoded))
+		require.Len(decoded.Sections, 1)
+		s := decoded.Sections[0]
+		assert.Equal(sectionConcentration.String(), s.Name)
+		assert.Equal("Share per holder size", s.Blocks[0].Text)
+		assert.Equal([]string{"HOLDING ($ATOM)", "$ATOM SHARE", "$ATONE SHARE"}, s.Blocks[1].Table.Headers)
+		assert.Len(s.Blocks[1].Table.Rows, len(holderBuckets))
+	})
+
+	t.Run("html", func(t *testing.T) {
+		r := &report{Sections: []*reportSection{{Name: sectionSummary.String(), Title: "Summary"}}}
+		r.Sections[0].text("<script>")
+		r.Sections[0].table("A").Append([]string{"1 & 2"})
+		var buf bytes.Buffer
+
+		require.NoError(r.render(&buf, reportConfig{format: reportFormatHTML}))
+
+		assert.Contains(buf.String(), `<h2 id="summary">Summary</h2>`)
+		assert.Contains(buf.String(), "<p>&lt;script&gt;</p>")
+		assert.Contains(buf.String(), "<tr><td>1 &amp; 2</td></tr>")
+	})
+}
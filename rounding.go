@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Policies applicable to the dust resulting from the rounding of the $ATONE
+// allocations to uatone.
+const (
+	// dustPolicyNone leaves the dust out of the supply.
+	dustPolicyNone = "none"
+	// dustPolicyCommunityPool adds the dust to the community pool, so the
+	// total supply matches exactly the computed distribution.
+	dustPolicyCommunityPool = "community-pool"
+)
+
+// roundingStats is the error budget of the Dec to Int rounding of the
+// allocations.
+type roundingStats struct {
+	// accounts is the number of allocations that weren't whole uatone amounts.
+	accounts int
+	// down is the total of the fractional parts lost by rounding down.
+	down sdk.Dec
+	// up is the total added by rounding up.
+	up sdk.Dec
+	// communityPool is the dust added to the community pool by the
+	// community-pool dust policy.
+	communityPool sdk.Dec
+}
+
+func newRoundingStats() roundingStats {
+	return roundingStats{down: sdk.ZeroDec(), up: sdk.ZeroDec(), communityPool: sdk.ZeroDec()}
+}
+
+func validateDustPolicy(policy string) error {
+	switch policy {
+	case "", dustPolicyNone, dustPolicyCommunityPool:
+		return nil
+	}
+	return fmt.Errorf("unknown dust policy '%s'", policy)
+}
+
+// add records the rounding of amt to rounded.
+func (r *roundingStats) add(amt sdk.Dec, rounded sdk.Int) {
+	diff := amt.Sub(rounded.ToLegacyDec())
+	switch {
+	case diff.IsPositive():
+		r.down = r.down.Add(diff)
+	case diff.IsNegative():
+		r.up = r.up.Sub(diff)
+	default:
+		return
+	}
+	r.accounts++
+}
+
+// dust returns the net amount lost by the rounding, negative if the rounding
+// up exceeds the rounding down.
+func (r roundingStats) dust() sdk.Dec {
+	return r.down.Sub(r.up)
+}
+
+func reportRounding(sec *reportSection, airdrop airdrop) {
+	r := airdrop.rounding
+	sec.text("Rounding error budget (uatone)")
+	table := sec.table("ADDRESSES", "ROUNDED DOWN", "ROUNDED UP", "DUST", "POLICY")
+	policy := "left out of the supply"
+	if airdrop.params.dustPolicy == dustPolicyCommunityPool {
+		policy = "sent to the community pool"
+	}
+	table.Append([]string{
+		fmt.Sprint(r.accounts),
+		r.down.String(),
+		r.up.String(),
+		r.dust().String(),
+		policy,
+	})
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// inputSchema is the result of the schema sniffing of an input file.
+type inputSchema struct {
+	file string
+	// detected describes the detected format, e.g. "gov v1beta1 (SDK v0.43+)".
+	detected string
+	// problem explains why the file can't be parsed, empty if it can.
+	problem string
+}
+
+// sniffInputSchemas identifies the format of each input file of datapath from
+// the keys of its first record, without decoding the whole file, so a wrong
+// export is reported before the deep parsing begins.
+func sniffInputSchemas(datapath string) []inputSchema {
+	var schemas []inputSchema
+	for _, file := range append(slices.Clone(inputFiles), "prop.json") {
+		var s inputSchema
+		keys, err := sniffKeys(joinInput(datapath, file))
+		if file == "prop.json" && errors.Is(err, fs.ErrNotExist) {
+			// Only required by the tally command
+			continue
+		}
+		if err != nil {
+			s = inputSchema{detected: "unreadable", problem: err.Error()}
+		} else {
+			s = detectSchema(file, keys)
+		}
+		s.file = file
+		schemas = append(schemas, s)
+	}
+	return schemas
+}
+
+// sniffKeys returns the keys of the first record of file: the first element
+// of a JSON array, or the first element of the accounts field for
+// auth_genesis.json, or the object itself for prop.json. nil is returned for
+// an empty array.
+func sniffKeys(file string) (_ []string, err error) {
+	f, err := openInput(context.Background(), file)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	dec := json.NewDecoder(f)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	switch tok {
+	case json.Delim('['):
+		return firstElementKeys(dec)
+	case json.Delim('{'):
+		var keys []string
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSON: %w", err)
+			}
+			if key == "accounts" && filepath.Base(file) == "auth_genesis.json" {
+				if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+					return nil, fmt.Errorf("accounts isn't a JSON array")
+				}
+				return firstElementKeys(dec)
+			}
+			keys = append(keys, key.(string))
+			// Skip the value
+			if err := dec.Decode(&json.RawMessage{}); err != nil {
+				return nil, fmt.Errorf("invalid JSON: %w", err)
+			}
+		}
+		return keys, nil
+	}
+	return nil, fmt.Errorf("expected a JSON array or object")
+}
+
+// firstElementKeys returns the keys of the next element of the array being
+// decoded by dec.
+func firstElementKeys(dec *json.Decoder) ([]string, error) {
+	if !dec.More() {
+		return nil, nil
+	}
+	var obj map[string]json.RawMessage
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// detectSchema identifies the format of file from keys, the keys of its first
+// record.
+func detectSchema(file string, keys []string) inputSchema {
+	has := func(k string) bool { return slices.Contains(keys, k) }
+	missing := func(required ...string) string {
+		var m []string
+		for _, k := range required {
+			if !has(k) {
+				m = append(m, k)
+			}
+		}
+		if len(m) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("missing field(s) %s, found %s", strings.Join(m, ", "), strings.Join(keys, ", "))
+	}
+	if keys == nil && file != "prop.json" {
+		return inputSchema{detected: "empty"}
+	}
+	switch file {
+	case "votes.json":
+		switch {
+		case has("metadata"):
+			return inputSchema{
+				detected: "gov v1 (SDK v0.46+)",
+				problem:  "gov v1 votes aren't supported, export the votes with the gov v1beta1 API",
+			}
+		case has("options"):
+			return inputSchema{detected: "gov v1beta1 (SDK v0.43+)", problem: missing("voter")}
+		case has("option"):
+			// Handled by parseVotesByAddr which converts option to options
+			return inputSchema{detected: "gov v1beta1 (SDK < v0.43, no weighted votes)", problem: missing("voter")}
+		}
+		return inputSchema{detected: "unknown", problem: missing("voter", "options")}
+	case "prop.json":
+		switch {
+		case has("messages"):
+			return inputSchema{
+				detected: "gov v1 (SDK v0.46+)",
+				problem:  "gov v1 proposals aren't supported, export the proposal with the gov v1beta1 API",
+			}
+		case has("content"):
+			return inputSchema{detected: "gov v1beta1"}
+		}
+		return inputSchema{detected: "unknown", problem: missing("content")}
+	case "delegations.json":
+		switch {
+		case has("delegation") && has("balance"):
+			return inputSchema{
+				detected: "DelegationResponse (REST/gRPC query)",
+				problem:  "expected Delegation objects, extract the delegation field of each element",
+			}
+		case has("delegatorAddress"):
+			return inputSchema{
+				detected: "staking Delegation (camelCase)",
+				problem:  "expected snake_case fields, export with the original proto field names",
+			}
+		}
+		return inputSchema{detected: "staking Delegation", problem: missing("delegator_address", "validator_address", "shares")}
+	case "active_validators.json":
+		if has("operatorAddress") {
+			return inputSchema{detected: "staking Validator (camelCase)", problem: missing("operatorAddress", "tokens", "delegatorShares")}
+		}
+		return inputSchema{detected: "staking Validator", problem: missing("operator_address", "tokens", "delegator_shares")}
+	case "balances.json":
+		return inputSchema{detected: "bank Balance", problem: missing("address", "coins")}
+	case "auth_genesis.json":
+		return inputSchema{detected: "auth GenesisState", problem: missing("@type")}
+	}
+	return inputSchema{detected: "unknown"}
+}
+
+// checkInputSchemas prints the detected format of each input file of
+// datapath, and returns an error if one of them can't be parsed.
+func checkInputSchemas(datapath string) error {
+	schemas := sniffInputSchemas(datapath)
+	table := newMarkdownTable("FILE", "DETECTED FORMAT", "PROBLEM")
+	var problems int
+	for _, s := range schemas {
+		table.Append([]string{s.file, s.detected, s.problem})
+		if s.problem != "" {
+			problems++
+		}
+	}
+	table.Render()
+	fmt.Println()
+	if problems > 0 {
+		return fmt.Errorf("%d input file(s) have an unsupported format", problems)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func writeInputFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestSniffInputSchemas(t *testing.T) {
+	valid := map[string]string{
+		"votes.json":             `[{"proposal_id":"848","voter":"cosmos1a","option":"VOTE_OPTION_UNSPECIFIED","options":[{"option":"VOTE_OPTION_YES","weight":"1"}]}]`,
+		"delegations.json":       `[{"delegator_address":"cosmos1a","validator_address":"cosmosvaloper1a","shares":"1"}]`,
+		"active_validators.json": `[{"operator_address":"cosmosvaloper1a","tokens":"1","delegator_shares":"1"}]`,
+		"balances.json":          `[{"address":"cosmos1a","coins":[]}]`,
+		"auth_genesis.json":      `{"params":{"max_memo_characters":"256"},"accounts":[{"@type":"/cosmos.auth.v1beta1.BaseAccount"}]}`,
+		"prop.json":              `{"proposal_id":"848","content":{}}`,
+	}
+	tests := []struct {
+		name             string
+		override         map[string]string
+		expectedProblems map[string]string
+	}{
+		{
+			name: "ok",
+		},
+		{
+			name: "legacy votes",
+			override: map[string]string{
+				"votes.json": `[{"proposal_id":"848","voter":"cosmos1a","option":"VOTE_OPTION_YES"}]`,
+			},
+		},
+		{
+			name: "gov v1",
+			override: map[string]string{
+				"votes.json": `[{"proposal_id":"848","voter":"cosmos1a","options":[],"metadata":""}]`,
+				"prop.json":  `{"id":"848","messages":[]}`,
+			},
+			expectedProblems: map[string]string{
+				"votes.json": "gov v1 votes aren't supported, export the votes with the gov v1beta1 API",
+				"prop.json":  "gov v1 proposals aren't supported, export the proposal with the gov v1beta1 API",
+			},
+		},
+		{
+			name: "wrong delegations and balances",
+			override: map[string]string{
+				"delegations.json": `[{"delegation":{},"balance":{}}]`,
+				"balances.json":    `[{"addr":"cosmos1a","coins":[]}]`,
+			},
+			expectedProblems: map[string]string{
+				"delegations.json": "expected Delegation objects, extract the delegation field of each element",
+				"balances.json":    "missing field(s) address, found addr, coins",
+			},
+		},
+		{
+			name: "camelCase delegations",
+			override: map[string]string{
+				"delegations.json": `[{"delegatorAddress":"cosmos1a","validatorAddress":"cosmosvaloper1a","shares":"1"}]`,
+			},
+			expectedProblems: map[string]string{
+				"delegations.json": "expected snake_case fields, export with the original proto field names",
+			},
+		},
+		{
+			name: "not json",
+			override: map[string]string{
+				"balances.json": `foo`,
+			},
+			expectedProblems: map[string]string{
+				"balances.json": "invalid JSON: invalid character 'o' in literal false (expecting 'a')",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := make(map[string]string)
+			for k, v := range valid {
+				files[k] = v
+			}
+			for k, v := range tt.override {
+				files[k] = v
+			}
+			dir := writeInputFiles(t, files)
+
+			schemas := sniffInputSchemas(dir)
+
+			require.Len(t, schemas, 6)
+			problems := make(map[string]string)
+			for _, s := range schemas {
+				assert.NotEmpty(t, s.detected)
+				if s.problem != "" {
+					problems[s.file] = s.problem
+				}
+			}
+			if tt.expectedProblems == nil {
+				tt.expectedProblems = map[string]string{}
+			}
+			assert.Equal(t, tt.expectedProblems, problems)
+		})
+	}
+}
+
+func TestParseVotesByAddrLegacy(t *testing.T) {
+	dir := writeInputFiles(t, map[string]string{
+		"votes.json": `[{"proposal_id":"848","voter":"cosmos1a","option":"VOTE_OPTION_NO"}]`,
+	})
+
+	votes, err := parseVotesByAddr(context.Background(), dir)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]govtypes.WeightedVoteOptions{
+		"cosmos1a": govtypes.NewNonSplitVoteOption(govtypes.OptionNo),
+	}, votes)
+}
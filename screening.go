@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+// addressScreener checks addresses against a compliance source (external
+// API, local list...) before the genesis generation.
+type addressScreener interface {
+	screen(ctx context.Context, addr string) (screeningResult, error)
+}
+
+type screeningResult struct {
+	Flagged bool   `json:"flagged"`
+	Reason  string `json:"reason,omitempty"`
+	// Source identifies the screener that flagged the address.
+	Source string `json:"source,omitempty"`
+}
+
+// screeningListEntry is an entry of the local list of listScreener.
+type screeningListEntry struct {
+	Address string `json:"address"`
+	Reason  string `json:"reason"`
+}
+
+// listScreener is the reference addressScreener, it flags the addresses of a
+// local list. Addresses are compared by their bytes, so the list and the
+// screened addresses can have different bech32 prefixes.
+type listScreener struct {
+	source string
+	// reasons by hex encoded address bytes
+	reasons map[string]string
+}
+
+// parseScreeningList reads a local list from a JSON file, for instance:
+//
+//	[
+//	  {"address": "cosmos1...", "reason": "sanctions list"},
+//	  {"address": "atone1...", "reason": "stolen funds"}
+//	]
+func parseScreeningList(path string) (_ *listScreener, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var entries []screeningListEntry
+	if err := json.NewDecoder(f).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("cannot json decode screening list from file %s: %w", path, err)
+	}
+	s := &listScreener{source: path, reasons: make(map[string]string, len(entries))}
+	for _, e := range entries {
+		_, bz, err := bech32.DecodeAndConvert(e.Address)
+		if err != nil {
+			return nil, fmt.Errorf("screening list %s: invalid address '%s': %w", path, e.Address, err)
+		}
+		s.reasons[hex.EncodeToString(bz)] = e.Reason
+	}
+	return s, nil
+}
+
+func (s *listScreener) screen(_ context.Context, addr string) (screeningResult, error) {
+	_, bz, err := bech32.DecodeAndConvert(addr)
+	if err != nil {
+		return screeningResult{}, fmt.Errorf("screen '%s': %w", addr, err)
+	}
+	reason, ok := s.reasons[hex.EncodeToString(bz)]
+	if !ok {
+		return screeningResult{}, nil
+	}
+	return screeningResult{Flagged: true, Reason: reason, Source: s.source}, nil
+}
+
+// cachedScreener caches the results of a screener in a JSON file, so the
+// addresses already screened by a previous run aren't screened again, which
+// matters for rate-limited or paid external APIs.
+type cachedScreener struct {
+	screener addressScreener
+	file     string
+	results  map[string]screeningResult
+	// misses is the number of addresses that weren't in the cache.
+	misses int
+}
+
+func newCachedScreener(screener addressScreener, file string) (*cachedScreener, error) {
+	c := &cachedScreener{
+		screener: screener,
+		file:     file,
+		results:  make(map[string]screeningResult),
+	}
+	bz, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bz, &c.results); err != nil {
+		return nil, fmt.Errorf("cannot json decode screening cache from file %s: %w", file, err)
+	}
+	return c, nil
+}
+
+func (c *cachedScreener) screen(ctx context.Context, addr string) (screeningResult, error) {
+	if res, ok := c.results[addr]; ok {
+		return res, nil
+	}
+	res, err := c.screener.screen(ctx, addr)
+	if err != nil {
+		return res, err
+	}
+	c.results[addr] = res
+	c.misses++
+	return res, nil
+}
+
+// save writes the cached results in the cache file.
+func (c *cachedScreener) save(ctx context.Context) error {
+	bz, err := json.MarshalIndent(c.results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(ctx, c.file, bz)
+}
+
+// flaggedAddress is an entry of the screening report.
+type flaggedAddress struct {
+	address string
+	amount  sdk.Int
+	screeningResult
+}
+
+// screenAirdrop screens the addresses of the airdrop, and returns the flagged
+// ones sorted by address.
+func screenAirdrop(ctx context.Context, screener addressScreener, airdrop airdrop) ([]flaggedAddress, error) {
+	var flagged []flaggedAddress
+	for _, addr := range slices.Sorted(maps.Keys(airdrop.addresses)) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		res, err := screener.screen(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		if res.Flagged {
+			flagged = append(flagged, flaggedAddress{
+				address:         addr,
+				amount:          airdrop.addresses[addr],
+				screeningResult: res,
+			})
+		}
+	}
+	return flagged, nil
+}
+
+// writeScreeningReport writes the flagged addresses in a CSV file, for a
+// manual review.
+func writeScreeningReport(ctx context.Context, file string, flagged []flaggedAddress) error {
+	return writeOutputFile(ctx, file, func(out io.Writer) error {
+		w := csv.NewWriter(out)
+		w.Write([]string{"address", "uatone", "reason", "source"})
+		for _, f := range flagged {
+			w.Write([]string{f.address, f.amount.String(), f.reason(), f.Source})
+		}
+		w.Flush()
+		return w.Error()
+	})
+}
+
+func (f flaggedAddress) reason() string {
+	if f.Reason == "" {
+		return "unspecified"
+	}
+	return f.Reason
+}
+
+func printScreening(flagged []flaggedAddress, screened int) {
+	total := sdk.ZeroInt()
+	for _, f := range flagged {
+		total = total.Add(f.amount)
+	}
+	fmt.Fprintf(os.Stderr, "Screening: %s flagged address(es) out of %s, holding %s $ATONE\n",
+		humanCount(len(flagged)), humanCount(screened), human(total))
+}
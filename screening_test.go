@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestScreenAirdrop(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		ctx       = context.Background()
+		dir       = t.TempDir()
+		listFile  = filepath.Join(dir, "list.json")
+		cacheFile = filepath.Join(dir, "cache.json")
+	)
+	flaggedAddr, err := convertBech32(icfWallets[0], "cosmos", "atone")
+	require.NoError(err)
+	cleanAddr, err := convertBech32(icfWallets[1], "cosmos", "atone")
+	require.NoError(err)
+	// The list uses the cosmos prefix, the airdrop the atone prefix
+	err = os.WriteFile(listFile, []byte(`[{"address":"`+icfWallets[0]+`","reason":"test"}]`), 0o600)
+	require.NoError(err)
+	list, err := parseScreeningList(listFile)
+	require.NoError(err)
+	airdrop := airdrop{addresses: map[string]sdk.Int{
+		flaggedAddr: sdk.NewInt(42),
+		cleanAddr:   sdk.NewInt(1),
+	}}
+
+	cache, err := newCachedScreener(list, cacheFile)
+	require.NoError(err)
+	flagged, err := screenAirdrop(ctx, cache, airdrop)
+	require.NoError(err)
+	require.Len(flagged, 1)
+	assert.Equal(flaggedAddr, flagged[0].address)
+	assert.Equal(sdk.NewInt(42), flagged[0].amount)
+	assert.Equal("test", flagged[0].Reason)
+	assert.Equal(listFile, flagged[0].Source)
+	assert.Equal(2, cache.misses)
+	require.NoError(cache.save(ctx))
+
+	// A second run is served by the cache
+	cache, err = newCachedScreener(list, cacheFile)
+	require.NoError(err)
+	flagged2, err := screenAirdrop(ctx, cache, airdrop)
+	require.NoError(err)
+	assert.Equal(flagged, flagged2)
+	assert.Zero(cache.misses)
+
+	reportFile := filepath.Join(dir, "report.csv")
+	require.NoError(writeScreeningReport(ctx, reportFile, flagged))
+	bz, err := os.ReadFile(reportFile)
+	require.NoError(err)
+	assert.Equal("address,uatone,reason,source\n"+flaggedAddr+",42,test,"+listFile+"\n", string(bz))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// sendTxsParams configures the transactions generated by airdropTxs.
+type sendTxsParams struct {
+	from  string
+	denom string
+	// chunk is the maximum number of outputs per MsgMultiSend.
+	chunk int
+	// gas is estimated as gasBase + gasPerOutput x number of outputs.
+	gasBase      uint64
+	gasPerOutput uint64
+	gasPrice     sdk.DecCoin
+	memo         string
+}
+
+// airdropDeltas returns the amounts to send to reach the target balances,
+// given the current ones. Addresses that already hold their target (or more)
+// are skipped, which makes the generated transactions idempotent: they can be
+// regenerated from the new current balances after a partial execution.
+func airdropDeltas(target, current map[string]sdk.Int) map[string]sdk.Int {
+	deltas := make(map[string]sdk.Int)
+	for addr, amt := range target {
+		if cur, ok := current[addr]; ok {
+			amt = amt.Sub(cur)
+		}
+		if amt.IsPositive() {
+			deltas[addr] = amt
+		}
+	}
+	return deltas
+}
+
+// airdropTxs converts deltas into unsigned transactions of one MsgMultiSend
+// each, with at most params.chunk outputs per transaction. Outputs are sorted
+// by address so the same deltas always produce the same transactions.
+func airdropTxs(deltas map[string]sdk.Int, params sendTxsParams) ([]*txtypes.Tx, error) {
+	if params.chunk <= 0 {
+		return nil, fmt.Errorf("invalid chunk size %d", params.chunk)
+	}
+	var txs []*txtypes.Tx
+	for chunk := range slices.Chunk(slices.Sorted(maps.Keys(deltas)), params.chunk) {
+		var (
+			outputs = make([]banktypes.Output, len(chunk))
+			total   = sdk.ZeroInt()
+		)
+		for i, addr := range chunk {
+			outputs[i] = banktypes.Output{
+				Address: addr,
+				Coins:   sdk.NewCoins(sdk.NewCoin(params.denom, deltas[addr])),
+			}
+			total = total.Add(deltas[addr])
+		}
+		msg := banktypes.NewMsgMultiSend(
+			[]banktypes.Input{{Address: params.from, Coins: sdk.NewCoins(sdk.NewCoin(params.denom, total))}},
+			outputs,
+		)
+		anyMsg, err := codectypes.NewAnyWithValue(msg)
+		if err != nil {
+			return nil, err
+		}
+		gas := params.gasBase + params.gasPerOutput*uint64(len(outputs))
+		fee := sdk.NewCoins(sdk.NewCoin(params.gasPrice.Denom,
+			params.gasPrice.Amount.MulInt64(int64(gas)).Ceil().TruncateInt()))
+		txs = append(txs, &txtypes.Tx{
+			Body: &txtypes.TxBody{
+				Messages: []*codectypes.Any{anyMsg},
+				Memo:     params.memo,
+			},
+			AuthInfo: &txtypes.AuthInfo{
+				Fee: &txtypes.Fee{Amount: fee, GasLimit: gas},
+			},
+		})
+	}
+	return txs, nil
+}
+
+// signTxsOffline signs txs with privKey in SIGN_MODE_DIRECT, the first tx
+// using sequence and the next ones the following sequences.
+func signTxsOffline(txs []*txtypes.Tx, privKey *secp256k1.PrivKey, chainID string, accountNumber, sequence uint64) error {
+	pubKey, err := codectypes.NewAnyWithValue(privKey.PubKey())
+	if err != nil {
+		return err
+	}
+	for i, tx := range txs {
+		tx.AuthInfo.SignerInfos = []*txtypes.SignerInfo{{
+			PublicKey: pubKey,
+			ModeInfo: &txtypes.ModeInfo{
+				Sum: &txtypes.ModeInfo_Single_{Single: &txtypes.ModeInfo_Single{Mode: signing.SignMode_SIGN_MODE_DIRECT}},
+			},
+			Sequence: sequence + uint64(i),
+		}}
+		bodyBz, err := cdc.Marshal(tx.Body)
+		if err != nil {
+			return err
+		}
+		authInfoBz, err := cdc.Marshal(tx.AuthInfo)
+		if err != nil {
+			return err
+		}
+		signDoc := txtypes.SignDoc{
+			BodyBytes:     bodyBz,
+			AuthInfoBytes: authInfoBz,
+			ChainId:       chainID,
+			AccountNumber: accountNumber,
+		}
+		signBz, err := cdc.Marshal(&signDoc)
+		if err != nil {
+			return err
+		}
+		sig, err := privKey.Sign(signBz)
+		if err != nil {
+			return err
+		}
+		tx.Signatures = [][]byte{sig}
+	}
+	return nil
+}
+
+// readPrivKey reads a hex encoded secp256k1 private key from file, as output
+// by `atomoned keys export --unarmored-hex --unsafe`.
+func readPrivKey(file string) (*secp256k1.PrivKey, error) {
+	bz, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(bz)))
+	if err != nil {
+		return nil, fmt.Errorf("decode private key: %w", err)
+	}
+	if len(key) != secp256k1.PrivKeySize {
+		return nil, fmt.Errorf("invalid private key length %d", len(key))
+	}
+	return &secp256k1.PrivKey{Key: key}, nil
+}
+
+// parseAmountsFile reads a JSON object of amounts per address, like
+// airdrop.json.
+func parseAmountsFile(file string) (map[string]sdk.Int, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var amounts map[string]sdk.Int
+	if err := json.NewDecoder(f).Decode(&amounts); err != nil {
+		return nil, fmt.Errorf("cannot json decode amounts from file %s: %w", file, err)
+	}
+	return amounts, nil
+}
+
+// writeTxs writes each tx in dir as proto JSON, in files named
+// airdrop_tx_0001.json, airdrop_tx_0002.json...
+func writeTxs(ctx context.Context, dir string, txs []*txtypes.Tx) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var files []string
+	for i, tx := range txs {
+		file := filepath.Join(dir, fmt.Sprintf("airdrop_tx_%04d.json", i+1))
+		err := writeOutputFile(ctx, file, func(w io.Writer) error {
+			return marshaler.Marshal(w, tx)
+		})
+		if err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func printTxsStats(deltas map[string]sdk.Int, txs []*txtypes.Tx, dir string) {
+	var (
+		total = sdk.ZeroInt()
+		gas   uint64
+		fees  = sdk.NewCoins()
+	)
+	for _, amt := range deltas {
+		total = total.Add(amt)
+	}
+	for _, tx := range txs {
+		gas += tx.AuthInfo.Fee.GasLimit
+		fees = fees.Add(tx.AuthInfo.Fee.Amount...)
+	}
+	fmt.Printf("%s addresses to fund with a total of %s, in %d transactions written in %s (total gas %s, total fees %s)\n",
+		humanCount(len(deltas)), human(total), len(txs), dir, humanCount(int64(gas)), fees)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+func TestAirdropDeltas(t *testing.T) {
+	target := map[string]sdk.Int{
+		"atone1a": sdk.NewInt(10),
+		"atone1b": sdk.NewInt(20),
+		"atone1c": sdk.NewInt(30),
+		"atone1d": sdk.NewInt(40),
+	}
+	current := map[string]sdk.Int{
+		"atone1a": sdk.NewInt(10), // already funded
+		"atone1b": sdk.NewInt(5),  // partially funded
+		"atone1d": sdk.NewInt(50), // over funded
+		"atone1e": sdk.NewInt(1),  // not a recipient
+	}
+
+	deltas := airdropDeltas(target, current)
+
+	assert.Equal(t, map[string]sdk.Int{
+		"atone1b": sdk.NewInt(15),
+		"atone1c": sdk.NewInt(30),
+	}, deltas)
+	// Applying the deltas leaves nothing to send
+	current["atone1b"] = current["atone1b"].Add(deltas["atone1b"])
+	current["atone1c"] = deltas["atone1c"]
+	assert.Empty(t, airdropDeltas(target, current))
+}
+
+func TestAirdropTxs(t *testing.T) {
+	deltas := map[string]sdk.Int{
+		"atone1c": sdk.NewInt(3),
+		"atone1a": sdk.NewInt(1),
+		"atone1e": sdk.NewInt(5),
+		"atone1b": sdk.NewInt(2),
+		"atone1d": sdk.NewInt(4),
+	}
+	params := sendTxsParams{
+		from:         "atone1faucet",
+		denom:        "uatone",
+		chunk:        2,
+		gasBase:      100,
+		gasPerOutput: 10,
+		gasPrice:     sdk.NewDecCoinFromDec("uatone", sdk.NewDecWithPrec(25, 3)),
+	}
+
+	txs, err := airdropTxs(deltas, params)
+
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+	var (
+		outputs []string
+		total   = sdk.ZeroInt()
+	)
+	for _, tx := range txs {
+		var msg banktypes.MsgMultiSend
+		require.NoError(t, cdc.Unmarshal(tx.Body.Messages[0].Value, &msg))
+		require.Len(t, msg.Inputs, 1)
+		assert.Equal(t, "atone1faucet", msg.Inputs[0].Address)
+		inputAmt := msg.Inputs[0].Coins.AmountOf("uatone")
+		outputsAmt := sdk.ZeroInt()
+		for _, o := range msg.Outputs {
+			outputs = append(outputs, o.Address)
+			outputsAmt = outputsAmt.Add(o.Coins.AmountOf("uatone"))
+		}
+		assert.Equal(t, inputAmt, outputsAmt)
+		total = total.Add(inputAmt)
+		gas := params.gasBase + params.gasPerOutput*uint64(len(msg.Outputs))
+		assert.Equal(t, gas, tx.AuthInfo.Fee.GasLimit)
+	}
+	assert.Equal(t, []string{"atone1a", "atone1b", "atone1c", "atone1d", "atone1e"}, outputs)
+	assert.Equal(t, sdk.NewInt(15), total)
+	// 120 gas x 0.025 = 3uatone
+	assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uatone", 3)), txs[0].AuthInfo.Fee.Amount)
+	// 110 gas x 0.025 = 2.75uatone rounded up
+	assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uatone", 3)), txs[2].AuthInfo.Fee.Amount)
+
+	_, err = airdropTxs(deltas, sendTxsParams{})
+	assert.EqualError(t, err, "invalid chunk size 0")
+}
+
+func TestSignTxsOffline(t *testing.T) {
+	privKey := secp256k1.GenPrivKey()
+	txs, err := airdropTxs(map[string]sdk.Int{
+		"atone1a": sdk.NewInt(1),
+		"atone1b": sdk.NewInt(2),
+	}, sendTxsParams{
+		from: "atone1faucet", denom: "uatone", chunk: 1,
+		gasPrice: sdk.NewDecCoin("uatone", sdk.ZeroInt()),
+	})
+	require.NoError(t, err)
+
+	err = signTxsOffline(txs, privKey, "atomone-test", 7, 3)
+
+	require.NoError(t, err)
+	for i, tx := range txs {
+		assert.EqualValues(t, 3+i, tx.AuthInfo.SignerInfos[0].Sequence)
+		bodyBz, err := cdc.Marshal(tx.Body)
+		require.NoError(t, err)
+		authInfoBz, err := cdc.Marshal(tx.AuthInfo)
+		require.NoError(t, err)
+		signBz, err := cdc.Marshal(&txtypes.SignDoc{
+			BodyBytes:     bodyBz,
+			AuthInfoBytes: authInfoBz,
+			ChainId:       "atomone-test",
+			AccountNumber: 7,
+		})
+		require.NoError(t, err)
+		assert.True(t, privKey.PubKey().VerifySignature(signBz, tx.Signatures[0]))
+	}
+}
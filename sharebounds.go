@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// shareCategories are the categories of the allocations that can be bounded:
+// the vote options, with the keys of voteOptionKeys, and the liquid amounts.
+// They're the keys of the audit log multipliers.
+var shareCategories = []string{"yes", "no", "noWithVeto", "abstain", "didNotVote", "liquid"}
+
+// shareBound constrains the share of the distributed $ATONE allocated to a
+// category, a nil Min or Max isn't enforced.
+type shareBound struct {
+	Min sdk.Dec `json:"min"`
+	Max sdk.Dec `json:"max"`
+}
+
+// shareBounds are the shareBound per category.
+type shareBounds map[string]shareBound
+
+// parseShareBounds reads the share bounds from a JSON file, for instance to
+// give at least 5% of the distribution to the Yes voters and at most 20% to
+// the NoWithVeto voters:
+//
+//	{"yes": {"min": "0.05"}, "noWithVeto": {"max": "0.2"}}
+func parseShareBounds(path string) (_ shareBounds, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var b shareBounds
+	if err := json.NewDecoder(f).Decode(&b); err != nil {
+		return nil, fmt.Errorf("cannot json decode share bounds from file %s: %w", path, err)
+	}
+	if err := b.validate(); err != nil {
+		return nil, fmt.Errorf("share bounds %s: %w", path, err)
+	}
+	return b, nil
+}
+
+func (b shareBounds) validate() error {
+	var mins, maxs = sdk.ZeroDec(), sdk.ZeroDec()
+	for _, cat := range shareCategories {
+		bound, ok := b[cat]
+		if !ok {
+			maxs = maxs.Add(sdk.OneDec())
+			continue
+		}
+		lo, hi := sdk.ZeroDec(), sdk.OneDec()
+		if !bound.Min.IsNil() {
+			lo = bound.Min
+		}
+		if !bound.Max.IsNil() {
+			hi = bound.Max
+		}
+		if lo.IsNegative() || hi.GT(sdk.OneDec()) || lo.GT(hi) {
+			return fmt.Errorf("invalid bounds [%s, %s] for '%s', must be 0 <= min <= max <= 1", lo, hi, cat)
+		}
+		mins, maxs = mins.Add(lo), maxs.Add(hi)
+	}
+	for cat := range b {
+		if !slices.Contains(shareCategories, cat) {
+			return fmt.Errorf("unknown category '%s'", cat)
+		}
+	}
+	if mins.GT(sdk.OneDec()) || maxs.LT(sdk.OneDec()) {
+		return errors.New("the bounds can't be satisfied together, the sum of the min must be <= 1 and the sum of the max >= 1")
+	}
+	return nil
+}
+
+func (d distriParams) hasShareBounds() bool {
+	return len(d.shareBounds) > 0
+}
+
+// shareFactor returns the factor applied to the allocations of the category
+// cat by the share bounds normalization, 1 if there's none.
+func (d distriParams) shareFactor(cat string) sdk.Dec {
+	if f, ok := d.shareFactors[cat]; ok {
+		return f
+	}
+	return sdk.OneDec()
+}
+
+// shareBoundsStats reports the normalization of the shares.
+type shareBoundsStats struct {
+	// before and after are the shares per category of the base distribution
+	// and of the normalized one.
+	before, after map[string]sdk.Dec
+	// binding is the bound (min or max) that fixed the share of a category.
+	binding map[string]string
+}
+
+// categoryAmounts returns the distributed $ATONE per category of a.
+func (a airdrop) categoryAmounts() map[string]sdk.Dec {
+	amounts := map[string]sdk.Dec{"liquid": a.atone.unstaked}
+	for key, opt := range voteOptionKeys {
+		amounts[key] = a.atone.votes[opt]
+	}
+	return amounts
+}
+
+// categoryShares returns the share of the distributed $ATONE per category of a.
+func (a airdrop) categoryShares() map[string]sdk.Dec {
+	shares := a.categoryAmounts()
+	for cat, amt := range shares {
+		if a.atone.supply.IsPositive() {
+			shares[cat] = amt.Quo(a.atone.supply)
+		}
+	}
+	return shares
+}
+
+// solveShareFactors returns the factor per category that moves the shares of
+// amounts within bounds, keeping the total amount unchanged. The categories
+// out of their bounds are fixed to the bound, one at a time starting with the
+// largest violation, and the other categories are scaled by the same factor
+// to absorb the difference, until no bound is violated. binding reports the
+// bound that fixed each category.
+func solveShareFactors(amounts map[string]sdk.Dec, bounds shareBounds) (factors map[string]sdk.Dec, binding map[string]string, err error) {
+	total := sdk.ZeroDec()
+	for _, cat := range shareCategories {
+		total = total.Add(amounts[cat])
+	}
+	if !total.IsPositive() {
+		return nil, nil, errors.New("share bounds: nothing distributed")
+	}
+	var (
+		// fixed holds the amounts of the categories fixed to a bound
+		fixed = make(map[string]sdk.Dec)
+		ratio sdk.Dec
+	)
+	binding = make(map[string]string)
+	for range shareCategories {
+		var (
+			fixedAmt = sdk.ZeroDec()
+			freeAmt  = sdk.ZeroDec()
+		)
+		for _, cat := range shareCategories {
+			if amt, ok := fixed[cat]; ok {
+				fixedAmt = fixedAmt.Add(amt)
+			} else {
+				freeAmt = freeAmt.Add(amounts[cat])
+			}
+		}
+		if !freeAmt.IsPositive() {
+			if !fixedAmt.Equal(total) {
+				return nil, nil, errors.New("share bounds: no unbounded allocation left to absorb the difference")
+			}
+			ratio = sdk.ZeroDec()
+			break
+		}
+		ratio = total.Sub(fixedAmt).Quo(freeAmt)
+		// the largest violation among the free categories
+		var (
+			worstCat, worstBound string
+			worstDelta           = sdk.ZeroDec()
+			worstAmt             sdk.Dec
+		)
+		for _, cat := range shareCategories {
+			b, ok := bounds[cat]
+			if _, isFixed := fixed[cat]; !ok || isFixed {
+				continue
+			}
+			share := amounts[cat].Mul(ratio).Quo(total)
+			if !b.Min.IsNil() && share.LT(b.Min) && b.Min.Sub(share).GT(worstDelta) {
+				worstCat, worstBound, worstDelta, worstAmt = cat, "min", b.Min.Sub(share), b.Min.Mul(total)
+			}
+			if !b.Max.IsNil() && share.GT(b.Max) && share.Sub(b.Max).GT(worstDelta) {
+				worstCat, worstBound, worstDelta, worstAmt = cat, "max", share.Sub(b.Max), b.Max.Mul(total)
+			}
+		}
+		if worstCat == "" {
+			break
+		}
+		if !amounts[worstCat].IsPositive() {
+			return nil, nil, fmt.Errorf("share bounds: nothing distributed to '%s' to reach its min share", worstCat)
+		}
+		fixed[worstCat] = worstAmt
+		binding[worstCat] = worstBound
+	}
+	factors = make(map[string]sdk.Dec, len(shareCategories))
+	for _, cat := range shareCategories {
+		if amt, ok := fixed[cat]; ok {
+			factors[cat] = amt.Quo(amounts[cat])
+		} else {
+			factors[cat] = ratio
+		}
+	}
+	return factors, binding, nil
+}
+
+// runShareBounds runs the distribution, then normalizes the allocations so
+// the share of each category is within params.shareBounds, by running the
+// distribution again with the factors of solveShareFactors. The address cap
+// and the voter floor apply after the normalization, so they can move the
+// final shares slightly off the bounds. The allocations are only streamed by
+// the normalized run.
+func (s *distributionStages) runShareBounds(ctx context.Context, params distriParams, prefix string, cp checkpointConfig) (airdrop, error) {
+	if cp.file != "" || cp.resume != nil {
+		return airdrop{}, errors.New("the share bounds don't support checkpoints")
+	}
+	stream := s.stream
+	s.stream = nil
+	base := params
+	base.shareBounds = nil
+	a, err := s.run(ctx, base, prefix, cp)
+	s.stream = stream
+	if err != nil {
+		return a, err
+	}
+	factors, binding, err := solveShareFactors(a.categoryAmounts(), params.shareBounds)
+	if err != nil {
+		return a, err
+	}
+	params.shareFactors = factors
+	normalized, err := s.run(ctx, params, prefix, cp)
+	if err != nil {
+		return normalized, err
+	}
+	normalized.shareBounds = shareBoundsStats{
+		before:  a.categoryShares(),
+		after:   normalized.categoryShares(),
+		binding: binding,
+	}
+	return normalized, nil
+}
+
+func reportShareBounds(sec *reportSection, airdrop airdrop) {
+	s := airdrop.shareBounds
+	table := sec.table("CATEGORY", "MIN", "MAX", "SHARE BEFORE", "SHARE AFTER", "FACTOR", "BINDING")
+	bound := func(d sdk.Dec) string {
+		if d.IsNil() {
+			return "-"
+		}
+		return humanPercent(d)
+	}
+	for _, cat := range shareCategories {
+		b := airdrop.params.shareBounds[cat]
+		binding := s.binding[cat]
+		if binding == "" {
+			binding = "-"
+		}
+		table.Append([]string{
+			cat, bound(b.Min), bound(b.Max), humanPercent(s.before[cat]), humanPercent(s.after[cat]),
+			fmt.Sprintf("x%.4f", airdrop.params.shareFactor(cat).MustFloat64()), binding,
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestSolveShareFactors(t *testing.T) {
+	dec := sdk.MustNewDecFromStr
+	amounts := map[string]sdk.Dec{
+		"yes": dec("10"), "no": dec("60"), "noWithVeto": dec("10"),
+		"abstain": dec("5"), "didNotVote": dec("5"), "liquid": dec("10"),
+	}
+	tests := []struct {
+		name            string
+		bounds          shareBounds
+		expectedFactors map[string]sdk.Dec
+		expectedBinding map[string]string
+		expectedError   string
+	}{
+		{
+			name:            "not binding",
+			bounds:          shareBounds{"yes": {Min: dec("0.05")}},
+			expectedFactors: map[string]sdk.Dec{"yes": dec("1"), "no": dec("1"), "noWithVeto": dec("1"), "abstain": dec("1"), "didNotVote": dec("1"), "liquid": dec("1")},
+			expectedBinding: map[string]string{},
+		},
+		{
+			name:   "min",
+			bounds: shareBounds{"yes": {Min: dec("0.28")}},
+			// the other 90 share the remaining 72
+			expectedFactors: map[string]sdk.Dec{"yes": dec("2.8"), "no": dec("0.8"), "noWithVeto": dec("0.8"), "abstain": dec("0.8"), "didNotVote": dec("0.8"), "liquid": dec("0.8")},
+			expectedBinding: map[string]string{"yes": "min"},
+		},
+		{
+			name: "max then min",
+			bounds: shareBounds{
+				"no":  {Max: dec("0.4")},
+				"yes": {Min: dec("0.16")},
+			},
+			// no fixed to 40, the other 40 scaled to 60 (x1.5), which brings
+			// yes to 15, below its min: yes fixed to 16, the others 30 scaled
+			// to 44
+			expectedFactors: map[string]sdk.Dec{
+				"no": dec("0.666666666666666667"), "yes": dec("1.6"),
+				"noWithVeto": dec("1.466666666666666667"), "abstain": dec("1.466666666666666667"),
+				"didNotVote": dec("1.466666666666666667"), "liquid": dec("1.466666666666666667"),
+			},
+			expectedBinding: map[string]string{"no": "max", "yes": "min"},
+		},
+		{
+			name:          "nothing to scale",
+			bounds:        shareBounds{"yes": {Min: dec("0.2")}},
+			expectedError: "nothing distributed to 'yes'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amounts := amounts
+			if tt.expectedError != "" {
+				amounts = map[string]sdk.Dec{
+					"yes": dec("0"), "no": dec("10"), "noWithVeto": dec("0"),
+					"abstain": dec("0"), "didNotVote": dec("0"), "liquid": dec("0"),
+				}
+			}
+
+			factors, binding, err := solveShareFactors(amounts, tt.bounds)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedBinding, binding)
+			for cat, f := range tt.expectedFactors {
+				assert.Equal(t, f.String(), factors[cat].String(), cat)
+			}
+		})
+	}
+}
+
+func TestShareBoundsValidate(t *testing.T) {
+	dec := sdk.MustNewDecFromStr
+	assert.NoError(t, shareBounds{"yes": {Min: dec("0.1"), Max: dec("0.5")}}.validate())
+	assert.ErrorContains(t, shareBounds{"veto": {}}.validate(), "unknown category 'veto'")
+	assert.ErrorContains(t, shareBounds{"yes": {Min: dec("0.5"), Max: dec("0.1")}}.validate(), "invalid bounds")
+	assert.ErrorContains(t, shareBounds{"yes": {Min: dec("0.6")}, "no": {Min: dec("0.6")}}.validate(), "can't be satisfied")
+}
+
+func TestRunShareBounds(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100 * M), Vote: vote(govtypes.OptionYes)},
+		{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100 * M), Vote: vote(govtypes.OptionNo)},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100 * M), StakedAmount: sdk.ZeroDec()},
+	}
+	params := defaultDistriParams()
+	params.shareBounds = shareBounds{"yes": {Min: sdk.NewDecWithPrec(2, 1)}}
+	base, err := distribution(accounts, defaultDistriParams(), "")
+	require.NoError(err)
+
+	airdrop, err := newDistributionStages(slices.Values(accounts)).run(context.Background(), params, "", checkpointConfig{})
+
+	require.NoError(err)
+	assert.True(base.categoryShares()["yes"].LT(sdk.NewDecWithPrec(2, 1)))
+	assert.Equal(sdk.NewDecWithPrec(2, 1).String(), airdrop.categoryShares()["yes"].String())
+	assert.Equal(map[string]string{"yes": "min"}, airdrop.shareBounds.binding)
+	// the distributed total is unchanged
+	assert.InDelta(base.atone.supply.MustFloat64(), airdrop.atone.supply.MustFloat64(), 1)
+	var sec reportSection
+	reportShareBounds(&sec, airdrop)
+	require.Len(sec.Blocks, 1)
+	assert.Len(sec.Blocks[0].Table.Rows, len(shareCategories))
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// The maintainer keys and the signatures of the artifacts use the minisign
+// format (https://jedisct1.github.io/minisign/), so the signatures can also be
+// verified with minisign -Vm <file> -p <name.pub>. Unlike the minisign ones,
+// the secret keys aren't encrypted, they must be kept offline.
+const (
+	// sigAlgEd is the algorithm of the keys, and of the signatures of the
+	// file content (legacy minisign signatures, only verified).
+	sigAlgEd = "Ed"
+	// sigAlgPrehashed is the algorithm of the signatures of the BLAKE2b-512
+	// hash of the file, which doesn't require to hold the file in memory.
+	sigAlgPrehashed = "ED"
+
+	signatureExt = ".minisig"
+
+	untrustedCommentPrefix = "untrusted comment: "
+	trustedCommentPrefix   = "trusted comment: "
+	// secretKeyComment and publicKeyComment start the comments of the key
+	// files, followed by the key id.
+	secretKeyComment = "govbox secret key "
+	publicKeyComment = "minisign public key "
+)
+
+// signingKeyID identifies the key pair of a maintainer.
+type signingKeyID [8]byte
+
+// String returns the id as displayed by minisign.
+func (id signingKeyID) String() string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id[:]))
+}
+
+type signingKey struct {
+	id   signingKeyID
+	priv ed25519.PrivateKey
+}
+
+type verifyingKey struct {
+	id  signingKeyID
+	pub ed25519.PublicKey
+	// file is the public key file, to report the signer.
+	file string
+}
+
+// generateSigningKeys writes a new key pair into name.key, which mustn't
+// exist, and name.pub.
+func generateSigningKeys(ctx context.Context, name string) (secretFile, publicFile string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	var id signingKeyID
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", "", err
+	}
+	secretFile, publicFile = name+".key", name+".pub"
+	f, err := os.OpenFile(secretFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", "", err
+	}
+	_, err = f.Write(encodeKeyFile(secretKeyComment+id.String(), id, priv.Seed()))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", "", err
+	}
+	err = writeFile(ctx, publicFile, encodeKeyFile(publicKeyComment+id.String(), id, pub))
+	return secretFile, publicFile, err
+}
+
+// encodeKeyFile returns the 2 lines of a key file: the comment, and the key
+// prefixed by its algorithm and id in base64.
+func encodeKeyFile(comment string, id signingKeyID, key []byte) []byte {
+	bz := append(append([]byte(sigAlgEd), id[:]...), key...)
+	return fmt.Appendf(nil, "%s%s\n%s\n", untrustedCommentPrefix, comment, base64.StdEncoding.EncodeToString(bz))
+}
+
+// decodeKeyFile returns the id and the key of a key file, whose comment must
+// start with comment and key be size bytes long.
+func decodeKeyFile(file, comment string, size int) (signingKeyID, []byte, error) {
+	var id signingKeyID
+	bz, err := os.ReadFile(file)
+	if err != nil {
+		return id, nil, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(bz)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], untrustedCommentPrefix) {
+		return id, nil, fmt.Errorf("key file %s: expected an untrusted comment line and a key line", file)
+	}
+	if !strings.HasPrefix(lines[0], untrustedCommentPrefix+comment) {
+		return id, nil, fmt.Errorf("key file %s isn't a %s", file, strings.TrimSpace(comment))
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return id, nil, fmt.Errorf("key file %s: %w", file, err)
+	}
+	if len(key) != 2+len(id)+size || string(key[:2]) != sigAlgEd {
+		return id, nil, fmt.Errorf("key file %s: not an %s key of %d bytes", file, sigAlgEd, size)
+	}
+	copy(id[:], key[2:])
+	return id, key[2+len(id):], nil
+}
+
+func parseSigningKey(file string) (signingKey, error) {
+	id, seed, err := decodeKeyFile(file, secretKeyComment, ed25519.SeedSize)
+	if err != nil {
+		return signingKey{}, err
+	}
+	return signingKey{id: id, priv: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// parseVerifyingKeys reads the public key files of the maintainers.
+func parseVerifyingKeys(files []string) ([]verifyingKey, error) {
+	var keys []verifyingKey
+	for _, file := range files {
+		id, pub, err := decodeKeyFile(file, publicKeyComment, ed25519.PublicKeySize)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, verifyingKey{id: id, pub: pub, file: file})
+	}
+	return keys, nil
+}
+
+// fileSignature is the content of a signature file.
+type fileSignature struct {
+	alg string
+	id  signingKeyID
+	sig []byte
+	// trustedComment is signed with sig by the global signature.
+	trustedComment  string
+	globalSignature []byte
+}
+
+func (s fileSignature) encode() []byte {
+	bz := append(append([]byte(s.alg), s.id[:]...), s.sig...)
+	return fmt.Appendf(nil, "%ssignature from %s%s\n%s\n%s%s\n%s\n",
+		untrustedCommentPrefix, secretKeyComment, s.id, base64.StdEncoding.EncodeToString(bz),
+		trustedCommentPrefix, s.trustedComment, base64.StdEncoding.EncodeToString(s.globalSignature))
+}
+
+func decodeFileSignature(bz []byte) (fileSignature, error) {
+	var s fileSignature
+	lines := strings.Split(strings.TrimRight(string(bz), "\r\n"), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], untrustedCommentPrefix) || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return s, errors.New("expected 4 lines: untrusted comment, signature, trusted comment and global signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return s, err
+	}
+	if len(sig) != 2+len(s.id)+ed25519.SignatureSize {
+		return s, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	s.alg = string(sig[:2])
+	copy(s.id[:], sig[2:])
+	s.sig = sig[2+len(s.id):]
+	s.trustedComment = strings.TrimSuffix(strings.TrimPrefix(lines[2], trustedCommentPrefix), "\r")
+	s.globalSignature, err = base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// hashFile returns the BLAKE2b-512 hash of r.
+func hashFile(r io.Reader) ([]byte, error) {
+	h, err := blake2b.New512(nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(h, bufio.NewReader(r)); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// signFile writes the signature of file by key into file.minisig. An empty
+// trustedComment defaults to the timestamp and the name of the file.
+func signFile(ctx context.Context, key signingKey, file, trustedComment string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash, err := hashFile(f)
+	if err != nil {
+		return "", fmt.Errorf("sign %s: %w", file, err)
+	}
+	if trustedComment == "" {
+		trustedComment = fmt.Sprintf("timestamp:%d\tfile:%s\thashed", time.Now().Unix(), filepath.Base(file))
+	}
+	s := fileSignature{
+		alg:            sigAlgPrehashed,
+		id:             key.id,
+		sig:            ed25519.Sign(key.priv, hash),
+		trustedComment: trustedComment,
+	}
+	s.globalSignature = ed25519.Sign(key.priv, append(bytes.Clone(s.sig), trustedComment...))
+	sigFile := file + signatureExt
+	return sigFile, writeFile(ctx, sigFile, s.encode())
+}
+
+// readSignatureFile reads the signature file sigFile, which is an input so it
+// can be next to a remote file.
+func readSignatureFile(ctx context.Context, sigFile string) (_ []byte, err error) {
+	f, err := openInput(ctx, sigFile)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	return io.ReadAll(f)
+}
+
+// verifyFileSignature verifies the signature sigFile of file by one of keys,
+// and returns the signer key and the trusted comment.
+func verifyFileSignature(ctx context.Context, file, sigFile string, keys []verifyingKey) (_ verifyingKey, _ string, err error) {
+	bz, err := readSignatureFile(ctx, sigFile)
+	if err != nil {
+		return verifyingKey{}, "", err
+	}
+	s, err := decodeFileSignature(bz)
+	if err != nil {
+		return verifyingKey{}, "", fmt.Errorf("signature %s: %w", sigFile, err)
+	}
+	var key *verifyingKey
+	for i := range keys {
+		if keys[i].id == s.id {
+			key = &keys[i]
+		}
+	}
+	if key == nil {
+		return verifyingKey{}, "", fmt.Errorf("%s is signed by key %s, which isn't one of the maintainer keys", file, s.id)
+	}
+	f, err := openInput(ctx, file)
+	if err != nil {
+		return verifyingKey{}, "", err
+	}
+	defer closeInput(f, &err)
+	var msg []byte
+	switch s.alg {
+	case sigAlgPrehashed:
+		msg, err = hashFile(f)
+	case sigAlgEd:
+		msg, err = io.ReadAll(f)
+	default:
+		return verifyingKey{}, "", fmt.Errorf("signature %s: unknown algorithm '%s'", sigFile, s.alg)
+	}
+	if err != nil {
+		return verifyingKey{}, "", err
+	}
+	if !ed25519.Verify(key.pub, msg, s.sig) {
+		return verifyingKey{}, "", fmt.Errorf("invalid signature of %s by key %s", file, s.id)
+	}
+	if !ed25519.Verify(key.pub, append(bytes.Clone(s.sig), s.trustedComment...), s.globalSignature) {
+		return verifyingKey{}, "", fmt.Errorf("invalid trusted comment signature of %s by key %s", file, s.id)
+	}
+	return *key, s.trustedComment, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignature(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		ctx  = context.Background()
+		dir  = t.TempDir()
+		file = filepath.Join(dir, "airdrop.json")
+	)
+	require.NoError(os.WriteFile(file, []byte(`{"cosmos1...": "1000"}`), 0o644))
+	secretFile, publicFile, err := generateSigningKeys(ctx, filepath.Join(dir, "alice"))
+	require.NoError(err)
+	fi, err := os.Stat(secretFile)
+	require.NoError(err)
+	assert.Equal(os.FileMode(0o600), fi.Mode().Perm())
+	_, _, err = generateSigningKeys(ctx, filepath.Join(dir, "alice"))
+	assert.ErrorIs(err, os.ErrExist)
+	_, otherPublic, err := generateSigningKeys(ctx, filepath.Join(dir, "bob"))
+	require.NoError(err)
+	key, err := parseSigningKey(secretFile)
+	require.NoError(err)
+	keys, err := parseVerifyingKeys([]string{otherPublic, publicFile})
+	require.NoError(err)
+
+	sigFile, err := signFile(ctx, key, file, "release v1")
+
+	require.NoError(err)
+	assert.Equal(file+".minisig", sigFile)
+	signer, comment, err := verifyFileSignature(ctx, file, sigFile, keys)
+	require.NoError(err)
+	assert.Equal(publicFile, signer.file)
+	assert.Equal("release v1", comment)
+
+	t.Run("default trusted comment", func(t *testing.T) {
+		_, err := signFile(ctx, key, file, "")
+		require.NoError(err)
+		_, comment, err := verifyFileSignature(ctx, file, sigFile, keys)
+		require.NoError(err)
+		assert.Regexp("^timestamp:[0-9]+\tfile:airdrop.json\thashed$", comment)
+	})
+
+	t.Run("legacy signature of the content", func(t *testing.T) {
+		content, err := os.ReadFile(file)
+		require.NoError(err)
+		s := fileSignature{alg: sigAlgEd, id: key.id, sig: ed25519.Sign(key.priv, content), trustedComment: "legacy"}
+		s.globalSignature = ed25519.Sign(key.priv, append(s.sig, s.trustedComment...))
+		legacy := filepath.Join(dir, "legacy.minisig")
+		require.NoError(os.WriteFile(legacy, s.encode(), 0o644))
+		_, comment, err := verifyFileSignature(ctx, file, legacy, keys)
+		require.NoError(err)
+		assert.Equal("legacy", comment)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, _, err := verifyFileSignature(ctx, file, sigFile, keys[:1])
+		assert.ErrorContains(err, "which isn't one of the maintainer keys")
+	})
+
+	t.Run("tampered trusted comment", func(t *testing.T) {
+		_, err := signFile(ctx, key, file, "release v1")
+		require.NoError(err)
+		bz, err := os.ReadFile(sigFile)
+		require.NoError(err)
+		tampered := filepath.Join(dir, "tampered.minisig")
+		require.NoError(os.WriteFile(tampered, []byte(strings.Replace(string(bz), "release v1", "release v2", 1)), 0o644))
+		_, _, err = verifyFileSignature(ctx, file, tampered, keys)
+		assert.ErrorContains(err, "invalid trusted comment signature")
+	})
+
+	t.Run("tampered file", func(t *testing.T) {
+		require.NoError(os.WriteFile(file, []byte(`{"cosmos1...": "9000"}`), 0o644))
+		_, _, err := verifyFileSignature(ctx, file, sigFile, keys)
+		assert.ErrorContains(err, "invalid signature of "+file)
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		_, err := parseSigningKey(publicFile)
+		assert.ErrorContains(err, "isn't a govbox secret key")
+		_, err = decodeFileSignature([]byte("untrusted comment: x\n"))
+		assert.ErrorContains(err, "expected 4 lines")
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// Accountings of the $ATONE share removed by the slash and partial-slash
+// entity group policies.
+const (
+	// slashAccountingBurn removes the share from the supply, this is what the
+	// distribution does.
+	slashAccountingBurn = "burn"
+	// slashAccountingVoters redistributes the share to the voters (Yes, No,
+	// NoWithVeto and Abstain allocations), pro-rata of their allocation.
+	slashAccountingVoters = "voters"
+	// slashAccountingCommunityPool sends the share to the community pool.
+	slashAccountingCommunityPool = "community-pool"
+)
+
+var slashAccountings = []string{slashAccountingBurn, slashAccountingVoters, slashAccountingCommunityPool}
+
+// slashAccountingResult is the outcome of the distribution under a slash
+// accounting.
+type slashAccountingResult struct {
+	accounting    string
+	distributed   sdk.Dec
+	communityPool sdk.Dec
+	reservedAddr  sdk.Dec
+	totalSupply   sdk.Dec
+	// ratio is the distributed $ATONE per $ATOM.
+	ratio sdk.Dec
+	// nonVotersPerc is the share of the distributed $ATONE held by the
+	// non-voters (did not vote and unstaked amounts).
+	nonVotersPerc sdk.Dec
+}
+
+// hasEntitySlashes returns true if some $ATONE has been removed by the slash
+// and partial-slash entity group policies.
+func (a airdrop) hasEntitySlashes() bool {
+	return a.slashedAtone().IsPositive()
+}
+
+// slashedAtone returns the total $ATONE the slashed $ATOM would have
+// received, regardless of the type multipliers, the hook and the address cap.
+func (a airdrop) slashedAtone() sdk.Dec {
+	total := sdk.ZeroDec()
+	for _, amt := range a.entitySlashedAtone {
+		total = total.Add(amt)
+	}
+	return total
+}
+
+// simulateSlashAccounting returns the outcome of the airdrop if the slashed
+// share was accounted according to accounting. The minted part follows the
+// distributed supply, so redistributing the share to the voters also
+// increases the community pool and the reserved address.
+func simulateSlashAccounting(a airdrop, accounting string) (slashAccountingResult, error) {
+	var (
+		slashed     = a.slashedAtone()
+		distributed = a.atone.supply
+		nonVoters   = a.atone.votes[govtypes.OptionEmpty].Add(a.atone.unstaked)
+		cp          = a.communityPool
+		reserved    = a.reservedAddr
+		incentives  = sdk.ZeroDec()
+	)
+	if !a.stakingIncentives.IsNil() {
+		incentives = a.stakingIncentives
+	}
+	switch accounting {
+	case slashAccountingBurn:
+	case slashAccountingVoters:
+		distributed = distributed.Add(slashed)
+		mintedCP, mintedReserved, mintedIncentives := a.params.mintedShares(slashed.Mul(a.params.supplyMintFactor))
+		cp = cp.Add(mintedCP)
+		reserved = reserved.Add(mintedReserved)
+		incentives = incentives.Add(mintedIncentives)
+	case slashAccountingCommunityPool:
+		cp = cp.Add(slashed)
+	default:
+		return slashAccountingResult{}, fmt.Errorf("unknown slash accounting '%s'", accounting)
+	}
+	r := slashAccountingResult{
+		accounting:    accounting,
+		distributed:   distributed,
+		communityPool: cp,
+		reservedAddr:  reserved,
+		totalSupply: distributed.Sub(a.rounding.communityPool).Add(a.voterFloor.topUp.ToLegacyDec()).
+			Add(cp).Add(reserved).Add(incentives),
+		ratio:         sdk.ZeroDec(),
+		nonVotersPerc: sdk.ZeroDec(),
+	}
+	if a.atom.supply.IsPositive() {
+		r.ratio = distributed.Quo(a.atom.supply)
+	}
+	if distributed.IsPositive() {
+		r.nonVotersPerc = nonVoters.Quo(distributed)
+	}
+	return r, nil
+}
+
+func reportSlashAccountings(sec *reportSection, airdrop airdrop) error {
+	sec.text("Slash accountings of the %s $ATONE removed by the entity groups policies",
+		humand(airdrop.slashedAtone()))
+	table := sec.table("ACCOUNTING", "DISTRIBUTED", "COMMUNITY POOL", "RESERVED ADDRESS", "TOTAL SUPPLY", "RATIO", "NON-VOTERS")
+	for _, accounting := range slashAccountings {
+		r, err := simulateSlashAccounting(airdrop, accounting)
+		if err != nil {
+			return err
+		}
+		table.Append([]string{
+			r.accounting,
+			humand(r.distributed),
+			humand(r.communityPool),
+			humand(r.reservedAddr),
+			humand(r.totalSupply),
+			fmt.Sprintf("x%.3f", r.ratio.MustFloat64()),
+			humanPercent(r.nonVotersPerc),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	dbm "github.com/cometbft/cometbft-db"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/snapshots"
+	snapshottypes "github.com/cosmos/cosmos-sdk/snapshots/types"
+	"github.com/cosmos/cosmos-sdk/store"
+	storetypes "github.com/cosmos/cosmos-sdk/store/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	govkeys "github.com/cosmos/cosmos-sdk/x/gov/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// snapshotStoreKeys are the store keys read from an application database.
+var snapshotStoreKeys = sdk.NewKVStoreKeys(
+	authtypes.StoreKey,
+	banktypes.StoreKey,
+	stakingtypes.StoreKey,
+	govkeys.StoreKey,
+)
+
+// openSnapshotStore opens the multistore of the application db at height,
+// with only the stores required to build the accounts mounted. A zero height
+// loads the latest version.
+func openSnapshotStore(db dbm.DB, height int64) (storetypes.CommitMultiStore, error) {
+	cms := store.NewCommitMultiStore(db)
+	for _, key := range snapshotStoreKeys {
+		cms.MountStoreWithDB(key, storetypes.StoreTypeIAVL, nil)
+	}
+	var err error
+	if height == 0 {
+		err = cms.LoadLatestVersion()
+	} else {
+		err = cms.LoadVersion(height)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load application db version %d: %w", height, err)
+	}
+	return cms, nil
+}
+
+// restoreSnapshotChunks restores the stores of snapshotStoreKeys from the
+// chunks of a state-sync snapshot into a memory multistore. dir is the
+// <height>/<format> directory of the snapshot in the snapshots directory of a
+// node, holding the chunk files 0, 1, ... Only the leaves of the IAVL trees
+// are kept, the other stores and the extension payloads are skipped.
+func restoreSnapshotChunks(ctx context.Context, dir string) (storetypes.CommitMultiStore, error) {
+	if format := filepath.Base(dir); format != strconv.FormatUint(uint64(snapshottypes.CurrentFormat), 10) {
+		return nil, fmt.Errorf("unsupported snapshot format '%s' of %s, expected %d", format, dir, snapshottypes.CurrentFormat)
+	}
+	chunks, err := snapshotChunkFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	cms := store.NewCommitMultiStore(dbm.NewMemDB())
+	for _, key := range snapshotStoreKeys {
+		cms.MountStoreWithDB(key, storetypes.StoreTypeDB, nil)
+	}
+	if err := cms.LoadLatestVersion(); err != nil {
+		return nil, err
+	}
+	// the chunks are a single stream, read as one chunk by the stream reader
+	ch := make(chan io.ReadCloser, 1)
+	ch <- &chunksReader{files: chunks}
+	close(ch)
+	sr, err := snapshots.NewStreamReader(ch)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot %s: %w", dir, err)
+	}
+	defer sr.Close()
+	var kvStore storetypes.KVStore
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var item snapshottypes.SnapshotItem
+		err := sr.ReadMsg(&item)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read snapshot %s: %w", dir, err)
+		}
+		switch x := item.Item.(type) {
+		case *snapshottypes.SnapshotItem_Store:
+			kvStore = nil
+			if key, ok := snapshotStoreKeys[x.Store.Name]; ok {
+				kvStore = cms.GetKVStore(key)
+			}
+		case *snapshottypes.SnapshotItem_IAVL:
+			// only the leaves (height 0) hold the key/values of the store
+			if kvStore == nil || x.IAVL.Height != 0 {
+				continue
+			}
+			value := x.IAVL.Value
+			if value == nil {
+				// empty values are decoded as nil, which the stores refuse
+				value = []byte{}
+			}
+			kvStore.Set(x.IAVL.Key, value)
+		}
+	}
+	return cms, nil
+}
+
+// snapshotChunkFiles returns the chunk files of the snapshot directory dir,
+// in order.
+func snapshotChunkFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var indexes []uint64
+	for _, e := range entries {
+		if i, err := strconv.ParseUint(e.Name(), 10, 32); err == nil && !e.IsDir() {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("no snapshot chunk in %s", dir)
+	}
+	slices.Sort(indexes)
+	files := make([]string, len(indexes))
+	for i, index := range indexes {
+		if index != uint64(i) {
+			return nil, fmt.Errorf("missing snapshot chunk %d in %s", i, dir)
+		}
+		files[i] = filepath.Join(dir, strconv.FormatUint(index, 10))
+	}
+	return files, nil
+}
+
+// chunksReader reads the concatenation of the chunk files, opened one at a
+// time.
+type chunksReader struct {
+	files []string
+	f     *os.File
+}
+
+func (r *chunksReader) Read(p []byte) (int, error) {
+	for {
+		if r.f == nil {
+			if len(r.files) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(r.files[0])
+			if err != nil {
+				return 0, err
+			}
+			r.f, r.files = f, r.files[1:]
+		}
+		n, err := r.f.Read(p)
+		if err == io.EOF {
+			r.f.Close()
+			r.f = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *chunksReader) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Close()
+}
+
+// extractSnapshot reads the staking, gov, bank and auth states of cms and
+// writes them in datapath, in the same files and formats than the JSON
+// module exports, so the accounts command can run without the export step.
+func extractSnapshot(ctx context.Context, cms storetypes.MultiStore, proposalID uint64, denom, datapath string) error {
+	var (
+		authStore    = cms.GetKVStore(snapshotStoreKeys[authtypes.StoreKey])
+		bankStore    = cms.GetKVStore(snapshotStoreKeys[banktypes.StoreKey])
+		stakingStore = cms.GetKVStore(snapshotStoreKeys[stakingtypes.StoreKey])
+		govStore     = cms.GetKVStore(snapshotStoreKeys[govkeys.StoreKey])
+	)
+	// Votes
+	var numVotes int
+	err := writeOutputFile(ctx, filepath.Join(datapath, "votes.json"), func(w io.Writer) error {
+		return writeProtoArray(ctx, w, govStore, govkeys.VotesKey(proposalID), func(bz []byte) (string, error) {
+			var vote govtypes.Vote
+			if err := cdc.Unmarshal(bz, &vote); err != nil {
+				return "", fmt.Errorf("unmarshal vote: %w", err)
+			}
+			numVotes++
+			return marshaler.MarshalToString(&vote)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s votes extracted\n", humanCount(numVotes))
+
+	// Active validators
+	var numVals int
+	err = writeOutputFile(ctx, filepath.Join(datapath, "active_validators.json"), func(w io.Writer) error {
+		return writeProtoArray(ctx, w, stakingStore, stakingtypes.ValidatorsKey, func(bz []byte) (string, error) {
+			var val stakingtypes.Validator
+			if err := cdc.Unmarshal(bz, &val); err != nil {
+				return "", fmt.Errorf("unmarshal validator: %w", err)
+			}
+			if !val.IsBonded() {
+				return "", nil
+			}
+			numVals++
+			return marshaler.MarshalToString(&val)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d active validators extracted\n", numVals)
+
+	// Delegations
+	var delegs []stakingtypes.Delegation
+	err = iteratePrefix(ctx, stakingStore, stakingtypes.DelegationKey, func(_, bz []byte) error {
+		var deleg stakingtypes.Delegation
+		if err := cdc.Unmarshal(bz, &deleg); err != nil {
+			return fmt.Errorf("unmarshal delegation: %w", err)
+		}
+		delegs = append(delegs, deleg)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(ctx, filepath.Join(datapath, "delegations.json"), delegs); err != nil {
+		return err
+	}
+	fmt.Printf("%s delegations extracted\n", humanCount(len(delegs)))
+
+	// Balances
+	var balances []banktypes.Balance
+	err = iteratePrefix(ctx, bankStore, banktypes.BalancesPrefix, func(key, bz []byte) error {
+		addr, keyDenom, err := banktypes.AddressAndDenomFromBalancesStore(key[len(banktypes.BalancesPrefix):])
+		if err != nil {
+			return err
+		}
+		if keyDenom != denom {
+			return nil
+		}
+		amt, err := unmarshalBalance(bz)
+		if err != nil {
+			return fmt.Errorf("unmarshal balance of %s: %w", addr, err)
+		}
+		balances = append(balances, banktypes.Balance{
+			Address: addr.String(),
+			Coins:   sdk.NewCoins(sdk.NewCoin(denom, amt)),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(ctx, filepath.Join(datapath, "balances.json"), balances); err != nil {
+		return err
+	}
+	fmt.Printf("%s balances extracted\n", humanCount(len(balances)))
+
+	// Supply, used to reconcile the balances
+	if bz := bankStore.Get(append(banktypes.SupplyKey, []byte(denom)...)); bz != nil {
+		var supply sdk.Int
+		if err := supply.Unmarshal(bz); err != nil {
+			return fmt.Errorf("unmarshal supply: %w", err)
+		}
+		if err := writeJSONFile(ctx, filepath.Join(datapath, "supply.json"), sdk.NewCoins(sdk.NewCoin(denom, supply))); err != nil {
+			return err
+		}
+	}
+
+	// Accounts
+	var authGen authtypes.GenesisState
+	err = iteratePrefix(ctx, authStore, authtypes.AddressStoreKeyPrefix, func(_, bz []byte) error {
+		var any codectypes.Any
+		if err := cdc.Unmarshal(bz, &any); err != nil {
+			return fmt.Errorf("unmarshal account: %w", err)
+		}
+		authGen.Accounts = append(authGen.Accounts, &any)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = writeOutputFile(ctx, filepath.Join(datapath, "auth_genesis.json"), func(w io.Writer) error {
+		return marshaler.Marshal(w, &authGen)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s accounts extracted\n", humanCount(len(authGen.Accounts)))
+
+	// Proposal
+	bz := govStore.Get(govkeys.ProposalKey(proposalID))
+	if bz == nil {
+		return fmt.Errorf("proposal %d not found", proposalID)
+	}
+	var prop govtypes.Proposal
+	if err := cdc.Unmarshal(bz, &prop); err != nil {
+		return fmt.Errorf("unmarshal proposal: %w", err)
+	}
+	return writeOutputFile(ctx, filepath.Join(datapath, "prop.json"), func(w io.Writer) error {
+		return marshaler.Marshal(w, &prop)
+	})
+}
+
+// unmarshalBalance decodes a balance store value, which is an sdk.Coin up to
+// SDK v0.45 and an sdk.Int since v0.46.
+func unmarshalBalance(bz []byte) (sdk.Int, error) {
+	if len(bz) > 0 && bz[0] == 0x0a { // tag of the sdk.Coin denom field
+		var coin sdk.Coin
+		if err := cdc.Unmarshal(bz, &coin); err != nil {
+			return sdk.Int{}, err
+		}
+		return coin.Amount, nil
+	}
+	var amt sdk.Int
+	if err := amt.Unmarshal(bz); err != nil {
+		return sdk.Int{}, err
+	}
+	return amt, nil
+}
+
+// iteratePrefix calls fn for each key/value of kvStore under prefix.
+func iteratePrefix(ctx context.Context, kvStore storetypes.KVStore, prefix []byte, fn func(key, value []byte) error) error {
+	it := storetypes.KVStorePrefixIterator(kvStore, prefix)
+	defer it.Close()
+	for ; it.Valid(); it.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(it.Key(), it.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeProtoArray writes in w a JSON array of the values of kvStore under
+// prefix, each value being converted to proto JSON by fn. Values for which fn
+// returns an empty string are skipped.
+func writeProtoArray(ctx context.Context, w io.Writer, kvStore storetypes.KVStore, prefix []byte, fn func([]byte) (string, error)) error {
+	sep := "["
+	err := iteratePrefix(ctx, kvStore, prefix, func(_, bz []byte) error {
+		s, err := fn(bz)
+		if err != nil || s == "" {
+			return err
+		}
+		_, err = io.WriteString(w, sep+s)
+		sep = ",\n"
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if sep == "[" {
+		// empty array
+		_, err = io.WriteString(w, "[")
+		if err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, "]")
+	return err
+}
+
+func writeJSONFile(ctx context.Context, file string, v any) error {
+	return writeOutputFile(ctx, file, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(v)
+	})
+}
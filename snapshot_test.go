@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dbm "github.com/cometbft/cometbft-db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/cosmos/cosmos-sdk/snapshots"
+	"github.com/cosmos/cosmos-sdk/store/rootmulti"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	govkeys "github.com/cosmos/cosmos-sdk/x/gov/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+func TestExtractSnapshot(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		db      = dbm.NewMemDB()
+		delAddr = sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address())
+		valAddr = sdk.ValAddress(ed25519.GenPrivKey().PubKey().Address())
+		ctx     = context.Background()
+	)
+	// Fill the stores
+	cms, err := openSnapshotStore(db, 0)
+	require.NoError(err)
+	var (
+		authStore    = cms.GetKVStore(snapshotStoreKeys[authtypes.StoreKey])
+		bankStore    = cms.GetKVStore(snapshotStoreKeys[banktypes.StoreKey])
+		stakingStore = cms.GetKVStore(snapshotStoreKeys[stakingtypes.StoreKey])
+		govStore     = cms.GetKVStore(snapshotStoreKeys[govkeys.StoreKey])
+	)
+	acc, err := cdc.MarshalInterface(authtypes.NewBaseAccountWithAddress(delAddr))
+	require.NoError(err)
+	authStore.Set(authtypes.AddressStoreKey(delAddr), acc)
+	amt, err := sdk.NewInt(42).Marshal()
+	require.NoError(err)
+	bankStore.Set(banktypes.CreatePrefixedAccountStoreKey(delAddr, []byte("uatom")), amt)
+	bankStore.Set(banktypes.CreatePrefixedAccountStoreKey(delAddr, []byte("uother")), amt)
+	bankStore.Set(append(banktypes.SupplyKey, []byte("uatom")...), amt)
+	val := stakingtypes.Validator{
+		OperatorAddress: valAddr.String(),
+		Status:          stakingtypes.Bonded,
+		Tokens:          sdk.NewInt(100),
+		DelegatorShares: sdk.NewDec(100),
+	}
+	stakingStore.Set(stakingtypes.GetValidatorKey(valAddr), cdc.MustMarshal(&val))
+	deleg := stakingtypes.NewDelegation(delAddr, valAddr, sdk.NewDec(100))
+	stakingStore.Set(stakingtypes.GetDelegationKey(delAddr, valAddr), cdc.MustMarshal(&deleg))
+	vote := govtypes.NewVote(848, delAddr, govtypes.NewNonSplitVoteOption(govtypes.OptionNo))
+	govStore.Set(govkeys.VoteKey(848, delAddr), cdc.MustMarshal(&vote))
+	prop, err := govtypes.NewProposal(govtypes.NewTextProposal("title", "desc"), 848, sdk.Context{}.BlockTime(), sdk.Context{}.BlockTime())
+	require.NoError(err)
+	govStore.Set(govkeys.ProposalKey(848), cdc.MustMarshal(&prop))
+	cms.Commit()
+
+	cms, err = openSnapshotStore(db, 1)
+	require.NoError(err)
+	datapath := t.TempDir()
+
+	err = extractSnapshot(ctx, cms, 848, "uatom", datapath)
+
+	require.NoError(err)
+	votesByAddr, err := parseVotesByAddr(ctx, datapath)
+	require.NoError(err)
+	assert.Equal(map[string]govtypes.WeightedVoteOptions{delAddr.String(): vote.Options}, votesByAddr)
+	valsByAddr, err := parseValidatorsByAddr(ctx, datapath, votesByAddr)
+	require.NoError(err)
+	assert.Contains(valsByAddr, valAddr.String())
+	delegsByAddr, err := parseDelegationsByAddr(context.Background(), datapath)
+	require.NoError(err)
+	assert.Equal(map[string][]stakingtypes.Delegation{delAddr.String(): {deleg}}, delegsByAddr)
+	balancesByAddr, err := parseBalancesByAddr(context.Background(), datapath, "uatom")
+	require.NoError(err)
+	assert.Equal(map[string]sdk.Coin{delAddr.String(): sdk.NewInt64Coin("uatom", 42)}, balancesByAddr)
+	supply, ok, err := parseSupply(datapath, "uatom")
+	require.NoError(err)
+	require.True(ok)
+	typesByAddr, err := parseAccountTypesPerAddr(datapath)
+	require.NoError(err)
+	assert.Equal(map[string]string{delAddr.String(): "/cosmos.auth.v1beta1.BaseAccount"}, typesByAddr)
+	assert.NoError(reconcileSupply(balancesByAddr, typesByAddr, supply))
+	resProp, err := parseProp(datapath)
+	require.NoError(err)
+	assert.Equal(uint64(848), resProp.ProposalId)
+}
+
+func TestRestoreSnapshotChunks(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	ctx := context.Background()
+	cms, err := openSnapshotStore(dbm.NewMemDB(), 0)
+	require.NoError(err)
+	authStore := cms.GetKVStore(snapshotStoreKeys[authtypes.StoreKey])
+	govStore := cms.GetKVStore(snapshotStoreKeys[govkeys.StoreKey])
+	authStore.Set([]byte("a"), []byte("1"))
+	authStore.Set([]byte("b"), []byte("2"))
+	authStore.Set([]byte("c"), []byte("3"))
+	govStore.Set([]byte("a"), []byte{})
+	cms.Commit()
+	// write the snapshot like a node, and split its chunk in two
+	dir := filepath.Join(t.TempDir(), "1", "3")
+	require.NoError(os.MkdirAll(dir, 0o755))
+	chunks := make(chan io.ReadCloser)
+	go func() {
+		sw := snapshots.NewStreamWriter(chunks)
+		if err := cms.(*rootmulti.Store).Snapshot(1, sw); err != nil {
+			sw.CloseWithError(err)
+			return
+		}
+		sw.Close()
+	}()
+	var bz []byte
+	for chunk := range chunks {
+		b, err := io.ReadAll(chunk)
+		require.NoError(err)
+		bz = append(bz, b...)
+	}
+	require.NoError(os.WriteFile(filepath.Join(dir, "0"), bz[:len(bz)/2], 0o644))
+	require.NoError(os.WriteFile(filepath.Join(dir, "1"), bz[len(bz)/2:], 0o644))
+
+	restored, err := restoreSnapshotChunks(ctx, dir)
+
+	require.NoError(err)
+	authStore = restored.GetKVStore(snapshotStoreKeys[authtypes.StoreKey])
+	assert.Equal([]byte("1"), authStore.Get([]byte("a")))
+	assert.Equal([]byte("3"), authStore.Get([]byte("c")))
+	assert.True(restored.GetKVStore(snapshotStoreKeys[govkeys.StoreKey]).Has([]byte("a")))
+	assert.False(restored.GetKVStore(snapshotStoreKeys[banktypes.StoreKey]).Has([]byte("a")))
+
+	require.NoError(os.Remove(filepath.Join(dir, "0")))
+	_, err = restoreSnapshotChunks(ctx, dir)
+	assert.EqualError(err, "missing snapshot chunk 0 in "+dir)
+	_, err = restoreSnapshotChunks(ctx, filepath.Dir(dir))
+	assert.ErrorContains(err, "unsupported snapshot format '1'")
+}
+
+func TestUnmarshalBalance(t *testing.T) {
+	// SDK v0.45 format
+	coin := sdk.NewInt64Coin("uatom", 42)
+	amt, err := unmarshalBalance(cdc.MustMarshal(&coin))
+	require.NoError(t, err)
+	assert.Equal(t, sdk.NewInt(42), amt)
+	// SDK v0.46+ format
+	bz, err := sdk.NewInt(43).Marshal()
+	require.NoError(t, err)
+	amt, err = unmarshalBalance(bz)
+	require.NoError(t, err)
+	assert.Equal(t, sdk.NewInt(43), amt)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultSnapshotMirror is the location of the pre-packaged exports of the
+// known snapshots.
+const defaultSnapshotMirror = "https://atomone.fra1.digitaloceanspaces.com"
+
+// knownSnapshot is a relevant height of a chain, whose export is
+// pre-packaged on the mirror as the input files of the accounts command.
+type knownSnapshot struct {
+	name        string
+	description string
+	chainID     string
+	proposalID  uint64
+	height      int64
+	// dir is the directory of the input files on the mirror.
+	dir string
+}
+
+// knownSnapshots is the registry of the known relevant heights, see
+// SNAPSHOT-EXTRACT.md for how the exports were made. Only prop848 has
+// published exports on the mirror, the other heights (e.g. the tally of
+// proposal 69) are to be added once their exports are made and uploaded.
+// NOTE: never update an existing snapshot, add a new one instead.
+var knownSnapshots = map[string]knownSnapshot{
+	"prop848": {
+		name:        "prop848",
+		description: "Pre-tally height of the voting end of cosmoshub-4 proposal 848, the GovGen and AtomOne snapshot",
+		chainID:     "cosmoshub-4",
+		proposalID:  848,
+		height:      18010657,
+		dir:         "cosmoshub-4/prop848",
+	},
+}
+
+func getKnownSnapshot(name string) (knownSnapshot, error) {
+	s, ok := knownSnapshots[name]
+	if !ok {
+		return knownSnapshot{}, fmt.Errorf("unknown snapshot '%s', available snapshots are: %s",
+			name, strings.Join(slices.Sorted(maps.Keys(knownSnapshots)), ", "))
+	}
+	return s, nil
+}
+
+// printKnownSnapshots writes the registry of the known snapshots into w.
+func printKnownSnapshots(w io.Writer) {
+	table := newMarkdownTableWriter(w, "NAME", "CHAIN", "PROPOSAL", "HEIGHT", "DESCRIPTION")
+	for _, name := range slices.Sorted(maps.Keys(knownSnapshots)) {
+		s := knownSnapshots[name]
+		table.Append([]string{s.name, s.chainID, strconv.FormatUint(s.proposalID, 10),
+			strconv.FormatInt(s.height, 10), s.description})
+	}
+	table.Render()
+}
+
+// downloadSnapshot downloads the input files of s from mirror into dir. The
+// files are verified against the .sha256 checksums of the mirror while they
+// are downloaded (see openInput), and with strict a missing checksum is an
+// error even with -noChecksum. prop.json is optional. The downloaded files are
+// returned.
+func downloadSnapshot(ctx context.Context, mirror string, s knownSnapshot, dir string, strict bool) ([]string, error) {
+	var (
+		src   = strings.TrimSuffix(mirror, "/") + "/" + s.dir
+		files []string
+	)
+	for _, name := range append(slices.Clone(inputFiles), "prop.json") {
+		path := joinInput(src, name)
+		before := warnings.get(warnNoChecksum)
+		f, err := openInput(ctx, path)
+		if name == "prop.json" && errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strict && warnings.get(warnNoChecksum) > before {
+			f.Close()
+			return nil, fmt.Errorf("strict mode: %s has no .sha256 checksum", path)
+		}
+		out := filepath.Join(dir, name)
+		err = writeOutputFile(ctx, out, func(w io.Writer) error {
+			_, err := io.Copy(w, f)
+			return err
+		})
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("download %s: %w", path, err)
+		}
+		files = append(files, out)
+	}
+	return files, nil
+}
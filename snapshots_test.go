@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadSnapshot(t *testing.T) {
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	defer func(b bool) { noChecksum = b }(noChecksum)
+	files := map[string]string{}
+	for _, snap := range []string{"ok", "bad", "nosum"} {
+		for _, name := range inputFiles {
+			content := snap + "/" + name
+			files["/"+content] = content
+			switch snap {
+			case "ok":
+				files["/"+content+".sha256"] = fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte(content)), name)
+			case "bad":
+				files["/"+content+".sha256"] = fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte("other")), name)
+			}
+		}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, s)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name          string
+		dir           string
+		noChecksum    bool
+		strict        bool
+		expectedError string
+		expectedWarns int
+	}{
+		{
+			name: "ok",
+			dir:  "ok",
+		},
+		{
+			name:          "checksum mismatch",
+			dir:           "bad",
+			expectedError: "checksum mismatch",
+		},
+		{
+			name:          "no checksum",
+			dir:           "nosum",
+			expectedError: "has no .sha256 checksum",
+		},
+		{
+			name:          "no checksum allowed",
+			dir:           "nosum",
+			noChecksum:    true,
+			expectedWarns: len(inputFiles),
+		},
+		{
+			name:          "no checksum strict",
+			dir:           "nosum",
+			noChecksum:    true,
+			strict:        true,
+			expectedError: "strict mode: ",
+		},
+		{
+			name:          "not found",
+			dir:           "missing",
+			expectedError: "404 Not Found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			warnings = newWarningsRegistry()
+			noChecksum = tt.noChecksum
+			dir := t.TempDir()
+
+			downloaded, err := downloadSnapshot(context.Background(), srv.URL+"/",
+				knownSnapshot{name: tt.name, dir: tt.dir}, dir, tt.strict)
+
+			if tt.expectedError != "" {
+				require.ErrorContains(err, tt.expectedError)
+				entries, err := os.ReadDir(dir)
+				require.NoError(err)
+				assert.Empty(entries, "no file must be left behind on error")
+				return
+			}
+			require.NoError(err)
+			require.Len(downloaded, len(inputFiles))
+			for i, name := range inputFiles {
+				assert.Equal(filepath.Join(dir, name), downloaded[i])
+				bz, err := os.ReadFile(downloaded[i])
+				require.NoError(err)
+				assert.Equal(tt.dir+"/"+name, string(bz))
+			}
+			assert.Equal(tt.expectedWarns, warnings.get(warnNoChecksum))
+		})
+	}
+}
+
+func TestGetKnownSnapshot(t *testing.T) {
+	s, err := getKnownSnapshot("prop848")
+	require.NoError(t, err)
+	assert.EqualValues(t, 848, s.proposalID)
+
+	_, err = getKnownSnapshot("prop0")
+	assert.EqualError(t, err, "unknown snapshot 'prop0', available snapshots are: prop848")
+}
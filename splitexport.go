@@ -0,0 +1,476 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// splitExportFiles are the input files of govbox written by splitExport, in
+// the order of the writers it receives.
+var splitExportFiles = []string{
+	"auth_genesis.json", "balances.json", "supply.json", "delegations.json",
+	"active_validators.json", "votes.json", "prop.json", "gov_params.json",
+}
+
+// splitExportStats counts the entries written by splitExport.
+type splitExportStats struct {
+	balances    int
+	delegations int
+	// validators is the number of bonded validators, of which active are
+	// in the active set.
+	validators int
+	active     int
+	votes      int
+}
+
+// splitExport extracts from the state export r (the output of `gaiad export`)
+// the input files of govbox into dir, like the jq commands of
+// SNAPSHOT-EXTRACT.md, with proposalID the proposal of the votes. The export
+// is parsed as a stream and the large arrays (accounts, balances,
+// delegations, votes) are copied element by element, so the export is never
+// held in memory.
+func splitExport(ctx context.Context, r io.Reader, dir, proposalID string) (splitExportStats, error) {
+	var files []string
+	for _, f := range splitExportFiles {
+		files = append(files, filepath.Join(dir, f))
+	}
+	var stats splitExportStats
+	err := writeOutputFiles(ctx, files, func(ws []io.Writer) error {
+		s := exportSplitter{
+			ctx: ctx, dec: json.NewDecoder(bufio.NewReader(r)), proposalID: proposalID,
+			auth: ws[0], balances: ws[1], supply: ws[2], delegations: ws[3],
+			validators: ws[4], votes: ws[5], prop: ws[6], govParams: ws[7],
+			found: make(map[string]bool),
+		}
+		s.dec.UseNumber()
+		err := s.split()
+		stats = s.stats
+		return err
+	})
+	return stats, err
+}
+
+// exportSplitter holds the state of splitExport.
+type exportSplitter struct {
+	ctx        context.Context
+	dec        *json.Decoder
+	proposalID string
+
+	auth, balances, supply, delegations, validators, votes, prop, govParams io.Writer
+
+	// found holds the sections of the export found, by path.
+	found         map[string]bool
+	maxValidators int
+	// bonded are the bonded validators, sorted at the end to keep the active
+	// set.
+	bonded []bondedValidator
+	// tallyParams are the tally params of the gov params (v1) or of the
+	// tally_params (v1beta1).
+	tallyParams json.RawMessage
+	stats       splitExportStats
+}
+
+type bondedValidator struct {
+	tokens sdk.Int
+	raw    json.RawMessage
+}
+
+func (s *exportSplitter) split() error {
+	err := s.object("export", func(key string) error {
+		if key != "app_state" {
+			return s.skip()
+		}
+		return s.object("app_state", s.module)
+	})
+	if err != nil {
+		return err
+	}
+	for _, path := range []string{
+		"app_state.auth", "app_state.bank.balances", "app_state.bank.supply",
+		"app_state.staking.delegations", "app_state.staking.validators",
+		"app_state.gov.votes", "app_state.gov.proposals",
+	} {
+		if !s.found[path] {
+			return fmt.Errorf("%s not found in the export", path)
+		}
+	}
+	if !s.found["prop"] {
+		return fmt.Errorf("proposal %s not found in app_state.gov.proposals", s.proposalID)
+	}
+	if s.tallyParams == nil {
+		return fmt.Errorf("app_state.gov.params or app_state.gov.tally_params not found in the export")
+	}
+	if _, err := fmt.Fprintf(s.govParams, "%s\n", s.tallyParams); err != nil {
+		return err
+	}
+	return s.writeActiveValidators()
+}
+
+// module splits the genesis of the module key of the app state.
+func (s *exportSplitter) module(key string) error {
+	path := "app_state." + key
+	switch key {
+	case "auth":
+		s.found[path] = true
+		if err := s.copy(s.auth); err != nil {
+			return err
+		}
+		_, err := io.WriteString(s.auth, "\n")
+		return err
+	case "bank", "staking", "gov":
+		return s.object(path, func(field string) error { return s.moduleField(path + "." + field) })
+	default:
+		return s.skip()
+	}
+}
+
+func (s *exportSplitter) moduleField(path string) error {
+	switch path {
+	case "app_state.bank.balances":
+		s.found[path] = true
+		return s.array(s.balances, func(raw json.RawMessage) (json.RawMessage, error) {
+			s.stats.balances++
+			return raw, nil
+		})
+	case "app_state.bank.supply":
+		s.found[path] = true
+		return s.array(s.supply, func(raw json.RawMessage) (json.RawMessage, error) { return raw, nil })
+	case "app_state.staking.params":
+		var params struct {
+			MaxValidators int `json:"max_validators"`
+		}
+		if err := s.dec.Decode(&params); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		s.maxValidators = params.MaxValidators
+		return nil
+	case "app_state.staking.delegations":
+		s.found[path] = true
+		return s.array(s.delegations, func(raw json.RawMessage) (json.RawMessage, error) {
+			s.stats.delegations++
+			return raw, nil
+		})
+	case "app_state.staking.validators":
+		s.found[path] = true
+		return s.elements(func(raw json.RawMessage) error {
+			var val struct {
+				Status string `json:"status"`
+				Tokens string `json:"tokens"`
+			}
+			if err := json.Unmarshal(raw, &val); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if val.Status != "BOND_STATUS_BONDED" {
+				return nil
+			}
+			tokens, ok := sdk.NewIntFromString(val.Tokens)
+			if !ok {
+				return fmt.Errorf("%s: invalid tokens '%s'", path, val.Tokens)
+			}
+			s.bonded = append(s.bonded, bondedValidator{tokens: tokens, raw: raw})
+			return nil
+		})
+	case "app_state.gov.votes":
+		s.found[path] = true
+		return s.array(s.votes, func(raw json.RawMessage) (json.RawMessage, error) {
+			var vote map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &vote); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if !s.isProposal(vote["proposal_id"]) {
+				return nil, nil
+			}
+			s.stats.votes++
+			if _, ok := vote["metadata"]; ok {
+				// gov v1 vote, the other fields are the ones of v1beta1
+				delete(vote, "metadata")
+				return json.Marshal(vote)
+			}
+			return raw, nil
+		})
+	case "app_state.gov.params", "app_state.gov.tally_params":
+		// the v1 genesis has the deprecated tally_params set to null
+		var params *tallyParamsJSON
+		if err := s.dec.Decode(&params); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if params == nil || params.Quorum == "" {
+			return nil
+		}
+		var err error
+		s.tallyParams, err = json.MarshalIndent(map[string]string{
+			"quorum": params.Quorum, "threshold": params.Threshold, "veto_threshold": params.VetoThreshold,
+		}, "", "  ")
+		return err
+	case "app_state.gov.proposals":
+		s.found[path] = true
+		return s.elements(func(raw json.RawMessage) error {
+			var prop map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &prop); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			id, ok := prop["proposal_id"]
+			if !ok {
+				// gov v1 proposal
+				id = prop["id"]
+			}
+			if !s.isProposal(id) {
+				return nil
+			}
+			s.found["prop"] = true
+			bz, err := json.MarshalIndent(v1beta1ProposalJSON(prop), "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(s.prop, "%s\n", bz)
+			return err
+		})
+	default:
+		return s.skip()
+	}
+}
+
+// isProposal returns true if the JSON proposal id raw, a string or a number,
+// is the one of s.
+func (s *exportSplitter) isProposal(raw json.RawMessage) bool {
+	var id json.Number
+	return json.Unmarshal(raw, &id) == nil && id.String() == s.proposalID
+}
+
+// v1beta1ProposalJSON converts a gov v1 proposal to the v1beta1 JSON read by
+// parseProp, and returns a v1beta1 proposal unchanged. The content of the
+// proposal isn't converted, only its id, status, tally and times are used.
+func v1beta1ProposalJSON(prop map[string]json.RawMessage) map[string]json.RawMessage {
+	if _, ok := prop["proposal_id"]; ok {
+		return prop
+	}
+	res := map[string]json.RawMessage{"proposal_id": prop["id"]}
+	for _, field := range []string{"status", "submit_time", "deposit_end_time", "total_deposit", "voting_start_time", "voting_end_time"} {
+		if v, ok := prop[field]; ok {
+			res[field] = v
+		}
+	}
+	var tally map[string]json.RawMessage
+	if err := json.Unmarshal(prop["final_tally_result"], &tally); err == nil {
+		res["final_tally_result"], _ = json.Marshal(map[string]json.RawMessage{
+			"yes":          tally["yes_count"],
+			"abstain":      tally["abstain_count"],
+			"no":           tally["no_count"],
+			"no_with_veto": tally["no_with_veto_count"],
+		})
+	}
+	return res
+}
+
+// writeActiveValidators writes the active set: the bonded validators sorted
+// by tokens, up to the max_validators param, like the validators iterated by
+// the tally.
+func (s *exportSplitter) writeActiveValidators() error {
+	slices.SortStableFunc(s.bonded, func(a, b bondedValidator) int { return b.tokens.BigInt().Cmp(a.tokens.BigInt()) })
+	s.stats.validators = len(s.bonded)
+	active := s.bonded
+	if s.maxValidators > 0 {
+		active = active[:min(len(active), s.maxValidators)]
+	}
+	s.stats.active = len(active)
+	w := newJSONArrayWriter(s.validators)
+	for _, v := range active {
+		if err := w.add(v.raw); err != nil {
+			return err
+		}
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(s.validators, "\n")
+	return err
+}
+
+// object iterates over the fields of the next JSON object of the stream, fn
+// must consume the value of each field.
+func (s *exportSplitter) object(path string, fn func(key string) error) error {
+	if err := s.delim('{', path); err != nil {
+		return err
+	}
+	for s.dec.More() {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+		tok, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := fn(tok.(string)); err != nil {
+			return err
+		}
+	}
+	_, err := s.dec.Token() // '}'
+	return err
+}
+
+// elements calls fn for each element of the next JSON array of the stream.
+func (s *exportSplitter) elements(fn func(json.RawMessage) error) error {
+	if err := s.delim('[', ""); err != nil {
+		return err
+	}
+	for s.dec.More() {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+		var raw json.RawMessage
+		if err := s.dec.Decode(&raw); err != nil {
+			return err
+		}
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+	_, err := s.dec.Token() // ']'
+	return err
+}
+
+// array writes into w the elements of the next JSON array of the stream
+// returned by fn, nil drops an element.
+func (s *exportSplitter) array(w io.Writer, fn func(json.RawMessage) (json.RawMessage, error)) error {
+	aw := newJSONArrayWriter(w)
+	err := s.elements(func(raw json.RawMessage) error {
+		raw, err := fn(raw)
+		if err != nil || raw == nil {
+			return err
+		}
+		return aw.add(raw)
+	})
+	if err != nil {
+		return err
+	}
+	if err := aw.close(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// copy copies into w the next JSON value of the stream. The arrays are copied
+// element by element.
+func (s *exportSplitter) copy(w io.Writer) error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		io.WriteString(w, "{")
+		for i := 0; s.dec.More(); i++ {
+			key, err := s.dec.Token()
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				io.WriteString(w, ",")
+			}
+			bz, _ := json.Marshal(key)
+			fmt.Fprintf(w, "%s:", bz)
+			if err := s.copy(w); err != nil {
+				return err
+			}
+		}
+		s.dec.Token() // '}'
+		_, err := io.WriteString(w, "}")
+		return err
+	case json.Delim('['):
+		aw := newJSONArrayWriter(w)
+		for s.dec.More() {
+			if err := s.ctx.Err(); err != nil {
+				return err
+			}
+			var raw json.RawMessage
+			if err := s.dec.Decode(&raw); err != nil {
+				return err
+			}
+			if err := aw.add(raw); err != nil {
+				return err
+			}
+		}
+		s.dec.Token() // ']'
+		return aw.close()
+	default:
+		bz, err := json.Marshal(tok)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(bz)
+		return err
+	}
+}
+
+// skip consumes the next JSON value of the stream token by token, so the
+// large sections of the export that aren't extracted aren't held in memory.
+func (s *exportSplitter) skip() error {
+	depth := 0
+	for {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// delim consumes the next token of the stream, which must be d.
+func (s *exportSplitter) delim(d json.Delim, path string) error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != d {
+		return fmt.Errorf("%s: expected '%s', got '%v'", path, d, tok)
+	}
+	return nil
+}
+
+// jsonArrayWriter writes a JSON array, one element per line.
+type jsonArrayWriter struct {
+	w io.Writer
+	n int
+}
+
+func newJSONArrayWriter(w io.Writer) *jsonArrayWriter {
+	return &jsonArrayWriter{w: w}
+}
+
+func (a *jsonArrayWriter) add(raw json.RawMessage) error {
+	sep := ",\n"
+	if a.n == 0 {
+		sep = "[\n"
+	}
+	a.n++
+	if _, err := io.WriteString(a.w, sep); err != nil {
+		return err
+	}
+	_, err := a.w.Write(raw)
+	return err
+}
+
+func (a *jsonArrayWriter) close() error {
+	end := "\n]"
+	if a.n == 0 {
+		end = "[]"
+	}
+	_, err := io.WriteString(a.w, end)
+	return err
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestSplitExport(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	addrs := createAccountAddrs(3)
+	export := `{
+  "app_hash": "",
+  "app_state": {
+    "auth": {"params": {"max_memo_characters": "256"}, "accounts": [{"@type": "/cosmos.auth.v1beta1.BaseAccount", "address": "` + addrs[0].String() + `"}]},
+    "bank": {
+      "params": {"default_send_enabled": true},
+      "balances": [
+        {"address": "` + addrs[0].String() + `", "coins": [{"denom": "uatom", "amount": "10"}]},
+        {"address": "` + addrs[1].String() + `", "coins": [{"denom": "uatom", "amount": "20"}]}
+      ],
+      "supply": [{"denom": "uatom", "amount": "30"}],
+      "denom_metadata": []
+    },
+    "distribution": {"delegator_starting_infos": [{"delegator_address": "x", "starting_info": {"height": "1", "stake": "1.0"}}]},
+    "gov": {
+      "starting_proposal_id": "3",
+      "params": {"min_deposit": [], "quorum": "0.400000000000000000", "threshold": "0.500000000000000000", "veto_threshold": "0.334000000000000000"},
+      "tally_params": null,
+      "proposals": [
+        {"id": "1", "messages": [], "status": "PROPOSAL_STATUS_PASSED"},
+        {
+          "id": "2", "messages": [], "status": "PROPOSAL_STATUS_VOTING_PERIOD", "metadata": "", "title": "t",
+          "final_tally_result": {"yes_count": "0", "abstain_count": "0", "no_count": "0", "no_with_veto_count": "0"},
+          "submit_time": "2024-01-01T00:00:00Z", "voting_start_time": "2024-01-01T00:00:00Z", "voting_end_time": "2024-01-15T00:00:00Z"
+        }
+      ],
+      "votes": [
+        {"proposal_id": "1", "voter": "` + addrs[0].String() + `", "options": [{"option": "VOTE_OPTION_NO", "weight": "1.000000000000000000"}], "metadata": ""},
+        {"proposal_id": "2", "voter": "` + addrs[1].String() + `", "options": [{"option": "VOTE_OPTION_YES", "weight": "1.000000000000000000"}], "metadata": ""}
+      ]
+    },
+    "staking": {
+      "params": {"max_validators": 1, "bond_denom": "uatom"},
+      "validators": [
+        {"operator_address": "cosmosvaloper1a", "status": "BOND_STATUS_BONDED", "tokens": "100"},
+        {"operator_address": "cosmosvaloper1b", "status": "BOND_STATUS_UNBONDED", "tokens": "1000"},
+        {"operator_address": "cosmosvaloper1c", "status": "BOND_STATUS_BONDED", "tokens": "200"}
+      ],
+      "delegations": [
+        {"delegator_address": "` + addrs[2].String() + `", "validator_address": "cosmosvaloper1c", "shares": "200.000000000000000000"}
+      ]
+    }
+  },
+  "chain_id": "cosmoshub-4"
+}`
+	dir := t.TempDir()
+
+	stats, err := splitExport(context.Background(), strings.NewReader(export), dir, "2")
+
+	require.NoError(err)
+	assert.Equal(splitExportStats{balances: 2, delegations: 1, validators: 2, active: 1, votes: 1}, stats)
+	for _, f := range splitExportFiles {
+		assert.FileExists(filepath.Join(dir, f))
+	}
+	votes, err := parseVotesByAddr(context.Background(), dir)
+	require.NoError(err)
+	assert.Equal(map[string]govtypes.WeightedVoteOptions{
+		addrs[1].String(): govtypes.NewNonSplitVoteOption(govtypes.OptionYes),
+	}, votes)
+	prop, err := parseProp(dir)
+	require.NoError(err)
+	assert.EqualValues(2, prop.ProposalId)
+	assert.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), prop.VotingEndTime)
+	vals, err := parseValidators(context.Background(), dir)
+	require.NoError(err)
+	require.Len(vals, 1)
+	assert.Equal("cosmosvaloper1c", vals[0].OperatorAddress)
+	delegs, err := parseDelegationsByAddr(context.Background(), dir)
+	require.NoError(err)
+	assert.Len(delegs[addrs[2].String()], 1)
+	balances, err := parseBalancesByAddr(context.Background(), dir, "uatom")
+	require.NoError(err)
+	assert.Len(balances, 2)
+	params, err := parseTallyParams(context.Background(), dir)
+	require.NoError(err)
+	assert.Equal(&govTallyParams{
+		quorum: sdk.NewDecWithPrec(4, 1), threshold: sdk.NewDecWithPrec(5, 1), vetoThreshold: sdk.NewDecWithPrec(334, 3),
+	}, params)
+	bz, err := os.ReadFile(filepath.Join(dir, "auth_genesis.json"))
+	require.NoError(err)
+	assert.JSONEq(`{"params": {"max_memo_characters": "256"}, "accounts": [{"@type": "/cosmos.auth.v1beta1.BaseAccount", "address": "`+addrs[0].String()+`"}]}`, string(bz))
+
+	t.Run("missing proposal", func(t *testing.T) {
+		dir := t.TempDir()
+
+		_, err := splitExport(context.Background(), strings.NewReader(export), dir, "5")
+
+		assert.EqualError(err, "proposal 5 not found in app_state.gov.proposals")
+		entries, _ := os.ReadDir(dir)
+		assert.Empty(entries, "no file must be created")
+	})
+	t.Run("missing section", func(t *testing.T) {
+		_, err := splitExport(context.Background(), strings.NewReader(`{"app_state": {"auth": {}}}`), t.TempDir(), "2")
+
+		assert.EqualError(err, "app_state.bank.balances not found in the export")
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+)
+
+// stakingIncentivesModuleName is the name of the module account that holds
+// the minted $ATONE reserved to reward the early stakers of the new chain.
+const stakingIncentivesModuleName = "staking_incentives"
+
+func (d distriParams) hasStakingIncentives() bool {
+	return !d.stakingIncentivesPerc.IsNil() && d.stakingIncentivesPerc.IsPositive()
+}
+
+// mintedShares splits the minted amount between the community pool, the
+// reserved address and the staking incentives module account: the staking
+// incentives take their stakingIncentivesPerc, and the rest is split equally
+// between the community pool and the reserved address.
+func (d distriParams) mintedShares(minted sdk.Dec) (communityPool, reservedAddr, stakingIncentives sdk.Dec) {
+	stakingIncentives = sdk.ZeroDec()
+	if d.hasStakingIncentives() {
+		stakingIncentives = minted.Mul(d.stakingIncentivesPerc)
+	}
+	half := minted.Sub(stakingIncentives).QuoInt64(2)
+	return half, half, stakingIncentives
+}
+
+// parseStakingIncentivesPerc parses the stakingIncentives flag, the share of
+// the minted supply of the staking incentives, zero disables it.
+func parseStakingIncentivesPerc(s string) (sdk.Dec, error) {
+	perc, err := sdk.NewDecFromStr(s)
+	if err != nil || perc.IsNegative() || perc.GT(sdk.OneDec()) {
+		return sdk.Dec{}, fmt.Errorf("invalid stakingIncentives '%s' (expected a share of the minted supply between 0 and 1)", s)
+	}
+	return perc, nil
+}
+
+// stakingIncentivesModuleAddr returns the address of the staking incentives
+// module account with prefix.
+func stakingIncentivesModuleAddr(prefix string) string {
+	return sdk.MustBech32ifyAddressBytes(prefix, authtypes.NewModuleAddress(stakingIncentivesModuleName))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestStakingIncentives(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs    = createAccountAddrs(2)
+		accounts = []Account{
+			{
+				Address: addrs[0].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000 * M),
+				Vote: govtypes.NewNonSplitVoteOption(govtypes.OptionNo),
+			},
+			{Address: addrs[1].String(), LiquidAmount: sdk.NewDec(1000 * M), StakedAmount: sdk.ZeroDec()},
+		}
+		params = defaultDistriParams()
+	)
+	params.entityGroups = nil
+	ref, err := distribution(accounts, params, "atone")
+	require.NoError(err)
+	params.stakingIncentivesPerc, err = parseStakingIncentivesPerc("0.2")
+	require.NoError(err)
+
+	airdrop, err := distribution(accounts, params, "atone")
+
+	require.NoError(err)
+	minted := airdrop.atone.supply.Mul(params.supplyMintFactor)
+	assert.Equal(minted.Mul(sdk.NewDecWithPrec(2, 1)), airdrop.stakingIncentives)
+	assert.Equal(minted.Mul(sdk.NewDecWithPrec(4, 1)), airdrop.reservedAddr)
+	assert.Equal(airdrop.reservedAddr, airdrop.communityPool)
+	// the distributed amounts and the total supply don't change
+	assert.Equal(ref.addresses, airdrop.addresses)
+	assert.True(ref.totalSupply().Sub(airdrop.totalSupply()).Abs().LT(sdk.NewDecWithPrec(1, 12)))
+
+	balances, _ := genesisBalances([]genesisDenom{atoneDenom(airdrop)}, "atone")
+
+	coinsByAddr := make(map[string]sdk.Coins)
+	for _, b := range balances {
+		coinsByAddr[b.Address] = b.Coins
+	}
+	assert.Equal(sdk.NewCoins(sdk.NewCoin("uatone", airdrop.stakingIncentives.RoundInt())),
+		coinsByAddr[stakingIncentivesModuleAddr("atone")])
+	balances, _ = genesisBalances([]genesisDenom{atoneDenom(ref)}, "atone")
+	for _, b := range balances {
+		assert.NotEqual(stakingIncentivesModuleAddr("atone"), b.Address)
+	}
+
+	for _, s := range []string{"-0.1", "1.5", "x"} {
+		_, err := parseStakingIncentivesPerc(s)
+		assert.Error(err, s)
+	}
+}
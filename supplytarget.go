@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	// supplyTargetMaxIterations is the maximum number of distributions run
+	// to solve the supplyFactor of a target supply.
+	supplyTargetMaxIterations = 30
+	// supplyTargetTolerance is the maximum difference, in uatone, between
+	// the final supply and the target supply. It can't be zero because of
+	// the rounding of the allocations to uatone.
+	supplyTargetTolerance = M
+)
+
+func (d distriParams) hasTargetSupply() bool {
+	return !d.targetSupply.IsNil() && d.targetSupply.IsPositive()
+}
+
+// runTargetSupply runs the distribution with the supplyFactor that gives a
+// final $ATONE supply of params.targetSupply, within supplyTargetTolerance.
+// The final supply is linear with the supplyFactor, except with the voter
+// floor, the address cap and the rounding, so the supplyFactor is solved
+// with the secant method, starting from params.supplyFactor. The
+// allocations are only streamed for the solved supplyFactor, which requires
+// an additional run.
+func (s *distributionStages) runTargetSupply(ctx context.Context, params distriParams, prefix string, cp checkpointConfig) (airdrop, error) {
+	if cp.file != "" || cp.resume != nil {
+		return airdrop{}, errors.New("the target supply doesn't support checkpoints")
+	}
+	stream := s.stream
+	s.stream = nil
+	defer func() { s.stream = stream }()
+	var (
+		target = params.targetSupply.ToLegacyDec()
+		p      = params
+		// previous supplyFactor and its supply, for the secant
+		prevFactor, prevSupply sdk.Dec
+	)
+	p.targetSupply = sdk.Int{}
+	for i := 0; i < supplyTargetMaxIterations; i++ {
+		a, err := s.run(ctx, p, prefix, cp)
+		if err != nil {
+			return a, err
+		}
+		supply := a.totalSupply()
+		if supply.Sub(target).Abs().LTE(sdk.NewDec(supplyTargetTolerance)) {
+			if stream != nil {
+				s.stream = stream
+				if a, err = s.run(ctx, p, prefix, cp); err != nil {
+					return a, err
+				}
+			}
+			a.params.targetSupply = params.targetSupply
+			return a, nil
+		}
+		if !supply.IsPositive() {
+			return a, fmt.Errorf("target supply: no supply with supplyFactor %s", p.supplyFactor)
+		}
+		next := p.supplyFactor.Mul(target).Quo(supply)
+		if !prevFactor.IsNil() && !supply.Equal(prevSupply) {
+			next = p.supplyFactor.Add(target.Sub(supply).Mul(p.supplyFactor.Sub(prevFactor)).Quo(supply.Sub(prevSupply)))
+		}
+		if !next.IsPositive() {
+			next = p.supplyFactor.QuoInt64(2)
+		}
+		prevFactor, prevSupply = p.supplyFactor, supply
+		p.supplyFactor = next
+	}
+	return airdrop{}, fmt.Errorf("target supply %s not reached after %d iterations, last supplyFactor %s gave %s",
+		human(params.targetSupply), supplyTargetMaxIterations, prevFactor, humand(prevSupply))
+}
+
+// printTargetSupply prints in w the supplyFactor solved for the target supply
+// of airdrop.
+func printTargetSupply(w io.Writer, airdrop airdrop) {
+	fmt.Fprintln(w, targetSupplyText(airdrop))
+}
+
+func targetSupplyText(airdrop airdrop) string {
+	return fmt.Sprintf("supplyFactor %s solved for the target supply of %s $ATONE (final supply %s)",
+		airdrop.params.supplyFactor, human(airdrop.params.targetSupply), humand(airdrop.totalSupply()))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestRunTargetSupply(t *testing.T) {
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100 * M), Vote: vote(govtypes.OptionYes)},
+		{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100 * M), Vote: vote(govtypes.OptionNo)},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100 * M), StakedAmount: sdk.ZeroDec()},
+	}
+	tests := []struct {
+		name   string
+		params func(*distriParams)
+	}{
+		{
+			name:   "linear",
+			params: func(*distriParams) {},
+		},
+		{
+			name: "address cap",
+			params: func(p *distriParams) {
+				p.addressCap = sdk.NewInt(300 * M)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			params := defaultDistriParams()
+			tt.params(&params)
+			params.targetSupply = sdk.NewInt(1000 * M)
+
+			airdrop, err := newDistributionStages(slices.Values(accounts)).run(context.Background(), params, "", checkpointConfig{})
+
+			require.NoError(err)
+			assert.InDelta(1000*M, airdrop.totalSupply().MustFloat64(), supplyTargetTolerance)
+			assert.Equal(params.targetSupply, airdrop.params.targetSupply)
+			assert.NotEqual(defaultDistriParams().supplyFactor, airdrop.params.supplyFactor)
+			// same supply without the target
+			params.targetSupply = sdk.Int{}
+			params.supplyFactor = airdrop.params.supplyFactor
+			again, err := distribution(accounts, params, "")
+			require.NoError(err)
+			assert.Equal(airdrop.totalSupply(), again.totalSupply())
+		})
+	}
+
+	t.Run("checkpoint", func(t *testing.T) {
+		params := defaultDistriParams()
+		params.targetSupply = sdk.NewInt(1000 * M)
+
+		_, err := newDistributionStages(slices.Values(accounts)).run(context.Background(), params, "", checkpointConfig{file: "cp"})
+
+		assert.ErrorContains(t, err, "doesn't support checkpoints")
+	})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// splitAccount splits acc into n accounts of equal amounts, keeping the same
+// votes and delegations. This is how an attacker would spread a balance over
+// multiple wallets.
+func splitAccount(acc Account, n int) []Account {
+	accounts := make([]Account, n)
+	for i := range accounts {
+		split := Account{
+			Address:      fmt.Sprintf("%s-sybil%d", acc.Address, i),
+			Type:         acc.Type,
+			LiquidAmount: splitAmount(acc.LiquidAmount, i, n),
+			StakedAmount: splitAmount(acc.StakedAmount, i, n),
+			Vote:         acc.Vote,
+		}
+		if acc.LockedAmount != nil {
+			locked := splitAmount(*acc.LockedAmount, i, n)
+			split.LockedAmount = &locked
+		}
+		for _, del := range acc.Delegations {
+			split.Delegations = append(split.Delegations, Delegation{
+				Amount:           splitAmount(del.Amount, i, n),
+				ValidatorAddress: del.ValidatorAddress,
+				Vote:             del.Vote,
+				Chain:            del.Chain,
+			})
+		}
+		accounts[i] = split
+	}
+	return accounts
+}
+
+// splitAmount returns the i-th of the n parts of amt, the last one gets the
+// remainder of the division so the parts add up to amt.
+func splitAmount(amt sdk.Dec, i, n int) sdk.Dec {
+	part := amt.QuoInt64(int64(n))
+	if i == n-1 {
+		return amt.Sub(part.MulInt64(int64(n - 1)))
+	}
+	return part
+}
+
+type sybilResult struct {
+	splits int
+	// amount received by the attacker, summed over all the wallets.
+	amount sdk.Int
+	// gain compared to the amount received without splitting.
+	gain sdk.Int
+	// nonVotersMultiplier of the resulting distribution.
+	nonVotersMultiplier sdk.Dec
+}
+
+// simulateSybil computes the airdrop received by the account at index attacker
+// when its balance is split into each number of wallets of splits.
+func simulateSybil(ctx context.Context, accounts []Account, attacker int, params distriParams, splits []int) ([]sybilResult, error) {
+	var (
+		results  []sybilResult
+		baseline sdk.Int
+	)
+	// sort a copy, splits belongs to the caller
+	splits = slices.Sorted(slices.Values(splits))
+	if len(splits) == 0 || splits[0] != 1 {
+		// always compute the baseline
+		splits = append([]int{1}, splits...)
+	}
+	for _, n := range splits {
+		if n < 1 {
+			return nil, fmt.Errorf("invalid number of splits %d", n)
+		}
+		var (
+			sybils   = splitAccount(accounts[attacker], n)
+			simAccts = make([]Account, 0, len(accounts)-1+n)
+		)
+		simAccts = append(simAccts, accounts[:attacker]...)
+		simAccts = append(simAccts, sybils...)
+		simAccts = append(simAccts, accounts[attacker+1:]...)
+		airdrop, err := distributionSeq(ctx, slices.Values(simAccts), params, "")
+		if err != nil {
+			return nil, err
+		}
+		amount := sdk.ZeroInt()
+		for _, s := range sybils {
+			if amt, ok := airdrop.addresses[s.Address]; ok {
+				amount = amount.Add(amt)
+			}
+		}
+		if n == 1 {
+			baseline = amount
+		}
+		results = append(results, sybilResult{
+			splits:              n,
+			amount:              amount,
+			gain:                amount.Sub(baseline),
+			nonVotersMultiplier: airdrop.nonVotersMultiplier,
+		})
+	}
+	return results, nil
+}
+
+// largestAccount returns the index of the account with the highest balance.
+func largestAccount(accounts []Account) int {
+	var (
+		idx int
+		max = sdk.ZeroDec()
+	)
+	for i, acc := range accounts {
+		if total := acc.LiquidAmount.Add(acc.lockedAmount()).Add(acc.StakedAmount); total.GT(max) {
+			idx = i
+			max = total
+		}
+	}
+	return idx
+}
+
+func printSybilResults(acc Account, results []sybilResult) {
+	fmt.Printf("Attacker %s: %s $ATOM liquid, %s $ATOM staked\n", acc.Address,
+		humand(acc.LiquidAmount), humand(acc.StakedAmount))
+	table := newMarkdownTable("WALLETS", "$ATONE", "GAIN (uatone)", "GAIN %", "NON VOTERS MULTIPLIER")
+	for _, r := range results {
+		gainPerc := sdk.ZeroDec()
+		if base := r.amount.Sub(r.gain); !base.IsZero() {
+			gainPerc = r.gain.ToLegacyDec().Quo(base.ToLegacyDec())
+		}
+		table.Append([]string{
+			fmt.Sprint(r.splits),
+			human(r.amount),
+			r.gain.String(),
+			humanPercent(gainPerc),
+			fmt.Sprintf("%.6f", r.nonVotersMultiplier.MustFloat64()),
+		})
+	}
+	table.Render()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestSimulateSybil(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs = createAccountAddrs(3)
+		vals  = createValidatorAddrs(1)
+	)
+	accounts := []Account{
+		{
+			Address:      addrs[0].String(),
+			LiquidAmount: sdk.ZeroDec(),
+			StakedAmount: sdk.NewDec(1000 * M),
+			Vote:         govtypes.NewNonSplitVoteOption(govtypes.OptionYes),
+			Delegations:  []Delegation{{Amount: sdk.NewDec(1000 * M), ValidatorAddress: vals[0].String()}},
+		},
+		{Address: addrs[1].String(), LiquidAmount: sdk.NewDec(600 * M), StakedAmount: sdk.ZeroDec()},
+		{Address: addrs[2].String(), LiquidAmount: sdk.NewDec(5 * M), StakedAmount: sdk.ZeroDec()},
+	}
+	params := defaultDistriParams()
+	params.entityGroups = nil
+	attacker := largestAccount(accounts)
+	require.Equal(0, attacker)
+	// the locked amount counts in the balance
+	locked := sdk.NewDec(500 * M)
+	accounts[1].LockedAmount = &locked
+	assert.Equal(1, largestAccount(accounts))
+	accounts[1].LockedAmount = nil
+	splits := []int{10, 2}
+
+	results, err := simulateSybil(context.Background(), accounts, attacker, params, splits)
+
+	require.NoError(err)
+	assert.Equal([]int{10, 2}, splits, "the splits of the caller are not sorted")
+	require.Len(results, 3)
+	for i, n := range []int{1, 2, 10} {
+		assert.Equal(n, results[i].splits)
+	}
+	assert.True(results[0].gain.IsZero())
+	for _, r := range results {
+		assert.Equal(r.amount.Sub(results[0].amount), r.gain)
+	}
+
+	_, err = simulateSybil(context.Background(), accounts, attacker, params, []int{0})
+	assert.EqualError(err, "invalid number of splits 0")
+}
+
+func TestSplitAccount(t *testing.T) {
+	assert := assert.New(t)
+	locked := sdk.NewDec(3)
+	acc := Account{
+		Address:      "cosmos1attacker",
+		LiquidAmount: sdk.NewDec(30),
+		LockedAmount: &locked,
+		StakedAmount: sdk.NewDec(100),
+		Delegations:  []Delegation{{Amount: sdk.NewDec(100), ValidatorAddress: "cosmosvaloper1"}},
+	}
+
+	splits := splitAccount(acc, 3)
+
+	assert.Len(splits, 3)
+	var (
+		third  = sdk.MustNewDecFromStr("33.333333333333333333")
+		liquid = sdk.ZeroDec()
+		staked = sdk.ZeroDec()
+		delegs = sdk.ZeroDec()
+	)
+	for i, s := range splits {
+		assert.Equal(fmt.Sprintf("cosmos1attacker-sybil%d", i), s.Address)
+		assert.Equal(sdk.NewDec(10), s.LiquidAmount)
+		assert.Equal(sdk.NewDec(1), s.lockedAmount())
+		assert.Equal("cosmosvaloper1", s.Delegations[0].ValidatorAddress)
+		liquid = liquid.Add(s.LiquidAmount)
+		staked = staked.Add(s.StakedAmount)
+		delegs = delegs.Add(s.Delegations[0].Amount)
+	}
+	assert.Equal(third, splits[0].StakedAmount)
+	// the last split gets the remainder, so the splits add up to acc
+	assert.Equal(sdk.MustNewDecFromStr("33.333333333333333334"), splits[2].StakedAmount)
+	assert.Equal(acc.LiquidAmount, liquid)
+	assert.Equal(acc.StakedAmount, staked)
+	assert.Equal(acc.StakedAmount, delegs)
+}
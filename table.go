@@ -1,15 +1,251 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 
+	"github.com/mattn/go-runewidth"
 	"github.com/olekukonko/tablewriter"
+	"golang.org/x/term"
 )
 
-func newMarkdownTable(headers ...string) *tablewriter.Table {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
-	table.SetCenterSeparator("|")
-	table.SetHeader(headers)
-	return table
+// minTableColWidth is the narrowest column width of a table wrapped to fit
+// the terminal, below it the table is rendered as records instead.
+const minTableColWidth = 12
+
+// highlightedRowKeys are the words of the first cell of the rows that are
+// highlighted on terminals: the totals and the ratios.
+var highlightedRowKeys = []string{"total", "supply", "ratio", "multiplier", "diff"}
+
+// terminalWidth returns the width of the terminal w writes to, and false if w
+// isn't a terminal (e.g. a pipe or a file). It's a variable for the tests.
+var terminalWidth = func(w io.Writer) (int, bool) {
+	f, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return 0, false
+	}
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0, false
+	}
+	return width, true
+}
+
+// table collects the rows of a table and renders them according to its
+// output: plain markdown when piped, and on a terminal aligned to its width
+// with the header and the key rows in color (unless NO_COLOR is set). A
+// table that doesn't fit the terminal is wrapped, or rendered as one record
+// per row if the columns would be too narrow.
+type table struct {
+	w        io.Writer
+	headers  []string
+	rows     [][]string
+	footer   []string
+	align    int
+	autoWrap bool
+}
+
+func newMarkdownTable(headers ...string) *table {
+	return newMarkdownTableWriter(os.Stdout, headers...)
+}
+
+func newMarkdownTableWriter(w io.Writer, headers ...string) *table {
+	return &table{w: w, headers: headers, align: tablewriter.ALIGN_DEFAULT, autoWrap: true}
+}
+
+func (t *table) Append(row []string) {
+	t.rows = append(t.rows, row)
+}
+
+func (t *table) AppendBulk(rows [][]string) {
+	t.rows = append(t.rows, rows...)
+}
+
+func (t *table) SetFooter(footer []string) {
+	t.footer = footer
+}
+
+// SetAlignment sets the alignment of the cells, see tablewriter.ALIGN_*.
+func (t *table) SetAlignment(align int) {
+	t.align = align
+}
+
+// SetAutoWrapText sets whether the long cells are wrapped when the table is
+// piped, the cells are always wrapped to fit a terminal.
+func (t *table) SetAutoWrapText(autoWrap bool) {
+	t.autoWrap = autoWrap
+}
+
+func (t *table) Render() {
+	width, ok := terminalWidth(t.w)
+	if !ok {
+		t.renderMarkdown()
+		return
+	}
+	color := os.Getenv("NO_COLOR") == ""
+	switch natural := t.naturalWidth(); {
+	case natural <= width:
+		t.renderTerminal(0, color)
+	case t.wrappedColWidth(width) >= minTableColWidth:
+		t.renderTerminal(t.wrappedColWidth(width), color)
+	default:
+		t.renderRecords(color)
+	}
+}
+
+// newWriter returns a tablewriter.Table writing into w with the markdown
+// borders, whose cells are wrapped at colWidth if autoWrap, or at the default
+// width if colWidth is zero.
+func (t *table) newWriter(w io.Writer, autoWrap bool, colWidth int) *tablewriter.Table {
+	tw := tablewriter.NewWriter(w)
+	tw.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	tw.SetCenterSeparator("|")
+	tw.SetAlignment(t.align)
+	// The cells are split in lines as they are added, so the wrapping must be
+	// set first.
+	tw.SetAutoWrapText(autoWrap)
+	if colWidth > 0 {
+		tw.SetColWidth(colWidth)
+	}
+	tw.SetHeader(t.headers)
+	if t.footer != nil {
+		tw.SetFooter(t.footer)
+	}
+	return tw
+}
+
+func (t *table) renderMarkdown() {
+	tw := t.newWriter(t.w, t.autoWrap, 0)
+	tw.AppendBulk(t.rows)
+	tw.Render()
+}
+
+// renderTerminal renders the table aligned, with the cells wrapped at
+// colWidth if it's not zero. The colors are added to the rendered lines,
+// because tablewriter doesn't align the numbers of the colored cells.
+func (t *table) renderTerminal(colWidth int, color bool) {
+	if !color {
+		tw := t.newWriter(t.w, colWidth > 0, colWidth)
+		tw.AppendBulk(t.rows)
+		tw.Render()
+		return
+	}
+	var buf bytes.Buffer
+	tw := t.newWriter(&buf, colWidth > 0, colWidth)
+	tw.AppendBulk(t.rows)
+	tw.Render()
+	var (
+		lines       = strings.SplitAfter(buf.String(), "\n")
+		highlighted bool
+	)
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			line = colorLine(line, "1")
+		case strings.HasPrefix(line, "|-"), !strings.HasPrefix(line, "|"):
+		default:
+			// The first cell of the wrapped lines of a row is empty
+			if first := strings.TrimSpace(strings.Split(line, "|")[1]); first != "" {
+				highlighted = highlightedRow([]string{first})
+			}
+			if highlighted {
+				line = colorLine(line, "1;36")
+			}
+		}
+		io.WriteString(t.w, line)
+	}
+}
+
+// colorLine wraps the content of line, without its newline, with the ANSI
+// graphic rendition code.
+func colorLine(line, code string) string {
+	content, found := strings.CutSuffix(line, "\n")
+	if content == "" {
+		return line
+	}
+	line = "\x1b[" + code + "m" + content + "\x1b[0m"
+	if found {
+		line += "\n"
+	}
+	return line
+}
+
+// renderRecords renders each row as a record of one line per column, for the
+// terminals too narrow for the table.
+func (t *table) renderRecords(color bool) {
+	labelWidth := 0
+	for _, h := range t.headers {
+		labelWidth = max(labelWidth, runewidth.StringWidth(h))
+	}
+	label := func(s string) string {
+		s = runewidth.FillRight(s, labelWidth)
+		if color {
+			return "\x1b[1m" + s + "\x1b[0m"
+		}
+		return s
+	}
+	records := t.rows
+	if len(t.footer) > 0 {
+		records = append(records[:len(records):len(records)], t.footer)
+	}
+	for i, row := range records {
+		if i > 0 {
+			fmt.Fprintln(t.w)
+		}
+		for j, cell := range row {
+			var h string
+			if j < len(t.headers) {
+				h = t.headers[j]
+			}
+			if color && highlightedRow(row) {
+				cell = "\x1b[1;36m" + cell + "\x1b[0m"
+			}
+			fmt.Fprintf(t.w, "%s  %s\n", label(h), cell)
+		}
+	}
+}
+
+// naturalWidth returns the width of the table without wrapping.
+func (t *table) naturalWidth() int {
+	widths := make([]int, len(t.headers))
+	for _, row := range append([][]string{t.headers, t.footer}, t.rows...) {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			for _, line := range strings.Split(cell, "\n") {
+				widths[i] = max(widths[i], runewidth.StringWidth(line))
+			}
+		}
+	}
+	// "| " before each column, " " after, and the closing "|"
+	total := 1
+	for _, w := range widths {
+		total += w + 3
+	}
+	return total
+}
+
+// wrappedColWidth returns the column width that fits the table in width.
+func (t *table) wrappedColWidth(width int) int {
+	n := max(len(t.headers), 1)
+	return (width - 1 - 3*n) / n
+}
+
+// highlightedRow returns true if the first cell of row names a total or a
+// ratio.
+func highlightedRow(row []string) bool {
+	if len(row) == 0 {
+		return false
+	}
+	first := strings.ToLower(row[0])
+	for _, key := range highlightedRowKeys {
+		if strings.Contains(first, key) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableRender(t *testing.T) {
+	defer func(f func(io.Writer) (int, bool)) { terminalWidth = f }(terminalWidth)
+	render := func() string {
+		var buf bytes.Buffer
+		table := newMarkdownTableWriter(&buf, "", "AMOUNT")
+		table.Append([]string{"liquid", "1,000"})
+		table.Append([]string{"total supply", "3,000"})
+		table.Render()
+		return buf.String()
+	}
+	tests := []struct {
+		name     string
+		width    int
+		terminal bool
+		noColor  bool
+		expected string
+	}{
+		{
+			name: "piped",
+			expected: `|              | AMOUNT |
+|--------------|--------|
+| liquid       |  1,000 |
+| total supply |  3,000 |
+`,
+		},
+		{
+			name:     "terminal",
+			width:    80,
+			terminal: true,
+			expected: "\x1b[1m|              | AMOUNT |\x1b[0m\n" +
+				"|--------------|--------|\n" +
+				"| liquid       |  1,000 |\n" +
+				"\x1b[1;36m| total supply |  3,000 |\x1b[0m\n",
+		},
+		{
+			name:     "terminal without color",
+			width:    80,
+			terminal: true,
+			noColor:  true,
+			expected: `|              | AMOUNT |
+|--------------|--------|
+| liquid       |  1,000 |
+| total supply |  3,000 |
+`,
+		},
+		{
+			name:     "narrow terminal",
+			width:    20,
+			terminal: true,
+			noColor:  true,
+			expected: `        liquid
+AMOUNT  1,000
+
+        total supply
+AMOUNT  3,000
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terminalWidth = func(io.Writer) (int, bool) { return tt.width, tt.terminal }
+			if tt.noColor {
+				t.Setenv("NO_COLOR", "1")
+			} else {
+				t.Setenv("NO_COLOR", "")
+			}
+
+			assert.Equal(t, tt.expected, render())
+		})
+	}
+}
+
+func TestTableRenderWrapped(t *testing.T) {
+	defer func(f func(io.Writer) (int, bool)) { terminalWidth = f }(terminalWidth)
+	terminalWidth = func(io.Writer) (int, bool) { return 40, true }
+	t.Setenv("NO_COLOR", "1")
+	var buf bytes.Buffer
+	table := newMarkdownTableWriter(&buf, "NAME", "DESCRIPTION")
+	table.Append([]string{"prop848", "Pre-tally height of the voting end of cosmoshub-4 proposal 848"})
+
+	table.Render()
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		assert.LessOrEqual(t, len(line), 40, line)
+	}
+	assert.Contains(t, buf.String(), "| prop848 |")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// untaggedValue is the group of the accounts that don't have the grouped tag.
+const untaggedValue = "(untagged)"
+
+// addrTags holds the arbitrary attributes attached to addresses by an
+// enrichment file, indexed by address then by tag name.
+type addrTags map[string]map[string]string
+
+// parseTags reads an enrichment file, which is expected to be a JSON object
+// with "cosmos" addresses as keys and tags as values, like:
+//
+//	{
+//	  "cosmos1...": {"country": "US", "kind": "exchange", "name": "Coinbase"},
+//	  "cosmos1...": {"country": "KR", "kind": "exchange"}
+//	}
+func parseTags(path string) (_ addrTags, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var tags addrTags
+	if err := json.NewDecoder(f).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("cannot json decode tags from file %s: %w", path, err)
+	}
+	return tags, nil
+}
+
+type tagGroup struct {
+	value    string
+	accounts int
+	// atom is the $ATOM amount (liquid+locked+staked) of the group.
+	atom sdk.Dec
+	// atone is the $ATONE airdrop amount of the group.
+	atone sdk.Int
+}
+
+// groupByTag aggregates the audit entries of an airdrop per value of the tag
+// key. Accounts without this tag are grouped under untaggedValue. Groups are
+// sorted by decreasing $ATONE amount.
+func groupByTag(entries []auditEntry, tags addrTags, key string) []tagGroup {
+	groups := make(map[string]*tagGroup)
+	for _, e := range entries {
+		value, ok := tags[e.Address][key]
+		if !ok {
+			value = untaggedValue
+		}
+		g, ok := groups[value]
+		if !ok {
+			g = &tagGroup{value: value, atom: sdk.ZeroDec(), atone: sdk.ZeroInt()}
+			groups[value] = g
+		}
+		g.accounts++
+		g.atom = g.atom.Add(e.LiquidAmount).Add(e.lockedAmount()).Add(e.StakedAmount)
+		g.atone = g.atone.Add(e.FinalAmount)
+	}
+	res := make([]tagGroup, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, *g)
+	}
+	slices.SortFunc(res, func(a, b tagGroup) int {
+		if c := b.atone.BigInt().Cmp(a.atone.BigInt()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.value, b.value)
+	})
+	return res
+}
+
+func printTagReport(key string, groups []tagGroup) {
+	var (
+		totalAtom  = sdk.ZeroDec()
+		totalAtone = sdk.ZeroInt()
+	)
+	for _, g := range groups {
+		totalAtom = totalAtom.Add(g.atom)
+		totalAtone = totalAtone.Add(g.atone)
+	}
+	table := newMarkdownTable(strings.ToUpper(key), "ACCOUNTS", "$ATOM", "$ATONE", "% OF $ATONE")
+	for _, g := range groups {
+		perc := sdk.ZeroDec()
+		if !totalAtone.IsZero() {
+			perc = g.atone.ToLegacyDec().Quo(totalAtone.ToLegacyDec())
+		}
+		table.Append([]string{
+			g.value,
+			fmt.Sprint(g.accounts),
+			humand(g.atom),
+			human(g.atone),
+			humanPercent(perc),
+		})
+	}
+	table.SetFooter([]string{"TOTAL", "", humand(totalAtom), human(totalAtone), ""})
+	table.Render()
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestGroupByTag(t *testing.T) {
+	entry := func(addr string, atom, atone int64) auditEntry {
+		return auditEntry{
+			Address:      addr,
+			LiquidAmount: sdk.NewDec(atom),
+			StakedAmount: sdk.ZeroDec(),
+			FinalAmount:  sdk.NewInt(atone),
+		}
+	}
+	entries := []auditEntry{
+		entry("cosmos1a", 10, 100),
+		entry("cosmos1b", 20, 200),
+		entry("cosmos1c", 30, 50),
+		entry("cosmos1d", 40, 400),
+	}
+	locked := sdk.NewDec(5)
+	entries[1].LockedAmount = &locked
+	tags := addrTags{
+		"cosmos1a": {"country": "US", "kind": "exchange"},
+		"cosmos1b": {"country": "US"},
+		"cosmos1c": {"country": "KR", "kind": "exchange"},
+	}
+
+	groups := groupByTag(entries, tags, "country")
+
+	assert.Equal(t, []tagGroup{
+		{value: untaggedValue, accounts: 1, atom: sdk.NewDec(40), atone: sdk.NewInt(400)},
+		{value: "US", accounts: 2, atom: sdk.NewDec(35), atone: sdk.NewInt(300)},
+		{value: "KR", accounts: 1, atom: sdk.NewDec(30), atone: sdk.NewInt(50)},
+	}, groups)
+}
@@ -3,8 +3,6 @@ package main
 import (
 	"fmt"
 
-	h "github.com/dustin/go-humanize"
-
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
@@ -66,7 +64,7 @@ func tally(
 }
 
 func printTallyResults(results map[govtypes.VoteOption]sdk.Dec, totalVotingPower sdk.Dec, prop govtypes.Proposal) {
-	fmt.Println("Computed total voting power", h.Comma(totalVotingPower.TruncateInt64()))
+	fmt.Println("Computed total voting power", humanCount(totalVotingPower.TruncateInt64()))
 	yesPercent := results[govtypes.OptionYes].
 		Quo(totalVotingPower.Sub(results[govtypes.OptionAbstain]))
 	fmt.Println("Yes percent:", yesPercent)
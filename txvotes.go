@@ -0,0 +1,290 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	govv1types "github.com/cosmos/cosmos-sdk/x/gov/types/v1"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// voteMsgTypes are the type URLs of the vote messages extracted from txs.
+var voteMsgTypes = []string{
+	"/cosmos.gov.v1beta1.MsgVote",
+	"/cosmos.gov.v1beta1.MsgVoteWeighted",
+	"/cosmos.gov.v1.MsgVote",
+	"/cosmos.gov.v1.MsgVoteWeighted",
+}
+
+// txSearchPerPage is the page size of the tx_search RPC requests.
+const txSearchPerPage = 100
+
+// rawTx is a tx as returned by the tx_search RPC endpoint of a node, which is
+// also the format of the indexer exports.
+type rawTx struct {
+	Hash     string `json:"hash"`
+	Height   string `json:"height"`
+	Index    uint32 `json:"index"`
+	TxResult struct {
+		Code uint32 `json:"code"`
+	} `json:"tx_result"`
+	// Tx is the protobuf encoded tx (base64 in JSON).
+	Tx []byte `json:"tx"`
+}
+
+func (t rawTx) height() int64 {
+	h, _ := strconv.ParseInt(t.Height, 10, 64)
+	return h
+}
+
+// txVote is a vote extracted from a tx.
+type txVote struct {
+	height  int64
+	voter   string
+	options govtypes.WeightedVoteOptions
+}
+
+// parseRawTxs reads an indexer export, a JSON array of txs in the format of
+// the tx_search RPC endpoint:
+//
+//	[{"hash":"...","height":"18010600","index":0,"tx_result":{"code":0},"tx":"<base64>"}]
+func parseRawTxs(path string) (_ []rawTx, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var txs []rawTx
+	if err := json.NewDecoder(f).Decode(&txs); err != nil {
+		return nil, fmt.Errorf("cannot json decode txs from file %s: %w", path, err)
+	}
+	return txs, nil
+}
+
+// fetchRawTxs queries the tx_search endpoint of the node rpcURL for the vote
+// txs between the heights fromHeight and toHeight (inclusive).
+func fetchRawTxs(ctx context.Context, rpcURL string, fromHeight, toHeight int64) ([]rawTx, error) {
+	var txs []rawTx
+	for _, msgType := range voteMsgTypes {
+		query := fmt.Sprintf("message.action='%s' AND tx.height>=%d AND tx.height<=%d", msgType, fromHeight, toHeight)
+		for page := 1; ; page++ {
+			res, err := txSearch(ctx, rpcURL, query, page)
+			if err != nil {
+				return nil, err
+			}
+			txs = append(txs, res.Txs...)
+			total, _ := strconv.Atoi(res.TotalCount)
+			if len(res.Txs) == 0 || page*txSearchPerPage >= total {
+				break
+			}
+		}
+	}
+	return txs, nil
+}
+
+type txSearchResult struct {
+	Txs        []rawTx `json:"txs"`
+	TotalCount string  `json:"total_count"`
+}
+
+func txSearch(ctx context.Context, rpcURL, query string, page int) (txSearchResult, error) {
+	params := url.Values{
+		"query":    {strconv.Quote(query)},
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(txSearchPerPage)},
+		"order_by": {`"asc"`},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rpcURL+"/tx_search?"+params.Encode(), nil)
+	if err != nil {
+		return txSearchResult{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return txSearchResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bz, _ := io.ReadAll(resp.Body)
+		return txSearchResult{}, fmt.Errorf("tx_search %s: %s %s", query, resp.Status, bz)
+	}
+	var res struct {
+		Result txSearchResult `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+			Data    string `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return txSearchResult{}, fmt.Errorf("cannot json decode tx_search response: %w", err)
+	}
+	if res.Error != nil {
+		return txSearchResult{}, fmt.Errorf("tx_search %s: %s %s", query, res.Error.Message, res.Error.Data)
+	}
+	return res.Result, nil
+}
+
+// extractTxVotes returns the votes for proposalID of the successful txs,
+// ordered by height and index, so the last vote of a voter is the one that
+// counts. Txs that can't be decoded are reported in the warnings.
+func extractTxVotes(txs []rawTx, proposalID uint64) []txVote {
+	txs = slices.Clone(txs)
+	slices.SortStableFunc(txs, func(a, b rawTx) int {
+		return cmp.Or(cmp.Compare(a.height(), b.height()), cmp.Compare(a.Index, b.Index))
+	})
+	var (
+		votes []txVote
+		seen  = make(map[string]bool)
+	)
+	for _, rtx := range txs {
+		if rtx.TxResult.Code != 0 {
+			// Failed tx, for instance a vote after the end of the voting period
+			continue
+		}
+		if rtx.Hash != "" {
+			// The same tx is returned by the queries of each of its message types
+			if seen[rtx.Hash] {
+				continue
+			}
+			seen[rtx.Hash] = true
+		}
+		// The tx is decoded without unpacking its messages, so the txs with
+		// messages of unknown types are still decoded.
+		var tx txtypes.Tx
+		if err := tx.Unmarshal(rtx.Tx); err != nil || tx.Body == nil {
+			warnings.add(warnUndecodableTx, rtx.Hash)
+			continue
+		}
+		for _, msg := range tx.Body.Messages {
+			voter, id, options, ok, err := decodeVoteMsg(msg.TypeUrl, msg.Value)
+			if err != nil {
+				warnings.add(warnUndecodableTx, rtx.Hash)
+				continue
+			}
+			if !ok || id != proposalID {
+				continue
+			}
+			votes = append(votes, txVote{height: rtx.height(), voter: voter, options: options})
+		}
+	}
+	return votes
+}
+
+// decodeVoteMsg decodes a MsgVote or a MsgVoteWeighted of gov v1beta1 or v1,
+// ok is false for other messages.
+func decodeVoteMsg(typeURL string, bz []byte) (voter string, proposalID uint64, options govtypes.WeightedVoteOptions, ok bool, err error) {
+	switch typeURL {
+	case "/cosmos.gov.v1beta1.MsgVote":
+		var msg govtypes.MsgVote
+		if err := msg.Unmarshal(bz); err != nil {
+			return "", 0, nil, false, err
+		}
+		return msg.Voter, msg.ProposalId, govtypes.NewNonSplitVoteOption(msg.Option), true, nil
+	case "/cosmos.gov.v1beta1.MsgVoteWeighted":
+		var msg govtypes.MsgVoteWeighted
+		if err := msg.Unmarshal(bz); err != nil {
+			return "", 0, nil, false, err
+		}
+		return msg.Voter, msg.ProposalId, msg.Options, true, nil
+	case "/cosmos.gov.v1.MsgVote":
+		var msg govv1types.MsgVote
+		if err := msg.Unmarshal(bz); err != nil {
+			return "", 0, nil, false, err
+		}
+		return msg.Voter, msg.ProposalId, govtypes.NewNonSplitVoteOption(govtypes.VoteOption(msg.Option)), true, nil
+	case "/cosmos.gov.v1.MsgVoteWeighted":
+		var msg govv1types.MsgVoteWeighted
+		if err := msg.Unmarshal(bz); err != nil {
+			return "", 0, nil, false, err
+		}
+		for _, o := range msg.Options {
+			w, err := sdk.NewDecFromStr(o.Weight)
+			if err != nil {
+				return "", 0, nil, false, err
+			}
+			options = append(options, govtypes.WeightedVoteOption{Option: govtypes.VoteOption(o.Option), Weight: w})
+		}
+		return msg.Voter, msg.ProposalId, options, true, nil
+	}
+	return "", 0, nil, false, nil
+}
+
+// mergeTxVotes adds to votesByAddr the votes of txVotes whose voter is
+// missing, the last tx vote of a voter being its final vote. The votes of
+// votesByAddr come from the state and take precedence. It returns the number
+// of added votes.
+func mergeTxVotes(votesByAddr map[string]govtypes.WeightedVoteOptions, txVotes []txVote) int {
+	fromTxs := make(map[string]govtypes.WeightedVoteOptions)
+	for _, v := range txVotes {
+		fromTxs[v.voter] = v.options
+	}
+	var added int
+	for voter, options := range fromTxs {
+		if _, ok := votesByAddr[voter]; ok {
+			continue
+		}
+		votesByAddr[voter] = options
+		added++
+	}
+	return added
+}
+
+// writeVotes writes votesByAddr in file in the votes.json format, sorted by
+// voter.
+func writeVotes(ctx context.Context, file string, proposalID uint64, votesByAddr map[string]govtypes.WeightedVoteOptions) error {
+	return writeOutputFile(ctx, file, func(w io.Writer) error {
+		sep := "["
+		for _, voter := range slices.Sorted(maps.Keys(votesByAddr)) {
+			vote := govtypes.Vote{ProposalId: proposalID, Voter: voter, Options: votesByAddr[voter]}
+			s, err := marshaler.MarshalToString(&vote)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, sep+s); err != nil {
+				return err
+			}
+			sep = ",\n"
+		}
+		if sep == "[" {
+			if _, err := io.WriteString(w, sep); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "]")
+		return err
+	})
+}
+
+// importTxVotes reconstructs <datapath>/votes.json from the state votes, if
+// any, completed with the votes of txs.
+func importTxVotes(ctx context.Context, datapath string, proposalID uint64, txs []rawTx) error {
+	votesByAddr := make(map[string]govtypes.WeightedVoteOptions)
+	votesFile := filepath.Join(datapath, "votes.json")
+	if _, err := os.Stat(votesFile); err == nil {
+		votesByAddr, err = parseVotesByAddr(ctx, datapath)
+		if err != nil {
+			return err
+		}
+	}
+	numState := len(votesByAddr)
+	txVotes := extractTxVotes(txs, proposalID)
+	added := mergeTxVotes(votesByAddr, txVotes)
+	if err := writeVotes(ctx, votesFile, proposalID, votesByAddr); err != nil {
+		return err
+	}
+	fmt.Printf("%s votes extracted from %s txs, %s added to the %s votes of the state\n",
+		humanCount(len(txVotes)), humanCount(len(txs)), humanCount(added), humanCount(numState))
+	fmt.Printf("⚠ '%s' has been created/updated, don't forget to update S3 ⚠\n", votesFile)
+	return nil
+}
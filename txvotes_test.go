@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	govv1types "github.com/cosmos/cosmos-sdk/x/gov/types/v1"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func newRawVoteTx(t *testing.T, hash, height string, code uint32, msgs ...sdk.Msg) rawTx {
+	t.Helper()
+	var anys []*codectypes.Any
+	for _, msg := range msgs {
+		any, err := codectypes.NewAnyWithValue(msg)
+		require.NoError(t, err)
+		anys = append(anys, any)
+	}
+	bz, err := cdc.Marshal(&txtypes.Tx{Body: &txtypes.TxBody{Messages: anys}, AuthInfo: &txtypes.AuthInfo{}})
+	require.NoError(t, err)
+	tx := rawTx{Hash: hash, Height: height, Tx: bz}
+	tx.TxResult.Code = code
+	return tx
+}
+
+func TestImportTxVotes(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	warnings = newWarningsRegistry()
+	var (
+		ctx      = context.Background()
+		addrs    = createAccountAddrs(4)
+		datapath = t.TempDir()
+		splitYes = govtypes.WeightedVoteOptions{
+			{Option: govtypes.OptionYes, Weight: sdk.NewDecWithPrec(7, 1)},
+			{Option: govtypes.OptionAbstain, Weight: sdk.NewDecWithPrec(3, 1)},
+		}
+		txs = []rawTx{
+			// voter 0 changed its vote, the last one counts
+			newRawVoteTx(t, "B", "12", 0, govtypes.NewMsgVote(addrs[0], 848, govtypes.OptionNo)),
+			newRawVoteTx(t, "A", "10", 0, govtypes.NewMsgVote(addrs[0], 848, govtypes.OptionYes)),
+			newRawVoteTx(t, "C", "11", 0, govtypes.NewMsgVoteWeighted(addrs[1], 848, splitYes)),
+			// failed tx
+			newRawVoteTx(t, "D", "13", 1, govtypes.NewMsgVote(addrs[2], 848, govtypes.OptionYes)),
+			// other proposal
+			newRawVoteTx(t, "E", "13", 0, govtypes.NewMsgVote(addrs[2], 847, govtypes.OptionYes)),
+			// gov v1
+			newRawVoteTx(t, "F", "14", 0, govv1types.NewMsgVote(addrs[3], 848, govv1types.OptionNoWithVeto, "")),
+			// undecodable
+			{Hash: "G", Height: "15", Tx: []byte("foo")},
+		}
+	)
+	// The state already has the vote of voter 3
+	err := writeVotes(ctx, filepath.Join(datapath, "votes.json"), 848, map[string]govtypes.WeightedVoteOptions{
+		addrs[3].String(): govtypes.NewNonSplitVoteOption(govtypes.OptionAbstain),
+	})
+	require.NoError(err)
+
+	err = importTxVotes(ctx, datapath, 848, txs)
+
+	require.NoError(err)
+	votesByAddr, err := parseVotesByAddr(ctx, datapath)
+	require.NoError(err)
+	assert.Equal(map[string]govtypes.WeightedVoteOptions{
+		addrs[0].String(): govtypes.NewNonSplitVoteOption(govtypes.OptionNo),
+		addrs[1].String(): splitYes,
+		addrs[3].String(): govtypes.NewNonSplitVoteOption(govtypes.OptionAbstain),
+	}, votesByAddr)
+	assert.Equal(1, warnings.get(warnUndecodableTx))
+	votes := extractTxVotes(txs, 848)
+	require.Len(votes, 4)
+	assert.Equal(govtypes.NewNonSplitVoteOption(govtypes.OptionNoWithVeto), votes[3].options)
+}
+
+func TestFetchRawTxs(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addr    = createAccountAddrs(1)[0]
+		tx      = newRawVoteTx(t, "A", "10", 0, govtypes.NewMsgVote(addr, 848, govtypes.OptionYes))
+		queries []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		queries = append(queries, query)
+		res := txSearchResult{TotalCount: "0"}
+		if query == `"message.action='/cosmos.gov.v1beta1.MsgVote' AND tx.height>=1 AND tx.height<=20"` {
+			res = txSearchResult{Txs: []rawTx{tx}, TotalCount: "1"}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": res})
+	}))
+	defer srv.Close()
+
+	txs, err := fetchRawTxs(context.Background(), srv.URL, 1, 20)
+
+	require.NoError(err)
+	assert.Len(queries, len(voteMsgTypes))
+	require.Len(txs, 1)
+	assert.Equal(tx.Tx, txs[0].Tx)
+	votes := extractTxVotes(txs, 848)
+	require.Len(votes, 1)
+	assert.Equal(addr.String(), votes[0].voter)
+}
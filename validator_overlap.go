@@ -0,0 +1,174 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// How a gentx is matched with a validator of the snapshot.
+const (
+	// overlapMatchKey is a gentx signed with the operator key of the
+	// snapshot validator, whatever the bech32 prefix.
+	overlapMatchKey = "operator key"
+	// overlapMatchIdentity is a gentx with the same identity (e.g. Keybase)
+	// as the snapshot validator, but another operator key.
+	overlapMatchIdentity = "identity"
+)
+
+// overlapValidator is a validator of the snapshot active set with a gentx in
+// the AtomOne genesis.
+type overlapValidator struct {
+	valoper string
+	moniker string
+	// tokens is the $ATOM stake of the validator in the snapshot.
+	tokens sdk.Int
+	vote   govtypes.VoteOption
+	// atoneValoper and selfDelegation are the operator address and the
+	// $ATONE self-delegation of the gentx.
+	atoneValoper   string
+	selfDelegation sdk.Int
+	match          string
+}
+
+// validatorOverlap compares the validator set of the snapshot with the
+// genesis validator set of AtomOne.
+type validatorOverlap struct {
+	// validators are the validators on both chains, by decreasing $ATOM
+	// stake.
+	validators []overlapValidator
+	// snapshotVals and snapshotTokens are the size and the $ATOM stake of the
+	// snapshot active set.
+	snapshotVals   int
+	snapshotTokens sdk.Int
+	// gentxs is the number of gentxs, newcomers the monikers of the gentxs
+	// that don't match any snapshot validator.
+	gentxs    int
+	newcomers []string
+	// voteTokens and overlapVoteTokens are the $ATOM stake per validator
+	// vote, of the snapshot active set and of the validators on both chains.
+	voteTokens, overlapVoteTokens map[govtypes.VoteOption]sdk.Int
+}
+
+// overlapTokens returns the $ATOM stake of the validators on both chains.
+func (o validatorOverlap) overlapTokens() sdk.Int {
+	tokens := sdk.ZeroInt()
+	for _, v := range o.validators {
+		tokens = tokens.Add(v.tokens)
+	}
+	return tokens
+}
+
+// computeValidatorOverlap matches the gentxs with the validators vals of the
+// snapshot, by operator key first, then by identity. votesByAddr are the
+// votes by account address, matched by key since the sdk config has the
+// prefixes of the gentxs (see loadGentxs).
+func computeValidatorOverlap(vals []stakingtypes.Validator, votesByAddr map[string]govtypes.WeightedVoteOptions, gentxs []*stakingtypes.MsgCreateValidator) (validatorOverlap, error) {
+	o := validatorOverlap{
+		snapshotVals:      len(vals),
+		snapshotTokens:    sdk.ZeroInt(),
+		gentxs:            len(gentxs),
+		voteTokens:        make(map[govtypes.VoteOption]sdk.Int),
+		overlapVoteTokens: make(map[govtypes.VoteOption]sdk.Int),
+	}
+	for _, opt := range allVoteOptions {
+		o.voteTokens[opt] = sdk.ZeroInt()
+		o.overlapVoteTokens[opt] = sdk.ZeroInt()
+	}
+	var (
+		byKey      = make(map[addrKey]int, len(vals))
+		byIdentity = make(map[string]int)
+		matched    = make(map[int]bool)
+		votes      = make([]govtypes.VoteOption, len(vals))
+		votesByKey = keyByAddr(votesByAddr)
+	)
+	for i, val := range vals {
+		key, err := newAddrKey(val.OperatorAddress)
+		if err != nil {
+			return o, err
+		}
+		byKey[key] = i
+		if id := strings.TrimSpace(val.Description.Identity); id != "" {
+			byIdentity[id] = i
+		}
+		votes[i] = mainVoteOption(votesByKey[key])
+		o.snapshotTokens = o.snapshotTokens.Add(val.Tokens)
+		o.voteTokens[votes[i]] = o.voteTokens[votes[i]].Add(val.Tokens)
+	}
+	for _, msg := range gentxs {
+		key, err := newAddrKey(msg.ValidatorAddress)
+		if err != nil {
+			return o, fmt.Errorf("gentx %s: %w", msg.Description.Moniker, err)
+		}
+		i, ok := byKey[key]
+		match := overlapMatchKey
+		if !ok {
+			i, ok = byIdentity[strings.TrimSpace(msg.Description.Identity)]
+			match = overlapMatchIdentity
+		}
+		if !ok || matched[i] {
+			o.newcomers = append(o.newcomers, msg.Description.Moniker)
+			continue
+		}
+		matched[i] = true
+		val := vals[i]
+		o.validators = append(o.validators, overlapValidator{
+			valoper:        val.OperatorAddress,
+			moniker:        strings.TrimSpace(val.Description.Moniker),
+			tokens:         val.Tokens,
+			vote:           votes[i],
+			atoneValoper:   msg.ValidatorAddress,
+			selfDelegation: msg.Value.Amount,
+			match:          match,
+		})
+		o.overlapVoteTokens[votes[i]] = o.overlapVoteTokens[votes[i]].Add(val.Tokens)
+	}
+	slices.SortFunc(o.validators, func(a, b overlapValidator) int {
+		return cmp.Or(b.tokens.BigInt().Cmp(a.tokens.BigInt()), strings.Compare(a.valoper, b.valoper))
+	})
+	return o, nil
+}
+
+func printValidatorOverlap(o validatorOverlap) {
+	var (
+		overlapTokens = o.overlapTokens()
+		share         = func(a, b sdk.Int) string {
+			if b.IsZero() {
+				return "-"
+			}
+			return humanPercent(a.ToLegacyDec().QuoInt(b))
+		}
+	)
+	fmt.Printf("%d of the %d validators of the snapshot have a gentx, they hold %s of the $ATOM stake\n",
+		len(o.validators), o.snapshotVals, share(overlapTokens, o.snapshotTokens))
+	fmt.Printf("%d of the %d gentxs are from validators outside the snapshot active set\n\n", len(o.newcomers), o.gentxs)
+
+	table := newMarkdownTable("MONIKER", "$ATOM STAKE", "SHARE", "VOTE", "$ATONE SELF-DELEGATION", "MATCH")
+	for _, v := range o.validators {
+		table.Append([]string{
+			v.moniker, human(v.tokens), share(v.tokens, o.snapshotTokens), voteOptionLabel(v.vote),
+			human(v.selfDelegation), v.match,
+		})
+	}
+	table.Render()
+	fmt.Println()
+
+	// Compare the votes of the validators on both chains with the votes of
+	// the whole snapshot active set.
+	table = newMarkdownTable("VOTE", "$ATOM STAKE ON BOTH CHAINS", "SHARE ON BOTH CHAINS", "SHARE OF THE SNAPSHOT")
+	for _, opt := range allVoteOptions {
+		table.Append([]string{
+			voteOptionLabel(opt), human(o.overlapVoteTokens[opt]),
+			share(o.overlapVoteTokens[opt], overlapTokens), share(o.voteTokens[opt], o.snapshotTokens),
+		})
+	}
+	table.Render()
+	if len(o.newcomers) > 0 {
+		fmt.Printf("\nNew validators: %s\n", strings.Join(o.newcomers, ", "))
+	}
+}
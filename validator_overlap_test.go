@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+func TestComputeValidatorOverlap(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	valAddrs := createValidatorAddrs(5)
+	val := func(i int, tokens int64, identity string) stakingtypes.Validator {
+		return stakingtypes.Validator{
+			OperatorAddress: valAddrs[i].String(),
+			Tokens:          sdk.NewInt(tokens),
+			Description:     stakingtypes.Description{Moniker: valAddrs[i].String()[:16], Identity: identity},
+		}
+	}
+	gentx := func(i int, amount int64, identity string) *stakingtypes.MsgCreateValidator {
+		return &stakingtypes.MsgCreateValidator{
+			ValidatorAddress: sdk.MustBech32ifyAddressBytes("atonevaloper", valAddrs[i]),
+			Value:            sdk.NewInt64Coin("uatone", amount),
+			Description:      stakingtypes.Description{Moniker: "gentx", Identity: identity},
+		}
+	}
+	vals := []stakingtypes.Validator{val(0, 100, ""), val(1, 300, "ID1"), val(2, 600, "")}
+	votesByAddr := map[string]govtypes.WeightedVoteOptions{
+		sdk.AccAddress(valAddrs[0]).String(): govtypes.NewNonSplitVoteOption(govtypes.OptionNo),
+		sdk.AccAddress(valAddrs[2]).String(): govtypes.NewNonSplitVoteOption(govtypes.OptionYes),
+	}
+	gentxs := []*stakingtypes.MsgCreateValidator{
+		// same operator key
+		gentx(0, 10, ""),
+		// new key, same identity as vals[1]
+		gentx(3, 20, "ID1"),
+		// newcomer
+		gentx(4, 30, "ID4"),
+	}
+
+	o, err := computeValidatorOverlap(vals, votesByAddr, gentxs)
+
+	require.NoError(err)
+	assert.Equal([]overlapValidator{
+		{
+			valoper: valAddrs[1].String(), moniker: valAddrs[1].String()[:16], tokens: sdk.NewInt(300),
+			vote: govtypes.OptionEmpty, atoneValoper: gentxs[1].ValidatorAddress, selfDelegation: sdk.NewInt(20),
+			match: overlapMatchIdentity,
+		},
+		{
+			valoper: valAddrs[0].String(), moniker: valAddrs[0].String()[:16], tokens: sdk.NewInt(100),
+			vote: govtypes.OptionNo, atoneValoper: gentxs[0].ValidatorAddress, selfDelegation: sdk.NewInt(10),
+			match: overlapMatchKey,
+		},
+	}, o.validators)
+	assert.Equal([]string{"gentx"}, o.newcomers)
+	assert.Equal(sdk.NewInt(1000), o.snapshotTokens)
+	assert.Equal(sdk.NewInt(400), o.overlapTokens())
+	assert.Equal(sdk.NewInt(600), o.voteTokens[govtypes.OptionYes])
+	assert.Equal(sdk.ZeroInt(), o.overlapVoteTokens[govtypes.OptionYes])
+	assert.Equal(sdk.NewInt(100), o.overlapVoteTokens[govtypes.OptionNo])
+	assert.Equal(sdk.NewInt(300), o.overlapVoteTokens[govtypes.OptionEmpty])
+	printValidatorOverlap(o)
+}
+
+// TestValidatorOverlapGentxs runs in a child process, since loadGentxs seals
+// the sdk config with the AtomOne prefixes.
+func TestValidatorOverlapGentxs(t *testing.T) {
+	if os.Getenv("GOVBOX_TEST_GENTXS") == "" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestValidatorOverlapGentxs$")
+		cmd.Env = append(os.Environ(), "GOVBOX_TEST_GENTXS=1")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return
+	}
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		pk      = ed25519.GenPrivKey().PubKey()
+		valAddr = sdk.ValAddress(pk.Address())
+		dir     = t.TempDir()
+	)
+	msg, err := stakingtypes.NewMsgCreateValidator(valAddr, pk,
+		sdk.NewInt64Coin("uatone", 10), stakingtypes.NewDescription("val", "", "", "", ""),
+		stakingtypes.NewCommissionRates(sdk.NewDecWithPrec(1, 1), sdk.NewDecWithPrec(2, 1), sdk.NewDecWithPrec(1, 2)),
+		sdk.OneInt())
+	require.NoError(err)
+	msg.DelegatorAddress = sdk.MustBech32ifyAddressBytes("atone", valAddr)
+	msg.ValidatorAddress = sdk.MustBech32ifyAddressBytes("atonevaloper", valAddr)
+	anyMsg, err := codectypes.NewAnyWithValue(msg)
+	require.NoError(err)
+	bz, err := cdc.MarshalJSON(&txtypes.Tx{Body: &txtypes.TxBody{Messages: []*codectypes.Any{anyMsg}}})
+	require.NoError(err)
+	require.NoError(os.WriteFile(filepath.Join(dir, "gentx-val.json"), bz, 0o600))
+
+	gentxs, err := loadGentxs(dir)
+
+	require.NoError(err)
+	require.Len(gentxs, 1)
+	assert.Equal(msg.ValidatorAddress, gentxs[0].ValidatorAddress)
+	// the snapshot validators and votes keep the cosmos prefix
+	vals := []stakingtypes.Validator{{
+		OperatorAddress: sdk.MustBech32ifyAddressBytes("cosmosvaloper", valAddr),
+		Tokens:          sdk.NewInt(100),
+	}}
+	votesByAddr := map[string]govtypes.WeightedVoteOptions{
+		sdk.MustBech32ifyAddressBytes("cosmos", valAddr): govtypes.NewNonSplitVoteOption(govtypes.OptionYes),
+	}
+	o, err := computeValidatorOverlap(vals, votesByAddr, gentxs)
+	require.NoError(err)
+	require.Len(o.validators, 1)
+	assert.Equal(overlapMatchKey, o.validators[0].match)
+	assert.Equal(govtypes.OptionYes, o.validators[0].vote)
+}
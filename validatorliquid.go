@@ -0,0 +1,54 @@
+package main
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const policyValidatorLiquid = "validator-liquid"
+
+// validatorLiquidStats records the liquid balances of the validator operator
+// accounts that got the multipliers of their own vote.
+type validatorLiquidStats struct {
+	accounts int
+	// liquid is the liquid $ATOM of the operator accounts, and delta the
+	// $ATONE they got on top of the liquid multiplier of the non-voters.
+	liquid sdk.Dec
+	delta  sdk.Dec
+}
+
+// validatorOperatorKeys returns the keys of the operator accounts of the
+// validators, which share the bytes of the operator addresses.
+func validatorOperatorKeys(validators []validatorPower) map[addrKey]bool {
+	keys := make(map[addrKey]bool, len(validators))
+	for _, v := range validators {
+		if key, err := newAddrKey(v.address); err == nil {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// isValidatorOperator returns true if acc is the operator account of one of
+// the validators of operatorKeys.
+func isValidatorOperator(acc Account, operatorKeys map[addrKey]bool) bool {
+	key, err := acc.addrKey()
+	return err == nil && operatorKeys[key]
+}
+
+// votedLiquid returns the allocation of the liquid amount of acc with the
+// multipliers of its direct vote, as if it was staked.
+func (k *allocationKernel) votedLiquid(acc Account) sdk.Dec {
+	amt := sdk.ZeroDec()
+	for _, v := range acc.Vote {
+		if int(v.Option) < len(k.chains) {
+			amt = amt.Add(mulDecChain(acc.LiquidAmount.Mul(v.Weight), k.chains[v.Option]))
+		}
+	}
+	return amt
+}
+
+func reportValidatorLiquid(sec *reportSection, airdrop airdrop) {
+	s := airdrop.validatorLiquid
+	sec.text("Validator liquid balances: %d operator accounts with %s liquid $ATOM got the multipliers of their vote, a difference of %s $ATONE with the non-voters malus",
+		s.accounts, humand(s.liquid), humand(s.delta))
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// validatorMapping links a validator operator address to its account
+// address, which is the address used to cast the validator vote.
+type validatorMapping struct {
+	Valoper  string `json:"valoper"`
+	Account  string `json:"account"`
+	Moniker  string `json:"moniker"`
+	Identity string `json:"identity,omitempty"`
+	// Vote holds the weight per vote option, empty if the validator didn't
+	// vote.
+	Vote map[string]sdk.Dec `json:"vote"`
+}
+
+// validatorAccAddr returns the account address of the validator operator
+// address valoper.
+func validatorAccAddr(valoper string) (string, error) {
+	valAddr, err := sdk.ValAddressFromBech32(valoper)
+	if err != nil {
+		return "", fmt.Errorf("validator %s: %w", valoper, err)
+	}
+	return sdk.AccAddress(valAddr.Bytes()).String(), nil
+}
+
+// parseValidatorMappings returns the mappings of the validators of
+// active_validators.json, sorted by operator address.
+func parseValidatorMappings(ctx context.Context, path string, votesByAddr map[string]govtypes.WeightedVoteOptions) ([]validatorMapping, error) {
+	var mappings []validatorMapping
+	err := iterateValidators(ctx, path, func(val stakingtypes.Validator) error {
+		accAddr, err := validatorAccAddr(val.OperatorAddress)
+		if err != nil {
+			return err
+		}
+		vote := make(map[string]sdk.Dec)
+		for _, o := range votesByAddr[accAddr] {
+			vote[o.Option.String()] = o.Weight
+		}
+		mappings = append(mappings, validatorMapping{
+			Valoper:  val.OperatorAddress,
+			Account:  accAddr,
+			Moniker:  val.Description.Moniker,
+			Identity: val.Description.Identity,
+			Vote:     vote,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(mappings, func(a, b validatorMapping) int {
+		return strings.Compare(a.Valoper, b.Valoper)
+	})
+	return mappings, nil
+}
+
+func writeValidatorMappings(ctx context.Context, file string, mappings []validatorMapping) error {
+	bz, err := json.MarshalIndent(mappings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(ctx, file, bz)
+}
+
+// validatorInfo is the description of a validator used to label it in the
+// reports.
+type validatorInfo struct {
+	moniker string
+	// identity is the optional identity signature of the validator (e.g.
+	// Keybase).
+	identity string
+}
+
+// validatorInfos holds the validatorInfo per operator address.
+type validatorInfos map[string]validatorInfo
+
+// parseValidatorInfos returns the descriptions of the validators of
+// active_validators.json by operator address. If the file doesn't exist, it
+// returns an empty validatorInfos, so the reports fall back to the operator
+// addresses.
+func parseValidatorInfos(ctx context.Context, path string) (validatorInfos, error) {
+	infos := make(validatorInfos)
+	err := iterateValidators(ctx, path, func(val stakingtypes.Validator) error {
+		infos[val.OperatorAddress] = validatorInfo{
+			moniker:  strings.TrimSpace(val.Description.Moniker),
+			identity: strings.TrimSpace(val.Description.Identity),
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return infos, nil
+	}
+	return infos, err
+}
+
+// moniker returns the moniker of the validator valoper, "-" if it's missing.
+func (v validatorInfos) moniker(valoper string) string {
+	if m := v[valoper].moniker; m != "" {
+		return m
+	}
+	return "-"
+}
+
+// label returns the moniker of the validator valoper, or valoper if the
+// moniker is missing.
+func (v validatorInfos) label(valoper string) string {
+	if m := v[valoper].moniker; m != "" {
+		return m
+	}
+	return valoper
+}
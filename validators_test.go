@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+func TestParseValidatorMappings(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		ctx      = context.Background()
+		datapath = t.TempDir()
+		accAddr1 = sdk.AccAddress("val1________________")
+		accAddr2 = sdk.AccAddress("val2________________")
+		vals     []string
+	)
+	for i, addr := range []sdk.AccAddress{accAddr2, accAddr1} {
+		val := stakingtypes.Validator{
+			OperatorAddress: sdk.ValAddress(addr).String(),
+			Tokens:          sdk.NewInt(1),
+			DelegatorShares: sdk.NewDec(1),
+			Description:     stakingtypes.Description{Moniker: []string{"two", "one"}[i]},
+			Commission:      stakingtypes.NewCommission(sdk.ZeroDec(), sdk.ZeroDec(), sdk.ZeroDec()),
+		}
+		s, err := marshaler.MarshalToString(&val)
+		require.NoError(err)
+		vals = append(vals, s)
+	}
+	err := os.WriteFile(filepath.Join(datapath, "active_validators.json"),
+		[]byte("["+strings.Join(vals, ",")+"]"), 0o644)
+	require.NoError(err)
+	votesByAddr := map[string]govtypes.WeightedVoteOptions{
+		accAddr1.String(): govtypes.NewNonSplitVoteOption(govtypes.OptionNo),
+	}
+
+	mappings, err := parseValidatorMappings(ctx, datapath, votesByAddr)
+
+	require.NoError(err)
+	assert.Equal([]validatorMapping{
+		{
+			Valoper: sdk.ValAddress(accAddr1).String(),
+			Account: accAddr1.String(),
+			Moniker: "one",
+			Vote:    map[string]sdk.Dec{"VOTE_OPTION_NO": sdk.OneDec()},
+		},
+		{
+			Valoper: sdk.ValAddress(accAddr2).String(),
+			Account: accAddr2.String(),
+			Moniker: "two",
+			Vote:    map[string]sdk.Dec{},
+		},
+	}, mappings)
+}
+
+func TestParseValidatorInfos(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		ctx      = context.Background()
+		datapath = t.TempDir()
+		valoper1 = sdk.ValAddress("val1________________").String()
+		valoper2 = sdk.ValAddress("val2________________").String()
+		vals     []string
+	)
+	for _, val := range []stakingtypes.Validator{
+		{OperatorAddress: valoper1, Description: stakingtypes.Description{Moniker: " one ", Identity: "ABCD"}},
+		{OperatorAddress: valoper2},
+	} {
+		val.Tokens = sdk.NewInt(1)
+		val.DelegatorShares = sdk.NewDec(1)
+		val.Commission = stakingtypes.NewCommission(sdk.ZeroDec(), sdk.ZeroDec(), sdk.ZeroDec())
+		s, err := marshaler.MarshalToString(&val)
+		require.NoError(err)
+		vals = append(vals, s)
+	}
+	// No file, fall back to the operator addresses
+	infos, err := parseValidatorInfos(ctx, datapath)
+	require.NoError(err)
+	assert.Empty(infos)
+	assert.Equal(valoper1, infos.label(valoper1))
+	err = os.WriteFile(filepath.Join(datapath, "active_validators.json"),
+		[]byte("["+strings.Join(vals, ",")+"]"), 0o644)
+	require.NoError(err)
+
+	infos, err = parseValidatorInfos(ctx, datapath)
+
+	require.NoError(err)
+	assert.Equal(validatorInfos{
+		valoper1: {moniker: "one", identity: "ABCD"},
+		valoper2: {},
+	}, infos)
+	assert.Equal("one", infos.label(valoper1))
+	assert.Equal("one", infos.moniker(valoper1))
+	assert.Equal(valoper2, infos.label(valoper2))
+	assert.Equal("-", infos.moniker(valoper2))
+}
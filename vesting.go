@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingexported "github.com/cosmos/cosmos-sdk/x/auth/vesting/exported"
+)
+
+// parseLockedByAddr returns the amount of denom that is still vesting at t in
+// the balance of each vesting account of auth_genesis.json. The vesting
+// tokens that are delegated are already part of the staked amount, so they
+// are deducted from the locked amount.
+func parseLockedByAddr(path, denom string, t time.Time) (_ map[string]sdk.Int, err error) {
+	f, err := openInput(context.Background(), joinInput(path, "auth_genesis.json"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var genesis authtypes.GenesisState
+	if err := unmarshaler.Unmarshal(f, &genesis); err != nil {
+		return nil, err
+	}
+	lockedByAddr := make(map[string]sdk.Int)
+	for _, any := range genesis.Accounts {
+		var acc authtypes.GenesisAccount
+		if err := registry.UnpackAny(any, &acc); err != nil {
+			return nil, fmt.Errorf("unpack account %s: %w", any.GetTypeUrl(), err)
+		}
+		v, ok := acc.(vestingexported.VestingAccount)
+		if !ok {
+			continue
+		}
+		locked := v.GetVestingCoins(t).AmountOf(denom).Sub(v.GetDelegatedVesting().AmountOf(denom))
+		if locked.IsPositive() {
+			lockedByAddr[acc.GetAddress().String()] = locked
+		}
+	}
+	return lockedByAddr, nil
+}
+
+// snapshotTime returns the block time of the snapshot, at which the vesting
+// amounts are computed: the RFC3339 time s if set, else the voting end time of
+// <path>/prop.json, the time of the tally block the snapshot is taken at.
+func snapshotTime(path, s string) (time.Time, error) {
+	if s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid snapshotTime '%s': %w", s, err)
+		}
+		return t, nil
+	}
+	prop, err := parseProp(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return time.Time{}, fmt.Errorf("no prop.json in %s for the snapshot time, set -snapshotTime", path)
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return prop.VotingEndTime, nil
+}
+
+// applyVestingLocks moves the locked amount of each account from its
+// LiquidAmount to its LockedAmount, so the locked tokens get the multiplier
+// of the lockedMultiplier policy instead of the liquid one. The locked amount
+// is limited to the liquid amount of the account. It returns the number of
+// accounts with a locked amount.
+func applyVestingLocks(accounts []Account, lockedByAddr map[string]sdk.Int) int {
+	lockedByKey := keyByAddr(lockedByAddr)
+	var n int
+	for i, acc := range accounts {
+		key, err := acc.addrKey()
+		if err != nil {
+			continue
+		}
+		vesting, ok := lockedByKey[key]
+		if !ok {
+			continue
+		}
+		amt := sdk.MinDec(vesting.ToLegacyDec(), acc.LiquidAmount)
+		if !amt.IsPositive() {
+			continue
+		}
+		accounts[i].LiquidAmount = acc.LiquidAmount.Sub(amt)
+		locked := acc.lockedAmount().Add(amt)
+		accounts[i].LockedAmount = &locked
+		n++
+	}
+	return n
+}
+
+// hasLockedMultiplier returns true if the locked amounts don't get the
+// multiplier of the liquid amounts.
+func (d distriParams) hasLockedMultiplier() bool {
+	return !d.lockedMultiplier.IsNil()
+}
+
+// lockedFactor returns the factor applied to the liquid multiplier for the
+// locked amounts.
+func (d distriParams) lockedFactor() sdk.Dec {
+	if !d.hasLockedMultiplier() {
+		return sdk.OneDec()
+	}
+	return d.lockedMultiplier
+}
+
+// parseLockedMultiplier parses the lockedMultiplier flag, an empty value
+// returns a nil sdk.Dec which keeps the liquid multiplier for the locked
+// amounts.
+func parseLockedMultiplier(s string) (sdk.Dec, error) {
+	if s == "" {
+		return sdk.Dec{}, nil
+	}
+	m, err := sdk.NewDecFromStr(s)
+	if err != nil || m.IsNegative() {
+		return sdk.Dec{}, fmt.Errorf("invalid lockedMultiplier '%s'", s)
+	}
+	return m, nil
+}
+
+func reportVestingLocks(sec *reportSection, airdrop airdrop) {
+	sec.text("Vesting locks")
+	table := sec.table("", "$ATOM", "$ATONE", "LOCKED MULTIPLIER")
+	table.Append([]string{
+		"Locked",
+		human(airdrop.atom.locked.TruncateInt()),
+		human(airdrop.atone.locked.TruncateInt()),
+		airdrop.params.lockedFactor().String(),
+	})
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingtypes "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestParseLockedByAddr(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs    = createAccountAddrs(3)
+		datapath = t.TempDir()
+		vesting  = sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000), sdk.NewInt64Coin("uother", 1000))
+		// half vested at 100, with 200uatom of the vesting delegated
+		continuous = vestingtypes.NewContinuousVestingAccount(authtypes.NewBaseAccountWithAddress(addrs[0]), vesting, 0, 200)
+		// fully vested at 100
+		delayed = vestingtypes.NewDelayedVestingAccount(authtypes.NewBaseAccountWithAddress(addrs[1]), vesting, 50)
+	)
+	continuous.DelegatedVesting = sdk.NewCoins(sdk.NewInt64Coin("uatom", 200))
+	writeAuthGenesis(t, datapath, continuous, delayed, authtypes.NewBaseAccountWithAddress(addrs[2]))
+
+	lockedByAddr, err := parseLockedByAddr(datapath, "uatom", time.Unix(100, 0))
+
+	require.NoError(err)
+	assert.Equal(map[string]sdk.Int{addrs[0].String(): sdk.NewInt(300)}, lockedByAddr)
+}
+
+// writeAuthGenesis writes the auth_genesis.json of accounts into datapath.
+func writeAuthGenesis(t *testing.T, datapath string, accounts ...authtypes.GenesisAccount) {
+	t.Helper()
+	var authGen authtypes.GenesisState
+	for _, acc := range accounts {
+		any, err := codectypes.NewAnyWithValue(acc)
+		require.NoError(t, err)
+		authGen.Accounts = append(authGen.Accounts, any)
+	}
+	bz, err := cdc.MarshalJSON(&authGen)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(datapath, "auth_genesis.json"), bz, 0o600))
+}
+
+func TestAnalyzeVestingAccounts(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs    = createAccountAddrs(2)
+		datapath = t.TempDir()
+		vesting  = sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000))
+	)
+	writeAuthGenesis(t, datapath,
+		vestingtypes.NewContinuousVestingAccount(authtypes.NewBaseAccountWithAddress(addrs[0]), vesting, 0, 200),
+		vestingtypes.NewDelayedVestingAccount(authtypes.NewBaseAccountWithAddress(addrs[1]), vesting, 50),
+	)
+
+	// the vesting amounts depend on the snapshot time, not on the current time
+	a, err := analyzeVestingAccounts(datapath, time.Unix(20, 0))
+	require.NoError(err)
+	assert.Equal(2, a.vesting)
+	assert.Equal(2, a.stillVesting)
+	assert.Equal(sdk.NewCoins(sdk.NewInt64Coin("uatom", 1900)), a.totalVesting)
+
+	a, err = analyzeVestingAccounts(datapath, time.Unix(100, 0))
+	require.NoError(err)
+	assert.Equal(1, a.stillVesting)
+	assert.Equal(sdk.NewCoins(sdk.NewInt64Coin("uatom", 500)), a.totalVesting)
+}
+
+func TestSnapshotTime(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	datapath := t.TempDir()
+
+	_, err := snapshotTime(datapath, "")
+	assert.ErrorContains(err, "set -snapshotTime")
+	_, err = snapshotTime(datapath, "2023-11-25")
+	assert.ErrorContains(err, "invalid snapshotTime")
+
+	votingEnd := time.Date(2023, 11, 25, 21, 0, 28, 0, time.UTC)
+	bz, err := cdc.MarshalJSON(&govtypes.Proposal{ProposalId: 848, VotingEndTime: votingEnd})
+	require.NoError(err)
+	require.NoError(os.WriteFile(filepath.Join(datapath, "prop.json"), bz, 0o600))
+	tm, err := snapshotTime(datapath, "")
+	require.NoError(err)
+	assert.True(votingEnd.Equal(tm), tm)
+	tm, err = snapshotTime(datapath, "2024-01-01T00:00:00Z")
+	require.NoError(err)
+	assert.True(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Equal(tm), tm)
+}
+
+func TestApplyVestingLocks(t *testing.T) {
+	assert := assert.New(t)
+	addrs := createAccountAddrs(3)
+	accounts := []Account{
+		{Address: addrs[0].String(), LiquidAmount: sdk.NewDec(1000), StakedAmount: sdk.ZeroDec()},
+		{Address: addrs[1].String(), LiquidAmount: sdk.NewDec(100), StakedAmount: sdk.NewDec(500)},
+		{Address: addrs[2].String(), LiquidAmount: sdk.NewDec(1000), StakedAmount: sdk.ZeroDec()},
+	}
+	lockedByAddr := map[string]sdk.Int{
+		addrs[0].String(): sdk.NewInt(300),
+		// more than the liquid amount
+		addrs[1].String(): sdk.NewInt(400),
+	}
+
+	n := applyVestingLocks(accounts, lockedByAddr)
+
+	assert.Equal(2, n)
+	assert.Equal(sdk.NewDec(700), accounts[0].LiquidAmount)
+	assert.Equal(sdk.NewDec(300), accounts[0].lockedAmount())
+	assert.True(accounts[1].LiquidAmount.IsZero())
+	assert.Equal(sdk.NewDec(100), accounts[1].lockedAmount())
+	assert.Equal(sdk.NewDec(1000), accounts[2].LiquidAmount)
+	assert.Nil(accounts[2].LockedAmount)
+}
+
+func TestDistributionVestingLocks(t *testing.T) {
+	locked := sdk.NewDec(300)
+	newAccounts := func() []Account {
+		return []Account{
+			{
+				Address:      "yes",
+				LiquidAmount: sdk.ZeroDec(),
+				StakedAmount: sdk.NewDec(1000),
+				Vote:         govtypes.NewNonSplitVoteOption(govtypes.OptionYes),
+			},
+			{Address: "liquid", LiquidAmount: sdk.NewDec(500), StakedAmount: sdk.ZeroDec()},
+			{Address: "vesting", LiquidAmount: sdk.NewDec(200), StakedAmount: sdk.ZeroDec(), LockedAmount: &locked},
+		}
+	}
+	tests := []struct {
+		name             string
+		lockedMultiplier sdk.Dec
+		expectedRatio    sdk.Dec
+	}{
+		{
+			name:          "default: liquid multiplier",
+			expectedRatio: sdk.OneDec(),
+		},
+		{
+			name:             "excluded",
+			lockedMultiplier: sdk.ZeroDec(),
+			expectedRatio:    sdk.NewDecWithPrec(4, 1), // 200/500
+		},
+		{
+			name:             "half",
+			lockedMultiplier: sdk.NewDecWithPrec(5, 1),
+			expectedRatio:    sdk.NewDecWithPrec(7, 1), // (200+150)/500
+		},
+	}
+	ref, err := distribution(newAccounts(), defaultDistriParams(), "")
+	require.NoError(t, err)
+	refNonVotersPerc := ref.atone.unstaked.Quo(ref.atone.supply)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			params := defaultDistriParams()
+			params.lockedMultiplier = tt.lockedMultiplier
+
+			airdrop, err := distribution(newAccounts(), params, "")
+
+			require.NoError(err)
+			assert.Equal(locked, airdrop.atom.locked)
+			assert.Equal(sdk.NewDec(2000), airdrop.atom.supply)
+			liquid, vesting := airdrop.addressesDetail[1], airdrop.addressesDetail[2]
+			require.Equal("liquid", liquid.Address)
+			require.Equal("vesting", vesting.Address)
+			ratio := vesting.Total.Quo(liquid.Total)
+			assert.True(tt.expectedRatio.Sub(ratio).Abs().LT(sdk.NewDecWithPrec(1, 12)),
+				"vesting/liquid ratio %s instead of %s", ratio, tt.expectedRatio)
+			assert.Equal(locked, vesting.LockedDetail.AtomAmt)
+			assert.Equal(vesting.Total, vesting.LiquidDetail.AtoneAmt.Add(vesting.LockedDetail.AtoneAmt))
+			// the nonVotersMultiplier is solved for the same share of the
+			// non-voters
+			nonVotersPerc := airdrop.atone.unstaked.Quo(airdrop.atone.supply)
+			assert.True(refNonVotersPerc.Sub(nonVotersPerc).Abs().LT(sdk.NewDecWithPrec(1, 12)),
+				"non-voters share %s instead of %s", nonVotersPerc, refNonVotersPerc)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// Buckets of vote options, which determine the multiplier applied to the
+// $ATOM amount of each vote option:
+// - aligned options get the noVotesMultiplier (No and NoWithVeto for prop848)
+// - opposed options get the yesVotesMultiplier (Yes for prop848)
+// - neutral options get the nonVotersMultiplier (Abstain and DNV for prop848)
+//
+// Whatever its bucket, NoWithVeto always gets the bonus and DNV the malus.
+//
+// If the abstainMultiplier parameter is set, Abstain is moved to the fixed
+// bucket, whatever the vote buckets, and gets the abstainMultiplier.
+const (
+	voteBucketAligned = "aligned"
+	voteBucketOpposed = "opposed"
+	voteBucketNeutral = "neutral"
+	voteBucketFixed   = "fixed"
+)
+
+// voteBuckets maps the vote options to their bucket, govtypes.OptionEmpty
+// being the "did not vote" option.
+type voteBuckets map[govtypes.VoteOption]string
+
+// voteOptionKeys are the keys of the vote options in a vote buckets file, the
+// same used in the audit log multipliers.
+var voteOptionKeys = map[string]govtypes.VoteOption{
+	"yes":        govtypes.OptionYes,
+	"no":         govtypes.OptionNo,
+	"noWithVeto": govtypes.OptionNoWithVeto,
+	"abstain":    govtypes.OptionAbstain,
+	"didNotVote": govtypes.OptionEmpty,
+}
+
+// defaultVoteBuckets returns the prop848 interpretation of the vote options.
+func defaultVoteBuckets() voteBuckets {
+	return voteBuckets{
+		govtypes.OptionYes:        voteBucketOpposed,
+		govtypes.OptionNo:         voteBucketAligned,
+		govtypes.OptionNoWithVeto: voteBucketAligned,
+		govtypes.OptionAbstain:    voteBucketNeutral,
+		govtypes.OptionEmpty:      voteBucketNeutral,
+	}
+}
+
+// parseVoteBuckets reads the vote buckets from a JSON file, for instance to
+// reward Yes instead of No:
+//
+//	{"yes": "aligned", "no": "opposed", "noWithVeto": "opposed"}
+//
+// Missing options keep their default bucket.
+func parseVoteBuckets(path string) (_ voteBuckets, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var m map[string]string
+	if err := json.NewDecoder(f).Decode(&m); err != nil {
+		return nil, fmt.Errorf("cannot json decode vote buckets from file %s: %w", path, err)
+	}
+	buckets := defaultVoteBuckets()
+	for key, bucket := range m {
+		opt, ok := voteOptionKeys[key]
+		if !ok {
+			var err error
+			if opt, err = parseVoteOption(key); err != nil {
+				return nil, fmt.Errorf("unknown vote option '%s' in vote buckets", key)
+			}
+		}
+		if !slices.Contains([]string{voteBucketAligned, voteBucketOpposed, voteBucketNeutral}, bucket) {
+			return nil, fmt.Errorf("unknown vote bucket '%s' for option '%s'", bucket, key)
+		}
+		buckets[opt] = bucket
+	}
+	return buckets, nil
+}
+
+// voteBucket returns the bucket of opt, using the default buckets if d
+// doesn't define any.
+func (d distriParams) voteBucket(opt govtypes.VoteOption) string {
+	if opt == govtypes.OptionAbstain && d.hasAbstainMultiplier() {
+		return voteBucketFixed
+	}
+	if d.voteBuckets == nil {
+		return defaultVoteBuckets()[opt]
+	}
+	return d.voteBuckets[opt]
+}
+
+// voteMultiplier returns the multiplier of the bucket of opt and the bonus or
+// malus applied to opt.
+func (d distriParams) voteMultiplier(opt govtypes.VoteOption, nonVotersMultiplier sdk.Dec) (multiplier, bonusMalus sdk.Dec) {
+	switch d.voteBucket(opt) {
+	case voteBucketAligned:
+		multiplier = d.noVotesMultiplier
+	case voteBucketOpposed:
+		multiplier = d.yesVotesMultiplier
+	case voteBucketFixed:
+		multiplier = d.abstainMultiplier
+	default:
+		multiplier = nonVotersMultiplier
+	}
+	switch {
+	case opt == govtypes.OptionNoWithVeto:
+		bonusMalus = d.bonus
+	case opt == govtypes.OptionEmpty:
+		bonusMalus = d.malus
+	case opt == govtypes.OptionAbstain && !d.abstainBonusMalus.IsNil():
+		bonusMalus = d.abstainBonusMalus
+	default:
+		bonusMalus = sdk.OneDec()
+	}
+	return multiplier, bonusMalus
+}
+
+// hasAbstainMultiplier returns true if params give Abstain its own
+// multiplier, instead of the nonVotersMultiplier shared with DNV.
+func (d distriParams) hasAbstainMultiplier() bool {
+	return !d.abstainMultiplier.IsNil()
+}
+
+// parseAbstainParams parses the abstainMultiplier and abstainBonusMalus
+// flags, an empty value returns a nil sdk.Dec which keeps the default
+// behavior (nonVotersMultiplier and no bonus nor malus).
+func parseAbstainParams(multiplier, bonusMalus string) (sdk.Dec, sdk.Dec, error) {
+	var m, bm sdk.Dec
+	if multiplier != "" {
+		var err error
+		m, err = sdk.NewDecFromStr(multiplier)
+		if err != nil || m.IsNegative() {
+			return m, bm, fmt.Errorf("invalid abstainMultiplier '%s'", multiplier)
+		}
+	}
+	if bonusMalus != "" {
+		var err error
+		bm, err = sdk.NewDecFromStr(bonusMalus)
+		if err != nil || bm.IsNegative() {
+			return m, bm, fmt.Errorf("invalid abstainBonusMalus '%s'", bonusMalus)
+		}
+	}
+	return m, bm, nil
+}
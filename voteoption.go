@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// errUnknownVoteOption is returned for the vote options that match none of
+// the encodings of voteOptionAliases.
+var errUnknownVoteOption = errors.New("unknown vote option")
+
+// voteOptionAliases are the vote options by their normalized name (see
+// lookupVoteOption), for the encodings seen in the exports of the various
+// tools: the proto enum names (VOTE_OPTION_NO_WITH_VETO), the amino and
+// explorers names (NoWithVeto), the CLI names (no_with_veto), the keys of the
+// vote buckets files (noWithVeto) and the proto enum numbers.
+var voteOptionAliases = map[string]govtypes.VoteOption{
+	"UNSPECIFIED": govtypes.OptionEmpty,
+	"EMPTY":       govtypes.OptionEmpty,
+	"0":           govtypes.OptionEmpty,
+	"YES":         govtypes.OptionYes,
+	"1":           govtypes.OptionYes,
+	"ABSTAIN":     govtypes.OptionAbstain,
+	"2":           govtypes.OptionAbstain,
+	"NO":          govtypes.OptionNo,
+	"3":           govtypes.OptionNo,
+	"NOWITHVETO":  govtypes.OptionNoWithVeto,
+	"4":           govtypes.OptionNoWithVeto,
+}
+
+// lookupVoteOption returns the vote option of s in any of the encodings of
+// voteOptionAliases, case insensitive and with or without the VOTE_OPTION_
+// prefix. OptionEmpty is returned for the unspecified option.
+func lookupVoteOption(s string) (govtypes.VoteOption, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	name = strings.TrimPrefix(name, "VOTE_")
+	name = strings.TrimPrefix(name, "OPTION_")
+	name = strings.NewReplacer("_", "", "-", "", " ", "").Replace(name)
+	opt, ok := voteOptionAliases[name]
+	if !ok {
+		return govtypes.OptionEmpty, fmt.Errorf("%w '%s'", errUnknownVoteOption, s)
+	}
+	return opt, nil
+}
+
+// parseVoteOption parses a vote option in any of the encodings of
+// voteOptionAliases, the unspecified option isn't a valid vote.
+func parseVoteOption(s string) (govtypes.VoteOption, error) {
+	opt, err := lookupVoteOption(s)
+	if err != nil {
+		return opt, err
+	}
+	if opt == govtypes.OptionEmpty {
+		return opt, fmt.Errorf("invalid vote option '%s'", s)
+	}
+	return opt, nil
+}
+
+// normalizeVoteJSON rewrites the vote options of the JSON vote bz with their
+// proto enum names, and its numeric weights as strings, so that the votes
+// exported by other tools can be unmarshaled as a govtypes.Vote. The error
+// wraps errUnknownVoteOption if an option can't be normalized.
+func normalizeVoteJSON(bz []byte) ([]byte, error) {
+	var vote map[string]json.RawMessage
+	if err := json.Unmarshal(bz, &vote); err != nil {
+		return nil, err
+	}
+	if raw, ok := vote["option"]; ok {
+		opt, err := normalizeVoteOptionJSON(raw)
+		if err != nil {
+			return nil, err
+		}
+		vote["option"] = opt
+	}
+	if raw, ok := vote["options"]; ok && !bytes.Equal(raw, []byte("null")) {
+		var options []map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &options); err != nil {
+			return nil, err
+		}
+		for _, o := range options {
+			opt, err := normalizeVoteOptionJSON(o["option"])
+			if err != nil {
+				return nil, err
+			}
+			o["option"] = opt
+			if w, ok := o["weight"]; ok && len(w) > 0 && w[0] != '"' {
+				o["weight"], _ = json.Marshal(string(w))
+			}
+		}
+		bz, err := json.Marshal(options)
+		if err != nil {
+			return nil, err
+		}
+		vote["options"] = bz
+	}
+	return json.Marshal(vote)
+}
+
+// normalizeVoteOptionJSON returns the proto enum name of the JSON vote option
+// raw, a string or a number.
+func normalizeVoteOptionJSON(raw json.RawMessage) (json.RawMessage, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		// not a string, keep the number as is
+		s = string(raw)
+	}
+	opt, err := lookupVoteOption(s)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(opt.String())
+}
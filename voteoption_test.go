@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestParseVoteOption(t *testing.T) {
+	tests := []struct {
+		encodings     []string
+		expected      govtypes.VoteOption
+		expectedError string
+	}{
+		{
+			encodings: []string{"VOTE_OPTION_YES", "OPTION_YES", "Yes", "yes", " YES ", "1"},
+			expected:  govtypes.OptionYes,
+		},
+		{
+			encodings: []string{"VOTE_OPTION_ABSTAIN", "Abstain", "abstain", "2"},
+			expected:  govtypes.OptionAbstain,
+		},
+		{
+			encodings: []string{"VOTE_OPTION_NO", "No", "no", "3"},
+			expected:  govtypes.OptionNo,
+		},
+		{
+			encodings: []string{
+				"VOTE_OPTION_NO_WITH_VETO", "OPTION_NO_WITH_VETO", "NoWithVeto", "noWithVeto",
+				"no_with_veto", "no-with-veto", "No With Veto", "4",
+			},
+			expected: govtypes.OptionNoWithVeto,
+		},
+		{
+			encodings:     []string{"VOTE_OPTION_UNSPECIFIED", "Empty", "0"},
+			expectedError: "invalid vote option",
+		},
+		{
+			encodings:     []string{"maybe", "VOTE_OPTION_MAYBE", "5", ""},
+			expectedError: "unknown vote option",
+		},
+	}
+	for _, tt := range tests {
+		for _, s := range tt.encodings {
+			t.Run(s, func(t *testing.T) {
+				opt, err := parseVoteOption(s)
+
+				if tt.expectedError != "" {
+					assert.ErrorContains(t, err, tt.expectedError)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, opt)
+			})
+		}
+	}
+}
+
+func TestParseVotesByAddrEncodings(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	warnings = newWarningsRegistry()
+	datapath := t.TempDir()
+	votes := `[
+	{"proposal_id":"848","voter":"proto","options":[{"option":"VOTE_OPTION_YES","weight":"1.000000000000000000"}]},
+	{"proposal_id":"848","voter":"legacy","option":"VOTE_OPTION_NO"},
+	{"proposal_id":"848","voter":"numeric","options":[{"option":4,"weight":"1"}]},
+	{"proposal_id":"848","voter":"amino","options":[{"option":"NoWithVeto","weight":"0.5"},{"option":"Abstain","weight":0.5}]},
+	{"proposal_id":"848","voter":"cli","option":"no_with_veto"},
+	{"proposal_id":"848","voter":"unknown","options":[{"option":"maybe","weight":"1"}]},
+	{"proposal_id":"848","voter":"keys","option":"noWithVeto"}
+]`
+	require.NoError(os.WriteFile(filepath.Join(datapath, "votes.json"), []byte(votes), 0o600))
+
+	votesByAddr, err := parseVotesByAddr(context.Background(), datapath)
+
+	require.NoError(err)
+	half := sdk.NewDecWithPrec(5, 1)
+	assert.Equal(map[string]govtypes.WeightedVoteOptions{
+		"proto":   govtypes.NewNonSplitVoteOption(govtypes.OptionYes),
+		"legacy":  govtypes.NewNonSplitVoteOption(govtypes.OptionNo),
+		"numeric": govtypes.NewNonSplitVoteOption(govtypes.OptionNoWithVeto),
+		"amino": {
+			{Option: govtypes.OptionNoWithVeto, Weight: half},
+			{Option: govtypes.OptionAbstain, Weight: half},
+		},
+		"cli":  govtypes.NewNonSplitVoteOption(govtypes.OptionNoWithVeto),
+		"keys": govtypes.NewNonSplitVoteOption(govtypes.OptionNoWithVeto),
+	}, votesByAddr)
+	assert.Equal(1, warnings.get(warnVoteUnknownOption))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// Pools that can fund the voter floor allocation.
+const (
+	voterFloorPoolCommunityPool   = "community-pool"
+	voterFloorPoolReservedAddress = "reserved-address"
+
+	policyVoterFloor = "voter-floor"
+)
+
+// voterFloorStats records the top-ups given to the voters whose allocation was
+// below the voter floor.
+type voterFloorStats struct {
+	accounts int
+	// topUp is the total $ATONE added to reach the floor, taken from the
+	// funding pool.
+	topUp sdk.Int
+}
+
+func validateVoterFloorPool(pool string) error {
+	switch pool {
+	case voterFloorPoolCommunityPool, voterFloorPoolReservedAddress:
+		return nil
+	}
+	return fmt.Errorf("unknown voter floor pool '%s'", pool)
+}
+
+// hasVoterFloor returns true if params define a voter floor allocation.
+func (d distriParams) hasVoterFloor() bool {
+	return !d.voterFloor.IsNil() && d.voterFloor.IsPositive()
+}
+
+// votedActive returns true if a voted directly with at least one of the
+// active vote options (Yes, No, NoWithVeto).
+func (a Account) votedActive() bool {
+	return slices.ContainsFunc(a.Vote, func(v govtypes.WeightedVoteOption) bool {
+		return slices.Contains(activeVoteOptions, v.Option) && v.Weight.IsPositive()
+	})
+}
+
+// fundVoterFloor takes the voter floor top-ups from the funding pool.
+func (a *airdrop) fundVoterFloor() error {
+	topUp := a.voterFloor.topUp.ToLegacyDec()
+	pool := &a.communityPool
+	if a.params.voterFloorPool == voterFloorPoolReservedAddress {
+		pool = &a.reservedAddr
+	}
+	if pool.LT(topUp) {
+		return fmt.Errorf("voter floor: %s pool (%s) can't fund the %s top-ups",
+			a.params.voterFloorPool, humand(*pool), human(a.voterFloor.topUp))
+	}
+	*pool = pool.Sub(topUp)
+	return nil
+}
+
+func reportVoterFloor(sec *reportSection, airdrop airdrop) {
+	sec.text("Voter floor of %s $ATONE: %d voters topped up with a total of %s $ATONE taken from the %s",
+		human(airdrop.params.voterFloor), airdrop.voterFloor.accounts,
+		human(airdrop.voterFloor.topUp), airdrop.params.voterFloorPool)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"iter"
+
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// voteOptionNames are the names of the vote options in the exported datasets,
+// the same as in the vote buckets files.
+var voteOptionNames = func() map[govtypes.VoteOption]string {
+	m := make(map[govtypes.VoteOption]string, len(voteOptionKeys))
+	for name, opt := range voteOptionKeys {
+		m[opt] = name
+	}
+	return m
+}()
+
+// Sources of a vote in the long format dataset.
+const (
+	voteSourceDirect    = "direct"
+	voteSourceInherited = "inherited"
+	voteSourceNone      = "none"
+)
+
+// writeVotesLong writes in file the votes of the accounts in a long (tidy)
+// CSV format: one row per account and vote option with a non-zero weight,
+// with the staked amount that went to that option. Accounts without staked
+// amount are skipped. It returns the number of rows written.
+func writeVotesLong(ctx context.Context, file string, accounts iter.Seq[Account]) (int, error) {
+	var rows int
+	err := writeOutputFile(ctx, file, func(out io.Writer) error {
+		w := csv.NewWriter(out)
+		w.Write([]string{"address", "account_type", "vote_option", "source", "weight", "staked_amount_for_option"})
+		for acc := range accounts {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if acc.StakedAmount.IsZero() {
+				continue
+			}
+			voteWeights := acc.voteWeights()
+			for _, opt := range allVoteOptions {
+				weight := voteWeights[opt]
+				if weight.IsZero() {
+					continue
+				}
+				source := voteSourceInherited
+				switch {
+				case len(acc.Vote) > 0:
+					source = voteSourceDirect
+				case opt == govtypes.OptionEmpty:
+					source = voteSourceNone
+				}
+				w.Write([]string{
+					acc.Address, acc.Type, voteOptionNames[opt], source,
+					weight.String(), weight.Mul(acc.StakedAmount).String(),
+				})
+				rows++
+			}
+		}
+		w.Flush()
+		return w.Error()
+	})
+	return rows, err
+}
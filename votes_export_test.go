@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestWriteVotesLong(t *testing.T) {
+	require := require.New(t)
+	accounts := []Account{
+		{
+			Address: "direct", Type: "base", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(10),
+			Vote: govtypes.WeightedVoteOptions{
+				{Option: govtypes.OptionYes, Weight: sdk.NewDecWithPrec(7, 1)},
+				{Option: govtypes.OptionNo, Weight: sdk.NewDecWithPrec(3, 1)},
+			},
+		},
+		{
+			Address: "inherited", Type: "base", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(4),
+			Delegations: []Delegation{
+				{Amount: sdk.NewDec(1), Vote: govtypes.NewNonSplitVoteOption(govtypes.OptionAbstain)},
+				{Amount: sdk.NewDec(3)},
+			},
+		},
+		{Address: "liquid", Type: "base", LiquidAmount: sdk.NewDec(1), StakedAmount: sdk.ZeroDec()},
+	}
+	file := filepath.Join(t.TempDir(), "votes_long.csv")
+
+	rows, err := writeVotesLong(context.Background(), file, slices.Values(accounts))
+
+	require.NoError(err)
+	assert.Equal(t, 4, rows)
+	bz, err := os.ReadFile(file)
+	require.NoError(err)
+	assert.Equal(t, `address,account_type,vote_option,source,weight,staked_amount_for_option
+direct,base,yes,direct,0.700000000000000000,7.000000000000000000
+direct,base,no,direct,0.300000000000000000,3.000000000000000000
+inherited,base,didNotVote,none,0.750000000000000000,3.000000000000000000
+inherited,base,abstain,inherited,0.250000000000000000,1.000000000000000000
+`, string(bz))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// voteSource provides the votes taken into account by the distribution, keyed
+// by voter address.
+type voteSource interface {
+	votes(ctx context.Context) (map[string]govtypes.WeightedVoteOptions, error)
+}
+
+// govVoteSource is the voteSource of the on-chain x/gov votes, read from
+// <datapath>/votes.json.
+type govVoteSource struct {
+	datapath string
+	// duplicates is the policy for the voters with several votes, empty means
+	// duplicateVotesKeepLast.
+	duplicates string
+}
+
+func (s govVoteSource) votes(ctx context.Context) (map[string]govtypes.WeightedVoteOptions, error) {
+	if s.duplicates == "" {
+		return parseVotesByAddr(ctx, s.datapath)
+	}
+	return parseVotesByAddrDuplicates(ctx, s.datapath, s.duplicates)
+}
+
+// signalVoteSource is the voteSource of off-chain signal votes, e.g. collected
+// by a snapshot-style voting page, provided as a CSV file with the header:
+//
+//	voter,option,pubkey,signature
+//
+// option is a vote option (yes, no, abstain, no_with_veto or any of the
+// encodings of voteOptionAliases), pubkey the base64 compressed secp256k1
+// public key of the voter, and signature the base64 ADR-036 signature (the
+// signArbitrary of the wallets) by the voter of the data returned by
+// signalVoteData.
+//
+// The rows with an invalid signature, or whose pubkey doesn't match the voter
+// address, are skipped and reported in the warnings. The voter addresses are
+// returned with the cosmos prefix, like the on-chain votes.
+type signalVoteSource struct {
+	file string
+	// signal identifies the off-chain vote, it's part of the signed data so
+	// a signature can't be replayed across signals.
+	signal string
+}
+
+// signalVoteData returns the data signed by the voter of an off-chain signal
+// vote.
+func signalVoteData(signal string, option govtypes.VoteOption) []byte {
+	return []byte(fmt.Sprintf("%s:%s", signal, option))
+}
+
+// adr036SignBytes returns the bytes signed by a wallet for data with the
+// ADR-036 signArbitrary method.
+func adr036SignBytes(signer string, data []byte) []byte {
+	doc := fmt.Sprintf(`{"account_number":"0","chain_id":"","fee":{"amount":[],"gas":"0"},"memo":"","msgs":[{"type":"sign/MsgSignData","value":{"data":"%s","signer":"%s"}}],"sequence":"0"}`,
+		base64.StdEncoding.EncodeToString(data), signer)
+	return sdk.MustSortJSON([]byte(doc))
+}
+
+func (s signalVoteSource) votes(ctx context.Context) (_ map[string]govtypes.WeightedVoteOptions, err error) {
+	if s.signal == "" {
+		return nil, errors.New("off-chain signal votes require a signal identifier")
+	}
+	f, err := openInput(ctx, s.file)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 4
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("signal votes %s: %w", s.file, err)
+	}
+	if strings.Join(header, ",") != "voter,option,pubkey,signature" {
+		return nil, fmt.Errorf("signal votes %s: unexpected header '%s'", s.file, strings.Join(header, ","))
+	}
+	votesByAddr := make(map[string]govtypes.WeightedVoteOptions)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("signal votes %s: %w", s.file, err)
+		}
+		voter, option, err := verifySignalVote(s.signal, rec[0], rec[1], rec[2], rec[3])
+		if err != nil {
+			warnings.add(warnSignalInvalid, fmt.Sprintf("%s: %v", rec[0], err))
+			continue
+		}
+		votesByAddr[voter.bech32("cosmos")] = govtypes.NewNonSplitVoteOption(option)
+	}
+	fmt.Printf("%s off-chain signal votes\n", humanCount(len(votesByAddr)))
+	return votesByAddr, nil
+}
+
+// verifySignalVote checks that signature is a valid signature by pubkey of
+// the vote option of voter for signal, and that pubkey is the key of voter.
+func verifySignalVote(signal, voter, option, pubkey, signature string) (addrKey, govtypes.VoteOption, error) {
+	key, err := newAddrKey(voter)
+	if err != nil {
+		return "", 0, err
+	}
+	opt, err := parseVoteOption(option)
+	if err != nil {
+		return "", 0, err
+	}
+	pkBz, err := base64.StdEncoding.DecodeString(pubkey)
+	if err != nil || len(pkBz) != secp256k1.PubKeySize {
+		return "", 0, fmt.Errorf("invalid pubkey '%s'", pubkey)
+	}
+	pk := &secp256k1.PubKey{Key: pkBz}
+	if addrKey(pk.Address()) != key {
+		return "", 0, errors.New("pubkey doesn't match the voter address")
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if !pk.VerifySignature(adr036SignBytes(voter, signalVoteData(signal, opt)), sig) {
+		return "", 0, errors.New("invalid signature")
+	}
+	return key, opt, nil
+}
+
+// collectVotes returns the votes of sources merged together. An address with
+// votes in several sources keeps the vote of the first one, e.g. the on-chain
+// vote takes precedence over an off-chain signal if the gov source is first;
+// the overridden votes are reported in the warnings.
+func collectVotes(ctx context.Context, sources ...voteSource) (map[string]govtypes.WeightedVoteOptions, error) {
+	var (
+		votesByAddr = make(map[string]govtypes.WeightedVoteOptions)
+		seen        = make(map[addrKey]bool)
+	)
+	for _, src := range sources {
+		votes, err := src.votes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		keys := make(map[addrKey]bool, len(votes))
+		for _, addr := range slices.Sorted(maps.Keys(votes)) {
+			options := votes[addr]
+			// undecodable addresses are kept as is, like parseVotesByAddr
+			key, err := newAddrKey(addr)
+			if err == nil {
+				if seen[key] {
+					warnings.add(warnVoteOverridden, addr)
+					continue
+				}
+				keys[key] = true
+			}
+			votesByAddr[addr] = options
+		}
+		maps.Copy(seen, keys)
+	}
+	return votesByAddr, nil
+}
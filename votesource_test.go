@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// signalVoteRow returns a CSV row of an off-chain signal vote of option for
+// signal, signed by key.
+func signalVoteRow(t *testing.T, key *secp256k1.PrivKey, prefix, signal, option string) string {
+	t.Helper()
+	voter := sdk.MustBech32ifyAddressBytes(prefix, key.PubKey().Address())
+	opt, err := parseVoteOption(option)
+	require.NoError(t, err)
+	sig, err := key.Sign(adr036SignBytes(voter, signalVoteData(signal, opt)))
+	require.NoError(t, err)
+	return fmt.Sprintf("%s,%s,%s,%s", voter, option,
+		base64.StdEncoding.EncodeToString(key.PubKey().Bytes()), base64.StdEncoding.EncodeToString(sig))
+}
+
+// mapVoteSource is a voteSource of fixed votes.
+type mapVoteSource map[string]govtypes.WeightedVoteOptions
+
+func (s mapVoteSource) votes(context.Context) (map[string]govtypes.WeightedVoteOptions, error) {
+	return s, nil
+}
+
+func TestSignalVoteSource(t *testing.T) {
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	warnings = newWarningsRegistry()
+	require := require.New(t)
+	assert := assert.New(t)
+	keys := []*secp256k1.PrivKey{secp256k1.GenPrivKey(), secp256k1.GenPrivKey(), secp256k1.GenPrivKey(), secp256k1.GenPrivKey()}
+	addr := func(i int) string { return sdk.MustBech32ifyAddressBytes("cosmos", keys[i].PubKey().Address()) }
+	// row of keys[3] with the pubkey of keys[2]
+	mismatch := strings.SplitN(signalVoteRow(t, keys[3], "cosmos", "prop848", "no"), ",", 3)
+	mismatch[2] = strings.SplitN(signalVoteRow(t, keys[2], "cosmos", "prop848", "no"), ",", 3)[2]
+	rows := []string{
+		"voter,option,pubkey,signature",
+		signalVoteRow(t, keys[0], "cosmos", "prop848", "yes"),
+		// other prefix, returned with the cosmos prefix
+		signalVoteRow(t, keys[1], "atone", "prop848", "VOTE_OPTION_NO_WITH_VETO"),
+		// signed for another signal
+		signalVoteRow(t, keys[2], "cosmos", "prop849", "abstain"),
+		strings.Join(mismatch, ","),
+	}
+	file := filepath.Join(t.TempDir(), "signals.csv")
+	require.NoError(os.WriteFile(file, []byte(strings.Join(rows, "\n")), 0o600))
+
+	votes, err := signalVoteSource{file: file, signal: "prop848"}.votes(context.Background())
+
+	require.NoError(err)
+	assert.Equal(map[string]govtypes.WeightedVoteOptions{
+		addr(0): govtypes.NewNonSplitVoteOption(govtypes.OptionYes),
+		addr(1): govtypes.NewNonSplitVoteOption(govtypes.OptionNoWithVeto),
+	}, votes)
+	assert.Equal(2, warnings.get(warnSignalInvalid))
+
+	_, err = signalVoteSource{file: file}.votes(context.Background())
+	assert.ErrorContains(err, "signal identifier")
+}
+
+func TestCollectVotes(t *testing.T) {
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	warnings = newWarningsRegistry()
+	require := require.New(t)
+	assert := assert.New(t)
+	addrs := createAccountAddrs(3)
+	var (
+		yes     = govtypes.NewNonSplitVoteOption(govtypes.OptionYes)
+		no      = govtypes.NewNonSplitVoteOption(govtypes.OptionNo)
+		onChain = mapVoteSource{
+			addrs[0].String(): yes,
+			addrs[1].String(): yes,
+		}
+		offChain = mapVoteSource{
+			// same address with another prefix
+			sdk.MustBech32ifyAddressBytes("atone", addrs[1]): no,
+			addrs[2].String(): no,
+		}
+	)
+
+	votes, err := collectVotes(context.Background(), onChain, offChain)
+
+	require.NoError(err)
+	assert.Equal(map[string]govtypes.WeightedVoteOptions{
+		addrs[0].String(): yes,
+		addrs[1].String(): yes,
+		addrs[2].String(): no,
+	}, votes)
+	assert.Equal(1, warnings.get(warnVoteOverridden))
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Decay curves of the vote time bonus.
+const (
+	// voteTimeDecayLinear decreases the bonus linearly from the start to the
+	// end of the voting period.
+	voteTimeDecayLinear = "linear"
+	// voteTimeDecayExponential halves the bonus every half-life since the
+	// start of the voting period.
+	voteTimeDecayExponential = "exponential"
+)
+
+// voteTiming rewards the early voters over the last-minute voters: the
+// allocation of a direct voter is multiplied by 1+bonus x decay, where decay
+// goes from 1 at the start of the voting period down to 0 (linear) or close
+// to 0 (exponential) at its end.
+type voteTiming struct {
+	// times holds the time of the last vote tx per voter address.
+	times    map[string]time.Time
+	start    time.Time
+	end      time.Time
+	decay    string
+	bonus    sdk.Dec
+	halfLife time.Duration
+}
+
+// voteTimeStats records the aggregate effect of the vote timing.
+type voteTimeStats struct {
+	// accounts is the number of voters with a vote time.
+	accounts int
+	// bonus is the total $ATONE added by the vote time multipliers.
+	bonus sdk.Dec
+	// maxMultiplier and minMultiplier are the extreme multipliers applied.
+	maxMultiplier sdk.Dec
+	minMultiplier sdk.Dec
+}
+
+func newVoteTimeStats() voteTimeStats {
+	return voteTimeStats{bonus: sdk.ZeroDec(), maxMultiplier: sdk.OneDec(), minMultiplier: sdk.OneDec()}
+}
+
+// voteTimeEntry is an entry of the indexer export of vote txs.
+type voteTimeEntry struct {
+	Voter     string    `json:"voter"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// parseVoteTimes reads the vote txs timestamps from an indexer export, a JSON
+// array like [{"voter": "cosmos1...", "timestamp": "2023-11-12T10:00:00Z"}].
+// Only the last vote tx of a voter is kept, since it's the one that counts.
+func parseVoteTimes(path string) (_ map[string]time.Time, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var entries []voteTimeEntry
+	if err := json.NewDecoder(f).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("cannot json decode vote times from file %s: %w", path, err)
+	}
+	times := make(map[string]time.Time, len(entries))
+	for _, e := range entries {
+		if t, ok := times[e.Voter]; !ok || e.Timestamp.After(t) {
+			times[e.Voter] = e.Timestamp
+		}
+	}
+	return times, nil
+}
+
+// newVoteTiming returns the voteTiming of times over the voting period
+// [start,end]. If start or end are zero, they default to the first and the
+// last vote times.
+func newVoteTiming(times map[string]time.Time, start, end time.Time, decay string, bonus sdk.Dec, halfLife time.Duration) (*voteTiming, error) {
+	switch decay {
+	case voteTimeDecayLinear:
+	case voteTimeDecayExponential:
+		if halfLife <= 0 {
+			return nil, fmt.Errorf("vote time decay %s requires a positive half-life", decay)
+		}
+	default:
+		return nil, fmt.Errorf("unknown vote time decay '%s'", decay)
+	}
+	if bonus.IsNil() || bonus.IsNegative() {
+		return nil, fmt.Errorf("vote time bonus must be positive")
+	}
+	for _, t := range times {
+		if start.IsZero() || t.Before(start) {
+			start = t
+		}
+		if end.IsZero() || t.After(end) {
+			end = t
+		}
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("vote time: empty voting period %s - %s", start, end)
+	}
+	return &voteTiming{
+		times:    times,
+		start:    start,
+		end:      end,
+		decay:    decay,
+		bonus:    bonus,
+		halfLife: halfLife,
+	}, nil
+}
+
+// loadVoteTiming returns the voteTiming of the vote times of file, over the
+// voting period of <datapath>/prop.json, or over the vote times if there's no
+// prop.json.
+func loadVoteTiming(datapath, file, decay, bonus string, halfLife time.Duration) (*voteTiming, error) {
+	times, err := parseVoteTimes(file)
+	if err != nil {
+		return nil, err
+	}
+	b, err := sdk.NewDecFromStr(bonus)
+	if err != nil {
+		return nil, fmt.Errorf("invalid voteTimeBonus '%s': %w", bonus, err)
+	}
+	var start, end time.Time
+	prop, err := parseProp(datapath)
+	switch {
+	case err == nil:
+		start, end = prop.VotingStartTime, prop.VotingEndTime
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+	return newVoteTiming(times, start, end, decay, b, halfLife)
+}
+
+// multiplier returns the vote time multiplier of addr, and false if addr has
+// no vote time.
+func (v *voteTiming) multiplier(addr string) (sdk.Dec, bool) {
+	t, ok := v.times[addr]
+	if !ok {
+		return sdk.OneDec(), false
+	}
+	elapsed := min(max(t.Sub(v.start), 0), v.end.Sub(v.start))
+	var decay float64
+	switch v.decay {
+	case voteTimeDecayLinear:
+		decay = 1 - float64(elapsed)/float64(v.end.Sub(v.start))
+	case voteTimeDecayExponential:
+		decay = math.Pow(0.5, float64(elapsed)/float64(v.halfLife))
+	}
+	// 6 decimals are enough precision for a multiplier
+	d := sdk.NewDecWithPrec(int64(math.Round(decay*1e6)), 6)
+	return sdk.OneDec().Add(v.bonus.Mul(d)), true
+}
+
+// add records the multiplier mult, which turned amt into multipliedAmt.
+func (s *voteTimeStats) add(mult, amt, multipliedAmt sdk.Dec) {
+	s.accounts++
+	s.bonus = s.bonus.Add(multipliedAmt.Sub(amt))
+	if mult.GT(s.maxMultiplier) {
+		s.maxMultiplier = mult
+	}
+	if s.accounts == 1 || mult.LT(s.minMultiplier) {
+		s.minMultiplier = mult
+	}
+}
+
+func reportVoteTiming(sec *reportSection, airdrop airdrop) {
+	var (
+		v = airdrop.params.voteTiming
+		s = airdrop.voteTime
+	)
+	curve := v.decay
+	if v.decay == voteTimeDecayExponential {
+		curve += fmt.Sprintf(" (half-life %s)", v.halfLife)
+	}
+	sec.text("Vote time bonus of up to %s, %s decay from %s to %s", humanPercent(v.bonus), curve,
+		v.start.UTC().Format(time.RFC3339), v.end.UTC().Format(time.RFC3339))
+	table := sec.table("VOTERS", "MIN MULTIPLIER", "MAX MULTIPLIER", "$ATONE ADDED", "SHARE OF DISTRIBUTED")
+	share := sdk.ZeroDec()
+	if airdrop.atone.supply.IsPositive() {
+		share = s.bonus.Quo(airdrop.atone.supply)
+	}
+	table.Append([]string{
+		humanCount(s.accounts),
+		s.minMultiplier.String(),
+		s.maxMultiplier.String(),
+		humand(s.bonus),
+		humanPercent(share),
+	})
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxWarningExamples is the number of examples kept per kind of warning.
+const maxWarningExamples = 3
+
+// Kinds of warnings.
+const (
+	warnVoteUnknownAddr  = "vote from an address without balance nor delegation"
+	warnDelegInactiveVal = "delegation to a validator out of the active set"
+	warnBalanceNoAuth    = "balance of an address without auth account"
+	warnDelegatorNoAuth  = "delegator without auth account"
+	// warnVestingBaseDuplicate is a vesting account also present as a plain
+	// account, e.g. its embedded BaseAccount.
+	warnVestingBaseDuplicate = "vesting account duplicated by its base account"
+	warnAccountDuplicate     = "duplicate auth account"
+	warnBalanceDuplicate     = "duplicate balance"
+	warnUndecodableTx        = "tx that can't be decoded"
+	warnInvalidAddr          = "invalid bech32 address"
+	warnNoChecksum           = "input URL without .sha256 checksum"
+	// warnPrefixDuplicate is an account present in the inputs with different
+	// bech32 prefixes.
+	warnPrefixDuplicate = "same address with different prefixes"
+	warnSignalInvalid   = "off-chain signal vote with an invalid signature"
+	// warnVoteOverridden is a vote ignored because the address has already
+	// voted in a preceding vote source, e.g. on-chain.
+	warnVoteOverridden = "vote overridden by a preceding vote source"
+	// warnVoteUnknownOption is a vote skipped because one of its options
+	// matches none of the known encodings.
+	warnVoteUnknownOption = "vote with an unknown option"
+	// warnVoteDuplicate is a voter with several entries in votes.json, only
+	// one of which is kept according to -duplicateVotes.
+	warnVoteDuplicate = "duplicate vote"
+	// warnUnreconciled is a distribution whose output addresses don't match
+	// its written allocations (see newReconciliation).
+	warnUnreconciled = "output addresses not reconciled with the allocations"
+	// warnTallyOutcome is a proposal whose final status in prop.json differs
+	// from the outcome of the computed tally with the gov params (see
+	// turnoutCheck).
+	warnTallyOutcome = "computed tally outcome different from the proposal status"
+)
+
+// Severities of the warnings, in increasing order.
+const (
+	severityInfo    = "info"
+	severityWarning = "warning"
+	severityError   = "error"
+)
+
+var severityLevels = []string{severityInfo, severityWarning, severityError}
+
+// warningSeverities are the severities of the kinds of warnings, the ones
+// missing are severityWarning. The errors are inconsistencies of the inputs
+// that likely change the allocations.
+var warningSeverities = map[string]string{
+	warnDelegInactiveVal:     severityInfo,
+	warnVestingBaseDuplicate: severityInfo,
+	warnVoteOverridden:       severityInfo,
+	warnAccountDuplicate:     severityError,
+	warnBalanceDuplicate:     severityError,
+	warnInvalidAddr:          severityError,
+	warnVoteUnknownOption:    severityError,
+	warnUnreconciled:         severityError,
+}
+
+func warningSeverity(kind string) string {
+	if s, ok := warningSeverities[kind]; ok {
+		return s
+	}
+	return severityWarning
+}
+
+// parseSeverity checks that s is one of the severityLevels.
+func parseSeverity(s string) (string, error) {
+	if !slices.Contains(severityLevels, s) {
+		return "", fmt.Errorf("invalid severity '%s' (expected %s)", s, strings.Join(severityLevels, ", "))
+	}
+	return s, nil
+}
+
+// warningsRegistry collects the anomalies met during a run, which would
+// otherwise be silently ignored, with their count and a few examples.
+type warningsRegistry struct {
+	mu sync.Mutex
+	// kinds holds the kinds of warning in the order they were first met.
+	kinds    []string
+	count    map[string]int
+	examples map[string][]string
+}
+
+// warnings is the registry of the current run, printed at the end of the run.
+var warnings = newWarningsRegistry()
+
+func newWarningsRegistry() *warningsRegistry {
+	return &warningsRegistry{
+		count:    make(map[string]int),
+		examples: make(map[string][]string),
+	}
+}
+
+// add records a warning of kind, example identifies the culprit (address...).
+func (r *warningsRegistry) add(kind, example string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.count[kind]; !ok {
+		r.kinds = append(r.kinds, kind)
+	}
+	r.count[kind]++
+	if len(r.examples[kind]) < maxWarningExamples && !slices.Contains(r.examples[kind], example) {
+		r.examples[kind] = append(r.examples[kind], example)
+	}
+}
+
+// get returns the count of warnings of kind.
+func (r *warningsRegistry) get(kind string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count[kind]
+}
+
+func (r *warningsRegistry) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.kinds)
+}
+
+// print writes the summary of the warnings into w, nothing if there's none.
+func (r *warningsRegistry) print(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.kinds) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nWarnings")
+	table := newMarkdownTableWriter(w, "WARNING", "SEVERITY", "COUNT", "EXAMPLES")
+	table.SetAutoWrapText(false)
+	for _, kind := range r.kinds {
+		examples := strings.Join(r.examples[kind], ", ")
+		if r.count[kind] > len(r.examples[kind]) {
+			examples += ", ..."
+		}
+		table.Append([]string{kind, warningSeverity(kind), humanCount(r.count[kind]), examples})
+	}
+	table.Render()
+}
+
+// warningLine is a warning kind printed by printJSON.
+type warningLine struct {
+	Warning  string   `json:"warning"`
+	Severity string   `json:"severity"`
+	Count    int      `json:"count"`
+	Examples []string `json:"examples"`
+}
+
+// printJSON writes the warnings into w as one JSON object per line, for the
+// CI logs.
+func (r *warningsRegistry) printJSON(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	enc := json.NewEncoder(w)
+	for _, kind := range r.kinds {
+		if err := enc.Encode(warningLine{
+			Warning:  kind,
+			Severity: warningSeverity(kind),
+			Count:    r.count[kind],
+			Examples: r.examples[kind],
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reaches returns the kinds of the warnings of severity or above.
+func (r *warningsRegistry) reaches(severity string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	level := slices.Index(severityLevels, severity)
+	var kinds []string
+	for _, kind := range r.kinds {
+		if slices.Index(severityLevels, warningSeverity(kind)) >= level {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
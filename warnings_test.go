@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+func TestGetAccountsWarnings(t *testing.T) {
+	assert := assert.New(t)
+	defer func(w *warningsRegistry) { warnings = w }(warnings)
+	warnings = newWarningsRegistry()
+	var (
+		accAddrs = createAccountAddrs(4)
+		valAddr  = createValidatorAddrs(1)[0].String()
+		voteYes  = govtypes.WeightedVoteOptions{{Option: govtypes.OptionYes, Weight: sdk.NewDec(1)}}
+	)
+
+	_, err := getAccounts(
+		context.Background(),
+		map[string][]stakingtypes.Delegation{
+			accAddrs[0].String(): {{DelegatorAddress: accAddrs[0].String(), ValidatorAddress: valAddr, Shares: sdk.NewDec(1)}},
+			accAddrs[1].String(): {{DelegatorAddress: accAddrs[1].String(), ValidatorAddress: valAddr, Shares: sdk.NewDec(1)}},
+		},
+		map[string]govtypes.WeightedVoteOptions{accAddrs[3].String(): voteYes},
+		map[string]govtypes.ValidatorGovInfo{},
+		map[string]sdk.Coin{accAddrs[2].String(): sdk.NewInt64Coin("uatom", 1)},
+		map[string]string{accAddrs[0].String(): "type", accAddrs[1].String(): "type"},
+		"cosmos",
+	)
+
+	require.NoError(t, err)
+	assert.Equal(2, warnings.get(warnDelegInactiveVal))
+	assert.Equal(1, warnings.get(warnBalanceNoAuth))
+	assert.Equal(1, warnings.get(warnVoteUnknownAddr))
+	assert.Zero(warnings.get(warnDelegatorNoAuth))
+	var buf bytes.Buffer
+	warnings.print(&buf)
+	assert.Contains(buf.String(), warnDelegInactiveVal)
+	assert.Contains(buf.String(), valAddr+", ...")
+}
+
+func TestWarningsRegistry(t *testing.T) {
+	assert := assert.New(t)
+	r := newWarningsRegistry()
+	var buf bytes.Buffer
+	r.print(&buf)
+	assert.Empty(buf.String())
+
+	for _, ex := range []string{"a", "b", "a", "c", "d"} {
+		r.add("kind", ex)
+	}
+
+	assert.Equal(5, r.get("kind"))
+	assert.Equal(1, r.len())
+	r.print(&buf)
+	assert.Contains(buf.String(), "a, b, c, ...")
+}
+
+func TestWarningsSeverity(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	r := newWarningsRegistry()
+	r.add(warnVoteOverridden, "a")
+	r.add(warnNoChecksum, "https://example.com/a.json")
+	r.add(warnInvalidAddr, "b")
+	r.add(warnInvalidAddr, "c")
+
+	var buf bytes.Buffer
+	require.NoError(r.printJSON(&buf))
+
+	assert.Equal(`{"warning":"vote overridden by a preceding vote source","severity":"info","count":1,"examples":["a"]}
+{"warning":"input URL without .sha256 checksum","severity":"warning","count":1,"examples":["https://example.com/a.json"]}
+{"warning":"invalid bech32 address","severity":"error","count":2,"examples":["b","c"]}
+`, buf.String())
+	assert.Equal([]string{warnVoteOverridden, warnNoChecksum, warnInvalidAddr}, r.reaches(severityInfo))
+	assert.Equal([]string{warnNoChecksum, warnInvalidAddr}, r.reaches(severityWarning))
+	assert.Equal([]string{warnInvalidAddr}, r.reaches(severityError))
+	assert.Empty(newWarningsRegistry().reaches(severityInfo))
+	_, err := parseSeverity("fatal")
+	assert.EqualError(err, "invalid severity 'fatal' (expected info, warning, error)")
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const policyWithholding = "withholding"
+
+// withholdingModule is the extension point of the jurisdiction-specific
+// rules, e.g. a tax withheld from the allocation of the corporates. It's
+// invoked for each address with a final allocation, after all the policies of
+// the distribution, and returns the part of the allocation to withhold.
+// The withheld amounts are sent to the escrow address and recorded in the
+// withholding ledger, the distribution math is unchanged.
+type withholdingModule interface {
+	// jurisdiction names the rules, for the ledger.
+	jurisdiction() string
+	// escrowAddress receives the withheld amounts.
+	escrowAddress() string
+	withhold(withholdingRequest) (withholdingDecision, error)
+}
+
+// withholdingRequest describes an address and its final allocation.
+type withholdingRequest struct {
+	Address string
+	Type    string
+	Amount  sdk.Int
+}
+
+// withholdingDecision is the part of the allocation withheld, a zero or nil
+// Amount withholds nothing.
+type withholdingDecision struct {
+	Amount sdk.Int
+	// Reason is recorded in the ledger and in the audit log.
+	Reason string
+}
+
+// withholdingEntry is a line of the withholding ledger.
+type withholdingEntry struct {
+	Address      string  `json:"address"`
+	Jurisdiction string  `json:"jurisdiction"`
+	Reason       string  `json:"reason,omitempty"`
+	Gross        sdk.Int `json:"gross"`
+	Withheld     sdk.Int `json:"withheld"`
+	Net          sdk.Int `json:"net"`
+}
+
+// withholdingLedger is the withholding-ledger output of the distribution.
+type withholdingLedger struct {
+	Jurisdiction  string             `json:"jurisdiction"`
+	EscrowAddress string             `json:"escrowAddress"`
+	Total         sdk.Int            `json:"total"`
+	Entries       []withholdingEntry `json:"entries"`
+}
+
+// totalWithheld returns the sum of the withheld amounts of the ledger.
+func (a airdrop) totalWithheld() sdk.Int {
+	total := sdk.ZeroInt()
+	for _, e := range a.withholdings {
+		total = total.Add(e.Withheld)
+	}
+	return total
+}
+
+// withhold applies params.withholding to the final allocation amt of
+// address, and returns the net amount. The decision is recorded in the ledger
+// of a.
+func (a *airdrop) withhold(params distriParams, address, accType string, amt sdk.Int) (sdk.Int, *withholdingEntry, error) {
+	d, err := params.withholding.withhold(withholdingRequest{Address: address, Type: accType, Amount: amt})
+	if err != nil {
+		return amt, nil, fmt.Errorf("withholding: %s: %w", address, err)
+	}
+	if d.Amount.IsNil() || d.Amount.IsZero() {
+		return amt, nil, nil
+	}
+	if d.Amount.IsNegative() || d.Amount.GT(amt) {
+		return amt, nil, fmt.Errorf("withholding: %s: withheld amount %s out of the allocation %s", address, d.Amount, amt)
+	}
+	e := withholdingEntry{
+		Address:      address,
+		Jurisdiction: params.withholding.jurisdiction(),
+		Reason:       d.Reason,
+		Gross:        amt,
+		Withheld:     d.Amount,
+		Net:          amt.Sub(d.Amount),
+	}
+	a.withholdings = append(a.withholdings, e)
+	return e.Net, &e, nil
+}
+
+// creditEscrow credits the withheld amounts of the ledger to the escrow
+// address, with prefix if not empty, and returns it. The credit is sent to
+// stream if the results are streamed, summed with the allocation of the
+// escrow address if it's also a recipient.
+func (a *airdrop) creditEscrow(params distriParams, prefix string, stream func(addressResult) error) (string, error) {
+	total := a.totalWithheld()
+	if a.streamed && !a.escrowAllocation.IsNil() {
+		total = total.Add(a.escrowAllocation)
+	}
+	addr := params.withholding.escrowAddress()
+	if total.IsZero() {
+		return addr, nil
+	}
+	if prefix != "" {
+		key, err := newAddrKey(addr)
+		if err != nil {
+			return addr, fmt.Errorf("withholding escrow address: %w", err)
+		}
+		addr = key.bech32(prefix)
+	}
+	if a.streamed {
+		return addr, a.addResult(stream, addr, total)
+	}
+	if amt, ok := a.addresses[addr]; ok {
+		a.addresses[addr] = amt.Add(total)
+	} else {
+		a.addresses[addr] = total
+	}
+	return addr, nil
+}
+
+// listWithholding is the withholdingModule of a list of addresses, e.g. the
+// corporates identified in a jurisdiction, each with its withholding rate.
+type listWithholding struct {
+	Jurisdiction string `json:"jurisdiction"`
+	Escrow       string `json:"escrowAddress"`
+	Entries      []struct {
+		Address string  `json:"address"`
+		Rate    sdk.Dec `json:"rate"`
+		Reason  string  `json:"reason"`
+	} `json:"entries"`
+	// rates are the entries by address key, whatever the bech32 prefix.
+	rates map[addrKey]int
+}
+
+// parseListWithholding reads a listWithholding from a JSON file:
+//
+//	{
+//	  "jurisdiction": "XX",
+//	  "escrowAddress": "cosmos1...",
+//	  "entries": [{"address": "cosmos1...", "rate": "0.3", "reason": "corporate"}]
+//	}
+func parseListWithholding(path string) (_ *listWithholding, err error) {
+	f, err := openInput(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput(f, &err)
+	var w listWithholding
+	if err := json.NewDecoder(f).Decode(&w); err != nil {
+		return nil, fmt.Errorf("cannot json decode withholding from file %s: %w", path, err)
+	}
+	if w.Jurisdiction == "" {
+		return nil, fmt.Errorf("withholding %s: missing jurisdiction", path)
+	}
+	if _, err := newAddrKey(w.Escrow); err != nil {
+		return nil, fmt.Errorf("withholding %s: invalid escrow address '%s': %w", path, w.Escrow, err)
+	}
+	w.rates = make(map[addrKey]int, len(w.Entries))
+	for i, e := range w.Entries {
+		if e.Rate.IsNil() || e.Rate.IsNegative() || e.Rate.GT(sdk.OneDec()) {
+			return nil, fmt.Errorf("withholding %s: invalid rate '%s' for %s (expected between 0 and 1)", path, e.Rate, e.Address)
+		}
+		key, err := newAddrKey(e.Address)
+		if err != nil {
+			return nil, fmt.Errorf("withholding %s: invalid address '%s': %w", path, e.Address, err)
+		}
+		if _, ok := w.rates[key]; ok {
+			return nil, fmt.Errorf("withholding %s: duplicate address '%s'", path, e.Address)
+		}
+		w.rates[key] = i
+	}
+	return &w, nil
+}
+
+func (w *listWithholding) jurisdiction() string  { return w.Jurisdiction }
+func (w *listWithholding) escrowAddress() string { return w.Escrow }
+
+func (w *listWithholding) withhold(req withholdingRequest) (withholdingDecision, error) {
+	key, err := newAddrKey(req.Address)
+	if err != nil {
+		return withholdingDecision{}, err
+	}
+	i, ok := w.rates[key]
+	if !ok {
+		return withholdingDecision{}, nil
+	}
+	e := w.Entries[i]
+	return withholdingDecision{
+		Amount: req.Amount.ToLegacyDec().Mul(e.Rate).TruncateInt(),
+		Reason: e.Reason,
+	}, nil
+}
+
+// withholdingLedgerOf returns the withholding ledger of airdrop.
+func withholdingLedgerOf(airdrop airdrop) withholdingLedger {
+	return withholdingLedger{
+		Jurisdiction:  airdrop.params.withholding.jurisdiction(),
+		EscrowAddress: airdrop.withholdingEscrow,
+		Total:         airdrop.totalWithheld(),
+		Entries:       airdrop.withholdings,
+	}
+}
+
+func reportWithholding(sec *reportSection, airdrop airdrop) {
+	sec.text("Withholding (%s): %d addresses, %s $ATONE withheld to %s",
+		airdrop.params.withholding.jurisdiction(), len(airdrop.withholdings),
+		humand(airdrop.totalWithheld().ToLegacyDec()), airdrop.withholdingEscrow)
+}
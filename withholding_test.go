@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestWithholding(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs    = createAccountAddrs(3)
+		accounts = []Account{
+			{
+				Address: addrs[0].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000 * M),
+				Vote: govtypes.NewNonSplitVoteOption(govtypes.OptionNo),
+			},
+			{Address: addrs[1].String(), LiquidAmount: sdk.NewDec(1000 * M), StakedAmount: sdk.ZeroDec()},
+		}
+		params = defaultDistriParams()
+		file   = filepath.Join(t.TempDir(), "withholding.json")
+	)
+	params.entityGroups = nil
+	ref, err := distribution(accounts, params, "atone")
+	require.NoError(err)
+	list := fmt.Sprintf(`{"jurisdiction":"XX","escrowAddress":"%s","entries":[{"address":"%s","rate":"0.3","reason":"corporate"}]}`,
+		addrs[2], addrs[0])
+	require.NoError(os.WriteFile(file, []byte(list), 0o600))
+	params.withholding, err = parseListWithholding(file)
+	require.NoError(err)
+
+	airdrop, err := distribution(accounts, params, "atone")
+
+	require.NoError(err)
+	var (
+		addr0  = sdk.MustBech32ifyAddressBytes("atone", addrs[0])
+		addr1  = sdk.MustBech32ifyAddressBytes("atone", addrs[1])
+		escrow = sdk.MustBech32ifyAddressBytes("atone", addrs[2])
+		gross  = ref.addresses[addr0]
+		held   = gross.ToLegacyDec().Mul(sdk.NewDecWithPrec(3, 1)).TruncateInt()
+	)
+	assert.Equal([]withholdingEntry{{
+		Address:      addr0,
+		Jurisdiction: "XX",
+		Reason:       "corporate",
+		Gross:        gross,
+		Withheld:     held,
+		Net:          gross.Sub(held),
+	}}, airdrop.withholdings)
+	assert.Equal(map[string]sdk.Int{
+		addr0:  gross.Sub(held),
+		addr1:  ref.addresses[addr1],
+		escrow: held,
+	}, airdrop.addresses)
+	assert.Equal(escrow, airdrop.withholdingEscrow)
+	// the distribution math is unchanged
+	assert.Equal(ref.atone.supply, airdrop.atone.supply)
+	assert.Equal(ref.totalSupply(), airdrop.totalSupply())
+	assert.Equal([]string{policyWithholding}, airdrop.audit[0].Policies)
+	ledger := withholdingLedgerOf(airdrop)
+	assert.Equal(held, ledger.Total)
+
+	for _, tt := range []struct {
+		list          string
+		expectedError string
+	}{
+		{list: `{"escrowAddress":"%[1]s"}`, expectedError: "missing jurisdiction"},
+		{list: `{"jurisdiction":"XX","escrowAddress":"x"}`, expectedError: "invalid escrow address"},
+		{list: `{"jurisdiction":"XX","escrowAddress":"%[1]s","entries":[{"address":"%[1]s","rate":"1.5"}]}`, expectedError: "invalid rate"},
+		{list: `{"jurisdiction":"XX","escrowAddress":"%[1]s","entries":[{"address":"%[1]s","rate":"0.1"},{"address":"%[1]s","rate":"0.2"}]}`, expectedError: "duplicate address"},
+	} {
+		require.NoError(os.WriteFile(file, []byte(fmt.Sprintf(tt.list, addrs[0])), 0o600))
+		_, err := parseListWithholding(file)
+		assert.ErrorContains(err, tt.expectedError)
+	}
+}
+
+func TestWithholdingStreamedEscrowRecipient(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		addrs    = createAccountAddrs(2)
+		accounts = []Account{
+			{
+				Address: addrs[0].String(), LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1000 * M),
+				Vote: govtypes.NewNonSplitVoteOption(govtypes.OptionNo),
+			},
+			// the escrow address is also a recipient
+			{Address: addrs[1].String(), LiquidAmount: sdk.NewDec(1000 * M), StakedAmount: sdk.ZeroDec()},
+		}
+		params = defaultDistriParams()
+		file   = filepath.Join(t.TempDir(), "withholding.json")
+		escrow = sdk.MustBech32ifyAddressBytes("atone", addrs[1])
+	)
+	params.entityGroups = nil
+	list := fmt.Sprintf(`{"jurisdiction":"XX","escrowAddress":"%s","entries":[{"address":"%s","rate":"0.3"}]}`,
+		addrs[1], addrs[0])
+	require.NoError(os.WriteFile(file, []byte(list), 0o600))
+	var err error
+	params.withholding, err = parseListWithholding(file)
+	require.NoError(err)
+	expected, err := distribution(accounts, params, "atone")
+	require.NoError(err)
+	var buf bytes.Buffer
+	stages := newDistributionStages(slices.Values(accounts))
+	stages.stream = ndjsonStream(&buf)
+
+	_, err = stages.run(context.Background(), params, "atone", checkpointConfig{})
+
+	require.NoError(err)
+	results := make(map[string]sdk.Int)
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r addressResult
+		require.NoError(dec.Decode(&r))
+		_, dup := results[r.Address]
+		assert.False(dup, "several records for %s", r.Address)
+		results[r.Address] = r.Amount
+	}
+	// a single record, summing the allocation and the withheld amounts
+	assert.Equal(expected.addresses, results)
+	assert.True(results[escrow].GT(expected.withholdings[0].Withheld))
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// xlsxTopHolders is the number of addresses of the top holders sheet.
+const xlsxTopHolders = 100
+
+// xlsxCell is a cell of a workbook sheet, either a string or a number.
+type xlsxCell struct {
+	value  string
+	number bool
+}
+
+func xlsxString(s string) xlsxCell {
+	return xlsxCell{value: s}
+}
+
+func xlsxInt(i int) xlsxCell {
+	return xlsxCell{value: fmt.Sprint(i), number: true}
+}
+
+func xlsxDec(d sdk.Dec) xlsxCell {
+	return xlsxCell{value: d.String(), number: true}
+}
+
+// xlsxAmount converts an amount of micro units into units.
+func xlsxAmount(d sdk.Dec) xlsxCell {
+	return xlsxDec(d.QuoInt64(M))
+}
+
+type xlsxSheet struct {
+	name string
+	rows [][]xlsxCell
+}
+
+func (s *xlsxSheet) append(cells ...xlsxCell) {
+	s.rows = append(s.rows, cells)
+}
+
+// writeXLSX writes sheets as an Office Open XML workbook (.xlsx), limited to
+// what is needed to be opened by spreadsheet softwares: inline strings and
+// numbers, without styles.
+func writeXLSX(w io.Writer, sheets []xlsxSheet) error {
+	z := zip.NewWriter(w)
+	var (
+		contentTypes strings.Builder
+		workbook     strings.Builder
+		workbookRels strings.Builder
+	)
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, s := range sheets {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(s.name), n, n)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+	}
+	for _, f := range files {
+		fw, err := z.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.content); err != nil {
+			return err
+		}
+	}
+	for i, s := range sheets {
+		fw, err := z.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err := writeXLSXSheet(fw, s); err != nil {
+			return fmt.Errorf("sheet %s: %w", s.name, err)
+		}
+	}
+	return z.Close()
+}
+
+func writeXLSXSheet(w io.Writer, s xlsxSheet) error {
+	if _, err := io.WriteString(w, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return err
+	}
+	for i, row := range s.rows {
+		var b strings.Builder
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for _, c := range row {
+			if c.number {
+				fmt.Fprintf(&b, `<c><v>%s</v></c>`, c.value)
+			} else {
+				fmt.Fprintf(&b, `<c t="inlineStr"><is><t>%s</t></is></c>`, xmlEscape(c.value))
+			}
+		}
+		b.WriteString(`</row>`)
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, `</sheetData></worksheet>`)
+	return err
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// airdropWorkbook returns the sheets of the spreadsheet version of airdrop,
+// for the review of the distribution by non-technical stakeholders:
+//   - Summary: the parameters and the supply breakdown;
+//   - Votes: the $ATOM and $ATONE amounts per vote;
+//   - Top holders: the xlsxTopHolders largest allocations;
+//   - Addresses: the detail per address, by decreasing allocation, limited to
+//     maxRows addresses.
+func airdropWorkbook(a airdrop, maxRows int) []xlsxSheet {
+	summary := xlsxSheet{name: "Summary"}
+	summary.append(xlsxString("Parameter"), xlsxString("Value"))
+	summary.append(xlsxString("Yes multiplier"), xlsxDec(a.params.yesVotesMultiplier))
+	summary.append(xlsxString("No multiplier"), xlsxDec(a.params.noVotesMultiplier))
+	summary.append(xlsxString("Bonus"), xlsxDec(a.params.bonus))
+	summary.append(xlsxString("Malus"), xlsxDec(a.params.malus))
+	summary.append(xlsxString("Supply factor"), xlsxDec(a.params.supplyFactor))
+	summary.append(xlsxString("Non-voters multiplier"), xlsxDec(a.nonVotersMultiplier))
+	summary.append(xlsxString("Addresses"), xlsxInt(len(a.addresses)))
+	summary.append(xlsxString("$ATOM supply"), xlsxAmount(a.atom.supply))
+	summary.append(xlsxString("$ATONE distributed"), xlsxAmount(a.atone.supply.Sub(a.rounding.communityPool)))
+	summary.append(xlsxString("$ATONE voter floor"), xlsxAmount(a.voterFloor.topUp.ToLegacyDec()))
+	summary.append(xlsxString("$ATONE community pool"), xlsxAmount(a.communityPool))
+	summary.append(xlsxString("$ATONE reserved address"), xlsxAmount(a.reservedAddr))
+	if a.params.hasStakingIncentives() {
+		summary.append(xlsxString("$ATONE staking incentives"), xlsxAmount(a.stakingIncentives))
+	}
+	summary.append(xlsxString("$ATONE total supply"), xlsxAmount(a.totalSupply()))
+
+	votes := xlsxSheet{name: "Votes"}
+	votes.append(xlsxString("Vote"), xlsxString("$ATOM"), xlsxString("$ATOM %"), xlsxString("$ATONE"), xlsxString("$ATONE %"))
+	var (
+		atomPercs  = a.atom.votePercentages()
+		atonePercs = a.atone.votePercentages()
+		hundred    = sdk.NewDec(100)
+	)
+	for _, opt := range []govtypes.VoteOption{
+		govtypes.OptionYes, govtypes.OptionNo, govtypes.OptionNoWithVeto,
+		govtypes.OptionAbstain, govtypes.OptionEmpty,
+	} {
+		votes.append(
+			xlsxString(voteOptionLabel(opt)),
+			xlsxAmount(a.atom.votes[opt]), xlsxDec(atomPercs[opt].Mul(hundred)),
+			xlsxAmount(a.atone.votes[opt]), xlsxDec(atonePercs[opt].Mul(hundred)),
+		)
+	}
+	votes.append(
+		xlsxString("Not staked"),
+		xlsxAmount(a.atom.unstaked), xlsxDec(a.atom.unstaked.Quo(a.atom.supply).Mul(hundred)),
+		xlsxAmount(a.atone.unstaked), xlsxDec(a.atone.unstaked.Quo(a.atone.supply).Mul(hundred)),
+	)
+
+	// Sort the addresses by decreasing amount, then by address for stable
+	// outputs
+	addrs := slices.SortedFunc(maps.Keys(a.addresses), func(x, y string) int {
+		switch amtX, amtY := a.addresses[x], a.addresses[y]; {
+		case amtX.GT(amtY):
+			return -1
+		case amtX.LT(amtY):
+			return 1
+		}
+		return cmp.Compare(x, y)
+	})
+	top := xlsxSheet{name: "Top holders"}
+	top.append(xlsxString("Rank"), xlsxString("Address"), xlsxString("$ATONE"), xlsxString("% of supply"))
+	totalSupply := a.totalSupply()
+	for i, addr := range addrs[:min(len(addrs), xlsxTopHolders)] {
+		amt := a.addresses[addr].ToLegacyDec()
+		top.append(xlsxInt(i+1), xlsxString(addr), xlsxAmount(amt), xlsxDec(amt.Quo(totalSupply).Mul(hundred)))
+	}
+
+	detailByAddr := make(map[string]addrAmtDetail, len(a.addressesDetail))
+	for _, d := range a.addressesDetail {
+		detailByAddr[d.Address] = d
+	}
+	detail := xlsxSheet{name: "Addresses"}
+	detail.append(
+		xlsxString("Address"), xlsxString("Yes"), xlsxString("No"), xlsxString("NWV"), xlsxString("Abstain"),
+		xlsxString("DNV"), xlsxString("Liquid"), xlsxString("Locked"), xlsxString("Type multiplier"), xlsxString("$ATONE"),
+	)
+	for _, addr := range addrs[:min(len(addrs), maxRows)] {
+		d, ok := detailByAddr[addr]
+		if !ok {
+			// no detail for addresses added by the distribution (voter floor...)
+			detail.append(xlsxString(addr), xlsxString(""), xlsxString(""), xlsxString(""), xlsxString(""),
+				xlsxString(""), xlsxString(""), xlsxString(""), xlsxString(""), xlsxAmount(a.addresses[addr].ToLegacyDec()))
+			continue
+		}
+		detail.append(
+			xlsxString(addr),
+			xlsxAmount(d.YesDetail.AtoneAmt), xlsxAmount(d.NoDetail.AtoneAmt), xlsxAmount(d.NWVDetail.AtoneAmt),
+			xlsxAmount(d.AbsDetail.AtoneAmt), xlsxAmount(d.DnvDetail.AtoneAmt), xlsxAmount(d.LiquidDetail.AtoneAmt),
+			xlsxAmount(d.LockedDetail.AtoneAmt), xlsxDec(d.TypeMultiplier), xlsxAmount(a.addresses[addr].ToLegacyDec()),
+		)
+	}
+	if len(addrs) > maxRows {
+		summary.append(xlsxString("Addresses sheet"),
+			xlsxString(fmt.Sprintf("limited to the %d largest allocations", maxRows)))
+	}
+	return []xlsxSheet{summary, votes, top, detail}
+}
+
+// writeAirdropWorkbook writes the spreadsheet version of airdrop in file.
+func writeAirdropWorkbook(ctx context.Context, file string, a airdrop, maxRows int) error {
+	return writeOutputFile(ctx, file, func(w io.Writer) error {
+		return writeXLSX(w, airdropWorkbook(a, maxRows))
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestAirdropWorkbook(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1_000 * M), Vote: vote(govtypes.OptionYes)},
+		{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(1_000 * M), Vote: vote(govtypes.OptionNo)},
+		{Address: "liquid<&>", LiquidAmount: sdk.NewDec(1_000 * M), StakedAmount: sdk.ZeroDec()},
+	}
+	a, err := distribution(accounts, defaultDistriParams(), "")
+	require.NoError(err)
+
+	sheets := airdropWorkbook(a, 2)
+
+	require.Len(sheets, 4)
+	assert.Equal([]string{"Summary", "Votes", "Top holders", "Addresses"},
+		[]string{sheets[0].name, sheets[1].name, sheets[2].name, sheets[3].name})
+	// Top holders are sorted by decreasing amount
+	top := sheets[2]
+	require.Len(top.rows, 4)
+	assert.Equal(xlsxString("no"), top.rows[1][1])
+	assert.Equal(xlsxAmount(a.addresses["no"].ToLegacyDec()), top.rows[1][2])
+	assert.Equal(xlsxString("liquid<&>"), top.rows[2][1])
+	assert.Equal(xlsxString("yes"), top.rows[3][1])
+	// Addresses are capped at 2 rows, plus the header
+	detail := sheets[3]
+	require.Len(detail.rows, 3)
+	assert.Equal(xlsxString("no"), detail.rows[1][0])
+	assert.Equal(xlsxAmount(a.addresses["no"].ToLegacyDec()), detail.rows[1][2])
+	assert.Equal(xlsxAmount(a.addresses["no"].ToLegacyDec()), detail.rows[1][9])
+	assert.Equal(xlsxString("limited to the 2 largest allocations"), sheets[0].rows[len(sheets[0].rows)-1][1])
+
+	var buf bytes.Buffer
+	err = writeXLSX(&buf, sheets)
+
+	require.NoError(err)
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(err)
+	files := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		require.NoError(err)
+		bz, err := io.ReadAll(r)
+		require.NoError(err)
+		files[f.Name] = string(bz)
+	}
+	assert.Len(files, 8)
+	assert.Contains(files["xl/workbook.xml"], `<sheet name="Top holders" sheetId="3" r:id="rId3"/>`)
+	assert.Contains(files["[Content_Types].xml"], `/xl/worksheets/sheet4.xml`)
+	assert.Contains(files["xl/worksheets/sheet3.xml"],
+		`<row r="3"><c><v>2</v></c><c t="inlineStr"><is><t>liquid&lt;&amp;&gt;</t></is></c>`)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+// yesRatioMaxIterations is the maximum number of nonVotersMultiplier solves
+// run to find the Yes multiplier of a target yesRatio.
+const yesRatioMaxIterations = 50
+
+// yesRatioTolerance is the maximum relative difference between the solved
+// ratio and the target yesRatio.
+var yesRatioTolerance = sdk.NewDecWithPrec(1, 9)
+
+// yesRatioMaxMultiplier bounds the solved Yes multiplier. The
+// nonVotersMultiplier grows with it, so the ratio has a limit which is
+// approached well below this bound.
+var yesRatioMaxMultiplier = sdk.NewDec(1_000_000)
+
+func (d distriParams) hasYesRatio() bool {
+	return !d.yesRatio.IsNil() && d.yesRatio.IsPositive()
+}
+
+// yesBucketMultiplier returns the multiplier of the bucket of the Yes votes,
+// which is solved for the yesRatio. The Yes votes of the neutral bucket get
+// the nonVotersMultiplier, so their ratio can't be solved.
+func (d *distriParams) yesBucketMultiplier() (*sdk.Dec, error) {
+	switch b := d.voteBucket(govtypes.OptionYes); b {
+	case voteBucketOpposed:
+		return &d.yesVotesMultiplier, nil
+	case voteBucketAligned:
+		return &d.noVotesMultiplier, nil
+	default:
+		return nil, fmt.Errorf("the Yes votes are in the %s bucket, their ratio to the non-voters can't be solved", b)
+	}
+}
+
+// perAtomYesRatio returns the ratio of the per-$ATOM allocation of the Yes
+// votes over the one of the staked non-voters, for nonVotersMultiplier. The
+// supplyFactor applies to both, so it doesn't change the ratio.
+func (d distriParams) perAtomYesRatio(nonVotersMultiplier sdk.Dec) sdk.Dec {
+	yesMultiplier, yesBonusMalus := d.voteMultiplier(govtypes.OptionYes, nonVotersMultiplier)
+	dnvMultiplier, dnvBonusMalus := d.voteMultiplier(govtypes.OptionEmpty, nonVotersMultiplier)
+	dnv := dnvMultiplier.Mul(dnvBonusMalus)
+	if !dnv.IsPositive() {
+		return sdk.ZeroDec()
+	}
+	return yesMultiplier.Mul(yesBonusMalus).Quo(dnv)
+}
+
+// runYesRatio runs the distribution with the multiplier of the Yes votes that
+// gives a per-$ATOM allocation of the Yes voters params.yesRatio times the
+// one of the non-voters. Since the nonVotersMultiplier is solved from the
+// vote multipliers, the ratio isn't linear with the Yes multiplier, which is
+// solved with the secant method over the solve stage only, starting from its
+// value in params.
+func (s *distributionStages) runYesRatio(ctx context.Context, params distriParams, prefix string, cp checkpointConfig) (airdrop, error) {
+	var (
+		target = params.yesRatio
+		p      = params
+		// previous Yes multiplier and its ratio, for the secant
+		prevMultiplier, prevRatio sdk.Dec
+	)
+	p.yesRatio = sdk.Dec{}
+	m, err := p.yesBucketMultiplier()
+	if err != nil {
+		return airdrop{}, err
+	}
+	if !m.IsPositive() {
+		return airdrop{}, errors.New("yes ratio: the Yes multiplier must be positive to be solved")
+	}
+	atom, err := s.aggregate(ctx)
+	if err != nil {
+		return airdrop{}, err
+	}
+	ratioAt := func(multiplier sdk.Dec) (sdk.Dec, error) {
+		*m = multiplier
+		solved, err := s.solve(ctx, atom, p)
+		if err != nil {
+			return sdk.Dec{}, err
+		}
+		return p.perAtomYesRatio(solved.nonVotersMultiplier), nil
+	}
+	start := *m
+	maxRatio, err := ratioAt(yesRatioMaxMultiplier)
+	if err != nil {
+		return airdrop{}, err
+	}
+	if target.GT(maxRatio) {
+		return airdrop{}, fmt.Errorf("yes ratio %s out of reach, the nonVotersMultiplier grows with the Yes multiplier up to a ratio of %s", target, maxRatio)
+	}
+	*m = start
+	for i := 0; i < yesRatioMaxIterations; i++ {
+		ratio, err := ratioAt(*m)
+		if err != nil {
+			return airdrop{}, err
+		}
+		if ratio.Sub(target).Abs().LTE(target.Mul(yesRatioTolerance)) {
+			a, err := s.run(ctx, p, prefix, cp)
+			if err != nil {
+				return a, err
+			}
+			a.params.yesRatio = params.yesRatio
+			return a, nil
+		}
+		if !ratio.IsPositive() {
+			return airdrop{}, fmt.Errorf("yes ratio: no ratio with Yes multiplier %s", *m)
+		}
+		next := m.Mul(target).Quo(ratio)
+		if !prevMultiplier.IsNil() && !ratio.Equal(prevRatio) {
+			next = m.Add(target.Sub(ratio).Mul(m.Sub(prevMultiplier)).Quo(ratio.Sub(prevRatio)))
+		}
+		if !next.IsPositive() {
+			next = m.QuoInt64(2)
+		}
+		next = sdk.MinDec(next, yesRatioMaxMultiplier)
+		prevMultiplier, prevRatio = *m, ratio
+		*m = next
+	}
+	return airdrop{}, fmt.Errorf("yes ratio %s not reached after %d iterations, last Yes multiplier %s gave %s",
+		target, yesRatioMaxIterations, prevMultiplier, prevRatio)
+}
+
+// printYesRatio prints in w the Yes multiplier solved for the yesRatio of
+// airdrop.
+func printYesRatio(w io.Writer, airdrop airdrop) {
+	if text := yesRatioText(airdrop); text != "" {
+		fmt.Fprintln(w, text)
+	}
+}
+
+// yesRatioText describes the Yes multiplier solved for the yesRatio of
+// airdrop, it's empty if the ratio can't be solved.
+func yesRatioText(airdrop airdrop) string {
+	p := airdrop.params
+	m, err := p.yesBucketMultiplier()
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("Yes multiplier %s solved for a Yes/non-voter per-$ATOM ratio of %s (nonVotersMultiplier %s)",
+		m, p.yesRatio, airdrop.nonVotersMultiplier)
+}
+
+// parseYesRatio parses the yesRatio flag, zero disables it.
+func parseYesRatio(s string) (sdk.Dec, error) {
+	r, err := sdk.NewDecFromStr(s)
+	if err != nil || r.IsNegative() {
+		return sdk.Dec{}, fmt.Errorf("invalid yesRatio '%s'", s)
+	}
+	return r, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+)
+
+func TestRunYesRatio(t *testing.T) {
+	vote := func(opt govtypes.VoteOption) govtypes.WeightedVoteOptions {
+		return govtypes.WeightedVoteOptions{{Option: opt, Weight: sdk.NewDec(1)}}
+	}
+	accounts := []Account{
+		{Address: "yes", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100 * M), Vote: vote(govtypes.OptionYes)},
+		{Address: "no", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100 * M), Vote: vote(govtypes.OptionNo)},
+		{
+			Address: "dnv", LiquidAmount: sdk.ZeroDec(), StakedAmount: sdk.NewDec(100 * M),
+			Delegations: []Delegation{{ValidatorAddress: "val", Amount: sdk.NewDec(100 * M)}},
+		},
+		{Address: "liquid", LiquidAmount: sdk.NewDec(100 * M), StakedAmount: sdk.ZeroDec()},
+	}
+	tests := []struct {
+		name          string
+		params        func(*distriParams)
+		yesRatio      sdk.Dec
+		expectedError string
+	}{
+		{
+			name:     "penalized Yes",
+			params:   func(*distriParams) {},
+			yesRatio: sdk.NewDecWithPrec(5, 1),
+		},
+		{
+			name:     "rewarded Yes",
+			params:   func(*distriParams) {},
+			yesRatio: sdk.NewDec(2),
+		},
+		{
+			name: "address cap",
+			params: func(p *distriParams) {
+				p.addressCap = sdk.NewInt(50 * M)
+			},
+			yesRatio: sdk.NewDecWithPrec(5, 1),
+		},
+		{
+			name: "Yes aligned",
+			params: func(p *distriParams) {
+				p.voteBuckets = voteBuckets{
+					govtypes.OptionYes:        voteBucketAligned,
+					govtypes.OptionNo:         voteBucketOpposed,
+					govtypes.OptionNoWithVeto: voteBucketOpposed,
+					govtypes.OptionAbstain:    voteBucketNeutral,
+					govtypes.OptionEmpty:      voteBucketNeutral,
+				}
+			},
+			yesRatio: sdk.NewDec(3),
+		},
+		{
+			name: "Yes neutral",
+			params: func(p *distriParams) {
+				p.voteBuckets = voteBuckets{
+					govtypes.OptionYes:        voteBucketNeutral,
+					govtypes.OptionNo:         voteBucketAligned,
+					govtypes.OptionNoWithVeto: voteBucketAligned,
+					govtypes.OptionAbstain:    voteBucketNeutral,
+					govtypes.OptionEmpty:      voteBucketNeutral,
+				}
+			},
+			yesRatio:      sdk.NewDec(2),
+			expectedError: "the Yes votes are in the neutral bucket, their ratio to the non-voters can't be solved",
+		},
+		{
+			// the Yes votes can't outweigh the non-voters that much while the
+			// non-voters keep their share
+			name:          "out of reach",
+			params:        func(*distriParams) {},
+			yesRatio:      sdk.NewDec(1000),
+			expectedError: "yes ratio 1000.000000000000000000 out of reach",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			params := defaultDistriParams()
+			tt.params(&params)
+			params.yesRatio = tt.yesRatio
+
+			airdrop, err := newDistributionStages(slices.Values(accounts)).run(context.Background(), params, "", checkpointConfig{})
+
+			if tt.expectedError != "" {
+				require.ErrorContains(err, tt.expectedError)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tt.yesRatio, airdrop.params.yesRatio)
+			// per-$ATOM allocations of the Yes voter and the staked non-voter
+			total := make(map[string]sdk.Dec)
+			for _, d := range airdrop.addressesDetail {
+				total[d.Address] = d.Total
+			}
+			ratio := total["yes"].Quo(total["dnv"])
+			assert.InDelta(tt.yesRatio.MustFloat64(), ratio.MustFloat64(), 1e-6)
+			// same ratio without the target
+			params.yesRatio = sdk.Dec{}
+			params.yesVotesMultiplier = airdrop.params.yesVotesMultiplier
+			params.noVotesMultiplier = airdrop.params.noVotesMultiplier
+			again, err := distribution(accounts, params, "")
+			require.NoError(err)
+			assert.Equal(airdrop.addressesDetail, again.addressesDetail)
+		})
+	}
+}